@@ -0,0 +1,67 @@
+package function
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// ErrArchiveNotFunction indicates a source archive has no config file in its
+// root, and so is not that of a Function.
+var ErrArchiveNotFunction = errors.New("source archive has no " + ConfigFile + " in its root")
+
+// NewFunctionFromArchive loads the Function whose source is the gzipped
+// tarball at path, such as one packaged by CI, from the func.yaml in its
+// root.  The Function has no Root, its source not being on local disk.
+// Without a func.yaml, or with one lacking the name or runtime, an error
+// wrapping ErrArchiveNotFunction is returned.
+func NewFunctionFromArchive(path string) (f Function, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	bb, err := readArchiveFile(file, ConfigFile)
+	if err != nil {
+		return f, fmt.Errorf("invalid source archive '%v': %w", path, err)
+	}
+	c, err := parseConfig(ConfigFile, bb)
+	if err != nil {
+		return
+	}
+	if c.Name == "" || c.Runtime == "" {
+		return f, fmt.Errorf("%w: the %v of '%v' does not declare both a name and runtime", ErrArchiveNotFunction, ConfigFile, path)
+	}
+	return fromConfig(c), nil
+}
+
+// readArchiveFile returns the contents of the named file in the root of the
+// gzipped tarball read from r.
+func readArchiveFile(r io.Reader, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, ErrArchiveNotFunction
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Entries may be prefixed with "./", as when archived with tar -C.
+		if path.Clean(header.Name) == name && header.FileInfo().Mode().IsRegular() {
+			return ioutil.ReadAll(tr)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// +build !integration
+
+package function
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestNewFunctionFromArchive ensures the Function is loaded from the
+// func.yaml in the root of a source archive, and that an archive without one
+// is rejected.
+func TestNewFunctionFromArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "func-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive := filepath.Join(dir, "source.tar.gz")
+	writeArchive(t, archive, map[string]string{
+		"./func.yaml": "name: myfunc\nruntime: go\nregistry: quay.io/alice\n",
+		"./handle.go": "package function\n",
+	})
+	f, err := NewFunctionFromArchive(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "myfunc" || f.Runtime != "go" || f.Registry != "quay.io/alice" {
+		t.Fatalf("unexpected function loaded from the archive: %+v", f)
+	}
+
+	writeArchive(t, archive, map[string]string{"handle.go": "package function\n"})
+	if _, err = NewFunctionFromArchive(archive); !errors.Is(err, ErrArchiveNotFunction) {
+		t.Fatalf("expected ErrArchiveNotFunction without a func.yaml, got %v", err)
+	}
+
+	writeArchive(t, archive, map[string]string{"func.yaml": "name: myfunc\n"})
+	if _, err = NewFunctionFromArchive(archive); !errors.Is(err, ErrArchiveNotFunction) {
+		t.Fatalf("expected ErrArchiveNotFunction without a runtime, got %v", err)
+	}
+}
+
+// writeArchive writes a gzipped tarball of the given files at path.
+func writeArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err = tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,12 +3,15 @@ package buildpacks
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
+	"regexp"
 	"runtime"
 	"strings"
 	"time"
@@ -22,9 +25,13 @@ import (
 	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/api/types/swarm"
 	"github.com/docker/docker/api/types/volume"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/config"
 	"github.com/buildpacks/pack/logging"
 
 	dockerClient "github.com/docker/docker/client"
@@ -36,6 +43,57 @@ import (
 //Buildpack builder
 type Builder struct {
 	Verbose bool
+	// Progress, if set, is called with the name of each phase of the
+	// buildpacks lifecycle as it begins, such as "detecting".  This allows
+	// for a concise summary of the build when its logs are not shown.
+	Progress func(phase string)
+	// PhaseDone, if set, is called with the timing of each phase of the
+	// lifecycle as it ends, including those of the buildpacks which ran in
+	// the building phase.  The last phase ends when the build succeeds.
+	PhaseDone func(PhaseTiming)
+}
+
+// PhaseTiming is the duration of a phase of the buildpacks lifecycle.
+type PhaseTiming struct {
+	Phase    string
+	Duration time.Duration
+	// Buildpacks which ran in the phase, in order, if known.  Only those of
+	// the building phase are known: from the first line each logs, which by
+	// convention is its unindented name and version.
+	Buildpacks []BuildpackTiming
+}
+
+// BuildpackTiming is the duration of a buildpack in the building phase.
+type BuildpackTiming struct {
+	Buildpack string
+	Duration  time.Duration
+}
+
+// ErrBuildFailed is returned when the build of a Function fails, identifying
+// the phase of the buildpacks lifecycle in which it failed, if known.
+type ErrBuildFailed struct {
+	// Phase of the lifecycle under way, such as "building".  Empty if the
+	// build failed before the lifecycle began, such as pulling the builder.
+	Phase string
+	// Err with which the build failed.
+	Err error
+	// Output of the build when not verbose, which would otherwise be lost.
+	Output string
+}
+
+func (e ErrBuildFailed) Error() string {
+	msg := fmt.Sprintf("failed to build the function: %v", e.Err)
+	if e.Phase != "" {
+		msg = fmt.Sprintf("failed to build the function in the %v phase: %v", e.Phase, e.Err)
+	}
+	if e.Output != "" {
+		msg = fmt.Sprintf("%v\noutput: %s\n", msg, e.Output)
+	}
+	return msg
+}
+
+func (e ErrBuildFailed) Unwrap() error {
+	return e.Err
 }
 
 //NewBuilder builds the new Builder configuration
@@ -55,23 +113,295 @@ var RuntimeToBuildpack = map[string]string{
 	"rust":       "quay.io/boson/faas-rust-builder",
 }
 
-// Build the Function at path.
-func (builder *Builder) Build(ctx context.Context, f fn.Function) (err error) {
+// RuntimeAliases maps common alternative spellings of runtimes to the
+// canonical runtime names of RuntimeToBuildpack.
+var RuntimeAliases = map[string]string{
+	"golang":      "go",
+	"javascript":  "node",
+	"js":          "node",
+	"nodejs":      "node",
+	"py":          "python",
+	"rs":          "rust",
+	"spring":      "springboot",
+	"spring-boot": "springboot",
+	"ts":          "typescript",
+}
+
+// RuntimeVersionEnvs maps runtimes to the build environment variable with
+// which their buildpack selects the version of the runtime built, to which a
+// Function's RuntimeVersion is passed.  Versions of other runtimes can not be
+// selected.
+var RuntimeVersionEnvs = map[string]string{
+	"go":         "BP_GO_VERSION",
+	"node":       "BP_NODE_VERSION",
+	"python":     "BP_CPYTHON_VERSION",
+	"quarkus":    "BP_JVM_VERSION",
+	"springboot": "BP_JVM_VERSION",
+	"typescript": "BP_NODE_VERSION",
+}
+
+// RuntimeVersions lists the versions of runtimes known to be supported by
+// their buildpacks, as are suggested to users.  Others may also be.
+var RuntimeVersions = map[string][]string{
+	"go":         {"1.17", "1.18", "1.19"},
+	"node":       {"14", "16", "18"},
+	"python":     {"3.8", "3.9", "3.10"},
+	"quarkus":    {"11", "17"},
+	"springboot": {"11", "17"},
+	"typescript": {"14", "16", "18"},
+}
+
+// ValidateRuntimeVersion ensures that the version, if any, of the runtime is
+// one which can be selected: that the runtime's buildpack selects versions,
+// and that the version is a version number.
+func ValidateRuntimeVersion(runtime, version string) error {
+	if version == "" {
+		return nil
+	}
+	if _, ok := RuntimeVersionEnvs[runtime]; !ok {
+		return fmt.Errorf("the version of runtime '%v' can not be selected", runtime)
+	}
+	return fn.ValidateRuntimeVersion(version)
+}
 
-	// Use the builder found in the Function configuration file
-	// If one isn't found, use the defaults
-	var packBuilder string
-	if f.Builder != "" {
-		packBuilder = f.Builder
-		pb, ok := f.BuilderMap[packBuilder]
-		if ok {
-			packBuilder = pb
+// withRuntimeVersion adds the Function's runtime version, if any, to the
+// build environment in the variable of its runtime's buildpack, unless set
+// by the Function's build envs, which take precedence.
+func withRuntimeVersion(envs map[string]string, f fn.Function) error {
+	if f.RuntimeVersion == "" {
+		return nil
+	}
+	if err := ValidateRuntimeVersion(f.Runtime, f.RuntimeVersion); err != nil {
+		return err
+	}
+	if env := RuntimeVersionEnvs[f.Runtime]; envs[env] == "" {
+		envs[env] = f.RuntimeVersion
+	}
+	return nil
+}
+
+// withLifecycleImage sets the lifecycle image of the Function, if any, as
+// that with which it is built, pulled from its registry mirror if any.  Pack
+// uses a lifecycle image only for the builders it does not trust, which
+// otherwise run their own lifecycle, so the builder is not trusted when one
+// is given.
+func withLifecycleImage(opts *pack.BuildOptions, f fn.Function) {
+	if f.LifecycleImage == "" {
+		return
+	}
+	opts.LifecycleImage, _ = fn.MirrorImage(f.LifecycleImage, f.RegistryMirrors)
+	opts.TrustBuilder = false
+}
+
+// withRunImageMirror sets the mirror of the run image of the stack of the
+// builder, by the registry mirrors of the Function, as an additional mirror
+// of the run image, which pack then selects, such that the run image is
+// pulled from its mirror as the builder is.
+func withRunImageMirror(client *pack.Client, opts *pack.BuildOptions, f fn.Function) error {
+	if len(f.RegistryMirrors) == 0 {
+		return nil
+	}
+	info, err := client.InspectBuilder(opts.Builder, true)
+	if err == nil && info == nil && opts.PullPolicy != config.PullNever {
+		info, err = client.InspectBuilder(opts.Builder, false)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to inspect the run image of builder '%v': %v", opts.Builder, err)
+	}
+	if info != nil {
+		opts.AdditionalMirrors = runImageMirrors(info.RunImage, f.RegistryMirrors)
+	}
+	return nil
+}
+
+// runImageMirrors returns the additional mirrors of the run image, keyed by
+// the run image as pack expects: its registry mirror, if any.
+func runImageMirrors(runImage string, mirrors map[string]string) map[string][]string {
+	mirrored, ok := fn.MirrorImage(runImage, mirrors)
+	if !ok {
+		return nil
+	}
+	return map[string][]string{runImage: {mirrored}}
+}
+
+// withBuildpacks adds the buildpacks of the Function's build, if any, to the
+// build options, run after the default group of the builder, from which pack
+// otherwise only runs those given.
+func withBuildpacks(opts *pack.BuildOptions, f fn.Function) {
+	if len(f.Build.Buildpacks) == 0 {
+		return
+	}
+	opts.Buildpacks = append([]string{"from=builder"}, f.Build.Buildpacks...)
+}
+
+// checkLifecycle ensures the lifecycle of the builder supports the platform
+// API requested, if any, and reports the lifecycle with which the build is
+// run to w when verbose.  Pack negotiates the platform API as the latest it
+// supports of those of the lifecycle, such that the platform API can only be
+// checked, rather than selected, when building with pack.  The builder is
+// inspected as present locally, or otherwise in its registry.
+func checkLifecycle(client *pack.Client, opts pack.BuildOptions, platformAPI string, w io.Writer, verbose bool) error {
+	info, err := client.InspectBuilder(opts.Builder, true)
+	if err == nil && info == nil && opts.PullPolicy != config.PullNever {
+		info, err = client.InspectBuilder(opts.Builder, false)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to inspect the lifecycle of builder '%v': %v", opts.Builder, err)
+	}
+	if info == nil {
+		return fmt.Errorf("failed to inspect the lifecycle of builder '%v': the image was not found", opts.Builder)
+	}
+	apis := info.Lifecycle.APIs.Platform
+	supported := append(apis.Deprecated.AsStrings(), apis.Supported.AsStrings()...)
+	if err = supportsPlatformAPI(opts.Builder, supported, platformAPI); err != nil {
+		return err
+	}
+	if verbose {
+		var version string
+		if info.Lifecycle.Info.Version != nil {
+			version = info.Lifecycle.Info.Version.String()
 		}
-	} else {
-		packBuilder = RuntimeToBuildpack[f.Runtime]
-		if packBuilder == "" {
-			return errors.New(fmt.Sprint("unsupported runtime: ", f.Runtime))
+		fmt.Fprintf(w, "Lifecycle: %v (platform API %v)\n", lifecycleOf(opts, version), strings.Join(supported, ", "))
+	}
+	return nil
+}
+
+// supportsPlatformAPI returns an error if the platform API, if any, is not
+// among those supported by the lifecycle of the builder.
+func supportsPlatformAPI(builder string, supported []string, platformAPI string) error {
+	if platformAPI == "" {
+		return nil
+	}
+	for _, api := range supported {
+		if api == platformAPI {
+			return nil
+		}
+	}
+	return fmt.Errorf("the lifecycle of builder '%v' does not support platform API %v: it supports %v", builder, platformAPI, strings.Join(supported, ", "))
+}
+
+// lifecycleOf the build with the given options, the lifecycle of the builder
+// being of the given version: the lifecycle image given, or that of the
+// builder when trusted, or otherwise the lifecycle image of its version.
+func lifecycleOf(opts pack.BuildOptions, version string) string {
+	switch {
+	case opts.LifecycleImage != "":
+		return opts.LifecycleImage
+	case opts.TrustBuilder:
+		return fmt.Sprintf("%v of builder '%v'", version, opts.Builder)
+	}
+	return "buildpacksio/lifecycle:" + version
+}
+
+// BuilderImage returns the builder image of the Function: that found in its
+// configuration, possibly by name in its BuilderMap, or otherwise the default
+// of its runtime.
+func BuilderImage(f fn.Function) (string, error) {
+	if f.Builder != "" {
+		if image, ok := f.BuilderMap[f.Builder]; ok {
+			return image, nil
 		}
+		return f.Builder, nil
+	}
+	if image := RuntimeToBuildpack[f.Runtime]; image != "" {
+		return image, nil
+	}
+	return "", errors.New(fmt.Sprint("unsupported runtime: ", f.Runtime))
+}
+
+// Pinned returns the reference to the image by the given digest, such as
+// quay.io/boson/faas-go-builder@sha256:a278a9..., in place of its tag.  The
+// image is returned as is without a digest.
+func Pinned(image, digest string) (string, error) {
+	if digest == "" {
+		return image, nil
+	}
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	return ref.Context().Name() + "@" + digest, nil
+}
+
+// ResolveBuilder returns the digest of the builder image of the Function,
+// by its tag, in its registry, or that of its mirror if any.  Credentials are
+// those of the docker config, anonymous access being used for registries
+// without any.
+func (builder *Builder) ResolveBuilder(ctx context.Context, f fn.Function) (string, error) {
+	image, err := BuilderImage(f)
+	if err != nil {
+		return "", err
+	}
+	image, _ = fn.MirrorImage(image, f.RegistryMirrors)
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	desc, err := remote.Head(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return "", err
+	}
+	return desc.Digest.String(), nil
+}
+
+// PullPolicy returns the policy of pack with which the builder image of the
+// Function is pulled: that of its BuilderPullPolicy, or otherwise
+// fn.DefaultBuilderPullPolicy.
+func PullPolicy(f fn.Function) (config.PullPolicy, error) {
+	policy := f.BuilderPullPolicy
+	if policy == "" {
+		policy = fn.DefaultBuilderPullPolicy
+	}
+	if err := fn.ValidateBuilderPullPolicy(policy); err != nil {
+		return config.PullAlways, err
+	}
+	return config.ParsePullPolicy(policy)
+}
+
+// CacheMountPath is the path in the build containers at which the build
+// cache directory is mounted.  This is the default cache location of the
+// build user, and is used by language toolchains for downloaded dependencies
+// and compilation results (for example GOCACHE and pip).
+const CacheMountPath = "/home/cnb/.cache"
+
+// Build the Function at path using the default build cache.
+func (builder *Builder) Build(ctx context.Context, f fn.Function) (err error) {
+	return builder.BuildWithCache(ctx, f, fn.BuildCache{})
+}
+
+// BuildWithCache builds the Function at path.  Layers cached by the buildpacks
+// are always reused unless the cache is disabled, in which case they are
+// cleared.  A cache directory, if provided, is additionally mounted into the
+// build containers such that its contents survive across builds.
+func (builder *Builder) BuildWithCache(ctx context.Context, f fn.Function, cache fn.BuildCache) error {
+	return builder.BuildWithLabels(ctx, f, cache, nil)
+}
+
+// labelEnvs are the build environment variables with which the Paketo image
+// labels buildpack labels the image with each of the OCI labels.
+var labelEnvs = map[string]string{
+	fn.SourceLabel:   "BP_OCI_SOURCE",
+	fn.RevisionLabel: "BP_OCI_REVISION",
+	fn.CreatedLabel:  "BP_OCI_CREATED",
+}
+
+// BuildWithLabels builds the Function as does BuildWithCache, labeling the
+// image with the given OCI labels.  Buildpacks, rather than pack, label the
+// image: the labels are provided to the build in the environment variables
+// of the Paketo image labels buildpack, such that they are applied by
+// builders which include it.  The Function's build envs take precedence.
+func (builder *Builder) BuildWithLabels(ctx context.Context, f fn.Function, cache fn.BuildCache, labels map[string]string) (err error) {
+
+	// Use the builder found in the Function configuration file, pulled from
+	// its registry mirror, if any, and pinned to its digest if resolved.
+	packBuilder, err := BuilderImage(f)
+	if err != nil {
+		return
+	}
+	trusted := strings.HasPrefix(packBuilder, "quay.io/boson")
+	packBuilder, _ = fn.MirrorImage(packBuilder, f.RegistryMirrors)
+	if packBuilder, err = Pinned(packBuilder, f.BuilderDigest); err != nil {
+		return
 	}
 
 	// Build options for the pack client.
@@ -84,13 +414,38 @@ func (builder *Builder) Build(ctx context.Context, f fn.Function) (err error) {
 		AppPath:      f.Root,
 		Image:        f.Image,
 		Builder:      packBuilder,
-		TrustBuilder: strings.HasPrefix(packBuilder, "quay.io/boson"),
+		TrustBuilder: trusted,
 		DockerHost:   os.Getenv("DOCKER_HOST"),
 		ContainerConfig: struct {
 			Network string
 			Volumes []string
 		}{Network: network, Volumes: nil},
 	}
+	withLifecycleImage(&packOpts, f)
+	withBuildpacks(&packOpts, f)
+	if packOpts.ClearCache, packOpts.ContainerConfig.Volumes, err = cacheOptions(cache); err != nil {
+		return
+	}
+	if packOpts.Env, err = BuildEnvs(f.BuildEnvs); err != nil {
+		return
+	}
+	if err = withRuntimeVersion(packOpts.Env, f); err != nil {
+		return
+	}
+	for label, value := range labels {
+		if env, ok := labelEnvs[label]; ok {
+			if _, ok = packOpts.Env[env]; !ok {
+				packOpts.Env[env] = value
+			}
+		}
+	}
+	// Files matched by the Function's ignore patterns, its .git directory and
+	// its RunDataDir are not copied into the build containers.
+	patterns, err := f.IgnorePatterns()
+	if err != nil {
+		return
+	}
+	packOpts.ProjectDescriptor.Build.Exclude = append(append([]string{}, patterns...), "/.git/", "/"+fn.RunDataDir+"/")
 
 	// log output is either STDOUt or kept in a buffer to be printed on error.
 	var logWriter io.Writer
@@ -111,26 +466,264 @@ func (builder *Builder) Build(ctx context.Context, f fn.Function) (err error) {
 		return err
 	}
 
+	// The builder image is re-pulled according to the Function's policy.
+	// Images of the requested platform are pulled ahead of the build, such
+	// that pack uses them rather than pulling those of the host's platform.
+	if packOpts.PullPolicy, err = PullPolicy(f); err != nil {
+		return
+	}
+	if f.Platform != "" && packOpts.PullPolicy != config.PullNever {
+		if err = pullForPlatform(ctx, dockerClient, packBuilder, f.Platform); err != nil {
+			return
+		}
+		packOpts.PullPolicy = config.PullIfNotPresent
+	}
+
+	// The phases of the lifecycle are tracked from the logs.
+	phases := &phaseWriter{out: logWriter, onPhase: builder.Progress, onDone: builder.PhaseDone}
+
 	dockerClientWrapper := &clientWrapper{dockerClient}
-	packClient, err := pack.NewClient(pack.WithLogger(logging.New(logWriter)), pack.WithDockerClient(dockerClientWrapper))
+	packClient, err := pack.NewClient(pack.WithLogger(logging.New(phases)), pack.WithDockerClient(dockerClientWrapper))
 	if err != nil {
 		return
 	}
 
+	// The run image of the stack of the builder is pulled from its registry
+	// mirror, if any.
+	if err = withRunImageMirror(packClient, &packOpts, f); err != nil {
+		return
+	}
+
+	// The lifecycle of the builder is inspected only to check the platform
+	// API requested and to report it.
+	if f.PlatformAPI != "" || builder.Verbose {
+		if err = checkLifecycle(packClient, packOpts, f.PlatformAPI, logWriter, builder.Verbose); err != nil {
+			return
+		}
+	}
+
 	// Build based using the given builder.
 	if err = packClient.Build(ctx, packOpts); err != nil {
 		if ctx.Err() != nil {
-			// received SIGINT
-			return
-		} else if !builder.Verbose {
+			// received SIGINT, or the build timed out
+			return ctx.Err()
+		}
+		failed := ErrBuildFailed{Phase: phases.phase, Err: err}
+		if !builder.Verbose {
 			// If the builder was not showing logs, embed the full logs in the error.
-			err = fmt.Errorf("%v\noutput: %s\n", err, logWriter.(*bytes.Buffer).String())
+			failed.Output = logWriter.(*bytes.Buffer).String()
 		}
+		err = failed
+		return
 	}
+	phases.end()
 
 	return
 }
 
+// phaseRegex matches the line logged by the lifecycle as it begins a phase,
+// such as "===> DETECTING", possibly prefixed by a timestamp.
+var phaseRegex = regexp.MustCompile(`===> ([A-Z]+)\s*$`)
+
+// phaseWriter forwards the logs of a build to out, tracking the phase of the
+// lifecycle under way from the lines it logs as each begins, and timing each
+// phase, and each buildpack of the building phase, as it ends.
+type phaseWriter struct {
+	out     io.Writer
+	onPhase func(phase string)
+	onDone  func(PhaseTiming)
+	now     func() time.Time // defaults to time.Now
+	phase   string
+	partial []byte // last line written, if not yet terminated
+
+	timing     bool      // whether the phase under way has yet to end
+	started    time.Time // of the phase under way
+	buildpacks []BuildpackTiming
+	bpStarted  time.Time // of the last of buildpacks
+}
+
+// lifecyclePrefix of the lines logged by each phase of the lifecycle when its
+// phases run in separate containers, such as "[builder] ".
+var lifecyclePrefix = regexp.MustCompile(`^\[[a-z]+\] `)
+
+func (w *phaseWriter) Write(p []byte) (n int, err error) {
+	if n, err = w.out.Write(p); err != nil {
+		return
+	}
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimRight(string(w.partial[:i]), "\r")
+		w.partial = w.partial[i+1:]
+		if match := phaseRegex.FindStringSubmatch(line); match != nil {
+			w.end()
+			w.phase = strings.ToLower(match[1])
+			w.started, w.timing = w.time(), true
+			if w.onPhase != nil {
+				w.onPhase(w.phase)
+			}
+			continue
+		}
+		if w.phase == "building" {
+			w.buildpack(lifecyclePrefix.ReplaceAllString(line, ""))
+		}
+	}
+	return
+}
+
+// buildpack begins the timing of a buildpack of the building phase if the
+// line is the first it logs: unindented, such as "Paketo Go Build 0.3.2".
+func (w *phaseWriter) buildpack(line string) {
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+		return
+	}
+	now := w.time()
+	w.endBuildpack(now)
+	w.buildpacks = append(w.buildpacks, BuildpackTiming{Buildpack: strings.TrimSpace(line)})
+	w.bpStarted = now
+}
+
+func (w *phaseWriter) endBuildpack(now time.Time) {
+	if len(w.buildpacks) > 0 {
+		w.buildpacks[len(w.buildpacks)-1].Duration = now.Sub(w.bpStarted)
+	}
+}
+
+// end the phase under way, if any, reporting its timing.
+func (w *phaseWriter) end() {
+	if !w.timing {
+		return
+	}
+	now := w.time()
+	w.endBuildpack(now)
+	timing := PhaseTiming{Phase: w.phase, Duration: now.Sub(w.started), Buildpacks: w.buildpacks}
+	w.timing, w.buildpacks = false, nil
+	if w.onDone != nil {
+		w.onDone(timing)
+	}
+}
+
+func (w *phaseWriter) time() time.Time {
+	if w.now != nil {
+		return w.now()
+	}
+	return time.Now()
+}
+
+// builderMetadataLabel is the label of a builder image describing, among
+// others, its stack.
+const builderMetadataLabel = "io.buildpacks.builder.metadata"
+
+// pullForPlatform pulls the builder image, and the run image of its stack, of
+// the given platform, ensuring that both support it.
+func pullForPlatform(ctx context.Context, cli dockerClient.CommonAPIClient, builderImage, platform string) error {
+	inspect, err := pullImage(ctx, cli, builderImage, platform)
+	if err != nil {
+		return err
+	}
+	if err = supportsPlatform(builderImage, inspect, platform); err != nil {
+		return err
+	}
+	runImage, err := stackRunImage(inspect)
+	if err != nil || runImage == "" {
+		return err
+	}
+	if inspect, err = pullImage(ctx, cli, runImage, platform); err != nil {
+		return err
+	}
+	return supportsPlatform(runImage, inspect, platform)
+}
+
+// pullImage of the given platform, returning its inspection.
+func pullImage(ctx context.Context, cli dockerClient.CommonAPIClient, image, platform string) (types.ImageInspect, error) {
+	rc, err := cli.ImagePull(ctx, image, types.ImagePullOptions{Platform: platform})
+	if err != nil {
+		return types.ImageInspect{}, fmt.Errorf("failed to pull image '%v' for platform '%v': %v", image, platform, err)
+	}
+	defer rc.Close()
+	if _, err = io.Copy(ioutil.Discard, rc); err != nil {
+		return types.ImageInspect{}, fmt.Errorf("failed to pull image '%v' for platform '%v': %v", image, platform, err)
+	}
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, image)
+	return inspect, err
+}
+
+// supportsPlatform returns an error if the inspected image is not of the
+// given platform, as is the case when the registry provides no image of that
+// platform and the daemon falls back to another.
+func supportsPlatform(image string, inspect types.ImageInspect, platform string) error {
+	actual := inspect.Os + "/" + inspect.Architecture
+	if actual != platform {
+		return fmt.Errorf("builder '%v' does not support platform '%v': the image is of platform '%v'", image, platform, actual)
+	}
+	return nil
+}
+
+// stackRunImage returns the run image of the stack of the inspected builder
+// image, if declared in its metadata.
+func stackRunImage(inspect types.ImageInspect) (string, error) {
+	if inspect.Config == nil || inspect.Config.Labels[builderMetadataLabel] == "" {
+		return "", nil
+	}
+	var metadata struct {
+		Stack struct {
+			RunImage struct {
+				Image string `json:"image"`
+			} `json:"runImage"`
+		} `json:"stack"`
+	}
+	if err := json.Unmarshal([]byte(inspect.Config.Labels[builderMetadataLabel]), &metadata); err != nil {
+		return "", fmt.Errorf("invalid builder metadata: %v", err)
+	}
+	return metadata.Stack.RunImage.Image, nil
+}
+
+// localEnvRegex matches build env values referencing a local environment
+// variable, such as {{ env:GO_VERSION }}.
+var localEnvRegex = regexp.MustCompile(`^{{\s*env:(\w+)\s*}}$`)
+
+// BuildEnvs returns the Function's build environment, with values referencing
+// local environment variables resolved.  Only the build environment is
+// returned: the Function's runtime Envs are not set when building.
+func BuildEnvs(envs fn.Envs) (map[string]string, error) {
+	m := make(map[string]string, len(envs))
+	for _, env := range envs {
+		if env.Name == nil || env.Value == nil {
+			continue
+		}
+		value := *env.Value
+		if match := localEnvRegex.FindStringSubmatch(value); match != nil {
+			v, ok := os.LookupEnv(match[1])
+			if !ok {
+				return nil, fmt.Errorf("required local environment variable %q of build env %q is not set", match[1], *env.Name)
+			}
+			value = v
+		}
+		m[*env.Name] = value
+	}
+	return m, nil
+}
+
+// cacheOptions returns the pack options which implement the given cache
+// configuration: whether to clear cached layers, and the volumes to mount.
+// The cache directory is created if it does not exist.  A disabled cache
+// neither mounts nor populates the directory.
+func cacheOptions(cache fn.BuildCache) (clearCache bool, volumes []string, err error) {
+	if cache.Disabled {
+		return true, nil, nil
+	}
+	if cache.Dir == "" {
+		return
+	}
+	if err = os.MkdirAll(cache.Dir, 0755); err != nil {
+		return false, nil, fmt.Errorf("unable to create build cache directory: %v", err)
+	}
+	return false, []string{cache.Dir + ":" + CacheMountPath}, nil
+}
+
 // hack this makes stdout non-closeable
 type stdoutWrapper struct {
 	impl io.Writer
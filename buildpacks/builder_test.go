@@ -0,0 +1,396 @@
+// +build !integration
+
+package buildpacks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/pack"
+	"github.com/buildpacks/pack/config"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_cacheOptions ensures the build cache configuration is translated into
+// the corresponding pack options, creating the cache directory as needed.
+func Test_cacheOptions(t *testing.T) {
+	root, err := ioutil.TempDir("", "func-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "build")
+
+	tests := []struct {
+		name    string
+		cache   fn.BuildCache
+		clear   bool
+		volumes []string
+	}{
+		{"default", fn.BuildCache{}, false, nil},
+		{"directory", fn.BuildCache{Dir: dir}, false, []string{dir + ":" + CacheMountPath}},
+		{"disabled", fn.BuildCache{Dir: dir, Disabled: true}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clear, volumes, err := cacheOptions(tt.cache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if clear != tt.clear {
+				t.Fatalf("expected clear cache %v, got %v", tt.clear, clear)
+			}
+			if !reflect.DeepEqual(volumes, tt.volumes) {
+				t.Fatalf("expected volumes %v, got %v", tt.volumes, volumes)
+			}
+		})
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("expected cache directory to be created: %v", err)
+	}
+}
+
+// Test_BuildEnvs ensures build envs are passed to pack, resolving those set
+// from the local environment.
+func Test_BuildEnvs(t *testing.T) {
+	os.Setenv("FUNC_TEST_BUILD_ENV", "local")
+	defer os.Unsetenv("FUNC_TEST_BUILD_ENV")
+
+	name, value := "BP_GO_VERSION", "1.16"
+	local, localValue := "FROM_LOCAL", "{{ env:FUNC_TEST_BUILD_ENV }}"
+	envs, err := BuildEnvs(fn.Envs{
+		{Name: &name, Value: &value},
+		{Name: &local, Value: &localValue},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"BP_GO_VERSION": "1.16", "FROM_LOCAL": "local"}
+	if !reflect.DeepEqual(envs, expected) {
+		t.Fatalf("expected build envs %v, got %v", expected, envs)
+	}
+
+	unset := "{{ env:FUNC_TEST_BUILD_ENV_UNSET }}"
+	if _, err = BuildEnvs(fn.Envs{{Name: &local, Value: &unset}}); err == nil {
+		t.Fatal("expected an error for an unset local environment variable")
+	}
+}
+
+// Test_supportsPlatform ensures an image of a platform other than that
+// requested is reported as unsupported.
+func Test_supportsPlatform(t *testing.T) {
+	inspect := types.ImageInspect{Os: "linux", Architecture: "amd64"}
+	if err := supportsPlatform("builder", inspect, "linux/amd64"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := supportsPlatform("builder", inspect, "linux/arm64"); err == nil {
+		t.Fatal("expected an error for the unsupported platform")
+	}
+}
+
+// Test_PullPolicy ensures the builder pull policy of the Function is that
+// with which pack pulls the builder, defaulting to if-not-present.
+func Test_PullPolicy(t *testing.T) {
+	tests := map[string]config.PullPolicy{
+		"":               config.PullIfNotPresent,
+		"always":         config.PullAlways,
+		"if-not-present": config.PullIfNotPresent,
+		"never":          config.PullNever,
+	}
+	for policy, expected := range tests {
+		got, err := PullPolicy(fn.Function{BuilderPullPolicy: policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != expected {
+			t.Fatalf("expected the policy '%v' to be %v, got %v", policy, expected, got)
+		}
+	}
+	if _, err := PullPolicy(fn.Function{BuilderPullPolicy: "sometimes"}); err == nil {
+		t.Fatal("expected an error for an invalid policy")
+	}
+}
+
+// Test_withRuntimeVersion ensures the runtime version is passed in the env of
+// the buildpack of the runtime, unless set by the build envs, and that the
+// version of runtimes which can not be selected is rejected.
+func Test_withRuntimeVersion(t *testing.T) {
+	envs := map[string]string{}
+	if err := withRuntimeVersion(envs, fn.Function{Runtime: "node", RuntimeVersion: "18"}); err != nil {
+		t.Fatal(err)
+	}
+	if envs["BP_NODE_VERSION"] != "18" {
+		t.Fatalf("expected BP_NODE_VERSION 18, got %v", envs)
+	}
+
+	envs = map[string]string{"BP_GO_VERSION": "1.17"}
+	if err := withRuntimeVersion(envs, fn.Function{Runtime: "go", RuntimeVersion: "1.19"}); err != nil {
+		t.Fatal(err)
+	}
+	if envs["BP_GO_VERSION"] != "1.17" {
+		t.Fatalf("expected the build env to take precedence, got %v", envs)
+	}
+
+	if err := withRuntimeVersion(map[string]string{}, fn.Function{Runtime: "rust", RuntimeVersion: "1.60"}); err == nil {
+		t.Fatal("expected an error for the version of rust")
+	}
+	if err := withRuntimeVersion(map[string]string{}, fn.Function{Runtime: "node", RuntimeVersion: "latest"}); err == nil {
+		t.Fatal("expected an error for an invalid version")
+	}
+}
+
+// Test_withLifecycleImage ensures the lifecycle image of the Function, if
+// any, is that of the build, the builder then not being trusted such that
+// pack uses it.
+func Test_withLifecycleImage(t *testing.T) {
+	opts := pack.BuildOptions{Builder: "quay.io/boson/faas-go-builder", TrustBuilder: true}
+	withLifecycleImage(&opts, fn.Function{})
+	if opts.LifecycleImage != "" || !opts.TrustBuilder {
+		t.Fatalf("expected the options to be unchanged without a lifecycle image, got %+v", opts)
+	}
+	withLifecycleImage(&opts, fn.Function{LifecycleImage: "buildpacksio/lifecycle:0.11.1"})
+	if opts.LifecycleImage != "buildpacksio/lifecycle:0.11.1" || opts.TrustBuilder {
+		t.Fatalf("expected the lifecycle image of an untrusted builder, got %+v", opts)
+	}
+	if lifecycle := lifecycleOf(opts, "0.10.2"); lifecycle != "buildpacksio/lifecycle:0.11.1" {
+		t.Fatalf("expected the lifecycle image to be reported, got %v", lifecycle)
+	}
+	opts.LifecycleImage = ""
+	if lifecycle := lifecycleOf(opts, "0.10.2"); lifecycle != "buildpacksio/lifecycle:0.10.2" {
+		t.Fatalf("expected the lifecycle image of the builder's version to be reported, got %v", lifecycle)
+	}
+	withLifecycleImage(&opts, fn.Function{LifecycleImage: "buildpacksio/lifecycle:0.11.1", RegistryMirrors: map[string]string{"docker.io": "registry.internal/hub"}})
+	if opts.LifecycleImage != "registry.internal/hub/buildpacksio/lifecycle:0.11.1" {
+		t.Fatalf("expected the lifecycle image to be pulled from its mirror, got %v", opts.LifecycleImage)
+	}
+}
+
+// Test_runImageMirrors ensures the run image of the stack is mirrored by the
+// registry mirrors, keyed by the run image as pack expects, and not when it
+// has no mirror.
+func Test_runImageMirrors(t *testing.T) {
+	mirrors := map[string]string{"gcr.io": "registry.internal/gcr"}
+	expected := map[string][]string{"gcr.io/paketo-buildpacks/run:base": {"registry.internal/gcr/paketo-buildpacks/run:base"}}
+	if m := runImageMirrors("gcr.io/paketo-buildpacks/run:base", mirrors); !reflect.DeepEqual(m, expected) {
+		t.Fatalf("expected the run image mirror %v, got %v", expected, m)
+	}
+	if m := runImageMirrors("docker.io/paketobuildpacks/run:base", mirrors); m != nil {
+		t.Fatalf("expected no mirror of a run image without one, got %v", m)
+	}
+}
+
+// Test_withBuildpacks ensures the buildpacks of the Function's build reach
+// pack after those of the builder, and that none are given otherwise.
+func Test_withBuildpacks(t *testing.T) {
+	opts := pack.BuildOptions{}
+	withBuildpacks(&opts, fn.Function{})
+	if opts.Buildpacks != nil {
+		t.Fatalf("expected the buildpacks of the builder alone, got %v", opts.Buildpacks)
+	}
+	withBuildpacks(&opts, fn.Function{Build: fn.BuildSpec{Buildpacks: []string{"gcr.io/paketo-buildpacks/datadog", "urn:cnb:registry:alice/audit"}}})
+	expected := []string{"from=builder", "gcr.io/paketo-buildpacks/datadog", "urn:cnb:registry:alice/audit"}
+	if !reflect.DeepEqual(opts.Buildpacks, expected) {
+		t.Fatalf("expected the buildpacks %v, got %v", expected, opts.Buildpacks)
+	}
+}
+
+// Test_supportsPlatformAPI ensures a platform API not supported by the
+// lifecycle of the builder is reported with those which are.
+func Test_supportsPlatformAPI(t *testing.T) {
+	supported := []string{"0.3", "0.4", "0.5"}
+	if err := supportsPlatformAPI("builder", supported, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := supportsPlatformAPI("builder", supported, "0.4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := supportsPlatformAPI("builder", supported, "0.7"); err == nil || !strings.Contains(err.Error(), "0.3, 0.4, 0.5") {
+		t.Fatalf("expected an error listing the supported platform APIs, got %v", err)
+	}
+}
+
+// Test_stackRunImage ensures the run image is read from the builder metadata.
+func Test_stackRunImage(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		image   string
+		wantErr bool
+	}{
+		{"no metadata", nil, "", false},
+		{"run image", map[string]string{builderMetadataLabel: `{"stack":{"runImage":{"image":"example.com/run"}}}`}, "example.com/run", false},
+		{"invalid metadata", map[string]string{builderMetadataLabel: `{`}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image, err := stackRunImage(types.ImageInspect{Config: &container.Config{Labels: tt.labels}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("stackRunImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if image != tt.image {
+				t.Fatalf("expected run image '%v', got '%v'", tt.image, image)
+			}
+		})
+	}
+}
+
+// Test_phaseWriter ensures the logs are forwarded as written, and each phase
+// of the lifecycle is reported as it begins, including when its line is split
+// across writes.
+func Test_phaseWriter(t *testing.T) {
+	var (
+		out    bytes.Buffer
+		phases []string
+	)
+	w := &phaseWriter{out: &out, onPhase: func(phase string) { phases = append(phases, phase) }}
+
+	logs := []string{
+		"===> DETECTING\n",
+		"[detector] 3 of 4 buildpacks participating\n",
+		"===> BUI", "LDING\r\n",
+		"[builder] go build... ===> not a phase\n",
+		"2021/05/11 10:00:00.000 ===> EXPORTING\n",
+	}
+	for _, l := range logs {
+		if _, err := w.Write([]byte(l)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if out.String() != strings.Join(logs, "") {
+		t.Fatalf("expected the logs to be forwarded, got %q", out.String())
+	}
+	if !reflect.DeepEqual(phases, []string{"detecting", "building", "exporting"}) {
+		t.Fatalf("unexpected phases %v", phases)
+	}
+	if w.phase != "exporting" {
+		t.Fatalf("expected the current phase 'exporting', got '%v'", w.phase)
+	}
+}
+
+// Test_phaseWriterTimings ensures each phase is timed as it ends, including
+// the last when the build ends, with those of the buildpacks of the building
+// phase from the first, unindented, line each logs.
+func Test_phaseWriterTimings(t *testing.T) {
+	var (
+		clock   time.Time
+		timings []PhaseTiming
+	)
+	w := &phaseWriter{out: ioutil.Discard, onDone: func(p PhaseTiming) { timings = append(timings, p) },
+		now: func() time.Time { return clock }}
+	log := func(seconds int, line string) {
+		clock = clock.Add(time.Duration(seconds) * time.Second)
+		if _, err := w.Write([]byte(line + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	log(0, "===> DETECTING")
+	log(2, "[builder] 2 of 3 buildpacks participating")
+	log(1, "===> BUILDING")
+	log(0, "[builder] Paketo Go Distribution 0.3.0")
+	log(4, "[builder]   Installing Go 1.15")
+	log(1, "[builder] ")
+	log(1, "[builder] Paketo Go Build 0.2.1")
+	log(9, "[builder]     Running 'go build'")
+	log(1, "===> EXPORTING")
+	clock = clock.Add(3 * time.Second)
+	w.end()
+	w.end() // ended once
+
+	expected := []PhaseTiming{
+		{Phase: "detecting", Duration: 3 * time.Second},
+		{Phase: "building", Duration: 16 * time.Second, Buildpacks: []BuildpackTiming{
+			{"Paketo Go Distribution 0.3.0", 6 * time.Second},
+			{"Paketo Go Build 0.2.1", 10 * time.Second},
+		}},
+		{Phase: "exporting", Duration: 3 * time.Second},
+	}
+	if !reflect.DeepEqual(timings, expected) {
+		t.Fatalf("expected the timings %+v, got %+v", expected, timings)
+	}
+}
+
+// Test_ErrBuildFailed ensures the error identifies the phase in which the
+// build failed, and includes the output if captured.
+func Test_ErrBuildFailed(t *testing.T) {
+	cause := errors.New("executing lifecycle: failed with status code: 51")
+
+	err := error(ErrBuildFailed{Phase: "building", Err: cause, Output: "[builder] exit 1"})
+	if !strings.HasPrefix(err.Error(), "failed to build the function in the building phase: executing lifecycle") ||
+		!strings.Contains(err.Error(), "output: [builder] exit 1") {
+		t.Fatalf("unexpected error message %q", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Fatal("expected the error to wrap its cause")
+	}
+	var failed ErrBuildFailed
+	if !errors.As(err, &failed) || failed.Phase != "building" {
+		t.Fatalf("expected ErrBuildFailed of the building phase, got %#v", err)
+	}
+
+	if msg := (ErrBuildFailed{Err: cause}).Error(); msg != "failed to build the function: "+cause.Error() {
+		t.Fatalf("unexpected error message without a phase %q", msg)
+	}
+}
+
+// TestResolveBuilder ensures the digest of the builder image is resolved
+// from its registry by tag, and that the builder is then referenced by it.
+func TestResolveBuilder(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	image := strings.TrimPrefix(server.URL, "http://") + "/boson/faas-go-builder:tip"
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := fn.Function{Builder: "default", BuilderMap: map[string]string{"default": image}}
+	digest, err := NewBuilder().ResolveBuilder(context.Background(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != expected.String() {
+		t.Fatalf("expected digest '%v', got '%v'", expected, digest)
+	}
+
+	pinned, err := Pinned(image, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pinned != strings.TrimSuffix(image, ":tip")+"@"+digest {
+		t.Fatalf("unexpected pinned reference '%v'", pinned)
+	}
+	if unpinned, _ := Pinned(image, ""); unpinned != image {
+		t.Fatalf("expected the image by tag without a digest, got '%v'", unpinned)
+	}
+}
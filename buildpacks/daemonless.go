@@ -0,0 +1,401 @@
+package buildpacks
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	fn "github.com/boson-project/func"
+)
+
+// PlatformAPIEnv is the environment variable of the lifecycle with which
+// the platform API at which it is run is selected.
+const PlatformAPIEnv = "CNB_PLATFORM_API"
+
+// CreatorPath is the path of the creator of the buildpacks lifecycle in
+// builder images, which runs all of its phases in the one process.
+const CreatorPath = "/cnb/lifecycle/creator"
+
+// StackPath is the path of the stack of builder images, which declares the
+// run image of the images they build.
+const StackPath = "/cnb/stack.toml"
+
+// ErrDaemonlessUnavailable is returned by a DaemonlessBuilder when the creator
+// of the buildpacks lifecycle is not present, as is the case other than when
+// running in a builder image.
+var ErrDaemonlessUnavailable = errors.New("the buildpacks lifecycle is not present for a daemonless build. Daemonless builds run in a builder image, such as that of the function's runtime used as the image of a CI job")
+
+// DaemonlessBuilder builds Functions with the buildpacks lifecycle of the
+// builder image in which it runs, without a container daemon, such as on CI
+// runners without docker.  The lifecycle exports the image directly to its
+// registry, such that it is pushed as it is built: its Push only resolves the
+// digest of the image pushed.  Its buildpacks are those of the builder image
+// in which it runs, rather than those of the Function's builder.
+type DaemonlessBuilder struct {
+	Verbose bool
+	// Progress, if set, is called with the name of each phase of the
+	// lifecycle as it begins, as with Builder.
+	Progress func(phase string)
+	// PhaseDone, if set, is called with the timing of each phase of the
+	// lifecycle as it ends, as with Builder.
+	PhaseDone func(PhaseTiming)
+	// Credentials, if set, provides the credentials of the registry to which
+	// the image is exported, in addition to those of the docker config.
+	Credentials fn.CredentialsProvider
+	// Creator is the path of the creator of the lifecycle.  Defaults to
+	// CreatorPath.
+	Creator string
+	// Stack is the path of the stack of the builder image, of which the run
+	// image is pulled from its registry mirror, if any.  Defaults to
+	// StackPath.
+	Stack string
+
+	// credentials of each registry resolved from Credentials, such that
+	// those with which the image is exported are those with which its
+	// digest is resolved, without being provided again.
+	credentials map[string]fn.Credentials
+}
+
+// NewDaemonlessBuilder returns a builder which builds without a container
+// daemon.
+func NewDaemonlessBuilder() *DaemonlessBuilder {
+	return &DaemonlessBuilder{Creator: CreatorPath, Stack: StackPath}
+}
+
+// DaemonlessAvailable returns whether the creator of the lifecycle is present,
+// such that daemonless builds are possible.
+func DaemonlessAvailable() bool {
+	_, err := os.Stat(CreatorPath)
+	return err == nil
+}
+
+// Build the Function using the default build cache.
+func (b *DaemonlessBuilder) Build(ctx context.Context, f fn.Function) error {
+	return b.BuildWithCache(ctx, f, fn.BuildCache{})
+}
+
+// BuildWithCache builds the Function, reusing the layers cached in the
+// "layers" directory of the cache directory, if any, unless disabled.
+func (b *DaemonlessBuilder) BuildWithCache(ctx context.Context, f fn.Function, cache fn.BuildCache) error {
+	return b.BuildWithLabels(ctx, f, cache, nil)
+}
+
+// BuildWithLabels builds the Function as does BuildWithCache, labeling the
+// image with the given OCI labels as does Builder.  The source of the
+// Function, other than the files matched by its ignore patterns, is copied
+// to a working directory from which it is built, such that it is not
+// modified by the build.
+func (b *DaemonlessBuilder) BuildWithLabels(ctx context.Context, f fn.Function, cache fn.BuildCache, labels map[string]string) (err error) {
+	creator := b.Creator
+	if creator == "" {
+		creator = CreatorPath
+	}
+	if _, err = os.Stat(creator); err != nil {
+		return fmt.Errorf("%w: %v", ErrDaemonlessUnavailable, err)
+	}
+	if f.Platform != "" {
+		return fmt.Errorf("building for the platform '%v' is not supported by daemonless builds, which build for that of the builder image in which they run", f.Platform)
+	}
+	if f.LifecycleImage != "" {
+		return fmt.Errorf("the lifecycle image '%v' is not supported by daemonless builds, which run the lifecycle of the builder image in which they run", f.LifecycleImage)
+	}
+	if len(f.Build.Buildpacks) > 0 {
+		return fmt.Errorf("the buildpacks %v are not supported by daemonless builds, which run those of the builder image in which they run", strings.Join(f.Build.Buildpacks, ", "))
+	}
+
+	work, err := ioutil.TempDir("", "func-build")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(work)
+	var (
+		app      = filepath.Join(work, "app")
+		layers   = filepath.Join(work, "layers")
+		platform = filepath.Join(work, "platform")
+	)
+
+	patterns, err := f.IgnorePatterns()
+	if err != nil {
+		return
+	}
+	if err = copySource(f.Root, app, fn.NewIgnorer(patterns)); err != nil {
+		return
+	}
+
+	envs, err := BuildEnvs(f.BuildEnvs)
+	if err != nil {
+		return
+	}
+	if err = withRuntimeVersion(envs, f); err != nil {
+		return
+	}
+	for label, value := range labels {
+		if env, ok := labelEnvs[label]; ok {
+			if _, ok = envs[env]; !ok {
+				envs[env] = value
+			}
+		}
+	}
+	if err = writePlatformEnvs(platform, envs); err != nil {
+		return
+	}
+
+	args := []string{"-app", app, "-layers", layers, "-platform", platform}
+	if cache.Dir != "" {
+		dir := filepath.Join(cache.Dir, "layers")
+		if cache.Disabled {
+			if err = os.RemoveAll(dir); err != nil {
+				return
+			}
+		}
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			return
+		}
+		args = append(args, "-cache-dir", dir)
+	}
+	if cache.Disabled {
+		args = append(args, "-skip-restore")
+	}
+	stack := b.Stack
+	if stack == "" {
+		stack = StackPath
+	}
+	if runImage, err := mirroredRunImage(stack, f.RegistryMirrors); err != nil {
+		return err
+	} else if runImage != "" {
+		args = append(args, "-run-image", runImage)
+	}
+	args = append(args, f.Image)
+
+	cmd := exec.CommandContext(ctx, creator, args...)
+	cmd.Env = os.Environ()
+	if auth, err := b.registryAuth(ctx, f.Image); err != nil {
+		return err
+	} else if auth != "" {
+		cmd.Env = append(cmd.Env, "CNB_REGISTRY_AUTH="+auth)
+	}
+	// The lifecycle is run at the platform API requested, if any, rather than
+	// the latest it supports.
+	if f.PlatformAPI != "" {
+		cmd.Env = append(cmd.Env, PlatformAPIEnv+"="+f.PlatformAPI)
+	}
+
+	// As with Builder, the output is kept to be printed on failure when not
+	// verbose, and the phases of the lifecycle are tracked from it.
+	var output bytes.Buffer
+	var out io.Writer = &output
+	if b.Verbose {
+		out = os.Stdout
+	}
+	phases := &phaseWriter{out: out, onPhase: b.Progress, onDone: b.PhaseDone}
+	if b.Verbose {
+		platformAPI := f.PlatformAPI
+		if platformAPI == "" {
+			platformAPI = "the latest supported"
+		}
+		fmt.Fprintf(out, "Lifecycle: %v (platform API %v)\n", filepath.Dir(creator), platformAPI)
+	}
+	cmd.Stdout = phases
+	cmd.Stderr = phases
+
+	if err = cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		failed := ErrBuildFailed{Phase: phases.phase, Err: err}
+		if !b.Verbose {
+			failed.Output = output.String()
+		}
+		return failed
+	}
+	phases.end()
+	return nil
+}
+
+// mirroredRunImage returns the run image of the stack at path rewritten to be
+// pulled from its registry mirror, or none if it has none, in which case the
+// lifecycle selects the run image of the stack itself.
+func mirroredRunImage(path string, mirrors map[string]string) (string, error) {
+	if len(mirrors) == 0 {
+		return "", nil
+	}
+	var stack struct {
+		RunImage struct {
+			Image string `toml:"image"`
+		} `toml:"run-image"`
+	}
+	if _, err := toml.DecodeFile(path, &stack); os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read the stack of the builder image: %v", err)
+	}
+	if image, ok := fn.MirrorImage(stack.RunImage.Image, mirrors); ok {
+		return image, nil
+	}
+	return "", nil
+}
+
+// Push the image of the Function, which was pushed to its registry as it was
+// built, returning its digest as resolved from the registry with the
+// credentials with which it was pushed.
+func (b *DaemonlessBuilder) Push(ctx context.Context, f fn.Function) (string, error) {
+	ref, err := name.ParseReference(f.Image)
+	if err != nil {
+		return "", err
+	}
+	auth, err := b.authenticator(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	desc, err := remote.Head(ref, remote.WithContext(ctx), remote.WithAuth(auth))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the digest of the image '%v' built: %w", f.Image, err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// registryAuth returns the value of CNB_REGISTRY_AUTH, with which the
+// lifecycle authenticates to the registry of the image, of the credentials
+// of the builder's provider, if any.  The lifecycle otherwise uses those of
+// the docker config.
+func (b *DaemonlessBuilder) registryAuth(ctx context.Context, image string) (string, error) {
+	if b.Credentials == nil {
+		return "", nil
+	}
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	registry := ref.Context().RegistryStr()
+	c, err := b.registryCredentials(ctx, registry)
+	if err != nil || c.Username == "" {
+		return "", err
+	}
+	basic := base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))
+	bb, err := json.Marshal(map[string]string{registry: "Basic " + basic})
+	return string(bb), err
+}
+
+// authenticator of the registry of the image, with which it is pushed: that
+// of the credentials of the builder's provider, if any, as with which the
+// lifecycle exports it (see registryAuth), and otherwise that of the docker
+// config.
+func (b *DaemonlessBuilder) authenticator(ctx context.Context, ref name.Reference) (authn.Authenticator, error) {
+	if b.Credentials != nil {
+		c, err := b.registryCredentials(ctx, ref.Context().RegistryStr())
+		if err != nil {
+			return nil, err
+		}
+		if c.Username != "" {
+			return &authn.Basic{Username: c.Username, Password: c.Password}, nil
+		}
+	}
+	return authn.DefaultKeychain.Resolve(ref.Context())
+}
+
+// registryCredentials returns the credentials of the registry of the
+// builder's provider, which is asked once per registry.
+func (b *DaemonlessBuilder) registryCredentials(ctx context.Context, registry string) (fn.Credentials, error) {
+	if c, ok := b.credentials[registry]; ok {
+		return c, nil
+	}
+	c, err := b.Credentials.Credentials(ctx, registry)
+	if err != nil {
+		return c, err
+	}
+	if b.credentials == nil {
+		b.credentials = map[string]fn.Credentials{}
+	}
+	b.credentials[registry] = c
+	return c, nil
+}
+
+// writePlatformEnvs writes each of the build envs as a file of the env
+// directory of the platform directory, as read by the lifecycle.
+func writePlatformEnvs(platform string, envs map[string]string) error {
+	dir := filepath.Join(platform, "env")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for name, value := range envs {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(value), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copySource copies the files of the directory at root to dst, other than
+// those ignored by the given ignorer, the .git directory and the
+// RunDataDir.
+func copySource(root, dst string, ignorer fn.Ignorer) error {
+	return filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if rel == "." {
+			return os.MkdirAll(target, 0755)
+		}
+		name := filepath.ToSlash(rel)
+		if fi.IsDir() {
+			name += "/"
+		}
+		if name == ".git/" || name == fn.RunDataDir+"/" || ignorer.Ignored(name) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch {
+		case fi.IsDir():
+			return os.MkdirAll(target, fi.Mode().Perm())
+		case fi.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case fi.Mode().IsRegular():
+			return copyFile(path, target, fi.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+// copyFile at src to dst with the given permissions.
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,262 @@
+// +build !integration
+
+package buildpacks
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	fn "github.com/boson-project/func"
+)
+
+// fakeCreator writes a script standing in for the creator of the lifecycle,
+// which records its arguments, the source and build envs it is given and the
+// registry auth and platform API of its environment in the returned
+// directory, logging the phases of the lifecycle and exiting with the given
+// status.
+func fakeCreator(t *testing.T, dir string, status int) (creator, record string) {
+	t.Helper()
+	record = filepath.Join(dir, "record")
+	creator = filepath.Join(dir, "creator")
+	script := `#!/bin/sh
+mkdir -p ` + record + `
+echo "$@" > ` + record + `/args
+while [ $# -gt 1 ]; do
+  case "$1" in
+    -app) cp -r "$2" ` + record + `/app ;;
+    -platform) cp -r "$2" ` + record + `/platform ;;
+  esac
+  shift
+done
+echo "$CNB_REGISTRY_AUTH" > ` + record + `/auth
+echo "$CNB_PLATFORM_API" > ` + record + `/platform-api
+echo "===> DETECTING"
+echo "===> BUILDING"
+exit ` + string(rune('0'+status)) + `
+`
+	if err := ioutil.WriteFile(creator, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// Test_DaemonlessBuild ensures the Function is built by the creator of the
+// lifecycle from a copy of its source without the files it ignores, with its
+// build envs, the labels and the credentials of the registry, and that a
+// failed build identifies the phase in which it failed.
+func Test_DaemonlessBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "func-daemonless")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "myfunc")
+	for path, content := range map[string]string{"handle.go": "package function", "ignored.txt": "", ".funcignore": "ignored.txt\n", ".func/instance.json": "{}"} {
+		if err = os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(root, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	name, value := "BP_GO_VERSION", "1.16"
+	f := fn.Function{Root: root, Runtime: "go", Image: "registry.example.com/alice/myfunc:latest", BuildEnvs: fn.Envs{{Name: &name, Value: &value}}, PlatformAPI: "0.4"}
+
+	creator, record := fakeCreator(t, dir, 0)
+	var phases []string
+	builder := &DaemonlessBuilder{
+		Creator:  creator,
+		Progress: func(phase string) { phases = append(phases, phase) },
+		Credentials: fn.CredentialsProviderFunc(func(ctx context.Context, registry string) (fn.Credentials, error) {
+			return fn.Credentials{Username: "alice", Password: "secret"}, nil
+		}),
+	}
+	cache := fn.BuildCache{Dir: filepath.Join(dir, "cache")}
+	if err = builder.BuildWithLabels(context.Background(), f, cache, map[string]string{fn.SourceLabel: "https://github.com/alice/myfunc.git"}); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(path string) string {
+		t.Helper()
+		bb, err := ioutil.ReadFile(filepath.Join(record, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(bb))
+	}
+	if args := read("args"); !strings.Contains(args, "-cache-dir "+filepath.Join(cache.Dir, "layers")) || !strings.HasSuffix(args, " "+f.Image) {
+		t.Fatalf("expected the image to be built with the cache, got arguments %v", args)
+	}
+	if read("app/handle.go") != "package function" {
+		t.Fatal("expected the source of the function to be built")
+	}
+	if _, err = os.Stat(filepath.Join(record, "app", "ignored.txt")); !os.IsNotExist(err) {
+		t.Fatal("expected the files ignored by the function not to be built")
+	}
+	if _, err = os.Stat(filepath.Join(record, "app", fn.RunDataDir)); !os.IsNotExist(err) {
+		t.Fatal("expected the runtime state of the function not to be built")
+	}
+	if read("platform/env/BP_GO_VERSION") != "1.16" || read("platform/env/BP_OCI_SOURCE") != "https://github.com/alice/myfunc.git" {
+		t.Fatal("expected the build envs and labels to be provided in the platform directory")
+	}
+	if auth := read("auth"); !strings.Contains(auth, `"registry.example.com":"Basic YWxpY2U6c2VjcmV0"`) {
+		t.Fatalf("expected the credentials of the registry, got %v", auth)
+	}
+	if read("platform-api") != "0.4" {
+		t.Fatalf("expected the lifecycle to be run at platform API 0.4, got %v", read("platform-api"))
+	}
+	if strings.Join(phases, ",") != "detecting,building" {
+		t.Fatalf("expected the phases to be reported, got %v", phases)
+	}
+
+	builder.Creator, _ = fakeCreator(t, dir, 1)
+	err = builder.Build(context.Background(), f)
+	var failed ErrBuildFailed
+	if !errors.As(err, &failed) || failed.Phase != "building" || !strings.Contains(failed.Output, "===> BUILDING") {
+		t.Fatalf("expected the build to fail in the building phase, with its output, got %v", err)
+	}
+
+	builder.Creator = filepath.Join(dir, "nonexistent")
+	if err = builder.Build(context.Background(), f); !errors.Is(err, ErrDaemonlessUnavailable) {
+		t.Fatalf("expected ErrDaemonlessUnavailable without the lifecycle, got %v", err)
+	}
+}
+
+// Test_DaemonlessRunImageMirror ensures the run image of the stack of the
+// builder image is pulled from its registry mirror, and that the lifecycle
+// selects it otherwise.
+func Test_DaemonlessRunImageMirror(t *testing.T) {
+	dir, err := ioutil.TempDir("", "func-daemonless")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "myfunc")
+	if err = os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	stack := filepath.Join(dir, "stack.toml")
+	if err = ioutil.WriteFile(stack, []byte("[run-image]\n  image = \"gcr.io/paketo-buildpacks/run:base\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	creator, record := fakeCreator(t, dir, 0)
+	builder := &DaemonlessBuilder{Creator: creator, Stack: stack}
+
+	f := fn.Function{Root: root, Runtime: "go", Image: "registry.example.com/alice/myfunc:latest"}
+	for _, test := range []struct {
+		mirrors  map[string]string
+		runImage string
+	}{
+		{map[string]string{"gcr.io": "registry.internal/gcr"}, "registry.internal/gcr/paketo-buildpacks/run:base"},
+		{map[string]string{"docker.io": "registry.internal/hub"}, ""},
+		{nil, ""},
+	} {
+		f.RegistryMirrors = test.mirrors
+		if err = builder.Build(context.Background(), f); err != nil {
+			t.Fatal(err)
+		}
+		bb, err := ioutil.ReadFile(filepath.Join(record, "args"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := strings.TrimSpace(string(bb))
+		if test.runImage != "" && !strings.HasSuffix(args, "-run-image "+test.runImage+" "+f.Image) {
+			t.Fatalf("expected the run image to be pulled from its mirror %v, got arguments %v", test.runImage, args)
+		}
+		if test.runImage == "" && strings.Contains(args, "-run-image") {
+			t.Fatalf("expected the lifecycle to select the run image without its mirror, got arguments %v", args)
+		}
+	}
+}
+
+// Test_DaemonlessPush ensures that the image built, being pushed as it was
+// built, is pushed by resolving its digest in the registry.
+func Test_DaemonlessPush(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	image := strings.TrimPrefix(server.URL, "http://") + "/alice/myfunc:latest"
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := NewDaemonlessBuilder().Push(context.Background(), fn.Function{Image: image})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != expected.String() {
+		t.Fatalf("expected the digest %v, got %v", expected, digest)
+	}
+}
+
+// Test_DaemonlessPushCredentials ensures the digest of the image built is
+// resolved with the credentials of the builder's provider, with which it was
+// pushed, which are provided once.
+func Test_DaemonlessPushCredentials(t *testing.T) {
+	handler := registry.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "alice" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	image := strings.TrimPrefix(server.URL, "http://") + "/alice/myfunc:latest"
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = remote.Write(ref, img, remote.WithAuth(&authn.Basic{Username: "alice", Password: "secret"})); err != nil {
+		t.Fatal(err)
+	}
+
+	provided := 0
+	b := NewDaemonlessBuilder()
+	b.Credentials = fn.CredentialsProviderFunc(func(ctx context.Context, registry string) (fn.Credentials, error) {
+		provided++
+		return fn.Credentials{Username: "alice", Password: "secret"}, nil
+	})
+	if _, err = b.registryAuth(context.Background(), image); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = b.Push(context.Background(), fn.Function{Image: image}); err != nil {
+		t.Fatal(err)
+	}
+	if provided != 1 {
+		t.Fatalf("expected the credentials to be provided once, got %v times", provided)
+	}
+}
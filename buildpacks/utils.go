@@ -1,6 +1,8 @@
 package buildpacks
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"sort"
 	"strings"
 )
@@ -29,3 +31,70 @@ func RuntimesList() []string {
 
 	return runtimes
 }
+
+//RuntimeAlias returns the canonical name of the runtime of the given name,
+//which is either an alias of RuntimeAliases or is returned unchanged
+func RuntimeAlias(name string) string {
+	if runtime, ok := RuntimeAliases[name]; ok {
+		return runtime
+	}
+	return name
+}
+
+//ParseRuntime returns the canonical name and the version, if any, of the
+//runtime of the given spec of the form name[@version], such as "nodejs@18"
+//of version 18 of node
+func ParseRuntime(spec string) (runtime, version string) {
+	if i := strings.Index(spec, "@"); i >= 0 {
+		spec, version = spec[:i], spec[i+1:]
+	}
+	return RuntimeAlias(spec), version
+}
+
+//Aliases returns the runtime aliases as comma separated strings of the form
+//"alias (runtime)", sorted alphabetically by alias
+func Aliases() string {
+	aliases := make([]string, 0, len(RuntimeAliases))
+	for alias := range RuntimeAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for i, alias := range aliases {
+		aliases[i] = alias + " (" + RuntimeAliases[alias] + ")"
+	}
+	return strings.Join(aliases, ", ")
+}
+
+// runtimeMarkers are the files whose presence at the root of a source tree
+// indicates its runtime, in order of precedence.  Where a marker is shared by
+// runtimes, such as package.json for both node and typescript, the more
+// specific runtime's marker is listed first.
+var runtimeMarkers = []struct {
+	file    string
+	runtime string
+}{
+	{"tsconfig.json", "typescript"},
+	{"package.json", "node"},
+	{"go.mod", "go"},
+	{"Cargo.toml", "rust"},
+	{"requirements.txt", "python"},
+	{"pyproject.toml", "python"},
+	{"pom.xml", "quarkus"}, // unless it references Spring Boot, see below
+}
+
+//DetectRuntime returns the runtime of the source at path as indicated by
+//the marker files therein, such as package.json or go.mod, and whether
+//or not a runtime was detected.
+func DetectRuntime(path string) (string, bool) {
+	for _, m := range runtimeMarkers {
+		bb, err := ioutil.ReadFile(filepath.Join(path, m.file))
+		if err != nil {
+			continue
+		}
+		if m.file == "pom.xml" && strings.Contains(string(bb), "spring-boot") {
+			return "springboot", true
+		}
+		return m.runtime, true
+	}
+	return "", false
+}
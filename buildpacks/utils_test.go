@@ -0,0 +1,86 @@
+// +build !integration
+
+package buildpacks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDetectRuntime ensures the runtime of source is detected from the
+// marker files at its root.
+func TestDetectRuntime(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		runtime  string
+		detected bool
+	}{
+		{"none", map[string]string{"README.md": ""}, "", false},
+		{"go", map[string]string{"go.mod": "module example.com/myfunc\n"}, "go", true},
+		{"node", map[string]string{"package.json": "{}"}, "node", true},
+		{"typescript", map[string]string{"package.json": "{}", "tsconfig.json": "{}"}, "typescript", true},
+		{"python", map[string]string{"requirements.txt": ""}, "python", true},
+		{"rust", map[string]string{"Cargo.toml": ""}, "rust", true},
+		{"quarkus", map[string]string{"pom.xml": "<artifactId>quarkus-funqy-http</artifactId>"}, "quarkus", true},
+		{"springboot", map[string]string{"pom.xml": "<artifactId>spring-boot-starter-parent</artifactId>"}, "springboot", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "detect-runtime")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+			for name, content := range tt.files {
+				if err = ioutil.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			runtime, detected := DetectRuntime(root)
+			if runtime != tt.runtime || detected != tt.detected {
+				t.Fatalf("expected (%q, %v), got (%q, %v)", tt.runtime, tt.detected, runtime, detected)
+			}
+		})
+	}
+}
+
+// TestRuntimeAlias ensures aliases are normalized to their canonical runtime,
+// and that other names, including unknown runtimes, are returned unchanged.
+func TestRuntimeAlias(t *testing.T) {
+	tests := map[string]string{
+		"js":      "node",
+		"ts":      "typescript",
+		"golang":  "go",
+		"node":    "node",
+		"unknown": "unknown",
+	}
+	for name, expected := range tests {
+		if runtime := RuntimeAlias(name); runtime != expected {
+			t.Errorf("expected '%v' to be normalized to '%v', got '%v'", name, expected, runtime)
+		}
+	}
+	for alias, runtime := range RuntimeAliases {
+		if _, ok := RuntimeToBuildpack[runtime]; !ok {
+			t.Errorf("alias '%v' is of unsupported runtime '%v'", alias, runtime)
+		}
+	}
+}
+
+// TestParseRuntime ensures the version, if any, is parsed from the runtime,
+// the name of which is resolved from any alias.
+func TestParseRuntime(t *testing.T) {
+	tests := map[string][2]string{
+		"node":        {"node", ""},
+		"node@18":     {"node", "18"},
+		"golang@1.19": {"go", "1.19"},
+	}
+	for spec, expected := range tests {
+		if runtime, version := ParseRuntime(spec); runtime != expected[0] || version != expected[1] {
+			t.Fatalf("expected '%v' to be runtime '%v' of version '%v', got '%v' of '%v'", spec, expected[0], expected[1], runtime, version)
+		}
+	}
+}
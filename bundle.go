@@ -0,0 +1,447 @@
+package function
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// BundleManifestFile is the manifest in the root of a bundle, which declares
+// the Function bundled and the digest of each of its files.
+const BundleManifestFile = "bundle.yaml"
+
+// BundleVersion is the version of the layout of the bundles written, the
+// only one read.
+const BundleVersion = 1
+
+// The directories of a bundle: that of the source of the Function, and that
+// of its image as an OCI image layout, if included.
+const (
+	bundleSourceDir = "source"
+	bundleImageDir  = "image"
+)
+
+// ErrInvalidBundle indicates a bundle is malformed, or its files are not
+// those declared by its manifest.
+var ErrInvalidBundle = errors.New("invalid bundle")
+
+// ErrProjectExists indicates the directory into which a bundle is imported
+// is not empty.
+var ErrProjectExists = errors.New("project already exists")
+
+// BundleManifest declares the Function of a bundle, and the files of which
+// the bundle is composed.
+type BundleManifest struct {
+	// Version of the layout of the bundle.
+	Version int `yaml:"version"`
+
+	// Name of the Function bundled.
+	Name string `yaml:"name"`
+
+	// Runtime of the Function bundled.
+	Runtime string `yaml:"runtime"`
+
+	// Config is the name of the config file of the Function in its source,
+	// if other than func.yaml.
+	Config string `yaml:"config,omitempty"`
+
+	// Image of the Function included in the bundle as an OCI image layout,
+	// if any.
+	Image string `yaml:"image,omitempty"`
+
+	// Files of the bundle, slash separated and relative to its root, and
+	// the digest of each, as "sha256:<hex>".
+	Files map[string]string `yaml:"files"`
+}
+
+// ExportBundle writes the bundle of the Function to the gzipped tarball at
+// path, such as to move it to another environment: its manifest, the regular
+// files of its source, less those excluded by its IgnorePatterns, .git and
+// RunDataDir, and, with image, its image as an OCI image layout, for which
+// the Function must have been built.
+func (c *Client) ExportBundle(ctx context.Context, f Function, path string, image bool) (err error) {
+	if !f.Initialized() {
+		return fmt.Errorf("%w: no function found in '%v'", ErrNotInitialized, f.Root)
+	}
+	var layout string
+	if image {
+		if f.Image == "" {
+			return fmt.Errorf("the image of the function is %w. Build it before bundling its image", ErrNotBuilt)
+		}
+		if layout, err = ioutil.TempDir("", "func-bundle"); err != nil {
+			return
+		}
+		defer os.RemoveAll(layout)
+		c.progressListener.Increment(fmt.Sprintf("Exporting the function image %v", f.Image))
+		if err = c.layoutExporter.ExportLayout(ctx, f, layout); err != nil {
+			return
+		}
+	}
+	c.progressListener.Increment("Writing the function bundle")
+	return writeBundle(f, path, layout)
+}
+
+// ImportBundle reconstructs the Function of the bundle at path in root, once
+// the bundle is validated, returning the Function.  An empty root is a
+// directory named after the Function in the current directory.  A root which
+// is not empty is an error wrapping ErrProjectExists unless force, in which
+// case the files of the bundle are written over those in root.  The image of
+// the bundle, if any, is written as an OCI image layout to imageDir, if given.
+func (c *Client) ImportBundle(path, root, imageDir string, force bool) (f Function, err error) {
+	c.progressListener.Increment("Validating the function bundle")
+	m, err := ReadBundleManifest(path)
+	if err != nil {
+		return
+	}
+	if imageDir != "" && m.Image == "" {
+		return f, fmt.Errorf("the bundle '%v' includes no image", path)
+	}
+	if root == "" {
+		root = m.Name
+	}
+	if !force {
+		entries, err := ioutil.ReadDir(root)
+		if err != nil && !os.IsNotExist(err) {
+			return f, err
+		}
+		if len(entries) > 0 {
+			return f, fmt.Errorf("%w in '%v'", ErrProjectExists, root)
+		}
+	}
+
+	c.progressListener.Increment(fmt.Sprintf("Importing the function %v", m.Name))
+	if err = extractBundle(path, map[string]string{bundleSourceDir: root, bundleImageDir: imageDir}); err != nil {
+		return
+	}
+	return NewFunctionFromFile(root, m.Config)
+}
+
+// ReadBundleManifest returns the manifest of the bundle at bundle, once the
+// bundle is validated: that its files are those of its manifest, with the
+// digests it declares, and that its source has the config file of the
+// Function it declares.  An invalid bundle is an error wrapping
+// ErrInvalidBundle.
+func ReadBundleManifest(bundle string) (m BundleManifest, err error) {
+	var manifest []byte
+	digests := map[string]string{}
+	configs := map[string][]byte{}
+	err = readBundle(bundle, func(name string, _ *tar.Header, r io.Reader) error {
+		if name == BundleManifestFile {
+			var err error
+			manifest, err = ioutil.ReadAll(r)
+			return err
+		}
+		h := sha256.New()
+		var config bytes.Buffer
+		isConfig := path.Dir(name) == bundleSourceDir && isConfigFile(path.Base(name))
+		if isConfig {
+			r = io.TeeReader(r, &config)
+		}
+		if _, err := io.Copy(h, r); err != nil {
+			return err
+		}
+		digests[name] = fmt.Sprintf("sha256:%x", h.Sum(nil))
+		if isConfig {
+			configs[path.Base(name)] = config.Bytes()
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	if manifest == nil {
+		return m, fmt.Errorf("%w '%v': no %v in its root", ErrInvalidBundle, bundle, BundleManifestFile)
+	}
+	if err = yaml.Unmarshal(manifest, &m); err != nil {
+		return m, fmt.Errorf("%w '%v': %v", ErrInvalidBundle, bundle, err)
+	}
+	if m.Version != BundleVersion {
+		return m, fmt.Errorf("%w '%v': unsupported version %v, expected %v", ErrInvalidBundle, bundle, m.Version, BundleVersion)
+	}
+	if !reflect.DeepEqual(digests, m.Files) {
+		return m, fmt.Errorf("%w '%v': its files do not match its manifest: %v", ErrInvalidBundle, bundle, strings.Join(bundleMismatches(m.Files, digests), ", "))
+	}
+	if err = validateConfigFile(m.Config); err != nil {
+		return m, fmt.Errorf("%w '%v': %v", ErrInvalidBundle, bundle, err)
+	}
+	file := configFileName(m.Config)
+	bb, ok := configs[file]
+	if !ok {
+		return m, fmt.Errorf("%w '%v': its source has no %v", ErrInvalidBundle, bundle, file)
+	}
+	c, err := parseConfig(file, bb)
+	if err != nil {
+		return m, fmt.Errorf("%w '%v': %v", ErrInvalidBundle, bundle, err)
+	}
+	if c.Name != m.Name || c.Runtime != m.Runtime {
+		return m, fmt.Errorf("%w '%v': the function '%v' (%v) of its %v is not that of its manifest, '%v' (%v)", ErrInvalidBundle, bundle, c.Name, c.Runtime, file, m.Name, m.Runtime)
+	}
+	return m, nil
+}
+
+// bundleMismatches returns the files of which the bundle does not match the
+// digests declared by its manifest: those missing, modified or undeclared.
+func bundleMismatches(declared, actual map[string]string) (mismatches []string) {
+	for name, digest := range declared {
+		if d, ok := actual[name]; !ok {
+			mismatches = append(mismatches, name+" is missing")
+		} else if d != digest {
+			mismatches = append(mismatches, name+" is modified")
+		}
+	}
+	for name := range actual {
+		if _, ok := declared[name]; !ok {
+			mismatches = append(mismatches, name+" is not declared")
+		}
+	}
+	sort.Strings(mismatches)
+	return
+}
+
+// isConfigFile returns whether the file of the given name is a config file
+// of a Function: func.yaml, or [name].func.yaml.
+func isConfigFile(name string) bool {
+	return name == ConfigFile || strings.HasSuffix(name, "."+ConfigFile)
+}
+
+// readBundle calls read with the name, slash separated and relative to its
+// root, the header and the contents of each file of the gzipped tarball at
+// bundle.  Entries other than regular files and directories, outside of its
+// root, or of neither the manifest nor the directories of a bundle, are an
+// error wrapping ErrInvalidBundle.
+func readBundle(bundle string, read func(name string, header *tar.Header, r io.Reader) error) error {
+	file, err := os.Open(bundle)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("%w '%v': %v", ErrInvalidBundle, bundle, err)
+	}
+	defer gz.Close()
+
+	seen := map[string]bool{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w '%v': %v", ErrInvalidBundle, bundle, err)
+		}
+		name := path.Clean(header.Name)
+		top := strings.SplitN(name, "/", 2)[0]
+		if path.IsAbs(name) || (name != BundleManifestFile && top != bundleSourceDir && top != bundleImageDir) {
+			return fmt.Errorf("%w '%v': unexpected entry '%v'", ErrInvalidBundle, bundle, header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg, tar.TypeRegA:
+		default:
+			return fmt.Errorf("%w '%v': '%v' is not a regular file", ErrInvalidBundle, bundle, header.Name)
+		}
+		if (name == top && name != BundleManifestFile) || seen[name] {
+			return fmt.Errorf("%w '%v': unexpected entry '%v'", ErrInvalidBundle, bundle, header.Name)
+		}
+		seen[name] = true
+		if err = read(name, header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// extractBundle writes the files of each directory of the bundle at bundle
+// to that of dirs, if any.
+func extractBundle(bundle string, dirs map[string]string) error {
+	return readBundle(bundle, func(name string, header *tar.Header, r io.Reader) error {
+		parts := strings.SplitN(name, "/", 2)
+		if len(parts) < 2 || dirs[parts[0]] == "" {
+			return nil
+		}
+		target := filepath.Join(dirs[parts[0]], filepath.FromSlash(parts[1]))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, header.FileInfo().Mode().Perm())
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err = io.Copy(file, r); err != nil {
+			return err
+		}
+		if header.ModTime.IsZero() {
+			return nil
+		}
+		return os.Chtimes(target, header.ModTime, header.ModTime)
+	})
+}
+
+// writeBundle of the Function to the gzipped tarball at bundle, with the
+// OCI image layout in the directory layout, if any.  The tarball is removed
+// should writing it fail.
+func writeBundle(f Function, bundle, layout string) (err error) {
+	files := map[string]string{} // the paths of the files of the bundle
+	sources, err := bundleSources(f, bundle)
+	if err != nil {
+		return
+	}
+	for _, rel := range sources {
+		files[bundleSourceDir+"/"+rel] = filepath.Join(f.Root, filepath.FromSlash(rel))
+	}
+	m := BundleManifest{
+		Version: BundleVersion,
+		Name:    f.Name,
+		Runtime: f.Runtime,
+		Config:  f.ConfigFile,
+		Files:   map[string]string{},
+	}
+	if layout != "" {
+		m.Image = f.Image
+		err = filepath.Walk(layout, func(p string, fi os.FileInfo, err error) error {
+			if err != nil || !fi.Mode().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(layout, p)
+			files[bundleImageDir+"/"+filepath.ToSlash(rel)] = p
+			return err
+		})
+		if err != nil {
+			return
+		}
+	}
+	names := make([]string, 0, len(files))
+	for name, p := range files {
+		if m.Files[name], err = fileDigest(p); err != nil {
+			return
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	manifest, err := yaml.Marshal(&m)
+	if err != nil {
+		return
+	}
+
+	out, err := os.Create(bundle)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(bundle)
+		}
+	}()
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	if err = tw.WriteHeader(&tar.Header{Name: BundleManifestFile, Mode: 0644, Size: int64(len(manifest)), Typeflag: tar.TypeReg}); err != nil {
+		return
+	}
+	if _, err = tw.Write(manifest); err != nil {
+		return
+	}
+	for _, name := range names {
+		if err = writeBundleFile(tw, name, files[name]); err != nil {
+			return
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return
+	}
+	return gz.Close()
+}
+
+// writeBundleFile writes the file at p to the bundle, named name.
+func writeBundleFile(tw *tar.Writer, name, p string) error {
+	file, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{Name: name, Mode: int64(fi.Mode().Perm()), Size: fi.Size(), ModTime: fi.ModTime(), Typeflag: tar.TypeReg}
+	if err = tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// bundleSources returns the regular files of the source of the Function,
+// relative to its root and slash separated, less those excluded by its
+// IgnorePatterns, .git, RunDataDir and the bundle being written.  Its config
+// file is always included.
+func bundleSources(f Function, bundle string) (sources []string, err error) {
+	patterns, err := f.IgnorePatterns()
+	if err != nil {
+		return
+	}
+	ignorer := NewIgnorer(patterns)
+	if bundle, err = filepath.Abs(bundle); err != nil {
+		return
+	}
+	config := configFileName(f.ConfigFile)
+	err = filepath.Walk(f.Root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(f.Root, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		name := rel
+		if fi.IsDir() {
+			name += "/"
+		}
+		if rel == ".git" || rel == RunDataDir || (rel != config && ignorer.Ignored(name)) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fi.Mode().IsRegular() && p != bundle {
+			sources = append(sources, rel)
+		}
+		return nil
+	})
+	return
+}
+
+// fileDigest returns the digest of the file at path, as "sha256:<hex>".
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil)), nil
+}
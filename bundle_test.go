@@ -0,0 +1,212 @@
+// +build !integration
+
+package function
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testLayoutExporter writes a stand-in for the OCI image layout of an image.
+type testLayoutExporter struct{}
+
+func (testLayoutExporter) ExportLayout(ctx context.Context, f Function, dir string) error {
+	if err := os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "blobs", "sha256", "a278a9"), []byte("layer"), 0644); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "index.json"), []byte(`{"schemaVersion":2}`), 0644)
+}
+
+// TestBundle ensures the bundle of a Function, of its source less the files
+// ignored and of its image, is imported as it was exported, and that a
+// directory which is not empty is refused unless forced.
+func TestBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "func-bundle-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "myfunc")
+	files := map[string]string{
+		"func.yaml":        "name: myfunc\nruntime: go\nimage: example.com/alice/myfunc:v1\n",
+		"handle.go":        "package function\n",
+		"hack/build.sh":    "#!/bin/sh\n",
+		".funcignore":      "secret.txt\n",
+		"secret.txt":       "hunter2\n",
+		".git/HEAD":        "ref: refs/heads/main\n",
+		".func/scaffolded": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = os.Chmod(filepath.Join(root, "hack/build.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := New(WithLayoutExporter(testLayoutExporter{}))
+	bundle := filepath.Join(dir, "myfunc.bundle.tar.gz")
+	if err = client.ExportBundle(context.Background(), f, bundle, true); err != nil {
+		t.Fatal(err)
+	}
+	m, err := ReadBundleManifest(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for name := range m.Files {
+		names = append(names, name)
+	}
+	expected := []string{".funcignore", "func.yaml", "hack/build.sh", "handle.go"}
+	for i := range expected {
+		expected[i] = "source/" + expected[i]
+	}
+	expected = append(expected, "image/blobs/sha256/a278a9", "image/index.json")
+	if !sameStrings(names, expected) {
+		t.Fatalf("expected the files %v to be bundled, got %v", expected, names)
+	}
+	if m.Name != "myfunc" || m.Runtime != "go" || m.Image != "example.com/alice/myfunc:v1" || m.Version != BundleVersion {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+
+	imported := filepath.Join(dir, "imported")
+	imageDir := filepath.Join(dir, "image")
+	g, err := client.ImportBundle(bundle, imported, imageDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Name != "myfunc" || g.Image != "example.com/alice/myfunc:v1" || g.Root != imported {
+		t.Fatalf("unexpected function imported %+v", g)
+	}
+	for _, name := range []string{"func.yaml", "handle.go", "hack/build.sh"} {
+		content, err := ioutil.ReadFile(filepath.Join(imported, filepath.FromSlash(name)))
+		if err != nil || string(content) != files[name] {
+			t.Fatalf("expected %v to be imported, got %q (%v)", name, content, err)
+		}
+	}
+	if fi, err := os.Stat(filepath.Join(imported, "hack/build.sh")); err != nil || fi.Mode().Perm() != 0755 {
+		t.Fatalf("expected the mode of hack/build.sh to be retained, got %v (%v)", fi.Mode(), err)
+	}
+	for _, name := range []string{"secret.txt", ".git", ".func"} {
+		if _, err := os.Stat(filepath.Join(imported, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %v not to be bundled", name)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(imageDir, "index.json")); err != nil {
+		t.Fatalf("expected the image layout to be imported: %v", err)
+	}
+
+	if _, err = client.ImportBundle(bundle, imported, "", false); !errors.Is(err, ErrProjectExists) {
+		t.Fatalf("expected ErrProjectExists importing over the function, got %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(imported, "handle.go"), []byte("modified"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.ImportBundle(bundle, imported, "", true); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(imported, "handle.go")); string(content) != files["handle.go"] {
+		t.Fatalf("expected the function to be overwritten when forced, got %q", content)
+	}
+
+	if err = client.ExportBundle(context.Background(), f, bundle, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.ImportBundle(bundle, filepath.Join(dir, "other"), imageDir, false); err == nil || !strings.Contains(err.Error(), "includes no image") {
+		t.Fatalf("expected an error importing the image of a bundle without one, got %v", err)
+	}
+}
+
+// TestReadBundleManifest ensures bundles of which the files are not those of
+// their manifest, or which are otherwise malformed, are invalid.
+func TestReadBundleManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "func-bundle-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := "name: myfunc\nruntime: go\n"
+	handle := "package function\n"
+	manifest := func(files map[string]string) string {
+		m := "version: 1\nname: myfunc\nruntime: go\nfiles:\n"
+		for name, content := range files {
+			m += fmt.Sprintf("  %v: sha256:%x\n", name, sha256.Sum256([]byte(content)))
+		}
+		return m
+	}
+	source := map[string]string{"source/func.yaml": config, "source/handle.go": handle}
+
+	tests := []struct {
+		name   string
+		files  map[string]string
+		reason string
+	}{
+		{"valid", map[string]string{"bundle.yaml": manifest(source), "source/func.yaml": config, "source/handle.go": handle}, ""},
+		{"no manifest", map[string]string{"source/func.yaml": config}, "no bundle.yaml"},
+		{"modified", map[string]string{"bundle.yaml": manifest(source), "source/func.yaml": config, "source/handle.go": "package evil\n"}, "source/handle.go is modified"},
+		{"missing", map[string]string{"bundle.yaml": manifest(source), "source/func.yaml": config}, "source/handle.go is missing"},
+		{"undeclared", map[string]string{"bundle.yaml": manifest(source), "source/func.yaml": config, "source/handle.go": handle, "source/evil.sh": "rm -rf /\n"}, "source/evil.sh is not declared"},
+		{"outside", map[string]string{"bundle.yaml": manifest(source), "source/func.yaml": config, "source/handle.go": handle, "source/../../evil.sh": ""}, "unexpected entry"},
+		{"no config", map[string]string{"bundle.yaml": manifest(map[string]string{"source/handle.go": handle}), "source/handle.go": handle}, "has no func.yaml"},
+		{"other function", map[string]string{"bundle.yaml": strings.Replace(manifest(source), "name: myfunc", "name: other", 1), "source/func.yaml": config, "source/handle.go": handle}, "is not that of its manifest"},
+		{"version", map[string]string{"bundle.yaml": strings.Replace(manifest(source), "version: 1", "version: 2", 1), "source/func.yaml": config, "source/handle.go": handle}, "unsupported version 2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bundle := filepath.Join(dir, "bundle.tar.gz")
+			writeArchive(t, bundle, test.files)
+			m, err := ReadBundleManifest(bundle)
+			if test.reason == "" {
+				if err != nil || !reflect.DeepEqual(m.Files, map[string]string{
+					"source/func.yaml": fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(config))),
+					"source/handle.go": fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(handle))),
+				}) {
+					t.Fatalf("expected the bundle to be valid, got %+v (%v)", m, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidBundle) || !strings.Contains(err.Error(), test.reason) {
+				t.Fatalf("expected ErrInvalidBundle as %v, got %v", test.reason, err)
+			}
+		})
+	}
+}
+
+// sameStrings returns whether a and b have the same strings, in any order.
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := map[string]int{}
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		if counts[s]--; counts[s] < 0 {
+			return false
+		}
+	}
+	return true
+}
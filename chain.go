@@ -0,0 +1,111 @@
+package function
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/boson-project/func/utils"
+)
+
+// ValidateNext ensures the next Function of the chain of the named Function,
+// if any, is the valid name of a Function other than itself.
+func ValidateNext(name, next string) error {
+	if next == "" {
+		return nil
+	}
+	if err := utils.ValidateFunctionName(next); err != nil {
+		return err
+	}
+	if next == name {
+		return errors.New("a function can not be the next of its own chain")
+	}
+	return nil
+}
+
+// ValidateDependency ensures the dependency of the named Function is the
+// valid name of a Function other than itself.
+func ValidateDependency(name, dependency string) error {
+	if err := utils.ValidateFunctionName(dependency); err != nil {
+		return err
+	}
+	if dependency == name {
+		return errors.New("a function can not depend on itself")
+	}
+	return nil
+}
+
+// ChainLevels returns the Functions in the levels in which they are to be
+// deployed such that the next of each, if any, is deployed before it: the
+// first of those of which the next is not among the Functions, and each
+// thereafter of those of which the next is of the level before.  The
+// Functions of each level are in the order given.  A chain which loops, such
+// as of two Functions each the next of the other, is an error naming it.
+func ChainLevels(functions []Function) (levels [][]Function, err error) {
+	return leveled(functions, func(f Function) []string {
+		return []string{f.Next}
+	}, "the chain of function '%v' loops: %v")
+}
+
+// DeployLevels returns the Functions in the levels in which they are to be
+// deployed such that both the next of each and those it depends on are
+// deployed before it, as do ChainLevels: each level is of the Functions of
+// which the latest deployed of those among the Functions is of the level
+// before.  Those which are not among the Functions are not ordered.  A cycle,
+// such as of two Functions each depending on the other, is an error naming
+// it.
+func DeployLevels(functions []Function) (levels [][]Function, err error) {
+	return leveled(functions, func(f Function) []string {
+		return append([]string{f.Next}, f.DependsOn...)
+	}, "the functions deployed before function '%v' form a cycle: %v")
+}
+
+// leveled returns the Functions in levels such that those named by the
+// dependencies of each which are among the Functions are of a level before
+// it.  A cycle is an error of the given format, with the name of the
+// Function and its path.
+func leveled(functions []Function, dependencies func(Function) []string, cycle string) (levels [][]Function, err error) {
+	byName := make(map[string]Function, len(functions))
+	for _, f := range functions {
+		byName[f.Name] = f
+	}
+	level := make(map[string]int, len(functions))
+	var depth func(f Function, path []string) (int, error)
+	depth = func(f Function, path []string) (int, error) {
+		if l, ok := level[f.Name]; ok {
+			return l, nil
+		}
+		for i, name := range path {
+			if name == f.Name {
+				return 0, fmt.Errorf(cycle, f.Name, strings.Join(append(path[i:], f.Name), " -> "))
+			}
+		}
+		l := 0
+		for _, name := range dependencies(f) {
+			d, ok := byName[name]
+			if name == "" || !ok {
+				continue
+			}
+			dl, err := depth(d, append(path, f.Name))
+			if err != nil {
+				return 0, err
+			}
+			if dl+1 > l {
+				l = dl + 1
+			}
+		}
+		level[f.Name] = l
+		return l, nil
+	}
+	for _, f := range functions {
+		l, err := depth(f, nil)
+		if err != nil {
+			return nil, err
+		}
+		for len(levels) <= l {
+			levels = append(levels, nil)
+		}
+		levels[l] = append(levels[l], f)
+	}
+	return
+}
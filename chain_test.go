@@ -0,0 +1,108 @@
+// +build !integration
+
+package function
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestChainLevels ensures Functions are leveled such that the next of each is
+// of a level before it, and that a chain which loops is an error.
+func TestChainLevels(t *testing.T) {
+	levels, err := ChainLevels([]Function{
+		{Name: "a", Next: "b"},
+		{Name: "b", Next: "c"},
+		{Name: "c"},
+		{Name: "d", Next: "b"},
+		{Name: "e", Next: "elsewhere"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, level := range levels {
+		var l []string
+		for _, f := range level {
+			l = append(l, f.Name)
+		}
+		names = append(names, strings.Join(l, ","))
+	}
+	if strings.Join(names, " ") != "c,e b a,d" {
+		t.Fatalf("expected the levels 'c,e b a,d', got '%v'", strings.Join(names, " "))
+	}
+
+	_, err = ChainLevels([]Function{{Name: "a", Next: "b"}, {Name: "b", Next: "c"}, {Name: "c", Next: "a"}})
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Fatalf("expected the loop of the chain to be an error, got '%v'", err)
+	}
+}
+
+// TestValidateNext ensures the next of a chain is a valid name of another
+// Function.
+func TestValidateNext(t *testing.T) {
+	tests := []struct {
+		next    string
+		wantErr bool
+	}{
+		{"", false},
+		{"other", false},
+		{"myfunc", true},
+		{"Not_Valid", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateNext("myfunc", tt.next); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateNext(%q) = %v, wantErr %v", tt.next, err, tt.wantErr)
+		}
+	}
+}
+
+// TestDeployLevels ensures Functions are leveled such that both the next of
+// each and those it depends on are of a level before it, and that a cycle is
+// an error.
+func TestDeployLevels(t *testing.T) {
+	levels, err := DeployLevels([]Function{
+		{Name: "api", DependsOn: []string{"db", "cache"}},
+		{Name: "cache"},
+		{Name: "db", DependsOn: []string{"elsewhere"}},
+		{Name: "web", Next: "api", DependsOn: []string{"db"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, level := range levels {
+		var l []string
+		for _, f := range level {
+			l = append(l, f.Name)
+		}
+		names = append(names, strings.Join(l, ","))
+	}
+	if strings.Join(names, " ") != "cache,db api web" {
+		t.Fatalf("expected the levels 'cache,db api web', got '%v'", strings.Join(names, " "))
+	}
+
+	_, err = DeployLevels([]Function{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", Next: "a"}})
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> a") {
+		t.Fatalf("expected the cycle to be an error, got '%v'", err)
+	}
+}
+
+// TestValidateDependency ensures a dependency is a valid name of another
+// Function.
+func TestValidateDependency(t *testing.T) {
+	tests := []struct {
+		dependency string
+		wantErr    bool
+	}{
+		{"other", false},
+		{"", true},
+		{"myfunc", true},
+		{"Not_Valid", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateDependency("myfunc", tt.dependency); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateDependency(%q) = %v, wantErr %v", tt.dependency, err, tt.wantErr)
+		}
+	}
+}
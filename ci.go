@@ -0,0 +1,72 @@
+package function
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/markbates/pkger"
+)
+
+// CISystems for which the CI of a Function may be written when it is
+// created: github, a GitHub Actions workflow, and tekton, a Tekton Pipeline,
+// each of which tests the Function, and builds and deploys it with func.
+var CISystems = []string{"github", "tekton"}
+
+// ErrCINotFound is returned when no CI is found for the CI system of a
+// Function.
+var ErrCINotFound = errors.New("CI not found")
+
+// ciDir is the hidden directory of the CI of each CI system, laid out as
+// [ciDir]/[system], both of the embedded templates and of a repository of
+// templates, which provides its own in place of those embedded.  The CI of a
+// system is written to the root of the Function as a template is, such that
+// its files declared by its ManifestFile are rendered with the Function as
+// data.
+const ciDir = ".ci"
+
+// ValidateCI ensures the CI system, if any, is one of CISystems.
+func ValidateCI(ci string) error {
+	if ci == "" {
+		return nil
+	}
+	for _, s := range CISystems {
+		if s == ci {
+			return nil
+		}
+	}
+	return fmt.Errorf("the CI system must be one of %v", strings.Join(CISystems, ", "))
+}
+
+// writeCI writes the CI of the system to dest: that of the repository of the
+// template, if it provides one, and otherwise that embedded.  It is rendered
+// with the name the Function is given when loaded, if it has none.
+func (t templateWriter) writeCI(system, template, dest string) (err error) {
+	src, accessor, err := t.resolveCI(system, template)
+	if err != nil {
+		return
+	}
+	f := t.function
+	if f.Name == "" {
+		f.Name = derivedName(f.Root, f.ConfigFile)
+	}
+	return write(src, dest, accessor, f, t.onConflict)
+}
+
+// resolveCI of the system to its path, and the accessor of its files.
+func (t templateWriter) resolveCI(system, template string) (string, fileAccessor, error) {
+	if isCustom(template) && t.templates != "" {
+		repo := strings.Split(template, "/")[0]
+		path := filepath.Join(t.templates, repo, ciDir, system)
+		if _, err := os.Stat(path); err == nil {
+			return path, filesystemAccessor{}, nil
+		}
+	}
+	path := filepath.Join("/templates", ciDir, system)
+	if _, err := pkger.Stat(path); err != nil {
+		return "", nil, fmt.Errorf("%w: '%v'", ErrCINotFound, system)
+	}
+	return path, embeddedAccessor{}, nil
+}
@@ -0,0 +1,100 @@
+// +build !integration
+
+package function_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	fn "github.com/boson-project/func"
+)
+
+// TestCreateCI ensures the CI of the system of a Function is written when it
+// is created, rendered for its runtime, and recorded in its config, and that
+// a repository of templates provides its own in place of that embedded.
+func TestCreateCI(t *testing.T) {
+	root := "testdata/example.com/testCreateCI"
+	defer using(t, root)()
+
+	client := fn.New(fn.WithRegistry(TestRegistry))
+	if err := client.Create(fn.Function{Root: root, Runtime: "go", CI: "github"}); err != nil {
+		t.Fatal(err)
+	}
+	workflow, err := ioutil.ReadFile(filepath.Join(root, ".github/workflows/ci.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"name: testCreateCI\n", "actions/setup-go", "run: go test ./...\n", "${{ secrets.REGISTRY }}", "run: kn func deploy --build=false\n"} {
+		if !strings.Contains(string(workflow), expected) {
+			t.Fatalf("expected the workflow to contain %q, got:\n%s", expected, workflow)
+		}
+	}
+	// Secrets are passed to the scripts of steps in their env, never pasted
+	// into them, where they would be interpreted by the shell.
+	for _, line := range strings.Split(string(workflow), "\n") {
+		if strings.Contains(line, "secrets.") && !regexp.MustCompile(`^\s+[A-Z_]+: \$\{\{ secrets\.[A-Z_]+ \}\}$`).MatchString(line) {
+			t.Fatalf("expected the secrets to be passed in env, got %q", line)
+		}
+	}
+	for _, file := range []string{".github/workflows/ci.yaml.tmpl", fn.ManifestFile} {
+		if _, err := os.Stat(filepath.Join(root, file)); !os.IsNotExist(err) {
+			t.Fatalf("expected %v not to be written", file)
+		}
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.CI != "github" {
+		t.Fatalf("expected the CI 'github' to be recorded, got '%v'", f.CI)
+	}
+
+	tekton := "testdata/example.com/testCreateCITekton"
+	defer using(t, tekton)()
+	if err := client.Create(fn.Function{Root: tekton, Runtime: "python", CI: "tekton"}); err != nil {
+		t.Fatal(err)
+	}
+	pipeline, err := ioutil.ReadFile(filepath.Join(tekton, ".tekton/pipeline.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(pipeline), "image: docker.io/library/python:3.9") || !strings.Contains(string(pipeline), "python -m unittest") {
+		t.Fatalf("expected the tests to be run with python, got:\n%s", pipeline)
+	}
+	if !strings.Contains(string(pipeline), `git fetch --depth 1 "$GIT_URL" "$GIT_REVISION"`) {
+		t.Fatalf("expected the revision to be fetched, such that it may be a commit, got:\n%s", pipeline)
+	}
+
+	custom := "testdata/example.com/testCreateCICustom"
+	defer using(t, custom)()
+	client = fn.New(fn.WithRepositories("testdata/repositories"))
+	if err := client.Create(fn.Function{Root: custom, Runtime: "test", Template: "customProvider/tpla", CI: "github"}); err != nil {
+		t.Fatal(err)
+	}
+	if workflow, err = ioutil.ReadFile(filepath.Join(custom, ".github/workflows/ci.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(workflow), "name: custom") {
+		t.Fatalf("expected the workflow of the repository, got:\n%s", workflow)
+	}
+}
+
+// TestCreateCIInvalid ensures a Function is not created with an unknown CI
+// system.
+func TestCreateCIInvalid(t *testing.T) {
+	root := "testdata/example.com/testCreateCIInvalid"
+	defer using(t, root)()
+
+	err := fn.New(fn.WithRegistry(TestRegistry)).Create(fn.Function{Root: root, CI: "jenkins"})
+	if err == nil || !strings.Contains(err.Error(), "github, tekton") {
+		t.Fatalf("expected an invalid CI system, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, fn.ConfigFile)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected no function to be created")
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -21,34 +23,178 @@ const (
 	// one implementation of each supported funciton sinagure.  Currently that
 	// includes an HTTP Handler ("http") and Cloud Events handler ("events")
 	DefaultTemplate = "http"
+
+	// RepositoriesDir is the directory of template repositories within the
+	// config directory of the client (see WithConfigDir).
+	RepositoriesDir = "repositories"
 )
 
 // Client for managing Function instances.
 type Client struct {
-	verbose          bool     // print verbose logs
-	builder          Builder  // Builds a runnable image from Function source
-	pusher           Pusher   // Pushes the image assocaited with a Function.
-	deployer         Deployer // Deploys or Updates a Function
-	runner           Runner   // Runs the Function locally
-	remover          Remover  // Removes remote services
-	lister           Lister   // Lists remote services
+	verbose          bool                // print verbose logs
+	builder          Builder             // Builds a runnable image from Function source
+	dockerfile       Builder             // Builds Functions of the DockerfileBuilder
+	pusher           Pusher              // Pushes the image assocaited with a Function.
+	credentials      CredentialsProvider // Credentials of registries, nil for the pusher's own
+	deployer         Deployer            // Deploys or Updates a Function
+	runner           Runner              // Runs the Function locally
+	remover          Remover             // Removes remote services
+	pipelines        PipelinesProvider   // Builds on the cluster
+	lister           Lister              // Lists remote services
 	describer        Describer
-	dnsProvider      DNSProvider      // Provider of DNS services
-	repositories     string           // path to extensible template repositories
-	registry         string           // default registry for OCI image tags
-	progressListener ProgressListener // progress listener
-	emitter          Emitter          // Emits CloudEvents to functions
+	revisionLister   RevisionLister    // Lists the revisions of described Functions
+	splitter         TrafficSplitter   // Splits traffic, such as to roll back
+	dnsProvider      DNSProvider       // Provider of DNS services
+	repositories     string            // path to extensible template repositories
+	repositoriesSet  bool              // repositories given explicitly
+	configDir        string            // directory of config, such as repositories
+	persist          bool              // write to the config directory
+	registry         string            // default registry for OCI image tags
+	registryMirrors  map[string]string // mirrors of the images built with and deployed
+	progressListener ProgressListener  // progress listener
+	emitter          Emitter           // Emits CloudEvents to functions
+	buildCache       BuildCache        // Reuse of layers across builds
+	buildTimeout     time.Duration     // bounds the builder, zero for none
+	updateBuilder    bool              // re-resolve the builder's digest
+	sourceLabels     bool              // label images with their source
+	reproducible     bool              // normalize timestamps of builds
+	exporter         Exporter          // Exports built images to disk
+	outputDir        string            // directory into which images are exported
+	outputFormat     string            // format of the images exported
+	sbomExporter     SBOMExporter      // Exports the SBOMs of built images
+	sbomDir          string            // directory into which SBOMs are exported
+	sbomFormat       string            // format of the SBOMs exported
+	layoutExporter   LayoutExporter    // Exports images as OCI image layouts
+	force            bool              // overwrite existing files on create
+	onConflict       ConflictResolver  // resolves existing files on create
+	managedOnly      bool              // write only managed files on create
+	style            bool              // write the style config of the runtime on create
+	licenseAuthor    string            // author of the copyright of the LICENSE written on create
+	versionFile      bool              // write the version manager file of the runtime version on create
+	version          string            // version of func, installed by dev containers written on create
+	push             bool              // push the image before deploying
+	status           bool              // record the status of deploys
+	configFile       string            // name of the config file of Functions
+	plan             *Plan             // populated in place of making changes
+	environment      string            // overlay merged over deployed Functions
+	deployEnvs       Envs              // envs merged over deployed Functions
+	telemetry        Telemetry         // records operations, if opted in
 }
 
 // ErrNotBuilt indicates the Function has not yet been built.
 var ErrNotBuilt = errors.New("not built")
 
+// ErrGitRequired indicates the Function has no git repository from which
+// to build it on the cluster.
+var ErrGitRequired = errors.New("git repository of the function's source required")
+
+// ErrPersistenceDisabled indicates the client may not write to its config
+// directory, such as to add a template repository (see WithoutPersistence).
+var ErrPersistenceDisabled = errors.New("writing to the config directory is disabled")
+
+// ErrRollbackUnsupported indicates the client has no traffic splitter with
+// which to roll back deployed Functions.
+var ErrRollbackUnsupported = errors.New("rolling back is not supported without a traffic splitter")
+
+// ErrBuildTimeout indicates the build of the Function did not complete
+// within the build timeout of the client, and was cancelled.
+var ErrBuildTimeout = errors.New("build timed out")
+
+// ErrNoSBOM indicates the image of the Function has no software bill of
+// materials of the format requested, such as when its builder generates none.
+var ErrNoSBOM = errors.New("the builder did not produce an SBOM")
+
 // Builder of Function source to runnable image.
 type Builder interface {
 	// Build a Function project with source located at path.
 	Build(context.Context, Function) error
 }
 
+// BuildCache configures the reuse of cached content across builds of a
+// Function, such that subsequent builds need not recreate it.
+type BuildCache struct {
+	// Dir in which cached content is kept between builds.  If empty, the
+	// Builder's default cache location is used.
+	Dir string
+	// Disabled forces a clean build, clearing any previously cached content.
+	Disabled bool
+}
+
+// CachingBuilder is a Builder which supports configuring its build cache.
+type CachingBuilder interface {
+	Builder
+	// BuildWithCache builds a Function using the given cache configuration.
+	BuildWithCache(context.Context, Function, BuildCache) error
+}
+
+// PinningBuilder is a Builder whose builder images are resolved to their
+// digest, to which the builds of a Function are pinned.
+type PinningBuilder interface {
+	Builder
+	// ResolveBuilder returns the digest of the builder image of the Function
+	// in its registry, such as "sha256:a278a9...".
+	ResolveBuilder(context.Context, Function) (string, error)
+}
+
+// Exporter of a Function image to the local filesystem.
+type Exporter interface {
+	// Export the image of the Function within dir in the given format (one
+	// of ImageFormats).  Returns the path of the archive or layout written.
+	Export(ctx context.Context, f Function, format, dir string) (string, error)
+}
+
+// SBOMExporter of the software bill of materials of a Function image, as
+// generated by the buildpacks which built it, to the local filesystem.
+type SBOMExporter interface {
+	// ExportSBOM writes the SBOM documents of the image of the Function in
+	// the given format (one of SBOMFormats) within dir.  Returns the paths
+	// of the documents written, or ErrNoSBOM if the image has none.
+	ExportSBOM(ctx context.Context, f Function, format, dir string) ([]string, error)
+}
+
+// LayoutExporter of a Function image to the local filesystem as an OCI image
+// layout, such as to include it in the bundle of the Function.
+type LayoutExporter interface {
+	// ExportLayout writes the image of the Function as an OCI image layout
+	// in dir.
+	ExportLayout(ctx context.Context, f Function, dir string) error
+}
+
+// ImageFormats in which the image of a Function may be saved: a
+// docker-archive tarball, as produced by `docker save`, or an OCI image
+// layout.  The first is the default.
+var ImageFormats = []string{"docker-archive", "oci"}
+
+// ValidateImageFormat ensures the format, if any, is one of ImageFormats.
+func ValidateImageFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, f := range ImageFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("the image format must be one of %v", strings.Join(ImageFormats, ", "))
+}
+
+// SBOMFormats in which the software bill of materials of an image may be
+// exported.  The first is the default.
+var SBOMFormats = []string{"cyclonedx", "spdx", "syft"}
+
+// ValidateSBOMFormat ensures the format, if any, is one of SBOMFormats.
+func ValidateSBOMFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, f := range SBOMFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("the SBOM format must be one of %v", strings.Join(SBOMFormats, ", "))
+}
+
 // Pusher of Function image to a registry.
 type Pusher interface {
 	// Push the image of the Function.
@@ -56,17 +202,53 @@ type Pusher interface {
 	Push(ctx context.Context, f Function) (string, error)
 }
 
+// AuthenticatingPusher is a Pusher which supports authenticating to
+// registries with the credentials of a given provider.
+type AuthenticatingPusher interface {
+	Pusher
+	// PushWithCredentials pushes the image of the Function, authenticating
+	// with the credentials provided for its registry.
+	PushWithCredentials(context.Context, Function, CredentialsProvider) (string, error)
+}
+
+// Credentials with which to authenticate to a container registry.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// CredentialsProvider of the credentials of container registries, such that
+// they may be resolved differently per environment, for example from a
+// cloud provider's credential helper.
+type CredentialsProvider interface {
+	// Credentials for the registry, such as "quay.io".  Empty credentials
+	// are anonymous.
+	Credentials(ctx context.Context, registry string) (Credentials, error)
+}
+
+// CredentialsProviderFunc is a function which implements CredentialsProvider.
+type CredentialsProviderFunc func(ctx context.Context, registry string) (Credentials, error)
+
+// Credentials for the registry, as returned by the function.
+func (f CredentialsProviderFunc) Credentials(ctx context.Context, registry string) (Credentials, error) {
+	return f(ctx, registry)
+}
+
 type Status int
 
 const (
 	Failed Status = iota
 	Deployed
 	Updated
+	// Unchanged by a deploy which would have changed nothing.
+	Unchanged
 )
 
 type DeploymentResult struct {
 	Status Status
 	URL    string
+	// Revision created by the deploy, if known.
+	Revision string
 }
 
 // Deployer of Function source to running status.
@@ -81,10 +263,23 @@ type Runner interface {
 	Run(context.Context, Function) error
 }
 
+// PipelinesProvider builds Functions on the cluster, from their source in a
+// git repository or a source archive, pushing the resultant image to the
+// registry.
+type PipelinesProvider interface {
+	// Run the build of the Function, until complete.
+	Run(ctx context.Context, f Function) error
+	// RunArchive builds the Function from the gzipped tarball of its source
+	// at the given path, uploaded to the cluster, until complete.
+	RunArchive(ctx context.Context, f Function, archive string) error
+}
+
 // Remover of deployed services.
 type Remover interface {
-	// Remove the Function from remote.
-	Remove(ctx context.Context, name string) error
+	// Remove the named Function from remote, from the given namespace, or
+	// that of the Remover if empty.  Removing a Function which is not
+	// deployed is not an error.
+	Remove(ctx context.Context, name, namespace string) error
 }
 
 // Lister of deployed services.
@@ -99,6 +294,26 @@ type ListItem struct {
 	Runtime   string `json:"runtime" yaml:"runtime"`
 	URL       string `json:"url" yaml:"url"`
 	Ready     string `json:"ready" yaml:"ready"`
+
+	// The details below are of the latest ready Revision of the Function,
+	// and are only listed when requested, such as with list -o wide.
+
+	// Revision is the name of the latest ready Revision.
+	Revision string `json:"revision,omitempty" yaml:"revision,omitempty"`
+
+	// ImageDigest of the image of the latest ready Revision.
+	ImageDigest string `json:"imageDigest,omitempty" yaml:"imageDigest,omitempty"`
+
+	// Deployed is when the latest ready Revision was created.
+	Deployed *time.Time `json:"deployed,omitempty" yaml:"deployed,omitempty"`
+
+	// ReadyReplicas of the latest ready Revision.
+	ReadyReplicas int32 `json:"readyReplicas,omitempty" yaml:"readyReplicas,omitempty"`
+
+	// MinScale and MaxScale bounds of the Function, empty where that of the
+	// cluster applies.
+	MinScale string `json:"minScale,omitempty" yaml:"minScale,omitempty"`
+	MaxScale string `json:"maxScale,omitempty" yaml:"maxScale,omitempty"`
 }
 
 // ProgressListener is notified of task progress.
@@ -123,12 +338,74 @@ type Describer interface {
 	Describe(ctx context.Context, name string) (description Description, err error)
 }
 
+// WatchingDescriber is a Describer which watches the running state of a
+// Function, describing it anew each time it changes.
+type WatchingDescriber interface {
+	Describer
+	// Watch the named Function, invoking changed with its description when
+	// watched and each time it changes thereafter, until changed returns true
+	// or the context is done.
+	Watch(ctx context.Context, name string, changed func(Description) bool) error
+}
+
 type Description struct {
-	Name          string         `json:"name" yaml:"name"`
-	Image         string         `json:"image" yaml:"image"`
-	Namespace     string         `json:"namespace" yaml:"namespace"`
-	Routes        []string       `json:"routes" yaml:"routes"`
-	Subscriptions []Subscription `json:"subscriptions" yaml:"subscriptions"`
+	Name            string          `json:"name" yaml:"name"`
+	Image           string          `json:"image" yaml:"image"`
+	Namespace       string          `json:"namespace" yaml:"namespace"`
+	Routes          []string        `json:"routes" yaml:"routes"`
+	Ready           string          `json:"ready,omitempty" yaml:"ready,omitempty"`
+	Conditions      []string        `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	Revision        string          `json:"revision,omitempty" yaml:"revision,omitempty"`
+	ServiceAccount  string          `json:"serviceAccount,omitempty" yaml:"serviceAccount,omitempty"`
+	ImagePullPolicy string          `json:"imagePullPolicy,omitempty" yaml:"imagePullPolicy,omitempty"`
+	Mesh            string          `json:"mesh,omitempty" yaml:"mesh,omitempty"`
+	Port            int             `json:"port,omitempty" yaml:"port,omitempty"`
+	Metrics         string          `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+	Tracing         string          `json:"tracing,omitempty" yaml:"tracing,omitempty"`
+	IngressClass    string          `json:"ingressClass,omitempty" yaml:"ingressClass,omitempty"`
+	LivenessPath    string          `json:"livenessPath,omitempty" yaml:"livenessPath,omitempty"`
+	ReadinessPath   string          `json:"readinessPath,omitempty" yaml:"readinessPath,omitempty"`
+	RequestTimeout  int64           `json:"requestTimeout,omitempty" yaml:"requestTimeout,omitempty"`
+	ChangeCause     string          `json:"changeCause,omitempty" yaml:"changeCause,omitempty"`
+	Locked          bool            `json:"locked,omitempty" yaml:"locked,omitempty"`
+	Invocation      string          `json:"invocation,omitempty" yaml:"invocation,omitempty"`
+	Volumes         []Volume        `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+	InitContainers  []InitContainer `json:"initContainers,omitempty" yaml:"initContainers,omitempty"`
+	Subscriptions   []Subscription  `json:"subscriptions" yaml:"subscriptions"`
+	Triggers        []Trigger       `json:"triggers,omitempty" yaml:"triggers,omitempty"`
+	Sources         []Source        `json:"sources,omitempty" yaml:"sources,omitempty"`
+
+	ExtendedResources []ExtendedResource `json:"extendedResources,omitempty" yaml:"extendedResources,omitempty"`
+	Autoscaling       Autoscaling        `json:"autoscaling" yaml:"autoscaling"`
+
+	// Chain of the Function, the names of the Functions to which its replies
+	// are sent in order, from the Function itself, if it is chained.
+	Chain []string `json:"chain,omitempty" yaml:"chain,omitempty"`
+
+	// Revisions of the Function, newest first, with the percent of its
+	// traffic routed to each.
+	Revisions []Revision `json:"revisions,omitempty" yaml:"revisions,omitempty"`
+
+	// MirrorRewrites of the images of the Function by its registry mirrors,
+	// each as "image -> mirrored".
+	MirrorRewrites []string `json:"mirrorRewrites,omitempty" yaml:"mirrorRewrites,omitempty"`
+}
+
+// Autoscaling of a deployed Function, as set on its revisions.  Settings
+// which are not set are empty, those of the cluster applying.
+type Autoscaling struct {
+	Min             string `json:"min,omitempty" yaml:"min,omitempty"`
+	Max             string `json:"max,omitempty" yaml:"max,omitempty"`
+	Window          string `json:"window,omitempty" yaml:"window,omitempty"`
+	ScaleDownDelay  string `json:"scaleDownDelay,omitempty" yaml:"scaleDownDelay,omitempty"`
+	RetentionPeriod string `json:"retentionPeriod,omitempty" yaml:"retentionPeriod,omitempty"`
+}
+
+// ExtendedResource of which a deployed Function is allocated a quantity, such
+// as the accelerator nvidia.com/gpu.
+type ExtendedResource struct {
+	Name     string `json:"name" yaml:"name"`
+	Quantity string `json:"quantity" yaml:"quantity"`
 }
 
 type Subscription struct {
@@ -137,6 +414,69 @@ type Subscription struct {
 	Broker string `json:"broker" yaml:"broker"`
 }
 
+// Trigger which subscribes a deployed Function to the events of a broker
+// matching its filters, described in detail, such as for debugging why
+// events do not reach the Function.
+type Trigger struct {
+	Name    string          `json:"name" yaml:"name"`
+	Broker  string          `json:"broker" yaml:"broker"`
+	Filters []TriggerFilter `json:"filters" yaml:"filters"`
+	// Ready status of the Trigger: True, False or Unknown.
+	Ready string `json:"ready" yaml:"ready"`
+	// Reason the Trigger is not ready, if any.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// Source of events, such as a PingSource or an ApiServerSource, of which a
+// deployed Function is the sink.
+type Source struct {
+	Kind string `json:"kind" yaml:"kind"`
+	Name string `json:"name" yaml:"name"`
+	// Ready status of the Source: True, False or Unknown.
+	Ready string `json:"ready" yaml:"ready"`
+}
+
+// TriggerFilter of the events delivered by a Trigger: those with the given
+// value of the CloudEvent attribute.
+type TriggerFilter struct {
+	Attribute string `json:"attribute" yaml:"attribute"`
+	Value     string `json:"value" yaml:"value"`
+}
+
+// Logger streams the logs of deployed Functions.
+type Logger interface {
+	// Logs of the deployed Function of the given name, written to out.  When
+	// following, this returns once the context is canceled.
+	Logs(ctx context.Context, name string, out io.Writer, options LogsOptions) error
+}
+
+// LogsOptions of the logs streamed by a Logger.
+type LogsOptions struct {
+	// Follow the logs as they are written, rather than returning once those
+	// written so far are streamed.
+	Follow bool
+
+	// Since is the age of the oldest logs streamed.  Zero streams all.
+	Since time.Duration
+
+	// Tail is the number of most recent lines streamed.  Negative streams all.
+	Tail int64
+}
+
+// TrafficSplitter splits the traffic of deployed Functions between their
+// Revisions.
+type TrafficSplitter interface {
+	// Split the traffic of the deployed Function of the given name as given,
+	// returning the traffic of the Function as split.
+	Split(ctx context.Context, name string, split TrafficSplit) ([]TrafficTarget, error)
+}
+
+// RevisionLister lists the Revisions of deployed Functions.
+type RevisionLister interface {
+	// Revisions of the deployed Function of the given name, newest first.
+	Revisions(ctx context.Context, name string) ([]Revision, error)
+}
+
 // DNSProvider exposes DNS services necessary for serving the Function.
 type DNSProvider interface {
 	// Provide the given name by routing requests to address.
@@ -153,20 +493,35 @@ func New(options ...Option) *Client {
 	// Instantiate client with static defaults.
 	c := &Client{
 		builder:          &noopBuilder{output: os.Stdout},
+		dockerfile:       &noopBuilder{output: os.Stdout},
 		pusher:           &noopPusher{output: os.Stdout},
+		exporter:         &noopExporter{output: os.Stdout},
+		sbomExporter:     &noopSBOMExporter{output: os.Stdout},
+		layoutExporter:   &noopLayoutExporter{output: os.Stdout},
 		deployer:         &noopDeployer{output: os.Stdout},
 		runner:           &noopRunner{output: os.Stdout},
 		remover:          &noopRemover{output: os.Stdout},
+		pipelines:        &noopPipelinesProvider{output: os.Stdout},
 		lister:           &noopLister{output: os.Stdout},
 		dnsProvider:      &noopDNSProvider{output: os.Stdout},
 		progressListener: &noopProgressListener{},
 		emitter:          &noopEmitter{},
+		push:             true,
+		sourceLabels:     true,
+		status:           true,
+		persist:          true,
 	}
 
 	// Apply passed options, which take ultimate precidence.
 	for _, o := range options {
 		o(c)
 	}
+
+	// Template repositories are those of the config directory unless given
+	// explicitly, including as none.
+	if c.configDir != "" && !c.repositoriesSet {
+		c.repositories = filepath.Join(c.configDir, RepositoriesDir)
+	}
 	return c
 }
 
@@ -188,6 +543,226 @@ func WithBuilder(d Builder) Option {
 	}
 }
 
+// WithDockerfileBuilder provides the concrete implementation of the builder
+// of Functions whose Builder is the DockerfileBuilder, which are built from
+// their Dockerfile rather than by the builder of WithBuilder.
+func WithDockerfileBuilder(d Builder) Option {
+	return func(c *Client) {
+		c.dockerfile = d
+	}
+}
+
+// WithBuildCache sets the cache configuration passed to builders which
+// implement CachingBuilder.  It is ignored by other builders.
+func WithBuildCache(cache BuildCache) Option {
+	return func(c *Client) {
+		c.buildCache = cache
+	}
+}
+
+// WithBuildTimeout bounds the build of a Function by its builder, which is
+// cancelled via its context on expiry.  Zero, the default, is no timeout.
+func WithBuildTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.buildTimeout = timeout
+	}
+}
+
+// WithUpdateBuilder re-resolves the digest of the builder image of a
+// Function when built, rather than building with that to which it is pinned,
+// such as to update to the latest of its tag.
+func WithUpdateBuilder(update bool) Option {
+	return func(c *Client) {
+		c.updateBuilder = update
+	}
+}
+
+// WithSourceLabels toggles labeling the images built with the OCI labels of
+// their source (by default true): the URL and HEAD commit of the git
+// repository of the Function's source, and the time of its source (see
+// WithReproducible).  Applies to builders which support labels (see
+// LabelingBuilder), as do both the buildpacks and Dockerfile builders.
+func WithSourceLabels(labels bool) Option {
+	return func(c *Client) {
+		c.sourceLabels = labels
+	}
+}
+
+// WithReproducible toggles reproducible builds, of which the timestamps are
+// normalized to the time of SOURCE_DATE_EPOCH, or else the commit time of the
+// HEAD of the git repository of the Function's source, such that builds of
+// the same source yield the same image.  The time is provided to the build as
+// its SOURCE_DATE_EPOCH env, unless the Function sets it.  Whether or not
+// reproducible, it is the created label of the image (see WithSourceLabels).
+func WithReproducible(reproducible bool) Option {
+	return func(c *Client) {
+		c.reproducible = reproducible
+	}
+}
+
+// WithExporter provides the concrete implementation of an image exporter.
+func WithExporter(e Exporter) Option {
+	return func(c *Client) {
+		c.exporter = e
+	}
+}
+
+// WithOutputDir sets the directory into which the image of a Function is
+// exported after being built, in the given format (one of ImageFormats).  If
+// not provided, the image is not exported.
+func WithOutputDir(dir, format string) Option {
+	return func(c *Client) {
+		c.outputDir = dir
+		c.outputFormat = format
+	}
+}
+
+// WithSBOMExporter provides the concrete implementation of an exporter of
+// the software bill of materials of images.
+func WithSBOMExporter(e SBOMExporter) Option {
+	return func(c *Client) {
+		c.sbomExporter = e
+	}
+}
+
+// WithLayoutExporter provides the concrete implementation of an exporter of
+// images as OCI image layouts, with which they are bundled.
+func WithLayoutExporter(e LayoutExporter) Option {
+	return func(c *Client) {
+		c.layoutExporter = e
+	}
+}
+
+// WithSBOM sets the directory into which the software bill of materials of
+// the image of a Function is exported after being built, in the given
+// format.  If not provided, no SBOM is exported.
+func WithSBOM(dir, format string) Option {
+	return func(c *Client) {
+		c.sbomDir = dir
+		c.sbomFormat = format
+	}
+}
+
+// WithForce toggles overwriting existing files when creating a Function,
+// such as those of a previous creation which did not complete.
+func WithForce(force bool) Option {
+	return func(c *Client) {
+		c.force = force
+	}
+}
+
+// ConflictResolver decides whether a file of the template which already exists
+// in the root of a Function being created is overwritten, returning false for
+// it to be left unchanged.  The path is relative to the Function root, and is
+// provided with the contents of both the existing file and that of the
+// template.  An error aborts the creation.
+type ConflictResolver func(path string, existing, template []byte) (overwrite bool, err error)
+
+// WithManagedFilesOnly sets whether create writes only the files declared as
+// managed by the manifest of the template, such as its build config,
+// overwriting them, and never touches any other.  The config of a Function
+// already created is kept, other than its builders, which are those of the
+// template.  Creating from a template which declares no managed files is
+// ErrTemplateNotManaged.  See ManifestFile.
+func WithManagedFilesOnly(m bool) Option {
+	return func(c *Client) {
+		c.managedOnly = m
+	}
+}
+
+// WithStyle sets whether create writes the style config of the runtime of
+// the Function, its .editorconfig and formatter config, where those files
+// do not already exist.  It is not written when writing only the managed
+// files of a template.
+func WithStyle(s bool) Option {
+	return func(c *Client) {
+		c.style = s
+	}
+}
+
+// WithRuntimeVersionFile sets whether create writes the file of the version
+// manager of the runtime of the Function, such as an .nvmrc for Node, of its
+// RuntimeVersion, if any, where that file does not already exist, such that
+// the version of the runtime used in local development is that built.
+func WithRuntimeVersionFile(v bool) Option {
+	return func(c *Client) {
+		c.versionFile = v
+	}
+}
+
+// WithVersion sets the version of func, such as "v0.16.0", which the dev
+// containers written on create install, such that those developing a
+// Function use the func with which it was created.  Without, or for a
+// development build ("tip"), they install the latest release.
+func WithVersion(v string) Option {
+	return func(c *Client) {
+		c.version = v
+	}
+}
+
+// WithLicenseAuthor sets the author of the copyright of the LICENSE written
+// when creating a Function with a License.  Defaults to the user.name of git.
+func WithLicenseAuthor(author string) Option {
+	return func(c *Client) {
+		c.licenseAuthor = author
+	}
+}
+
+// WithConflictResolver provides the resolver of the existing files of the
+// template when creating a Function in a directory which is not empty, such
+// as to prompt for each.  As with WithForce, existing files are permitted.
+func WithConflictResolver(r ConflictResolver) Option {
+	return func(c *Client) {
+		c.onConflict = r
+	}
+}
+
+// WithPush toggles pushing the Function's image to its registry before it is
+// deployed (by default true).  Without, the image is expected to have been
+// pushed already, such as by CI, and is deployed by its tag.
+func WithPush(push bool) Option {
+	return func(c *Client) {
+		c.push = push
+	}
+}
+
+// WithStatus toggles recording the status of the Function in its config
+// file after each successful deploy (by default true).  Without, the config
+// file is not written by deploys other than to record the image digest
+// pushed, such as for read-only workflows.
+func WithStatus(status bool) Option {
+	return func(c *Client) {
+		c.status = status
+	}
+}
+
+// WithEnvironment sets the name of the environment to which Functions are
+// deployed, the overlay of which is merged over their settings when deployed
+// (see Function.WithEnvironment).  Empty, the default, deploys them as is.
+func WithEnvironment(name string) Option {
+	return func(c *Client) {
+		c.environment = name
+	}
+}
+
+// WithDeployEnvs sets envs merged over those of Functions when deployed,
+// after the overlay of any environment, without being written to their
+// config, such as those read from an env file (see ReadEnvFile).
+func WithDeployEnvs(envs Envs) Option {
+	return func(c *Client) {
+		c.deployEnvs = envs
+	}
+}
+
+// WithPlan sets the Client to plan the changes its methods would make,
+// populating the given Plan in place of making them, such as for a dry run
+// (see Plan).  Nil, the default, makes the changes.
+func WithPlan(p *Plan) Option {
+	return func(c *Client) {
+		c.plan = p
+	}
+}
+
 // WithPusher provides the concrete implementation of a pusher.
 func WithPusher(d Pusher) Option {
 	return func(c *Client) {
@@ -195,6 +770,15 @@ func WithPusher(d Pusher) Option {
 	}
 }
 
+// WithCredentialsProvider provides the credentials with which images are
+// pushed to registries, for pushers which implement AuthenticatingPusher.
+// By default such pushers resolve credentials themselves.
+func WithCredentialsProvider(cp CredentialsProvider) Option {
+	return func(c *Client) {
+		c.credentials = cp
+	}
+}
+
 // WithDeployer provides the concrete implementation of a deployer.
 func WithDeployer(d Deployer) Option {
 	return func(c *Client) {
@@ -202,6 +786,14 @@ func WithDeployer(d Deployer) Option {
 	}
 }
 
+// WithTrafficSplitter provides the concrete implementation of a splitter of
+// the traffic of deployed Functions, with which they are rolled back.
+func WithTrafficSplitter(s TrafficSplitter) Option {
+	return func(c *Client) {
+		c.splitter = s
+	}
+}
+
 // WithRunner provides the concrete implementation of a deployer.
 func WithRunner(r Runner) Option {
 	return func(c *Client) {
@@ -216,6 +808,14 @@ func WithRemover(r Remover) Option {
 	}
 }
 
+// WithPipelinesProvider provides the concrete implementation of the builds
+// run on the cluster.
+func WithPipelinesProvider(p PipelinesProvider) Option {
+	return func(c *Client) {
+		c.pipelines = p
+	}
+}
+
 // WithLister provides the concrete implementation of a lister.
 func WithLister(l Lister) Option {
 	return func(c *Client) {
@@ -230,6 +830,14 @@ func WithDescriber(describer Describer) Option {
 	}
 }
 
+// WithRevisionLister provides the concrete implementation of a lister of the
+// Revisions of deployed Functions, with which they are described.
+func WithRevisionLister(l RevisionLister) Option {
+	return func(c *Client) {
+		c.revisionLister = l
+	}
+}
+
 // WithProgressListener provides a concrete implementation of a listener to
 // be notified of progress updates.
 func WithProgressListener(p ProgressListener) Option {
@@ -247,12 +855,54 @@ func WithDNSProvider(provider DNSProvider) Option {
 	}
 }
 
+// WithConfigFile sets the name of the config file in the root of each
+// Function from which its configuration is loaded, and to which it is
+// written, in place of func.yaml.  Functions created are given this name
+// unless that of the Function created is set explicitly.
+func WithConfigFile(file string) Option {
+	return func(c *Client) {
+		c.configFile = file
+	}
+}
+
 // WithRepositories sets the location to use for extensible template repositories.
 // Extensible template repositories are additional templates that exist on disk and are
 // not built into the binary.
 func WithRepositories(repositories string) Option {
 	return func(c *Client) {
 		c.repositories = repositories
+		c.repositoriesSet = true
+	}
+}
+
+// WithConfigDir sets the config directory of the client, such as a temporary
+// directory of tests which do not share state.  Its template repositories
+// are those of the "repositories" directory within it, unless provided with
+// WithRepositories.  By default the client has no config directory.
+func WithConfigDir(path string) Option {
+	return func(c *Client) {
+		c.configDir = path
+	}
+}
+
+// WithoutPersistence disables writing to the config directory, such as in
+// sandboxed or ephemeral environments: template repositories are only read,
+// their being added, updated or removed failing with ErrPersistenceDisabled,
+// and a template repository pinned to a ref is checked out in a temporary
+// directory rather than within the repositories directory.
+func WithoutPersistence() Option {
+	return func(c *Client) {
+		c.persist = false
+	}
+}
+
+// WithTelemetry opts in to recording an event of each operation of the client
+// (create, build, deploy, run-pipeline and remove) with the given telemetry,
+// such as to monitor their durations and success.  Disabled by default.
+// Events are anonymous; see Telemetry.
+func WithTelemetry(t Telemetry) Option {
+	return func(c *Client) {
+		c.telemetry = t
 	}
 }
 
@@ -266,6 +916,17 @@ func WithRegistry(registry string) Option {
 	}
 }
 
+// WithRegistryMirrors sets the registry mirrors of the images with which
+// Functions are built and deployed, by the prefix of the images which are
+// pulled instead from their mirror (see MirrorImage).  They take precedence
+// over those of the same prefix of a Function's config, and are not written
+// to it.
+func WithRegistryMirrors(mirrors map[string]string) Option {
+	return func(c *Client) {
+		c.registryMirrors = mirrors
+	}
+}
+
 // WithEmitter sets a CloudEvent emitter on the client which is capable of sending
 // a CloudEvent to an arbitrary function endpoint
 func WithEmitter(e Emitter) Option {
@@ -287,7 +948,7 @@ func (c *Client) New(ctx context.Context, cfg Function) (err error) {
 	}
 
 	// Load the now-initialized Function.
-	f, err := NewFunction(cfg.Root)
+	f, err := NewFunctionFromFile(cfg.Root, c.configFileOf(cfg))
 	if err != nil {
 		return
 	}
@@ -325,6 +986,10 @@ func (c *Client) New(ctx context.Context, cfg Function) (err error) {
 // Create a new Function project locally using the settings provided on a
 // Function object.
 func (c *Client) Create(cfg Function) (err error) {
+	if c.plan != nil {
+		return c.planCreate(cfg)
+	}
+	defer func(start time.Time) { c.record("create", cfg, start, err) }(time.Now())
 
 	// Create project root directory, if it doesn't already exist
 	if err = os.MkdirAll(cfg.Root, 0755); err != nil {
@@ -332,39 +997,217 @@ func (c *Client) Create(cfg Function) (err error) {
 	}
 
 	// Create Function of the given root path.
-	f, err := NewFunction(cfg.Root)
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return
+	}
+	f := Function{Root: root, ConfigFile: c.configFileOf(cfg)}
+	if err = validateConfigFile(f.ConfigFile); err != nil {
+		return
+	}
+	cfg, templateURL, err := templateURLOf(cfg)
 	if err != nil {
 		return
 	}
+	if err = ValidateCI(cfg.CI); err != nil {
+		return
+	}
+	if err = ValidateLicense(cfg.License); err != nil {
+		return
+	}
+	if err = ValidateImageSuffix(cfg.ImageSuffix); err != nil {
+		return
+	}
+	runtime := cfg.Runtime
+	if runtime == "" {
+		runtime = DefaultRuntime
+	}
+	if err = ValidatePackageManager(runtime, cfg.PackageManager); err != nil {
+		return
+	}
+	var author string
+	if cfg.License != "" && !c.managedOnly {
+		if author, err = licenseAuthor(f.Root, c.licenseAuthor); err != nil {
+			return
+		}
+	}
 
-	// Assert the specified root is free of visible files and contentious
-	// hidden files (the ConfigFile, which indicates it is already initialized)
-	if err = assertEmptyRoot(f.Root); err != nil {
+	// Writing only the managed files of the template, a Function already
+	// created there must be of the runtime requested, and is kept.
+	var existing Function
+	var keep bool
+	if c.managedOnly {
+		if existing, err = NewFunctionFromFile(f.Root, f.ConfigFile); err != nil {
+			return
+		}
+		if keep = existing.Initialized(); keep {
+			if diffs := existing.differences(Function{Runtime: cfg.Runtime, Template: existing.Template}); len(diffs) > 0 {
+				return fmt.Errorf("%w in '%v': %v", ErrConfigMismatch, f.Root, strings.Join(diffs, "; "))
+			}
+		}
+	} else if err = c.assertCreatable(f.Root, f.ConfigFile, cfg); err != nil {
+		return
+	}
+
+	// Templates of a repository pinned to a ref are those of a checkout of
+	// the ref, and a template given as a URL is that of its repository, which
+	// are fetched before anything is written.
+	templates := c.repositories
+	var fetched, commit string
+	if templateURL != nil {
+		var cleanup func()
+		if fetched, commit, cleanup, err = fetchTemplate(context.Background(), *templateURL); err != nil {
+			return
+		}
+		defer cleanup()
+	} else if cfg.TemplateRef != "" {
+		if !isCustom(cfg.Template) {
+			return fmt.Errorf("a template ref may only be given for the template of a repository, not '%v'", cfg.Template)
+		}
+		repo := strings.Split(cfg.Template, "/")[0]
+		if templates, err = c.checkoutRepository(context.Background(), repo, cfg.TemplateRef); err != nil {
+			return
+		}
+		defer os.RemoveAll(templates)
+	}
+
+	// The handler of the template which is the entrypoint of the Function,
+	// of templates exposing several, is selected before anything is written.
+	// Writing only the managed files of the template, the handlers are not
+	// written.
+	w := templateWriter{templates: templates, fetched: fetched, verbose: c.verbose, onConflict: c.onConflict, managed: c.managedOnly, version: c.version}
+	entrypoint := cfg.Entrypoint
+	if !c.managedOnly {
+		if entrypoint, err = w.entrypoint(runtime, cfg.Template, cfg.Entrypoint); err != nil {
+			return
+		}
+	}
+
+	// Mark the creation as in progress until the config is written, such that
+	// a creation which fails part way is recognized as such.
+	if err = markScaffolding(f.Root); err != nil {
 		return
 	}
 
 	// Map requested fields to the newly created function.
+	f.SpecVersion = SpecVersion
 	f.Image = cfg.Image
 	f.Name = cfg.Name
+	f.Registry = cfg.Registry
+	f.ImageSuffix = cfg.ImageSuffix
 
 	// Assert runtime was provided, or default.
 	f.Runtime = cfg.Runtime
 	if f.Runtime == "" {
 		f.Runtime = DefaultRuntime
 	}
+	f.RuntimeVersion = cfg.RuntimeVersion
 
 	// Assert template was provided, or default.
 	f.Template = cfg.Template
 	if f.Template == "" {
 		f.Template = DefaultTemplate
 	}
+	f.TemplateRef = cfg.TemplateRef
+	f.TemplateCommit = commit
+	f.CI = cfg.CI
+	f.Devcontainer = cfg.Devcontainer
+	f.License = cfg.License
+	f.PackageManager = cfg.PackageManager
+	f.Entrypoint = entrypoint
 
 	// Write out a template.
-	w := templateWriter{templates: c.repositories, verbose: c.verbose}
+	w.function = f
 	if err = w.Write(f.Runtime, f.Template, f.Root); err != nil {
 		return
 	}
 
+	// Write out the variant of the template for the package manager, if any,
+	// unless writing only the managed files of the template.
+	if !c.managedOnly {
+		if err = w.writePackageManager(f.PackageManager, f.Runtime, f.Template, f.Root); err != nil {
+			return
+		}
+	}
+
+	// Write out the CI of the Function, if any, unless writing only the
+	// managed files of the template.
+	if f.CI != "" && !c.managedOnly {
+		if err = w.writeCI(f.CI, f.Template, f.Root); err != nil {
+			return
+		}
+	}
+
+	// Write out the dev container config of the runtime, if requested, unless
+	// writing only the managed files of the template or one exists.  Writing
+	// only the managed files of a Function created with one, it is refreshed,
+	// such that it installs this version of func.
+	if f.Devcontainer && !c.managedOnly {
+		var written bool
+		if written, err = w.writeDevcontainer(f.Runtime, f.Template, f.Root, false); err != nil {
+			return
+		}
+		if !written && c.verbose {
+			fmt.Printf("A %v already exists, and is kept\n", DevcontainerFile)
+		}
+	} else if c.managedOnly && keep && existing.Devcontainer {
+		w.function = existing
+		if _, err = w.writeDevcontainer(existing.Runtime, existing.Template, existing.Root, true); err != nil {
+			return
+		}
+	}
+
+	// Write out the LICENSE of the Function, if any, unless one exists.
+	if f.License != "" && !c.managedOnly {
+		var written bool
+		if written, err = writeLicense(f.Root, f.License, author); err != nil {
+			return
+		}
+		if !written && c.verbose {
+			fmt.Println("A LICENSE already exists, and is kept")
+		}
+	}
+
+	// Write out the version manager file of the runtime version, if any and
+	// requested, unless one exists.
+	if c.versionFile && f.RuntimeVersion != "" && !c.managedOnly {
+		var written bool
+		if written, err = writeRuntimeVersionFile(f.Root, f.Runtime, f.RuntimeVersion); err != nil {
+			return
+		}
+		if !written && c.verbose {
+			fmt.Printf("No version manager file of the runtime '%v' written\n", f.Runtime)
+		}
+	}
+
+	// Write out the style config of the runtime, if requested, where its
+	// files do not already exist.  Runtimes without are skipped.
+	if c.style && !c.managedOnly {
+		if err = w.writeStyle(f.Runtime, f.Template, f.Root); errors.Is(err, ErrStyleNotFound) {
+			if c.verbose {
+				fmt.Printf("No style config of the runtime '%v' to write\n", f.Runtime)
+			}
+			err = nil
+		} else if err != nil {
+			return
+		}
+	}
+
+	// Use the builders declared for the runtime by the manifest of the
+	// template's repository, if any.  Those of the template itself take
+	// precedence.
+	if isCustom(f.Template) {
+		repo := strings.Split(f.Template, "/")[0]
+		var r Repository
+		if r, err = readRepository(filepath.Join(templates, repo), repo); err != nil {
+			return
+		}
+		if rt, ok := r.runtime(f.Runtime); ok && len(rt.Builders) > 0 {
+			f.Builder = rt.Builders["default"]
+			f.BuilderMap = rt.Builders
+		}
+	}
+
 	// Check if template specifies a builder image. If so, add to configuration
 	builderFilePath := filepath.Join(f.Root, ".builders.yaml")
 	if builderConfig, err := ioutil.ReadFile(builderFilePath); err == nil {
@@ -387,11 +1230,36 @@ func (c *Client) Create(cfg Function) (err error) {
 		}
 	}
 
+	// The builder requested takes precedence over that of the template.
+	// Functions built from a Dockerfile are provided that of their runtime
+	// unless the template includes one.
+	if cfg.Builder != "" {
+		f.Builder = cfg.Builder
+	}
+	if f.Builder == DockerfileBuilder {
+		if err = writeDockerfile(f); err != nil {
+			return
+		}
+	}
+
+	// The config of a Function already created is kept, other than its
+	// builders.
+	if keep {
+		if f.Builder != "" {
+			existing.Builder, existing.BuilderMap = f.Builder, f.BuilderMap
+		}
+		f = existing
+	}
+
 	// Write out the config.
 	if err = writeConfig(f); err != nil {
 		return
 	}
 
+	if err = unmarkScaffolding(f.Root); err != nil {
+		return
+	}
+
 	// TODO: Create a status structure and return it for clients to use
 	// for output, such as from the CLI.
 	if c.verbose {
@@ -400,24 +1268,132 @@ func (c *Client) Create(cfg Function) (err error) {
 	return
 }
 
+// assertCreatable asserts that the Function described by cfg may be created
+// at root.
+func (c *Client) assertCreatable(root, file string, cfg Function) error {
+	// A Function already created there with the requested settings is left as
+	// is, such that creating may be re-run, unless forced.
+	if !c.force {
+		if err := assertNotCreated(root, file, cfg); err != nil {
+			return err
+		}
+	}
+
+	// Assert the specified root is free of visible files and contentious
+	// hidden files (the ConfigFile, which indicates it is already initialized)
+	// unless forced, in which case existing files are overwritten, or unless
+	// they are to be resolved.
+	if err := assertEmptyRoot(root); err != nil && !c.force && c.onConflict == nil {
+		return err
+	}
+	return nil
+}
+
+// configFileOf returns the name of the config file of the Function: that
+// set explicitly, or otherwise that of the client.
+func (c *Client) configFileOf(f Function) string {
+	if f.ConfigFile != "" {
+		return f.ConfigFile
+	}
+	return c.configFile
+}
+
+// Templates lists the templates available for the creation of Functions,
+// both embedded and those of the client's template repositories, of the
+// given runtimes or of all runtimes if none are given.
+func (c *Client) Templates(runtimes ...string) ([]Template, error) {
+	return templates(c.repositories, runtimes...)
+}
+
+// Repositories returns the client's template repositories, as declared by
+// their manifests or inferred from their directory structure.
+func (c *Client) Repositories() ([]Repository, error) {
+	if c.repositories == "" {
+		return nil, nil
+	}
+	return readRepositories(c.repositories)
+}
+
 // Build the Function at path.  Errors if the Function is either unloadable or does
 // not contain a populated Image.
 func (c *Client) Build(ctx context.Context, path string) (err error) {
-	c.progressListener.Increment("Building function image")
+	var f Function
+	defer func(start time.Time) { c.record("build", f, start, err) }(time.Now())
 
-	f, err := NewFunction(path)
+	f, err = c.load(path)
 	if err != nil {
 		return
 	}
 
 	// Derive Image from the path (precedence is given to extant config)
-	if f.Image, err = DerivedImage(path, c.registry); err != nil {
+	if f.Image, err = derivedImageOf(f, c.registry); err != nil {
 		return
 	}
 
-	if err = c.builder.Build(ctx, f); err != nil {
+	builder := c.builder
+	if f.Builder == DockerfileBuilder {
+		builder = c.dockerfile
+	}
+	if c.plan != nil {
+		return c.planBuild(f)
+	}
+	c.progressListener.Increment("Building function image")
+
+	// Run the pre-build hook, if any, aborting the build if it fails.
+	if err = c.runHook(ctx, f, PreBuildHook); err != nil {
+		return
+	}
+
+	if err = c.pinBuilder(ctx, builder, &f); err != nil {
 		return
 	}
+	buildCtx := ctx
+	if c.buildTimeout > 0 {
+		var cancel context.CancelFunc
+		buildCtx, cancel = context.WithTimeout(ctx, c.buildTimeout)
+		defer cancel()
+	}
+
+	// An image is created at the time of its source rather than now, such
+	// that its labels are those of its source alone.  Of the time of a
+	// reproducible build its build envs, and not those persisted, are
+	// informed, as they are of the signature with which it is invoked, if
+	// declared.  The builder pulls its images from the registry mirrors of
+	// the client too.
+	built := f
+	created, err := sourceDateEpoch(ctx, f)
+	if err != nil {
+		return
+	}
+	if c.reproducible {
+		built.BuildEnvs = withSourceDateEpoch(built.BuildEnvs, created)
+	}
+	if f.Invocation.Signature != "" {
+		built.BuildEnvs = withBuildEnv(built.BuildEnvs, SignatureBuildEnv, f.Invocation.Signature)
+	}
+	built = built.WithRegistryMirrors(c.registryMirrors)
+	if lb, ok := builder.(LabelingBuilder); ok && c.sourceLabels {
+		err = lb.BuildWithLabels(buildCtx, built, c.buildCache, sourceLabels(ctx, f, created))
+	} else if cb, ok := builder.(CachingBuilder); ok {
+		err = cb.BuildWithCache(buildCtx, built, c.buildCache)
+	} else {
+		err = builder.Build(buildCtx, built)
+	}
+	if err != nil {
+		// Expiry of the timeout, rather than cancellation by the caller.
+		if buildCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return fmt.Errorf("%w: the build did not complete within %v", ErrBuildTimeout, c.buildTimeout)
+		}
+		return
+	}
+
+	if err = c.runHook(ctx, f, PostBuildHook); err != nil {
+		return
+	}
+
+	// The image built has not yet been pushed, such that the digest of any
+	// previously pushed no longer applies.
+	f.ImageDigest = ""
 
 	// Write out config, which will now contain a populated image tag
 	// if it had not already
@@ -430,13 +1406,84 @@ func (c *Client) Build(ctx context.Context, path string) (err error) {
 	message := fmt.Sprintf("🙌 Function image built: %v", f.Image)
 	c.progressListener.Increment(message)
 
+	// Export the image to the output directory, if requested.
+	if c.outputDir != "" {
+		c.progressListener.Increment("Saving function image")
+		path, err := c.exporter.Export(ctx, f, c.outputFormat, c.outputDir)
+		if err != nil {
+			return err
+		}
+		c.progressListener.Increment(fmt.Sprintf("Function image saved: %v", path))
+	}
+
+	// Export the SBOM of the image, if requested.
+	if c.sbomDir != "" {
+		c.progressListener.Increment("Exporting the SBOM of the function image")
+		paths, err := c.sbomExporter.ExportSBOM(ctx, f, c.sbomFormat, c.sbomDir)
+		if err != nil {
+			return err
+		}
+		c.progressListener.Increment(fmt.Sprintf("Function SBOM exported: %v", strings.Join(paths, ", ")))
+	}
+
 	return
 }
 
+// pinBuilder resolves the digest of the builder image of the Function, if
+// not already pinned or if updating, when its builder supports pinning.  A
+// digest which can not be resolved is reported and the Function built by tag,
+// such as when offline, unless updating.
+func (c *Client) pinBuilder(ctx context.Context, builder Builder, f *Function) error {
+	pb, ok := builder.(PinningBuilder)
+	if !ok || (f.BuilderDigest != "" && !c.updateBuilder) {
+		return nil
+	}
+	digest, err := pb.ResolveBuilder(ctx, f.WithRegistryMirrors(c.registryMirrors))
+	if err != nil {
+		if c.updateBuilder {
+			return fmt.Errorf("unable to update the builder image: %w", err)
+		}
+		c.progressListener.Increment(fmt.Sprintf("Unable to resolve the digest of the builder image, building by tag: %v", err))
+		return nil
+	}
+	if digest != f.BuilderDigest {
+		c.progressListener.Increment(fmt.Sprintf("Builder image pinned to digest %v", digest))
+	}
+	f.BuilderDigest = digest
+	return nil
+}
+
+// PinBuilder resolves the digest of the builder image of the Function at
+// root, of its config file if given, querying its registry once, and writes
+// it to its config, such that its builds are pinned to it until updated (see
+// WithUpdateBuilder).  The builder of a Function which does not support
+// pinning, such as that of Dockerfiles, is left as is.
+func (c *Client) PinBuilder(ctx context.Context, cfg Function) error {
+	f, err := NewFunctionFromFile(cfg.Root, c.configFileOf(cfg))
+	if err != nil {
+		return err
+	}
+	builder := c.builder
+	if f.Builder == DockerfileBuilder {
+		builder = c.dockerfile
+	}
+	pb, ok := builder.(PinningBuilder)
+	if !ok {
+		return nil
+	}
+	if f.BuilderDigest, err = pb.ResolveBuilder(ctx, f.WithRegistryMirrors(c.registryMirrors)); err != nil {
+		return fmt.Errorf("unable to resolve the digest of the builder image: %w", err)
+	}
+	return writeConfig(f)
+}
+
 // Deploy the Function at path.  Errors if the Function has not been
 // initialized with an image tag.
 func (c *Client) Deploy(ctx context.Context, path string) (err error) {
-	f, err := NewFunction(path)
+	var f Function
+	defer func(start time.Time) { c.record("deploy", f, start, err) }(time.Now())
+
+	f, err = c.load(path)
 	if err != nil {
 		return
 	}
@@ -446,30 +1493,177 @@ func (c *Client) Deploy(ctx context.Context, path string) (err error) {
 	if !f.Built() {
 		return ErrNotBuilt
 	}
+	if c.plan != nil {
+		return c.planDeploy(ctx, f, c.push)
+	}
 
 	// Push the image for the named service to the configured registry
-	c.progressListener.Increment("Pushing function image to the registry")
-	imageDigest, err := c.pusher.Push(ctx, f)
+	if c.push {
+		c.progressListener.Increment("Pushing function image to the registry")
+		var imageDigest string
+		if ap, ok := c.pusher.(AuthenticatingPusher); ok && c.credentials != nil {
+			imageDigest, err = ap.PushWithCredentials(ctx, f, c.credentials)
+		} else {
+			imageDigest, err = c.pusher.Push(ctx, f)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Store the produced image Digest in the config, such that the
+		// Function is deployed by its immutable digest rather than its tag.
+		f.ImageDigest = imageDigest
+		if err = writeConfig(f); err != nil {
+			return err
+		}
+		c.progressListener.Increment(fmt.Sprintf("Function image pushed: %v", f.ImageWithDigest()))
+	}
+
+	result, err := c.deploy(ctx, f)
 	if err != nil {
 		return
 	}
+	return c.recordStatus(f, result)
+}
+
+// RunPipeline builds the Function at path on the cluster, from its source in
+// its git repository, and deploys the resultant image.  The Function must
+// have a git repository and either an image or a registry from which it is
+// derived.
+func (c *Client) RunPipeline(ctx context.Context, path string) (err error) {
+	var f Function
+	defer func(start time.Time) { c.record("run-pipeline", f, start, err) }(time.Now())
+
+	f, err = c.load(path)
+	if err != nil {
+		return
+	}
+	if f.Git.URL == "" {
+		return ErrGitRequired
+	}
+	if f.Image, err = derivedImageOf(f, c.registry); err != nil {
+		return
+	}
 
-	// Store the produced image Digest in the config
-	f.ImageDigest = imageDigest
+	// The digest of any image built locally does not apply to that built on
+	// the cluster, which is deployed by its tag.
+	f.ImageDigest = ""
+	if c.plan != nil {
+		c.plan.WriteConfig(f)
+		c.plan.Add(PlanStep{Action: "build", Target: f.Image, Detail: "on the cluster from " + f.Git.URL})
+		return c.planDeploy(ctx, f, false)
+	}
 	if err = writeConfig(f); err != nil {
 		return
 	}
 
-	// Deploy a new or Update the previously-deployed Function
+	c.progressListener.Increment("Building function image on the cluster")
+	if err = c.pipelines.Run(ctx, f); err != nil {
+		return
+	}
+	c.progressListener.Increment(fmt.Sprintf("🙌 Function image built: %v", f.Image))
+
+	result, err := c.deploy(ctx, f)
+	if err != nil {
+		return
+	}
+	return c.recordStatus(f, result)
+}
+
+// RunPipelineArchive builds the Function on the cluster from the gzipped
+// tarball of its source at archive, without a local checkout, and deploys the
+// resultant image.  The Function is that loaded from the archive (see
+// NewFunctionFromArchive), with any overrides applied, and must have either
+// an image or a registry from which it is derived.  Its configuration is not
+// written, having no local source.
+func (c *Client) RunPipelineArchive(ctx context.Context, f Function, archive string) (err error) {
+	if c.plan != nil {
+		return ErrNotPlanned
+	}
+	if c.registry != "" {
+		f.Registry = c.registry
+	}
+	if f.Image, err = f.ImageName(); err != nil {
+		return
+	}
+	f.ImageDigest = ""
+
+	c.progressListener.Increment("Uploading function source to the cluster")
+	if err = c.pipelines.RunArchive(ctx, f, archive); err != nil {
+		return
+	}
+	c.progressListener.Increment(fmt.Sprintf("🙌 Function image built: %v", f.Image))
+
+	_, err = c.deploy(ctx, f)
+	return
+}
+
+// deploy a new or update the previously-deployed Function.
+func (c *Client) deploy(ctx context.Context, f Function) (DeploymentResult, error) {
+	f, rewrites, err := c.deployed(f)
+	if err != nil {
+		return DeploymentResult{}, err
+	}
+	if c.verbose {
+		for _, rewrite := range rewrites {
+			fmt.Printf("Registry mirror: %v\n", rewrite)
+		}
+	}
 	c.progressListener.Increment("Deploying function to the cluster")
 	result, err := c.deployer.Deploy(ctx, f)
 	if result.Status == Deployed {
 		c.progressListener.Increment(fmt.Sprintf("Function deployed at URL: %v", result.URL))
 	} else if result.Status == Updated {
 		c.progressListener.Increment(fmt.Sprintf("Function updated at URL: %v", result.URL))
+	} else if result.Status == Unchanged {
+		c.progressListener.Increment(fmt.Sprintf("No changes, function unchanged at URL: %v", result.URL))
 	}
 
-	return err
+	return result, err
+}
+
+// deployed returns the Function as deployed: with the overlay of the client's
+// environment, if any, merged over its settings, its deploy envs over its
+// envs, and its images pulled from their registry mirrors, of which the
+// rewrites applied are returned.
+func (c *Client) deployed(f Function) (Function, []string, error) {
+	if c.environment != "" {
+		var err error
+		if f, err = f.WithEnvironment(c.environment); err != nil {
+			return f, nil, err
+		}
+	}
+	if len(c.deployEnvs) > 0 {
+		f = f.WithEnvs(c.deployEnvs)
+	}
+	f, rewrites := f.WithRegistryMirrors(c.registryMirrors).Mirrored()
+	return f, rewrites, nil
+}
+
+// recordStatus of the Function as deployed in its config file, unless
+// disabled with WithStatus.  Deploys which neither create nor update the
+// Function, such as dry runs, are not recorded.
+func (c *Client) recordStatus(f Function, result DeploymentResult) error {
+	if !c.status || (result.Status != Deployed && result.Status != Updated) {
+		return nil
+	}
+	previous := f.Status.PreviousRevision
+	if f.Status.Revision != result.Revision {
+		previous = f.Status.Revision
+	}
+	deployed, _, err := c.deployed(f)
+	if err != nil {
+		return err
+	}
+	f.Status = FunctionStatus{
+		Image:            f.ImageWithDigest(),
+		Revision:         result.Revision,
+		PreviousRevision: previous,
+		URL:              result.URL,
+		Domain:           deployed.Domain,
+		Deployed:         time.Now().UTC().Truncate(time.Second),
+	}
+	return writeConfig(f)
 }
 
 func (c *Client) Route(path string) (err error) {
@@ -480,7 +1674,10 @@ func (c *Client) Route(path string) (err error) {
 	// but DNS subdomain CNAME to the Kourier Load Balancer is
 	// still manual, and the initial cluster config to suppot the TLD
 	// is still manual.
-	f, err := NewFunction(path)
+	if c.plan != nil {
+		return
+	}
+	f, err := NewFunctionFromFile(path, c.configFile)
 	if err != nil {
 		return
 	}
@@ -489,9 +1686,12 @@ func (c *Client) Route(path string) (err error) {
 
 // Run the Function whose code resides at root.
 func (c *Client) Run(ctx context.Context, root string) error {
+	if c.plan != nil {
+		return ErrNotPlanned
+	}
 
 	// Create an instance of a Function representation at the given root.
-	f, err := NewFunction(root)
+	f, err := NewFunctionFromFile(root, c.configFile)
 	if err != nil {
 		return err
 	}
@@ -512,45 +1712,107 @@ func (c *Client) List(ctx context.Context) ([]ListItem, error) {
 }
 
 // Describe a Function.  Name takes precidence.  If no name is provided,
-// the Function defined at root is used.
+// the Function defined at root is used.  The description of the describer,
+// such as its routes and triggers, is aggregated with the Revisions of the
+// Function, newest first, when the client has a RevisionLister.
 func (c *Client) Describe(ctx context.Context, name, root string) (d Description, err error) {
-	// If name is provided, it takes precidence.
-	// Otherwise load the Function defined at root.
-	if name != "" {
-		return c.describer.Describe(ctx, name)
+	if name, err = c.describedName(name, root); err != nil {
+		return
 	}
+	if d, err = c.describer.Describe(ctx, name); err != nil {
+		return
+	}
+	if c.revisionLister != nil {
+		d.Revisions, err = c.revisionLister.Revisions(ctx, name)
+	}
+	return
+}
 
-	f, err := NewFunction(root)
+// Watch a Function, invoking changed with its description, as of Describe,
+// when watched and each time it changes thereafter, until changed returns
+// true or the context is done.  Name takes precedence.  Requires a
+// WatchingDescriber.
+func (c *Client) Watch(ctx context.Context, name, root string, changed func(Description) bool) (err error) {
+	watcher, ok := c.describer.(WatchingDescriber)
+	if !ok {
+		return errors.New("the describer of the client does not watch functions")
+	}
+	if name, err = c.describedName(name, root); err != nil {
+		return
+	}
+	werr := watcher.Watch(ctx, name, func(d Description) bool {
+		if c.revisionLister != nil {
+			if d.Revisions, err = c.revisionLister.Revisions(ctx, name); err != nil {
+				return true
+			}
+		}
+		return changed(d)
+	})
 	if err != nil {
-		return d, err
+		return
+	}
+	return werr
+}
+
+// describedName returns the name of the Function described: that given, if
+// any, or else that of the Function defined at root.
+func (c *Client) describedName(name, root string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	f, err := NewFunctionFromFile(root, c.configFile)
+	if err != nil {
+		return "", err
 	}
 	if !f.Initialized() {
-		return d, fmt.Errorf("%v is not initialized", f.Name)
+		return "", fmt.Errorf("%v is not initialized", f.Name)
 	}
-	return c.describer.Describe(ctx, f.Name)
+	return f.Name, nil
 }
 
 // Remove a Function.  Name takes precidence.  If no name is provided,
-// the Function defined at root is used if it exists.
-func (c *Client) Remove(ctx context.Context, cfg Function) error {
+// the Function defined at root is used if it exists.  It is removed from its
+// Namespace, if any, and otherwise from that of the Remover.
+func (c *Client) Remove(ctx context.Context, cfg Function) (err error) {
+	f := cfg
+	defer func(start time.Time) { c.record("remove", f, start, err) }(time.Now())
+
 	// If name is provided, it takes precidence.
 	// Otherwise load the Function deined at root.
 	if cfg.Name != "" {
-		return c.remover.Remove(ctx, cfg.Name)
+		return c.remove(ctx, cfg.Name, cfg.Namespace)
 	}
 
-	f, err := NewFunction(cfg.Root)
+	f, err = NewFunctionFromFile(cfg.Root, c.configFileOf(cfg))
 	if err != nil {
 		return err
 	}
 	if !f.Initialized() {
 		return fmt.Errorf("Function at %v can not be removed unless initialized.  Try removing by name.", f.Root)
 	}
-	return c.remover.Remove(ctx, f.Name)
+	if cfg.Namespace != "" {
+		f.Namespace = cfg.Namespace
+	}
+	return c.remove(ctx, f.Name, f.Namespace)
+}
+
+// Rollback routes all of the traffic of the deployed Function of the given
+// name to the given revision, returning its traffic as split.
+func (c *Client) Rollback(ctx context.Context, name, revision string) ([]TrafficTarget, error) {
+	if c.plan != nil {
+		return nil, ErrNotPlanned
+	}
+	if c.splitter == nil {
+		return nil, ErrRollbackUnsupported
+	}
+	return c.splitter.Split(ctx, name, TrafficSplit{revision: 100})
 }
 
 // Emit a CloudEvent to a function endpoint
 func (c *Client) Emit(ctx context.Context, endpoint string) error {
+	if c.plan != nil {
+		return ErrNotPlanned
+	}
 	return c.emitter.Emit(ctx, endpoint)
 }
 
@@ -570,6 +1832,24 @@ type noopPusher struct{ output io.Writer }
 
 func (n *noopPusher) Push(ctx context.Context, f Function) (string, error) { return "", nil }
 
+type noopExporter struct{ output io.Writer }
+
+func (n *noopExporter) Export(ctx context.Context, f Function, format, dir string) (string, error) {
+	return "", nil
+}
+
+type noopSBOMExporter struct{ output io.Writer }
+
+func (n *noopSBOMExporter) ExportSBOM(ctx context.Context, f Function, format, dir string) ([]string, error) {
+	return nil, nil
+}
+
+type noopLayoutExporter struct{ output io.Writer }
+
+func (n *noopLayoutExporter) ExportLayout(ctx context.Context, f Function, dir string) error {
+	return nil
+}
+
 type noopDeployer struct{ output io.Writer }
 
 func (n *noopDeployer) Deploy(ctx context.Context, _ Function) (DeploymentResult, error) {
@@ -582,7 +1862,14 @@ func (n *noopRunner) Run(_ context.Context, _ Function) error { return nil }
 
 type noopRemover struct{ output io.Writer }
 
-func (n *noopRemover) Remove(context.Context, string) error { return nil }
+func (n *noopRemover) Remove(context.Context, string, string) error { return nil }
+
+type noopPipelinesProvider struct{ output io.Writer }
+
+func (n *noopPipelinesProvider) Run(context.Context, Function) error { return nil }
+func (n *noopPipelinesProvider) RunArchive(context.Context, Function, string) error {
+	return nil
+}
 
 type noopLister struct{ output io.Writer }
 
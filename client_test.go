@@ -3,13 +3,29 @@
 package function_test
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
 
 	fn "github.com/boson-project/func"
 	"github.com/boson-project/func/mock"
@@ -59,6 +75,41 @@ func TestTemplateWrites(t *testing.T) {
 	}
 }
 
+// TestConfigFile ensures a Function created with a config file of another
+// name than func.yaml is written to, and subsequently built from, it.
+func TestConfigFile(t *testing.T) {
+	root := "testdata/example.com/testConfigFile"
+	defer using(t, root)()
+
+	client := fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithBuilder(mock.NewBuilder()),
+		fn.WithConfigFile("api.func.yaml"))
+	if err := client.Create(fn.Function{Root: root, Name: "api"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, fn.ConfigFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected no %v to be written, got %v", fn.ConfigFile, err)
+	}
+	if err := client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := fn.Load(root, "api.func.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "api" || f.Image != TestRegistry+"/api:latest" {
+		t.Fatalf("expected the image built to be written to the config file, got %v", f.Image)
+	}
+	if _, err = fn.Load(root, ""); !errors.Is(err, fn.ErrNotInitialized) {
+		t.Fatalf("expected ErrNotInitialized loading %v, got %v", fn.ConfigFile, err)
+	}
+	if _, err = fn.Load(root, "../api.func.yaml"); err == nil {
+		t.Fatal("expected a config file outside the root to be invalid")
+	}
+}
+
 // TestExtantAborts ensures that a directory which contains an extant
 // Function does not reinitialize
 func TestExtantAborts(t *testing.T) {
@@ -77,6 +128,177 @@ func TestExtantAborts(t *testing.T) {
 	}
 }
 
+// TestCreateIdempotent ensures that creating a Function again with the same
+// settings reports it as already created, and with others their differences.
+func TestCreateIdempotent(t *testing.T) {
+	root := "testdata/example.com/testCreateIdempotent"
+	defer using(t, root)()
+
+	client := fn.New()
+	if err := client.Create(fn.Function{Root: root, Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Create(fn.Function{Root: root, Runtime: "go"}); !errors.Is(err, fn.ErrAlreadyCreated) {
+		t.Fatalf("expected ErrAlreadyCreated, got '%v'", err)
+	}
+	err := client.Create(fn.Function{Root: root, Runtime: "go", Template: "events"})
+	if !errors.Is(err, fn.ErrConfigMismatch) || !strings.Contains(err.Error(), "template is 'http', not 'events'") {
+		t.Fatalf("expected ErrConfigMismatch listing the template, got '%v'", err)
+	}
+}
+
+// TestCreateManagedFilesOnly ensures that creating with only the managed
+// files of the template refreshes them in a Function already created, whose
+// config and other files are kept, and that its runtime must be that of the
+// template.
+func TestCreateManagedFilesOnly(t *testing.T) {
+	repositories, err := ioutil.TempDir("", "func-managed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repositories)
+	template := filepath.Join(repositories, "managed", "go", "http")
+	for name, content := range map[string]string{
+		fn.ManifestFile: "managed: [go.mod]\n",
+		"go.mod":        "module function // refreshed",
+		"handle.go":     "package function",
+	} {
+		mkdir(t, template)
+		if err = ioutil.WriteFile(filepath.Join(template, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root := "testdata/example.com/testCreateManagedFilesOnly"
+	defer using(t, root)()
+	client := fn.New(fn.WithRepositories(repositories))
+	if err = client.Create(fn.Function{Root: root, Name: "myfunc", Runtime: "go", Template: "managed/http", Image: "quay.io/alice/myfunc"}); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"go.mod": "module function", "handle.go": "package mine"} {
+		if err = ioutil.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client = fn.New(fn.WithRepositories(repositories), fn.WithManagedFilesOnly(true))
+	if err = client.Create(fn.Function{Root: root, Runtime: "go", Template: "managed/http"}); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"go.mod": "module function // refreshed", "handle.go": "package mine"} {
+		bb, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bb) != content {
+			t.Fatalf("expected '%v' to contain %q, got %q", name, content, bb)
+		}
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Image != "quay.io/alice/myfunc" {
+		t.Fatalf("expected the config of the function to be kept, got image '%v'", f.Image)
+	}
+
+	if err = client.Create(fn.Function{Root: root, Runtime: "node", Template: "managed/http"}); !errors.Is(err, fn.ErrConfigMismatch) {
+		t.Fatalf("expected ErrConfigMismatch refreshing the files of another runtime, got %v", err)
+	}
+}
+
+// TestCreateEntrypoint ensures that the handler of a template exposing several
+// which is selected as the entrypoint is recorded in the Function's config,
+// and that one the template does not declare fails before anything is
+// written.
+func TestCreateEntrypoint(t *testing.T) {
+	repositories, err := ioutil.TempDir("", "func-entrypoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repositories)
+	template := filepath.Join(repositories, "multi", "go", "http")
+	for name, content := range map[string]string{
+		fn.ManifestFile: "entrypoint: handle.go\nhandlers:\n- name: echo\n  file: echo.go\n- name: json\n  file: json.go\n",
+		"echo.go":       "package echo",
+		"json.go":       "package json",
+	} {
+		mkdir(t, template)
+		if err = ioutil.WriteFile(filepath.Join(template, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root := "testdata/example.com/testCreateEntrypoint"
+	defer using(t, root)()
+	client := fn.New(fn.WithRepositories(repositories))
+	if err = client.Create(fn.Function{Root: root, Runtime: "go", Template: "multi/http", Entrypoint: "xml"}); !errors.Is(err, fn.ErrEntrypointNotFound) {
+		t.Fatalf("expected ErrEntrypointNotFound, got '%v'", err)
+	}
+	if _, err = os.Stat(filepath.Join(root, "echo.go")); !os.IsNotExist(err) {
+		t.Fatal("expected nothing to be written for a handler not declared")
+	}
+
+	if err = client.Create(fn.Function{Root: root, Runtime: "go", Template: "multi/http", Entrypoint: "json"}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Entrypoint != "json" {
+		t.Fatalf("expected the entrypoint to be recorded, got '%v'", f.Entrypoint)
+	}
+	if bb, err := ioutil.ReadFile(filepath.Join(root, "handle.go")); err != nil || string(bb) != "package json" {
+		t.Fatalf("expected the json handler to be the entrypoint, got %q (%v)", bb, err)
+	}
+}
+
+// TestCreateEmbeddedManagedFiles ensures the embedded templates declare their
+// managed files, which alone are refreshed, and that the handlers of those
+// exposing several are selectable as the entrypoint.
+func TestCreateEmbeddedManagedFiles(t *testing.T) {
+	root := "testdata/example.com/testCreateEmbeddedManagedFiles"
+	defer using(t, root)()
+
+	client := fn.New()
+	if err := client.Create(fn.Function{Root: root, Runtime: "go", Template: "http", Entrypoint: "echo"}); err != nil {
+		t.Fatal(err)
+	}
+	if bb, err := ioutil.ReadFile(filepath.Join(root, "handle.go")); err != nil || !strings.Contains(string(bb), "echoing its body") {
+		t.Fatalf("expected the echo handler to be the entrypoint, got %q (%v)", bb, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "handlers")); !os.IsNotExist(err) {
+		t.Fatalf("expected the other handlers to be removed, got %v", err)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder := f.Builder
+	f.Builder, f.BuilderMap = "example.com/alice/builder", nil
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(root, "handle.go"), []byte("modified"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The builders of the template are refreshed from its managed
+	// .builders.yaml, and the handler kept.
+	client = fn.New(fn.WithManagedFilesOnly(true))
+	if err = client.Create(fn.Function{Root: root, Runtime: "go", Template: "http"}); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = fn.NewFunction(root); err != nil || f.Builder != builder {
+		t.Fatalf("expected the builder '%v' of the template to be refreshed, got '%v' (%v)", builder, f.Builder, err)
+	}
+	if bb, err := ioutil.ReadFile(filepath.Join(root, "handle.go")); err != nil || string(bb) != "modified" {
+		t.Fatalf("expected handle.go to be kept, got %q (%v)", bb, err)
+	}
+}
+
 // TestNonemptyDirectoryAborts ensures that a directory which contains any
 // visible files aborts.
 func TestNonemptyDirectoryAborts(t *testing.T) {
@@ -117,6 +339,63 @@ func TestHiddenFilesIgnored(t *testing.T) {
 	}
 }
 
+// TestIncompleteScaffold ensures that a directory containing the files of a
+// creation which did not complete is distinguished from one containing
+// unrelated files, and that the creation may be completed when forced,
+// leaving no marker of its progress behind.
+func TestIncompleteScaffold(t *testing.T) {
+	root := "testdata/example.com/testCreateIncompleteScaffold"
+	defer using(t, root)()
+
+	// Simulate a creation which failed part way: its progress marker and some
+	// source are present, but no config.
+	mkdir(t, filepath.Join(root, fn.RunDataDir))
+	if err := ioutil.WriteFile(filepath.Join(root, fn.RunDataDir, fn.ScaffoldingFile), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "handle.go"), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := fn.New().Create(fn.Function{Root: root})
+	if !errors.Is(err, fn.ErrIncompleteScaffold) {
+		t.Fatalf("expected ErrIncompleteScaffold, got '%v'", err)
+	}
+
+	if err = fn.New(fn.WithForce(true)).Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(root, fn.ConfigFile)); err != nil {
+		t.Fatalf("expected '%v' to be written once completed: %v", fn.ConfigFile, err)
+	}
+	if _, err = os.Stat(filepath.Join(root, fn.RunDataDir)); !os.IsNotExist(err) {
+		t.Fatalf("expected '%v' to be removed once completed", fn.RunDataDir)
+	}
+}
+
+// TestForceUnrelatedFiles ensures that a directory containing unrelated
+// visible files is reported as such, and that creation proceeds when forced.
+func TestForceUnrelatedFiles(t *testing.T) {
+	root := "testdata/example.com/testCreateForceUnrelatedFiles"
+	defer using(t, root)()
+
+	if err := ioutil.WriteFile(filepath.Join(root, "file.txt"), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := fn.New().Create(fn.Function{Root: root})
+	if !errors.Is(err, fn.ErrUnrelatedFiles) {
+		t.Fatalf("expected ErrUnrelatedFiles, got '%v'", err)
+	}
+
+	if err = fn.New(fn.WithForce(true)).Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(root, "file.txt")); err != nil {
+		t.Fatalf("expected unrelated files to be left in place: %v", err)
+	}
+}
+
 // TestDefaultRuntime ensures that the default runtime is applied to new
 // Functions and persisted.
 func TestDefaultRuntime(t *testing.T) {
@@ -660,6 +939,50 @@ func TestList(t *testing.T) {
 	}
 }
 
+// TestDescribe ensures that the description of a Function, by name or that
+// at root, aggregates that of the describer with the Function's Revisions.
+func TestDescribe(t *testing.T) {
+	root := "testdata/example.com/testDescribe"
+	defer using(t, root)()
+
+	describer := mock.NewDescriber()
+	describer.DescribeFn = func(name string) (fn.Description, error) {
+		return fn.Description{Name: name, Routes: []string{"http://" + name + ".example.com"}, Ready: "True"}, nil
+	}
+	revisions := mock.NewRevisionLister()
+	revisions.RevisionsFn = func(name string) ([]fn.Revision, error) {
+		return []fn.Revision{{Name: name + "-00002", Percent: 100}, {Name: name + "-00001"}}, nil
+	}
+	client := fn.New(fn.WithDescriber(describer), fn.WithRevisionLister(revisions))
+
+	d, err := client.Describe(context.Background(), "myfunc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "myfunc" || d.Ready != "True" || len(d.Revisions) != 2 || d.Revisions[0].Name != "myfunc-00002" {
+		t.Fatalf("expected the description and revisions of myfunc, got %+v", d)
+	}
+
+	if err = client.Create(fn.Function{Name: "testDescribe", Root: root, Runtime: TestRuntime}); err != nil {
+		t.Fatal(err)
+	}
+	if d, err = client.Describe(context.Background(), "", root); err != nil {
+		t.Fatal(err)
+	}
+	if d.Name != "testDescribe" || len(d.Revisions) != 2 {
+		t.Fatalf("expected the function at root to be described, got %+v", d)
+	}
+
+	// Without a revision lister, the description is that of the describer.
+	client = fn.New(fn.WithDescriber(describer))
+	if d, err = client.Describe(context.Background(), "myfunc", ""); err != nil {
+		t.Fatal(err)
+	}
+	if d.Revisions != nil {
+		t.Fatalf("expected no revisions, got %+v", d.Revisions)
+	}
+}
+
 // TestListOutsideRoot ensures that a call to a Function (in this case list)
 // that is not contextually dependent on being associated with a Function,
 // can be run from anywhere, thus ensuring that the client itself makes
@@ -705,52 +1028,1278 @@ func TestDeployUnbuilt(t *testing.T) {
 	}
 }
 
-// TestEmit ensures that the
-func TestEmit(t *testing.T) {
-	sink := "http://testy.mctestface.com"
-	emitter := mock.NewEmitter()
+// TestDeployWithoutPush ensures that a Function is deployed without pushing
+// its image when push is disabled, such as an image already pushed by CI.
+func TestDeployWithoutPush(t *testing.T) {
+	root := "testdata/example.com/testDeployWithoutPush"
+	defer using(t, root)()
 
-	// Ensure sink passthrough from client
-	emitter.EmitFn = func(s string) error {
-		if s != sink {
-			t.Fatalf("Unexpected sink %v\n", s)
-		}
-		return nil
+	pusher := mock.NewPusher()
+	deployer := mock.NewDeployer()
+	client := fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithPusher(pusher),
+		fn.WithDeployer(deployer),
+		fn.WithPush(false))
+	if err := client.Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Image = "example.com/alice/prebuilt:v1"
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
 	}
 
-	// Instantiate in the current working directory, with no name.
-	client := fn.New(fn.WithEmitter(emitter))
-
-	if err := client.Emit(context.Background(), sink); err != nil {
+	if err = client.Deploy(context.Background(), root); err != nil {
 		t.Fatal(err)
 	}
-	if !emitter.EmitInvoked {
-		t.Fatal("Client did not invoke emitter.Emit()")
+	if pusher.PushInvoked {
+		t.Fatal("expected the image not to be pushed")
+	}
+	if !deployer.DeployInvoked {
+		t.Fatal("expected the Function to be deployed")
 	}
 }
 
-// Helpers ----
+// TestDeployWithEnvironment ensures that a Function is deployed with the
+// overlay of its environment merged over its settings, without the overlay
+// being written to its config.
+func TestDeployWithEnvironment(t *testing.T) {
+	root := "testdata/example.com/testDeployWithEnvironment"
+	defer using(t, root)()
 
-// using the given directory (creating it) returns a closure which removes the
-// directory, intended to be run in a defer statement.
-func using(t *testing.T, root string) func() {
-	t.Helper()
-	mkdir(t, root)
-	return func() {
-		rm(t, root)
+	deployer := mock.NewDeployer()
+	client := fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithDeployer(deployer),
+		fn.WithEnvironment("prod"))
+	if err := client.Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Namespace = "dev"
+	f.Environments = map[string]fn.Environment{"prod": {Namespace: "prod"}}
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+
+	deployer.DeployFn = func(f fn.Function) error {
+		if f.Namespace != "prod" {
+			t.Fatalf("expected the namespace 'prod', got '%v'", f.Namespace)
+		}
+		return nil
+	}
+	if err = client.Deploy(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if !deployer.DeployInvoked {
+		t.Fatal("expected the Function to be deployed")
+	}
+	if f, err = fn.NewFunction(root); err != nil {
+		t.Fatal(err)
+	}
+	if f.Namespace != "dev" {
+		t.Fatalf("expected the namespace of the config to remain 'dev', got '%v'", f.Namespace)
+	}
+
+	// An environment which is not defined is an error.
+	client = fn.New(fn.WithDeployer(deployer), fn.WithEnvironment("qa"))
+	var unknown fn.ErrUnknownEnvironment
+	if err = client.Deploy(context.Background(), root); !errors.As(err, &unknown) {
+		t.Fatalf("expected ErrUnknownEnvironment, got %v", err)
 	}
 }
 
-func mkdir(t *testing.T, dir string) {
-	t.Helper()
-	if err := os.MkdirAll(dir, 0700); err != nil {
+// TestBuildSourceLabels ensures that images are built with the OCI labels of
+// their source, created at the time of their source, by the Dockerfile
+// builder as by buildpacks, unless disabled with WithSourceLabels(false).
+func TestBuildSourceLabels(t *testing.T) {
+	root := "testdata/example.com/testBuildSourceLabels"
+	defer using(t, root)()
+	defer os.Setenv(fn.SourceDateEpochEnv, os.Getenv(fn.SourceDateEpochEnv))
+	os.Setenv(fn.SourceDateEpochEnv, "1622548800")
+
+	builder := mock.NewBuilder()
+	client := fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(builder))
+	if err := client.Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if builder.Labels[fn.CreatedLabel] != "2021-06-01T12:00:00Z" {
+		t.Fatalf("expected the image to be labeled with the time of its source, got %v", builder.Labels)
+	}
+
+	dockerfile := "testdata/example.com/testBuildSourceLabelsDockerfile"
+	defer using(t, dockerfile)()
+	builder = mock.NewBuilder()
+	client = fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(mock.NewBuilder()), fn.WithDockerfileBuilder(builder))
+	if err := client.Create(fn.Function{Root: dockerfile, Builder: fn.DockerfileBuilder}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Build(context.Background(), dockerfile); err != nil {
+		t.Fatal(err)
+	}
+	if builder.Labels[fn.CreatedLabel] != "2021-06-01T12:00:00Z" {
+		t.Fatalf("expected the image built from its Dockerfile to be labeled, got %v", builder.Labels)
+	}
+
+	builder = mock.NewBuilder()
+	client = fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(builder), fn.WithSourceLabels(false))
+	if err := client.Build(context.Background(), root); err != nil {
 		t.Fatal(err)
 	}
+	if builder.Labels != nil {
+		t.Fatalf("expected the image not to be labeled, got %v", builder.Labels)
+	}
 }
 
-func rm(t *testing.T, dir string) {
-	t.Helper()
-	if err := os.RemoveAll(dir); err != nil {
+// TestBuildReproducible ensures that reproducible builds of the same source
+// at different times yield the same image, as pushed to a registry, their
+// timestamps normalized to the source date epoch, and that the epoch is
+// provided to the build without being written to the Function's config.
+func TestBuildReproducible(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "myfunc")
+	defer os.Setenv(fn.SourceDateEpochEnv, os.Getenv(fn.SourceDateEpochEnv))
+	os.Unsetenv(fn.SourceDateEpochEnv)
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	image := strings.TrimPrefix(server.URL, "http://") + "/alice/myfunc:latest"
+
+	// The image built is that the buildpacks lifecycle exports: of the source
+	// of the Function and the labels given, of which the timestamps are those
+	// of the build env SOURCE_DATE_EPOCH, or else the time it is built.  Its
+	// digest is that of the image pushed to the registry.
+	build := func() (string, fn.Function) {
+		var built fn.Function
+		builder := mock.NewBuilder()
+		builder.BuildFn = func(f fn.Function) error {
+			built = f
+			return nil
+		}
+		client := fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(builder), fn.WithReproducible(true))
+		if err := client.Build(context.Background(), root); err != nil {
+			t.Fatal(err)
+		}
+		img, err := exportedImage(built, builder.Labels)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref, err := name.ParseReference(image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = remote.Write(ref, img); err != nil {
+			t.Fatal(err)
+		}
+		desc, err := remote.Head(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return desc.Digest.String(), built
+	}
+
+	client := fn.New(fn.WithRegistry(TestRegistry))
+	if err := client.Create(fn.Function{Name: "myfunc", Root: root, Runtime: "go"}); err != nil {
 		t.Fatal(err)
 	}
+	first, f := build()
+	time.Sleep(1 * time.Second)
+	if second, _ := build(); first != second {
+		t.Fatalf("expected builds of the same source to yield the same digest, got %v and %v", first, second)
+	}
+	if epoch := f.BuildEnvs[len(f.BuildEnvs)-1]; *epoch.Name != fn.SourceDateEpochEnv || *epoch.Value != "315532801" {
+		t.Fatalf("expected the build env %v=315532801, got %v=%v", fn.SourceDateEpochEnv, *epoch.Name, *epoch.Value)
+	}
+	if saved, err := fn.NewFunction(root); err != nil || len(saved.BuildEnvs) != 0 {
+		t.Fatalf("expected the build envs of the function not to be written, got %v (%v)", saved.BuildEnvs, err)
+	}
+
+	os.Setenv(fn.SourceDateEpochEnv, "1622548800")
+	if epoch, _ := build(); epoch == first {
+		t.Fatalf("expected the build to be of the time of %v", fn.SourceDateEpochEnv)
+	}
+	os.Setenv(fn.SourceDateEpochEnv, "yesterday")
+	if err := fn.New(fn.WithBuilder(mock.NewBuilder()), fn.WithReproducible(true)).Build(context.Background(), root); err == nil {
+		t.Fatalf("expected an invalid %v to be an error", fn.SourceDateEpochEnv)
+	}
+}
+
+// exportedImage returns the image of the source of the built Function with
+// the given labels, as exported by the buildpacks lifecycle: its timestamps
+// are those of its build env SOURCE_DATE_EPOCH, if any, or else now.
+func exportedImage(f fn.Function, labels map[string]string) (v1.Image, error) {
+	created := time.Now()
+	for _, e := range f.BuildEnvs {
+		if *e.Name == fn.SourceDateEpochEnv {
+			seconds, err := strconv.ParseInt(*e.Value, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			created = time.Unix(seconds, 0).UTC()
+		}
+	}
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	err := filepath.Walk(f.Root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil || !fi.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(f.Root, path)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err = tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(rel), Mode: 0644, Size: int64(len(content)), ModTime: created}); err != nil {
+			return err
+		}
+		_, err = tw.Write(content)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = tw.Close(); err != nil {
+		return nil, err
+	}
+	layer, err := tarball.LayerFromReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		return nil, err
+	}
+	if img, err = mutate.Config(img, v1.Config{Labels: labels}); err != nil {
+		return nil, err
+	}
+	return mutate.CreatedAt(img, v1.Time{Time: created})
+}
+
+// TestBuildInvocation ensures that the build of a Function which declares the
+// signature with which it is invoked is informed of it by build env, unless
+// it sets the env itself, and that those of the Function are not written.
+func TestBuildInvocation(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "myfunc")
+	var built fn.Function
+	builder := mock.NewBuilder()
+	builder.BuildFn = func(f fn.Function) error {
+		built = f
+		return nil
+	}
+	client := fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(builder))
+	if err := client.Create(fn.Function{Name: "myfunc", Root: root, Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Invocation.Signature = "events"
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if env := built.BuildEnvs[len(built.BuildEnvs)-1]; *env.Name != fn.SignatureBuildEnv || *env.Value != "events" {
+		t.Fatalf("expected the build env %v=events, got %v=%v", fn.SignatureBuildEnv, *env.Name, *env.Value)
+	}
+	if saved, err := fn.NewFunction(root); err != nil || len(saved.BuildEnvs) != 0 {
+		t.Fatalf("expected the build envs of the function not to be written, got %v (%v)", saved.BuildEnvs, err)
+	}
+
+	name, value := fn.SignatureBuildEnv, "http"
+	f.BuildEnvs = fn.Envs{{Name: &name, Value: &value}}
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if len(built.BuildEnvs) != 1 || *built.BuildEnvs[0].Value != "http" {
+		t.Fatalf("expected the build env set by the function to be kept, got %v", built.BuildEnvs)
+	}
+}
+
+// TestRegistryMirrors ensures a Function is built with the registry mirrors
+// of its config and of the client, and deployed with its image pulled from
+// its mirror, without the mirrors of the client or the image mirrored being
+// written to its config.
+func TestRegistryMirrors(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "myfunc")
+	var built, deployed fn.Function
+	builder := mock.NewBuilder()
+	builder.BuildFn = func(f fn.Function) error {
+		built = f
+		return nil
+	}
+	deployer := mock.NewDeployer()
+	deployer.DeployFn = func(f fn.Function) error {
+		deployed = f
+		return nil
+	}
+	client := fn.New(
+		fn.WithBuilder(builder),
+		fn.WithDeployer(deployer),
+		fn.WithRegistryMirrors(map[string]string{"docker.io": "registry.internal/hub"}))
+	if err := client.Create(fn.Function{Name: "myfunc", Root: root, Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Image = "gcr.io/alice/myfunc:v1"
+	f.RegistryMirrors = map[string]string{"gcr.io": "registry.internal/gcr-mirror"}
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if len(built.RegistryMirrors) != 2 || built.RegistryMirrors["docker.io"] != "registry.internal/hub" {
+		t.Fatalf("expected the function to be built with the mirrors of its config and the client, got %v", built.RegistryMirrors)
+	}
+	if err = client.Deploy(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if deployed.Image != "registry.internal/gcr-mirror/alice/myfunc:v1" {
+		t.Fatalf("expected the image to be deployed from its mirror, got %v", deployed.Image)
+	}
+	if f, err = fn.NewFunction(root); err != nil {
+		t.Fatal(err)
+	}
+	if f.Image != "gcr.io/alice/myfunc:v1" || len(f.RegistryMirrors) != 1 {
+		t.Fatalf("expected the config to be unchanged, got %v and %v", f.Image, f.RegistryMirrors)
+	}
+}
+
+// TestPlan ensures that a planning Client plans the changes of creating,
+// building, deploying and removing a Function in place of making them.
+func TestPlan(t *testing.T) {
+	var (
+		root     = filepath.Join(t.TempDir(), "myfunc")
+		builder  = mock.NewBuilder()
+		pusher   = mock.NewPusher()
+		deployer = mock.NewDeployer()
+		remover  = mock.NewRemover()
+		plan     = &fn.Plan{}
+	)
+	client := fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithBuilder(builder),
+		fn.WithPusher(pusher),
+		fn.WithDeployer(deployer),
+		fn.WithRemover(remover),
+		fn.WithPlan(plan))
+
+	if err := client.Create(fn.Function{Name: "myfunc", Root: root, Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Deploy(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Remove(context.Background(), fn.Function{Name: "myfunc"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Run(context.Background(), root); !errors.Is(err, fn.ErrNotPlanned) {
+		t.Fatalf("expected ErrNotPlanned running, got %v", err)
+	}
+
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Fatalf("expected the function not to be created, got %v", err)
+	}
+	if builder.BuildInvoked || pusher.PushInvoked || deployer.DeployInvoked || remover.RemoveInvoked {
+		t.Fatal("expected the function to be neither built, pushed, deployed nor removed")
+	}
+
+	var (
+		config  = filepath.Join(root, fn.ConfigFile)
+		image   = TestRegistry + "/myfunc:latest"
+		configs int
+		actions []string
+	)
+	for _, s := range plan.Steps() {
+		if s.Action == "write" {
+			if s.Target == config {
+				configs++
+			}
+			continue
+		}
+		actions = append(actions, s.Action+" "+s.Target)
+	}
+	if configs != 1 {
+		t.Fatalf("expected the config to be planned to be written once, got %v", configs)
+	}
+	expected := []string{"build " + image, "push " + image, "deploy myfunc", "delete myfunc"}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Fatalf("expected the steps %v, got %v", expected, actions)
+	}
+}
+
+// TestTest ensures that the tests of a Function are run with its configured
+// test command, that their failure is returned with the exit code of the
+// command, and that a Function without a test command is an error.
+func TestTest(t *testing.T) {
+	root := "testdata/example.com/testTest"
+	defer using(t, root)()
+
+	client := fn.New(fn.WithRegistry(TestRegistry))
+	if err := client.Create(fn.Function{Root: root, Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if command, err := f.TestCommand(); err != nil || command != "go test ./..." {
+		t.Fatalf("expected the test command of the runtime, got '%v' (%v)", command, err)
+	}
+
+	f.Test.Command = "test \"$FUNC_NAME\" = testTest"
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Test(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Test.Command = "exit 3"
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	var failed fn.ErrTestsFailed
+	if err = client.Test(context.Background(), root); !errors.As(err, &failed) || failed.ExitCode() != 3 {
+		t.Fatalf("expected the tests to fail with exit code 3, got '%v'", err)
+	}
+
+	f.Test.Command = ""
+	f.Runtime = "cobol"
+	if _, err = f.TestCommand(); err == nil {
+		t.Fatal("expected no test command for an unknown runtime")
+	}
+}
+
+// TestDeployStatus ensures that the status of the Function as deployed is
+// recorded in its configuration, unless disabled with WithStatus(false).
+func TestDeployStatus(t *testing.T) {
+	root := "testdata/example.com/testDeployStatus"
+	defer using(t, root)()
+
+	client := fn.New(fn.WithRegistry(TestRegistry))
+	if err := client.Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Image = "example.com/alice/prebuilt:v1"
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	deploy := func(revision string, options ...fn.Option) fn.FunctionStatus {
+		t.Helper()
+		deployer := &statusDeployer{result: fn.DeploymentResult{Status: fn.Updated, URL: "http://myfunc.example.com", Revision: revision}}
+		client := fn.New(append(options, fn.WithPush(false), fn.WithDeployer(deployer))...)
+		if err := client.Deploy(context.Background(), root); err != nil {
+			t.Fatal(err)
+		}
+		f, err := fn.NewFunction(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f.Status
+	}
+
+	if status := deploy("myfunc-00001", fn.WithStatus(false)); status != (fn.FunctionStatus{}) {
+		t.Fatalf("expected no status to be recorded, got %+v", status)
+	}
+
+	before := time.Now().Add(-time.Second)
+	status := deploy("myfunc-00002")
+	if status.Image != "example.com/alice/prebuilt:v1" || status.Revision != "myfunc-00002" || status.URL != "http://myfunc.example.com" {
+		t.Fatalf("unexpected status recorded: %+v", status)
+	}
+	if status.Deployed.Before(before) {
+		t.Fatalf("expected the time deployed to be recorded, got %v", status.Deployed)
+	}
+
+	// The revision deployed before is recorded as the previous revision, to
+	// which the Function may be rolled back, unless the revision is the same.
+	if status = deploy("myfunc-00003"); status.PreviousRevision != "myfunc-00002" {
+		t.Fatalf("expected the previous revision 'myfunc-00002', got '%v'", status.PreviousRevision)
+	}
+	if status = deploy("myfunc-00003"); status.PreviousRevision != "myfunc-00002" {
+		t.Fatalf("expected the previous revision to remain 'myfunc-00002', got '%v'", status.PreviousRevision)
+	}
+
+	// The domain deployed is recorded, such that its DomainMapping may be
+	// removed by the next deploy without it.
+	if f, err = fn.NewFunction(root); err != nil {
+		t.Fatal(err)
+	}
+	f.Domain = "myfunc.example.com"
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if status = deploy("myfunc-00004"); status.Domain != "myfunc.example.com" {
+		t.Fatalf("expected the domain 'myfunc.example.com', got '%v'", status.Domain)
+	}
+}
+
+// statusDeployer returns the given result of each deploy.
+type statusDeployer struct{ result fn.DeploymentResult }
+
+func (d *statusDeployer) Deploy(context.Context, fn.Function) (fn.DeploymentResult, error) {
+	return d.result, nil
+}
+
+// TestDeployWithCredentialsProvider ensures that the image is pushed with
+// the credentials of the provider of the client, when one is provided.
+func TestDeployWithCredentialsProvider(t *testing.T) {
+	root := "testdata/example.com/testDeployWithCredentialsProvider"
+	defer using(t, root)()
+
+	pusher := mock.NewPusher()
+	provider := fn.CredentialsProviderFunc(func(ctx context.Context, registry string) (fn.Credentials, error) {
+		return fn.Credentials{Username: "alice", Password: "secret"}, nil
+	})
+	client := fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithPusher(pusher),
+		fn.WithCredentialsProvider(provider))
+	if err := client.Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Deploy(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if pusher.Credentials == nil {
+		t.Fatal("expected the image to be pushed with the credentials provider")
+	}
+	c, err := pusher.Credentials.Credentials(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Username != "alice" {
+		t.Fatalf("expected the credentials of the provider, got those of '%v'", c.Username)
+	}
+}
+
+// TestDeployByDigest ensures that the digest of the pushed image is stored in
+// the Function's configuration and that it is deployed by that digest, and
+// that rebuilding clears the digest of the image previously pushed.
+func TestDeployByDigest(t *testing.T) {
+	root := "testdata/example.com/testDeployByDigest"
+	defer using(t, root)()
+
+	const digest = "sha256:a278a91112d17f8bde6b5f802a3317c7c752cf88078dae6f4b5a0784deb81782"
+	pusher := mock.NewPusher()
+	pusher.PushFn = func(fn.Function) (string, error) { return digest, nil }
+	deployer := mock.NewDeployer()
+	deployer.DeployFn = func(f fn.Function) error {
+		if expected := TestRegistry + "/testDeployByDigest@" + digest; f.ImageWithDigest() != expected {
+			t.Fatalf("expected the image '%v' to be deployed, got '%v'", expected, f.ImageWithDigest())
+		}
+		return nil
+	}
+	client := fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithPusher(pusher),
+		fn.WithDeployer(deployer))
+	if err := client.Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Deploy(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.ImageDigest != digest {
+		t.Fatalf("expected the digest '%v' to be stored, got '%v'", digest, f.ImageDigest)
+	}
+
+	if err = client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = fn.NewFunction(root); err != nil {
+		t.Fatal(err)
+	}
+	if f.ImageDigest != "" {
+		t.Fatalf("expected the digest to be cleared when rebuilt, got '%v'", f.ImageDigest)
+	}
+}
+
+// TestRunPipeline ensures that a Function is built on the cluster from its
+// git repository, and then deployed, only when it has a repository.
+func TestRunPipeline(t *testing.T) {
+	root := "testdata/example.com/testRunPipeline"
+	defer using(t, root)()
+
+	pipelines := mock.NewPipelinesProvider()
+	deployer := mock.NewDeployer()
+	client := fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithPipelinesProvider(pipelines),
+		fn.WithDeployer(deployer))
+	if err := client.Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.RunPipeline(context.Background(), root); !errors.Is(err, fn.ErrGitRequired) {
+		t.Fatalf("expected ErrGitRequired without a git repository, got %v", err)
+	}
+
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Git.URL = "https://github.com/alice/myfunc.git"
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	pipelines.RunFn = func(f fn.Function) error {
+		if f.Image != TestRegistry+"/testRunPipeline:latest" {
+			t.Fatalf("expected the derived image to be built, got '%v'", f.Image)
+		}
+		return nil
+	}
+	if err = client.RunPipeline(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if !pipelines.RunInvoked || !deployer.DeployInvoked {
+		t.Fatal("expected the Function to be built on the cluster and deployed")
+	}
+}
+
+// TestRunPipelineArchive ensures that a Function is built on the cluster
+// from a source archive, with the image derived from the registry, and then
+// deployed.
+func TestRunPipelineArchive(t *testing.T) {
+	pipelines := mock.NewPipelinesProvider()
+	deployer := mock.NewDeployer()
+	client := fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithPipelinesProvider(pipelines),
+		fn.WithDeployer(deployer))
+
+	pipelines.RunArchiveFn = func(f fn.Function, archive string) error {
+		if f.Image != TestRegistry+"/myfunc:latest" {
+			t.Fatalf("expected the derived image to be built, got '%v'", f.Image)
+		}
+		if archive != "myfunc.tar.gz" {
+			t.Fatalf("expected the archive to be built, got '%v'", archive)
+		}
+		return nil
+	}
+	f := fn.Function{Name: "myfunc", Runtime: "go"}
+	if err := client.RunPipelineArchive(context.Background(), f, "myfunc.tar.gz"); err != nil {
+		t.Fatal(err)
+	}
+	if !pipelines.RunArchiveInvoked || !deployer.DeployInvoked {
+		t.Fatal("expected the Function to be built on the cluster and deployed")
+	}
+}
+
+// TestEmit ensures that the
+func TestEmit(t *testing.T) {
+	sink := "http://testy.mctestface.com"
+	emitter := mock.NewEmitter()
+
+	// Ensure sink passthrough from client
+	emitter.EmitFn = func(s string) error {
+		if s != sink {
+			t.Fatalf("Unexpected sink %v\n", s)
+		}
+		return nil
+	}
+
+	// Instantiate in the current working directory, with no name.
+	client := fn.New(fn.WithEmitter(emitter))
+
+	if err := client.Emit(context.Background(), sink); err != nil {
+		t.Fatal(err)
+	}
+	if !emitter.EmitInvoked {
+		t.Fatal("Client did not invoke emitter.Emit()")
+	}
+}
+
+// TestBuildHooks ensures the pre-build hook found by convention and the
+// post-build hook declared in func.yaml are run around the build, provided
+// the Function's details, and that a failing hook fails the build.
+func TestBuildHooks(t *testing.T) {
+	root := "testdata/example.com/testBuildHooks"
+	defer using(t, root)()
+
+	builder := mock.NewBuilder()
+	client := fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(builder))
+	if err := client.Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Build.PostScript = "post.sh"
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	mkdir(t, filepath.Join(root, fn.RunDataDir, fn.HooksDir))
+	writeScript(t, filepath.Join(root, fn.RunDataDir, fn.HooksDir, fn.PreBuildHook), `echo "$FUNC_NAME $FUNC_RUNTIME" > pre.out`)
+	writeScript(t, filepath.Join(root, "post.sh"), "echo post failed; exit 3")
+
+	err = client.Build(context.Background(), root)
+	var hookErr fn.ErrHookFailed
+	if !errors.As(err, &hookErr) || hookErr.Hook != fn.PostBuildHook {
+		t.Fatalf("expected the post-build hook to fail the build, got %v", err)
+	}
+	if !strings.Contains(hookErr.Output, "post failed") {
+		t.Fatalf("expected the output of the hook in the error, got %q", hookErr.Output)
+	}
+	if !builder.BuildInvoked {
+		t.Fatal("expected the function to be built before the post-build hook")
+	}
+
+	out, err := ioutil.ReadFile(filepath.Join(root, "pre.out"))
+	if err != nil {
+		t.Fatalf("expected the pre-build hook to run in the function's root: %v", err)
+	}
+	if expected := f.Name + " " + f.Runtime + "\n"; string(out) != expected {
+		t.Fatalf("expected the pre-build hook to be provided %q, got %q", expected, out)
+	}
+}
+
+// TestBuildHookOutsideRoot ensures a hook script outside of the Function's
+// root is not run, and the Function is then not built.
+func TestBuildHookOutsideRoot(t *testing.T) {
+	root := "testdata/example.com/testBuildHookOutsideRoot"
+	defer using(t, root)()
+
+	builder := mock.NewBuilder()
+	client := fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(builder))
+	if err := client.Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Build.PreScript = "../outside.sh"
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	writeScript(t, filepath.Join(root, "..", "outside.sh"), "exit 0")
+	defer os.Remove(filepath.Join(root, "..", "outside.sh"))
+
+	if err = client.Build(context.Background(), root); err == nil {
+		t.Fatal("expected a hook outside of the function's root to error")
+	}
+	if builder.BuildInvoked {
+		t.Fatal("expected the function not to be built")
+	}
+}
+
+// Helpers ----
+
+// using the given directory (creating it) returns a closure which removes the
+// directory, intended to be run in a defer statement.
+func using(t *testing.T, root string) func() {
+	t.Helper()
+	mkdir(t, root)
+	return func() {
+		rm(t, root)
+	}
+}
+
+func mkdir(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func rm(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBuildCache ensures that the cache configuration of the client is passed
+// to a caching builder, such that repeated builds of a Function reuse the
+// same cache unless it is explicitly disabled.
+func TestBuildCache(t *testing.T) {
+	root := "testdata/example.com/testBuildCache" // Root from which to run the test
+	defer using(t, root)()
+
+	if err := fn.New(fn.WithRegistry(TestRegistry)).Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		cache fn.BuildCache
+	}{
+		{"default", fn.BuildCache{}},
+		{"directory", fn.BuildCache{Dir: "/tmp/func-cache"}},
+		{"disabled", fn.BuildCache{Dir: "/tmp/func-cache", Disabled: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := mock.NewBuilder()
+			client := fn.New(
+				fn.WithRegistry(TestRegistry),
+				fn.WithBuilder(builder),
+				fn.WithBuildCache(tt.cache))
+
+			// Build twice, as subsequent builds must use the same cache.
+			for i := 0; i < 2; i++ {
+				builder.BuildCache = fn.BuildCache{}
+				if err := client.Build(context.Background(), root); err != nil {
+					t.Fatal(err)
+				}
+				if !builder.BuildInvoked {
+					t.Fatal("build did not invoke builder implementation")
+				}
+				if builder.BuildCache != tt.cache {
+					t.Fatalf("build %v: expected cache %+v, got %+v", i, tt.cache, builder.BuildCache)
+				}
+			}
+		})
+	}
+}
+
+// TestCreateDockerfile ensures that a Function created with the Dockerfile
+// builder is scaffolded with the Dockerfile of its runtime and template, and
+// records the builder, while those of runtimes without one fail to create.
+func TestCreateDockerfile(t *testing.T) {
+	root := "testdata/example.com/testCreateDockerfile"
+	defer using(t, root)()
+
+	client := fn.New(fn.WithRegistry(TestRegistry))
+	if err := client.Create(fn.Function{Root: root, Runtime: "go", Template: "events", Builder: fn.DockerfileBuilder}); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{fn.Dockerfile, "cmd/function/main.go"} {
+		if _, err := os.Stat(filepath.Join(root, file)); err != nil {
+			t.Fatalf("expected %v to be scaffolded: %v", file, err)
+		}
+	}
+	main, err := ioutil.ReadFile(filepath.Join(root, "cmd/function/main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(main), "NewHTTPReceiveHandler") {
+		t.Fatalf("expected the entrypoint of the events template, got:\n%s", main)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Builder != fn.DockerfileBuilder {
+		t.Fatalf("expected builder '%v', got '%v'", fn.DockerfileBuilder, f.Builder)
+	}
+
+	// A custom template of a runtime for which none is available, which does
+	// not itself provide a Dockerfile.
+	other := "testdata/example.com/testCreateDockerfileUnsupported"
+	defer using(t, other)()
+	client = fn.New(fn.WithRepositories("testdata/repositories"))
+	err = client.Create(fn.Function{Root: other, Runtime: "test", Template: "customProvider/tpla", Builder: fn.DockerfileBuilder})
+	if err == nil || !strings.Contains(err.Error(), "no Dockerfile") {
+		t.Fatalf("expected an error for a template without a Dockerfile, got %v", err)
+	}
+}
+
+// TestBuildDockerfile ensures that a Function of the Dockerfile builder is
+// built by the Dockerfile builder of the client, and others by its builder.
+func TestBuildDockerfile(t *testing.T) {
+	root := "testdata/example.com/testBuildDockerfile"
+	defer using(t, root)()
+
+	if err := fn.New(fn.WithRegistry(TestRegistry)).Create(fn.Function{Root: root, Runtime: "go", Builder: fn.DockerfileBuilder}); err != nil {
+		t.Fatal(err)
+	}
+
+	builder, dockerfile := mock.NewBuilder(), mock.NewBuilder()
+	client := fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithBuilder(builder),
+		fn.WithDockerfileBuilder(dockerfile))
+	if err := client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if !dockerfile.BuildInvoked || builder.BuildInvoked {
+		t.Fatal("expected the function to be built by the Dockerfile builder only")
+	}
+}
+
+// blockingBuilder is a Builder which blocks until its context is done.
+type blockingBuilder struct{}
+
+func (blockingBuilder) Build(ctx context.Context, _ fn.Function) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// TestBuildTimeout ensures that a build which does not complete within the
+// build timeout is cancelled, returning ErrBuildTimeout, and that without a
+// timeout the build is not bounded.
+func TestBuildTimeout(t *testing.T) {
+	root := "testdata/example.com/testBuildTimeout"
+	defer using(t, root)()
+
+	if err := fn.New(fn.WithRegistry(TestRegistry)).Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+
+	client := fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithBuilder(blockingBuilder{}),
+		fn.WithBuildTimeout(10*time.Millisecond))
+	if err := client.Build(context.Background(), root); !errors.Is(err, fn.ErrBuildTimeout) {
+		t.Fatalf("expected ErrBuildTimeout, got %v", err)
+	}
+
+	// Cancellation by the caller is not a timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.Build(ctx, root); err == nil || errors.Is(err, fn.ErrBuildTimeout) {
+		t.Fatalf("expected the cancellation error, got %v", err)
+	}
+
+	// Zero is no timeout.
+	builder := mock.NewBuilder()
+	client = fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(builder))
+	if err := client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if !builder.BuildInvoked {
+		t.Fatal("build did not invoke builder implementation")
+	}
+}
+
+// TestBuildPinsBuilder ensures that the digest of the builder image is
+// resolved when first built and persisted, that builds remain pinned to it,
+// and that it is re-resolved when updating.
+func TestBuildPinsBuilder(t *testing.T) {
+	root := "testdata/example.com/testBuildPinsBuilder"
+	defer using(t, root)()
+
+	if err := fn.New(fn.WithRegistry(TestRegistry)).Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+
+	first, latest := "sha256:"+strings.Repeat("a", 64), "sha256:"+strings.Repeat("b", 64)
+	builder := mock.NewBuilder()
+	builder.ResolveBuilderFn = func(fn.Function) (string, error) { return first, nil }
+	if err := fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(builder)).Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.BuilderDigest != first {
+		t.Fatalf("expected the builder digest '%v' to be persisted, got '%v'", first, f.BuilderDigest)
+	}
+
+	builder.ResolveBuilderFn = func(fn.Function) (string, error) { return latest, nil }
+	builder.BuildFn = func(f fn.Function) error {
+		if f.BuilderDigest != first {
+			t.Fatalf("expected the build to be pinned to '%v', got '%v'", first, f.BuilderDigest)
+		}
+		return nil
+	}
+	if err = fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(builder)).Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+
+	builder.BuildFn = func(fn.Function) error { return nil }
+	if err = fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(builder), fn.WithUpdateBuilder(true)).Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = fn.NewFunction(root); err != nil {
+		t.Fatal(err)
+	}
+	if f.BuilderDigest != latest {
+		t.Fatalf("expected the builder digest to be updated to '%v', got '%v'", latest, f.BuilderDigest)
+	}
+}
+
+// TestBuildExport ensures that a built image is exported to the output
+// directory only when one is provided.
+func TestBuildExport(t *testing.T) {
+	root := "testdata/example.com/testBuildExport" // Root from which to run the test
+	defer using(t, root)()
+
+	if err := fn.New(fn.WithRegistry(TestRegistry)).Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without an output directory, the image is not exported.
+	exporter := mock.NewExporter()
+	client := fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithExporter(exporter))
+	if err := client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if exporter.ExportInvoked {
+		t.Fatal("exporter invoked without an output directory")
+	}
+
+	// With an output directory, the built image is exported to it.
+	exporter.ExportFn = func(f fn.Function, format, dir string) (string, error) {
+		if dir != "dist" {
+			t.Fatalf("expected output directory 'dist', got '%v'", dir)
+		}
+		if format != "oci" {
+			t.Fatalf("expected image format 'oci', got '%v'", format)
+		}
+		if f.Image == "" {
+			t.Fatal("expected the exported Function to have an image")
+		}
+		return filepath.Join(dir, "image-oci"), nil
+	}
+	client = fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithExporter(exporter),
+		fn.WithOutputDir("dist", "oci"))
+	if err := client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if !exporter.ExportInvoked {
+		t.Fatal("build did not invoke exporter implementation")
+	}
+}
+
+// TestBuildSBOM ensures that the SBOM of a built image is exported in the
+// format requested only when a directory is provided, and that an image
+// without an SBOM fails the build with ErrNoSBOM.
+func TestBuildSBOM(t *testing.T) {
+	root := "testdata/example.com/testBuildSBOM" // Root from which to run the test
+	defer using(t, root)()
+
+	if err := fn.New(fn.WithRegistry(TestRegistry)).Create(fn.Function{Root: root}); err != nil {
+		t.Fatal(err)
+	}
+
+	exporter := mock.NewExporter()
+	client := fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithSBOMExporter(exporter))
+	if err := client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if exporter.ExportSBOMInvoked {
+		t.Fatal("SBOM exported without a directory")
+	}
+
+	exporter.ExportSBOMFn = func(f fn.Function, format, dir string) ([]string, error) {
+		if format != "spdx" || dir != "sbom" {
+			t.Fatalf("expected the spdx SBOM in 'sbom', got '%v' in '%v'", format, dir)
+		}
+		return []string{filepath.Join(dir, "sbom.spdx.json")}, nil
+	}
+	client = fn.New(
+		fn.WithRegistry(TestRegistry),
+		fn.WithSBOMExporter(exporter),
+		fn.WithSBOM("sbom", "spdx"))
+	if err := client.Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if !exporter.ExportSBOMInvoked {
+		t.Fatal("build did not export the SBOM")
+	}
+
+	exporter.ExportSBOMFn = func(fn.Function, string, string) ([]string, error) {
+		return nil, fn.ErrNoSBOM
+	}
+	if err := client.Build(context.Background(), root); !errors.Is(err, fn.ErrNoSBOM) {
+		t.Fatalf("expected ErrNoSBOM, got %v", err)
+	}
+}
+
+// TestTemplates ensures that both embedded templates and those of the
+// client's repositories are listed with their source and any declared
+// signature, description, preferences and handlers, and that they may be
+// filtered by runtime.
+func TestTemplates(t *testing.T) {
+	client := fn.New(fn.WithRepositories("testdata/repositories"))
+
+	all, err := client.Templates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []fn.Template{
+		{Name: "http", Runtime: "go", Signature: "http", Description: "Function invoked by HTTP requests", Handlers: []string{"ok", "echo"}},
+		{Name: "events", Runtime: "node", Signature: "events", Description: "Function invoked by CloudEvents"},
+		{Name: "customProvider/tpld", Runtime: "test", Repository: "customProvider", Signature: "events",
+			Description: "Function of the test runtime with rendered files",
+			Preferences: &fn.TemplatePreferences{Name: "tpldfunc", ImageSuffix: "-fn"}},
+		{Name: "customProvider/json", Runtime: "node", Repository: "customProvider"},
+	}
+	for _, e := range expected {
+		found := false
+		for _, tpl := range all {
+			if reflect.DeepEqual(tpl, e) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected template %+v to be listed in %+v", e, all)
+		}
+	}
+
+	filtered, err := client.Templates("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered) == 0 {
+		t.Fatal("expected templates of the 'test' runtime")
+	}
+	for _, tpl := range filtered {
+		if tpl.Runtime != "test" {
+			t.Fatalf("expected only templates of the 'test' runtime, got %+v", tpl)
+		}
+	}
+
+	// A nonexistent repositories location lists only the embedded templates.
+	embedded, err := fn.New(fn.WithRepositories("testdata/nonexistent")).Templates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tpl := range embedded {
+		if tpl.Repository != "" {
+			t.Fatalf("expected only embedded templates, got %+v", tpl)
+		}
+	}
+}
+
+// TestRepositoryManifest ensures that the runtimes and templates of a
+// repository are those declared by its manifest, that undeclared templates
+// are not created, and that the builders declared for the runtime are set on
+// created Functions.
+func TestRepositoryManifest(t *testing.T) {
+	root := "testdata/example.com/testRepositoryManifest"
+	defer using(t, root)()
+
+	client := fn.New(fn.WithRepositories("testdata/repositories"))
+
+	repositories, err := client.Repositories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var declared fn.Repository
+	for _, r := range repositories {
+		if r.Name == "manifestProvider" {
+			declared = r
+		}
+	}
+	if len(declared.Runtimes) != 1 || len(declared.Runtimes[0].Templates) != 1 || declared.Runtimes[0].Templates[0] != "tpla" {
+		t.Fatalf("expected only the declared template, got %+v", declared)
+	}
+
+	err = client.Create(fn.Function{Root: root, Runtime: "test", Template: "manifestProvider/undeclared"})
+	if !errors.Is(err, fn.ErrTemplateNotFound) || !errors.Is(err, fn.ErrNotDeclared) {
+		t.Fatalf("expected ErrTemplateNotFound for the undeclared template, got %v", err)
+	}
+
+	// Forced, as the failed creation is incomplete.
+	client = fn.New(fn.WithRepositories("testdata/repositories"), fn.WithForce(true))
+	if err = client.Create(fn.Function{Root: root, Runtime: "test", Template: "manifestProvider/tpla"}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Builder != "example.com/builders/test" || f.BuilderMap["alternate"] != "example.com/builders/test-alternate" {
+		t.Fatalf("expected the builders declared by the manifest, got '%v' %v", f.Builder, f.BuilderMap)
+	}
+}
+
+// writeScript writes an executable shell script of the given commands.
+func writeScript(t *testing.T, path, commands string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+commands+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestTelemetry ensures that operations are recorded only when opted in to,
+// with their outcome, the runtime and the counts of the settings of the
+// Function, and without its values.
+func TestTelemetry(t *testing.T) {
+	root := "testdata/example.com/testTelemetry"
+	defer using(t, root)()
+
+	var events []fn.TelemetryEvent
+	telemetry := fn.TelemetryFunc(func(e fn.TelemetryEvent) { events = append(events, e) })
+	name, value := "SECRET", "s3cr3t"
+	if err := fn.New(fn.WithRegistry(TestRegistry), fn.WithTelemetry(telemetry)).Create(fn.Function{Root: root, Runtime: "go", Envs: fn.Envs{{Name: &name, Value: &value}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	builder := mock.NewBuilder()
+	builder.BuildFn = func(fn.Function) error { return errors.New("build failed") }
+	if err := fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(builder), fn.WithTelemetry(telemetry)).Build(context.Background(), root); err == nil {
+		t.Fatal("expected the build to fail")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected an event of each operation, got %v", events)
+	}
+	if e := events[0]; e.Operation != "create" || !e.Success || e.Runtime != "go" || e.Counts["envs"] != 1 {
+		t.Fatalf("unexpected event of create: %+v", e)
+	}
+	if e := events[1]; e.Operation != "build" || e.Success || e.Runtime != "go" {
+		t.Fatalf("unexpected event of build: %+v", e)
+	}
+	if bb, _ := json.Marshal(events); strings.Contains(string(bb), value) || strings.Contains(string(bb), name) || strings.Contains(string(bb), "build failed") {
+		t.Fatalf("expected no values of the Function or errors to be recorded, got %s", bb)
+	}
+
+	// Without telemetry, and when planning, nothing is recorded.
+	events = nil
+	if err := fn.New(fn.WithRegistry(TestRegistry), fn.WithBuilder(mock.NewBuilder())).Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if err := fn.New(fn.WithRegistry(TestRegistry), fn.WithTelemetry(telemetry), fn.WithPlan(&fn.Plan{})).Build(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("expected no events, got %v", events)
+	}
 }
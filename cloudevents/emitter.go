@@ -3,8 +3,10 @@ package cloudevents
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
 	"github.com/cloudevents/sdk-go/v2/client"
 	"github.com/cloudevents/sdk-go/v2/event"
 	"github.com/cloudevents/sdk-go/v2/protocol/http"
@@ -17,6 +19,21 @@ const (
 	DefaultType   = "boson.fn"
 )
 
+// Content modes in which a CloudEvent is sent over HTTP: binary, its
+// attributes as Ce- headers and its data the body, or structured, the event
+// as a whole encoded as the JSON body.
+const (
+	ModeBinary     = "binary"
+	ModeStructured = "structured"
+)
+
+// Modes in which a CloudEvent may be sent.
+var Modes = []string{ModeBinary, ModeStructured}
+
+// attributes of the CloudEvents specification, which may not be given as
+// extension attributes.
+var attributes = []string{"specversion", "id", "source", "type", "datacontenttype", "dataschema", "subject", "time", "data", "data_base64"}
+
 type Emitter struct {
 	Endpoint    string
 	Source      string
@@ -24,6 +41,10 @@ type Emitter struct {
 	Id          string
 	Data        string
 	ContentType string
+	// Mode in which the event is sent, one of Modes.  Defaults to binary.
+	Mode string
+	// Extensions are the extension attributes of the event, by name.
+	Extensions map[string]string
 }
 
 func NewEmitter() *Emitter {
@@ -33,28 +54,123 @@ func NewEmitter() *Emitter {
 		Id:          uuid.NewString(),
 		Data:        "",
 		ContentType: event.TextPlain,
+		Mode:        ModeBinary,
 	}
 }
 
+// ValidateMode ensures the mode, if any, is one of Modes.
+func ValidateMode(mode string) error {
+	if mode == "" {
+		return nil
+	}
+	for _, m := range Modes {
+		if m == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("the mode must be one of %v", strings.Join(Modes, ", "))
+}
+
 func (e *Emitter) Emit(ctx context.Context, endpoint string) (err error) {
 	c, err := newClient(endpoint)
 	if err != nil {
 		return
 	}
-	evt := event.Event{
+	evt, err := e.event()
+	if err != nil {
+		return
+	}
+	if result := c.Send(e.withMode(ctx), evt); cloudevents.IsUndelivered(result) {
+		return fmt.Errorf(result.Error())
+	}
+	return nil
+}
+
+// Response to a CloudEvent sent as a request.
+type Response struct {
+	// StatusCode of the HTTP response.
+	StatusCode int
+	// Event sent in reply, if any.
+	Event *event.Event
+}
+
+// Request sends the CloudEvent to the endpoint, returning the response,
+// including any event sent in reply.  Unlike Emit, a response with an
+// unsuccessful status code is returned rather than treated as an error.
+func (e *Emitter) Request(ctx context.Context, endpoint string) (response Response, err error) {
+	c, err := newClient(endpoint)
+	if err != nil {
+		return
+	}
+	evt, err := e.event()
+	if err != nil {
+		return
+	}
+	reply, result := c.Request(e.withMode(ctx), evt)
+	if cloudevents.IsUndelivered(result) {
+		return response, fmt.Errorf(result.Error())
+	}
+	response.Event = reply
+
+	var httpResult *http.Result
+	if cloudevents.ResultAs(result, &httpResult) {
+		response.StatusCode = httpResult.StatusCode
+	}
+	return
+}
+
+// Validate the event described by the Emitter, such that it is not sent
+// missing its required attributes.
+func (e *Emitter) Validate() error {
+	_, err := e.event()
+	return err
+}
+
+// withMode returns the context with which the event is sent in the mode of
+// the Emitter.
+func (e *Emitter) withMode(ctx context.Context) context.Context {
+	if e.Mode == ModeStructured {
+		return binding.WithForceStructured(ctx)
+	}
+	return binding.WithForceBinary(ctx)
+}
+
+// event returns the CloudEvent described by the Emitter, validated, such that
+// an event missing its required attributes, such as its type or source, is
+// not sent.
+func (e *Emitter) event() (evt event.Event, err error) {
+	if e.Type == "" {
+		return evt, fmt.Errorf("the type of the CloudEvent is required")
+	}
+	if e.Source == "" {
+		return evt, fmt.Errorf("the source of the CloudEvent is required")
+	}
+	if err = ValidateMode(e.Mode); err != nil {
+		return
+	}
+	evt = event.Event{
 		Context: event.EventContextV1{
 			Type:   e.Type,
 			Source: *types.ParseURIRef(e.Source),
 			ID:     e.Id,
 		}.AsV1(),
 	}
-	if err = evt.SetData(e.ContentType, e.Data); err != nil {
-		return
+	for name, value := range e.Extensions {
+		for _, a := range attributes {
+			if strings.EqualFold(name, a) {
+				return evt, fmt.Errorf("invalid extension '%v': it is an attribute of the CloudEvents specification", name)
+			}
+		}
+		if err = evt.Context.SetExtension(name, value); err != nil {
+			return evt, fmt.Errorf("invalid extension '%v': %v", name, err)
+		}
 	}
-	if result := c.Send(ctx, evt); cloudevents.IsUndelivered(result) {
-		return fmt.Errorf(result.Error())
+	// Data is sent verbatim rather than being encoded per the content type.
+	if err = evt.SetData(e.ContentType, []byte(e.Data)); err != nil {
+		return
 	}
-	return nil
+	err = evt.Validate()
+	return
 }
 
 func newClient(target string) (c client.Client, err error) {
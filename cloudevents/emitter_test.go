@@ -138,3 +138,28 @@ func TestEmitter(t *testing.T) {
 		})
 	}
 }
+
+func TestEmitterValidate(t *testing.T) {
+	testCases := map[string]struct {
+		mutate  func(e *Emitter)
+		wantErr bool
+	}{
+		"defaults":          {func(e *Emitter) {}, false},
+		"structured":        {func(e *Emitter) { e.Mode = ModeStructured }, false},
+		"with-extension":    {func(e *Emitter) { e.Extensions = map[string]string{"tenant": "acme"} }, false},
+		"without-type":      {func(e *Emitter) { e.Type = "" }, true},
+		"without-source":    {func(e *Emitter) { e.Source = "" }, true},
+		"invalid-mode":      {func(e *Emitter) { e.Mode = "batched" }, true},
+		"invalid-extension": {func(e *Emitter) { e.Extensions = map[string]string{"ten-ant": "acme"} }, true},
+		"attribute":         {func(e *Emitter) { e.Extensions = map[string]string{"subject": "acme"} }, true},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			emitter := NewEmitter()
+			tc.mutate(emitter)
+			if err := emitter.Validate(); (err != nil) != tc.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+)
+
+func init() {
+	root.AddCommand(NewAllCmd(newDeployClient, newDeleteRemover))
+}
+
+// errSkipped is the result of a Function not operated upon with --fail-fast,
+// another having failed first.
+var errSkipped = errors.New("skipped")
+
+// NewAllCmd creates an all command, which builds, deploys or deletes each of
+// the functions beneath a directory using the given client and remover
+// creators.
+func NewAllCmd(newClient deployClientFn, newRemover deleteRemoverFn) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "all",
+		Short: "Build, deploy or delete all functions beneath a directory",
+		Long: `Build, deploy or delete all functions beneath a directory
+
+Finds the function projects in the current directory or in that provided with
+--path, and in its subdirectories, such as those of a monorepo, and builds,
+deploys or deletes each.  Hidden directories and node_modules are not searched,
+nor are the directories of the functions found.
+
+Up to --parallelism functions are operated upon at a time.  Each is reported in
+order of path as it completes, followed by a summary.  The failure of one does
+not prevent the others being operated upon, unless --fail-fast is provided.
+
+When deploying, functions chained with the next of their func.yaml are deployed
+after their next, if it is among those found, such that each chain is deployed
+from its end.  Likewise, functions are deployed after those named by the
+dependsOn of their func.yaml which are among those found, each being Ready
+before those depending on it are deployed; those not found are ignored.  A
+cycle of dependencies is an error, and a function of which the next or a
+dependency fails to deploy is skipped.  The order is reported with --verbose.
+`,
+		Example: `
+# Build all functions beneath the current directory
+kn func all build --registry quay.io/myuser
+
+# Deploy all functions beneath ./services, two at a time, stopping at the
+# first failure
+kn func all deploy --path ./services --parallelism 2 --fail-fast
+
+# Undeploy all functions beneath the current directory
+kn func all delete
+`,
+		SuggestFor: []string{"recursive"},
+	}
+
+	cmd.PersistentFlags().StringP("path", "p", cwd(), "Path to the directory beneath which functions are found (Env: $FUNC_PATH)")
+	cmd.PersistentFlags().StringP("namespace", "n", "", "Namespace of the functions. By default, the namespace in the func.yaml of each is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
+	cmd.PersistentFlags().StringP("registry", "r", "", "Registry + namespace part of the images built, ex 'quay.io/myuser', if not in the func.yaml of each function (Env: $FUNC_REGISTRY)")
+	cmd.PersistentFlags().Int("parallelism", 4, "Number of functions operated upon at a time (Env: $FUNC_PARALLELISM)")
+	cmd.PersistentFlags().Bool("fail-fast", false, "Stop at the first failure, skipping the functions not yet operated upon (Env: $FUNC_FAIL_FAST)")
+
+	cmd.AddCommand(newAllSubCmd("build", "Build all functions", "Built", newClient, newRemover))
+	cmd.AddCommand(newAllSubCmd("deploy", "Build and deploy all functions", "Deployed", newClient, newRemover))
+	cmd.AddCommand(newAllSubCmd("delete", "Undeploy all functions", "Deleted", newClient, newRemover))
+	return cmd
+}
+
+// newAllSubCmd creates the subcommand of the all command which performs the
+// named operation upon each function, reporting each as done or failed.
+func newAllSubCmd(op, short, done string, newClient deployClientFn, newRemover deleteRemoverFn) *cobra.Command {
+	return &cobra.Command{
+		Use:     op,
+		Short:   short,
+		PreRunE: bindEnv("path", "namespace", "registry", "parallelism", "fail-fast"),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			config := newAllConfig()
+			if config.Parallelism < 1 {
+				return fmt.Errorf("--parallelism must be at least 1, got %v", config.Parallelism)
+			}
+			functions, err := fn.LoadAll(config.Path, configFile())
+			if err != nil {
+				return
+			}
+			out := infoOut(cmd.OutOrStdout())
+			if len(functions) == 0 {
+				fmt.Fprintf(out, "No functions found in %v\n", config.Path)
+				return
+			}
+			if op != "build" {
+				if err = configureClusterAccess(); err != nil {
+					return
+				}
+			}
+
+			progress := &sync.Mutex{}
+			run := func(ctx context.Context, f fn.Function) error {
+				ns := config.Namespace
+				if ns == "" {
+					ns = f.Namespace
+				}
+				listener := &prefixedListener{mu: progress, out: out, prefix: f.Name, verbose: config.Verbose}
+				if op == "delete" {
+					remover, err := newRemover(ns, deleteConfig{Verbose: config.Verbose}, listener)
+					if err != nil {
+						return err
+					}
+					return fn.New(fn.WithVerbose(config.Verbose), fn.WithRemover(remover)).Remove(ctx, fn.Function{Name: f.Name})
+				}
+				client, err := newClient(config.deployConfig(f, ns), listener)
+				if err != nil {
+					return err
+				}
+				if err = client.Build(ctx, f.Root); err != nil || op == "build" {
+					return err
+				}
+				return client.Deploy(ctx, f.Root)
+			}
+
+			var results []chan error
+			if op == "deploy" {
+				levels, err := fn.DeployLevels(functions)
+				if err != nil {
+					return err
+				}
+				if config.Verbose {
+					fmt.Fprintln(out, "Deploying in order:")
+					for i, level := range levels {
+						names := make([]string, len(level))
+						for j, f := range level {
+							names[j] = f.Name
+						}
+						fmt.Fprintf(out, "  %v. %v\n", i+1, strings.Join(names, ", "))
+					}
+				}
+				results = runChained(cmd.Context(), functions, levels, config.Parallelism, config.FailFast, run)
+			} else {
+				results = runAll(cmd.Context(), functions, config.Parallelism, config.FailFast, run)
+			}
+
+			var failures []string
+			for i, result := range results {
+				f := functions[i]
+				if err = <-result; err != nil {
+					fmt.Fprintf(out, "Failed to %v function '%v' in %v: %v\n", op, f.Name, f.Root, err)
+					failures = append(failures, fmt.Sprintf("%v: %v", f.Name, err))
+					continue
+				}
+				fmt.Fprintf(out, "%v function '%v' in %v\n", done, f.Name, f.Root)
+			}
+			fmt.Fprintf(out, "%v %v of %v functions\n", done, len(functions)-len(failures), len(functions))
+			if len(failures) > 0 {
+				return fmt.Errorf("failed to %v %v of %v functions:\n  %v", op, len(failures), len(functions), strings.Join(failures, "\n  "))
+			}
+			return nil
+		},
+	}
+}
+
+// runAll runs the operation upon each of the Functions in order, at most
+// parallelism at a time, returning a channel per Function on which its result
+// is sent, in the order of the Functions regardless of the order in which they
+// complete.  With failFast, the first failure cancels those in progress, and
+// those not yet started are skipped with errSkipped.
+func runAll(ctx context.Context, functions []fn.Function, parallelism int, failFast bool, run func(context.Context, fn.Function) error) []chan error {
+	ctx, cancel := context.WithCancel(ctx)
+	results := make([]chan error, len(functions))
+	for i := range results {
+		results[i] = make(chan error, 1)
+	}
+	go func() {
+		defer cancel()
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, parallelism)
+		for i, f := range functions {
+			sem <- struct{}{}
+			if failFast && ctx.Err() != nil {
+				results[i] <- errSkipped
+				<-sem
+				continue
+			}
+			wg.Add(1)
+			go func(result chan error, f fn.Function) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				err := run(ctx, f)
+				if err != nil && failFast {
+					cancel()
+				}
+				result <- err
+			}(results[i], f)
+		}
+		wg.Wait()
+	}()
+	return results
+}
+
+// runChained runs the operation upon each of the Functions as does runAll,
+// but level by level, such that the next of each and its dependencies are
+// operated upon before it.  A Function of which the next or a dependency
+// failed is not operated upon, and with failFast, neither are those of the
+// levels after a failure.
+func runChained(ctx context.Context, functions []fn.Function, levels [][]fn.Function, parallelism int, failFast bool, run func(context.Context, fn.Function) error) []chan error {
+	results := make([]chan error, len(functions))
+	byRoot := make(map[string]chan error, len(functions))
+	for i, f := range functions {
+		results[i] = make(chan error, 1)
+		byRoot[f.Root] = results[i]
+	}
+	go func() {
+		failed := make(map[string]bool)
+		stopped := false
+		for _, level := range levels {
+			var pending []fn.Function
+			for _, f := range level {
+				dependency := ""
+				for _, d := range f.DependsOn {
+					if failed[d] {
+						dependency = d
+						break
+					}
+				}
+				switch {
+				case stopped:
+					failed[f.Name] = true
+					byRoot[f.Root] <- errSkipped
+				case failed[f.Next]:
+					failed[f.Name] = true
+					byRoot[f.Root] <- fmt.Errorf("%w: the next of its chain, '%v', failed to deploy", errSkipped, f.Next)
+				case dependency != "":
+					failed[f.Name] = true
+					byRoot[f.Root] <- fmt.Errorf("%w: its dependency, '%v', failed to deploy", errSkipped, dependency)
+				default:
+					pending = append(pending, f)
+				}
+			}
+			for i, result := range runAll(ctx, pending, parallelism, failFast, run) {
+				err := <-result
+				if err != nil {
+					failed[pending[i].Name] = true
+					stopped = stopped || failFast
+				}
+				byRoot[pending[i].Root] <- err
+			}
+		}
+	}()
+	return results
+}
+
+// prefixedListener reports the progress of one of several Functions operated
+// upon at a time as lines prefixed with its name, when verbose.
+type prefixedListener struct {
+	mu      *sync.Mutex
+	out     io.Writer
+	prefix  string
+	verbose bool
+}
+
+func (l *prefixedListener) SetTotal(int) {}
+
+func (l *prefixedListener) Increment(message string) {
+	if !l.verbose {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(l.out, "%v: %v\n", l.prefix, message)
+}
+
+func (l *prefixedListener) Complete(message string) { l.Increment(message) }
+
+func (l *prefixedListener) Done() {}
+
+type allConfig struct {
+	// Path beneath which Functions are found.
+	Path string
+
+	// Namespace override of the Functions.
+	Namespace string
+
+	// Registry from which the images of Functions without one are derived.
+	Registry string
+
+	// Parallelism is the number of Functions operated upon at a time.
+	Parallelism int
+
+	// FailFast stops at the first failure.
+	FailFast bool
+
+	// Verbose logging.
+	Verbose bool
+}
+
+// newAllConfig returns a config populated from the current execution context
+// (flags and environment variables).
+func newAllConfig() allConfig {
+	return allConfig{
+		Path:        viper.GetString("path"),
+		Namespace:   viper.GetString("namespace"),
+		Registry:    viper.GetString("registry"),
+		Parallelism: viper.GetInt("parallelism"),
+		FailFast:    viper.GetBool("fail-fast"),
+		Verbose:     viper.GetBool("verbose"),
+	}
+}
+
+// deployConfig with which the client of a Function in the given namespace is
+// created: built and pushed with the defaults of the deploy command, in the
+// cache directory of the Function, such that those built concurrently do not
+// share a cache.
+func (c allConfig) deployConfig(f fn.Function, namespace string) deployConfig {
+	return deployConfig{
+		buildConfig: buildConfig{
+			Registry:   c.Registry,
+			Verbose:    c.Verbose,
+			BuildCache: functionCachePath(f.Name),
+		},
+		Namespace: namespace,
+		Verbose:   c.Verbose,
+		Build:     true,
+		Push:      true,
+	}
+}
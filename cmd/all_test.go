@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ory/viper"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/mock"
+)
+
+// TestAll ensures that each of the functions beneath the path is operated
+// upon, the failure of one not preventing the others unless --fail-fast is
+// provided, and that the failures are returned as an error.
+func TestAll(t *testing.T) {
+	defer fromTempDir(t)()
+
+	for _, name := range []string{"a", "b", "c"} {
+		f := fn.Function{Name: name, Root: filepath.Join("services", name), Runtime: "go"}
+		if err := fn.New().Create(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		deployed []string
+		removed  []string
+	)
+	newClient := func(config deployConfig, listener fn.ProgressListener) (*fn.Client, error) {
+		builder := mock.NewBuilder()
+		builder.BuildFn = func(f fn.Function) error {
+			if f.Name == "b" {
+				return errors.New("build failed")
+			}
+			return nil
+		}
+		deployer := mock.NewDeployer()
+		deployer.DeployFn = func(f fn.Function) error {
+			mu.Lock()
+			defer mu.Unlock()
+			deployed = append(deployed, f.Name)
+			return nil
+		}
+		return fn.New(
+			fn.WithRegistry(config.Registry),
+			fn.WithBuilder(builder),
+			fn.WithPusher(mock.NewPusher()),
+			fn.WithDeployer(deployer),
+			fn.WithProgressListener(listener)), nil
+	}
+	newRemover := func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
+		remover := mock.NewRemover()
+		remover.RemoveFn = func(name string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			removed = append(removed, name)
+			return nil
+		}
+		return remover, nil
+	}
+	all := func(args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		cmd := NewAllCmd(newClient, newRemover)
+		cmd.SetOut(out)
+		cmd.SetArgs(append(args, "--path", pwd(t), "--registry", "example.com/alice"))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := all("deploy")
+	if err == nil || !strings.Contains(err.Error(), "failed to deploy 1 of 3 functions") {
+		t.Fatalf("expected the failure of function b, got '%v'", err)
+	}
+	sort.Strings(deployed)
+	if strings.Join(deployed, ",") != "a,c" {
+		t.Fatalf("expected functions a and c to be deployed, got %v", deployed)
+	}
+	if !strings.Contains(out, "Deployed 2 of 3 functions") {
+		t.Fatalf("expected a summary of the functions deployed, got:\n%v", out)
+	}
+
+	deployed = nil
+	if _, err = all("deploy", "--fail-fast", "--parallelism", "1"); err == nil || !strings.Contains(err.Error(), "c: skipped") {
+		t.Fatalf("expected function c to be skipped, got '%v'", err)
+	}
+	if strings.Join(deployed, ",") != "a" {
+		t.Fatalf("expected only function a to be deployed, got %v", deployed)
+	}
+
+	if _, err = all("delete"); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(removed)
+	if strings.Join(removed, ",") != "a,b,c" {
+		t.Fatalf("expected functions a, b and c to be deleted, got %v", removed)
+	}
+}
+
+// TestAllChained ensures functions are deployed after the next of their
+// chain, and that those of which the next failed to deploy are skipped.
+func TestAllChained(t *testing.T) {
+	defer fromTempDir(t)()
+
+	for name, next := range map[string]string{"a": "b", "b": "c", "c": "", "d": "b"} {
+		f := fn.Function{Name: name, Root: filepath.Join("services", name), Runtime: "go"}
+		if err := fn.New().Create(f); err != nil {
+			t.Fatal(err)
+		}
+		f, err := fn.NewFunction(f.Root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Next = next
+		if err = f.WriteConfig(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		deployed []string
+		failing  string
+	)
+	newClient := func(config deployConfig, listener fn.ProgressListener) (*fn.Client, error) {
+		deployer := mock.NewDeployer()
+		deployer.DeployFn = func(f fn.Function) error {
+			if f.Name == failing {
+				return errors.New("deploy failed")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			deployed = append(deployed, f.Name)
+			return nil
+		}
+		return fn.New(
+			fn.WithRegistry(config.Registry),
+			fn.WithBuilder(mock.NewBuilder()),
+			fn.WithPusher(mock.NewPusher()),
+			fn.WithDeployer(deployer),
+			fn.WithProgressListener(listener)), nil
+	}
+	all := func() (string, error) {
+		out := &bytes.Buffer{}
+		cmd := NewAllCmd(newClient, nil)
+		cmd.SetOut(out)
+		cmd.SetArgs([]string{"deploy", "--path", pwd(t), "--registry", "example.com/alice"})
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if _, err := all(); err != nil {
+		t.Fatal(err)
+	}
+	if len(deployed) != 4 || deployed[0] != "c" || deployed[1] != "b" {
+		t.Fatalf("expected function c, then b, then a and d to be deployed, got %v", deployed)
+	}
+
+	deployed, failing = nil, "b"
+	_, err := all()
+	if err == nil || !strings.Contains(err.Error(), "a: skipped: the next of its chain, 'b', failed to deploy") {
+		t.Fatalf("expected function a to be skipped, got '%v'", err)
+	}
+	if strings.Join(deployed, ",") != "c" {
+		t.Fatalf("expected only function c to be deployed, got %v", deployed)
+	}
+}
+
+// TestAllDependsOn ensures functions are deployed after those they depend on,
+// that the order is reported when verbose, and that a cycle is an error.
+func TestAllDependsOn(t *testing.T) {
+	defer fromTempDir(t)()
+
+	write := func(dependsOn map[string][]string) {
+		for name, deps := range dependsOn {
+			root := filepath.Join("services", name)
+			f, err := fn.NewFunction(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !f.Initialized() {
+				if err = fn.New().Create(fn.Function{Name: name, Root: root, Runtime: "go"}); err != nil {
+					t.Fatal(err)
+				}
+				if f, err = fn.NewFunction(root); err != nil {
+					t.Fatal(err)
+				}
+			}
+			f.DependsOn = deps
+			if err = f.WriteConfig(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	write(map[string][]string{"api": {"db"}, "db": nil, "web": {"api", "elsewhere"}})
+
+	var (
+		mu       sync.Mutex
+		deployed []string
+	)
+	newClient := func(config deployConfig, listener fn.ProgressListener) (*fn.Client, error) {
+		deployer := mock.NewDeployer()
+		deployer.DeployFn = func(f fn.Function) error {
+			mu.Lock()
+			defer mu.Unlock()
+			deployed = append(deployed, f.Name)
+			return nil
+		}
+		return fn.New(
+			fn.WithRegistry(config.Registry),
+			fn.WithBuilder(mock.NewBuilder()),
+			fn.WithPusher(mock.NewPusher()),
+			fn.WithDeployer(deployer),
+			fn.WithProgressListener(listener)), nil
+	}
+	all := func() (string, error) {
+		out := &bytes.Buffer{}
+		cmd := NewAllCmd(newClient, nil)
+		cmd.SetOut(out)
+		cmd.SetArgs([]string{"deploy", "--path", pwd(t), "--registry", "example.com/alice"})
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	viper.Set("verbose", true)
+	defer viper.Set("verbose", false)
+	out, err := all()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(deployed, ",") != "db,api,web" {
+		t.Fatalf("expected function db, then api, then web to be deployed, got %v", deployed)
+	}
+	if !strings.Contains(out, "1. db\n  2. api\n  3. web") {
+		t.Fatalf("expected the order to be reported, got:\n%v", out)
+	}
+
+	write(map[string][]string{"db": {"web"}})
+	deployed = nil
+	if _, err = all(); err == nil || !strings.Contains(err.Error(), "form a cycle") {
+		t.Fatalf("expected the cycle to be an error, got '%v'", err)
+	}
+	if len(deployed) != 0 {
+		t.Fatalf("expected no function to be deployed, got %v", deployed)
+	}
+}
@@ -1,30 +1,66 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
-	"github.com/AlecAivazis/survey/v2/terminal"
 	"github.com/ory/viper"
 	"github.com/spf13/cobra"
 
 	fn "github.com/boson-project/func"
 	"github.com/boson-project/func/buildpacks"
-	"github.com/boson-project/func/progress"
+	"github.com/boson-project/func/docker"
 )
 
 func init() {
 	root.AddCommand(buildCmd)
-	buildCmd.Flags().StringP("builder", "b", "", "Buildpack builder, either an as a an image name or a mapping name.\nSpecified value is stored in func.yaml for subsequent builds.")
+	buildCmd.Flags().StringP("builder", "b", "", "Buildpack builder, either an as a an image name or a mapping name, or '"+fn.DockerfileBuilder+"' to build with the Dockerfile of the function.\nSpecified value is stored in func.yaml for subsequent builds.")
+	buildCmd.Flags().String("builder-digest", "", "Digest of the builder image to which builds are pinned, such as sha256:a278a9..., rather than that resolved from its tag when first built. Stored in func.yaml (Env: $FUNC_BUILDER_DIGEST)")
+	buildCmd.Flags().String("builder-pull-policy", "", fmt.Sprintf("Policy with which the builder image is pulled before building, one of %v: always re-pulls it, such that the latest is used, and never uses that already present, such as in air-gapped environments. Defaults to %v. Stored in func.yaml (Env: $FUNC_BUILDER_PULL_POLICY)", strings.Join(fn.BuilderPullPolicies, ", "), fn.DefaultBuilderPullPolicy))
+	buildCmd.Flags().String("lifecycle-image", "", "Image of the buildpacks lifecycle with which the function is built, such as buildpacksio/lifecycle:0.11.1, in place of that compatible with the builder, for builders requiring a specific lifecycle. Stored in func.yaml (Env: $FUNC_LIFECYCLE_IMAGE)")
+	buildCmd.Flags().String("platform-api", "", "Buildpacks platform API at which the function is built, such as 0.4. Daemonless builds run the lifecycle at it, and other builds fail unless the lifecycle of the builder supports it. Stored in func.yaml (Env: $FUNC_PLATFORM_API)")
+	buildCmd.Flags().Bool("update-builder", false, "Resolve the digest of the builder image from its tag again, pinning builds to the latest (Env: $FUNC_UPDATE_BUILDER)")
 	buildCmd.Flags().BoolP("confirm", "c", false, "Prompt to confirm all configuration options (Env: $FUNC_CONFIRM)")
 	buildCmd.Flags().StringP("image", "i", "", "Full image name in the orm [registry]/[namespace]/[name]:[tag] (optional). This option takes precedence over --registry (Env: $FUNC_IMAGE")
 	buildCmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
 	buildCmd.Flags().StringP("registry", "r", "", "Registry + namespace part of the image to build, ex 'quay.io/myuser'.  The full image name is automatically determined based on the local directory name. If not provided the registry will be taken from func.yaml (Env: $FUNC_REGISTRY)")
+	buildCmd.Flags().String("build-cache", "", "Directory in which content is cached for reuse by subsequent builds. By default, that of the function, $XDG_CACHE_HOME/func/<name> (Env: $FUNC_BUILD_CACHE)")
+	buildCmd.Flags().Bool("no-cache", false, "Build without reusing cached content, clearing any previously cached (Env: $FUNC_NO_CACHE)")
+	buildCmd.Flags().Bool("no-oci-labels", false, "Do not label the image with the OCI labels of its source: the git remote and HEAD commit of the function's source, and the time of its source (Env: $FUNC_NO_OCI_LABELS)")
+	buildCmd.Flags().Bool("reproducible", false, "Build reproducibly, normalizing timestamps to $SOURCE_DATE_EPOCH, or else the commit time of the HEAD of the function's source, such that builds of the same source yield the same image (Env: $FUNC_REPRODUCIBLE)")
+	buildCmd.Flags().Duration("build-timeout", 0, "Time after which the build is cancelled, such as 10m. Zero is no timeout (Env: $FUNC_BUILD_TIMEOUT)")
+	buildCmd.Flags().Bool("save-image", false, "Save the built image in the output directory, as a docker-archive tarball or an OCI image layout per --save-image-format (Env: $FUNC_SAVE_IMAGE)")
+	buildCmd.Flags().StringArray("build-env", []string{}, "Environment variable set when building, such as BP_GO_VERSION=1.16, in the form NAME=VALUE. "+
+		"It is not set in the deployed function. You may provide this flag multiple times. "+
+		"To unset, specify the variable name followed by a \"-\" (e.g., NAME-). Stored in func.yaml")
+	buildCmd.Flags().StringArray("buildpack", []string{}, "Buildpack run after the default group of the builder, such as an observability buildpack, as an image, an http(s) URI or a urn:cnb:registry: ID. "+
+		"You may provide this flag multiple times, in place of the buildpacks of func.yaml. Stored in func.yaml")
+	buildCmd.Flags().String("platform", "", fmt.Sprintf("Platform for which to build the function, one of %v. Defaults to that of the builder. Stored in func.yaml (Env: $FUNC_PLATFORM)", strings.Join(fn.Platforms, ", ")))
+	buildCmd.Flags().String("pre-build", "", "Script run before the function is built, such as to generate code, as a path relative to the project directory. Stored in func.yaml (Env: $FUNC_PRE_BUILD)")
+	buildCmd.Flags().String("post-build", "", "Script run after the function is built, as a path relative to the project directory. Stored in func.yaml (Env: $FUNC_POST_BUILD)")
+	buildCmd.Flags().Bool("daemonless", false, "Build without a container daemon, with the buildpacks lifecycle of the builder image in which func runs, such as the image of a CI job. The image is pushed to the registry as it is built. Used by default when no daemon is available (Env: $FUNC_DAEMONLESS)")
+	buildCmd.Flags().String("output-dir", "", "Directory in which the image is saved when --save-image is provided. Defaults to the project directory (Env: $FUNC_OUTPUT_DIR)")
+	buildCmd.Flags().String("save-image-format", "", fmt.Sprintf("Format of the image saved with --save-image, one of %v. Defaults to %v (Env: $FUNC_SAVE_IMAGE_FORMAT)", strings.Join(fn.ImageFormats, ", "), fn.ImageFormats[0]))
+	buildCmd.Flags().String("sbom", "", "Directory to which the software bill of materials (SBOM) generated by the buildpacks is written once built, as a document per buildpack or layer of a buildpack (Env: $FUNC_SBOM)")
+	buildCmd.Flags().String("sbom-format", "", fmt.Sprintf("Format of the SBOM written with --sbom, one of %v. Defaults to %v (Env: $FUNC_SBOM_FORMAT)", strings.Join(fn.SBOMFormats, ", "), fn.SBOMFormats[0]))
 
 	err := buildCmd.RegisterFlagCompletionFunc("builder", CompleteBuilderList)
 	if err != nil {
 		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
 	}
+	registerCompletions(buildCmd, map[string]completionFunc{
+		"builder-pull-policy": completeValues(fn.BuilderPullPolicies...),
+		"platform":            completeValues(fn.Platforms...),
+		"save-image-format":   completeValues(fn.ImageFormats...),
+		"sbom-format":         completeValues(fn.SBOMFormats...),
+	})
 }
 
 var buildCmd = &cobra.Command{
@@ -37,6 +73,11 @@ specified by --path. The result will be a container image that is pushed to a re
 The func.yaml file is read to determine the image name and registry. 
 If the project has not already been built, either --registry or --image must be provided 
 and the image name is stored in the configuration file.
+
+Scripts of the project given by --pre-build and --post-build, or found in
+.func/hooks, are run before and after the function is built. Either failing
+aborts the build. They run with your privileges, so review those of projects
+you did not write before building them.
 `,
 	Example: `
 # Build from the local directory, using the given registry as target.
@@ -52,18 +93,42 @@ kn func build
 
 # Build with a custom buildpack builder
 kn func build --builder cnbs/sample-builder:bionic
+
+# Build from scratch, ignoring the content cached by previous builds
+kn func build --no-cache
+
+# Build with the latest builder image of its tag, pinning subsequent builds
+# to its digest
+kn func build --update-builder
+
+# Build with the Go buildpack selecting Go 1.16
+kn func build --build-env BP_GO_VERSION=1.16
+
+# Build the function with an additional buildpack run after those of the builder
+kn func build --buildpack gcr.io/paketo-buildpacks/datadog
+
+# Build for ARM64 machines, such as a Raspberry Pi
+kn func build --platform linux/arm64
+
+# Build, first generating code with a script of the project
+kn func build --pre-build scripts/codegen.sh
+
+# Build and save the image as a tarball in ./dist, for example for transfer
+# to an air-gapped environment
+kn func build --save-image --output-dir ./dist
+
+# Build and write the SPDX software bill of materials of the image to ./sbom
+kn func build --sbom ./sbom --sbom-format spdx
 `,
-	SuggestFor: []string{"biuld", "buidl", "built"},
-	PreRunE:    bindEnv("image", "path", "builder", "registry", "confirm"),
-	RunE:       runBuild,
+	SuggestFor:  []string{"biuld", "buidl", "built"},
+	Annotations: map[string]string{dryRunAnnotation: dryRunPlan},
+	PreRunE:     bindEnv("image", "path", "builder", "builder-digest", "builder-pull-policy", "lifecycle-image", "platform-api", "update-builder", "registry", "confirm", "build-cache", "no-cache", "build-timeout", "no-oci-labels", "reproducible", "save-image", "save-image-format", "output-dir", "platform", "pre-build", "post-build", "daemonless", "sbom", "sbom-format"),
+	RunE:        runBuild,
 }
 
 func runBuild(cmd *cobra.Command, _ []string) (err error) {
 	config, err := newBuildConfig().Prompt()
 	if err != nil {
-		if err == terminal.InterruptErr {
-			return nil
-		}
 		return
 	}
 
@@ -72,25 +137,105 @@ func runBuild(cmd *cobra.Command, _ []string) (err error) {
 		return
 	}
 
-	// Check if the Function has been initialized
-	if !function.Initialized() {
-		return fmt.Errorf("the given path '%v' does not contain an initialized function. Please create one at this path before deploying", config.Path)
+	function.BuildEnvs, err = mergeBuildEnvs(cmd, function.BuildEnvs)
+	if err != nil {
+		return
+	}
+	if function.Build.Buildpacks, err = buildpacksFromFlag(cmd, function.Build.Buildpacks); err != nil {
+		return
+	}
+
+	if config.Platform != "" {
+		if err = fn.ValidatePlatform(config.Platform); err != nil {
+			return fmt.Errorf("invalid value '%v' for --platform: %v", config.Platform, err)
+		}
+		function.Platform = config.Platform
+	}
+	if config.Daemonless && function.Builder == fn.DockerfileBuilder {
+		return fmt.Errorf("--daemonless is not supported by the %v builder, which builds with a container daemon", fn.DockerfileBuilder)
+	}
+	if config.PreBuild != "" {
+		function.Build.PreScript = config.PreBuild
+	}
+	if config.PostBuild != "" {
+		function.Build.PostScript = config.PostBuild
+	}
+	if config.BuilderDigest != "" {
+		if err = fn.ValidateDigest(config.BuilderDigest); err != nil {
+			return fmt.Errorf("invalid value '%v' for --builder-digest: %v", config.BuilderDigest, err)
+		}
+		function.BuilderDigest = config.BuilderDigest
+	}
+	if config.BuilderPullPolicy != "" {
+		if err = fn.ValidateBuilderPullPolicy(config.BuilderPullPolicy); err != nil {
+			return fmt.Errorf("invalid value '%v' for --builder-pull-policy: %v", config.BuilderPullPolicy, err)
+		}
+		function.BuilderPullPolicy = config.BuilderPullPolicy
+	}
+	if config.LifecycleImage != "" {
+		function.LifecycleImage = config.LifecycleImage
+	}
+	if config.PlatformAPI != "" {
+		if err = fn.ValidatePlatformAPI(config.PlatformAPI); err != nil {
+			return fmt.Errorf("invalid value '%v' for --platform-api: %v", config.PlatformAPI, err)
+		}
+		function.PlatformAPI = config.PlatformAPI
+	}
+
+	// Determine and validate the directory into which the image is saved,
+	// which is created unless planning.
+	plan := newPlan(dryRun())
+	var outputDir string
+	if config.SaveImage {
+		outputDir = config.OutputDir
+		if outputDir == "" {
+			outputDir = config.Path
+		}
+		if err = fn.ValidateImageFormat(config.SaveImageFormat); err != nil {
+			return fmt.Errorf("invalid value '%v' for --save-image-format: %v", config.SaveImageFormat, err)
+		}
+		if config.SaveImageFormat == "" {
+			config.SaveImageFormat = fn.ImageFormats[0]
+		}
+		if plan == nil {
+			if err = validateOutputDir(outputDir); err != nil {
+				return
+			}
+		}
+	} else if config.OutputDir != "" {
+		return fmt.Errorf("--output-dir requires --save-image")
+	} else if config.SaveImageFormat != "" {
+		return fmt.Errorf("--save-image-format requires --save-image")
+	}
+
+	// Validate the SBOM requested, the directory of which is likewise created
+	// unless planning.
+	if config.SBOM != "" {
+		if function.Builder == fn.DockerfileBuilder {
+			return fmt.Errorf("--sbom is not supported by the %v builder, which generates no SBOM", fn.DockerfileBuilder)
+		}
+		if err = fn.ValidateSBOMFormat(config.SBOMFormat); err != nil {
+			return fmt.Errorf("invalid value '%v' for --sbom-format: %v", config.SBOMFormat, err)
+		}
+		if config.SBOMFormat == "" {
+			config.SBOMFormat = fn.SBOMFormats[0]
+		}
+		if plan == nil {
+			if err = validateOutputDir(config.SBOM); err != nil {
+				return
+			}
+		}
+	} else if config.SBOMFormat != "" {
+		return fmt.Errorf("--sbom-format requires --sbom")
 	}
 
 	// If the Function does not yet have an image name and one was not provided on the command line
 	if function.Image == "" {
-		//  AND a --registry was not provided, then we need to
-		// prompt for a registry from which we can derive an image name.
-		if config.Registry == "" {
-			fmt.Println("A registry for Function images is required. For example, 'docker.io/tigerteam'.")
-
-			err = survey.AskOne(
-				&survey.Input{Message: "Registry for Function images:"},
-				&config.Registry, survey.WithValidator(survey.Required))
-			if err != nil {
-				if err == terminal.InterruptErr {
-					return nil
-				}
+		//  AND a --registry was neither provided nor persisted, nor can one be
+		// inferred from the git remote, then we need one of the docker config
+		// or to prompt for a registry from which we can derive an image name.
+		if config.Registry == "" && function.Registry == "" && fn.GitRemoteRegistry(function.Root) == "" {
+			if config.Registry, err = promptRegistry(); err != nil {
 				return
 			}
 		}
@@ -101,31 +246,204 @@ func runBuild(cmd *cobra.Command, _ []string) (err error) {
 	}
 
 	// All set, let's write changes in the config to the disk
-	err = function.WriteConfig()
-	if err != nil {
+	if plan != nil {
+		plan.WriteConfig(function)
+	} else if err = function.WriteConfig(); err != nil {
 		return
 	}
 
-	builder := buildpacks.NewBuilder()
-	builder.Verbose = config.Verbose
-
-	listener := progress.New()
-	listener.Verbose = config.Verbose
+	listener := newProgressListener(progressOut(plan), config.Verbose)
 	defer listener.Done()
 
+	progress := newBuildProgress(config.Verbose, listener)
+	builder, err := newBuilder(config, progress, true, plan != nil)
+	if err != nil {
+		return
+	}
+
+	dockerfileBuilder := docker.NewBuilder()
+	dockerfileBuilder.Verbose = config.Verbose
+
 	context := cmd.Context()
 	go func() {
 		<-context.Done()
 		listener.Done()
 	}()
 
+	mirrors, err := registryMirrors()
+	if err != nil {
+		return
+	}
+
 	client := fn.New(
 		fn.WithVerbose(config.Verbose),
+		fn.WithConfigFile(configFile()),
 		fn.WithRegistry(config.Registry), // for deriving image name when --image not provided explicitly.
+		fn.WithRegistryMirrors(mirrors),
 		fn.WithBuilder(builder),
-		fn.WithProgressListener(listener))
+		fn.WithDockerfileBuilder(dockerfileBuilder),
+		fn.WithBuildCache(config.buildCache()),
+		fn.WithBuildTimeout(config.BuildTimeout),
+		fn.WithUpdateBuilder(config.UpdateBuilder),
+		fn.WithSourceLabels(!config.NoOCILabels),
+		fn.WithReproducible(config.Reproducible),
+		fn.WithExporter(docker.NewExporter()),
+		fn.WithOutputDir(outputDir, config.SaveImageFormat),
+		fn.WithSBOMExporter(docker.NewExporter()),
+		fn.WithSBOM(config.SBOM, config.SBOMFormat),
+		fn.WithProgressListener(listener),
+		fn.WithPlan(plan))
+
+	if err = client.Build(context, config.Path); err != nil {
+		return
+	}
+	if plan != nil {
+		return plan.Print(cmd.OutOrStdout())
+	}
+	// The summary of the phases is of the output, other than with
+	// --json-logs, of which the phases ended are events.
+	if jsonEvents() == nil {
+		listener.Done()
+		progress.summary(infoOut(cmd.OutOrStdout()))
+	}
+	return
+}
+
+// buildProgress reports the phases of a build to the given listener as each
+// begins and ends, with its duration, as a concise summary of the build when
+// its logs are not shown.  When verbose, the phases are evident from the logs
+// as they begin, and the duration of each buildpack is reported as it ends.
+// The phases ended are kept for a summary of the build.
+type buildProgress struct {
+	listener fn.ProgressListener
+	verbose  bool
+	phases   []buildpacks.PhaseTiming
+}
+
+func newBuildProgress(verbose bool, listener fn.ProgressListener) *buildProgress {
+	return &buildProgress{listener: listener, verbose: verbose}
+}
+
+func (p *buildProgress) begin(phase string) {
+	if !p.verbose {
+		p.listener.Increment(fmt.Sprintf("Building function image (%v)", phase))
+	}
+}
 
-	return client.Build(context, config.Path)
+func (p *buildProgress) done(timing buildpacks.PhaseTiming) {
+	p.phases = append(p.phases, timing)
+	p.listener.Increment(fmt.Sprintf("Built function image (%v) in %v", timing.Phase, roundDuration(timing.Duration)))
+	if !p.verbose {
+		return
+	}
+	for _, b := range timing.Buildpacks {
+		p.listener.Increment(fmt.Sprintf("Built function image (%v) with %v in %v", timing.Phase, b.Buildpack, roundDuration(b.Duration)))
+	}
+}
+
+// progress returns the reporter of the beginning of phases, as of the
+// builders, which is unset when verbose.
+func (p *buildProgress) progress() func(phase string) {
+	if p.verbose {
+		return nil
+	}
+	return p.begin
+}
+
+// summary writes a table of the duration of each phase of the build, and of
+// the build in total.  Nothing is written if no phases were reported, as when
+// built from a Dockerfile.
+func (p *buildProgress) summary(w io.Writer) {
+	if len(p.phases) == 0 {
+		return
+	}
+	tabWriter := tabwriter.NewWriter(boldHeader(w), 0, 8, 2, ' ', 0)
+	defer tabWriter.Flush()
+
+	var total time.Duration
+	fmt.Fprintf(tabWriter, "%s\t%s\n", "PHASE", "DURATION")
+	for _, phase := range p.phases {
+		fmt.Fprintf(tabWriter, "%s\t%v\n", phase.Phase, roundDuration(phase.Duration))
+		total += phase.Duration
+	}
+	fmt.Fprintf(tabWriter, "%s\t%v\n", "total", roundDuration(total))
+}
+
+// roundDuration rounds durations of builds to a precision which is readable.
+func roundDuration(d time.Duration) time.Duration {
+	return d.Round(100 * time.Millisecond)
+}
+
+// daemonPingTimeout is the time within which the container daemon must respond
+// to be considered available.
+const daemonPingTimeout = 5 * time.Second
+
+// newBuilder returns the builder of Functions with buildpacks: one which builds
+// without a container daemon when requested, or when building and no daemon
+// is available but the buildpacks lifecycle is, and otherwise one which
+// builds with the daemon, as is preferred when available.  A daemonless
+// builder is also the pusher of the images it builds.  Neither being
+// available when building is an error.  Nothing is checked when planning, nor
+// for a Function built from its Dockerfile, which is not built with
+// buildpacks.
+func newBuilder(config buildConfig, progress *buildProgress, building, planning bool) (fn.Builder, error) {
+	daemonless := config.Daemonless
+	if daemonless && !buildpacks.DaemonlessAvailable() {
+		return nil, fmt.Errorf("--daemonless: %w", buildpacks.ErrDaemonlessUnavailable)
+	}
+	if !daemonless && building && !planning && !builtFromDockerfile(config) {
+		ctx, cancel := context.WithTimeout(context.Background(), daemonPingTimeout)
+		defer cancel()
+		if err := docker.CheckAvailable(ctx); err != nil {
+			if !buildpacks.DaemonlessAvailable() {
+				return nil, fmt.Errorf("the function can not be built: no container daemon is available, nor the buildpacks lifecycle for a daemonless build. Start docker or podman, setting DOCKER_HOST to the socket of podman, or build in a builder image with --daemonless")
+			}
+			daemonless = true
+		}
+	}
+	if daemonless {
+		if config.SaveImage {
+			return nil, fmt.Errorf("--save-image is not supported by daemonless builds, which push the image as it is built")
+		}
+		if config.SBOM != "" {
+			return nil, fmt.Errorf("--sbom is not supported by daemonless builds, which push the image as it is built")
+		}
+		builder := buildpacks.NewDaemonlessBuilder()
+		builder.Verbose = config.Verbose
+		builder.Progress = progress.progress()
+		builder.PhaseDone = progress.done
+		builder.Credentials = newCredentialsProvider()
+		return builder, nil
+	}
+	builder := buildpacks.NewBuilder()
+	builder.Verbose = config.Verbose
+	builder.Progress = progress.progress()
+	builder.PhaseDone = progress.done
+	return builder, nil
+}
+
+// builtFromDockerfile returns whether the Function of the build is built from
+// its Dockerfile, by the builder given or otherwise by that of its config.
+func builtFromDockerfile(config buildConfig) bool {
+	if config.Builder != "" {
+		return config.Builder == fn.DockerfileBuilder
+	}
+	f, err := fn.Load(config.Path, configFile())
+	return err == nil && f.Builder == fn.DockerfileBuilder
+}
+
+// validateOutputDir ensures the given directory exists, creating it if
+// necessary, and that it is writable.
+func validateOutputDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("unable to create output directory '%v': %v", dir, err)
+	}
+	f, err := ioutil.TempFile(dir, ".func-write-test")
+	if err != nil {
+		return fmt.Errorf("output directory '%v' is not writable: %v", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
 }
 
 type buildConfig struct {
@@ -151,6 +469,63 @@ type buildConfig struct {
 	// with interactive prompting (only applicable when attached to a TTY).
 	Confirm bool
 	Builder string
+
+	// BuilderDigest of the builder image to which builds are pinned.
+	BuilderDigest string
+
+	// BuilderPullPolicy with which the builder image is pulled.
+	BuilderPullPolicy string
+
+	// LifecycleImage and PlatformAPI of the buildpacks lifecycle with which
+	// the Function is built.
+	LifecycleImage string
+	PlatformAPI    string
+
+	// UpdateBuilder re-resolves the digest of the builder image.
+	UpdateBuilder bool
+
+	// BuildCache is the directory in which content is cached across builds.
+	// By default, that of the Function at Path.
+	BuildCache string
+
+	// NoCache forces a clean build, clearing any cached content.
+	NoCache bool
+
+	// BuildTimeout after which the build is cancelled.  Zero is no timeout.
+	BuildTimeout time.Duration
+
+	// NoOCILabels disables labeling the image with the OCI labels of its
+	// source.
+	NoOCILabels bool
+
+	// Reproducible normalizes the timestamps of the build to the source date
+	// epoch, such that builds of the same source yield the same image.
+	Reproducible bool
+
+	// SaveImage writes the built image to OutputDir, as a docker-archive
+	// tarball or an OCI image layout per SaveImageFormat.
+	SaveImage       bool
+	SaveImageFormat string
+
+	// OutputDir in which generated artifacts, such as a saved image, are
+	// written.  Defaults to the Function's path.
+	OutputDir string
+
+	// Platform for which the Function is built, such as linux/arm64.
+	Platform string
+
+	// PreBuild and PostBuild scripts run before and after the Function is
+	// built.
+	PreBuild  string
+	PostBuild string
+
+	// Daemonless builds without a container daemon.
+	Daemonless bool
+
+	// SBOM is the directory to which the software bill of materials of the
+	// built image is written, in SBOMFormat.
+	SBOM       string
+	SBOMFormat string
 }
 
 func newBuildConfig() buildConfig {
@@ -161,9 +536,43 @@ func newBuildConfig() buildConfig {
 		Verbose:  viper.GetBool("verbose"), // defined on root
 		Confirm:  viper.GetBool("confirm"),
 		Builder:  viper.GetString("builder"),
+
+		BuilderDigest:   viper.GetString("builder-digest"),
+		UpdateBuilder:   viper.GetBool("update-builder"),
+		BuildCache:      viper.GetString("build-cache"),
+		NoCache:         viper.GetBool("no-cache"),
+		BuildTimeout:    viper.GetDuration("build-timeout"),
+		NoOCILabels:     viper.GetBool("no-oci-labels"),
+		Reproducible:    viper.GetBool("reproducible"),
+		SaveImage:       viper.GetBool("save-image"),
+		SaveImageFormat: viper.GetString("save-image-format"),
+		OutputDir:       viper.GetString("output-dir"),
+		Platform:        viper.GetString("platform"),
+		PreBuild:        viper.GetString("pre-build"),
+		PostBuild:       viper.GetString("post-build"),
+		Daemonless:      viper.GetBool("daemonless"),
+		SBOM:            viper.GetString("sbom"),
+		SBOMFormat:      viper.GetString("sbom-format"),
+
+		BuilderPullPolicy: viper.GetString("builder-pull-policy"),
+		LifecycleImage:    viper.GetString("lifecycle-image"),
+		PlatformAPI:       viper.GetString("platform-api"),
 	}
 }
 
+// buildCache returns the cache configuration of the builder: the directory
+// given, or else the cache directory of the Function at Path, such that
+// Functions do not share a cache.
+func (c buildConfig) buildCache() fn.BuildCache {
+	dir := c.BuildCache
+	if dir == "" {
+		if f, err := fn.NewFunctionFromFile(c.Path, configFile()); err == nil && f.Name != "" {
+			dir = functionCachePath(f.Name)
+		}
+	}
+	return fn.BuildCache{Dir: dir, Disabled: c.NoCache}
+}
+
 // Prompt the user with value of config members, allowing for interaractive changes.
 // Skipped if not in an interactive terminal (non-TTY), or if --confirm false (agree to
 // all prompts) was set (default).
@@ -173,7 +582,28 @@ func (c buildConfig) Prompt() (buildConfig, error) {
 		return c, nil
 	}
 
-	bc := buildConfig{Verbose: c.Verbose}
+	bc := buildConfig{
+		Verbose:         c.Verbose,
+		BuilderDigest:   c.BuilderDigest,
+		UpdateBuilder:   c.UpdateBuilder,
+		BuildCache:      c.BuildCache,
+		NoCache:         c.NoCache,
+		NoOCILabels:     c.NoOCILabels,
+		Reproducible:    c.Reproducible,
+		SaveImage:       c.SaveImage,
+		SaveImageFormat: c.SaveImageFormat,
+		OutputDir:       c.OutputDir,
+		Platform:        c.Platform,
+		PreBuild:        c.PreBuild,
+		PostBuild:       c.PostBuild,
+		Daemonless:      c.Daemonless,
+		SBOM:            c.SBOM,
+		SBOMFormat:      c.SBOMFormat,
+
+		BuilderPullPolicy: c.BuilderPullPolicy,
+		LifecycleImage:    c.LifecycleImage,
+		PlatformAPI:       c.PlatformAPI,
+	}
 
 	var qs = []*survey.Question{
 		{
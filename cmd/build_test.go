@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boson-project/func/buildpacks"
+)
+
+type recordingListener struct {
+	messages []string
+}
+
+func (l *recordingListener) SetTotal(int)             {}
+func (l *recordingListener) Increment(message string) { l.messages = append(l.messages, message) }
+func (l *recordingListener) Complete(string)          {}
+func (l *recordingListener) Done()                    {}
+
+// TestBuildProgress ensures phases are reported as they begin and end, with
+// the durations of buildpacks when verbose, and summarized in a table.
+func TestBuildProgress(t *testing.T) {
+	listener := &recordingListener{}
+	p := newBuildProgress(true, listener)
+	if p.progress() != nil {
+		t.Fatal("expected the phases not to be reported as they begin when verbose")
+	}
+	p.done(buildpacks.PhaseTiming{Phase: "detecting", Duration: 1230 * time.Millisecond})
+	p.done(buildpacks.PhaseTiming{Phase: "building", Duration: 16 * time.Second,
+		Buildpacks: []buildpacks.BuildpackTiming{{Buildpack: "Paketo Go Build 0.2.1", Duration: 10 * time.Second}}})
+
+	expected := []string{
+		"Built function image (detecting) in 1.2s",
+		"Built function image (building) in 16s",
+		"Built function image (building) with Paketo Go Build 0.2.1 in 10s",
+	}
+	if len(listener.messages) != len(expected) {
+		t.Fatalf("expected %q, got %q", expected, listener.messages)
+	}
+	for i := range expected {
+		if listener.messages[i] != expected[i] {
+			t.Fatalf("expected %q, got %q", expected[i], listener.messages[i])
+		}
+	}
+
+	var b bytes.Buffer
+	p.summary(&b)
+	table := "PHASE      DURATION\ndetecting  1.2s\nbuilding   16s\ntotal      17.2s\n"
+	if b.String() != table {
+		t.Fatalf("expected the summary:\n%v\ngot:\n%v", table, b.String())
+	}
+}
+
+// TestBuildConfigCache ensures that by default the Function is built in its
+// own cache directory, and otherwise in that given.
+func TestBuildConfigCache(t *testing.T) {
+	defer fromTempDir(t)()
+	root := pwd(t)
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(root, "cache"))
+	if err := ioutil.WriteFile("func.yaml", []byte("name: myfunc\nruntime: go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if cache := (buildConfig{Path: root}).buildCache(); cache.Dir != filepath.Join(root, "cache", "func", "myfunc") {
+		t.Fatalf("expected the cache of the function by default, got %v", cache.Dir)
+	}
+	if cache := (buildConfig{Path: root, BuildCache: "/tmp/cache", NoCache: true}).buildCache(); cache.Dir != "/tmp/cache" || !cache.Disabled {
+		t.Fatalf("expected the cache given, got %+v", cache)
+	}
+}
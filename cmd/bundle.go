@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/docker"
+)
+
+func init() {
+	root.AddCommand(NewBundleCmd(newBundleClient))
+}
+
+// newBundleClient returns an instance of fn.Client for the "Bundle" commands,
+// exporting images from the local docker daemon.
+func newBundleClient() *fn.Client {
+	return fn.New(fn.WithLayoutExporter(docker.NewExporter()))
+}
+
+// NewBundleCmd creates a bundle command, and its subcommands, using the given
+// client creator.
+func NewBundleCmd(newClient func() *fn.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Export and import functions as bundles",
+		Long: `Export and import functions as bundles
+
+A bundle is a gzipped tarball of a function, with which it is moved between
+environments, such as into an air-gapped one, or archived.  It is composed of
+the source of the function, with its func.yaml, less the files excluded by its
+.funcignore, and optionally of its built image as an OCI image layout.  Its
+manifest, bundle.yaml, declares the function and the digest of each file,
+with which the bundle is validated when imported.
+`,
+		SuggestFor: []string{"bundel", "pack", "unpack"},
+	}
+	cmd.AddCommand(newBundleExportCmd(newClient))
+	cmd.AddCommand(newBundleImportCmd(newClient))
+	return cmd
+}
+
+func newBundleExportCmd(newClient func() *fn.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [file]",
+		Short: "Export a function as a bundle",
+		Long: `Export a function as a bundle
+
+Writes the bundle of the function in the current directory, or in that
+provided with --path, to the given file, by default <name>.bundle.tar.gz in
+the current directory.  Its source is that of its build: its files less those
+excluded by its .funcignore, or else its .gitignore, and .git and .func.
+
+With --with-image, the image of the function, as built in the local container
+daemon, is included as an OCI image layout, such that it may be pushed to a
+registry of the environment to which the bundle is moved.
+`,
+		Example: `
+# Export the function in the current directory as myfunc.bundle.tar.gz
+kn func bundle export
+
+# Export the function with its built image, to be moved to an air-gapped cluster
+kn func build
+kn func bundle export --with-image /media/usb/myfunc.tar.gz
+`,
+		Args:    cobra.MaximumNArgs(1),
+		PreRunE: bindEnv("path", "with-image"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := fn.Load(viper.GetString("path"), configFile())
+			if err != nil {
+				return err
+			}
+			file := f.Name + ".bundle.tar.gz"
+			if len(args) > 0 {
+				file = args[0]
+			}
+			if err = newClient().ExportBundle(cmd.Context(), f, file, viper.GetBool("with-image")); err != nil {
+				return err
+			}
+			fmt.Fprintf(infoOut(cmd.OutOrStdout()), "Function '%v' exported to %v\n", f.Name, file)
+			return nil
+		},
+	}
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	cmd.Flags().Bool("with-image", false, "Include the built image of the function as an OCI image layout (Env: $FUNC_WITH_IMAGE)")
+	return cmd
+}
+
+func newBundleImportCmd(newClient func() *fn.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import a function from a bundle",
+		Long: `Import a function from a bundle
+
+Validates the bundle, that its files are those of its manifest, and that its
+source has the func.yaml of the function it declares, and then reconstructs
+the function in the directory provided with --path, by default one named after
+the function in the current directory.
+
+A directory which is not empty is refused, unless --force is given, in which
+case the files of the bundle are written over those in it.  The image included
+in the bundle, if any, is written as an OCI image layout to --image-dir, if
+given, from which it may be pushed to a registry, as with 'crane push' or
+'skopeo copy'.
+`,
+		Example: `
+# Import the function of the bundle into the directory myfunc
+kn func bundle import myfunc.bundle.tar.gz
+
+# Import the function, writing its image as an OCI image layout to push it to
+# a registry of an air-gapped cluster
+kn func bundle import /media/usb/myfunc.tar.gz --path myfunc --image-dir myfunc-image
+`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: bindEnv("path", "image-dir", "force"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := newClient().ImportBundle(args[0], viper.GetString("path"), viper.GetString("image-dir"), viper.GetBool("force"))
+			if errors.Is(err, fn.ErrProjectExists) {
+				return fmt.Errorf("%w. Provide --force to write the files of the bundle over those in it", err)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(infoOut(cmd.OutOrStdout()), "Function '%v' imported to %v\n", f.Name, f.Root)
+			return nil
+		},
+	}
+	cmd.Flags().StringP("path", "p", "", "Path of the directory into which the function is imported. By default, a directory named after the function in the current directory (Env: $FUNC_PATH)")
+	cmd.Flags().String("image-dir", "", "Directory to which the image included in the bundle is written as an OCI image layout (Env: $FUNC_IMAGE_DIR)")
+	cmd.Flags().BoolP("force", "f", false, "Import into a directory which is not empty, writing the files of the bundle over those in it (Env: $FUNC_FORCE)")
+	return cmd
+}
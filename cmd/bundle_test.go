@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/mock"
+)
+
+// TestBundle ensures that a function is exported as a bundle, with its image
+// when requested, and imported from it, and that importing into a directory
+// which is not empty is refused without --force.
+func TestBundle(t *testing.T) {
+	defer fromTempDir(t)()
+	nonInteractive(t)
+
+	if err := fn.New().Create(fn.Function{Root: "myfunc", Name: "myfunc", Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	exporter := mock.NewExporter()
+	bundle := func(args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		cmd := NewBundleCmd(func() *fn.Client {
+			return fn.New(fn.WithLayoutExporter(exporter))
+		})
+		cmd.SetOut(out)
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if _, err := bundle("export", "--path", "myfunc", "--with-image"); err == nil || !strings.Contains(err.Error(), "not built") {
+		t.Fatalf("expected an error bundling the image of a function not built, got %v", err)
+	}
+	out, err := bundle("export", "--path", "myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "exported to myfunc.bundle.tar.gz") || exporter.ExportLayoutInvoked {
+		t.Fatalf("expected the function to be exported without its image, got %v", out)
+	}
+
+	if _, err = bundle("import", "myfunc.bundle.tar.gz"); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected the import over the function to require --force, got %v", err)
+	}
+	if _, err = bundle("import", "myfunc.bundle.tar.gz", "--path", "imported"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.Load("imported", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "myfunc" || f.Runtime != "go" {
+		t.Fatalf("unexpected function imported: %+v", f)
+	}
+	if _, err = os.Stat(filepath.Join("imported", "handle.go")); err != nil {
+		t.Fatalf("expected the source of the function to be imported: %v", err)
+	}
+	if _, err = bundle("import", "myfunc.bundle.tar.gz", "--path", "imported", "--force"); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Image = "example.com/alice/myfunc:v1"
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = bundle("export", "--path", "imported", "--with-image", "with-image.tar.gz"); err != nil || !exporter.ExportLayoutInvoked {
+		t.Fatalf("expected the image to be exported, got %v", err)
+	}
+}
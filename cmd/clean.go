@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/docker"
+)
+
+func init() {
+	root.AddCommand(NewCleanCmd())
+}
+
+// NewCleanCmd creates a clean command, which removes the local build
+// artifacts and caches of a function.
+func NewCleanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove the local build artifacts and caches of a function",
+		Long: `Remove the local build artifacts and caches of a function
+
+Removes the build cache of the function in the current directory, or in that
+provided with --path, and the image archive or OCI image layout saved in its
+directory with func build --save-image, if any, reporting the space
+reclaimed.  The build cache is that given with --build-cache, by default that
+of the function, $XDG_CACHE_HOME/func/<name>, such that its next build is
+built from scratch while the caches of other functions are kept.
+
+With --all, the entire func cache directory is removed, along with the
+artifacts of the function, if any.
+
+Only the func cache directory, and the files within it, are removed: a
+--build-cache outside of it is refused.  Provide --dry-run to list what would
+be removed without removing it.
+`,
+		Example: `
+# Remove the build cache and artifacts of the function in the current directory
+kn func clean
+
+# List what clearing the entire func cache directory would remove
+kn func clean --all --dry-run
+`,
+		SuggestFor:  []string{"clena", "prune"},
+		Annotations: map[string]string{dryRunAnnotation: dryRunPlan},
+		PreRunE:     bindEnv("path", "build-cache", "all"),
+		RunE:        runClean,
+	}
+
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	cmd.Flags().String("build-cache", "", "Directory in which content is cached by builds, which is removed. By default, that of the function, $XDG_CACHE_HOME/func/<name> (Env: $FUNC_BUILD_CACHE)")
+	cmd.Flags().Bool("all", false, "Remove the entire func cache directory, $XDG_CACHE_HOME/func (~/.cache/func if XDG_CACHE_HOME is not set) (Env: $FUNC_ALL)")
+
+	return cmd
+}
+
+func runClean(cmd *cobra.Command, args []string) (err error) {
+	config := newCleanConfig()
+	cache, err := filepath.Abs(cachePath())
+	if err != nil {
+		return
+	}
+
+	f, err := fn.NewFunctionFromFile(config.Path, configFile())
+	if err != nil {
+		return
+	}
+	if !f.Initialized() && !config.All {
+		return fmt.Errorf("the given path '%v' does not contain an initialized function", config.Path)
+	}
+
+	var paths []string
+	if config.All {
+		paths = append(paths, cache)
+	} else {
+		if config.BuildCache == "" {
+			config.BuildCache = functionCachePath(f.Name)
+		}
+		dir, err := filepath.Abs(config.BuildCache)
+		if err != nil {
+			return err
+		}
+		if dir == cache || !withinDir(cache, dir) {
+			return fmt.Errorf("refusing to remove the build cache '%v', which is not within the func cache directory '%v'", dir, cache)
+		}
+		paths = append(paths, dir)
+	}
+	if f.Initialized() && f.Image != "" {
+		archive := filepath.Join(f.Root, docker.ArchiveName(f.Image))
+		if fi, err := os.Lstat(archive); err == nil && fi.Mode().IsRegular() {
+			paths = append(paths, archive)
+		}
+		layout := filepath.Join(f.Root, docker.LayoutName(f.Image))
+		if _, err := os.Lstat(filepath.Join(layout, "oci-layout")); err == nil {
+			paths = append(paths, layout)
+		}
+	}
+
+	plan := newPlan(dryRun())
+	out := infoOut(cmd.OutOrStdout())
+	var (
+		removed   int
+		reclaimed int64
+	)
+	for _, path := range paths {
+		size, err := diskUsage(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if plan != nil {
+			plan.Add(fn.PlanStep{Action: "delete", Target: path, Detail: formatSize(size)})
+			continue
+		}
+		if err = os.RemoveAll(path); err != nil {
+			return err
+		}
+		removed++
+		reclaimed += size
+		fmt.Fprintf(out, "Removed %v (%v)\n", path, formatSize(size))
+	}
+	if plan != nil {
+		return plan.Print(cmd.OutOrStdout())
+	}
+	if removed == 0 {
+		fmt.Fprintln(out, "Nothing to clean")
+		return
+	}
+	fmt.Fprintf(out, "Reclaimed %v\n", formatSize(reclaimed))
+	return
+}
+
+// withinDir returns whether the absolute path is dir or beneath it.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// diskUsage returns the size of the regular files at path, the links within
+// it not being followed.
+func diskUsage(path string) (size int64, err error) {
+	if _, err = os.Lstat(path); err != nil {
+		return
+	}
+	err = filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	return
+}
+
+// formatSize returns the size in bytes in decimal units, such as 12.3 MB.
+func formatSize(size int64) string {
+	const unit = 1000
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "kMGTPE"[exp])
+}
+
+type cleanConfig struct {
+	// Path of the Function implementation on local disk. Defaults to current
+	// working directory of the process.
+	Path string
+
+	// BuildCache is the directory in which content is cached across builds,
+	// which is removed.  By default, that of the Function.
+	BuildCache string
+
+	// All removes the entire func cache directory.
+	All bool
+}
+
+// newCleanConfig returns a config populated from the current execution
+// context (flags and environment variables).
+func newCleanConfig() cleanConfig {
+	return cleanConfig{
+		Path:       viper.GetString("path"),
+		BuildCache: viper.GetString("build-cache"),
+		All:        viper.GetBool("all"),
+	}
+}
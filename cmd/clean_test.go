@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCleanCmd ensures the build cache and saved images of the function are
+// removed, reporting the space reclaimed, and the caches of other functions
+// kept, that --dry-run only lists them, that --all removes the entire cache
+// directory and that a build cache outside of it is refused.
+func TestCleanCmd(t *testing.T) {
+	defer fromTempDir(t)()
+	root := pwd(t)
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", filepath.Join(root, "cache"))
+
+	fnRoot := filepath.Join(root, "myfunc")
+	buildCache := filepath.Join(root, "cache", "func", "myfunc")
+	other := filepath.Join(root, "cache", "func", "other")
+	for path, content := range map[string]string{
+		filepath.Join(fnRoot, "func.yaml"):                "name: myfunc\nruntime: go\nimage: example.com/alice/myfunc:latest\n",
+		filepath.Join(fnRoot, "myfunc.tar"):               "image",
+		filepath.Join(fnRoot, "myfunc-oci", "oci-layout"): "layout",
+		filepath.Join(buildCache, "go", "mod.cache"):      "dependencies",
+		filepath.Join(other, "cached"):                    "other",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clean := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := withDryRun(t, NewCleanCmd())
+		cmd.SetOut(&out)
+		cmd.SetArgs(append([]string{"clean", "-p", fnRoot}, args...))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := clean("--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, buildCache) || !strings.Contains(out, filepath.Join(fnRoot, "myfunc.tar")) || !strings.Contains(out, filepath.Join(fnRoot, "myfunc-oci")) || !strings.Contains(out, "12 B") {
+		t.Fatalf("expected the build cache and saved images to be listed, got:\n%v", out)
+	}
+	if _, err = os.Stat(buildCache); err != nil {
+		t.Fatalf("expected nothing to be removed with --dry-run, got %v", err)
+	}
+
+	if out, err = clean(); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{buildCache, filepath.Join(fnRoot, "myfunc.tar"), filepath.Join(fnRoot, "myfunc-oci")} {
+		if _, err = os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("expected %v to be removed, got %v", path, err)
+		}
+	}
+	if _, err = os.Stat(other); err != nil {
+		t.Fatalf("expected the cache of other functions to be kept, got %v", err)
+	}
+	if !strings.Contains(out, "Reclaimed 23 B") {
+		t.Fatalf("expected the space reclaimed to be reported, got:\n%v", out)
+	}
+
+	if out, err = clean(); err != nil || !strings.Contains(out, "Nothing to clean") {
+		t.Fatalf("expected nothing to clean, got %v:\n%v", err, out)
+	}
+
+	if _, err = clean("--all"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(root, "cache", "func")); !os.IsNotExist(err) {
+		t.Fatalf("expected the cache directory to be removed, got %v", err)
+	}
+
+	if _, err = clean("--build-cache", fnRoot); err == nil || !strings.Contains(err.Error(), "not within the func cache directory") {
+		t.Fatalf("expected a build cache outside the cache directory to be refused, got %v", err)
+	}
+	if _, err = os.Stat(fnRoot); err != nil {
+		t.Fatalf("expected the function to be kept, got %v", err)
+	}
+}
+
+// TestFormatSize ensures sizes are formatted in decimal units.
+func TestFormatSize(t *testing.T) {
+	for size, expected := range map[int64]string{
+		0:          "0 B",
+		999:        "999 B",
+		1500:       "1.5 kB",
+		12300000:   "12.3 MB",
+		2000000000: "2.0 GB",
+	} {
+		if formatted := formatSize(size); formatted != expected {
+			t.Errorf("expected %v to be formatted as %v, got %v", size, expected, formatted)
+		}
+	}
+}
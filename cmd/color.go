@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ory/viper"
+)
+
+// Colors of human output, as ANSI escape sequences.
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+)
+
+// colorEnabled returns whether output written to w is colored: only if it is
+// a terminal, and color is not disabled.  Output written to any other writer,
+// such as a pipe, file or buffer, is never colored, nor is structured output
+// (-o json|yaml|xml), which is not written with colors.
+func colorEnabled(w io.Writer) bool {
+	return colorAllowed() && isTerminal(w)
+}
+
+// colorAllowed returns whether color is not disabled by --no-color, by
+// $NO_COLOR (see https://no-color.org), by a terminal without colors
+// (TERM=dumb), or by --json-logs.
+func colorAllowed() bool {
+	if viper.GetBool("no-color") || viper.GetBool("json-logs") {
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return true
+}
+
+// isTerminal returns whether w is a file which is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && (fi.Mode()&os.ModeCharDevice) != 0
+}
+
+// colored returns the text in the color if output written to w is colored,
+// and otherwise as is.
+func colored(w io.Writer, color, text string) string {
+	if !colorEnabled(w) {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// boldHeader returns a writer to w of a table, the first line of which (its
+// header) is bold if output written to w is colored.  Tables are colored
+// once aligned, such that escape sequences do not count towards the widths
+// of their columns.
+func boldHeader(w io.Writer) io.Writer {
+	if !colorEnabled(w) {
+		return w
+	}
+	return &headerWriter{w: w}
+}
+
+// headerWriter writes its first line in bold.
+type headerWriter struct {
+	w       io.Writer
+	started bool // the bold escape sequence is written
+	done    bool // the first line is written
+}
+
+func (h *headerWriter) Write(p []byte) (int, error) {
+	if h.done || len(p) == 0 {
+		return h.w.Write(p)
+	}
+	var b bytes.Buffer
+	if !h.started {
+		b.WriteString(colorBold)
+		h.started = true
+	}
+	if i := bytes.IndexByte(p, '\n'); i >= 0 {
+		b.Write(p[:i])
+		b.WriteString(colorReset)
+		b.Write(p[i:])
+		h.done = true
+	} else {
+		b.Write(p)
+	}
+	if _, err := h.w.Write(b.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// warn writes the warning to w, prefixed with "Warning:" in yellow if output
+// written to w is colored.
+func warn(w io.Writer, format string, a ...interface{}) {
+	fmt.Fprintf(w, "%v %v\n", colored(w, colorYellow, "Warning:"), fmt.Sprintf(format, a...))
+}
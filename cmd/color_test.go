@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ory/viper"
+)
+
+// TestColorAllowed ensures color is disabled by --no-color, $NO_COLOR and
+// TERM=dumb.
+func TestColorAllowed(t *testing.T) {
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+	defer os.Setenv("TERM", os.Getenv("TERM"))
+	defer viper.Set("no-color", false)
+
+	tests := []struct {
+		name     string
+		noColor  bool
+		env      string
+		term     string
+		expected bool
+	}{
+		{"default", false, "", "xterm-256color", true},
+		{"--no-color", true, "", "xterm-256color", false},
+		{"NO_COLOR", false, "1", "xterm-256color", false},
+		{"TERM=dumb", false, "", "dumb", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			viper.Set("no-color", test.noColor)
+			os.Setenv("NO_COLOR", test.env)
+			os.Setenv("TERM", test.term)
+			if colorAllowed() != test.expected {
+				t.Fatalf("expected color allowed to be %v", test.expected)
+			}
+		})
+	}
+}
+
+// TestColoredNotTerminal ensures output which is not written to a terminal
+// is never colored.
+func TestColoredNotTerminal(t *testing.T) {
+	var b bytes.Buffer
+	if s := colored(&b, colorRed, "Error:"); s != "Error:" {
+		t.Fatalf("expected no color, got %q", s)
+	}
+	if w := boldHeader(&b); w != &b {
+		t.Fatal("expected the header of a table not to be bold")
+	}
+	warn(&b, "the registry %v is insecure", "localhost:5000")
+	if b.String() != "Warning: the registry localhost:5000 is insecure\n" {
+		t.Fatalf("expected an uncolored warning, got %q", b.String())
+	}
+}
+
+// TestHeaderWriter ensures only the first line is bold, however written.
+func TestHeaderWriter(t *testing.T) {
+	var b bytes.Buffer
+	w := &headerWriter{w: &b}
+	fmt.Fprint(w, "NAME  ")
+	fmt.Fprint(w, "READY\nmyfunc")
+	fmt.Fprint(w, "  True\n")
+
+	expected := colorBold + "NAME  READY" + colorReset + "\nmyfunc  True\n"
+	if b.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, b.String())
+	}
+}
@@ -1,21 +1,36 @@
 package cmd
 
 import (
-	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 )
 
 func init() {
-	root.AddCommand(completionCmd)
+	root.AddCommand(NewCompletionCmd())
 }
 
-// completionCmd represents the completion command
-var completionCmd = &cobra.Command{
-	Use:   "completion <bash|zsh|fish>",
-	Short: "Generate completion scripts for bash, fish and zsh",
-	Long: `To load completion run
+// NewCompletionCmd creates a completion command, which writes the script
+// completing the commands, flags and their values for the given shell.
+func NewCompletionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "completion <bash|zsh|fish|powershell>",
+		Short: "Generate completion scripts for bash, zsh, fish and powershell",
+		Long: `Generate completion scripts for bash, zsh, fish and powershell
+
+Writes the script completing the commands and flags of func, and values such
+as runtimes, templates, environments and the names of deployed functions, for
+the given shell to stdout.
+
+When run as a kn plugin, as 'kn func completion', the script completes the
+invocations of 'kn func' rather than those of 'func'.
+
+To load completions in the current shell:
+
+For bash:
+source <(func completion bash)
 
 For zsh:
 source <(func completion zsh)
@@ -24,27 +39,55 @@ If you would like to use alias:
 alias f=func
 compdef _func f
 
-For bash:
-source <(func completion bash)
+For fish:
+func completion fish | source
 
+For powershell:
+func completion powershell | Out-String | Invoke-Expression
+
+To load completions for every new session, write the script to the
+completions directory of the shell, such as for bash:
+func completion bash > /etc/bash_completion.d/func
 `,
-	ValidArgs: []string{"bash", "zsh", "fish"},
-	Args:      cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		if len(args) < 1 {
-			return errors.New("missing argument")
-		}
-		switch args[0] {
-		case "bash":
-			err = root.GenBashCompletion(os.Stdout)
-		case "zsh":
-			err = root.GenZshCompletion(os.Stdout)
-		case "fish":
-			err = root.GenFishCompletion(os.Stdout, true)
-		default:
-			err = errors.New("unknown shell, only bash, zsh and fish are supported")
-		}
-
-		return err
-	},
+		ValidArgs:   []string{"bash", "zsh", "fish", "powershell"},
+		Args:        cobra.ExactValidArgs(1),
+		Annotations: map[string]string{dryRunAnnotation: dryRunReadOnly},
+		RunE:        runCompletion,
+	}
+	return cmd
+}
+
+func runCompletion(cmd *cobra.Command, args []string) (err error) {
+	completed := cmd.Root()
+
+	// As a kn plugin, the commands are those of 'kn func', such that the
+	// script completes the func command of kn.  The root is restored as such
+	// once written.
+	if asPlugin() && completed.Parent() == nil {
+		kn := &cobra.Command{Use: "kn"}
+		kn.AddCommand(completed)
+		defer kn.RemoveCommand(completed)
+		completed = kn
+	}
+
+	out := cmd.OutOrStdout()
+	switch args[0] {
+	case "bash":
+		err = completed.GenBashCompletion(out)
+	case "zsh":
+		err = completed.GenZshCompletion(out)
+	case "fish":
+		err = completed.GenFishCompletion(out, true)
+	case "powershell":
+		err = completed.GenPowerShellCompletion(out)
+	default:
+		err = fmt.Errorf("unknown shell '%v', only bash, zsh, fish and powershell are supported", args[0])
+	}
+	return
+}
+
+// asPlugin returns whether func is running as a kn plugin, as which it is
+// executed as kn-func.
+func asPlugin() bool {
+	return filepath.Base(os.Args[0]) == "kn-func"
 }
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestCompletion ensures that the script of each shell is written, that those
+// of other shells are rejected, and that as a kn plugin the script completes
+// the invocations of kn func.
+func TestCompletion(t *testing.T) {
+	complete := func(args ...string) (string, error) {
+		t.Helper()
+		root := &cobra.Command{Use: "func"}
+		root.AddCommand(NewCompletionCmd())
+		out := &bytes.Buffer{}
+		root.SetOut(out)
+		root.SetArgs(append([]string{"completion"}, args...))
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		out, err := complete(shell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, "func") {
+			t.Fatalf("expected the %v script of func, got:\n%v", shell, out)
+		}
+	}
+	if _, err := complete("tcsh"); err == nil {
+		t.Fatal("expected an error for an unsupported shell")
+	}
+
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"kn-func"}
+	out, err := complete("bash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "complete -o default -F __start_kn kn") || !strings.Contains(out, "_kn_func_completion()") {
+		t.Fatalf("expected the script to complete kn func, got:\n%v", out)
+	}
+}
@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/user"
 	"path"
+	"sort"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -13,24 +17,54 @@ import (
 	"github.com/boson-project/func/knative"
 )
 
-func CompleteFunctionList(cmd *cobra.Command, args []string, toComplete string) (strings []string, directive cobra.ShellCompDirective) {
-	lister, err := knative.NewLister("")
+// completionTimeout bounds the time spent querying the cluster when
+// completing, such that completion does not hang when it is unreachable.
+const completionTimeout = 3 * time.Second
+
+// CompleteFunctionList completes the names of the Functions deployed in the
+// namespace given by --namespace, or the current namespace.
+func CompleteFunctionList(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeFunctionList(cmd, args, func(namespace string) (fn.Lister, error) {
+		return knative.NewLister(namespace)
+	})
+}
+
+// completeFunctionList completes the first argument with the names of the
+// Functions listed by the lister of the namespace.  Failures, such as an
+// unreachable cluster, complete nothing rather than erroring.
+func completeFunctionList(cmd *cobra.Command, args []string, newLister func(namespace string) (fn.Lister, error)) (names []string, directive cobra.ShellCompDirective) {
+	names, directive = []string{}, cobra.ShellCompDirectiveNoFileComp
+	if len(args) > 0 {
+		return // only the NAME argument is completed
+	}
+
+	// Flags are parsed, but not bound to the environment, when completing.
+	namespace := os.Getenv("FUNC_NAMESPACE")
+	if flag := cmd.Flags().Lookup("namespace"); flag != nil && flag.Changed {
+		namespace = flag.Value.String()
+	}
+
+	lister, err := newLister(namespace)
 	if err != nil {
-		directive = cobra.ShellCompDirectiveError
 		return
 	}
-	list, err := lister.List(cmd.Context())
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, completionTimeout)
+	defer cancel()
+	list, err := lister.List(ctx)
 	if err != nil {
-		directive = cobra.ShellCompDirectiveError
 		return
 	}
 
 	for _, item := range list {
-		strings = append(strings, item.Name)
+		names = append(names, item.Name)
 	}
-	directive = cobra.ShellCompDirectiveDefault
 	return
 }
+
 func CompleteRuntimeList(cmd *cobra.Command, args []string, toComplete string) (strings []string, directive cobra.ShellCompDirective) {
 	strings = []string{}
 	for lang := range buildpacks.RuntimeToBuildpack {
@@ -87,7 +121,7 @@ func CompleteBuilderList(cmd *cobra.Command, args []string, complete string) (st
 		return
 	}
 
-	f, err = fn.NewFunction(path)
+	f, err = fn.NewFunctionFromFile(path, configFile())
 	if err != nil {
 		return
 	}
@@ -100,3 +134,80 @@ func CompleteBuilderList(cmd *cobra.Command, args []string, complete string) (st
 	directive = cobra.ShellCompDirectiveDefault
 	return
 }
+
+// CompleteEnvironmentList completes the names of the environments declared in
+// the configuration of the Function at --path.
+func CompleteEnvironmentList(cmd *cobra.Command, args []string, toComplete string) (names []string, directive cobra.ShellCompDirective) {
+	names, directive = []string{}, cobra.ShellCompDirectiveNoFileComp
+	path, err := cmd.Flags().GetString("path")
+	if err != nil {
+		return
+	}
+	f, err := fn.NewFunctionFromFile(path, configFile())
+	if err != nil {
+		return
+	}
+	for name := range f.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// CompleteTemplateList completes the names of the templates of the runtime
+// given with --runtime, both those embedded and those of the repositories
+// of --repositories.
+func CompleteTemplateList(cmd *cobra.Command, args []string, toComplete string) (names []string, directive cobra.ShellCompDirective) {
+	names, directive = []string{}, cobra.ShellCompDirectiveNoFileComp
+	spec, err := cmd.Flags().GetString("runtime")
+	if err != nil {
+		return
+	}
+	repositories, err := cmd.Flags().GetString("repositories")
+	if err != nil {
+		return
+	}
+	runtime, _ := buildpacks.ParseRuntime(spec)
+	templates, err := fn.New(fn.WithRepositories(repositories)).Templates(runtime)
+	if err != nil {
+		return
+	}
+	for _, t := range templates {
+		names = appendUnique(names, t.Name)
+	}
+	return
+}
+
+// CompleteProfileList completes the names of the profiles.
+func CompleteProfileList(cmd *cobra.Command, args []string, toComplete string) (names []string, directive cobra.ShellCompDirective) {
+	names, directive = []string{}, cobra.ShellCompDirectiveNoFileComp
+	pp, err := readProfiles()
+	if err != nil {
+		return
+	}
+	for _, i := range pp.infos() {
+		names = append(names, i.Name)
+	}
+	return
+}
+
+// completionFunc completes the value of a flag.
+type completionFunc = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completeValues returns the completion of a flag of the given enumerated
+// values, such as a policy.
+func completeValues(values ...string) completionFunc {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// registerCompletions registers the completion of each of the flags of the
+// command.
+func registerCompletions(cmd *cobra.Command, completions map[string]completionFunc) {
+	for flag, complete := range completions {
+		if err := cmd.RegisterFlagCompletionFunc(flag, complete); err != nil {
+			fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
+		}
+	}
+}
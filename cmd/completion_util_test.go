@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/mock"
+)
+
+// TestCompleteFunctionList ensures that the names of the listed Functions are
+// completed, using the namespace given by --namespace.
+func TestCompleteFunctionList(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().StringP("namespace", "n", "", "")
+	if err := cmd.Flags().Set("namespace", "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	lister := mock.NewLister()
+	lister.ListFn = func() ([]fn.ListItem, error) {
+		return []fn.ListItem{{Name: "a"}, {Name: "b"}}, nil
+	}
+	var namespace string
+	names, directive := completeFunctionList(cmd, []string{}, func(ns string) (fn.Lister, error) {
+		namespace = ns
+		return lister, nil
+	})
+
+	if namespace != "alice" {
+		t.Fatalf("expected namespace 'alice', got '%v'", namespace)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Fatalf("expected names [a b], got %v", names)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Fatalf("expected directive %v, got %v", cobra.ShellCompDirectiveNoFileComp, directive)
+	}
+}
+
+// TestCompleteFunctionListUnreachable ensures that nothing is completed, and
+// no error directive returned, when the Functions can not be listed.
+func TestCompleteFunctionListUnreachable(t *testing.T) {
+	lister := mock.NewLister()
+	lister.ListFn = func() ([]fn.ListItem, error) {
+		return nil, errors.New("connection refused")
+	}
+	names, directive := completeFunctionList(&cobra.Command{}, []string{}, func(string) (fn.Lister, error) {
+		return lister, nil
+	})
+
+	if len(names) != 0 {
+		t.Fatalf("expected no names, got %v", names)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Fatalf("expected directive %v, got %v", cobra.ShellCompDirectiveNoFileComp, directive)
+	}
+}
+
+// TestCompleteEnvironmentList ensures that the environments of the Function
+// at --path are completed, in order.
+func TestCompleteEnvironmentList(t *testing.T) {
+	defer fromTempDir(t)()
+	root := pwd(t)
+	if err := ioutil.WriteFile("func.yaml", []byte("name: myfunc\nruntime: go\nenvironments:\n  prod: {}\n  staging: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("path", root, "")
+	names, directive := CompleteEnvironmentList(cmd, []string{}, "")
+	if !reflect.DeepEqual(names, []string{"prod", "staging"}) {
+		t.Fatalf("expected environments [prod staging], got %v", names)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Fatalf("expected directive %v, got %v", cobra.ShellCompDirectiveNoFileComp, directive)
+	}
+}
@@ -1,10 +1,7 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/AlecAivazis/survey/v2"
-	"github.com/AlecAivazis/survey/v2/terminal"
 	"github.com/ory/viper"
 	"github.com/spf13/cobra"
 
@@ -62,9 +59,6 @@ func runConfigCmd(cmd *cobra.Command, args []string) (err error) {
 
 	err = survey.Ask(qs, &answers)
 	if err != nil {
-		if err == terminal.InterruptErr {
-			return nil
-		}
 		return
 	}
 
@@ -116,15 +110,5 @@ func newConfigCmdConfig(args []string) configCmdConfig {
 func initConfigCommand(args []string) (fn.Function, error) {
 	config := newConfigCmdConfig(args)
 
-	function, err := fn.NewFunction(config.Path)
-	if err != nil {
-		return fn.Function{}, err
-	}
-
-	// Check if the Function has been initialized
-	if !function.Initialized() {
-		return fn.Function{}, fmt.Errorf("the given path '%v' does not contain an initialized function", config.Path)
-	}
-
-	return function, nil
+	return fn.Load(config.Path, configFile())
 }
@@ -6,7 +6,6 @@ import (
 	"os"
 
 	"github.com/AlecAivazis/survey/v2"
-	"github.com/AlecAivazis/survey/v2/terminal"
 	"github.com/spf13/cobra"
 
 	fn "github.com/boson-project/func"
@@ -120,9 +119,6 @@ func runAddEnvsPrompt(ctx context.Context, f fn.Function) (err error) {
 		}
 		err = survey.AskOne(prompt, &selectedEnv)
 		if err != nil {
-			if err == terminal.InterruptErr {
-				return nil
-			}
 			return
 		}
 
@@ -169,9 +165,6 @@ func runAddEnvsPrompt(ctx context.Context, f fn.Function) (err error) {
 		Options: options,
 	}, &selectedOption)
 	if err != nil {
-		if err == terminal.InterruptErr {
-			return nil
-		}
 		return
 	}
 
@@ -200,9 +193,6 @@ func runAddEnvsPrompt(ctx context.Context, f fn.Function) (err error) {
 
 		err = survey.Ask(qs, &answers)
 		if err != nil {
-			if err == terminal.InterruptErr {
-				return nil
-			}
 			return
 		}
 
@@ -232,14 +222,11 @@ func runAddEnvsPrompt(ctx context.Context, f fn.Function) (err error) {
 
 		err = survey.Ask(qs, &answers)
 		if err != nil {
-			if err == terminal.InterruptErr {
-				return nil
-			}
 			return
 		}
 
 		if _, ok := os.LookupEnv(answers.Value); !ok {
-			fmt.Printf("Warning: specified local environment variable %q is not set\n", answers.Value)
+			warn(os.Stdout, "specified local environment variable %q is not set", answers.Value)
 		}
 
 		value := fmt.Sprintf("{{ env:%s }}", answers.Value)
@@ -254,9 +241,6 @@ func runAddEnvsPrompt(ctx context.Context, f fn.Function) (err error) {
 			Options: configMaps,
 		}, &selectedResource)
 		if err != nil {
-			if err == terminal.InterruptErr {
-				return nil
-			}
 			return
 		}
 
@@ -294,9 +278,6 @@ func runAddEnvsPrompt(ctx context.Context, f fn.Function) (err error) {
 
 		err = survey.Ask(qs, &answers)
 		if err != nil {
-			if err == terminal.InterruptErr {
-				return nil
-			}
 			return
 		}
 
@@ -312,9 +293,6 @@ func runAddEnvsPrompt(ctx context.Context, f fn.Function) (err error) {
 			Options: secrets,
 		}, &selectedResource)
 		if err != nil {
-			if err == terminal.InterruptErr {
-				return nil
-			}
 			return
 		}
 
@@ -352,9 +330,6 @@ func runAddEnvsPrompt(ctx context.Context, f fn.Function) (err error) {
 
 		err = survey.Ask(qs, &answers)
 		if err != nil {
-			if err == terminal.InterruptErr {
-				return nil
-			}
 			return
 		}
 
@@ -373,7 +348,7 @@ func runAddEnvsPrompt(ctx context.Context, f fn.Function) (err error) {
 
 	err = f.WriteConfig()
 	if err == nil {
-		fmt.Println("Environment variable entry was added to the function configuration")
+		fmt.Fprintln(infoOut(os.Stdout), "Environment variable entry was added to the function configuration")
 	}
 
 	return
@@ -397,9 +372,6 @@ func runRemoveEnvsPrompt(f fn.Function) (err error) {
 	}
 	err = survey.AskOne(prompt, &selectedEnv)
 	if err != nil {
-		if err == terminal.InterruptErr {
-			return nil
-		}
 		return
 	}
 
@@ -417,7 +389,7 @@ func runRemoveEnvsPrompt(f fn.Function) (err error) {
 		f.Envs = newEnvs
 		err = f.WriteConfig()
 		if err == nil {
-			fmt.Println("Environment variable entry was removed from the function configuration")
+			fmt.Fprintln(infoOut(os.Stdout), "Environment variable entry was removed from the function configuration")
 		}
 	}
 
@@ -3,10 +3,10 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
-	"github.com/AlecAivazis/survey/v2/terminal"
 	"github.com/spf13/cobra"
 
 	fn "github.com/boson-project/func"
@@ -132,9 +132,6 @@ func runAddVolumesPrompt(ctx context.Context, f fn.Function) (err error) {
 			Options: options,
 		}, &selectedOption)
 		if err != nil {
-			if err == terminal.InterruptErr {
-				return nil
-			}
 			return
 		}
 	}
@@ -157,9 +154,6 @@ func runAddVolumesPrompt(ctx context.Context, f fn.Function) (err error) {
 		Options: optionsResoures,
 	}, &selectedResource)
 	if err != nil {
-		if err == terminal.InterruptErr {
-			return nil
-		}
 		return
 	}
 
@@ -175,9 +169,6 @@ func runAddVolumesPrompt(ctx context.Context, f fn.Function) (err error) {
 		return nil
 	}))
 	if err != nil {
-		if err == terminal.InterruptErr {
-			return nil
-		}
 		return
 	}
 
@@ -194,7 +185,7 @@ func runAddVolumesPrompt(ctx context.Context, f fn.Function) (err error) {
 
 	err = f.WriteConfig()
 	if err == nil {
-		fmt.Println("Volume entry was added to the function configuration")
+		fmt.Fprintln(infoOut(os.Stdout), "Volume entry was added to the function configuration")
 	}
 
 	return
@@ -218,9 +209,6 @@ func runRemoveVolumesPrompt(f fn.Function) (err error) {
 	}
 	err = survey.AskOne(prompt, &selectedVolume)
 	if err != nil {
-		if err == terminal.InterruptErr {
-			return nil
-		}
 		return
 	}
 
@@ -238,7 +226,7 @@ func runRemoveVolumesPrompt(f fn.Function) (err error) {
 		f.Volumes = newVolumes
 		err = f.WriteConfig()
 		if err == nil {
-			fmt.Println("Volume entry was removed from the function configuration")
+			fmt.Fprintln(infoOut(os.Stdout), "Volume entry was removed from the function configuration")
 		}
 	}
 
@@ -1,16 +1,24 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
-	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/google/go-cmp/cmp"
 	"github.com/ory/viper"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 
 	fn "github.com/boson-project/func"
 	"github.com/boson-project/func/buildpacks"
+	"github.com/boson-project/func/docker"
 	"github.com/boson-project/func/utils"
 )
 
@@ -24,13 +32,31 @@ func init() {
 // The createClientFn is a client factory which creates a new Client for use by
 // the create command during normal execution (see tests for alternative client
 // factories which return clients with various mocks).
-func newCreateClient(repositories string, verbose bool) *fn.Client {
-	return fn.New(fn.WithRepositories(repositories), fn.WithVerbose(verbose))
+func newCreateClient(config createConfig) *fn.Client {
+	force, onConflict := config.conflictResolution()
+	return fn.New(
+		fn.WithRepositories(config.Repositories),
+		fn.WithVerbose(config.Verbose),
+		fn.WithForce(force),
+		fn.WithManagedFilesOnly(config.OverwriteRuntimeFilesOnly),
+		fn.WithStyle(config.Style),
+		fn.WithRuntimeVersionFile(config.RuntimeVersionFile),
+		fn.WithLicenseAuthor(config.Author),
+		fn.WithConflictResolver(onConflict),
+		fn.WithVersion(version.Vers),
+		fn.WithBuilder(buildpacks.NewBuilder()),
+		fn.WithPlan(config.Plan))
 }
 
 // createClientFn is a factory function which returns a Client suitable for
-// use with the Create command.
-type createClientFn func(repositories string, verbose bool) *fn.Client
+// use with the Create command, as configured: resolving conflicts with the
+// existing files as forced or by the policy of --on-conflict, writing only
+// the managed files of the template with --overwrite-runtime-files-only, the
+// style config and version manager file of the runtime, the author of the
+// copyright of its LICENSE, and planning its changes in the config's plan
+// when not nil.  Its builder pins the builder image of the Function with
+// --pin-builder.
+type createClientFn func(config createConfig) *fn.Client
 
 // NewCreateCmd creates a create command using the given client creator.
 func NewCreateCmd(clientFn createClientFn) *cobra.Command {
@@ -41,12 +67,20 @@ func NewCreateCmd(clientFn createClientFn) *cobra.Command {
 
 Creates a new function project in PATH, or in the current directory if no PATH is given. 
 The name of the project is determined by the directory name the project is created in.
+
+With --projects-root, a relative PATH is within that directory rather than the
+current directory, such that functions are kept together, for example as
+~/functions/<name>.  An absolute PATH is used as given.
 `,
 		Example: `
 # Create a Node.js function project in the current directory, choosing the
 # directory name as the project's name.
 kn func create
 
+# Create a function project in an existing directory of Node.js source, the
+# runtime being detected from its package.json
+kn func create --force
+
 # Create a Quarkus function project in the directory "sample-service". 
 # The directory will be created in the local directory if non-existent and 
 # the project is called "sample-service"
@@ -54,24 +88,127 @@ kn func create --runtime quarkus myfunc
 
 # Create a function project that uses a CloudEvent based function signature
 kn func create --template events myfunc
+
+# Complete the creation of a function project which previously failed part
+# way, overwriting the files already written
+kn func create --force myfunc
+
+# Create a function project non-interactively, answering the prompts with
+# the answers in answers.yaml
+kn func create --answers answers.yaml
+
+# Create a function from the template of the "boson" repository at tag v1.0.0
+kn func create --template boson/http --ref v1.0.0 myfunc
+
+# Create a function project whose image is pushed to the "alice" namespace
+# of the ghcr.io registry when deployed, without providing --registry again
+kn func create --registry ghcr.io/alice myfunc
+
+# Create a function project with a GitHub Actions workflow which tests it,
+# and builds and deploys it with func on each push to main
+kn func create --with-ci github myfunc
+
+# Create a function project with an .editorconfig and the formatter config
+# of its runtime, such as a .prettierrc for Node
+kn func create --runtime node --with-style myfunc
+
+# Create a function project with a VS Code dev container of its runtime,
+# with func installed
+kn func create --with-devcontainer myfunc
+
+# Create a function project licensed under the MIT license, the LICENSE of
+# which is of the copyright of the user.name of git, or of --author
+kn func create --license MIT myfunc
+
+# Create a function project from the embedded templates only, ignoring any
+# template repositories, such as in a hermetic CI environment
+kn func create --offline myfunc
+
+# Create a function project in ~/functions/myfunc, whichever the current
+# directory
+kn func create --projects-root ~/functions myfunc
+
+# Create a function project in an existing directory, keeping any existing
+# files of the same name as those of the template
+kn func create --on-conflict skip myfunc
+
+# Refresh the files managed by the template of the function project in the
+# directory "myfunc", such as its build config, leaving its handler and tests
+# untouched
+kn func create --template boson/http --overwrite-runtime-files-only myfunc
+
+# Create a function project in an existing directory, choosing whether to
+# overwrite, skip or first compare each existing file
+kn func create --force --confirm myfunc
 	`,
-		SuggestFor: []string{"vreate", "creaet", "craete", "new"},
-		PreRunE:    bindEnv("runtime", "template", "repositories", "confirm"),
+		SuggestFor:  []string{"vreate", "creaet", "craete", "new"},
+		Annotations: map[string]string{dryRunAnnotation: dryRunPlan},
+		PreRunE:     bindEnv("runtime", "template", "repositories", "repositories-ttl", "offline", "ref", "builder", "registry", "image-suffix", "pin-builder", "force", "on-conflict", "answers", "confirm", "projects-root", "overwrite-runtime-files-only", "with-ci", "with-style", "with-devcontainer", "runtime-version-file", "package-manager", "entrypoint", "license", "author", "strict-name"),
 	}
 
 	cmd.Flags().BoolP("confirm", "c", false,
 		"Prompt to confirm all configuration options (Env: $FUNC_CONFIRM)")
 	cmd.Flags().StringP("runtime", "l", fn.DefaultRuntime,
-		"Function runtime language/framework. Available runtimes: "+buildpacks.Runtimes()+" (Env: $FUNC_RUNTIME)")
+		"Function runtime language/framework. Available runtimes: "+buildpacks.Runtimes()+". Aliases: "+buildpacks.Aliases()+". The version of the runtime built may be given as name@version, such as node@18, and is stored in func.yaml. Defaults to that detected from any source in the project directory (Env: $FUNC_RUNTIME)")
 	cmd.Flags().StringP("repositories", "r", filepath.Join(configPath(), "repositories"),
 		"Path to extended template repositories (Env: $FUNC_REPOSITORIES)")
+	cmd.Flags().Duration("repositories-ttl", 0,
+		"Time after which template repositories added from git are updated before creating, such as 24h. By default, they are only updated by 'func repository update' (Env: $FUNC_REPOSITORIES_TTL)")
+	cmd.Flags().Bool("offline", false,
+		"Use only the embedded templates, ignoring any template repositories and --repositories (Env: $FUNC_OFFLINE)")
 	cmd.Flags().StringP("template", "t", fn.DefaultTemplate,
-		"Function template. Available templates: 'http' and 'events' (Env: $FUNC_TEMPLATE)")
+		"Function template. Available templates: 'http' and 'events', those of the template repositories as [repository]/[template], or the directory of a git repository given as its URL, such as git://github.com/alice/templates//go/json@v1 (Env: $FUNC_TEMPLATE)")
+	cmd.Flags().String("ref", "",
+		"Branch, tag or commit of the git repository of the template to create from, rather than that with which it was added. Stored in func.yaml (Env: $FUNC_REF)")
+	cmd.Flags().String("builder", "",
+		"Builder of the function. '"+fn.DockerfileBuilder+"' scaffolds a Dockerfile for the runtime, from which the function is built with docker or podman rather than with buildpacks. Defaults to the buildpack builder of the template. Stored in func.yaml (Env: $FUNC_BUILDER)")
+	cmd.Flags().Bool("pin-builder", false,
+		"Resolve the builder image of the function to its digest in its registry when created, and store it in func.yaml, such that the first build uses a known builder. Refreshed by building with --update-builder. Skipped with --offline (Env: $FUNC_PIN_BUILDER)")
+	cmd.Flags().String("answers", "",
+		"Path to a YAML file of answers to the prompts (path, name, runtime, template, registry), used in place of interactive prompting (Env: $FUNC_ANSWERS)")
+	cmd.Flags().Bool("force", false,
+		"Create the function even if the directory is not empty, overwriting existing files. With --confirm, prompts for each existing file (Env: $FUNC_FORCE)")
+	cmd.Flags().String("on-conflict", onConflictError,
+		"Policy for existing files of the same name as those of the template: overwrite, skip or error (Env: $FUNC_ON_CONFLICT)")
+	cmd.Flags().Bool("overwrite-runtime-files-only", false,
+		"Write only the files the template declares as managed in its manifest, such as its build config, overwriting them, and never touch any other, such as the function's handler and tests. The template must declare its managed files (Env: $FUNC_OVERWRITE_RUNTIME_FILES_ONLY)")
+	cmd.Flags().Bool("strict-name", false,
+		"Fail when the name derived from PATH is not a valid function name, such as one of uppercase characters or underscores, rather than naming the function with a valid name derived from it (Env: $FUNC_STRICT_NAME)")
+	cmd.Flags().String("projects-root", "",
+		"Directory within which the function is created when PATH is relative, such as ~/functions, rather than the current directory. An absolute PATH is used as given (Env: $FUNC_PROJECTS_ROOT)")
+	cmd.Flags().String("registry", "",
+		"Default registry + namespace part of the image, ex 'ghcr.io/myuser'. Stored in func.yaml, from which the image name is derived (Env: $FUNC_REGISTRY)")
+	cmd.Flags().String("image-suffix", "",
+		"Suffix of the function's name in the image name derived from the registry, such as -fn of ghcr.io/myuser/myfunc-fn. Defaults to that the template suggests when prompting. Stored in func.yaml (Env: $FUNC_IMAGE_SUFFIX)")
+	cmd.Flags().String("with-ci", ciNone,
+		"CI of the function to write: 'github' for a GitHub Actions workflow, or 'tekton' for a Tekton Pipeline, which test the function and build and deploy it with func, or 'none'. Stored in func.yaml (Env: $FUNC_WITH_CI)")
+	cmd.Flags().Bool("with-style", false,
+		"Write an .editorconfig and the formatter config of the runtime, such as a .prettierrc for Node, where those files do not already exist. Not written with --overwrite-runtime-files-only (Env: $FUNC_WITH_STYLE)")
+	cmd.Flags().Bool("with-devcontainer", false,
+		"Write a VS Code dev container of the runtime, with func installed, as .devcontainer/devcontainer.json, unless one already exists. Stored in func.yaml (Env: $FUNC_WITH_DEVCONTAINER)")
+	cmd.Flags().Bool("runtime-version-file", true,
+		"Write the file of the version manager of the runtime version given with --runtime, such as an .nvmrc for node@18 or a .tool-versions for go and python of an exact version such as python@3.10.9, where it does not already exist (Env: $FUNC_RUNTIME_VERSION_FILE)")
+	cmd.Flags().String("package-manager", "",
+		fmt.Sprintf("Package manager of a function of the node or typescript runtime: %v. Writes the variant of the template for it, such as its package.json, without the lockfiles of the others, and installs the dependencies with it when built. Defaults to npm. Stored in func.yaml (Env: $FUNC_PACKAGE_MANAGER)", strings.Join(fn.PackageManagers, ", ")))
+	cmd.Flags().String("entrypoint", "",
+		"Handler of a template exposing several which is the entrypoint of the function, written as its handler without the others. Defaults to the first the template declares, or is prompted for. Stored in func.yaml (Env: $FUNC_ENTRYPOINT)")
+	cmd.Flags().String("license", "",
+		fmt.Sprintf("SPDX identifier of the license of the function, the text of which is written as its LICENSE where none exists: %v. Stored in func.yaml (Env: $FUNC_LICENSE)", strings.Join(fn.Licenses, ", ")))
+	cmd.Flags().String("author", "",
+		"Author of the copyright of the LICENSE written with --license. Defaults to the user.name of git (Env: $FUNC_AUTHOR)")
 
 	// Register tab-completeion function integration
 	if err := cmd.RegisterFlagCompletionFunc("runtime", CompleteRuntimeList); err != nil {
 		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
 	}
+	if err := cmd.RegisterFlagCompletionFunc("template", CompleteTemplateList); err != nil {
+		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
+	}
+	registerCompletions(cmd, map[string]completionFunc{
+		"with-ci":         completeValues(append(fn.CISystems, ciNone)...),
+		"license":         completeValues(fn.Licenses...),
+		"package-manager": completeValues(fn.PackageManagers...),
+	})
 
 	// The execution delegate is invoked with the command, arguments, and the
 	// client creator.
@@ -83,29 +220,373 @@ kn func create --template events myfunc
 }
 
 func runCreate(cmd *cobra.Command, args []string, clientFn createClientFn) (err error) {
-	config := newCreateConfig(args)
+	config := newCreateConfig(cmd, args)
 
-	if err = utils.ValidateFunctionName(config.Name); err != nil {
+	if config.Name, err = resolveName(config.Name, config.StrictName); err != nil {
 		return
 	}
 
-	if config, err = config.Prompt(); err != nil {
-		if err == terminal.InterruptErr {
-			return nil
+	if err = validateOnConflict(config.OnConflict); err != nil {
+		return
+	}
+
+	if config.OverwriteRuntimeFilesOnly && (config.Force || config.OnConflict != onConflictError) {
+		return fmt.Errorf("--overwrite-runtime-files-only overwrites only the files managed by the template, and can not be combined with --force or --on-conflict")
+	}
+
+	if config.Registry != "" {
+		if err = utils.ValidateRegistry(config.Registry); err != nil {
+			return
 		}
+	}
+
+	if err = fn.ValidateCI(config.CI); err != nil {
+		return fmt.Errorf("invalid value '%v' for --with-ci: %v, or %v", config.CI, err, ciNone)
+	}
+
+	if err = fn.ValidateLicense(config.License); err != nil {
+		return fmt.Errorf("invalid value '%v' for --license: %v", config.License, err)
+	}
+
+	if err = fn.ValidateImageSuffix(config.ImageSuffix); err != nil {
+		return fmt.Errorf("invalid value '%v' for --image-suffix: %v", config.ImageSuffix, err)
+	}
+
+	// Offline, the client is without repositories, such that only the
+	// embedded templates are available.
+	plan := newPlan(dryRun())
+	config.Plan = plan
+	client := clientFn(config)
+
+	if !config.Offline && config.RepositoriesTTL > 0 && plan == nil {
+		updateStaleRepositories(cmd, client, config.RepositoriesTTL)
+	}
+
+	// The templates available populate the runtime and template options.
+	templates, err := client.Templates()
+	if err != nil {
 		return
 	}
 
+	if config.Answers != "" {
+		config, err = config.Answer(config.Answers, templates)
+	} else {
+		config, err = config.Prompt(templates)
+	}
+	if err != nil {
+		return
+	}
+
+	if config.Offline && fn.IsTemplateURL(config.Template) {
+		return fmt.Errorf("template '%v' is fetched from its git repository, which is not done offline", config.Template)
+	}
+	if config.Offline && strings.Contains(config.Template, "/") {
+		return fmt.Errorf("template '%v' is not embedded, and template repositories are not used offline. Embedded templates for runtime '%v': %v",
+			config.Template, config.Runtime, strings.Join(embeddedTemplates(templates, config.Runtime), ", "))
+	}
+
+	// The template is validated before anything is written, such that a
+	// project is not scaffolded from a template the runtime does not have.
+	if err = validateTemplate(templates, config.Runtime, config.Template, config.TemplateRef); err != nil {
+		return templateErrorHelp(client, err)
+	}
+	if err = validateEntrypoint(templates, config.Runtime, config.Template, config.Entrypoint); err != nil {
+		return fmt.Errorf("invalid value '%v' for --entrypoint: %v", config.Entrypoint, err)
+	}
+	if err = buildpacks.ValidateRuntimeVersion(config.Runtime, config.RuntimeVersion); err != nil {
+		return fmt.Errorf("invalid value '%v@%v' for --runtime: %v", config.Runtime, config.RuntimeVersion, err)
+	}
+	if err = fn.ValidatePackageManager(config.Runtime, config.PackageManager); err != nil {
+		return fmt.Errorf("invalid value '%v' for --package-manager: %v", config.PackageManager, err)
+	}
+
 	function := fn.Function{
-		Name:     config.Name,
-		Root:     config.Path,
-		Runtime:  config.Runtime,
-		Template: config.Template,
+		Name:           config.Name,
+		Root:           config.Path,
+		Runtime:        config.Runtime,
+		RuntimeVersion: config.RuntimeVersion,
+		Template:       config.Template,
+		TemplateRef:    config.TemplateRef,
+		Builder:        config.Builder,
+		Registry:       config.Registry,
+		ImageSuffix:    config.ImageSuffix,
+		ConfigFile:     config.ConfigFile,
+		CI:             config.CI,
+		Devcontainer:   config.Devcontainer,
+		License:        config.License,
+		PackageManager: config.PackageManager,
+		Entrypoint:     config.Entrypoint,
+	}
+	if err = function.Validate(); err != nil {
+		return
 	}
 
-	client := clientFn(config.Repositories, config.Verbose)
+	// Functions built from a Dockerfile require docker or podman, which is
+	// warned of, as the function may yet be built elsewhere.
+	if config.Builder == fn.DockerfileBuilder {
+		if err := docker.CheckAvailable(cmd.Context()); err != nil {
+			warn(cmd.ErrOrStderr(), "%v", err)
+		}
+	}
 
-	return client.Create(function)
+	err = client.Create(function)
+	if errors.Is(err, fn.ErrAlreadyCreated) {
+		fmt.Fprintf(cmd.OutOrStdout(), "Function '%v' already created in %v\n", config.Name, config.Path)
+		return nil
+	}
+	if errors.Is(err, fn.ErrTemplateNotManaged) {
+		return fmt.Errorf("%w: the manifest of template '%v' must list the files it manages under 'managed' for --overwrite-runtime-files-only", err, config.Template)
+	}
+	if errors.Is(err, fn.ErrConfigMismatch) && config.OverwriteRuntimeFilesOnly {
+		return fmt.Errorf("%w\nThe files of a template can only be refreshed for a function of its runtime", err)
+	}
+	if errors.Is(err, fn.ErrConfigMismatch) {
+		return fmt.Errorf("%w\nUse --force to create the function regardless, overwriting the existing one", err)
+	}
+	if errors.Is(err, fn.ErrIncompleteScaffold) {
+		// Offer to complete the creation which previously failed part way,
+		// when confirming interactively.
+		complete := false
+		if interactiveTerminal() && config.Confirm {
+			if err := survey.AskOne(&survey.Confirm{Message: "Complete the function's creation, overwriting existing files?"}, &complete); err != nil {
+				return err
+			}
+		}
+		if !complete {
+			return fmt.Errorf("%w\nRun create again with --force to complete it", err)
+		}
+		overwrite := config
+		overwrite.Force, overwrite.OnConflict, overwrite.OverwriteRuntimeFilesOnly = false, onConflictOverwrite, false
+		client = clientFn(overwrite)
+		err = client.Create(function)
+	}
+	if errors.Is(err, fn.ErrUnrelatedFiles) {
+		return fmt.Errorf("%w\nUse --force to create the function regardless, overwriting any existing files of the same name, or --on-conflict skip to keep them", err)
+	}
+	if err == nil && plan != nil {
+		return plan.Print(cmd.OutOrStdout())
+	}
+	if err == nil && config.PinBuilder {
+		pinBuilder(cmd, client, config)
+	}
+	return templateErrorHelp(client, err)
+}
+
+// pinBuilder pins the builder image of the Function created to its digest,
+// resolved from its registry by the builder of the client.  Offline, or failing to resolve it, such as
+// without network access, is only warned of: the Function is built by the
+// tag of its builder image, and pinned when first built.
+func pinBuilder(cmd *cobra.Command, client *fn.Client, config createConfig) {
+	if config.Offline {
+		warn(cmd.ErrOrStderr(), "the builder image is not pinned offline: the function is pinned to it when first built")
+		return
+	}
+	if err := client.PinBuilder(cmd.Context(), fn.Function{Root: config.Path, ConfigFile: config.ConfigFile}); err != nil {
+		warn(cmd.ErrOrStderr(), "%v. The function is pinned to it when first built", err)
+		return
+	}
+	f, err := fn.NewFunctionFromFile(config.Path, config.ConfigFile)
+	if err == nil && f.BuilderDigest != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "Builder image pinned to digest %v\n", f.BuilderDigest)
+	}
+}
+
+// updateStaleRepositories updates the client's repositories added from git
+// which were last updated longer ago than the ttl, concurrently.  Failing to
+// update is only warned of, the cached templates being used, such that
+// creating does not require network access.
+func updateStaleRepositories(cmd *cobra.Command, client *fn.Client, ttl time.Duration) {
+	_, err := client.UpdateStaleRepositories(cmd.Context(), ttl)
+	var errs fn.RepositoryErrors
+	if !errors.As(err, &errs) {
+		if err != nil {
+			warn(cmd.ErrOrStderr(), "unable to update template repositories: %v", err)
+		}
+		return
+	}
+	rr, _ := client.RepositoryInfos(cmd.Context())
+	for _, r := range rr {
+		if err, ok := errs[r.Name]; ok {
+			warn(cmd.ErrOrStderr(), "unable to update template repository '%v', using that last updated %v: %v",
+				r.Name, r.Updated.Local().Format(time.RFC3339), err)
+		}
+	}
+}
+
+// templateErrorHelp returns the error with the options available added when
+// it is a failure to resolve the template, runtime or repository requested.
+// Other errors are returned as-is.  The original error remains wrapped.
+func templateErrorHelp(client *fn.Client, err error) error {
+	var terr *fn.TemplateError
+	if !errors.As(err, &terr) {
+		return err
+	}
+
+	var (
+		templates, _ = client.Templates()
+		available    []string
+		kind         string
+	)
+	switch {
+	case errors.Is(err, fn.ErrRepositoryNotFound):
+		kind = "repositories"
+		for _, t := range templates {
+			if t.Repository != "" {
+				available = appendUnique(available, t.Repository)
+			}
+		}
+	case errors.Is(err, fn.ErrRuntimeNotFound):
+		kind = "runtimes"
+		for _, t := range templates {
+			if t.Repository == terr.Repository {
+				available = appendUnique(available, t.Runtime)
+			}
+		}
+	case errors.Is(err, fn.ErrTemplateNotFound):
+		kind = fmt.Sprintf("templates for runtime '%v'", terr.Runtime)
+		for _, t := range templates {
+			if t.Runtime == terr.Runtime {
+				available = appendUnique(available, t.Name)
+			}
+		}
+		if supported := runtimeSignatures(templates, terr.Runtime); len(supported) > 0 {
+			err = fmt.Errorf("%w\nSignatures supported by runtime '%v': %v", err, terr.Runtime, strings.Join(supported, ", "))
+		}
+	default:
+		return err
+	}
+
+	if len(available) == 0 {
+		return fmt.Errorf("%w\nNo %v are available", err, kind)
+	}
+	return fmt.Errorf("%w\nAvailable %v: %v", err, kind, strings.Join(available, ", "))
+}
+
+// validateTemplate ensures the template is one of those available for the
+// runtime, and that the signature it declares, if any, is one supported by
+// the runtime.  Templates of a repository pinned to a ref are those of the
+// ref, which are not yet known, and so are not validated, as are those given
+// as a URL, of which only the form is.
+func validateTemplate(templates []fn.Template, runtime, template, ref string) error {
+	if fn.IsTemplateURL(template) {
+		_, err := fn.ParseTemplateURL(template)
+		return err
+	}
+	if ref != "" {
+		return nil
+	}
+	var repository string
+	if strings.Contains(template, "/") {
+		repository = strings.Split(template, "/")[0]
+	}
+
+	var found *fn.Template
+	hasRepository, hasRuntime := repository == "", false
+	for i, t := range templates {
+		if t.Repository != repository {
+			continue
+		}
+		hasRepository = true
+		if t.Runtime != runtime {
+			continue
+		}
+		hasRuntime = true
+		if t.Name == template {
+			found = &templates[i]
+		}
+	}
+	switch {
+	case !hasRepository:
+		return &fn.TemplateError{Err: fn.ErrRepositoryNotFound, Runtime: runtime, Template: template, Repository: repository}
+	case !hasRuntime:
+		return &fn.TemplateError{Err: fn.ErrRuntimeNotFound, Runtime: runtime, Template: template, Repository: repository}
+	case found == nil:
+		return &fn.TemplateError{Err: fn.ErrTemplateNotFound, Runtime: runtime, Template: template, Repository: repository}
+	}
+
+	if found.Signature == "" {
+		return nil
+	}
+	supported := runtimeSignatures(templates, runtime)
+	for _, s := range supported {
+		if s == found.Signature {
+			return nil
+		}
+	}
+	return fmt.Errorf("template '%v' implements the signature '%v', which is not supported by runtime '%v'. Supported signatures: %v",
+		template, found.Signature, runtime, strings.Join(supported, ", "))
+}
+
+// validateEntrypoint ensures the entrypoint, if any, is one of the handlers
+// of the template of the runtime, if it is listed.  Those of templates not
+// listed, such as those given as a URL, are validated as they are written.
+func validateEntrypoint(templates []fn.Template, runtime, template, entrypoint string) error {
+	if entrypoint == "" {
+		return nil
+	}
+	for _, t := range templates {
+		if t.Runtime != runtime || t.Name != template {
+			continue
+		}
+		for _, h := range t.Handlers {
+			if h == entrypoint {
+				return nil
+			}
+		}
+		if len(t.Handlers) == 0 {
+			return fmt.Errorf("template '%v' declares no handlers", template)
+		}
+		return fmt.Errorf("template '%v' declares the handlers %v", template, strings.Join(t.Handlers, ", "))
+	}
+	return nil
+}
+
+// runtimeSignatures returns the signatures supported by the runtime: those
+// of its embedded templates or, for runtimes without embedded templates,
+// any of the known signatures.
+func runtimeSignatures(templates []fn.Template, runtime string) (signatures []string) {
+	for _, t := range templates {
+		if t.Repository == "" && t.Runtime == runtime && t.Signature != "" {
+			signatures = appendUnique(signatures, t.Signature)
+		}
+	}
+	if len(signatures) == 0 {
+		return fn.Signatures
+	}
+	sort.Strings(signatures)
+	return
+}
+
+// runtimeOptions returns the runtimes supported by the builders and those of
+// the templates available, sorted.
+func runtimeOptions(templates []fn.Template) []string {
+	runtimes := buildpacks.RuntimesList()
+	for _, t := range templates {
+		runtimes = appendUnique(runtimes, t.Runtime)
+	}
+	sort.Strings(runtimes)
+	return runtimes
+}
+
+// embeddedTemplates returns the names of the embedded templates of the
+// runtime.
+func embeddedTemplates(templates []fn.Template, runtime string) (names []string) {
+	for _, t := range templates {
+		if t.Repository == "" && t.Runtime == runtime {
+			names = appendUnique(names, t.Name)
+		}
+	}
+	return
+}
+
+// appendUnique appends s to ss if not already present.
+func appendUnique(ss []string, s string) []string {
+	for _, v := range ss {
+		if v == s {
+			return ss
+		}
+	}
+	return append(ss, s)
 }
 
 type createConfig struct {
@@ -115,15 +596,31 @@ type createConfig struct {
 	// Absolute path to Function on disk.
 	Path string
 
+	// ProjectsRoot is the directory within which Functions given a relative
+	// path are created, rather than the current working directory.
+	ProjectsRoot string
+
 	// Runtime language/framework.
 	Runtime string
 
+	// RuntimeVersion of the runtime built, if any, given as runtime@version.
+	RuntimeVersion string
+
 	// Repositories is an optional path that, if it exists, will be used as a source
 	// for additional template repositories not included in the binary.  If not provided
 	// explicitly as a flag (--repositories) or env (FUNC_REPOSITORIES), the default
 	// location is $XDG_CONFIG_HOME/repositories ($HOME/.config/func/repositories)
 	Repositories string
 
+	// RepositoriesTTL is the time after which repositories added from git are
+	// updated before creating.  Zero, the default, disables updating, such
+	// that creating does not fetch from the network unless requested.
+	RepositoriesTTL time.Duration
+
+	// Offline use of the embedded templates only, no template repositories
+	// being read.  Repositories is then empty.
+	Offline bool
+
 	// Template is the code written into the new Function project, including
 	// an implementation adhering to one of the supported function signatures.
 	// May also include additional configuration settings or examples.
@@ -133,48 +630,333 @@ type createConfig struct {
 	// minimum implementation of the signature itself and example tests.
 	Template string
 
+	// ConfigFile is the name of the config file written in Path, given with
+	// the global --config-file flag.  Empty for the default, func.yaml.
+	ConfigFile string
+
+	// TemplateRef is the ref (branch, tag or commit) of the git repository of
+	// the template to create from, pinning the template used.  Persisted in
+	// the Function's configuration.
+	TemplateRef string
+
+	// Builder of the Function, overriding that of the template.  The
+	// DockerfileBuilder scaffolds a Dockerfile for the runtime.  Persisted in
+	// the Function's configuration.
+	Builder string
+
+	// Registry is the default registry, in the form [registry]/[namespace],
+	// from which the Function's image name is derived when not provided
+	// explicitly.  Persisted in the Function's configuration.
+	Registry string
+
+	// ImageSuffix of the Function's name in the image name derived from the
+	// Registry, such as "-fn".  Persisted in the Function's configuration.
+	ImageSuffix string
+
+	// CI system of the Function, the CI of which is written, such as
+	// "github" or "tekton".  Empty for none.  Persisted in the Function's
+	// configuration.
+	CI string
+
+	// Devcontainer writes the dev container config of the runtime, unless
+	// one already exists.  Persisted in the Function's configuration.
+	Devcontainer bool
+
+	// Style writes the .editorconfig and formatter config of the runtime
+	// where those files do not already exist.
+	Style bool
+
+	// RuntimeVersionFile writes the version manager file of the runtime
+	// version, if any, where it does not already exist.
+	RuntimeVersionFile bool
+
+	// PackageManager of a Function of a Node runtime, such as "yarn".  Empty
+	// for npm.  Persisted in the Function's configuration.
+	PackageManager string
+
+	// Entrypoint is the handler of a template exposing several which is
+	// written as that of the Function.  Defaults to the first the template
+	// declares.  Persisted in the Function's configuration.
+	Entrypoint string
+
+	// PinBuilder resolves the builder image of the Function to its digest
+	// when created, which is persisted in the Function's configuration.
+	PinBuilder bool
+
+	// License of the Function by SPDX identifier, the text of which is
+	// written as its LICENSE where none exists.  Empty for none.  Persisted
+	// in the Function's configuration.
+	License string
+
+	// Author of the copyright of the LICENSE.  Defaults to the user.name of
+	// git.
+	Author string
+
+	// StrictName fails the creation of a function whose name derived from its
+	// path is not valid, rather than naming it with one generated from it.
+	StrictName bool
+
+	// Answers is the path to a YAML file of answers to the prompts, used in
+	// place of interactive prompting.
+	Answers string
+
+	// Force creation in a directory which is not empty, overwriting existing
+	// files.
+	Force bool
+
+	// OnConflict is the policy for existing files of the same name as those
+	// of the template: overwrite, skip or error (the default).  Error permits
+	// no existing files unless forced.
+	OnConflict string
+
+	// OverwriteRuntimeFilesOnly writes only the files declared as managed by
+	// the template's manifest, such as its build config, overwriting them,
+	// and leaves all others untouched.
+	OverwriteRuntimeFilesOnly bool
+
 	// Verbose output
 	Verbose bool
 
 	// Confirm: confirm values arrived upon from environment plus flags plus defaults,
 	// with interactive prompting (only applicable when attached to a TTY).
 	Confirm bool
+
+	// Plan populated in place of creating with --dry-run.
+	Plan *fn.Plan
 }
 
 // newCreateConfig returns a config populated from the current execution context
-// (args, flags and environment variables)
-func newCreateConfig(args []string) createConfig {
+// (args, flags and environment variables).  Unless provided explicitly, the
+// runtime defaults to that detected from any source already at the path.
+func newCreateConfig(cmd *cobra.Command, args []string) createConfig {
 	var path string
 	if len(args) > 0 {
 		path = args[0] // If explicitly provided, use.
 	}
 
-	derivedName, derivedPath := deriveNameAndAbsolutePathFromPath(path)
+	projectsRoot := viper.GetString("projects-root")
+	derivedName, derivedPath := deriveNameAndAbsolutePathFromPath(path, projectsRoot)
+
+	// A config file named [name].func.yaml names the function, such that
+	// those of one directory are named distinctly.
+	configFile := configFile()
+	if strings.HasSuffix(configFile, "."+fn.ConfigFile) {
+		derivedName = strings.TrimSuffix(configFile, "."+fn.ConfigFile)
+	}
+
+	runtime, version := buildpacks.ParseRuntime(viper.GetString("runtime"))
+	if _, env := os.LookupEnv("FUNC_RUNTIME"); !env && !cmd.Flags().Changed("runtime") {
+		if detected, ok := buildpacks.DetectRuntime(derivedPath); ok {
+			runtime = detected
+		}
+	}
+
+	offline := viper.GetBool("offline")
+	repositories := viper.GetString("repositories")
+	if offline {
+		repositories = ""
+	}
+
+	ci := viper.GetString("with-ci")
+	if ci == ciNone {
+		ci = ""
+	}
+
 	return createConfig{
 		Name:         derivedName,
 		Path:         derivedPath,
-		Repositories: viper.GetString("repositories"),
-		Runtime:      viper.GetString("runtime"),
-		Template:     viper.GetString("template"),
-		Confirm:      viper.GetBool("confirm"),
-		Verbose:      viper.GetBool("verbose"),
+		ProjectsRoot: projectsRoot,
+		ConfigFile:   configFile,
+		Repositories: repositories,
+		Offline:      offline,
+
+		RepositoriesTTL: viper.GetDuration("repositories-ttl"),
+		Runtime:         runtime,
+		RuntimeVersion:  version,
+		Template:        viper.GetString("template"),
+		TemplateRef:     viper.GetString("ref"),
+		Builder:         viper.GetString("builder"),
+		Registry:        viper.GetString("registry"),
+		ImageSuffix:     viper.GetString("image-suffix"),
+		PinBuilder:      viper.GetBool("pin-builder"),
+		CI:              ci,
+		Devcontainer:    viper.GetBool("with-devcontainer"),
+		Style:           viper.GetBool("with-style"),
+		License:         viper.GetString("license"),
+		PackageManager:  viper.GetString("package-manager"),
+		Entrypoint:      viper.GetString("entrypoint"),
+		Author:          viper.GetString("author"),
+		StrictName:      viper.GetBool("strict-name"),
+		Force:           viper.GetBool("force"),
+		OnConflict:      viper.GetString("on-conflict"),
+		Answers:         viper.GetString("answers"),
+		Confirm:         viper.GetBool("confirm"),
+		Verbose:         viper.GetBool("verbose"),
+
+		OverwriteRuntimeFilesOnly: viper.GetBool("overwrite-runtime-files-only"),
+		RuntimeVersionFile:        viper.GetBool("runtime-version-file"),
 	}
 }
 
 // Prompt the user with value of config members, allowing for interaractive changes.
 // Skipped if not in an interactive terminal (non-TTY), or if --confirm false (agree to
 // all prompts) was set (default).
-func (c createConfig) Prompt() (createConfig, error) {
+func (c createConfig) Prompt(templates []fn.Template) (createConfig, error) {
 	if !interactiveTerminal() || !c.Confirm {
 		// Just print the basics if not confirming
-		fmt.Printf("Project path: %v\n", c.Path)
-		fmt.Printf("Function name: %v\n", c.Name)
-		fmt.Printf("Runtime: %v\n", c.Runtime)
-		fmt.Printf("Template: %v\n", c.Template)
+		c.print()
 		return c, nil
 	}
 
-	var qs = []*survey.Question{
+	// The runtime and template are asked first, such that the template is
+	// selected from those of the runtime, and its preferences default the
+	// path, registry and image suffix asked thereafter.
+	answers := createAnswers{}
+	err := survey.Ask(questionsNamed(c.questions(templates), "runtime"), &answers)
+	if err != nil {
+		return createConfig{}, err
+	}
+	runtime, _ := buildpacks.ParseRuntime(answers.Runtime)
+	template, err := selectTemplate(templates, runtime, c.Template)
+	if err != nil {
+		return createConfig{}, err
+	}
+	answers.Template = template.Name
+
+	c = c.withPreferences(template.Preferences)
+	err = survey.Ask(questionsNamed(c.questions(templates), "path", "registry", "imageSuffix"), &answers)
+	if err != nil {
+		return createConfig{}, err
+	}
+
+	c = c.withAnswers(answers)
+	if c.Entrypoint, err = selectEntrypoint(template, c.Entrypoint); err != nil {
+		return createConfig{}, err
+	}
+	if c.Name, err = resolveName(c.Name, c.StrictName); err != nil {
+		return createConfig{}, err
+	}
+	return c, nil
+}
+
+// selectEntrypoint prompts for the handler of the template which is the
+// entrypoint of the Function, of templates exposing several, unless one is
+// given.
+func selectEntrypoint(template fn.Template, entrypoint string) (string, error) {
+	if entrypoint != "" || len(template.Handlers) < 2 {
+		return entrypoint, nil
+	}
+	err := survey.AskOne(&survey.Select{
+		Message: "Entrypoint:",
+		Options: template.Handlers,
+		Default: template.Handlers[0],
+	}, &entrypoint)
+	return entrypoint, err
+}
+
+// questionsNamed returns those of the questions of the given names, in order.
+func questionsNamed(questions []*survey.Question, names ...string) (named []*survey.Question) {
+	for _, q := range questions {
+		for _, name := range names {
+			if q.Name == name {
+				named = append(named, q)
+			}
+		}
+	}
+	return
+}
+
+// otherTemplate is the option of the template prompt with which a template
+// not listed is entered by name, such as one of a repository not yet added.
+const otherTemplate = "other (enter its name)"
+
+// selectTemplate prompts for one of the templates of the runtime, each listed
+// with its description, defaulting to the template of the given name.  That
+// of another name, such as one not listed, is entered as free text.
+func selectTemplate(templates []fn.Template, runtime, name string) (fn.Template, error) {
+	options, selectable := templateOptions(templates, runtime)
+	listed := false
+	if len(options) > 0 {
+		def := otherTemplate
+		if name == "" {
+			def = options[0]
+		}
+		for option, t := range selectable {
+			if t.Name == name {
+				def, listed = option, true
+			}
+		}
+		var selected string
+		err := survey.AskOne(&survey.Select{
+			Message: "Template:",
+			Options: append(options, otherTemplate),
+			Default: def,
+		}, &selected)
+		if err != nil {
+			return fn.Template{}, err
+		}
+		if t, ok := selectable[selected]; ok {
+			return t, nil
+		}
+	}
+
+	if listed {
+		name = ""
+	}
+	err := survey.AskOne(&survey.Input{
+		Message: "Template name:",
+		Default: name,
+	}, &name, survey.WithValidator(survey.Required))
+	return fn.Template{Name: name, Runtime: runtime}, err
+}
+
+// templateOptions returns the options with which the templates of the runtime
+// are prompted for: their names, followed by their descriptions, if any.  The
+// templates are returned by option.
+func templateOptions(templates []fn.Template, runtime string) (options []string, byOption map[string]fn.Template) {
+	byOption = map[string]fn.Template{}
+	for _, t := range templates {
+		if t.Runtime != runtime {
+			continue
+		}
+		option := t.Name
+		if t.Description != "" {
+			option = fmt.Sprintf("%v - %v", t.Name, t.Description)
+		}
+		options = append(options, option)
+		byOption[option] = t
+	}
+	return
+}
+
+// withPreferences returns the config with the preferences of the template, if
+// any, as its defaults: the name it suggests as a directory of the current
+// one, unless another path is given, the registry it suggests, unless one is
+// configured, and the image suffix it suggests, unless one is given.
+func (c createConfig) withPreferences(preferences *fn.TemplatePreferences) createConfig {
+	if preferences == nil {
+		return c
+	}
+	if preferences.Name != "" && c.Path == cwd() {
+		c.Path = filepath.Join(c.Path, preferences.Name)
+		c.Name = preferences.Name
+	}
+	if preferences.Registry != "" && defaultRegistry(c.Registry, c.Path) == "" {
+		c.Registry = preferences.Registry
+	}
+	if preferences.ImageSuffix != "" && c.ImageSuffix == "" {
+		c.ImageSuffix = preferences.ImageSuffix
+	}
+	return c
+}
+
+// questions asked when prompting, defaulting to the values of the config,
+// with runtime options populated from the templates available.  The template
+// is selected separately (see selectTemplate).
+// Their validation is also applied to answers read from a file.
+func (c createConfig) questions(templates []fn.Template) []*survey.Question {
+	runtimes := runtimeOptions(templates)
+	return []*survey.Question{
 		{
 			Name: "path",
 			Prompt: &survey.Input{
@@ -182,7 +964,10 @@ func (c createConfig) Prompt() (createConfig, error) {
 				Default: c.Path,
 			},
 			Validate: func(val interface{}) error {
-				derivedName, _ := deriveNameAndAbsolutePathFromPath(val.(string))
+				derivedName, _ := deriveNameAndAbsolutePathFromPath(val.(string), c.ProjectsRoot)
+				if !c.StrictName && utils.ValidateFunctionName(derivedName) != nil {
+					derivedName = generateName(derivedName)
+				}
 				return utils.ValidateFunctionName(derivedName)
 			},
 		},
@@ -190,35 +975,294 @@ func (c createConfig) Prompt() (createConfig, error) {
 			Name: "runtime",
 			Prompt: &survey.Select{
 				Message: "Runtime:",
-				Options: buildpacks.RuntimesList(),
+				Options: runtimes,
 				Default: c.Runtime,
 			},
+			Validate: func(val interface{}) error {
+				var runtime string
+				switch v := val.(type) {
+				case string:
+					runtime = v
+				case survey.OptionAnswer:
+					runtime = v.Value
+				}
+				runtime, version := buildpacks.ParseRuntime(runtime)
+				if err := buildpacks.ValidateRuntimeVersion(runtime, version); err != nil {
+					return err
+				}
+				for _, r := range runtimes {
+					if r == runtime {
+						return nil
+					}
+				}
+				return fmt.Errorf("unsupported runtime '%v'. Available runtimes: %v", runtime, strings.Join(runtimes, ", "))
+			},
+		},
+		{
+			Name: "registry",
+			Prompt: &survey.Input{
+				Message: "Registry for Function images (optional, e.g. ghcr.io/alice):",
+				Default: defaultRegistry(c.Registry, c.Path),
+			},
+			Validate: func(val interface{}) error {
+				if val.(string) == "" {
+					return nil
+				}
+				return utils.ValidateRegistry(val.(string))
+			},
 		},
 		{
-			Name: "template",
+			Name: "imageSuffix",
 			Prompt: &survey.Input{
-				Message: "Template:",
-				Default: c.Template,
-				// TODO add template suggestions: https://github.com/AlecAivazis/survey#suggestion-options
+				Message: fmt.Sprintf("Suffix of the Function's name in its image name (optional, e.g. -fn, or %v):", noImageSuffix),
+				Default: c.ImageSuffix,
+			},
+			Validate: func(val interface{}) error {
+				if val.(string) == noImageSuffix {
+					return nil
+				}
+				return fn.ValidateImageSuffix(val.(string))
 			},
 		},
 	}
-	answers := struct {
-		Template string
-		Runtime  string
-		Path     string
-	}{}
-	err := survey.Ask(qs, &answers)
+}
+
+// noImageSuffix is the answer clearing the image suffix, such as one suggested
+// by the template, which an empty answer would otherwise default to.
+const noImageSuffix = "none"
+
+// createAnswers are the responses to the create prompts, as given
+// interactively or read from an answers file.
+type createAnswers struct {
+	Path        string `yaml:"path"`
+	Name        string `yaml:"name"`
+	Runtime     string `yaml:"runtime"`
+	Template    string `yaml:"template"`
+	Registry    string `yaml:"registry"`
+	ImageSuffix string `yaml:"imageSuffix"`
+}
+
+// withAnswers returns the config updated with the given answers, all other
+// members carried through as given.
+func (c createConfig) withAnswers(answers createAnswers) createConfig {
+	derivedName, derivedPath := deriveNameAndAbsolutePathFromPath(answers.Path, c.ProjectsRoot)
+	if answers.Name != "" {
+		derivedName = answers.Name
+	}
+
+	// The version of the runtime is that answered, if any, or otherwise that
+	// given with --runtime.
+	runtime, version := buildpacks.ParseRuntime(answers.Runtime)
+	if version == "" {
+		version = c.RuntimeVersion
+	}
+
+	// The image suffix is that answered, if any, or otherwise that given
+	// with --image-suffix, unless cleared with noImageSuffix.
+	imageSuffix := answers.ImageSuffix
+	if imageSuffix == "" {
+		imageSuffix = c.ImageSuffix
+	} else if imageSuffix == noImageSuffix {
+		imageSuffix = ""
+	}
+
+	c.Name = derivedName
+	c.Path = derivedPath
+	c.Runtime = runtime
+	c.RuntimeVersion = version
+	c.Template = answers.Template
+	c.Registry = answers.Registry
+	c.ImageSuffix = imageSuffix
+	return c
+}
+
+// Answer the prompts with the answers read from the given YAML file rather
+// than interactively, applying the same validation as the prompts.  The path,
+// runtime and template are required.  The name defaults to that derived from
+// the path, and the registry is optional.  Runtimes are validated against
+// those of the given templates available.
+func (c createConfig) Answer(file string, templates []fn.Template) (createConfig, error) {
+	bb, err := ioutil.ReadFile(file)
 	if err != nil {
-		return createConfig{}, err
+		return createConfig{}, fmt.Errorf("unable to read answers file: %w", err)
+	}
+	answers := createAnswers{}
+	if err = yaml.UnmarshalStrict(bb, &answers); err != nil {
+		return createConfig{}, fmt.Errorf("answers file '%v' is not valid: %v", file, err)
 	}
 
-	derivedName, derivedPath := deriveNameAndAbsolutePathFromPath(answers.Path)
+	values := map[string]string{
+		"path":        answers.Path,
+		"runtime":     answers.Runtime,
+		"template":    answers.Template,
+		"registry":    answers.Registry,
+		"imageSuffix": answers.ImageSuffix,
+	}
+	missing := []string{}
+	for _, required := range []string{"path", "runtime", "template"} {
+		if values[required] == "" {
+			missing = append(missing, required)
+		}
+	}
+	if len(missing) > 0 {
+		return createConfig{}, fmt.Errorf("answers file '%v' is missing required answers: %v", file, strings.Join(missing, ", "))
+	}
 
-	return createConfig{
-		Name:     derivedName,
-		Path:     derivedPath,
-		Runtime:  answers.Runtime,
-		Template: answers.Template,
-	}, nil
+	for _, q := range c.questions(templates) {
+		if q.Validate == nil {
+			continue
+		}
+		if err = q.Validate(values[q.Name]); err != nil {
+			return createConfig{}, fmt.Errorf("answers file '%v' has an invalid %v: %w", file, q.Name, err)
+		}
+	}
+	if answers.Name != "" {
+		if err = utils.ValidateFunctionName(answers.Name); err != nil {
+			return createConfig{}, fmt.Errorf("answers file '%v' has an invalid name: %w", file, err)
+		}
+	}
+
+	c = c.withAnswers(answers)
+	if answers.Name == "" {
+		if c.Name, err = resolveName(c.Name, c.StrictName); err != nil {
+			return createConfig{}, fmt.Errorf("answers file '%v' has an invalid path: %w", file, err)
+		}
+	}
+	c.print()
+	return c, nil
+}
+
+// nameGenerator proposes a valid function name derived from one which is not,
+// such as that of a directory, returning "" if it has none to propose.
+type nameGenerator func(name string) string
+
+// generateName is the generator of the names of functions created with
+// names derived from their path which are not valid.
+var generateName nameGenerator = utils.SanitizeFunctionName
+
+// resolveName returns the name of the function to create: that given, if
+// valid, or otherwise, unless strict, that proposed by generateName, which is
+// confirmed in an interactive terminal and warned of otherwise.
+func resolveName(name string, strict bool) (string, error) {
+	err := utils.ValidateFunctionName(name)
+	if err == nil || strict {
+		return name, err
+	}
+	proposed := generateName(name)
+	if utils.ValidateFunctionName(proposed) != nil {
+		return name, err
+	}
+	if !interactiveTerminal() {
+		warn(os.Stderr, "'%v' is not a valid function name, naming the function '%v'. Use --strict-name to fail instead", name, proposed)
+		return proposed, nil
+	}
+	accept := true
+	prompt := &survey.Confirm{Message: fmt.Sprintf("'%v' is not a valid function name. Name the function '%v'?", name, proposed), Default: true}
+	if e := survey.AskOne(prompt, &accept); e != nil {
+		return name, e
+	}
+	if !accept {
+		return name, err
+	}
+	return proposed, nil
+}
+
+// print the basics of the config.
+func (c createConfig) print() {
+	out := infoOut(os.Stdout)
+	fmt.Fprintf(out, "Project path: %v\n", c.Path)
+	fmt.Fprintf(out, "Function name: %v\n", c.Name)
+	fmt.Fprintf(out, "Runtime: %v\n", c.Runtime)
+	if c.RuntimeVersion != "" {
+		fmt.Fprintf(out, "Runtime version: %v\n", c.RuntimeVersion)
+	}
+	fmt.Fprintf(out, "Template: %v\n", c.Template)
+	if c.TemplateRef != "" {
+		fmt.Fprintf(out, "Template ref: %v\n", c.TemplateRef)
+	}
+	if c.Builder != "" {
+		fmt.Fprintf(out, "Builder: %v\n", c.Builder)
+	}
+	if c.Registry != "" {
+		fmt.Fprintf(out, "Registry: %v\n", c.Registry)
+	}
+	if c.ImageSuffix != "" {
+		fmt.Fprintf(out, "Image suffix: %v\n", c.ImageSuffix)
+	}
+	if c.CI != "" {
+		fmt.Fprintf(out, "CI: %v\n", c.CI)
+	}
+	if c.Devcontainer {
+		fmt.Fprintln(out, "Dev container: .devcontainer/devcontainer.json")
+	}
+	if c.Style {
+		fmt.Fprintln(out, "Style: editorconfig and formatter config")
+	}
+	if c.License != "" {
+		fmt.Fprintf(out, "License: %v\n", c.License)
+	}
+	if c.PackageManager != "" {
+		fmt.Fprintf(out, "Package manager: %v\n", c.PackageManager)
+	}
+	if c.Entrypoint != "" {
+		fmt.Fprintf(out, "Entrypoint: %v\n", c.Entrypoint)
+	}
+}
+
+// ciNone is the value of --with-ci for which no CI is written.
+const ciNone = "none"
+
+// Policies for existing files of the same name as those of the template.
+const (
+	onConflictOverwrite = "overwrite"
+	onConflictSkip      = "skip"
+	onConflictError     = "error"
+)
+
+// validateOnConflict ensures the policy given with --on-conflict is known.
+func validateOnConflict(policy string) error {
+	switch policy {
+	case onConflictOverwrite, onConflictSkip, onConflictError:
+		return nil
+	}
+	return fmt.Errorf("invalid value '%v' for --on-conflict: must be one of %v, %v or %v", policy, onConflictOverwrite, onConflictSkip, onConflictError)
+}
+
+// conflictResolution returns whether existing files are overwritten, or
+// otherwise their resolver, if any.  When forced interactively with --confirm
+// each is prompted for, and otherwise the --on-conflict policy applies, with
+// --force overwriting unless they are to be skipped.
+func (c createConfig) conflictResolution() (force bool, onConflict fn.ConflictResolver) {
+	switch {
+	case c.Force && c.Confirm && interactiveTerminal():
+		return false, promptConflict
+	case c.OnConflict == onConflictSkip:
+		return false, func(string, []byte, []byte) (bool, error) { return false, nil }
+	case c.OnConflict == onConflictOverwrite:
+		return true, nil
+	}
+	return c.Force, nil
+}
+
+// promptConflict asks whether to overwrite or skip the existing file at path,
+// offering to first show its differences from that of the template.
+func promptConflict(path string, existing, template []byte) (overwrite bool, err error) {
+	for {
+		var answer string
+		if err = survey.AskOne(&survey.Select{
+			Message: fmt.Sprintf("File '%v' already exists:", path),
+			Options: []string{"overwrite", "skip", "diff"},
+			Default: "skip",
+		}, &answer); err != nil {
+			return
+		}
+		switch answer {
+		case "overwrite":
+			return true, nil
+		case "skip":
+			return false, nil
+		}
+		fmt.Printf("Differences of '%v' (-existing +template):\n%v", path,
+			cmp.Diff(strings.Split(string(existing), "\n"), strings.Split(string(template), "\n")))
+	}
 }
@@ -1,10 +1,21 @@
 package cmd
 
 import (
+	"bytes"
 	"errors"
 	"io/ioutil"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/spf13/cobra"
 
 	fn "github.com/boson-project/func"
 	"github.com/boson-project/func/utils"
@@ -17,12 +28,12 @@ func TestCreateValidatesName(t *testing.T) {
 
 	// Create a new Create command with a fn.Client construtor
 	// which returns a default (noop) client suitable for tests.
-	cmd := NewCreateCmd(func(string, bool) *fn.Client {
+	cmd := NewCreateCmd(func(createConfig) *fn.Client {
 		return fn.New()
 	})
 
 	// Execute the command with a function name containing invalid characters.
-	cmd.SetArgs([]string{"invalid!"})
+	cmd.SetArgs([]string{"--strict-name", "invalid!"})
 	err := cmd.Execute()
 
 	// Confirm the expected error is returned
@@ -32,6 +43,530 @@ func TestCreateValidatesName(t *testing.T) {
 	}
 }
 
+// TestCreateSanitizesName ensures that, without --strict-name, a function
+// created in a directory whose name is not a valid function name is named
+// with a valid name derived from it when not in an interactive terminal, and
+// that one from which none can be derived fails.
+func TestCreateSanitizesName(t *testing.T) {
+	defer fromTempDir(t)()
+
+	// Not in an interactive terminal, the name is not confirmed.
+	stdin, err := ioutil.TempFile("", "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(stdin.Name())
+	defer func(s *os.File) { os.Stdin = s }(os.Stdin)
+	os.Stdin = stdin
+
+	tests := []struct {
+		dir  string
+		name string
+	}{
+		{"My_Func", "my-func"},
+		{"Hello World", "hello-world"},
+		{"__init__", "init"},
+		{"api.v2", "api-v2"},
+	}
+	for _, tt := range tests {
+		cmd := NewCreateCmd(newCreateClient)
+		cmd.SetArgs([]string{tt.dir})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%v: %v", tt.dir, err)
+		}
+		f, err := fn.NewFunction(tt.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name != tt.name {
+			t.Fatalf("expected the function of '%v' to be named '%v', got '%v'", tt.dir, tt.name, f.Name)
+		}
+	}
+
+	cmd := NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"___"})
+	var e utils.ErrInvalidFunctionName
+	if err := cmd.Execute(); !errors.As(err, &e) || !strings.Contains(err.Error(), "Function name") {
+		t.Fatalf("expected the name to be invalid, got %v", err)
+	}
+}
+
+// TestCreateValidatesRegistry ensures that the create command only accepts
+// registries of the form 'namespace' or 'registry/namespace', of which each
+// namespace component is valid.
+func TestCreateValidatesRegistry(t *testing.T) {
+	defer fromTempDir(t)()
+
+	cmd := NewCreateCmd(func(createConfig) *fn.Client {
+		return fn.New()
+	})
+
+	cmd.SetArgs([]string{"--registry", "quay.io/alice/Extra", "myfunc"})
+	err := cmd.Execute()
+
+	var e utils.ErrInvalidRegistry
+	if err == nil || !errors.As(err, &e) {
+		t.Fatalf("Did not receive ErrInvalidRegistry. Got %v", err)
+	}
+}
+
+// TestCreatePersistsRegistry ensures that the registry provided to the
+// create command is stored in the Function's configuration, from which its
+// image name is then derived.
+func TestCreatePersistsRegistry(t *testing.T) {
+	defer fromTempDir(t)()
+
+	cmd := NewCreateCmd(func(createConfig) *fn.Client {
+		return fn.New()
+	})
+
+	cmd.SetArgs([]string{"--registry", "ghcr.io/alice", "myfunc"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := fn.NewFunction("myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Registry != "ghcr.io/alice" {
+		t.Fatalf("expected registry 'ghcr.io/alice', got '%v'", f.Registry)
+	}
+
+	image, err := fn.DerivedImage("myfunc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image != "ghcr.io/alice/myfunc:latest" {
+		t.Fatalf("expected image 'ghcr.io/alice/myfunc:latest', got '%v'", image)
+	}
+}
+
+// TestCreateRerun ensures that creating a Function again with the same
+// settings succeeds without modifying it, that differing settings are
+// reported, and that --force overwrites it regardless.
+func TestCreateRerun(t *testing.T) {
+	defer fromTempDir(t)()
+
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := NewCreateCmd(func(config createConfig) *fn.Client {
+			force, _ := config.conflictResolution()
+			return fn.New(fn.WithForce(force))
+		})
+		cmd.SetArgs(append(args, "myfunc"))
+		return cmd
+	}
+
+	if err := newCmd("--runtime", "go").Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if err := newCmd("--runtime", "go").Execute(); err != nil {
+		t.Fatalf("expected re-running with the same settings to succeed, got %v", err)
+	}
+
+	err := newCmd("--runtime", "node").Execute()
+	if !errors.Is(err, fn.ErrConfigMismatch) || !strings.Contains(err.Error(), "runtime is 'go', not 'node'") {
+		t.Fatalf("expected the differing runtime to be reported, got '%v'", err)
+	}
+
+	if err = newCmd("--runtime", "node", "--force").Execute(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction("myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Runtime != "node" {
+		t.Fatalf("expected the function to be overwritten when forced, got runtime '%v'", f.Runtime)
+	}
+}
+
+// TestCreateIncompleteScaffold ensures that creating a Function in a
+// directory containing a scaffold whose creation did not complete errors
+// suggesting --force, with which the creation is completed.
+func TestCreateIncompleteScaffold(t *testing.T) {
+	defer fromTempDir(t)()
+
+	// Simulate a creation which failed part way: the marker of a creation in
+	// progress, some source, but no func.yaml.
+	if err := os.MkdirAll(filepath.Join("myfunc", fn.RunDataDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join("myfunc", fn.RunDataDir, fn.ScaffoldingFile), []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join("myfunc", "handle.go"), []byte("package function\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := NewCreateCmd(func(config createConfig) *fn.Client {
+			force, _ := config.conflictResolution()
+			return fn.New(fn.WithForce(force))
+		})
+		cmd.SetArgs(append(args, "myfunc"))
+		return cmd
+	}
+
+	err := newCmd().Execute()
+	if !errors.Is(err, fn.ErrIncompleteScaffold) || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected an incomplete scaffold error suggesting --force, got '%v'", err)
+	}
+
+	if err = newCmd("--force").Execute(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction("myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Initialized() {
+		t.Fatal("expected the function to be initialized once completed")
+	}
+}
+
+// TestCreateAnswers ensures that answers read from a file are used in place
+// of interactive prompting, are validated, and that missing required answers
+// are listed.
+func TestCreateAnswers(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers string
+		err     string // expected error substring, if any
+	}{
+		{"valid", "path: myfunc\nruntime: go\ntemplate: events\nregistry: ghcr.io/alice\n", ""},
+		{"missing", "registry: ghcr.io/alice\n", "path, runtime, template"},
+		{"invalid runtime", "path: myfunc\nruntime: cobol\ntemplate: http\n", "runtime"},
+		{"invalid registry", "path: myfunc\nruntime: go\ntemplate: http\nregistry: a/B/c\n", "registry"},
+		{"unknown answer", "path: myfunc\nruntime: go\ntemplate: http\ncolor: blue\n", "not valid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer fromTempDir(t)()
+
+			if err := ioutil.WriteFile("answers.yaml", []byte(tt.answers), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := NewCreateCmd(func(createConfig) *fn.Client {
+				return fn.New()
+			})
+			cmd.SetArgs([]string{"--answers", "answers.yaml"})
+			err := cmd.Execute()
+
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected an error containing '%v', got '%v'", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := fn.NewFunction("myfunc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Name != "myfunc" || f.Runtime != "go" || f.Template != "events" || f.Registry != "ghcr.io/alice" {
+				t.Fatalf("expected the function to be created per the answers, got %+v", f)
+			}
+		})
+	}
+}
+
+// TestCreateDetectsRuntime ensures that the runtime defaults to that detected
+// from source already present, and that an explicit runtime takes precedence.
+func TestCreateDetectsRuntime(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		runtime string
+	}{
+		{"detected", nil, "node"},
+		{"explicit", []string{"--runtime", "go"}, "go"},
+		{"alias", []string{"--runtime", "golang"}, "go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer fromTempDir(t)()
+
+			if err := os.MkdirAll("myfunc", 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(filepath.Join("myfunc", "package.json"), []byte("{}"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := NewCreateCmd(func(config createConfig) *fn.Client {
+				force, _ := config.conflictResolution()
+				return fn.New(fn.WithForce(force))
+			})
+			cmd.SetArgs(append(tt.args, "--force", "myfunc"))
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := fn.NewFunction("myfunc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Runtime != tt.runtime {
+				t.Fatalf("expected runtime '%v', got '%v'", tt.runtime, f.Runtime)
+			}
+		})
+	}
+}
+
+// TestCreateRuntimeVersion ensures that the version of a runtime given as
+// runtime@version is stored in the Function's configuration, and that one of
+// a runtime the version of which can not be selected is rejected.
+func TestCreateRuntimeVersion(t *testing.T) {
+	defer fromTempDir(t)()
+
+	create := func(args ...string) error {
+		cmd := NewCreateCmd(func(createConfig) *fn.Client {
+			return fn.New()
+		})
+		cmd.SetArgs(args)
+		return cmd.Execute()
+	}
+
+	if err := create("--runtime", "nodejs@18", "myfunc"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction("myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Runtime != "node" || f.RuntimeVersion != "18" {
+		t.Fatalf("expected runtime 'node' of version '18', got '%v' of '%v'", f.Runtime, f.RuntimeVersion)
+	}
+
+	if err = create("--runtime", "rust@1.60", "otherfunc"); err == nil || !strings.Contains(err.Error(), "can not be selected") {
+		t.Fatalf("expected an error for the version of rust, got %v", err)
+	}
+}
+
+// TestCreateProjectsRoot ensures that a Function given a relative path is
+// created within the projects root, and one given an absolute path where
+// given.
+func TestCreateProjectsRoot(t *testing.T) {
+	defer fromTempDir(t)()
+	root := filepath.Join(pwd(t), "functions")
+	elsewhere := filepath.Join(pwd(t), "elsewhere", "otherfunc")
+
+	for _, path := range []string{"myfunc", elsewhere} {
+		cmd := NewCreateCmd(func(createConfig) *fn.Client {
+			return fn.New()
+		})
+		cmd.SetArgs([]string{"--projects-root", root, path})
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := fn.NewFunction(filepath.Join(root, "myfunc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Initialized() || f.Name != "myfunc" {
+		t.Fatalf("expected function 'myfunc' to be created within the projects root")
+	}
+	if _, err = os.Stat("myfunc"); !os.IsNotExist(err) {
+		t.Fatal("expected the function not to be created in the current directory")
+	}
+	if f, err = fn.NewFunction(elsewhere); err != nil || !f.Initialized() {
+		t.Fatalf("expected the function given an absolute path to be created there, bypassing the projects root. %v", err)
+	}
+}
+
+// TestCreateOverwriteRuntimeFilesOnly ensures that refreshing the managed
+// files of a template can not be combined with --force, and writes those
+// declared by the embedded templates.
+func TestCreateOverwriteRuntimeFilesOnly(t *testing.T) {
+	defer fromTempDir(t)()
+
+	for _, args := range [][]string{{"--force"}, {"--on-conflict", "skip"}, {}} {
+		cmd := NewCreateCmd(func(config createConfig) *fn.Client {
+			force, _ := config.conflictResolution()
+			return fn.New(fn.WithForce(force), fn.WithManagedFilesOnly(config.OverwriteRuntimeFilesOnly))
+		})
+		cmd.SetArgs(append(args, "--overwrite-runtime-files-only", "myfunc"))
+		err := cmd.Execute()
+		if len(args) > 0 && (err == nil || !strings.Contains(err.Error(), "can not be combined")) {
+			t.Fatalf("expected %v to be refused, got %v", args, err)
+		}
+		if len(args) == 0 && err != nil {
+			t.Fatalf("expected the managed files of the embedded template to be written, got %v", err)
+		}
+	}
+}
+
+// TestCreateListsAvailableTemplates ensures that requesting a nonexistent
+// template lists the templates available for the runtime.
+func TestCreateListsAvailableTemplates(t *testing.T) {
+	defer fromTempDir(t)()
+
+	cmd := NewCreateCmd(func(createConfig) *fn.Client {
+		return fn.New()
+	})
+	cmd.SetArgs([]string{"--runtime", "go", "--template", "invalid", "myfunc"})
+	err := cmd.Execute()
+	if !errors.Is(err, fn.ErrTemplateNotFound) {
+		t.Fatalf("expected ErrTemplateNotFound, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Available templates for runtime 'go': events, http") {
+		t.Fatalf("expected the available templates to be listed, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Signatures supported by runtime 'go': events, http") {
+		t.Fatalf("expected the supported signatures to be listed, got %v", err)
+	}
+	if _, err = os.Stat("myfunc"); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be scaffolded, got %v", err)
+	}
+}
+
+// TestCreateRepositoryTemplate ensures a template of a repository is found
+// by the name with which it is listed.
+func TestCreateRepositoryTemplate(t *testing.T) {
+	repositories, err := filepath.Abs("../testdata/repositories")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fromTempDir(t)()
+
+	cmd := NewCreateCmd(func(config createConfig) *fn.Client {
+		return fn.New(fn.WithRepositories(config.Repositories))
+	})
+	cmd.SetArgs([]string{"--repositories", repositories, "--repositories-ttl", "0", "--runtime", "test", "--template", "customProvider/tpla", "myfunc"})
+	if err = cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCreateTemplatePreferences ensures the templates of a runtime are offered
+// with their descriptions, and that the name and registry a template prefers
+// default those of the Function, unless given.
+func TestCreateTemplatePreferences(t *testing.T) {
+	defer fromTempDir(t)()
+	root := pwd(t)
+
+	templates := []fn.Template{
+		{Name: "http", Runtime: "go", Description: "Function invoked by HTTP requests"},
+		{Name: "events", Runtime: "go"},
+		{Name: "events", Runtime: "node"},
+	}
+	options, selectable := templateOptions(templates, "go")
+	if len(options) != 2 || options[0] != "http - Function invoked by HTTP requests" || options[1] != "events" {
+		t.Fatalf("unexpected template options %v", options)
+	}
+	if selectable[options[0]].Name != "http" {
+		t.Fatalf("expected the option to select the template, got %+v", selectable[options[0]])
+	}
+
+	preferences := &fn.TemplatePreferences{Name: "jsonfunc", Registry: "quay.io/alice"}
+	c := createConfig{Path: root}.withPreferences(preferences)
+	if c.Path != filepath.Join(root, "jsonfunc") || c.Name != "jsonfunc" || c.Registry != "quay.io/alice" {
+		t.Fatalf("expected the preferences to default the path, name and registry, got %+v", c)
+	}
+	c = createConfig{Path: filepath.Join(root, "myfunc"), Name: "myfunc", Registry: "ghcr.io/bob"}.withPreferences(preferences)
+	if c.Path != filepath.Join(root, "myfunc") || c.Name != "myfunc" || c.Registry != "ghcr.io/bob" {
+		t.Fatalf("expected the given path and registry to be kept, got %+v", c)
+	}
+
+	// The image suffix the template suggests defaults that of the Function,
+	// unless one is given, and the directory given names the Function over
+	// the name the template suggests.
+	preferences = &fn.TemplatePreferences{Name: "jsonfunc", ImageSuffix: "-fn"}
+	c = createConfig{Path: root}.withPreferences(preferences)
+	if c.Name != "jsonfunc" || c.ImageSuffix != "-fn" {
+		t.Fatalf("expected the preferences to default the name and image suffix, got %+v", c)
+	}
+	c = createConfig{Path: filepath.Join(root, "orders"), Name: "orders", ImageSuffix: "-svc"}.withPreferences(preferences)
+	if c.Name != "orders" || c.ImageSuffix != "-svc" {
+		t.Fatalf("expected the given directory and image suffix to be kept, got %+v", c)
+	}
+	answered := c.withAnswers(createAnswers{Path: filepath.Join(root, "orders"), Runtime: "go", Template: "http"})
+	if answered.ImageSuffix != "-svc" {
+		t.Fatalf("expected the image suffix to be kept when not answered, got %+v", answered)
+	}
+	if answered = c.withAnswers(createAnswers{Path: filepath.Join(root, "orders"), Runtime: "go", Template: "http", ImageSuffix: "-api"}); answered.ImageSuffix != "-api" {
+		t.Fatalf("expected the image suffix answered to be used, got %+v", answered)
+	}
+	if answered = c.withAnswers(createAnswers{Path: filepath.Join(root, "orders"), Runtime: "go", Template: "http", ImageSuffix: noImageSuffix}); answered.ImageSuffix != "" {
+		t.Fatalf("expected the image suffix to be cleared, got %+v", answered)
+	}
+}
+
+// TestValidateTemplate ensures a template is only valid for a runtime which
+// has it, and which supports the signature it declares, and that one given as
+// a URL is of a path within its repository.
+func TestValidateTemplate(t *testing.T) {
+	templates := []fn.Template{
+		{Name: "http", Runtime: "go", Signature: "http"},
+		{Name: "events", Runtime: "go", Signature: "events"},
+		{Name: "boson/grpc", Runtime: "go", Repository: "boson", Signature: "grpc"},
+		{Name: "boson/json", Runtime: "go", Repository: "boson"},
+		{Name: "boson/events", Runtime: "cobol", Repository: "boson", Signature: "events"},
+	}
+	tests := []struct {
+		name     string
+		runtime  string
+		template string
+		ref      string
+		err      error // expected sentinel, if any
+		wantErr  bool
+	}{
+		{"embedded", "go", "http", "", nil, false},
+		{"undeclared signature", "go", "boson/json", "", nil, false},
+		{"runtime without embedded templates", "cobol", "boson/events", "", nil, false},
+		{"unknown template", "go", "invalid", "", fn.ErrTemplateNotFound, true},
+		{"unknown runtime", "rust", "http", "", fn.ErrRuntimeNotFound, true},
+		{"unknown runtime of repository", "rust", "boson/json", "", fn.ErrRuntimeNotFound, true},
+		{"unknown repository", "go", "alice/http", "", fn.ErrRepositoryNotFound, true},
+		{"unsupported signature", "go", "boson/grpc", "", nil, true},
+		{"pinned to a ref", "go", "boson/new", "v1.0.0", nil, false},
+		{"url", "go", "git://github.com/alice/templates//go/json@v1", "", nil, false},
+		{"url outside of its repository", "go", "git://github.com/alice/templates//../json", "", fn.ErrInvalidTemplateURL, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTemplate(templates, tt.runtime, tt.template, tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+// TestCreateOffline ensures that offline, template repositories are neither
+// provided to the client nor used, templates which are not embedded being an
+// error.
+func TestCreateOffline(t *testing.T) {
+	repositories, err := filepath.Abs("../testdata/repositories")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fromTempDir(t)()
+
+	var provided string
+	cmd := NewCreateCmd(func(config createConfig) *fn.Client {
+		provided = config.Repositories
+		return fn.New(fn.WithRepositories(config.Repositories))
+	})
+	cmd.SetArgs([]string{"--offline", "--repositories", repositories, "--runtime", "test", "--template", "customProvider/tpla", "myfunc"})
+	err = cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "not embedded") {
+		t.Fatalf("expected an error for the template which is not embedded, got %v", err)
+	}
+	if provided != "" {
+		t.Fatalf("expected no repositories to be provided offline, got '%v'", provided)
+	}
+}
+
 // Helpers ----
 
 // change directory into a new temp directory.
@@ -69,3 +604,382 @@ func cd(t *testing.T, dir string) {
 		t.Fatal(err)
 	}
 }
+
+// TestCreateOnConflict ensures that existing files of the same name as those
+// of the template are kept or overwritten according to --on-conflict, and
+// that by default they are an error.
+func TestCreateOnConflict(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		kept bool
+		err  error
+	}{
+		{"default", nil, true, fn.ErrUnrelatedFiles},
+		{"skip", []string{"--on-conflict", "skip"}, true, nil},
+		{"overwrite", []string{"--on-conflict", "overwrite"}, false, nil},
+		{"force", []string{"--force"}, false, nil},
+		{"force skip", []string{"--force", "--on-conflict", "skip"}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer fromTempDir(t)()
+
+			if err := os.MkdirAll("myfunc", 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(filepath.Join("myfunc", "handle.go"), []byte("existing"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := NewCreateCmd(newCreateClient)
+			cmd.SetArgs(append(tt.args, "--runtime", "go", "myfunc"))
+			err := cmd.Execute()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected error '%v', got '%v'", tt.err, err)
+			}
+
+			bb, err := ioutil.ReadFile(filepath.Join("myfunc", "handle.go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kept := string(bb) == "existing"; kept != tt.kept {
+				t.Fatalf("expected handle.go to be kept: %v, got %q", tt.kept, bb)
+			}
+		})
+	}
+}
+
+// TestCreateOnConflictInvalid ensures an unknown --on-conflict policy is an
+// error.
+func TestCreateOnConflictInvalid(t *testing.T) {
+	defer fromTempDir(t)()
+
+	cmd := NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"--on-conflict", "merge", "myfunc"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--on-conflict") {
+		t.Fatalf("expected an error for the invalid policy, got '%v'", err)
+	}
+}
+
+// TestCreateWithCI ensures the CI system given with --with-ci is written and
+// stored in func.yaml, that none writes none, and that others are invalid.
+func TestCreateWithCI(t *testing.T) {
+	defer fromTempDir(t)()
+
+	cmd := NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"--with-ci", "tekton", "myfunc"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction("myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.CI != "tekton" {
+		t.Fatalf("expected the CI 'tekton', got '%v'", f.CI)
+	}
+	if _, err = os.Stat(filepath.Join("myfunc", ".tekton", "pipeline.yaml")); err != nil {
+		t.Fatalf("expected the Tekton Pipeline to be written: %v", err)
+	}
+
+	cmd = NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"--with-ci", "none", "other"})
+	if err = cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = fn.NewFunction("other"); err != nil || f.CI != "" {
+		t.Fatalf("expected no CI, got '%v' (%v)", f.CI, err)
+	}
+
+	cmd = NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"--with-ci", "jenkins", "third"})
+	if err = cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--with-ci") {
+		t.Fatalf("expected an error for the invalid CI system, got '%v'", err)
+	}
+}
+
+// TestCreateWithStyle ensures --with-style writes the .editorconfig and the
+// formatter config of the runtime.
+func TestCreateWithStyle(t *testing.T) {
+	defer fromTempDir(t)()
+
+	cmd := NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"--runtime", "node", "--with-style", "myfunc"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{".editorconfig", ".prettierrc"} {
+		if _, err := os.Stat(filepath.Join("myfunc", file)); err != nil {
+			t.Fatalf("expected %v to be written: %v", file, err)
+		}
+	}
+}
+
+// TestCreateWithDevcontainer ensures --with-devcontainer writes the dev
+// container config of the runtime, and records it in func.yaml.
+func TestCreateWithDevcontainer(t *testing.T) {
+	defer fromTempDir(t)()
+
+	cmd := NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"--runtime", "python", "--with-devcontainer", "myfunc"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join("myfunc", fn.DevcontainerFile)); err != nil {
+		t.Fatalf("expected %v to be written: %v", fn.DevcontainerFile, err)
+	}
+	f, err := fn.NewFunction("myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Devcontainer {
+		t.Fatal("expected the dev container to be recorded in func.yaml")
+	}
+}
+
+// TestCreateWithLicense ensures --license writes the LICENSE of the copyright
+// of --author, and that a license which is not bundled is invalid.
+func TestCreateWithLicense(t *testing.T) {
+	defer fromTempDir(t)()
+
+	cmd := NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"--license", "ISC", "--author", "Alice", "myfunc"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	license, err := ioutil.ReadFile(filepath.Join("myfunc", "LICENSE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(license), "Alice") {
+		t.Fatalf("expected the LICENSE of the copyright of Alice, got:\n%s", license)
+	}
+
+	cmd = NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"--license", "WTFPL", "other"})
+	if err = cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--license") {
+		t.Fatalf("expected an error for the invalid license, got '%v'", err)
+	}
+}
+
+// TestCreateRuntimeVersionFile ensures the version manager file of the version
+// of the runtime given with --runtime is written by default, and not with
+// --runtime-version-file=false.
+func TestCreateRuntimeVersionFile(t *testing.T) {
+	defer fromTempDir(t)()
+
+	cmd := NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"--runtime", "node@18", "myfunc"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	nvmrc, err := ioutil.ReadFile(filepath.Join("myfunc", ".nvmrc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(nvmrc) != "18\n" {
+		t.Fatalf("expected the .nvmrc of node 18, got %q", nvmrc)
+	}
+
+	cmd = NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"--runtime", "node@18", "--runtime-version-file=false", "other"})
+	if err = cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join("other", ".nvmrc")); !os.IsNotExist(err) {
+		t.Fatal("expected no .nvmrc to be written with --runtime-version-file=false")
+	}
+}
+
+// TestCreatePackageManager ensures the package manager given is recorded, and
+// that it may only be given for a Node runtime.
+func TestCreatePackageManager(t *testing.T) {
+	defer fromTempDir(t)()
+
+	cmd := NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"--runtime", "node", "--package-manager", "yarn", "myfunc"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction("myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.PackageManager != "yarn" {
+		t.Fatalf("expected the package manager yarn, got '%v'", f.PackageManager)
+	}
+
+	cmd = NewCreateCmd(newCreateClient)
+	cmd.SetArgs([]string{"--runtime", "go", "--package-manager", "yarn", "other"})
+	if err = cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--package-manager") {
+		t.Fatalf("expected an error for the package manager of the go runtime, got '%v'", err)
+	}
+}
+
+// TestCreateEntrypoint ensures the handler of a template exposing several
+// given with --entrypoint is written as that of the function and recorded,
+// and that one the template does not declare fails before anything is
+// scaffolded.
+func TestCreateEntrypoint(t *testing.T) {
+	repositories, err := ioutil.TempDir("", "func-entrypoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repositories)
+	template := filepath.Join(repositories, "mine", "go", "multi")
+	if err = os.MkdirAll(template, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		fn.ManifestFile: "entrypoint: handle.go\nhandlers:\n- name: echo\n  file: echo.go\n- name: json\n  file: json.go\n",
+		"echo.go":       "package echo",
+		"json.go":       "package json",
+	} {
+		if err = ioutil.WriteFile(filepath.Join(template, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer fromTempDir(t)()
+
+	create := func(args ...string) error {
+		cmd := NewCreateCmd(func(config createConfig) *fn.Client {
+			return fn.New(fn.WithRepositories(config.Repositories))
+		})
+		cmd.SetArgs(append([]string{"--repositories", repositories, "--repositories-ttl", "0", "--runtime", "go", "--template", "mine/multi"}, args...))
+		return cmd.Execute()
+	}
+	if err = create("--entrypoint", "json", "myfunc"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction("myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Entrypoint != "json" {
+		t.Fatalf("expected the entrypoint json, got '%v'", f.Entrypoint)
+	}
+	if bb, err := ioutil.ReadFile(filepath.Join("myfunc", "handle.go")); err != nil || string(bb) != "package json" {
+		t.Fatalf("expected the json handler to be written as the function's, got %q (%v)", bb, err)
+	}
+
+	err = create("--entrypoint", "xml", "other")
+	if err == nil || !strings.Contains(err.Error(), "--entrypoint") || !strings.Contains(err.Error(), "echo, json") {
+		t.Fatalf("expected an error listing the handlers of the template, got '%v'", err)
+	}
+	if _, err = os.Stat("other"); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be scaffolded, got %v", err)
+	}
+}
+
+// TestCreateImageSuffix ensures the image suffix given with --image-suffix is
+// recorded, such that the image name derived from the registry has it, and
+// that an invalid suffix fails before anything is scaffolded.
+func TestCreateImageSuffix(t *testing.T) {
+	defer fromTempDir(t)()
+
+	create := func(args ...string) error {
+		cmd := NewCreateCmd(newCreateClient)
+		cmd.SetArgs(append([]string{"--repositories", "", "--runtime", "go", "--registry", "quay.io/alice"}, args...))
+		return cmd.Execute()
+	}
+	if err := create("--image-suffix", "-fn", "myfunc"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction("myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image, err := f.ImageName(); err != nil || image != "quay.io/alice/myfunc-fn:latest" {
+		t.Fatalf("expected the image name to have the suffix, got '%v' (%v)", image, err)
+	}
+
+	err = create("--image-suffix", "fn", "other")
+	if err == nil || !strings.Contains(err.Error(), "invalid value 'fn' for --image-suffix") {
+		t.Fatalf("expected an error for the suffix without a separator, got '%v'", err)
+	}
+	if _, err = os.Stat("other"); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be scaffolded, got %v", err)
+	}
+}
+
+// TestCreatePinBuilder ensures that the builder image of the function is
+// pinned to its digest, resolved from a fake registry, with --pin-builder,
+// and that it is skipped, and warned of, offline or when it can not be
+// resolved, the function being created regardless.
+func TestCreatePinBuilder(t *testing.T) {
+	defer fromTempDir(t)()
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	image := strings.TrimPrefix(server.URL, "http://") + "/boson/faas-go-builder:tip"
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	create := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := NewCreateCmd(newCreateClient)
+		cmd.SetOut(&out)
+		cmd.SetErr(&out)
+		cmd.SetArgs(append([]string{"--repositories", "", "--runtime", "go", "--pin-builder"}, args...))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+	out, err := create("--builder", image, "myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := fn.NewFunction("myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.BuilderDigest != digest.String() || !strings.Contains(out, digest.String()) {
+		t.Fatalf("expected the builder to be pinned to %v, got '%v':\n%v", digest, f.BuilderDigest, out)
+	}
+
+	for _, args := range [][]string{
+		{"--builder", image, "--offline", "offline"},
+		{"--builder", strings.TrimPrefix(server.URL, "http://") + "/boson/missing:tip", "missing"},
+	} {
+		out, err = create(args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f, err = fn.NewFunction(args[len(args)-1]); err != nil {
+			t.Fatal(err)
+		}
+		if f.BuilderDigest != "" || !strings.Contains(out, "Warning:") {
+			t.Fatalf("expected the builder not to be pinned, and warned of, got '%v':\n%v", f.BuilderDigest, out)
+		}
+	}
+}
+
+// TestCreateConfigWithAnswers ensures the answers to the create prompts update
+// only the members asked, such that those of the template repositories and
+// verbosity are those given when completing an incomplete creation.
+func TestCreateConfigWithAnswers(t *testing.T) {
+	defer fromTempDir(t)()
+	root := pwd(t)
+
+	c := createConfig{Path: root, Repositories: "/tmp/repositories", RepositoriesTTL: time.Hour, Verbose: true, Confirm: true}
+	answered := c.withAnswers(createAnswers{Path: filepath.Join(root, "orders"), Runtime: "go", Template: "http", Registry: "quay.io/alice"})
+	if answered.Name != "orders" || answered.Runtime != "go" || answered.Template != "http" || answered.Registry != "quay.io/alice" {
+		t.Fatalf("expected the answers to be used, got %+v", answered)
+	}
+	if answered.Repositories != c.Repositories || answered.RepositoriesTTL != c.RepositoriesTTL || !answered.Verbose || !answered.Confirm {
+		t.Fatalf("expected the members not asked to be carried through, got %+v", answered)
+	}
+}
@@ -1,22 +1,68 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
-	"github.com/AlecAivazis/survey/v2/terminal"
 	"github.com/ory/viper"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
 
 	fn "github.com/boson-project/func"
 	"github.com/boson-project/func/knative"
 )
 
 func init() {
-	root.AddCommand(deleteCmd)
+	root.AddCommand(NewDeleteCmd(newDeleteRemover, newDeleteLister))
 }
 
-func NewDeleteCmd(newRemover func(ns string, verbose bool) (fn.Remover, error)) *cobra.Command {
+// newDeleteRemover returns the Knative remover used by the "Delete" command
+// during normal execution (see tests for alternative remover factories which
+// return mocks).
+func newDeleteRemover(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
+	r, err := knative.NewRemover(ns)
+	if err != nil {
+		return nil, err
+	}
+	r.Verbose = config.Verbose
+	r.KeepTriggers = config.KeepTriggers
+	r.Wait = config.Wait
+	r.WaitTimeout = config.Timeout
+	r.Listener = listener
+	return r, nil
+}
+
+// deleteRemoverFn is a factory function which returns a Remover of the
+// Functions deployed in the given namespace, configured by the config of the
+// Delete command, reporting its progress to the listener, if any.
+type deleteRemoverFn func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error)
+
+// newDeleteLister returns the Knative lister of the Functions deleted with
+// --all or --selector during normal execution.
+func newDeleteLister(ns, selector string) (fn.Lister, error) {
+	l, err := knative.NewLister(ns)
+	if err != nil {
+		return nil, err
+	}
+	if selector != "" {
+		if l.Selector, err = labels.Parse(selector); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// deleteListerFn is a factory function which returns a Lister of the
+// Functions deployed in the given namespace of which the labels match the
+// selector, if any, which are deleted with --all or --selector.
+type deleteListerFn func(ns, selector string) (fn.Lister, error)
+
+// NewDeleteCmd creates a delete command using the given remover and lister
+// creators.
+func NewDeleteCmd(newRemover deleteRemoverFn, newLister deleteListerFn) *cobra.Command {
 	delCmd := &cobra.Command{
 		Use:   "delete [NAME]",
 		Short: "Undeploy a function",
@@ -26,6 +72,23 @@ This command undeploys a function from the cluster. By default the function from
 the project in the current directory is undeployed. Alternatively either the name 
 of the function can be given as argument or the project path provided with --path.
 
+Triggers which send events to the function are removed as well, unless
+--keep-triggers is provided.
+
+With --wait, the command returns only once the Knative Service and its
+revisions no longer exist, such as before recreating the function, failing
+after --timeout with those still present.
+
+With --all, every function deployed in the namespace is undeployed, after
+confirming interactively unless --confirm is provided, which is required
+without an interactive terminal, such as in CI.  Up to --parallelism
+functions are undeployed at a time.  Each is reported in order of name as it
+is undeployed, and failures do not prevent the others being undeployed.
+
+With --selector, the same is done of only the functions of which the labels
+match the label selector, such as 'team=payments' or 'tier in (web,api)', and
+likewise --confirm is required without an interactive terminal.
+
 No local files are deleted.
 `,
 		Example: `
@@ -34,18 +97,52 @@ kn func delete
 
 # Undeploy the function 'myfunc' in namespace 'apps'
 kn func delete -n apps myfunc
+
+# Undeploy all functions in namespace 'test' without prompting
+kn func delete --all --confirm -n test
+
+# Undeploy the functions of the payments team, after confirming
+kn func delete --selector team=payments
+
+# Undeploy the function 'myfunc', waiting up to a minute until it is gone
+kn func delete --wait --timeout 1m myfunc
 `,
 		SuggestFor:        []string{"remove", "rm", "del"},
+		Annotations:       map[string]string{dryRunAnnotation: dryRunPlan},
 		ValidArgsFunction: CompleteFunctionList,
-		PreRunE:           bindEnv("path", "confirm", "namespace"),
+		PreRunE:           bindEnv("path", "confirm", "namespace", "keep-triggers", "parallelism", "wait", "timeout"),
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
-			config, err := newDeleteConfig(args).Prompt()
+			all, err := cmd.Flags().GetBool("all")
 			if err != nil {
-				if err == terminal.InterruptErr {
-					return nil
+				return
+			}
+			selector, err := cmd.Flags().GetString("selector")
+			if err != nil {
+				return
+			}
+			if selector != "" {
+				if all || len(args) > 0 || cmd.Flags().Changed("path") {
+					return fmt.Errorf("Only one of --all, --selector, --path and [NAME] should be provided")
+				}
+				if _, err = labels.Parse(selector); err != nil {
+					return fmt.Errorf("invalid value '%v' for --selector: %v", selector, err)
 				}
+				return runDeleteAll(cmd, newDeleteConfig(args), selector, newRemover, newLister)
+			}
+			if all {
+				if len(args) > 0 || cmd.Flags().Changed("path") {
+					return fmt.Errorf("Only one of --all, --path and [NAME] should be provided")
+				}
+				return runDeleteAll(cmd, newDeleteConfig(args), "", newRemover, newLister)
+			}
+
+			config, err := newDeleteConfig(args).Prompt()
+			if err != nil {
 				return
 			}
+			if config.Timeout <= 0 {
+				return fmt.Errorf("invalid value '%v' for --timeout: must be positive", config.Timeout)
+			}
 
 			var function fn.Function
 
@@ -59,56 +156,181 @@ kn func delete -n apps myfunc
 					Name: args[0],
 				}
 			} else {
-				function, err = fn.NewFunction(config.Path)
-				if err != nil {
+				if function, err = fn.Load(config.Path, configFile()); err != nil {
 					return
 				}
+			}
 
-				// Check if the Function has been initialized
-				if !function.Initialized() {
-					return fmt.Errorf("the given path '%v' does not contain an initialized function", config.Path)
-				}
+			if err = configureClusterAccess(); err != nil {
+				return
 			}
 
 			ns := config.Namespace
 			if ns == "" {
 				ns = function.Namespace
 			}
+			function.Namespace = ns
 
-			remover, err := newRemover(ns, config.Verbose)
+			plan := newPlan(dryRun())
+			listener := newProgressListener(progressOut(plan), config.Verbose)
+			defer listener.Done()
+
+			remover, err := newRemover(ns, config, listener)
 			if err != nil {
 				return
 			}
 
 			client := fn.New(
 				fn.WithVerbose(config.Verbose),
-				fn.WithRemover(remover))
+				fn.WithRemover(remover),
+				fn.WithPlan(plan))
 
-			return client.Remove(cmd.Context(), function)
+			if err = client.Remove(cmd.Context(), function); err != nil || plan == nil {
+				return
+			}
+			return plan.Print(cmd.OutOrStdout())
 		},
 	}
 
 	delCmd.Flags().BoolP("confirm", "c", false, "Prompt to confirm all configuration options (Env: $FUNC_CONFIRM)")
 	delCmd.Flags().StringP("path", "p", cwd(), "Path to the function project that should be undeployed (Env: $FUNC_PATH)")
 	delCmd.Flags().StringP("namespace", "n", "", "Namespace of the function to undeploy. By default, the namespace in func.yaml is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
+	delCmd.Flags().Bool("keep-triggers", false, "Do not remove the Triggers which target the function (Env: $FUNC_KEEP_TRIGGERS)")
+	delCmd.Flags().Bool("all", false, "Undeploy all functions in the namespace. With --confirm, they are undeployed without prompting, as is required without an interactive terminal")
+	delCmd.Flags().String("selector", "", "Undeploy all functions in the namespace of which the labels match the label selector, such as 'team=payments'. With --confirm, they are undeployed without prompting, as is required without an interactive terminal")
+	delCmd.Flags().Int("parallelism", 4, "Number of functions undeployed at a time with --all or --selector (Env: $FUNC_PARALLELISM)")
+	delCmd.Flags().Bool("wait", false, "Wait until the Knative Service and its revisions are gone, rather than only until it is deleted (Env: $FUNC_WAIT)")
+	delCmd.Flags().Duration("timeout", knative.RemoveTimeout, "Time to wait for the function to be gone with --wait, after which those of its objects still present are reported (Env: $FUNC_TIMEOUT)")
 
 	return delCmd
 }
 
-var deleteCmd = NewDeleteCmd(func(ns string, verbose bool) (fn.Remover, error) {
-	r, err := knative.NewRemover(ns)
+// runDeleteAll removes each of the Functions deployed in the namespace of
+// which the labels match the selector, if any, config.Parallelism at a time,
+// reporting each in the order listed as it is removed.  Failures are reported
+// and the remaining Functions removed, the combined error being returned.
+func runDeleteAll(cmd *cobra.Command, config deleteConfig, selector string, newRemover deleteRemoverFn, newLister deleteListerFn) (err error) {
+	if err = configureClusterAccess(); err != nil {
+		return
+	}
+
+	lister, err := newLister(config.Namespace, selector)
 	if err != nil {
-		return nil, err
+		return
+	}
+	items, err := lister.List(cmd.Context())
+	if err != nil {
+		return
+	}
+	out := infoOut(cmd.OutOrStdout())
+	if len(items) == 0 {
+		if selector != "" {
+			fmt.Fprintf(out, "No functions matching '%v' found to delete\n", selector)
+			return
+		}
+		fmt.Fprintln(out, "No functions found to delete")
+		return
+	}
+
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.Name
+	}
+	// Deleting several functions is confirmed interactively, or otherwise
+	// requires --confirm, such that it is never done silently, as in CI.
+	plan := newPlan(dryRun())
+	if !viper.GetBool("confirm") && plan == nil {
+		message := fmt.Sprintf("Delete all %v functions (%v)?", len(names), strings.Join(names, ", "))
+		if selector != "" {
+			message = fmt.Sprintf("Delete the %v functions matching '%v' (%v)?", len(names), selector, strings.Join(names, ", "))
+		}
+		if !interactiveTerminal() {
+			which := fmt.Sprintf("the %v functions", len(names))
+			if selector != "" {
+				which = fmt.Sprintf("the %v functions matching '%v'", len(names), selector)
+			}
+			return fmt.Errorf("deleting %v (%v) requires confirmation. Provide --confirm to delete them without an interactive terminal", which, strings.Join(names, ", "))
+		}
+		confirmed := false
+		if err = survey.AskOne(&survey.Confirm{Message: message}, &confirmed); err != nil || !confirmed {
+			return
+		}
 	}
-	r.Verbose = verbose
-	return r, nil
-})
+
+	if config.Parallelism < 1 {
+		return fmt.Errorf("--parallelism must be at least 1, got %v", config.Parallelism)
+	}
+	if config.Timeout <= 0 {
+		return fmt.Errorf("invalid value '%v' for --timeout: must be positive", config.Timeout)
+	}
+	// Functions removed at once are reported as each is removed, rather than
+	// by the progress of their removals.
+	remover, err := newRemover(config.Namespace, config, nil)
+	if err != nil {
+		return
+	}
+	client := fn.New(
+		fn.WithVerbose(config.Verbose),
+		fn.WithRemover(remover),
+		fn.WithPlan(plan))
+
+	// The removals are planned in order of name, whatever the parallelism.
+	if plan != nil {
+		for _, name := range names {
+			if err = client.Remove(cmd.Context(), fn.Function{Name: name}); err != nil {
+				return
+			}
+		}
+		return plan.Print(cmd.OutOrStdout())
+	}
+
+	var failures []string
+	for i, result := range removeAll(cmd.Context(), client, names, config.Parallelism) {
+		name := names[i]
+		if err = <-result; err != nil {
+			fmt.Fprintf(out, "Failed to delete function '%v': %v\n", name, err)
+			failures = append(failures, fmt.Sprintf("%v: %v", name, err))
+			continue
+		}
+		fmt.Fprintf(out, "Deleted function '%v'\n", name)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to delete %v of %v functions:\n  %v", len(failures), len(names), strings.Join(failures, "\n  "))
+	}
+	return nil
+}
+
+// removeAll removes the Functions of the given names, at most parallelism at
+// a time, returning a channel per name on which the result of its removal is
+// sent.  Results are thus received in the order of the names, regardless of
+// the order in which the removals complete.
+func removeAll(ctx context.Context, client *fn.Client, names []string, parallelism int) []chan error {
+	results := make([]chan error, len(names))
+	for i := range results {
+		results[i] = make(chan error, 1)
+	}
+	sem := make(chan struct{}, parallelism)
+	for i, name := range names {
+		go func(result chan error, name string) {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			result <- client.Remove(ctx, fn.Function{Name: name})
+		}(results[i], name)
+	}
+	return results
+}
 
 type deleteConfig struct {
-	Name      string
-	Namespace string
-	Path      string
-	Verbose   bool
+	Name         string
+	Namespace    string
+	Path         string
+	KeepTriggers bool
+	Parallelism  int
+	Verbose      bool
+
+	// Wait until the Function's objects are gone, up to Timeout.
+	Wait    bool
+	Timeout time.Duration
 }
 
 // newDeleteConfig returns a config populated from the current execution context
@@ -119,10 +341,14 @@ func newDeleteConfig(args []string) deleteConfig {
 		name = args[0]
 	}
 	return deleteConfig{
-		Path:      viper.GetString("path"),
-		Namespace: viper.GetString("namespace"),
-		Name:      deriveName(name, viper.GetString("path")), // args[0] or derived
-		Verbose:   viper.GetBool("verbose"),                  // defined on root
+		Path:         viper.GetString("path"),
+		Namespace:    viper.GetString("namespace"),
+		Name:         deriveName(name, viper.GetString("path")), // args[0] or derived
+		KeepTriggers: viper.GetBool("keep-triggers"),
+		Parallelism:  viper.GetInt("parallelism"),
+		Verbose:      viper.GetBool("verbose"), // defined on root
+		Wait:         viper.GetBool("wait"),
+		Timeout:      viper.GetDuration("timeout"),
 	}
 }
 
@@ -134,7 +360,7 @@ func (c deleteConfig) Prompt() (deleteConfig, error) {
 		return c, nil
 	}
 
-	dc := deleteConfig{}
+	dc := c
 
 	return dc, survey.AskOne(
 		&survey.Input{
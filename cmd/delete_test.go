@@ -1,20 +1,29 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/mock"
 )
 
 type testRemover struct {
 	invokedWith *string
 }
 
-func (t *testRemover) Remove(ctx context.Context, name string) error {
+func (t *testRemover) Remove(ctx context.Context, name, namespace string) error {
 	t.invokedWith = &name
 	return nil
 }
@@ -22,9 +31,9 @@ func (t *testRemover) Remove(ctx context.Context, name string) error {
 // test delete outside project just using function name
 func TestDeleteCmdWithoutProject(t *testing.T) {
 	tr := &testRemover{}
-	cmd := NewDeleteCmd(func(ns string, verbose bool) (fn.Remover, error) {
+	cmd := NewDeleteCmd(func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
 		return tr, nil
-	})
+	}, newDeleteLister)
 
 	cmd.SetArgs([]string{"foo"})
 	err := cmd.Execute()
@@ -88,9 +97,9 @@ annotations: {}
 	}
 
 	tr := &testRemover{}
-	cmd := NewDeleteCmd(func(ns string, verbose bool) (fn.Remover, error) {
+	cmd := NewDeleteCmd(func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
 		return tr, nil
-	})
+	}, newDeleteLister)
 
 	cmd.SetArgs([]string{"-p", "."})
 	err = cmd.Execute()
@@ -110,9 +119,9 @@ annotations: {}
 // test where both name and path are provided
 func TestDeleteCmdWithBothPathAndName(t *testing.T) {
 	tr := &testRemover{}
-	cmd := NewDeleteCmd(func(ns string, verbose bool) (fn.Remover, error) {
+	cmd := NewDeleteCmd(func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
 		return tr, nil
-	})
+	}, newDeleteLister)
 
 	cmd.SetArgs([]string{"foo", "-p", "/adir/"})
 	err := cmd.Execute()
@@ -124,3 +133,312 @@ func TestDeleteCmdWithBothPathAndName(t *testing.T) {
 		t.Fatal("fn.Remove was call when it shouldn't have been")
 	}
 }
+
+// test that the remover is created for, and the function removed from, the
+// namespace of the function in func.yaml, unless overridden with --namespace
+func TestDeleteCmdNamespace(t *testing.T) {
+	defer fromTempDir(t)()
+	root := pwd(t)
+	if err := ioutil.WriteFile(filepath.Join(root, "func.yaml"), []byte("name: bar\nnamespace: apps\nruntime: go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args      []string
+		namespace string
+	}{
+		{[]string{"-p", root}, "apps"},
+		{[]string{"-p", root, "-n", "other"}, "other"},
+	}
+	for _, tt := range tests {
+		remover := mock.NewRemover()
+		remover.RemoveFn = func(name string) error {
+			if name != "bar" {
+				t.Fatalf("expected 'bar' to be removed, got '%v'", name)
+			}
+			return nil
+		}
+		var namespace string
+		cmd := NewDeleteCmd(func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
+			namespace = ns
+			return remover, nil
+		}, newDeleteLister)
+
+		cmd.SetArgs(tt.args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		if !remover.RemoveInvoked {
+			t.Fatal("fn.Remover has not been invoked")
+		}
+		if namespace != tt.namespace {
+			t.Fatalf("expected the remover of namespace '%v', got '%v'", tt.namespace, namespace)
+		}
+		if remover.Namespace != tt.namespace {
+			t.Fatalf("expected the function to be removed from '%v', got '%v'", tt.namespace, remover.Namespace)
+		}
+	}
+}
+
+// test that all functions of the namespace are removed with --all, those
+// failing to be removed being reported without preventing the others
+func TestDeleteCmdAll(t *testing.T) {
+	lister := mock.NewLister()
+	lister.ListFn = func() ([]fn.ListItem, error) {
+		return []fn.ListItem{{Name: "a"}, {Name: "b"}, {Name: "c"}}, nil
+	}
+	var (
+		mu      sync.Mutex
+		removed []string
+	)
+	remover := mock.NewRemover()
+	remover.RemoveFn = func(name string) error {
+		if name == "b" {
+			return errors.New("forbidden")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		removed = append(removed, name)
+		return nil
+	}
+	cmd := NewDeleteCmd(func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
+		if ns != "test" {
+			t.Fatalf("expected the remover of namespace 'test', got '%v'", ns)
+		}
+		return remover, nil
+	}, func(ns, selector string) (fn.Lister, error) {
+		if ns != "test" {
+			t.Fatalf("expected the lister of namespace 'test', got '%v'", ns)
+		}
+		return lister, nil
+	})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--all", "--confirm", "-n", "test"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "b: forbidden") {
+		t.Fatalf("expected an error naming the function which failed, got '%v'", err)
+	}
+	sort.Strings(removed)
+	if !reflect.DeepEqual(removed, []string{"a", "c"}) {
+		t.Fatalf("expected a and c to be removed, got %v", removed)
+	}
+	if !strings.Contains(out.String(), "Deleted function 'c'") {
+		t.Fatalf("expected each deletion to be reported, got %q", out.String())
+	}
+}
+
+// test that with --all or --selector nothing is removed without --confirm when
+// not in an interactive terminal, where it can not be confirmed
+func TestDeleteCmdRequiresConfirm(t *testing.T) {
+	// stdin of a pipe, as in CI, rather than a terminal
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	for _, args := range [][]string{
+		{"--all", "-n", "test"},
+		{"--selector", "team=payments", "-n", "test"},
+	} {
+		lister := mock.NewLister()
+		lister.ListFn = func() ([]fn.ListItem, error) {
+			return []fn.ListItem{{Name: "a"}, {Name: "b"}}, nil
+		}
+		remover := mock.NewRemover()
+		cmd := NewDeleteCmd(func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
+			return remover, nil
+		}, func(ns, selector string) (fn.Lister, error) {
+			return lister, nil
+		})
+
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--confirm") {
+			t.Fatalf("expected --confirm to be required with %v, got '%v'", args, err)
+		}
+		if remover.RemoveInvoked {
+			t.Fatalf("expected nothing to be removed without confirmation with %v", args)
+		}
+	}
+}
+
+// test that with --all no more than --parallelism functions are removed at a
+// time, and that they are reported in the order listed regardless
+func TestDeleteCmdAllParallelism(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e"}
+	lister := mock.NewLister()
+	lister.ListFn = func() (items []fn.ListItem, err error) {
+		for _, name := range names {
+			items = append(items, fn.ListItem{Name: name})
+		}
+		return
+	}
+	var (
+		mu                  sync.Mutex
+		inFlight, maxFlight int
+	)
+	remover := mock.NewRemover()
+	remover.RemoveFn = func(name string) error {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxFlight {
+			maxFlight = inFlight
+		}
+		mu.Unlock()
+		// Those listed first complete last
+		time.Sleep(time.Duration(len(names)-strings.Index("abcde", name)) * 5 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return nil
+	}
+	cmd := NewDeleteCmd(func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
+		return remover, nil
+	}, func(ns, selector string) (fn.Lister, error) {
+		return lister, nil
+	})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--all", "--confirm", "-n", "test", "--parallelism", "2"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if maxFlight < 1 || maxFlight > 2 {
+		t.Fatalf("expected at most 2 removals at a time, got %v", maxFlight)
+	}
+	expected := ""
+	for _, name := range names {
+		expected += fmt.Sprintf("Deleted function '%v'\n", name)
+	}
+	if out.String() != expected {
+		t.Fatalf("expected deletions reported in order, got %q", out.String())
+	}
+}
+
+// test where both --all and a name are provided
+func TestDeleteCmdAllWithName(t *testing.T) {
+	remover := mock.NewRemover()
+	cmd := NewDeleteCmd(func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
+		return remover, nil
+	}, func(ns, selector string) (fn.Lister, error) {
+		return mock.NewLister(), nil
+	})
+
+	cmd.SetArgs([]string{"--all", "foo"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("error was expected as both --all and a name cannot be used together")
+	}
+	if remover.RemoveInvoked {
+		t.Fatal("fn.Remove was call when it shouldn't have been")
+	}
+}
+
+// TestDeleteCmdWait ensures the remover is created to wait until the function
+// is gone for the given timeout with --wait, and that a timeout which is not
+// positive is rejected.
+func TestDeleteCmdWait(t *testing.T) {
+	var created deleteConfig
+	cmd := NewDeleteCmd(func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
+		created = config
+		return &testRemover{}, nil
+	}, newDeleteLister)
+	cmd.SetArgs([]string{"myfunc", "--wait", "--timeout", "30s"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !created.Wait || created.Timeout != 30*time.Second {
+		t.Fatalf("expected the remover to wait up to 30s, got wait %v for %v", created.Wait, created.Timeout)
+	}
+
+	cmd = NewDeleteCmd(func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
+		t.Fatal("expected an invalid timeout to fail before removing")
+		return nil, nil
+	}, newDeleteLister)
+	cmd.SetArgs([]string{"myfunc", "--wait", "--timeout", "0s"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--timeout") {
+		t.Fatalf("expected an error for the invalid timeout, got %v", err)
+	}
+}
+
+// test that with --selector the functions of which the labels match are
+// listed and removed, and that an invalid selector is rejected before either
+// is created
+func TestDeleteCmdSelector(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		removed []string
+	)
+	remover := mock.NewRemover()
+	remover.RemoveFn = func(name string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		removed = append(removed, name)
+		return nil
+	}
+	lister := mock.NewLister()
+	lister.ListFn = func() ([]fn.ListItem, error) {
+		return []fn.ListItem{{Name: "a"}, {Name: "c"}}, nil
+	}
+	var listed string
+	cmd := NewDeleteCmd(func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
+		return remover, nil
+	}, func(ns, selector string) (fn.Lister, error) {
+		listed = selector
+		return lister, nil
+	})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--selector", "team=payments", "--confirm", "-n", "test"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if listed != "team=payments" {
+		t.Fatalf("expected the functions matching 'team=payments' to be listed, got '%v'", listed)
+	}
+	sort.Strings(removed)
+	if !reflect.DeepEqual(removed, []string{"a", "c"}) {
+		t.Fatalf("expected a and c to be removed, got %v", removed)
+	}
+	if out.String() != "Deleted function 'a'\nDeleted function 'c'\n" {
+		t.Fatalf("expected each deletion to be reported, got %q", out.String())
+	}
+
+	listed, removed = "", nil
+	cmd.SetArgs([]string{"--selector", "team in (payments", "--confirm", "-n", "test"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "invalid value 'team in (payments' for --selector") {
+		t.Fatalf("expected the invalid selector to be rejected, got '%v'", err)
+	}
+	if listed != "" || len(removed) > 0 {
+		t.Fatal("expected nothing to be listed or removed with an invalid selector")
+	}
+}
+
+// test that --selector cannot be used with --all or a name
+func TestDeleteCmdSelectorExclusive(t *testing.T) {
+	for _, args := range [][]string{
+		{"--selector", "team=payments", "--all"},
+		{"--selector", "team=payments", "foo"},
+	} {
+		remover := mock.NewRemover()
+		cmd := NewDeleteCmd(func(ns string, config deleteConfig, listener fn.ProgressListener) (fn.Remover, error) {
+			return remover, nil
+		}, func(ns, selector string) (fn.Lister, error) {
+			return mock.NewLister(), nil
+		})
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--selector") {
+			t.Fatalf("expected %v to be rejected, got '%v'", args, err)
+		}
+		if remover.RemoveInvoked {
+			t.Fatalf("expected nothing to be removed with %v", args)
+		}
+	}
+}
@@ -3,40 +3,134 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
-	"github.com/AlecAivazis/survey/v2/terminal"
-	"github.com/containers/image/v5/pkg/docker/config"
-	containersTypes "github.com/containers/image/v5/types"
 	"github.com/ory/viper"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"knative.dev/client/pkg/util"
 
 	fn "github.com/boson-project/func"
 	"github.com/boson-project/func/buildpacks"
 	"github.com/boson-project/func/docker"
 	"github.com/boson-project/func/knative"
-	"github.com/boson-project/func/progress"
+	"github.com/boson-project/func/tekton"
 )
 
 func init() {
-	root.AddCommand(deployCmd)
-	deployCmd.Flags().BoolP("confirm", "c", false, "Prompt to confirm all configuration options (Env: $FUNC_CONFIRM)")
-	deployCmd.Flags().StringArrayP("env", "e", []string{}, "Environment variable to set in the form NAME=VALUE. "+
-		"You may provide this flag multiple times for setting multiple environment variables. "+
-		"To unset, specify the environment variable name followed by a \"-\" (e.g., NAME-).")
-	deployCmd.Flags().StringP("image", "i", "", "Full image name in the form [registry]/[namespace]/[name]:[tag] (optional). This option takes precedence over --registry (Env: $FUNC_IMAGE")
-	deployCmd.Flags().StringP("namespace", "n", "", "Namespace of the function to undeploy. By default, the namespace in func.yaml is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
-	deployCmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
-	deployCmd.Flags().StringP("registry", "r", "", "Registry + namespace part of the image to build, ex 'quay.io/myuser'.  The full image name is automatically determined based on the local directory name. If not provided the registry will be taken from func.yaml (Env: $FUNC_REGISTRY)")
-	deployCmd.Flags().BoolP("build", "b", true, "Build the image before deploying (Env: $FUNC_BUILD)")
+	root.AddCommand(NewDeployCmd(newDeployClient))
 }
 
-var deployCmd = &cobra.Command{
-	Use:   "deploy",
-	Short: "Deploy a function",
-	Long: `Deploy a function
+const (
+	// defaultReadinessCheckTimeout is the time within which the path of
+	// --readiness-check must respond successfully by default.
+	defaultReadinessCheckTimeout = time.Minute
+
+	// readinessCheckInterval is the time between requests of the readiness
+	// check which did not respond successfully.
+	readinessCheckInterval = time.Second
+)
+
+// newDeployClient returns an instance of fn.Client for the "Deploy" command,
+// which builds with buildpacks, pushes with docker and deploys to Knative
+// (see tests for alternative client factories which return clients with
+// various mocks).
+func newDeployClient(config deployConfig, listener fn.ProgressListener) (*fn.Client, error) {
+	builder, err := newBuilder(config.buildConfig, newBuildProgress(config.Verbose, listener), config.Build && !config.Remote && config.SourceArchive == "", config.Plan != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerfileBuilder := docker.NewBuilder()
+	dockerfileBuilder.Verbose = config.Verbose
+
+	dockerPusher, err := docker.NewPusher()
+	if err != nil {
+		return nil, err
+	}
+	dockerPusher.Verbose = config.Verbose
+	var pusher fn.Pusher = dockerPusher
+	// Images built without a daemon are pushed as they are built.
+	if daemonless, ok := builder.(*buildpacks.DaemonlessBuilder); ok {
+		pusher = daemonless
+	}
+
+	deployer, err := knative.NewDeployer(config.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	deployer.Verbose = config.Verbose
+	deployer.CreateNamespace = config.CreateNamespace
+	deployer.Replace = config.Replace
+	deployer.IfChanged = config.IfChanged
+	deployer.ForceLocked = config.ForceLocked
+	deployer.NoRetryConflict = config.NoRetryConflict
+	deployer.WaitCondition = config.WaitCondition
+	deployer.WaitForTraffic = config.WaitForTraffic
+	deployer.TrafficTimeout = config.Timeout
+	deployer.Sources = config.SinkFrom
+	deployer.ChangeCause = config.Message
+
+	splitter, err := knative.NewTrafficSplitter(config.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelinesProvider, err := tekton.NewPipelinesProvider(config.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	pipelinesProvider.Verbose = config.Verbose
+	pipelinesProvider.Timeout = config.Timeout
+	pipelinesProvider.Progress = func(uploaded, total int) {
+		listener.Increment(fmt.Sprintf("Uploaded function source to the cluster (%v of %v parts)", uploaded, total))
+	}
+
+	mirrors, err := registryMirrors()
+	if err != nil {
+		return nil, err
+	}
+
+	return fn.New(
+		fn.WithVerbose(config.Verbose),
+		fn.WithConfigFile(configFile()),
+		fn.WithRegistry(config.Registry), // for deriving image name when --image not provided explicitly.
+		fn.WithRegistryMirrors(mirrors),
+		fn.WithBuilder(builder),
+		fn.WithDockerfileBuilder(dockerfileBuilder),
+		fn.WithBuildCache(config.buildCache()),
+		fn.WithBuildTimeout(config.BuildTimeout),
+		fn.WithUpdateBuilder(config.UpdateBuilder),
+		fn.WithSourceLabels(!config.NoOCILabels),
+		fn.WithReproducible(config.Reproducible),
+		fn.WithPusher(pusher),
+		fn.WithCredentialsProvider(newCredentialsProvider()),
+		fn.WithDeployer(deployer),
+		fn.WithTrafficSplitter(splitter),
+		fn.WithPipelinesProvider(pipelinesProvider),
+		fn.WithPush(config.Push),
+		fn.WithStatus(!config.NoStatus),
+		fn.WithEnvironment(config.Environment),
+		fn.WithDeployEnvs(config.DeployEnvs),
+		fn.WithProgressListener(listener),
+		fn.WithPlan(config.Plan)), nil
+}
+
+// deployClientFn is a factory function which returns a Client suitable for
+// use with the Deploy command, reporting progress to the given listener.
+type deployClientFn func(config deployConfig, listener fn.ProgressListener) (*fn.Client, error)
+
+// NewDeployCmd creates a deploy command using the given client creator.
+func NewDeployCmd(clientFn deployClientFn) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deploy",
+		Short: "Deploy a function",
+		Long: `Deploy a function
 
 Builds a container image for the function and deploys it to the connected Knative enabled cluster. 
 The function is picked up from the project in the current directory or from the path provided
@@ -47,7 +141,7 @@ in the configuration file.
 If the function is already deployed, it is updated with a new container image
 that is pushed to an image registry, and finally the function's Knative service is updated.
 `,
-	Example: `
+		Example: `
 # Build and deploy the function from the current directory's project. The image will be
 # pushed to "quay.io/myuser/<function name>" and deployed as Knative service with the 
 # same name as the function to the currently connected cluster.
@@ -56,55 +150,354 @@ kn func deploy --registry quay.io/myuser
 # Same as above but using a full image name, that will create a Knative service "myfunc" in 
 # the namespace "myns"
 kn func deploy --image quay.io/myuser/myfunc -n myns
+
+# Same as above, creating the namespace "myns" if it does not exist
+kn func deploy --image quay.io/myuser/myfunc -n myns --create-namespace
+
+# Deploy the function with an image pulled from a private registry using the
+# credentials in the Secret "regcred"
+kn func deploy --pull-secret regcred
+
+# Deploy the function to run as the ServiceAccount "myfunc-sa", for example
+# one bound to a cloud IAM identity
+kn func deploy --service-account myfunc-sa
+
+# Deploy the function, reachable also at the custom domain myfunc.example.com
+kn func deploy --domain myfunc.example.com
+
+# Deploy the function as the revision "myfunc-v<generation>", tagged "green"
+# such that it is reachable at its own URL before traffic is routed to it
+kn func deploy --revision-name "{{.Service}}-v{{.Generation}}" --tag green
+
+# Deploy the function with a readiness probe at "/ready", first run 10 seconds
+# after the function starts, for functions which are slow to start
+kn func deploy --readiness-path /ready --readiness-initial-delay 10
+
+# Deploy the image built and pushed by CI, without building or pushing it
+kn func deploy --build=false --push=false --image quay.io/myuser/myfunc:v1.0.0
+
+# Promote the image deployed to a lower environment, by the digest recorded
+# in func.yaml, without building or pushing it
+kn func deploy --use-status-digest --namespace production
+
+# Deploy the function, printing the reference by digest of the image deployed,
+# such as for a provenance record
+kn func deploy --image-digest
+
+# Build the function on the cluster from the main branch of its git
+# repository, and deploy it, without a local container engine
+kn func deploy --remote --git-url https://github.com/alice/myfunc.git --git-branch main
+
+# Build the function on the cluster from a source archive produced by CI,
+# which contains its func.yaml, and deploy it, without a local checkout
+kn func deploy --source-archive myfunc.tar.gz --registry quay.io/myuser
+
+# Deploy the function, checking that its "/health" path responds successfully
+# once deployed, and rolling it back to the revision deployed before if not
+kn func deploy --readiness-check /health --rollback-on-failure
+
+# Deploy the function, waiting up to 15 minutes for its gradual rollout to
+# route all of its traffic to the revision deployed, such as to gate CD
+kn func deploy --wait-for-traffic --timeout 15m
+
+# Print the changes the deploy would make, including the Knative Service as
+# rendered locally, without making them
+kn func deploy --dry-run
+
+# Print the Knative Service as it would be persisted by the cluster, without
+# building, pushing or deploying the function
+kn func deploy --dry-run=server
 `,
-	SuggestFor: []string{"delpoy", "deplyo"},
-	PreRunE:    bindEnv("image", "namespace", "path", "registry", "confirm", "build"),
-	RunE:       runDeploy,
+		SuggestFor:  []string{"delpoy", "deplyo"},
+		Annotations: map[string]string{dryRunAnnotation: dryRunPlan},
+		PreRunE:     bindEnv("image", "namespace", "path", "registry", "confirm", "env-file", "save-env", "build", "push", "use-status-digest", "build-cache", "no-cache", "no-oci-labels", "reproducible", "build-timeout", "builder-digest", "builder-pull-policy", "lifecycle-image", "platform-api", "update-builder", "environment", "pull-secret", "service-account", "image-pull-policy", "mesh", "port", "metrics-port", "metrics-path", "tracing-endpoint", "tracing-service-name", "init-name", "init-image", "init-command", "ingress-class", "domain", "revision-name", "tag", "liveness-path", "liveness-initial-delay", "liveness-period", "readiness-path", "readiness-initial-delay", "readiness-period", "request-timeout", "scale-window", "scale-down-delay", "scale-retention-period", "create-namespace", "replace", "if-changed", "force-locked", "no-retry-conflict", "wait-condition", "wait-for-traffic", "remote", "git-url", "git-branch", "source-archive", "timeout", "dry-run", "image-digest", "no-status", "output", "message", "daemonless", "readiness-check", "readiness-check-timeout", "rollback-on-failure"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDeploy(cmd, args, clientFn)
+		},
+	}
+
+	cmd.Flags().BoolP("confirm", "c", false, "Prompt to confirm all configuration options (Env: $FUNC_CONFIRM)")
+	cmd.Flags().StringArrayP("env", "e", []string{}, "Environment variable to set in the form NAME=VALUE. "+
+		"You may provide this flag multiple times for setting multiple environment variables. "+
+		"To unset, specify the environment variable name followed by a \"-\" (e.g., NAME-). "+
+		"To use the value of a local environment variable, without storing it in func.yaml, specify only its name (e.g., NAME).")
+	cmd.Flags().String("env-file", "", "File of environment variables to set, such as .env, of a NAME=VALUE per line, as read by Docker. Blank lines and those beginning with # are skipped, and values may be quoted. "+
+		"Those given with --env take precedence. Not stored in func.yaml, unless --save-env is provided (Env: $FUNC_ENV_FILE)")
+	cmd.Flags().Bool("save-env", false, "Store the environment variables of --env-file in func.yaml (Env: $FUNC_SAVE_ENV)")
+	cmd.Flags().StringArray("volume", []string{}, "Volume to mount, in the form secret:NAME:PATH or configMap:NAME:PATH for a Secret or ConfigMap of the namespace, or emptyDir:PATH for an empty directory. "+
+		"You may provide this flag multiple times for mounting multiple volumes. "+
+		"To unmount, specify the path followed by a \"-\" (e.g., /etc/config-). Stored in func.yaml")
+	cmd.Flags().StringArray("requests", []string{}, "Resource requested by the function, in the form NAME=QUANTITY, such as cpu=500m, memory=256Mi, or an extended resource such as nvidia.com/gpu=1. "+
+		"You may provide this flag multiple times. To remove, specify the resource name followed by a \"-\" (e.g., cpu-). Stored in func.yaml")
+	cmd.Flags().StringArray("limits", []string{}, "Resource to which the function is limited, in the form NAME=QUANTITY, such as cpu=1, memory=512Mi, or an extended resource such as nvidia.com/gpu=1, of which the limit alone may be given, being the request. "+
+		"You may provide this flag multiple times. To remove, specify the resource name followed by a \"-\" (e.g., nvidia.com/gpu-). Stored in func.yaml")
+	cmd.Flags().StringP("image", "i", "", "Full image name in the form [registry]/[namespace]/[name]:[tag] (optional). This option takes precedence over --registry (Env: $FUNC_IMAGE")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace of the function to undeploy. By default, the namespace in func.yaml is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	cmd.Flags().StringP("registry", "r", "", "Registry + namespace part of the image to build, ex 'quay.io/myuser'.  The full image name is automatically determined based on the local directory name. If not provided the registry will be taken from func.yaml (Env: $FUNC_REGISTRY)")
+	cmd.Flags().BoolP("build", "b", true, "Build the image before deploying (Env: $FUNC_BUILD)")
+	cmd.Flags().Bool("push", true, "Push the image to the registry before deploying. With --build=false and --push=false, an image already in the registry, such as one built and pushed by CI, is deployed (Env: $FUNC_PUSH)")
+	cmd.Flags().Bool("use-status-digest", false, "Deploy exactly the image recorded by digest in the status of func.yaml, as last deployed, without building or pushing it, such as to promote it to another environment (Env: $FUNC_USE_STATUS_DIGEST)")
+	cmd.Flags().StringArray("build-env", []string{}, "Environment variable set when building, in the form NAME=VALUE. "+
+		"It is not set in the deployed function. You may provide this flag multiple times. "+
+		"To unset, specify the variable name followed by a \"-\" (e.g., NAME-). Stored in func.yaml")
+	cmd.Flags().StringArray("buildpack", []string{}, "Buildpack run after the default group of the builder, as an image, an http(s) URI or a urn:cnb:registry: ID. "+
+		"You may provide this flag multiple times, in place of the buildpacks of func.yaml. Stored in func.yaml")
+	cmd.Flags().String("build-cache", "", "Directory in which content is cached for reuse by subsequent builds. By default, that of the function, $XDG_CACHE_HOME/func/<name> (Env: $FUNC_BUILD_CACHE)")
+	cmd.Flags().Bool("no-cache", false, "Build without reusing cached content, clearing any previously cached (Env: $FUNC_NO_CACHE)")
+	cmd.Flags().Bool("daemonless", false, "Build without a container daemon, with the buildpacks lifecycle of the builder image in which func runs, such as the image of a CI job. The image is pushed to the registry as it is built. Used by default when building and no daemon is available (Env: $FUNC_DAEMONLESS)")
+	cmd.Flags().Bool("no-oci-labels", false, "Do not label the image built with the OCI labels of its source: the git remote and HEAD commit of the function's source, and the time of its source (Env: $FUNC_NO_OCI_LABELS)")
+	cmd.Flags().Bool("reproducible", false, "Build reproducibly, normalizing timestamps to $SOURCE_DATE_EPOCH, or else the commit time of the HEAD of the function's source, such that builds of the same source yield the same image (Env: $FUNC_REPRODUCIBLE)")
+	cmd.Flags().Duration("build-timeout", 0, "Time after which the build is cancelled, such as 10m. Zero is no timeout (Env: $FUNC_BUILD_TIMEOUT)")
+	cmd.Flags().String("builder-digest", "", "Digest of the builder image to which builds are pinned, such as sha256:a278a9..., rather than that resolved from its tag when first built. Stored in func.yaml (Env: $FUNC_BUILDER_DIGEST)")
+	cmd.Flags().String("builder-pull-policy", "", fmt.Sprintf("Policy with which the builder image is pulled before building, one of %v: always re-pulls it, such that the latest is used, and never uses that already present, such as in air-gapped environments. Defaults to %v. Stored in func.yaml (Env: $FUNC_BUILDER_PULL_POLICY)", strings.Join(fn.BuilderPullPolicies, ", "), fn.DefaultBuilderPullPolicy))
+	cmd.Flags().String("lifecycle-image", "", "Image of the buildpacks lifecycle with which the function is built, such as buildpacksio/lifecycle:0.11.1, in place of that compatible with the builder, for builders requiring a specific lifecycle. Stored in func.yaml (Env: $FUNC_LIFECYCLE_IMAGE)")
+	cmd.Flags().String("platform-api", "", "Buildpacks platform API at which the function is built, such as 0.4. Daemonless builds run the lifecycle at it, and other builds fail unless the lifecycle of the builder supports it. Stored in func.yaml (Env: $FUNC_PLATFORM_API)")
+	cmd.Flags().Bool("update-builder", false, "Resolve the digest of the builder image from its tag again when building, pinning builds to the latest (Env: $FUNC_UPDATE_BUILDER)")
+	cmd.Flags().String("environment", "", "Name of the environment to which the function is deployed, such as staging or prod, the overlay of which under environments in func.yaml is merged over its settings when deployed (Env: $FUNC_ENVIRONMENT)")
+	cmd.Flags().String("pull-secret", "", "Name of a Secret in the namespace used to pull the function's image from a private registry. Stored in func.yaml (Env: $FUNC_PULL_SECRET)")
+	cmd.Flags().String("service-account", "", "Name of a ServiceAccount in the namespace as which the function runs. Stored in func.yaml (Env: $FUNC_SERVICE_ACCOUNT)")
+	cmd.Flags().String("image-pull-policy", "", fmt.Sprintf("Policy with which the function's image is pulled, one of %v, such as Never for images loaded into a kind or minikube cluster. Defaults to that of Kubernetes. Provide an empty value to remove it. Stored in func.yaml (Env: $FUNC_IMAGE_PULL_POLICY)", strings.Join(fn.ImagePullPolicies, ", ")))
+	cmd.Flags().String("mesh", "", fmt.Sprintf("Service mesh of which the function is made a part by the injection of its sidecar, one of %v. Provide an empty value to remove it. Stored in func.yaml (Env: $FUNC_MESH)", strings.Join(fn.Meshes, ", ")))
+	cmd.Flags().Int("port", 0, "Port on which the function listens, between 1 and 65535, set as the port of its container and with which Knative sets $PORT. Defaults to Knative's 8080. Provide 0 to remove it. Stored in func.yaml (Env: $FUNC_PORT)")
+	cmd.Flags().Int("metrics-port", 0, "Port on which the function serves Prometheus metrics, by which its pods are annotated to be scraped. Provide 0 to remove it. Stored in func.yaml (Env: $FUNC_METRICS_PORT)")
+	cmd.Flags().String("metrics-path", "", fmt.Sprintf("Path at which the function serves Prometheus metrics, with --metrics-port. Defaults to %v. Provide an empty value to remove it. Stored in func.yaml (Env: $FUNC_METRICS_PATH)", fn.DefaultMetricsPath))
+	cmd.Flags().String("tracing-endpoint", "", "URL of the OpenTelemetry collector to which the function exports traces over OTLP, such as http://otel-collector.observability:4317, set as $OTEL_EXPORTER_OTLP_ENDPOINT of its container. Provide an empty value to remove it. Stored in func.yaml (Env: $FUNC_TRACING_ENDPOINT)")
+	cmd.Flags().String("tracing-service-name", "", "Service name of the traces of the function, with --tracing-endpoint, set as $OTEL_SERVICE_NAME of its container. Defaults to the function's name. Provide an empty value to remove it. Stored in func.yaml (Env: $FUNC_TRACING_SERVICE_NAME)")
+	cmd.Flags().String("init-name", fn.DefaultInitContainerName, "Name of the init container set by --init-image, --init-command and --init-env. Stored in func.yaml (Env: $FUNC_INIT_NAME)")
+	cmd.Flags().String("init-image", "", "Image of an init container run to completion before the function starts, such as to migrate a database. Requires the Knative kubernetes.podspec-init-containers feature. Provide an empty value to remove the init container. Stored in func.yaml (Env: $FUNC_INIT_IMAGE)")
+	cmd.Flags().String("init-command", "", "Command run by the init container in place of the entrypoint of its image, such as \"migrate up\". Provide an empty value to remove it. Stored in func.yaml (Env: $FUNC_INIT_COMMAND)")
+	cmd.Flags().StringArray("init-env", []string{}, "Environment variable of the init container in the form NAME=VALUE. "+
+		"You may provide this flag multiple times. To unset, specify the environment variable name followed by a \"-\" (e.g., NAME-). Stored in func.yaml")
+	cmd.Flags().String("ingress-class", "", "Class of the Knative ingress through which the function is reached, such as kourier.ingress.networking.knative.dev, on clusters of several. Defaults to that of the cluster. Provide an empty value to remove it. Stored in func.yaml (Env: $FUNC_INGRESS_CLASS)")
+	cmd.Flags().String("domain", "", "Custom domain at which the function is reachable in addition to its default URL, such as myfunc.example.com. Requires the Knative DomainMapping API. Provide an empty value to remove it. Stored in func.yaml (Env: $FUNC_DOMAIN)")
+	cmd.Flags().String("revision-name", "", "Template of the name of the revision deployed, such as {{.Service}}-v{{.Generation}}, prefixed with the function's name if not already. {{.Random 5}} may also be used. Must render a DNS-compatible name which is unique per deploy. Provide an empty value to remove it. Stored in func.yaml (Env: $FUNC_REVISION_NAME)")
+	cmd.Flags().String("tag", "", "Traffic tag of the revision deployed, such that it is reachable at its own URL, of the form <tag>-<function>.<domain>, without traffic being routed to it. Provide an empty value to remove it. Stored in func.yaml (Env: $FUNC_TAG)")
+	cmd.Flags().String("liveness-path", "", "Path of the HTTP liveness probe. Defaults to that of the runtime. Stored in func.yaml (Env: $FUNC_LIVENESS_PATH)")
+	cmd.Flags().Int32("liveness-initial-delay", 0, "Seconds after the function starts before the liveness probe is first run. Stored in func.yaml (Env: $FUNC_LIVENESS_INITIAL_DELAY)")
+	cmd.Flags().Int32("liveness-period", 0, "Seconds between runs of the liveness probe. Defaults to Knative's. Stored in func.yaml (Env: $FUNC_LIVENESS_PERIOD)")
+	cmd.Flags().String("readiness-path", "", "Path of the HTTP readiness probe. Defaults to that of the runtime. Stored in func.yaml (Env: $FUNC_READINESS_PATH)")
+	cmd.Flags().Int32("readiness-initial-delay", 0, "Seconds after the function starts before the readiness probe is first run. Stored in func.yaml (Env: $FUNC_READINESS_INITIAL_DELAY)")
+	cmd.Flags().Int32("readiness-period", 0, "Seconds between runs of the readiness probe. Defaults to Knative's. Stored in func.yaml (Env: $FUNC_READINESS_PERIOD)")
+	cmd.Flags().Int64("request-timeout", 0, fmt.Sprintf("Seconds within which a request to the function must be responded to, between 1 and %d. Defaults to Knative's (300). Stored in func.yaml (Env: $FUNC_REQUEST_TIMEOUT)", fn.MaxRequestTimeout))
+	cmd.Flags().String("scale-window", "", fmt.Sprintf("Window over which the metric the function is autoscaled by is averaged, such as 60s, between %v and %v. Defaults to the cluster's. Stored in func.yaml (Env: $FUNC_SCALE_WINDOW)", fn.MinScaleWindow, fn.MaxScaleWindow))
+	cmd.Flags().String("scale-down-delay", "", "Delay for which the demand must stay lower before the function is scaled down, such as 15m, which avoids cold starts of latency-sensitive functions. Defaults to the cluster's. Stored in func.yaml (Env: $FUNC_SCALE_DOWN_DELAY)")
+	cmd.Flags().String("scale-retention-period", "", "Period for which the last instance of the function is retained once it is to be scaled to zero, such as 5m. Defaults to the cluster's. Stored in func.yaml (Env: $FUNC_SCALE_RETENTION_PERIOD)")
+	cmd.Flags().Bool("create-namespace", false, "Create the namespace if it does not exist (Env: $FUNC_CREATE_NAMESPACE)")
+	cmd.Flags().Bool("replace", false, "Replace the deployed Knative Service with that of the function, rather than patching only the fields it declares. Resets fields set by others, such as their annotations (Env: $FUNC_REPLACE)")
+	cmd.Flags().Bool("if-changed", false, "Skip the update of the deployed Knative Service when it would change nothing, such that no revision is created. The changes are printed with --verbose (Env: $FUNC_IF_CHANGED)")
+	cmd.Flags().Bool("force-locked", false, "Deploy a locked function, of which the Knative Service is annotated as locked, such as by locked: true in func.yaml, even though it would be modified. Without it, the changes are printed and the deploy refused (Env: $FUNC_FORCE_LOCKED)")
+	cmd.Flags().Bool("no-retry-conflict", false, fmt.Sprintf("Fail when the deployed Knative Service was modified since it was read, such as by a concurrent deploy, rather than reading it again and reapplying the update, up to %v times (Env: $FUNC_NO_RETRY_CONFLICT)", knative.DefaultConflictRetries))
+	cmd.Flags().String("wait-condition", knative.DefaultWaitCondition, "Condition of the Knative Service awaited once deployed, such as RoutesReady or ConfigurationsReady. On timeout, the conditions observed are printed (Env: $FUNC_WAIT_CONDITION)")
+	cmd.Flags().Int64("wait-for-traffic", 0, "Percent of the traffic of the Knative Service awaited to be routed to the revision deployed, once it is ready, such as for the full cutover of a gradual rollout. Given without a value, 100. On --timeout, the traffic split observed is printed (Env: $FUNC_WAIT_FOR_TRAFFIC)")
+	cmd.Flags().Lookup("wait-for-traffic").NoOptDefVal = "100"
+	cmd.Flags().StringArray("sink-from", []string{}, "Knative Eventing source, such as PingSource/heartbeat or heartbeat, of which the function is made the sink once deployed. The source must exist in the function's namespace. You may provide this flag multiple times")
+	cmd.Flags().StringP("message", "m", "", "Message recording the cause of the change deployed, such as for an audit of the changes made across rollouts, with which the revision created is annotated (func.boson.dev/change-cause). Not stored in func.yaml (Env: $FUNC_MESSAGE)")
+	cmd.Flags().String("readiness-check", "", "Path of the function, such as /health, requested with GET once deployed, the deploy failing unless it responds with a 2xx status within --readiness-check-timeout (Env: $FUNC_READINESS_CHECK)")
+	cmd.Flags().Duration("readiness-check-timeout", defaultReadinessCheckTimeout, "Time within which the path of --readiness-check must respond successfully (Env: $FUNC_READINESS_CHECK_TIMEOUT)")
+	cmd.Flags().Bool("rollback-on-failure", false, "Roll the function back to the revision deployed before, as recorded in func.yaml, if the readiness check fails. Requires --readiness-check (Env: $FUNC_ROLLBACK_ON_FAILURE)")
+	cmd.Flags().Bool("remote", false, "Build the function on the cluster with Tekton, from the source in its git repository, rather than locally (Env: $FUNC_REMOTE)")
+	cmd.Flags().String("git-url", "", "URL of the git repository of the function's source, built with --remote. Stored in func.yaml (Env: $FUNC_GIT_URL)")
+	cmd.Flags().String("git-branch", "", "Branch or tag of the git repository built with --remote. Stored in func.yaml (Env: $FUNC_GIT_BRANCH)")
+	cmd.Flags().String("source-archive", "", "Path of a gzipped tarball of the function's source, containing its func.yaml, which is uploaded and built on the cluster with Tekton, without a local checkout. Uploaded in parts, each of which is retried, and limited to 16MiB (Env: $FUNC_SOURCE_ARCHIVE)")
+	cmd.Flags().Duration("timeout", tekton.DefaultTimeout, "Time to wait for the build on the cluster with --remote or --source-archive to complete, and for the traffic of --wait-for-traffic to be routed (Env: $FUNC_TIMEOUT)")
+	cmd.Flags().Bool("image-digest", false, "Print the reference by digest of the image deployed, such as quay.io/myuser/myfunc@sha256:..., once deployed (Env: $FUNC_IMAGE_DIGEST)")
+	cmd.Flags().Bool("no-status", false, "Do not record the status of the function as deployed (its image, revision, URL and time) in func.yaml, such as for read-only workflows (Env: $FUNC_NO_STATUS)")
+	cmd.Flags().String("dry-run", knative.DryRunNone, "Print the changes the deploy would make without making them. One of 'none', 'plan' (the default when given without a value: the config written, the image built and pushed, and the Knative Service applied), 'client' (only the Knative Service as YAML, rendered locally) or 'server' (only the Knative Service as YAML, submitted to the cluster without persisting) (Env: $FUNC_DRY_RUN)")
+	cmd.Flags().Lookup("dry-run").NoOptDefVal = dryRunPlan
+	cmd.Flags().StringP("output", "o", "", "Go template of the function deployed, written once deployed, as go-template=TEMPLATE or go-template-file=PATH. Its fields are Name, Image, URL, Revision and Deployed (Env: $FUNC_OUTPUT)")
+
+	registerCompletions(cmd, map[string]completionFunc{
+		"environment":         CompleteEnvironmentList,
+		"image-pull-policy":   completeValues(fn.ImagePullPolicies...),
+		"mesh":                completeValues(fn.Meshes...),
+		"ingress-class":       completeValues(fn.IngressClasses...),
+		"builder-pull-policy": completeValues(fn.BuilderPullPolicies...),
+		"dry-run":             completeValues(dryRunPlan, knative.DryRunNone, knative.DryRunClient, knative.DryRunServer),
+	})
+
+	return cmd
 }
 
-func runDeploy(cmd *cobra.Command, _ []string) (err error) {
+func runDeploy(cmd *cobra.Command, _ []string, clientFn deployClientFn) (err error) {
 
 	config, err := newDeployConfig(cmd)
 	if err != nil {
 		return err
 	}
+
+	if err = configureClusterAccess(); err != nil {
+		return
+	}
 	config, err = config.Prompt()
 	if err != nil {
-		if err == terminal.InterruptErr {
-			return nil
-		}
 		return
 	}
 
+	if config.SourceArchive != "" {
+		return runDeployArchive(cmd, config, clientFn)
+	}
+
 	function, err := functionWithOverrides(config.Path, functionOverrides{Namespace: config.Namespace, Image: config.Image})
 	if err != nil {
 		return
 	}
 
+	if config.EnvFile != "" {
+		if config.DeployEnvs, err = envsFromFile(config.EnvFile, config.EnvToUpdate, config.EnvToRemove); err != nil {
+			return
+		}
+		if config.SaveEnv {
+			function.Envs = function.WithEnvs(config.DeployEnvs).Envs
+			config.DeployEnvs = nil
+		}
+	}
+
 	function.Envs, err = mergeEnvs(function.Envs, config.EnvToUpdate, config.EnvToRemove)
 	if err != nil {
 		return
 	}
 
-	// Check if the Function has been initialized
-	if !function.Initialized() {
-		return fmt.Errorf("the given path '%v' does not contain an initialized function. Please create one at this path before deploying", config.Path)
+	function.BuildEnvs, err = mergeBuildEnvs(cmd, function.BuildEnvs)
+	if err != nil {
+		return
+	}
+	if function.Build.Buildpacks, err = buildpacksFromFlag(cmd, function.Build.Buildpacks); err != nil {
+		return
+	}
+
+	function.Volumes, err = mergeVolumes(cmd, function.Volumes)
+	if err != nil {
+		return
+	}
+
+	function.Options, err = mergeResources(cmd, function.Options)
+	if err != nil {
+		return
+	}
+
+	function.InitContainers, err = mergeInitContainers(cmd, function.InitContainers, config)
+	if err != nil {
+		return
+	}
+
+	if config.BuilderDigest != "" {
+		if err = fn.ValidateDigest(config.BuilderDigest); err != nil {
+			return fmt.Errorf("invalid value '%v' for --builder-digest: %v", config.BuilderDigest, err)
+		}
+		function.BuilderDigest = config.BuilderDigest
+	}
+	if config.BuilderPullPolicy != "" {
+		if err = fn.ValidateBuilderPullPolicy(config.BuilderPullPolicy); err != nil {
+			return fmt.Errorf("invalid value '%v' for --builder-pull-policy: %v", config.BuilderPullPolicy, err)
+		}
+		function.BuilderPullPolicy = config.BuilderPullPolicy
+	}
+	if config.LifecycleImage != "" {
+		function.LifecycleImage = config.LifecycleImage
+	}
+	if config.PlatformAPI != "" {
+		if err = fn.ValidatePlatformAPI(config.PlatformAPI); err != nil {
+			return fmt.Errorf("invalid value '%v' for --platform-api: %v", config.PlatformAPI, err)
+		}
+		function.PlatformAPI = config.PlatformAPI
+	}
+	if config.PullSecret != "" {
+		function.PullSecret = config.PullSecret
+	}
+	if config.ServiceAccount != "" {
+		function.ServiceAccount = config.ServiceAccount
+	}
+	if config.ImagePullPolicy != "" || cmd.Flags().Changed("image-pull-policy") {
+		function.ImagePullPolicy = config.ImagePullPolicy
+	}
+	if config.Mesh != "" || cmd.Flags().Changed("mesh") {
+		function.Mesh = config.Mesh
+	}
+	if config.Port != 0 || cmd.Flags().Changed("port") {
+		function.Port = config.Port
+	}
+	if config.Metrics.Port != 0 || cmd.Flags().Changed("metrics-port") {
+		function.Metrics.Port = config.Metrics.Port
+	}
+	if config.Metrics.Path != "" || cmd.Flags().Changed("metrics-path") {
+		function.Metrics.Path = config.Metrics.Path
+	}
+	if err = fn.ValidateMetrics(function.Metrics); err != nil {
+		return fmt.Errorf("invalid metrics endpoint ':%v%v': %v", function.Metrics.Port, function.Metrics.Path, err)
+	}
+	if config.Tracing.Endpoint != "" || cmd.Flags().Changed("tracing-endpoint") {
+		function.Tracing.Endpoint = config.Tracing.Endpoint
+	}
+	if config.Tracing.ServiceName != "" || cmd.Flags().Changed("tracing-service-name") {
+		function.Tracing.ServiceName = config.Tracing.ServiceName
+	}
+	if err = fn.ValidateTracing(function.Tracing); err != nil {
+		return fmt.Errorf("invalid tracing endpoint '%v': %v", function.Tracing.Endpoint, err)
+	}
+	if config.IngressClass != "" || cmd.Flags().Changed("ingress-class") {
+		function.IngressClass = config.IngressClass
+	}
+	if config.Domain != "" || cmd.Flags().Changed("domain") {
+		function.Domain = config.Domain
+	}
+	if config.RevisionName != "" || cmd.Flags().Changed("revision-name") {
+		function.RevisionName = config.RevisionName
+	}
+	if config.TrafficTag != "" || cmd.Flags().Changed("tag") {
+		function.TrafficTag = config.TrafficTag
+	}
+	if config.GitURL != "" {
+		function.Git.URL = config.GitURL
+	}
+	if config.GitBranch != "" {
+		function.Git.Revision = config.GitBranch
+	}
+	if config.Remote && function.Git.URL == "" {
+		return fmt.Errorf("the function has no git repository from which to build it on the cluster. Provide --git-url")
+	}
+	function.Health.Liveness = mergeProbe(function.Health.Liveness, config.Health.Liveness)
+	function.Health.Readiness = mergeProbe(function.Health.Readiness, config.Health.Readiness)
+	if config.RequestTimeout != 0 {
+		function.Options.RequestTimeout = &config.RequestTimeout
+	}
+	function.Options.Scale = mergeAutoscaling(cmd, function.Options.Scale, config)
+
+	// The environment is deployed to the namespace of its overlay, unless
+	// one is provided explicitly.
+	if config.Environment != "" {
+		deployed, err := function.WithEnvironment(config.Environment)
+		if err != nil {
+			return err
+		}
+		if config.Namespace == "" {
+			config.Namespace = deployed.Namespace
+		}
+	}
+
+	if config.Daemonless && config.Build && function.Builder == fn.DockerfileBuilder {
+		return fmt.Errorf("--daemonless is not supported by the %v builder, which builds with a container daemon", fn.DockerfileBuilder)
+	}
+
+	// The image deployed last, to a lower environment, is promoted as is.
+	if config.UseStatusDigest {
+		if function, err = function.WithStatusDigest(); err != nil {
+			return
+		}
+	}
+
+	// Without building, the image to deploy must already be known.
+	if !config.Build && !config.Remote && !function.Built() {
+		return fmt.Errorf("the function has no image to deploy without building. Provide --image, or deploy with --build")
 	}
 
 	// If the Function does not yet have an image name and one was not provided on the command line
 	if function.Image == "" {
-		//  AND a --registry was not provided, then we need to
-		// prompt for a registry from which we can derive an image name.
-		if config.Registry == "" {
-			fmt.Println("A registry for Function images is required. For example, 'docker.io/tigerteam'.")
-
-			err = survey.AskOne(
-				&survey.Input{Message: "Registry for Function images:"},
-				&config.Registry, survey.WithValidator(survey.Required))
-			if err != nil {
-				if err == terminal.InterruptErr {
-					return nil
-				}
+		//  AND a --registry was neither provided nor persisted, nor can one be
+		// inferred from the git remote, then we need one of the docker config
+		// or to prompt for a registry from which we can derive an image name.
+		if config.Registry == "" && function.Registry == "" && fn.GitRemoteRegistry(function.Root) == "" {
+			if config.Registry, err = promptRegistry(); err != nil {
 				return
 			}
 		}
@@ -114,105 +507,333 @@ func runDeploy(cmd *cobra.Command, _ []string) (err error) {
 		function.Image = config.Image
 	}
 
-	// All set, let's write changes in the config to the disk
-	err = function.WriteConfig()
-	if err != nil {
+	// The Function as configured by the flags is validated as a whole, such
+	// that all of its problems are reported at once.
+	if err = function.Validate(); err != nil {
 		return
 	}
 
-	builder := buildpacks.NewBuilder()
-	builder.Verbose = config.Verbose
+	// A dry run of the Knative Service alone neither modifies the function's
+	// configuration on disk nor builds and pushes its image.  Those of the
+	// plan are planned instead.
+	config.Plan = newPlan(config.DryRun == dryRunPlan)
+	dryRun := config.DryRun == knative.DryRunClient || config.DryRun == knative.DryRunServer
 
-	pusher, err := docker.NewPusher(docker.WithCredentialsProvider(credentialsProvider))
-	if err != nil {
-		if err == terminal.InterruptErr {
-			return nil
+	// All set, let's write changes in the config to the disk
+	if config.Plan != nil {
+		config.Plan.WriteConfig(function)
+	} else if !dryRun {
+		err = function.WriteConfig()
+		if err != nil {
+			return
 		}
-		return err
 	}
-	pusher.Verbose = config.Verbose
 
-	ns := config.Namespace
-	if ns == "" {
-		ns = function.Namespace
+	if config.Namespace == "" {
+		config.Namespace = function.Namespace
 	}
 
-	deployer, err := knative.NewDeployer(ns)
-	if err != nil {
-		return
+	if dryRun {
+		// A client dry run renders the Service locally, such that without
+		// the access to a cluster of which the namespace is that of its
+		// current context, it is rendered without one.
+		deployer, err := knative.NewDeployer(config.Namespace)
+		if err != nil && config.DryRun == knative.DryRunClient {
+			deployer, err = &knative.Deployer{}, nil
+		}
+		if err != nil {
+			return err
+		}
+		deployer.DryRun = config.DryRun
+		deployer.Output = cmd.OutOrStdout()
+		deployer.CreateNamespace = config.CreateNamespace
+		deployer.Replace = config.Replace
+		deployer.ChangeCause = config.Message
+		if config.Environment != "" {
+			if function, err = function.WithEnvironment(config.Environment); err != nil {
+				return err
+			}
+		}
+		_, err = deployer.Deploy(cmd.Context(), function.WithEnvs(config.DeployEnvs))
+		return err
 	}
 
-	listener := progress.New()
+	listener := newProgressListener(progressOut(config.Plan), config.Verbose)
 	defer listener.Done()
 
-	deployer.Verbose = config.Verbose
-	listener.Verbose = config.Verbose
-
 	context := cmd.Context()
 	go func() {
 		<-context.Done()
 		listener.Done()
 	}()
 
-	client := fn.New(
-		fn.WithVerbose(config.Verbose),
-		fn.WithRegistry(config.Registry), // for deriving image name when --image not provided explicitly.
-		fn.WithBuilder(builder),
-		fn.WithPusher(pusher),
-		fn.WithDeployer(deployer),
-		fn.WithProgressListener(listener))
+	client, err := clientFn(config, listener)
+	if err != nil {
+		return
+	}
 
-	if config.Build {
-		if err := client.Build(context, config.Path); err != nil {
-			return err
+	if config.Remote {
+		err = client.RunPipeline(context, config.Path)
+	} else {
+		if config.Build {
+			if err := client.Build(context, config.Path); err != nil {
+				return err
+			}
 		}
+		err = client.Deploy(context, config.Path)
+	}
+	var nsErr knative.ErrNamespaceNotFound
+	if errors.As(err, &nsErr) {
+		return fmt.Errorf("%w. Use --create-namespace to create it", err)
+	}
+	var lockedErr knative.ErrLocked
+	if errors.As(err, &lockedErr) {
+		listener.Done()
+		fmt.Fprintf(cmd.OutOrStdout(), "Changes to the Knative Service refused (-deployed +function):\n%v", lockedErr.Changes)
+		return err
+	}
+	if err == nil && config.Plan != nil {
+		listener.Done()
+		return config.Plan.Print(cmd.OutOrStdout())
+	}
+	if err != nil {
+		return
+	}
+	if config.ReadinessCheck != "" {
+		listener.Done()
+		if err = checkDeployed(cmd, client, config); err != nil {
+			return
+		}
+	}
+	if !config.ImageDigest && config.Output == "" {
+		return
 	}
 
-	return client.Deploy(context, config.Path)
+	// The digest of the image pushed, and the status of the deploy, are
+	// stored in the configuration.
+	listener.Done()
+	if function, err = fn.NewFunctionFromFile(config.Path, configFile()); err != nil {
+		return
+	}
+	if config.ImageDigest {
+		fmt.Fprintln(cmd.OutOrStdout(), function.ImageWithDigest())
+	}
+	if config.Output != "" {
+		t, err := outputTemplate(config.Output)
+		if err != nil {
+			return err
+		}
+		return t.Execute(cmd.OutOrStdout(), deployment{
+			Name:     function.Name,
+			Image:    function.ImageWithDigest(),
+			URL:      function.Status.URL,
+			Revision: function.Status.Revision,
+			Deployed: function.Status.Deployed,
+		})
+	}
+	return
 
 	// NOTE: Namespace is optional, default is that used by k8s client
 	// (for example kubectl usually uses ~/.kube/config)
 }
 
-func credentialsProvider(ctx context.Context, registry string) (docker.Credentials, error) {
+// checkDeployed checks the readiness of the Function deployed, at the path
+// of its URL given with --readiness-check, as recorded in its status.  If the
+// check fails, the Function is rolled back to the revision deployed before it
+// with --rollback-on-failure, as is then recorded in its status, and the
+// failure is returned in either case.
+func checkDeployed(cmd *cobra.Command, client *fn.Client, config deployConfig) error {
+	f, err := fn.NewFunctionFromFile(config.Path, configFile())
+	if err != nil {
+		return err
+	}
+	if f.Status.URL == "" {
+		return fmt.Errorf("the readiness of function '%v' can not be checked: the URL at which it is deployed is not known", f.Name)
+	}
+	url := strings.TrimRight(f.Status.URL, "/") + "/" + strings.TrimLeft(config.ReadinessCheck, "/")
+	fmt.Fprintf(cmd.OutOrStdout(), "Checking readiness of %v\n", url)
+	checkErr := checkReadiness(cmd.Context(), url, config.ReadinessCheckTimeout)
+	if checkErr == nil {
+		fmt.Fprintf(cmd.OutOrStdout(), "Function '%v' is ready\n", f.Name)
+		return nil
+	}
 
-	result := docker.Credentials{}
-	credentials, err := config.GetCredentials(nil, registry)
+	if !config.RollbackOnFailure {
+		return fmt.Errorf("readiness check of function '%v' failed: %v. Revision '%v' remains deployed. Roll back with 'func rollback'", f.Name, checkErr, f.Status.Revision)
+	}
+	previous := f.Status.PreviousRevision
+	if previous == "" {
+		return fmt.Errorf("readiness check of function '%v' failed: %v. No prior revision is recorded in func.yaml to roll back to, and revision '%v' remains deployed", f.Name, checkErr, f.Status.Revision)
+	}
+	traffic, err := client.Rollback(cmd.Context(), f.Name, previous)
 	if err != nil {
-		return result, errors.Wrap(err, "failed to get credentials")
+		return fmt.Errorf("readiness check of function '%v' failed: %v. Rolling back to revision '%v' failed, and revision '%v' remains deployed: %w", f.Name, checkErr, previous, f.Status.Revision, err)
 	}
+	printTraffic(cmd.OutOrStdout(), f.Name, traffic)
 
-	if credentials != (containersTypes.DockerAuthConfig{}) {
-		result.Username, result.Password = credentials.Username, credentials.Password
-		return result, nil
+	// The status records the revision rolled back to as that deployed, the
+	// image of which is not known.
+	f.Status.Revision = previous
+	f.Status.Image = ""
+	if err = f.WriteConfig(); err != nil {
+		return fmt.Errorf("readiness check of function '%v' failed: %v. Rolled back to revision '%v', which could not be recorded in func.yaml: %w", f.Name, checkErr, previous, err)
 	}
+	return fmt.Errorf("readiness check of function '%v' failed: %v. Rolled back to revision '%v'", f.Name, checkErr, previous)
+}
 
-	credentials, _ = docker.GetCredentialsFromCredsStore(registry)
-	if credentials != (containersTypes.DockerAuthConfig{}) {
-		result.Username, result.Password = credentials.Username, credentials.Password
-		return result, nil
+// checkReadiness requests the URL with GET until it responds with a 2xx
+// status, returning the last failure if it has not within the timeout.
+func checkReadiness(ctx context.Context, url string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var last error
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err == nil {
+			res.Body.Close()
+			if res.StatusCode >= 200 && res.StatusCode < 300 {
+				return nil
+			}
+			err = fmt.Errorf("%v responded with %v", url, res.Status)
+		}
+		last = err
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no successful response within %v: %w", timeout, last)
+		case <-time.After(readinessCheckInterval):
+		}
 	}
+}
 
-	fmt.Println("Please provide credentials for image registry.")
-	var qs = []*survey.Question{
-		{
+// deployment is the result of a deploy, as recorded in the status of the
+// Function, against which the template of --output is executed.
+type deployment struct {
+	Name     string
+	Image    string
+	URL      string
+	Revision string
+	Deployed time.Time
+}
+
+// runDeployArchive builds the function on the cluster from its source archive
+// and deploys it.  The function is that of the func.yaml in the archive, of
+// which only the namespace and image may be overridden, there being no local
+// configuration in which to persist other settings.
+func runDeployArchive(cmd *cobra.Command, config deployConfig, clientFn deployClientFn) (err error) {
+	if config.DryRun != knative.DryRunNone {
+		return fmt.Errorf("--dry-run is not supported with --source-archive")
+	}
+	if config.Environment != "" {
+		return fmt.Errorf("--environment is not supported with --source-archive")
+	}
+	if cmd.Flags().Changed("volume") {
+		return fmt.Errorf("--volume is not supported with --source-archive")
+	}
+	if cmd.Flags().Changed("requests") || cmd.Flags().Changed("limits") {
+		return fmt.Errorf("--requests and --limits are not supported with --source-archive")
+	}
+	if config.ScaleWindow != "" || config.ScaleDownDelay != "" || config.ScaleRetentionPeriod != "" {
+		return fmt.Errorf("--scale-window, --scale-down-delay and --scale-retention-period are not supported with --source-archive")
+	}
+	function, err := fn.NewFunctionFromArchive(config.SourceArchive)
+	if err != nil {
+		return
+	}
+	if config.Namespace != "" {
+		function.Namespace = config.Namespace
+	}
+	if config.Image != "" {
+		function.Image = config.Image
+	}
+	if config.Namespace == "" {
+		config.Namespace = function.Namespace
+	}
+
+	listener := newProgressListener(infoOut(os.Stdout), config.Verbose)
+	defer listener.Done()
+
+	client, err := clientFn(config, listener)
+	if err != nil {
+		return
+	}
+	err = client.RunPipelineArchive(cmd.Context(), function, config.SourceArchive)
+	var nsErr knative.ErrNamespaceNotFound
+	if errors.As(err, &nsErr) {
+		return fmt.Errorf("%w. Use --create-namespace to create it", err)
+	}
+	var lockedErr knative.ErrLocked
+	if errors.As(err, &lockedErr) {
+		listener.Done()
+		fmt.Fprintf(cmd.OutOrStdout(), "Changes to the Knative Service refused (-deployed +function):\n%v", lockedErr.Changes)
+		return err
+	}
+	return
+}
+
+// newCredentialsProvider returns the provider of the credentials with which
+// images are pushed: those stored, as by 'docker login', or of the environment
+// (see docker.NewCredentialsProvider), or else, in an interactive terminal,
+// those prompted for.
+func newCredentialsProvider() fn.CredentialsProvider {
+	var prompt docker.CredentialsProvider
+	if interactiveTerminal() {
+		prompt = promptForCredentials
+	}
+	return docker.NewCredentialsProvider(prompt)
+}
+
+// promptForCredentials of the registry, offering to save them in the docker
+// config for subsequent pushes.
+func promptForCredentials(ctx context.Context, registry string) (result fn.Credentials, err error) {
+	if result, err = askCredentials(registry, ""); err != nil {
+		return
+	}
+
+	save := false
+	if err = survey.AskOne(&survey.Confirm{
+		Message: "Save the credentials in the docker config?",
+	}, &save); err != nil || !save {
+		return
+	}
+	path, err := docker.SaveCredentials(registry, result)
+	if err != nil {
+		return
+	}
+	fmt.Printf("Credentials saved in %v\n", path)
+	return
+}
+
+// askCredentials of the registry: the username, unless given, and password.
+func askCredentials(registry, username string) (result fn.Credentials, err error) {
+	fmt.Printf("Please provide credentials for image registry %v.\n", registry)
+	var qs []*survey.Question
+	if username == "" {
+		qs = append(qs, &survey.Question{
 			Name: "username",
 			Prompt: &survey.Input{
 				Message: "Username:",
 			},
 			Validate: survey.Required,
+		})
+	}
+	qs = append(qs, &survey.Question{
+		Name: "password",
+		Prompt: &survey.Password{
+			Message: "Password:",
 		},
-		{
-			Name: "password",
-			Prompt: &survey.Password{
-				Message: "Password:",
-			},
-			Validate: survey.Required,
-		},
+		Validate: survey.Required,
+	})
+	if err = survey.Ask(qs, &result); err != nil {
+		return
 	}
-	err = survey.Ask(qs, &result)
-
-	return result, err
+	if username != "" {
+		result.Username = username
+	}
+	return
 }
 
 type deployConfig struct {
@@ -240,11 +861,176 @@ type deployConfig struct {
 	// Build the associated Function before deploying.
 	Build bool
 
+	// Push the Function's image before deploying.
+	Push bool
+
+	// UseStatusDigest deploys the image recorded by digest in the status of
+	// the Function, neither building nor pushing it.
+	UseStatusDigest bool
+
+	// ImageDigest of the image deployed is printed once deployed.
+	ImageDigest bool
+
+	// NoStatus disables recording the status of the deployed Function in its
+	// configuration.
+	NoStatus bool
+
+	// Output is the Go template format, if any, with which the Function
+	// deployed is written once deployed.
+	Output string
+
+	// DryRun mode: "none", "plan", "client" or "server".
+	DryRun string
+
+	// Plan populated in place of deploying with the "plan" dry run mode.
+	Plan *fn.Plan
+
+	// CreateNamespace to which the Function is deployed if it does not exist.
+	CreateNamespace bool
+
+	// Replace the deployed Service rather than patching it.
+	Replace bool
+
+	// IfChanged skips the update of the deployed Service when it would
+	// change nothing.
+	IfChanged bool
+
+	// ForceLocked deploys a locked Function even though its Service would
+	// be modified.
+	ForceLocked bool
+
+	// NoRetryConflict fails the update of the deployed Service when it was
+	// modified concurrently, rather than retrying it.
+	NoRetryConflict bool
+
+	// WaitCondition of the Service awaited once deployed.
+	WaitCondition string
+
+	// WaitForTraffic is the percent of the traffic of the Service awaited to
+	// be routed to the revision deployed, within Timeout.  Zero for none.
+	WaitForTraffic int64
+
+	// SinkFrom are the Knative Eventing sources, as KIND/NAME or NAME, of
+	// which the Function is made the sink once deployed.
+	SinkFrom []string
+
+	// Message recording the cause of the change deployed, with which the
+	// Revision created is annotated.  Not persisted.
+	Message string
+
+	// Remote build of the Function on the cluster, from the source in its git
+	// repository, rather than locally.
+	Remote bool
+
+	// GitURL and GitBranch of the repository built remotely.  Persisted in
+	// the Function's configuration.
+	GitURL    string
+	GitBranch string
+
+	// SourceArchive from which the Function is built remotely, rather than
+	// from its local source or git repository.
+	SourceArchive string
+
+	// Timeout of the remote build, and of the wait for traffic.
+	Timeout time.Duration
+
+	// PullSecret is the name of a Secret used to pull the Function's image.
+	// Persisted in the Function's configuration.
+	PullSecret string
+
+	// ServiceAccount is the name of the ServiceAccount as which the Function
+	// runs.  Persisted in the Function's configuration.
+	ServiceAccount string
+
+	// ImagePullPolicy of the Function's container.  Persisted in the
+	// Function's configuration.
+	ImagePullPolicy string
+
+	// Mesh of which the Function's sidecar is injected.  Persisted in the
+	// Function's configuration.
+	Mesh string
+
+	// Port on which the Function listens.  Persisted in the Function's
+	// configuration.
+	Port int
+
+	// Metrics endpoint of the Function, if it exposes Prometheus metrics.
+	// Persisted in the Function's configuration.
+	Metrics fn.Metrics
+
+	// Tracing of the Function by OpenTelemetry, if it exports traces.
+	// Persisted in the Function's configuration.
+	Tracing fn.Tracing
+
+	// InitName of the init container set by InitImage and InitCommand.
+	InitName string
+
+	// InitImage of the init container, removed if provided empty.
+	// Persisted in the Function's configuration.
+	InitImage string
+
+	// InitCommand run by the init container, split on whitespace.
+	// Persisted in the Function's configuration.
+	InitCommand string
+
+	// IngressClass of the ingress through which the Function is reached.
+	// Persisted in the Function's configuration.
+	IngressClass string
+
+	// Domain at which the Function is reachable in addition to its default
+	// URL.  Persisted in the Function's configuration.
+	Domain string
+
+	// RevisionName template and TrafficTag of the revision deployed.
+	// Persisted in the Function's configuration.
+	RevisionName string
+	TrafficTag   string
+
+	// Environment to which the Function is deployed, the overlay of which is
+	// merged over its settings when deployed.
+	Environment string
+
+	// Health probe settings provided, which are persisted in the Function's
+	// configuration.  Settings not provided are nil.
+	Health fn.Health
+
+	// RequestTimeout of the Function in seconds, if provided.  Persisted in
+	// the Function's configuration.
+	RequestTimeout int64
+
+	// ScaleWindow, ScaleDownDelay and ScaleRetentionPeriod of the autoscaling
+	// of the Function, as durations, if provided.  Persisted in the Function's
+	// configuration.
+	ScaleWindow          string
+	ScaleDownDelay       string
+	ScaleRetentionPeriod string
+
+	// ReadinessCheck is the path of the Function requested once deployed,
+	// which must respond successfully within ReadinessCheckTimeout.
+	ReadinessCheck        string
+	ReadinessCheckTimeout time.Duration
+
+	// RollbackOnFailure of the readiness check to the revision deployed
+	// before.
+	RollbackOnFailure bool
+
 	// Envs passed via cmd to be added/updated
 	EnvToUpdate *util.OrderedMap
 
 	// Envs passed via cmd to removed
 	EnvToRemove []string
+
+	// EnvFile of envs to set, of which those of EnvToUpdate and EnvToRemove
+	// take precedence.
+	EnvFile string
+
+	// SaveEnv stores the envs of EnvFile in the Function's config, rather
+	// than deploying them alone.
+	SaveEnv bool
+
+	// DeployEnvs merged over those of the Function when deployed, without
+	// being stored: those of EnvFile, unless SaveEnv.
+	DeployEnvs fn.Envs
 }
 
 // newDeployConfig creates a buildConfig populated from command flags and
@@ -255,18 +1041,382 @@ func newDeployConfig(cmd *cobra.Command) (deployConfig, error) {
 		return deployConfig{}, err
 	}
 
+	dryRun, err := deployDryRun(viper.GetString("dry-run"))
+	if err != nil {
+		return deployConfig{}, err
+	}
+
+	if output := viper.GetString("output"); output != "" {
+		t, err := outputTemplate(output)
+		if err != nil {
+			return deployConfig{}, err
+		}
+		switch {
+		case t == nil:
+			return deployConfig{}, fmt.Errorf("invalid value '%v' for --output: must be %v=TEMPLATE or %v=PATH", output, GoTemplate, GoTemplateFile)
+		case viper.GetBool("no-status"):
+			return deployConfig{}, fmt.Errorf("--output is not supported with --no-status, being written from the status recorded")
+		case dryRun != knative.DryRunNone:
+			return deployConfig{}, fmt.Errorf("--output is not supported with --dry-run")
+		case viper.GetString("source-archive") != "":
+			return deployConfig{}, fmt.Errorf("--output is not supported with --source-archive")
+		}
+	}
+
+	sinkFrom, err := cmd.Flags().GetStringArray("sink-from")
+	if err != nil {
+		return deployConfig{}, err
+	}
+	if len(sinkFrom) > 0 && (viper.GetBool("remote") || viper.GetString("source-archive") != "") {
+		return deployConfig{}, fmt.Errorf("--sink-from is not supported when building on the cluster with --remote or --source-archive")
+	}
+	if viper.GetString("message") != "" && (viper.GetBool("remote") || viper.GetString("source-archive") != "") {
+		return deployConfig{}, fmt.Errorf("--message is not supported when building on the cluster with --remote or --source-archive")
+	}
+
+	if err = validateObjectName("pull-secret", viper.GetString("pull-secret")); err != nil {
+		return deployConfig{}, err
+	}
+	if err = validateObjectName("service-account", viper.GetString("service-account")); err != nil {
+		return deployConfig{}, err
+	}
+
+	if err = fn.ValidateImagePullPolicy(viper.GetString("image-pull-policy")); err != nil {
+		return deployConfig{}, fmt.Errorf("invalid value '%v' for --image-pull-policy: %v", viper.GetString("image-pull-policy"), err)
+	}
+	if err = fn.ValidateMesh(viper.GetString("mesh")); err != nil {
+		return deployConfig{}, fmt.Errorf("invalid value '%v' for --mesh: %v", viper.GetString("mesh"), err)
+	}
+	if err = fn.ValidatePort(viper.GetInt("port")); err != nil {
+		return deployConfig{}, fmt.Errorf("invalid value '%v' for --port: %v", viper.GetInt("port"), err)
+	}
+	if err = fn.ValidatePort(viper.GetInt("metrics-port")); err != nil {
+		return deployConfig{}, fmt.Errorf("invalid value '%v' for --metrics-port: %v", viper.GetInt("metrics-port"), err)
+	}
+	if path := viper.GetString("metrics-path"); path != "" {
+		if err = fn.ValidateProbePath(path); err != nil {
+			return deployConfig{}, fmt.Errorf("invalid value '%v' for --metrics-path: %v", path, err)
+		}
+	}
+	if endpoint := viper.GetString("tracing-endpoint"); endpoint != "" {
+		if err = fn.ValidateTracing(fn.Tracing{Endpoint: endpoint}); err != nil {
+			return deployConfig{}, fmt.Errorf("invalid value '%v' for --tracing-endpoint: %v", endpoint, err)
+		}
+	}
+	if errs := validation.IsDNS1123Label(viper.GetString("init-name")); len(errs) > 0 {
+		return deployConfig{}, fmt.Errorf("invalid value '%v' for --init-name: %v", viper.GetString("init-name"), strings.Join(errs, "; "))
+	}
+	if image := viper.GetString("init-image"); image != "" {
+		if err = fn.ValidateImageReference(image); err != nil {
+			return deployConfig{}, fmt.Errorf("invalid value '%v' for --init-image: %v", image, err)
+		}
+	}
+	if err = fn.ValidateIngressClass(viper.GetString("ingress-class")); err != nil {
+		return deployConfig{}, fmt.Errorf("invalid value '%v' for --ingress-class: %v", viper.GetString("ingress-class"), err)
+	}
+	if err = fn.ValidateDomain(viper.GetString("domain")); err != nil {
+		return deployConfig{}, fmt.Errorf("invalid value '%v' for --domain: %v", viper.GetString("domain"), err)
+	}
+	if err = fn.ValidateRevisionName(viper.GetString("revision-name")); err != nil {
+		return deployConfig{}, fmt.Errorf("invalid value '%v' for --revision-name: %v", viper.GetString("revision-name"), err)
+	}
+	if err = fn.ValidateTrafficTag(viper.GetString("tag")); err != nil {
+		return deployConfig{}, fmt.Errorf("invalid value '%v' for --tag: %v", viper.GetString("tag"), err)
+	}
+
+	if viper.GetDuration("timeout") <= 0 {
+		return deployConfig{}, fmt.Errorf("invalid value '%v' for --timeout: must be positive", viper.GetDuration("timeout"))
+	}
+	if percent := viper.GetInt64("wait-for-traffic"); percent < 0 || percent > 100 {
+		return deployConfig{}, fmt.Errorf("invalid value '%v' for --wait-for-traffic: must be a percent between 0 and 100", percent)
+	}
+
+	if timeout := viper.GetInt64("request-timeout"); timeout != 0 {
+		if err = fn.ValidateRequestTimeout(timeout); err != nil {
+			return deployConfig{}, fmt.Errorf("invalid value '%v' for --request-timeout: %v", timeout, err)
+		}
+	}
+
+	scaleDurations := []struct {
+		flag     string
+		validate func(string) error
+	}{
+		{"scale-window", fn.ValidateScaleWindow},
+		{"scale-down-delay", fn.ValidateScaleDownDelay},
+		{"scale-retention-period", fn.ValidateRetentionPeriod},
+	}
+	for _, d := range scaleDurations {
+		if value := viper.GetString(d.flag); value != "" {
+			if err = d.validate(value); err != nil {
+				return deployConfig{}, fmt.Errorf("invalid value '%v' for --%v: %v", value, d.flag, err)
+			}
+		}
+	}
+
+	if viper.GetBool("use-status-digest") {
+		switch {
+		case viper.GetBool("remote") || viper.GetString("source-archive") != "":
+			return deployConfig{}, fmt.Errorf("--use-status-digest is not supported with --remote or --source-archive, which build the function")
+		case viper.GetString("image") != "":
+			return deployConfig{}, fmt.Errorf("--use-status-digest conflicts with --image")
+		}
+	}
+	if viper.GetBool("if-changed") && (viper.GetBool("remote") || viper.GetString("source-archive") != "") {
+		return deployConfig{}, fmt.Errorf("--if-changed is not supported with --remote or --source-archive")
+	}
+	if viper.GetBool("no-retry-conflict") && (viper.GetBool("remote") || viper.GetString("source-archive") != "") {
+		return deployConfig{}, fmt.Errorf("--no-retry-conflict is not supported with --remote or --source-archive")
+	}
+
+	if viper.GetString("readiness-check") != "" {
+		switch {
+		case viper.GetBool("no-status"):
+			return deployConfig{}, fmt.Errorf("--readiness-check is not supported with --no-status, the URL checked being that of the status recorded")
+		case dryRun != knative.DryRunNone:
+			return deployConfig{}, fmt.Errorf("--readiness-check is not supported with --dry-run")
+		case viper.GetString("source-archive") != "":
+			return deployConfig{}, fmt.Errorf("--readiness-check is not supported with --source-archive")
+		case viper.GetDuration("readiness-check-timeout") <= 0:
+			return deployConfig{}, fmt.Errorf("invalid value '%v' for --readiness-check-timeout: must be positive", viper.GetDuration("readiness-check-timeout"))
+		}
+	} else if viper.GetBool("rollback-on-failure") {
+		return deployConfig{}, fmt.Errorf("--rollback-on-failure requires --readiness-check")
+	}
+
+	liveness, err := probeFromFlags("liveness")
+	if err != nil {
+		return deployConfig{}, err
+	}
+	readiness, err := probeFromFlags("readiness")
+	if err != nil {
+		return deployConfig{}, err
+	}
+
 	return deployConfig{
-		buildConfig: newBuildConfig(),
-		Namespace:   viper.GetString("namespace"),
-		Path:        viper.GetString("path"),
-		Verbose:     viper.GetBool("verbose"), // defined on root
-		Confirm:     viper.GetBool("confirm"),
-		Build:       viper.GetBool("build"),
-		EnvToUpdate: envToUpdate,
-		EnvToRemove: envToRemove,
+		buildConfig:           newBuildConfig(),
+		Namespace:             viper.GetString("namespace"),
+		Path:                  viper.GetString("path"),
+		Verbose:               viper.GetBool("verbose"), // defined on root
+		Confirm:               viper.GetBool("confirm"),
+		Build:                 viper.GetBool("build") && !viper.GetBool("use-status-digest"),
+		Push:                  viper.GetBool("push") && !viper.GetBool("use-status-digest"),
+		UseStatusDigest:       viper.GetBool("use-status-digest"),
+		ImageDigest:           viper.GetBool("image-digest"),
+		NoStatus:              viper.GetBool("no-status"),
+		Output:                viper.GetString("output"),
+		DryRun:                dryRun,
+		CreateNamespace:       viper.GetBool("create-namespace"),
+		Replace:               viper.GetBool("replace"),
+		IfChanged:             viper.GetBool("if-changed"),
+		ForceLocked:           viper.GetBool("force-locked"),
+		NoRetryConflict:       viper.GetBool("no-retry-conflict"),
+		WaitCondition:         viper.GetString("wait-condition"),
+		WaitForTraffic:        viper.GetInt64("wait-for-traffic"),
+		SinkFrom:              sinkFrom,
+		Message:               viper.GetString("message"),
+		Remote:                viper.GetBool("remote"),
+		GitURL:                viper.GetString("git-url"),
+		GitBranch:             viper.GetString("git-branch"),
+		SourceArchive:         viper.GetString("source-archive"),
+		Timeout:               viper.GetDuration("timeout"),
+		PullSecret:            viper.GetString("pull-secret"),
+		ServiceAccount:        viper.GetString("service-account"),
+		ImagePullPolicy:       viper.GetString("image-pull-policy"),
+		Mesh:                  viper.GetString("mesh"),
+		Port:                  viper.GetInt("port"),
+		Metrics:               fn.Metrics{Port: viper.GetInt("metrics-port"), Path: viper.GetString("metrics-path")},
+		Tracing:               fn.Tracing{Endpoint: viper.GetString("tracing-endpoint"), ServiceName: viper.GetString("tracing-service-name")},
+		InitName:              viper.GetString("init-name"),
+		InitImage:             viper.GetString("init-image"),
+		InitCommand:           viper.GetString("init-command"),
+		IngressClass:          viper.GetString("ingress-class"),
+		Domain:                viper.GetString("domain"),
+		RevisionName:          viper.GetString("revision-name"),
+		TrafficTag:            viper.GetString("tag"),
+		Environment:           viper.GetString("environment"),
+		Health:                fn.Health{Liveness: liveness, Readiness: readiness},
+		RequestTimeout:        viper.GetInt64("request-timeout"),
+		ReadinessCheck:        viper.GetString("readiness-check"),
+		ReadinessCheckTimeout: viper.GetDuration("readiness-check-timeout"),
+		RollbackOnFailure:     viper.GetBool("rollback-on-failure"),
+		ScaleWindow:           viper.GetString("scale-window"),
+		ScaleDownDelay:        viper.GetString("scale-down-delay"),
+		ScaleRetentionPeriod:  viper.GetString("scale-retention-period"),
+		EnvToUpdate:           envToUpdate,
+		EnvToRemove:           envToRemove,
+		EnvFile:               viper.GetString("env-file"),
+		SaveEnv:               viper.GetBool("save-env"),
 	}, nil
 }
 
+// deployDryRun returns the given dry run mode if it is one of those supported:
+// "plan", printing the plan of the deploy as does the global --dry-run of
+// other commands, or one of the knative.DryRunModes.  As the global flag, it
+// may also be given as a boolean, true being the plan and false none.
+func deployDryRun(mode string) (string, error) {
+	switch mode {
+	case "true":
+		return dryRunPlan, nil
+	case "false":
+		return knative.DryRunNone, nil
+	}
+	modes := append([]string{dryRunPlan}, knative.DryRunModes...)
+	for _, m := range modes {
+		if mode == m {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("invalid value '%v' for --dry-run. Must be one of: %v", mode, strings.Join(modes, ", "))
+}
+
+// probeFromFlags returns the settings of the named health probe provided by
+// its flags, such as --liveness-path for the "liveness" probe, or nil if none
+// are provided.
+func probeFromFlags(name string) (*fn.Probe, error) {
+	var (
+		probe  fn.Probe
+		path   = viper.GetString(name + "-path")
+		delay  = viper.GetInt32(name + "-initial-delay")
+		period = viper.GetInt32(name + "-period")
+	)
+	if path != "" {
+		if err := fn.ValidateProbePath(path); err != nil {
+			return nil, fmt.Errorf("invalid value '%v' for --%v-path: %v", path, name, err)
+		}
+		probe.Path = &path
+	}
+	if delay < 0 {
+		return nil, fmt.Errorf("invalid value '%v' for --%v-initial-delay: must not be negative", delay, name)
+	}
+	if delay > 0 {
+		probe.InitialDelaySeconds = &delay
+	}
+	if period < 0 {
+		return nil, fmt.Errorf("invalid value '%v' for --%v-period: must not be negative", period, name)
+	}
+	if period > 0 {
+		probe.PeriodSeconds = &period
+	}
+	if probe == (fn.Probe{}) {
+		return nil, nil
+	}
+	return &probe, nil
+}
+
+// mergeProbe returns the probe with the settings provided applied.
+func mergeProbe(probe, provided *fn.Probe) *fn.Probe {
+	if provided == nil {
+		return probe
+	}
+	if probe == nil {
+		probe = &fn.Probe{}
+	}
+	if provided.Path != nil {
+		probe.Path = provided.Path
+	}
+	if provided.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = provided.InitialDelaySeconds
+	}
+	if provided.PeriodSeconds != nil {
+		probe.PeriodSeconds = provided.PeriodSeconds
+	}
+	return probe
+}
+
+// mergeAutoscaling returns the autoscaling with the durations provided
+// applied, those provided empty being unset such that those of the cluster
+// apply.  Once set, the autoscaling is kept even when empty, such that the
+// annotations of those unset are removed from the deployed Service.
+func mergeAutoscaling(cmd *cobra.Command, scale *fn.ScaleOptions, config deployConfig) *fn.ScaleOptions {
+	durations := []struct {
+		flag  string
+		value string
+		field func(*fn.ScaleOptions) **string
+	}{
+		{"scale-window", config.ScaleWindow, func(s *fn.ScaleOptions) **string { return &s.Window }},
+		{"scale-down-delay", config.ScaleDownDelay, func(s *fn.ScaleOptions) **string { return &s.ScaleDownDelay }},
+		{"scale-retention-period", config.ScaleRetentionPeriod, func(s *fn.ScaleOptions) **string { return &s.RetentionPeriod }},
+	}
+	for _, d := range durations {
+		if d.value == "" && !cmd.Flags().Changed(d.flag) {
+			continue
+		}
+		if scale == nil {
+			scale = &fn.ScaleOptions{}
+		}
+		field := d.field(scale)
+		if d.value == "" {
+			*field = nil
+		} else {
+			value := d.value
+			*field = &value
+		}
+	}
+	return scale
+}
+
+// mergeInitContainers returns the init containers updated per the --init-*
+// flags: that named by --init-name is added or updated with the image,
+// command and envs provided, or removed if --init-image is provided empty.
+func mergeInitContainers(cmd *cobra.Command, containers []fn.InitContainer, config deployConfig) ([]fn.InitContainer, error) {
+	if !cmd.Flags().Changed("init-image") && !cmd.Flags().Changed("init-command") && !cmd.Flags().Changed("init-env") &&
+		config.InitImage == "" && config.InitCommand == "" {
+		return containers, nil
+	}
+	merged := make([]fn.InitContainer, 0, len(containers)+1)
+	index := -1
+	for _, c := range containers {
+		if c.Name == config.InitName {
+			index = len(merged)
+		}
+		merged = append(merged, c)
+	}
+	if config.InitImage == "" && cmd.Flags().Changed("init-image") {
+		if index >= 0 {
+			merged = append(merged[:index], merged[index+1:]...)
+		}
+		return merged, nil
+	}
+	if index < 0 {
+		merged = append(merged, fn.InitContainer{Name: config.InitName})
+		index = len(merged) - 1
+	}
+	c := merged[index]
+	if config.InitImage != "" {
+		c.Image = config.InitImage
+	}
+	if config.InitCommand != "" || cmd.Flags().Changed("init-command") {
+		c.Command = strings.Fields(config.InitCommand)
+	}
+	envToUpdate, envToRemove, err := envFromFlag(cmd, "init-env")
+	if err != nil {
+		return nil, err
+	}
+	if c.Envs, err = mergeEnvs(append(fn.Envs{}, c.Envs...), envToUpdate, envToRemove); err != nil {
+		return nil, err
+	}
+	if c.Image == "" {
+		return nil, fmt.Errorf("the init container '%v' has no image. Provide --init-image", c.Name)
+	}
+	merged[index] = c
+	if errs := fn.ValidateInitContainers(merged); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid init container: %v", strings.Join(errs, "; "))
+	}
+	return merged, nil
+}
+
+// validateObjectName ensures the name given by the flag, if any, is a valid
+// Kubernetes object name, such as that of a Secret or ServiceAccount.
+func validateObjectName(flag, name string) error {
+	if name == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid value '%v' for --%v: %v", name, flag, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
 // Prompt the user with value of config members, allowing for interaractive changes.
 // Skipped if not in an interactive terminal (non-TTY), or if --yes (agree to
 // all prompts) was explicitly set.
@@ -300,26 +1450,30 @@ func (c deployConfig) Prompt() (deployConfig, error) {
 			Validate: survey.Required,
 		},
 	}
-	answers := struct {
-		Registry  string
-		Namespace string
-		Path      string
-	}{}
+	answers := deployAnswers{}
 	err := survey.Ask(qs, &answers)
 	if err != nil {
 		return deployConfig{}, err
 	}
 
-	dc := deployConfig{
-		buildConfig: buildConfig{
-			Registry: answers.Registry,
-		},
-		Namespace: answers.Namespace,
-		Path:      answers.Path,
-		Verbose:   c.Verbose,
-	}
-
+	dc := c.withAnswers(answers)
 	dc.Image = deriveImage(dc.Image, dc.Registry, dc.Path)
 
 	return dc, nil
 }
+
+// deployAnswers are the members of the deploy config which are prompted for.
+type deployAnswers struct {
+	Registry  string
+	Namespace string
+	Path      string
+}
+
+// withAnswers returns the config updated with the given answers, all other
+// members carried through as given.
+func (c deployConfig) withAnswers(answers deployAnswers) deployConfig {
+	c.Registry = answers.Registry
+	c.Namespace = answers.Namespace
+	c.Path = answers.Path
+	return c
+}
@@ -0,0 +1,1083 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/mock"
+)
+
+// testDeploy is a function deployed by the deploy command with mocks, which
+// records the config, output, and the function built and deployed, of its
+// last deploy.
+type testDeploy struct {
+	t         *testing.T
+	root      string
+	builder   *mock.Builder
+	pusher    *mock.Pusher
+	deployer  *mock.Deployer
+	pipelines *mock.PipelinesProvider
+	config    deployConfig
+	built     fn.Function
+	deployed  fn.Function
+	out       bytes.Buffer
+}
+
+// newTestDeploy changes to a temporary directory holding a function with an
+// image, to be deployed with mocks.
+func newTestDeploy(t *testing.T) *testDeploy {
+	t.Helper()
+	t.Cleanup(fromTempDir(t))
+	d := &testDeploy{
+		t:         t,
+		root:      pwd(t),
+		builder:   mock.NewBuilder(),
+		pusher:    mock.NewPusher(),
+		deployer:  mock.NewDeployer(),
+		pipelines: mock.NewPipelinesProvider(),
+	}
+	d.builder.BuildFn = func(f fn.Function) error {
+		d.built = f
+		return nil
+	}
+	d.deployer.DeployFn = func(f fn.Function) error {
+		d.deployed = f
+		return nil
+	}
+	d.writeConfig("name: myfunc\nruntime: go\nimage: example.com/alice/myfunc:latest\n")
+	return d
+}
+
+// writeConfig replaces the func.yaml of the function.
+func (d *testDeploy) writeConfig(funcYaml string) {
+	d.t.Helper()
+	if err := ioutil.WriteFile(filepath.Join(d.root, "func.yaml"), []byte(funcYaml), 0644); err != nil {
+		d.t.Fatal(err)
+	}
+}
+
+// command returns a deploy command of a client of the mocks.
+func (d *testDeploy) command() *cobra.Command {
+	cmd := NewDeployCmd(func(config deployConfig, listener fn.ProgressListener) (*fn.Client, error) {
+		d.config = config
+		return fn.New(
+			fn.WithBuilder(d.builder),
+			fn.WithPusher(d.pusher),
+			fn.WithDeployer(d.deployer),
+			fn.WithPipelinesProvider(d.pipelines),
+			fn.WithPush(config.Push),
+			fn.WithEnvironment(config.Environment),
+			fn.WithDeployEnvs(config.DeployEnvs),
+			fn.WithProgressListener(listener)), nil
+	})
+	cmd.SetOut(&d.out)
+	return cmd
+}
+
+// deploy the function with the given arguments.
+func (d *testDeploy) deploy(args ...string) error {
+	d.config, d.built, d.deployed = deployConfig{}, fn.Function{}, fn.Function{}
+	d.out.Reset()
+	cmd := d.command()
+	cmd.SetArgs(append([]string{"-p", d.root}, args...))
+	return cmd.Execute()
+}
+
+// function reads the func.yaml of the function.
+func (d *testDeploy) function() fn.Function {
+	d.t.Helper()
+	f, err := fn.NewFunction(d.root)
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	return f
+}
+
+// TestDeployCmdWithMocks ensures the function is built, pushed and deployed
+// via the client returned by the given client factory.
+func TestDeployCmdWithMocks(t *testing.T) {
+	d := newTestDeploy(t)
+	if err := d.deploy("-n", "apps"); err != nil {
+		t.Fatal(err)
+	}
+	if d.config.Namespace != "apps" {
+		t.Fatalf("expected the client for namespace 'apps', got '%v'", d.config.Namespace)
+	}
+	if !d.builder.BuildInvoked || !d.pusher.PushInvoked || !d.deployer.DeployInvoked {
+		t.Fatalf("expected the function to be built, pushed and deployed, got build=%v push=%v deploy=%v",
+			d.builder.BuildInvoked, d.pusher.PushInvoked, d.deployer.DeployInvoked)
+	}
+	if d.deployed.Name != "myfunc" || d.deployed.Namespace != "apps" {
+		t.Fatalf("expected 'myfunc' to be deployed to 'apps', got '%v' in '%v'", d.deployed.Name, d.deployed.Namespace)
+	}
+}
+
+// TestDeployCmdWithoutBuild ensures that with --build=false the builder is
+// not invoked, the image being pushed unless --push=false, and that the
+// function must then already have an image.
+func TestDeployCmdWithoutBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		funcYaml string
+		args     []string
+		push     bool
+		err      bool
+	}{
+		{"push", "name: myfunc\nruntime: go\nimage: example.com/alice/myfunc:latest\n", []string{"--build=false"}, true, false},
+		{"prebuilt", "name: myfunc\nruntime: go\n", []string{"--build=false", "--push=false", "--image", "example.com/alice/myfunc:v1"}, false, false},
+		{"no image", "name: myfunc\nruntime: go\n", []string{"--build=false"}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDeploy(t)
+			d.writeConfig(tt.funcYaml)
+
+			err := d.deploy(tt.args...)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error deploying without building a function without an image")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.builder.BuildInvoked {
+				t.Fatal("expected the builder not to be invoked with --build=false")
+			}
+			if d.pusher.PushInvoked != tt.push {
+				t.Fatalf("expected push invoked to be %v, got %v", tt.push, d.pusher.PushInvoked)
+			}
+			if !d.deployer.DeployInvoked {
+				t.Fatal("expected the function to be deployed")
+			}
+		})
+	}
+}
+
+// TestDeployCmdDryRunClient ensures that --dry-run=client, given where func
+// defines --dry-run as a flag of every command, renders the Knative Service
+// without access to a cluster, changing nothing.
+func TestDeployCmdDryRunClient(t *testing.T) {
+	d := newTestDeploy(t)
+	funcYaml := "name: myfunc\nruntime: go\n"
+	d.writeConfig(funcYaml)
+	defer os.Setenv("KUBECONFIG", os.Getenv("KUBECONFIG"))
+	os.Setenv("KUBECONFIG", filepath.Join(d.root, "nonexistent"))
+
+	cmd := withDryRun(t, d.command())
+	cmd.SetArgs([]string{"deploy", "-p", d.root, "--build=false", "--image", "example.com/alice/myfunc:v1", "--dry-run=client"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if d.deployer.DeployInvoked {
+		t.Fatal("expected the function not to be deployed")
+	}
+	if out := d.out.String(); !strings.Contains(out, "kind: Service") || !strings.Contains(out, "image: example.com/alice/myfunc:v1") {
+		t.Fatalf("expected the Knative Service to be rendered, got:\n%v", out)
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join(d.root, "func.yaml")); string(content) != funcYaml {
+		t.Fatalf("expected func.yaml not to be written, got:\n%s", content)
+	}
+}
+
+// TestDeployCmdValidate ensures the function is validated as a whole, its
+// name included, before it is deployed.
+func TestDeployCmdValidate(t *testing.T) {
+	d := newTestDeploy(t)
+	d.writeConfig("name: My_Func\nruntime: go\n")
+
+	err := d.deploy("--build=false", "--push=false", "--image", "example.com/alice/myfunc:v1")
+	var verr fn.ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Field != "name" {
+		t.Fatalf("expected the name to be invalid, got %v", err)
+	}
+	if d.deployer.DeployInvoked {
+		t.Fatal("expected an invalid function not to be deployed")
+	}
+}
+
+// TestDeployCmdRemote ensures that with --remote the function is built on
+// the cluster from its git repository, which is persisted, rather than
+// built and pushed locally.
+func TestDeployCmdRemote(t *testing.T) {
+	d := newTestDeploy(t)
+	d.writeConfig("name: myfunc\nruntime: go\nregistry: quay.io/alice\n")
+	d.pipelines.RunFn = func(f fn.Function) error {
+		if f.Git.URL != "https://github.com/alice/myfunc.git" || f.Git.Revision != "main" {
+			t.Fatalf("expected the git repository to be built, got %+v", f.Git)
+		}
+		return nil
+	}
+
+	if err := d.deploy("--remote", "--git-url", "https://github.com/alice/myfunc.git", "--git-branch", "main"); err != nil {
+		t.Fatal(err)
+	}
+	if !d.pipelines.RunInvoked || !d.deployer.DeployInvoked {
+		t.Fatal("expected the function to be built on the cluster and deployed")
+	}
+	if d.builder.BuildInvoked || d.pusher.PushInvoked {
+		t.Fatal("expected the function not to be built or pushed locally")
+	}
+	if f := d.function(); f.Git.URL != "https://github.com/alice/myfunc.git" {
+		t.Fatalf("expected the git repository to be persisted, got %+v", f.Git)
+	}
+}
+
+// TestDeployCmdImageDigest ensures that the reference by digest of the image
+// pushed and deployed is printed with --image-digest.
+func TestDeployCmdImageDigest(t *testing.T) {
+	d := newTestDeploy(t)
+	const digest = "sha256:a278a91112d17f8bde6b5f802a3317c7c752cf88078dae6f4b5a0784deb81782"
+	d.pusher.PushFn = func(fn.Function) (string, error) { return digest, nil }
+
+	if err := d.deploy("--build=false", "--image-digest"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "example.com/alice/myfunc@" + digest; !strings.Contains(d.out.String(), expected) {
+		t.Fatalf("expected '%v' to be printed, got %q", expected, d.out.String())
+	}
+}
+
+// TestDeployCmdUseStatusDigest ensures that with --use-status-digest the
+// image recorded by digest in the status of the function is deployed, as
+// referenced by its Service, without being built or pushed, and that a
+// function without one is not deployed.
+func TestDeployCmdUseStatusDigest(t *testing.T) {
+	d := newTestDeploy(t)
+	const image = "example.com/alice/myfunc@sha256:a278a91112d17f8bde6b5f802a3317c7c752cf88078dae6f4b5a0784deb81782"
+	d.writeConfig("name: myfunc\nruntime: go\nimage: example.com/alice/myfunc:latest\nstatus:\n  image: " + image + "\n")
+
+	if err := d.deploy("--use-status-digest"); err != nil {
+		t.Fatal(err)
+	}
+	if d.builder.BuildInvoked || d.pusher.PushInvoked {
+		t.Fatal("expected the image of the status not to be built or pushed")
+	}
+	if d.deployed.ImageWithDigest() != image {
+		t.Fatalf("expected the Service to reference the image %v, got %v", image, d.deployed.ImageWithDigest())
+	}
+
+	if err := d.deploy("--use-status-digest", "--image", "example.com/alice/myfunc:v1"); err == nil {
+		t.Fatal("expected --use-status-digest to conflict with --image")
+	}
+
+	d.writeConfig("name: myfunc\nruntime: go\nimage: example.com/alice/myfunc:latest\nstatus:\n  image: example.com/alice/myfunc:latest\n")
+	if err := d.deploy("--use-status-digest"); err == nil || !strings.Contains(err.Error(), "no image digest recorded") {
+		t.Fatalf("expected an error deploying without a digest recorded, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected the function not to be deployed")
+	}
+}
+
+// TestDeployCmdDomain ensures the domain provided is deployed and persisted,
+// and is removed when provided as empty.
+func TestDeployCmdDomain(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--domain", "myfunc.example.com"); err != nil || d.deployed.Domain != "myfunc.example.com" {
+		t.Fatalf("expected the domain to be deployed, got '%v' (%v)", d.deployed.Domain, err)
+	}
+	if err := d.deploy(); err != nil || d.deployed.Domain != "myfunc.example.com" {
+		t.Fatalf("expected the persisted domain to be deployed, got '%v' (%v)", d.deployed.Domain, err)
+	}
+	if err := d.deploy("--domain", ""); err != nil || d.deployed.Domain != "" {
+		t.Fatalf("expected the domain to be removed, got '%v' (%v)", d.deployed.Domain, err)
+	}
+	if f := d.function(); f.Domain != "" {
+		t.Fatalf("expected the removal of the domain to be persisted, got '%v'", f.Domain)
+	}
+
+	if err := d.deploy("--domain", "https://myfunc.example.com"); err == nil || !strings.Contains(err.Error(), "--domain") {
+		t.Fatalf("expected an error for the invalid domain, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected an invalid domain to fail before deploying")
+	}
+}
+
+// TestDeployCmdRevision ensures the revision name template and traffic tag
+// provided are deployed and persisted, and that an invalid revision name
+// fails before deploying.
+func TestDeployCmdRevision(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--revision-name", "{{.Service}}-v{{.Generation}}", "--tag", "green"); err != nil {
+		t.Fatal(err)
+	}
+	if d.deployed.RevisionName != "{{.Service}}-v{{.Generation}}" || d.deployed.TrafficTag != "green" {
+		t.Fatalf("expected the revision name and tag to be deployed, got '%v' and '%v'", d.deployed.RevisionName, d.deployed.TrafficTag)
+	}
+	if f := d.function(); f.RevisionName != d.deployed.RevisionName || f.TrafficTag != "green" {
+		t.Fatalf("expected the revision name and tag to be persisted, got '%v' and '%v'", f.RevisionName, f.TrafficTag)
+	}
+
+	if err := d.deploy("--revision-name", "{{.Service}}_v1"); err == nil || !strings.Contains(err.Error(), "--revision-name") {
+		t.Fatalf("expected an error for the invalid revision name, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected an invalid revision name to fail before deploying")
+	}
+}
+
+// TestDeployCmdLifecycle ensures that the lifecycle image and platform API
+// are passed to the builder and persisted, and that an invalid platform API
+// fails before building.
+func TestDeployCmdLifecycle(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--lifecycle-image", "buildpacksio/lifecycle:0.11.1", "--platform-api", "0.4"); err != nil {
+		t.Fatal(err)
+	}
+	f := d.function()
+	if d.built.LifecycleImage != "buildpacksio/lifecycle:0.11.1" || d.built.PlatformAPI != "0.4" ||
+		f.LifecycleImage != d.built.LifecycleImage || f.PlatformAPI != d.built.PlatformAPI {
+		t.Fatalf("expected the lifecycle to be built with and persisted, got %+v", f)
+	}
+
+	if err := d.deploy("--platform-api", "v0.4"); err == nil || !strings.Contains(err.Error(), "--platform-api") {
+		t.Fatalf("expected an error for the invalid platform API, got %v", err)
+	}
+	if d.built.Name != "" {
+		t.Fatal("expected the function not to be built with an invalid platform API")
+	}
+}
+
+// TestDeployCmdBuilderPullPolicy ensures that the builder pull policy is
+// passed to the builder and persisted, later builds using that persisted, and
+// that an invalid policy fails before building.
+func TestDeployCmdBuilderPullPolicy(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--builder-pull-policy", "never"); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); d.built.BuilderPullPolicy != "never" || f.BuilderPullPolicy != "never" {
+		t.Fatalf("expected the builder pull policy to be built with and persisted, got '%v' and '%v'", d.built.BuilderPullPolicy, f.BuilderPullPolicy)
+	}
+
+	if err := d.deploy(); err != nil {
+		t.Fatal(err)
+	}
+	if d.built.BuilderPullPolicy != "never" {
+		t.Fatalf("expected the persisted builder pull policy to be built with, got '%v'", d.built.BuilderPullPolicy)
+	}
+
+	if err := d.deploy("--builder-pull-policy", "sometimes"); err == nil || !strings.Contains(err.Error(), "--builder-pull-policy") {
+		t.Fatalf("expected an error for the invalid builder pull policy, got %v", err)
+	}
+	if d.built.Name != "" {
+		t.Fatal("expected the function not to be built with an invalid builder pull policy")
+	}
+}
+
+// TestDeployCmdImagePullPolicy ensures that the image pull policy is deployed
+// and persisted, that an empty value removes it, and that an invalid policy
+// fails before deploying.
+func TestDeployCmdImagePullPolicy(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--image-pull-policy", "Never"); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); d.deployed.ImagePullPolicy != "Never" || f.ImagePullPolicy != "Never" {
+		t.Fatalf("expected the image pull policy to be deployed and persisted, got '%v' and '%v'", d.deployed.ImagePullPolicy, f.ImagePullPolicy)
+	}
+
+	if err := d.deploy("--image-pull-policy", ""); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); f.ImagePullPolicy != "" {
+		t.Fatalf("expected the image pull policy to be removed, got '%v'", f.ImagePullPolicy)
+	}
+
+	if err := d.deploy("--image-pull-policy", "Sometimes"); err == nil || !strings.Contains(err.Error(), "--image-pull-policy") {
+		t.Fatalf("expected an error for the invalid image pull policy, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected an invalid image pull policy to fail before deploying")
+	}
+}
+
+// TestDeployCmdMesh ensures that the mesh is deployed and persisted, that an
+// empty value removes it, and that a mesh not known fails before deploying.
+func TestDeployCmdMesh(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--mesh", "istio"); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); d.deployed.Mesh != "istio" || f.Mesh != "istio" {
+		t.Fatalf("expected the mesh to be deployed and persisted, got '%v' and '%v'", d.deployed.Mesh, f.Mesh)
+	}
+
+	if err := d.deploy("--mesh", ""); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); f.Mesh != "" {
+		t.Fatalf("expected the mesh to be removed, got '%v'", f.Mesh)
+	}
+
+	if err := d.deploy("--mesh", "consul"); err == nil || !strings.Contains(err.Error(), "--mesh") {
+		t.Fatalf("expected an error for the unknown mesh, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected an unknown mesh to fail before deploying")
+	}
+}
+
+// TestDeployCmdMetrics ensures that the metrics endpoint is deployed and
+// persisted, that 0 removes it, and that a path without a port fails before
+// deploying.
+func TestDeployCmdMetrics(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--metrics-port", "9095", "--metrics-path", "/q/metrics"); err != nil {
+		t.Fatal(err)
+	}
+	expected := fn.Metrics{Port: 9095, Path: "/q/metrics"}
+	if f := d.function(); d.deployed.Metrics != expected || f.Metrics != expected {
+		t.Fatalf("expected the metrics endpoint to be deployed and persisted, got %+v and %+v", d.deployed.Metrics, f.Metrics)
+	}
+
+	if err := d.deploy("--metrics-port", "0", "--metrics-path", ""); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); f.Metrics != (fn.Metrics{}) {
+		t.Fatalf("expected the metrics endpoint to be removed, got %+v", f.Metrics)
+	}
+
+	if err := d.deploy("--metrics-path", "/metrics"); err == nil || !strings.Contains(err.Error(), "the metrics port must be set") {
+		t.Fatalf("expected an error for the path without a port, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected a path without a port to fail before deploying")
+	}
+}
+
+// TestDeployCmdTracing ensures that the tracing is deployed and persisted,
+// that empty values remove it, and that an endpoint which is not a URL fails
+// before deploying.
+func TestDeployCmdTracing(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--tracing-endpoint", "http://otel-collector:4317", "--tracing-service-name", "orders"); err != nil {
+		t.Fatal(err)
+	}
+	expected := fn.Tracing{Endpoint: "http://otel-collector:4317", ServiceName: "orders"}
+	if f := d.function(); d.deployed.Tracing != expected || f.Tracing != expected {
+		t.Fatalf("expected the tracing to be deployed and persisted, got %+v and %+v", d.deployed.Tracing, f.Tracing)
+	}
+
+	if err := d.deploy("--tracing-endpoint", "", "--tracing-service-name", ""); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); f.Tracing != (fn.Tracing{}) {
+		t.Fatalf("expected the tracing to be removed, got %+v", f.Tracing)
+	}
+
+	if err := d.deploy("--tracing-endpoint", "otel-collector:4317"); err == nil || !strings.Contains(err.Error(), "invalid value 'otel-collector:4317' for --tracing-endpoint") {
+		t.Fatalf("expected an error for the endpoint which is not a URL, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected an invalid endpoint to fail before deploying")
+	}
+}
+
+// TestDeployCmdEnvFile ensures that the envs of --env-file are deployed,
+// those of --env taking precedence over them and they over those of
+// func.yaml, and that they are stored in func.yaml only with --save-env.
+func TestDeployCmdEnvFile(t *testing.T) {
+	d := newTestDeploy(t)
+	d.writeConfig("name: myfunc\nruntime: go\nimage: example.com/alice/myfunc:latest\nenvs:\n- name: GREETING\n  value: Hi\n- name: LEVEL\n  value: info\n")
+	envFile := "# Settings\nGREETING=\"Hello, World\"\nNAME=alice\nLEVEL=debug\n"
+	if err := ioutil.WriteFile(filepath.Join(d.root, ".env"), []byte(envFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	envs := func(envs fn.Envs) map[string]string {
+		m := map[string]string{}
+		for _, e := range envs {
+			m[*e.Name] = *e.Value
+		}
+		return m
+	}
+
+	if err := d.deploy("--env-file", ".env", "--env", "LEVEL=warn"); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"GREETING": "Hello, World", "NAME": "alice", "LEVEL": "warn"}
+	if !reflect.DeepEqual(envs(d.deployed.Envs), expected) {
+		t.Fatalf("expected the envs %v to be deployed, got %v", expected, envs(d.deployed.Envs))
+	}
+	expected = map[string]string{"GREETING": "Hi", "LEVEL": "warn"}
+	if f := d.function(); !reflect.DeepEqual(envs(f.Envs), expected) {
+		t.Fatalf("expected the envs of the file not to be stored, got %v", envs(f.Envs))
+	}
+
+	if err := d.deploy("--env-file", ".env", "--save-env", "--env", "NAME-"); err != nil {
+		t.Fatal(err)
+	}
+	expected = map[string]string{"GREETING": "Hello, World", "LEVEL": "debug"}
+	if f := d.function(); !reflect.DeepEqual(envs(f.Envs), expected) || !reflect.DeepEqual(envs(d.deployed.Envs), expected) {
+		t.Fatalf("expected the envs %v to be stored and deployed, got %v and %v", expected, envs(f.Envs), envs(d.deployed.Envs))
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(d.root, ".env"), []byte("1NAME=alice\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.deploy("--env-file", ".env"); err == nil || !strings.Contains(err.Error(), "invalid value '.env' for --env-file") {
+		t.Fatalf("expected an invalid env file to be an error, got %v", err)
+	}
+}
+
+// TestDeployCmdInitContainer ensures that the init container is deployed and
+// persisted, updated in place, removed by an empty image, and that an invalid
+// image fails before deploying.
+func TestDeployCmdInitContainer(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--init-name", "migrate", "--init-image", "example.com/alice/migrate:v1", "--init-command", "migrate up", "--init-env", "DB_URL=postgres://db"); err != nil {
+		t.Fatal(err)
+	}
+	f := d.function()
+	if len(d.deployed.InitContainers) != 1 || !reflect.DeepEqual(d.deployed.InitContainers, f.InitContainers) {
+		t.Fatalf("expected the init container to be deployed and persisted, got %+v and %+v", d.deployed.InitContainers, f.InitContainers)
+	}
+	c := f.InitContainers[0]
+	if c.Name != "migrate" || c.Image != "example.com/alice/migrate:v1" || !reflect.DeepEqual(c.Command, []string{"migrate", "up"}) ||
+		len(c.Envs) != 1 || *c.Envs[0].Name != "DB_URL" || *c.Envs[0].Value != "postgres://db" {
+		t.Fatalf("unexpected init container %+v", c)
+	}
+
+	if err := d.deploy("--init-name", "migrate", "--init-image", "example.com/alice/migrate:v2"); err != nil {
+		t.Fatal(err)
+	}
+	if f = d.function(); len(f.InitContainers) != 1 || f.InitContainers[0].Image != "example.com/alice/migrate:v2" || len(f.InitContainers[0].Command) != 2 {
+		t.Fatalf("expected the image of the init container alone to be updated, got %+v", f.InitContainers)
+	}
+
+	if err := d.deploy("--init-name", "migrate", "--init-image", ""); err != nil {
+		t.Fatal(err)
+	}
+	if f = d.function(); len(f.InitContainers) != 0 {
+		t.Fatalf("expected the init container to be removed, got %+v", f.InitContainers)
+	}
+
+	if err := d.deploy("--init-command", "seed"); err == nil || !strings.Contains(err.Error(), "has no image") {
+		t.Fatalf("expected an error for the init container without an image, got %v", err)
+	}
+	if err := d.deploy("--init-image", "example.com/alice/Migrate:v1"); err == nil || !strings.Contains(err.Error(), "invalid value 'example.com/alice/Migrate:v1' for --init-image") {
+		t.Fatalf("expected an error for the invalid image, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected an invalid image to fail before deploying")
+	}
+}
+
+// TestDeployCmdPort ensures that the port is deployed and persisted, that 0
+// removes it, and that a port out of range fails before deploying.
+func TestDeployCmdPort(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--port", "3000"); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); d.deployed.Port != 3000 || f.Port != 3000 {
+		t.Fatalf("expected the port to be deployed and persisted, got %v and %v", d.deployed.Port, f.Port)
+	}
+
+	if err := d.deploy("--port", "0"); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); f.Port != 0 {
+		t.Fatalf("expected the port to be removed, got %v", f.Port)
+	}
+
+	if err := d.deploy("--port", "70000"); err == nil || !strings.Contains(err.Error(), "--port") {
+		t.Fatalf("expected an error for the port out of range, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected a port out of range to fail before deploying")
+	}
+}
+
+// TestDeployCmdResources ensures that resource requests and limits, such as
+// of the extended resource nvidia.com/gpu, are deployed and persisted, that
+// NAME- removes them, and that invalid resources fail before deploying.
+func TestDeployCmdResources(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--limits", "nvidia.com/gpu=1", "--limits", "memory=512Mi", "--requests", "cpu=500m"); err != nil {
+		t.Fatal(err)
+	}
+	r := d.function().Options.Resources
+	if r == nil || r.Limits == nil || r.Limits.Extended["nvidia.com/gpu"] != "1" || *r.Limits.Memory != "512Mi" || *r.Requests.CPU != "500m" {
+		t.Fatalf("expected the resources to be persisted, got %+v", r)
+	}
+	if d.deployed.Options.Resources.Limits.Extended["nvidia.com/gpu"] != "1" {
+		t.Fatal("expected the extended resource to be deployed")
+	}
+
+	if err := d.deploy("--limits", "nvidia.com/gpu-", "--limits", "memory-", "--requests", "cpu-"); err != nil {
+		t.Fatal(err)
+	}
+	if r = d.function().Options.Resources; r != nil {
+		t.Fatalf("expected the resources to be removed, got %+v", r)
+	}
+
+	for _, args := range [][]string{
+		{"--limits", "gpu=1"},
+		{"--limits", "nvidia.com/gpu=0.5"},
+		{"--requests", "nvidia.com/gpu=1"},
+		{"--requests", "cpu=lots"},
+	} {
+		if err := d.deploy(args...); err == nil || !strings.Contains(err.Error(), args[0]) {
+			t.Fatalf("expected an error for %v, got %v", args, err)
+		}
+		if d.deployed.Name != "" {
+			t.Fatal("expected invalid resources to fail before deploying")
+		}
+	}
+}
+
+// TestDeployCmdAutoscaling ensures the durations of the autoscaling are
+// deployed and persisted, that an empty value unsets one, and that those
+// which are not durations of the bounds of Knative fail.
+func TestDeployCmdAutoscaling(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--scale-window", "90s", "--scale-down-delay", "15m", "--scale-retention-period", "5m"); err != nil {
+		t.Fatal(err)
+	}
+	s := d.function().Options.Scale
+	if s == nil || *s.Window != "90s" || *s.ScaleDownDelay != "15m" || *s.RetentionPeriod != "5m" {
+		t.Fatalf("expected the autoscaling to be persisted, got %+v", s)
+	}
+	if d.deployed.Options.Scale == nil || *d.deployed.Options.Scale.ScaleDownDelay != "15m" {
+		t.Fatal("expected the scale down delay to be deployed")
+	}
+
+	if err := d.deploy("--scale-down-delay", ""); err != nil {
+		t.Fatal(err)
+	}
+	if s = d.function().Options.Scale; s.ScaleDownDelay != nil || s.Window == nil {
+		t.Fatalf("expected only the scale down delay to be unset, got %+v", s)
+	}
+
+	for _, args := range [][]string{
+		{"--scale-window", "5s"},
+		{"--scale-down-delay", "2h"},
+		{"--scale-retention-period", "5"},
+	} {
+		if err := d.deploy(args...); err == nil || !strings.Contains(err.Error(), args[0]) {
+			t.Fatalf("expected an error for %v, got %v", args, err)
+		}
+		if d.deployed.Name != "" {
+			t.Fatal("expected invalid durations to fail before deploying")
+		}
+	}
+}
+
+// TestDeployCmdIngressClass ensures that the ingress class is deployed and
+// persisted, that an empty value removes it, and that an invalid class fails.
+func TestDeployCmdIngressClass(t *testing.T) {
+	d := newTestDeploy(t)
+
+	class := "kourier.ingress.networking.knative.dev"
+	if err := d.deploy("--ingress-class", class); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); d.deployed.IngressClass != class || f.IngressClass != class {
+		t.Fatalf("expected the ingress class to be deployed and persisted, got '%v' and '%v'", d.deployed.IngressClass, f.IngressClass)
+	}
+
+	if err := d.deploy("--ingress-class", ""); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); f.IngressClass != "" {
+		t.Fatalf("expected the ingress class to be removed, got '%v'", f.IngressClass)
+	}
+
+	if err := d.deploy("--ingress-class", "not a class"); err == nil || !strings.Contains(err.Error(), "--ingress-class") {
+		t.Fatalf("expected an error for the invalid ingress class, got %v", err)
+	}
+}
+
+// TestDeployCmdVolume ensures that the volumes given are deployed and
+// persisted, replacing those at the same path, that those of a path followed
+// by "-" are removed, and that one not valid fails before deploying.
+func TestDeployCmdVolume(t *testing.T) {
+	d := newTestDeploy(t)
+	d.writeConfig("name: myfunc\nruntime: go\nimage: example.com/alice/myfunc:latest\nvolumes:\n- secret: old\n  path: /etc/config\n")
+
+	if err := d.deploy("--volume", "secret:my-secret:/etc/config", "--volume", "configMap:my-config:/etc/settings", "--volume", "emptyDir:/tmp/cache"); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`Secret "my-secret" mounted at path: "/etc/config"`,
+		`ConfigMap "my-config" mounted at path: "/etc/settings"`,
+		`EmptyDir mounted at path: "/tmp/cache"`,
+	}
+	for _, volumes := range []fn.Volumes{d.deployed.Volumes, d.function().Volumes} {
+		var got []string
+		for _, v := range volumes {
+			got = append(got, v.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+			t.Fatalf("expected the volumes to be deployed and persisted:\n%v\ngot:\n%v", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+		}
+	}
+
+	if err := d.deploy("--volume", "/etc/settings-", "--volume", "/tmp/cache-"); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); len(f.Volumes) != 1 || *f.Volumes[0].Secret != "my-secret" {
+		t.Fatalf("expected only the secret to remain mounted, got %v", f.Volumes)
+	}
+
+	if err := d.deploy("--volume", "secret:my-secret:etc/config"); err == nil || !strings.Contains(err.Error(), "absolute") {
+		t.Fatalf("expected an error for the relative path, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected an invalid volume to fail before deploying")
+	}
+}
+
+// TestDeployCmdRequestTimeout ensures that the request timeout is deployed and
+// persisted, and that one out of bounds fails before deploying.
+func TestDeployCmdRequestTimeout(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--request-timeout", "450"); err != nil {
+		t.Fatal(err)
+	}
+	deployed, f := d.deployed.Options.RequestTimeout, d.function().Options.RequestTimeout
+	if deployed == nil || *deployed != 450 || f == nil || *f != 450 {
+		t.Fatalf("expected the request timeout to be deployed and persisted, got %v and %v", deployed, f)
+	}
+
+	for _, timeout := range []string{"-1", "601"} {
+		if err := d.deploy("--request-timeout", timeout); err == nil || !strings.Contains(err.Error(), "--request-timeout") {
+			t.Fatalf("expected an error for the request timeout %v, got %v", timeout, err)
+		}
+		if d.deployed.Name != "" {
+			t.Fatal("expected an invalid request timeout to fail before deploying")
+		}
+	}
+}
+
+// TestDeployCmdBuildpack ensures that the buildpacks given reach the builder
+// and are persisted, and that an invalid one fails before building.
+func TestDeployCmdBuildpack(t *testing.T) {
+	d := newTestDeploy(t)
+
+	buildpacks := []string{"gcr.io/paketo-buildpacks/datadog", "urn:cnb:registry:alice/audit@1.0.0"}
+	if err := d.deploy("--buildpack", buildpacks[0], "--buildpack", buildpacks[1]); err != nil {
+		t.Fatal(err)
+	}
+	if f := d.function(); !reflect.DeepEqual(d.built.Build.Buildpacks, buildpacks) || !reflect.DeepEqual(f.Build.Buildpacks, buildpacks) {
+		t.Fatalf("expected the buildpacks to be built with and persisted, got %v and %v", d.built.Build.Buildpacks, f.Build.Buildpacks)
+	}
+
+	if err := d.deploy("--buildpack", "gcr.io/Paketo/Datadog"); err == nil || !strings.Contains(err.Error(), "invalid value 'gcr.io/Paketo/Datadog' for --buildpack") {
+		t.Fatalf("expected an error for the invalid buildpack, got %v", err)
+	}
+	if d.built.Name != "" {
+		t.Fatal("expected an invalid buildpack to fail before building")
+	}
+}
+
+// TestDeployCmdEnvironment ensures that the overlay of the environment given
+// is deployed, and that an environment which is not defined fails before
+// deploying, listing those which are.
+func TestDeployCmdEnvironment(t *testing.T) {
+	d := newTestDeploy(t)
+	d.writeConfig("name: myfunc\nruntime: go\nimage: example.com/alice/myfunc:latest\nnamespace: dev\n" +
+		"environments:\n  prod:\n    namespace: prod\n  staging:\n    namespace: staging\n")
+
+	if err := d.deploy("--environment", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if d.deployed.Namespace != "prod" {
+		t.Fatalf("expected the namespace 'prod' to be deployed, got '%v'", d.deployed.Namespace)
+	}
+	if f := d.function(); f.Namespace != "dev" {
+		t.Fatalf("expected the namespace 'dev' to be persisted, got '%v'", f.Namespace)
+	}
+
+	if err := d.deploy("--environment", "qa"); err == nil || !strings.Contains(err.Error(), "Defined environments: prod, staging") {
+		t.Fatalf("expected an error listing the defined environments, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected an unknown environment to fail before deploying")
+	}
+}
+
+// TestDeployCmdSinkFrom ensures the sources of which the function is made the
+// sink are provided to the client factory, and that they are rejected when
+// building on the cluster.
+func TestDeployCmdSinkFrom(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--sink-from", "PingSource/heartbeat", "--sink-from", "orders"); err != nil {
+		t.Fatal(err)
+	}
+	if s := d.config.SinkFrom; len(s) != 2 || s[0] != "PingSource/heartbeat" || s[1] != "orders" {
+		t.Fatalf("expected the sources to be provided, got %v", s)
+	}
+
+	if err := d.deploy("--remote", "--sink-from", "heartbeat"); err == nil || !strings.Contains(err.Error(), "--sink-from") {
+		t.Fatalf("expected an error for --sink-from with --remote, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected --sink-from with --remote to fail before deploying")
+	}
+}
+
+// TestDeployCmdMessage ensures the message of the deploy is provided as the
+// cause of its change, and is not stored in func.yaml.
+func TestDeployCmdMessage(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--build=false", "--push=false", "-m", "Fix the handling of empty payloads"); err != nil {
+		t.Fatal(err)
+	}
+	if d.config.Message != "Fix the handling of empty payloads" {
+		t.Fatalf("expected the message to be provided, got '%v'", d.config.Message)
+	}
+	config, err := ioutil.ReadFile(filepath.Join(d.root, "func.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(config), "empty payloads") {
+		t.Fatalf("expected the message not to be stored in func.yaml, got:\n%s", config)
+	}
+}
+
+// TestDeployCmdOutput ensures the function deployed is written with the Go
+// template of --output, and that an invalid template fails before deploying.
+func TestDeployCmdOutput(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--build=false", "--push=false", "-o", "go-template={{.Name}} {{.Image}}"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "myfunc example.com/alice/myfunc:latest"; !strings.Contains(d.out.String(), expected) {
+		t.Fatalf("expected '%v' to be written, got %q", expected, d.out.String())
+	}
+
+	for _, output := range []string{"go-template={{.Name", "go-template-file=nonexistent.tmpl", "json"} {
+		if err := d.deploy("--build=false", "-o", output); err == nil || !strings.Contains(err.Error(), "--output") {
+			t.Fatalf("expected an error for --output %v, got %v", output, err)
+		}
+		if d.deployed.Name != "" {
+			t.Fatal("expected an invalid --output to fail before deploying")
+		}
+	}
+}
+
+// testRevisionDeployer deploys functions as the given revision, reachable at
+// the given URL.
+type testRevisionDeployer struct {
+	url, revision string
+}
+
+func (d *testRevisionDeployer) Deploy(ctx context.Context, f fn.Function) (fn.DeploymentResult, error) {
+	return fn.DeploymentResult{Status: fn.Updated, URL: d.url, Revision: d.revision}, nil
+}
+
+// TestDeployCmdReadinessCheck ensures the path of --readiness-check of the
+// function deployed must respond successfully, the function being rolled back
+// to the revision deployed before if not with --rollback-on-failure.
+func TestDeployCmdReadinessCheck(t *testing.T) {
+	defer fromTempDir(t)()
+	root := pwd(t)
+
+	healthy := true
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	deploy := func(args ...string) (*testSplitter, string, error) {
+		funcYaml := "name: myfunc\nruntime: go\nimage: example.com/alice/myfunc:latest\nstatus:\n  revision: myfunc-00001\n"
+		if err := ioutil.WriteFile(filepath.Join(root, "func.yaml"), []byte(funcYaml), 0644); err != nil {
+			t.Fatal(err)
+		}
+		splitter := &testSplitter{}
+		cmd := NewDeployCmd(func(config deployConfig, listener fn.ProgressListener) (*fn.Client, error) {
+			return fn.New(
+				fn.WithDeployer(&testRevisionDeployer{url: server.URL + "/", revision: "myfunc-00002"}),
+				fn.WithTrafficSplitter(splitter),
+				fn.WithProgressListener(listener)), nil
+		})
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs(append([]string{"-p", root, "--build=false", "--push=false", "--readiness-check-timeout", "100ms"}, args...))
+		err := cmd.Execute()
+		return splitter, out.String(), err
+	}
+
+	if _, out, err := deploy("--readiness-check", "/health"); err != nil || requested != "/health" || !strings.Contains(out, "is ready") {
+		t.Fatalf("expected the readiness check of /health to pass, got %v: %v", err, out)
+	}
+
+	healthy = false
+	splitter, _, err := deploy("--readiness-check", "health")
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "'myfunc-00002' remains deployed") {
+		t.Fatalf("expected the readiness check to fail, reporting the revision deployed, got %v", err)
+	}
+	if splitter.name != "" {
+		t.Fatal("expected the function not to be rolled back without --rollback-on-failure")
+	}
+
+	splitter, _, err = deploy("--readiness-check", "/health", "--rollback-on-failure")
+	if err == nil || !strings.Contains(err.Error(), "Rolled back to revision 'myfunc-00001'") {
+		t.Fatalf("expected the function to be rolled back, got %v", err)
+	}
+	if splitter.name != "myfunc" || splitter.split["myfunc-00001"] != 100 {
+		t.Fatalf("expected all of the traffic to be routed to the revision deployed before, got %v", splitter.split)
+	}
+	f, err := fn.NewFunctionFromFile(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Status.Revision != "myfunc-00001" || f.Status.PreviousRevision != "myfunc-00001" || f.Status.Image != "" {
+		t.Fatalf("expected the revision rolled back to to be recorded as deployed, got %+v", f.Status)
+	}
+
+	if _, _, err = deploy("--rollback-on-failure"); err == nil || !strings.Contains(err.Error(), "--readiness-check") {
+		t.Fatalf("expected --rollback-on-failure to require --readiness-check, got %v", err)
+	}
+}
+
+// TestDeployCmdWaitForTraffic ensures that the percent of the traffic awaited
+// is given to the deployer as all of it when given without a value, and that
+// one which is not a percent fails before deploying.
+func TestDeployCmdWaitForTraffic(t *testing.T) {
+	d := newTestDeploy(t)
+
+	if err := d.deploy("--wait-for-traffic", "--timeout", "15m"); err != nil {
+		t.Fatal(err)
+	}
+	if d.config.WaitForTraffic != 100 || d.config.Timeout != 15*time.Minute {
+		t.Fatalf("expected all of the traffic to be awaited for 15m, got %v%% for %v", d.config.WaitForTraffic, d.config.Timeout)
+	}
+	if err := d.deploy("--wait-for-traffic=50"); err != nil || d.config.WaitForTraffic != 50 {
+		t.Fatalf("expected 50%% of the traffic to be awaited, got %v%% (%v)", d.config.WaitForTraffic, err)
+	}
+
+	if err := d.deploy("--wait-for-traffic=150"); err == nil || !strings.Contains(err.Error(), "invalid value '150' for --wait-for-traffic") {
+		t.Fatalf("expected an error for the percent 150, got %v", err)
+	}
+	if d.deployed.Name != "" {
+		t.Fatal("expected an invalid percent to fail before deploying")
+	}
+}
+
+// TestDeployConfigWithAnswers ensures the answers to the deploy prompt update
+// only the members asked, all others carried through as given.
+func TestDeployConfigWithAnswers(t *testing.T) {
+	c := deployConfig{
+		buildConfig: buildConfig{Registry: "quay.io/alice", Image: "quay.io/alice/myfunc:v1"},
+		Namespace:   "default",
+		Path:        "/tmp/myfunc",
+		Confirm:     true,
+		Build:       true,
+		Push:        true,
+		Remote:      true,
+		GitURL:      "https://github.com/alice/myfunc.git",
+		GitBranch:   "main",
+		ImageDigest: true,
+	}
+
+	answered := c.withAnswers(deployAnswers{Registry: "docker.io/bob", Namespace: "prod", Path: "/tmp/otherfunc"})
+	if answered.Registry != "docker.io/bob" || answered.Namespace != "prod" || answered.Path != "/tmp/otherfunc" {
+		t.Fatalf("expected the answers to be used, got %+v", answered)
+	}
+	if !answered.Build || !answered.Push {
+		t.Fatalf("expected --build and --push to be carried through, got build %v, push %v", answered.Build, answered.Push)
+	}
+	if !answered.Remote || answered.GitURL != c.GitURL || answered.GitBranch != c.GitBranch {
+		t.Fatalf("expected the remote build and its git source to be carried through, got remote %v, %v@%v", answered.Remote, answered.GitURL, answered.GitBranch)
+	}
+	if !answered.ImageDigest {
+		t.Fatal("expected --image-digest to be carried through")
+	}
+	if answered.Image != c.Image || !answered.Confirm {
+		t.Fatalf("expected the members not asked to be kept, got %+v", answered)
+	}
+}
+
+// TestDeployConfigWithAnswersPopulated ensures no member of a fully populated
+// config, such as the scale window, delay and retention period, is lost to the
+// answers to the deploy prompt.
+func TestDeployConfigWithAnswersPopulated(t *testing.T) {
+	var c deployConfig
+	populate(reflect.ValueOf(&c).Elem())
+	if c.ScaleWindow == "" || c.ScaleDownDelay == "" || c.ScaleRetentionPeriod == "" || c.Builder == "" {
+		t.Fatalf("expected the config to be fully populated, got %+v", c)
+	}
+
+	expected := c
+	expected.Registry = "docker.io/bob"
+	expected.Namespace = "prod"
+	expected.Path = "/tmp/otherfunc"
+
+	answered := c.withAnswers(deployAnswers{Registry: "docker.io/bob", Namespace: "prod", Path: "/tmp/otherfunc"})
+	if !reflect.DeepEqual(answered, expected) {
+		t.Fatalf("expected all members not asked to be carried through\nexpected: %+v\ngot:      %+v", expected, answered)
+	}
+}
+
+// populate the given value, and every member within it, with a non-zero value.
+func populate(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("populated")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		populate(v.Elem())
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		populate(v.Index(0))
+	case reflect.Map:
+		key, value := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+		populate(key)
+		populate(value)
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(key, value)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			// embedded structs, such as the build config, are populated
+			// through their exported members.
+			if v.Field(i).CanSet() || v.Type().Field(i).Anonymous {
+				populate(v.Field(i))
+			}
+		}
+	}
+}
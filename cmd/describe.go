@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
-	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/ory/viper"
 	"github.com/spf13/cobra"
@@ -16,71 +19,354 @@ import (
 )
 
 func init() {
-	root.AddCommand(describeCmd)
-	describeCmd.Flags().StringP("namespace", "n", "", "Namespace of the function. By default, the namespace in func.yaml is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
-	describeCmd.Flags().StringP("output", "o", "human", "Output format (human|plain|json|xml|yaml|url) (Env: $FUNC_OUTPUT)")
-	describeCmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	root.AddCommand(NewDescribeCmd(newDescribeClient))
+}
 
-	err := describeCmd.RegisterFlagCompletionFunc("output", CompleteOutputFormatList)
+// newDescribeClient returns the client with which the Function deployed in the
+// given namespace is described during normal execution: its Knative Service,
+// the Triggers and sources of its events, and its Revisions.
+func newDescribeClient(namespace string, config describeConfig) (*fn.Client, error) {
+	describer, err := knative.NewDescriber(namespace)
 	if err != nil {
-		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
+		return nil, err
 	}
+	describer.Verbose = config.Verbose
+
+	revisions, err := knative.NewTrafficSplitter(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return fn.New(
+		fn.WithVerbose(config.Verbose),
+		fn.WithConfigFile(configFile()),
+		fn.WithDescriber(describer),
+		fn.WithRevisionLister(revisions)), nil
 }
 
-var describeCmd = &cobra.Command{
-	Use:   "describe <name>",
-	Short: "Show details of a function",
-	Long: `Show details of a function
+// describeClientFn is a factory function which returns the client with which
+// the Function deployed in the given namespace is described.
+type describeClientFn func(namespace string, config describeConfig) (*fn.Client, error)
+
+// NewDescribeCmd creates a describe command using the given client creator.
+func NewDescribeCmd(newClient describeClientFn) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "describe <name>",
+		Short: "Show details of a function",
+		Long: `Show details of a function
+
+Prints the name, route, readiness, the revisions to which traffic is routed
+and any event subscriptions for a deployed function in the current directory
+or from the directory specified with --path.
 
-Prints the name, route and any event subscriptions for a deployed function in
-the current directory or from the directory specified with --path.
+With --show-triggers the Triggers subscribing the function to events are
+described in detail: their name, broker, filter attributes and readiness,
+such as to find why events do not reach the function.
+
+Each deploy records the status of the function in func.yaml: the image and
+revision deployed, its URL and the time.  With --offline the function is
+described from this status alone, without access to the cluster.  Otherwise,
+a warning is printed if the revision deployed differs from that recorded, as
+when the function has since been deployed from elsewhere.
+
+With --watch the description is updated as the function changes until it is
+ready, such as while waiting for a deploy to complete, failing if it is not
+ready within --timeout, if given.  In a terminal it is redrawn each time the
+Knative Service of the function changes; otherwise a description is printed
+each few seconds.  Interrupting the watch stops it.
 `,
-	Example: `
+		Example: `
 # Show the details of a function as declared in the local func.yaml
 kn func describe
 
 # Show the details of the function in the myotherfunc directory with yaml output
 kn func describe --output yaml --path myotherfunc
+
+# Show the details of the function "myfunc" in whichever namespace it is deployed
+kn func describe myfunc --all-namespaces
+
+# Show the details of the function, including those of its Triggers
+kn func describe --show-triggers
+
+# Show the details of the function as last deployed, without the cluster
+kn func describe --offline
+
+# Print only the image of the function deployed
+kn func describe --output go-template='{{.Image}}'
+
+# Watch the function until it is ready, failing after two minutes
+kn func describe --watch --timeout 2m
 `,
-	SuggestFor:        []string{"desc", "get"},
-	ValidArgsFunction: CompleteFunctionList,
-	PreRunE:           bindEnv("namespace", "output", "path"),
-	RunE:              runDescribe,
+		SuggestFor:        []string{"desc", "get"},
+		ValidArgsFunction: CompleteFunctionList,
+		PreRunE:           bindEnv("namespace", "output", "path", "offline", "show-triggers", "watch", "timeout"),
+		Annotations:       map[string]string{dryRunAnnotation: dryRunReadOnly},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDescribe(cmd, args, newClient)
+		},
+	}
+
+	cmd.Flags().BoolP("all-namespaces", "A", false, "Find the function by name in all namespaces, listing the matches if it is deployed in more than one. Conflicts with --namespace.")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace of the function. By default, the namespace in func.yaml is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
+	cmd.Flags().StringP("output", "o", "human", "Output format (human|plain|json|xml|yaml|url), or a Go template of the description as go-template=TEMPLATE or go-template-file=PATH (Env: $FUNC_OUTPUT)")
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	cmd.Flags().Bool("offline", false, "Describe the function as last deployed from the status recorded in func.yaml, without access to the cluster (Env: $FUNC_OFFLINE)")
+	cmd.Flags().Bool("show-triggers", false, "Show the name, broker, filters and readiness of each Trigger subscribing the function to events (Env: $FUNC_SHOW_TRIGGERS)")
+	cmd.Flags().BoolP("watch", "w", false, "Update the description as the function changes until it is ready (Env: $FUNC_WATCH)")
+	cmd.Flags().Duration("timeout", 0, "Time to wait with --watch for the function to be ready, such as 2m. Zero waits until it is ready or interrupted (Env: $FUNC_TIMEOUT)")
+
+	err := cmd.RegisterFlagCompletionFunc("output", CompleteOutputFormatList)
+	if err != nil {
+		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
+	}
+
+	return cmd
 }
 
-func runDescribe(cmd *cobra.Command, args []string) (err error) {
+func runDescribe(cmd *cobra.Command, args []string, newClient describeClientFn) (err error) {
 	config := newDescribeConfig(args)
+	if _, err = outputTemplate(config.Output); err != nil {
+		return
+	}
+	if config.Timeout < 0 {
+		return fmt.Errorf("invalid value '%v' for --timeout: must not be negative", config.Timeout)
+	}
 
-	function, err := fn.NewFunction(config.Path)
+	all, err := allNamespaces(cmd)
+	if err != nil {
+		return
+	}
+	mirrors, err := registryMirrors()
 	if err != nil {
 		return
 	}
 
-	// Check if the Function has been initialized
-	if !function.Initialized() {
-		return fmt.Errorf("the given path '%v' does not contain an initialized function", config.Path)
+	if config.Offline {
+		if all {
+			return fmt.Errorf("--offline conflicts with --all-namespaces")
+		}
+		if config.Watch {
+			return fmt.Errorf("--offline conflicts with --watch")
+		}
+		function, err := fn.Load(config.Path, configFile())
+		if err != nil {
+			return err
+		}
+		d, err := offlineDescription(function)
+		if err != nil {
+			return err
+		}
+		_, d.MirrorRewrites = function.WithRegistryMirrors(mirrors).Mirrored()
+		write(cmd.OutOrStdout(), description(d), config.Output)
+		return nil
+	}
+
+	if err = configureClusterAccess(); err != nil {
+		return
 	}
 
-	describer, err := knative.NewDescriber(config.Namespace)
+	// The Function need only be initialized if it is not found by name in all
+	// namespaces.
+	load := fn.Load
+	if all {
+		load = fn.NewFunctionFromFile
+	}
+	function, err := load(config.Path, configFile())
 	if err != nil {
 		return
 	}
-	describer.Verbose = config.Verbose
 
-	client := fn.New(
-		fn.WithVerbose(config.Verbose),
-		fn.WithDescriber(describer))
+	namespace := config.Namespace
+	if all {
+		if config.Name == "" {
+			return fmt.Errorf("the given path '%v' does not contain an initialized function. Please provide the name of the function", config.Path)
+		}
+		if namespace, err = findNamespace(cmd, config.Name); err != nil {
+			return
+		}
+	}
+
+	client, err := newClient(namespace, config)
+	if err != nil {
+		return
+	}
+
+	// The image of the Function is that of its config, with the rewrites of
+	// its registry mirrors, its invocation that of its template unless one is
+	// declared, and so recorded on its Service, and its Triggers are only
+	// described in detail when requested.
+	render := func(d fn.Description) {
+		if function.Name == d.Name {
+			d.Image = function.Image
+			_, d.MirrorRewrites = function.WithRegistryMirrors(mirrors).Mirrored()
+			if d.Invocation == "" {
+				d.Invocation = function.Signature()
+			}
+		}
+		if !config.ShowTriggers {
+			d.Triggers = nil
+		}
+		write(cmd.OutOrStdout(), description(d), config.Output)
+	}
+	if config.Watch {
+		return watchDescription(cmd, client, config, render)
+	}
 
 	d, err := client.Describe(cmd.Context(), config.Name, config.Path)
 	if err != nil {
 		return
 	}
-	d.Image = function.Image
+	if function.Name == d.Name {
+		if warning := revisionDrift(function, d); warning != "" {
+			fmt.Fprintln(cmd.ErrOrStderr(), warning)
+		}
+	}
+	render(d)
+	return
+}
+
+// describeWatchInterval between the descriptions printed by describe --watch
+// without an interactive terminal.
+var describeWatchInterval = 5 * time.Second
+
+// clearScreen moves the cursor to the top left of the terminal and clears it,
+// such that a description is redrawn in place of the last.
+const clearScreen = "\033[H\033[2J"
+
+// watchDescription renders the description of the Function until it is
+// ready: in a terminal redrawn each time it changes, as watched
+// by the client, and otherwise printed each describeWatchInterval, separated
+// by a blank line.  It fails if the Function is not ready within the timeout
+// of the config, if any.  Interrupting the watch stops it without error.
+func watchDescription(cmd *cobra.Command, client *fn.Client, config describeConfig, render func(fn.Description)) (err error) {
+	ctx := cmd.Context()
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+	ready := func(d fn.Description) bool {
+		return d.Ready == "True"
+	}
+
+	if isTerminal(cmd.OutOrStdout()) {
+		err = client.Watch(ctx, config.Name, config.Path, func(d fn.Description) bool {
+			fmt.Fprint(cmd.OutOrStdout(), clearScreen)
+			render(d)
+			return ready(d)
+		})
+	} else {
+		for i := 0; ; i++ {
+			var d fn.Description
+			if d, err = client.Describe(ctx, config.Name, config.Path); err != nil {
+				break
+			}
+			if i > 0 {
+				fmt.Fprintln(cmd.OutOrStdout())
+			}
+			render(d)
+			if ready(d) {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+			case <-time.After(describeWatchInterval):
+			}
+			if err != nil {
+				break
+			}
+		}
+	}
 
-	write(os.Stdout, description(d), config.Output)
+	switch {
+	case cmd.Context().Err() != nil:
+		return nil // interrupted
+	case ctx.Err() == context.DeadlineExceeded:
+		return fmt.Errorf("function '%v' was not ready within %v", config.Name, config.Timeout)
+	}
+	return
+}
+
+// offlineDescription of the Function as last deployed, from the status
+// recorded in its configuration.
+func offlineDescription(f fn.Function) (d fn.Description, err error) {
+	if f.Status == (fn.FunctionStatus{}) {
+		return d, fmt.Errorf("function '%v' has no recorded status. Please deploy it, or describe it without --offline", f.Name)
+	}
+	d = fn.Description{
+		Name:          f.Name,
+		Image:         f.Status.Image,
+		Namespace:     f.Namespace,
+		Routes:        []string{},
+		Revision:      f.Status.Revision,
+		Subscriptions: []fn.Subscription{},
+		Volumes:       f.Volumes,
+		Invocation:    f.Signature(),
+	}
+	if f.Status.URL != "" {
+		d.Routes = append(d.Routes, f.Status.URL)
+	}
 	return
 }
 
+// volumeSource returns the source of the volume as given with --volume, such
+// as secret:my-secret, without its path.
+func volumeSource(v fn.Volume) string {
+	switch {
+	case v.Secret != nil:
+		return "secret:" + *v.Secret
+	case v.ConfigMap != nil:
+		return "configMap:" + *v.ConfigMap
+	default:
+		return "emptyDir"
+	}
+}
+
+// revisionDrift returns a warning if the revision of the deployed Function
+// differs from that recorded in its status when last deployed, or nothing if
+// either is unknown.
+func revisionDrift(f fn.Function, d fn.Description) string {
+	if f.Status.Revision == "" || d.Revision == "" || f.Status.Revision == d.Revision {
+		return ""
+	}
+	return fmt.Sprintf("Warning: the deployed revision %v differs from the revision %v recorded when last deployed at %v. The function may have been deployed from elsewhere.",
+		d.Revision, f.Status.Revision, f.Status.Deployed.Format(time.RFC3339))
+}
+
+// findNamespace in which the named Function is deployed, searching all
+// namespaces.  When it is deployed in more than one, the matches are listed
+// and the user asked to choose with --namespace.
+func findNamespace(cmd *cobra.Command, name string) (namespace string, err error) {
+	lister, err := knative.NewLister("")
+	if err != nil {
+		return
+	}
+	lister.Namespace = ""
+
+	items, err := lister.List(cmd.Context())
+	if err != nil {
+		return
+	}
+
+	var matches listItems
+	for _, item := range items {
+		if item.Name == name {
+			matches = append(matches, item)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("function '%v' is not deployed in any namespace", name)
+	case 1:
+		return matches[0].Namespace, nil
+	default:
+		_ = matches.Plain(cmd.OutOrStdout())
+		return "", fmt.Errorf("function '%v' is deployed in %v namespaces. Please choose one with --namespace", name, len(matches))
+	}
+}
+
 // CLI Configuration (parameters)
 // ------------------------------
 
@@ -90,6 +376,17 @@ type describeConfig struct {
 	Output    string
 	Path      string
 	Verbose   bool
+
+	// Offline describes the Function from its recorded status.
+	Offline bool
+
+	// ShowTriggers includes the details of the Function's Triggers.
+	ShowTriggers bool
+
+	// Watch updates the description until the Function is ready, waiting up
+	// to Timeout, if given.
+	Watch   bool
+	Timeout time.Duration
 }
 
 func newDescribeConfig(args []string) describeConfig {
@@ -103,6 +400,11 @@ func newDescribeConfig(args []string) describeConfig {
 		Output:    viper.GetString("output"),
 		Path:      viper.GetString("path"),
 		Verbose:   viper.GetBool("verbose"),
+
+		Offline:      viper.GetBool("offline"),
+		ShowTriggers: viper.GetBool("show-triggers"),
+		Watch:        viper.GetBool("watch"),
+		Timeout:      viper.GetDuration("timeout"),
 	}
 }
 
@@ -124,15 +426,201 @@ func (d description) Human(w io.Writer) error {
 		fmt.Fprintf(w, "  %v\n", route)
 	}
 
+	if d.Ready != "" {
+		fmt.Fprintln(w, "Ready:")
+		fmt.Fprintf(w, "  %v\n", d.Ready)
+	}
+
+	if len(d.Conditions) > 0 {
+		fmt.Fprintln(w, "Conditions:")
+		for _, c := range d.Conditions {
+			fmt.Fprintf(w, "  %v\n", c)
+		}
+	}
+
+	if d.Revision != "" {
+		fmt.Fprintln(w, "Function revision:")
+		fmt.Fprintf(w, "  %v\n", d.Revision)
+	}
+
+	if traffic := revisionsWithTraffic(d.Revisions); len(traffic) > 0 {
+		fmt.Fprintln(w, "Traffic (Revision, Percent, Ready):")
+		for _, r := range traffic {
+			fmt.Fprintf(w, "  %v %v%% %v\n", r.Name, r.Percent, r.Ready)
+		}
+	}
+
+	if d.ChangeCause != "" {
+		fmt.Fprintln(w, "Change cause:")
+		fmt.Fprintf(w, "  %v\n", d.ChangeCause)
+	}
+
+	if d.Locked {
+		fmt.Fprintln(w, "Locked:")
+		fmt.Fprintln(w, "  deploys which would modify the function are refused, unless forced with --force-locked")
+	}
+
+	if d.Invocation != "" {
+		fmt.Fprintln(w, "Invocation:")
+		fmt.Fprintf(w, "  %v\n", d.Invocation)
+	}
+
+	if len(d.MirrorRewrites) > 0 {
+		fmt.Fprintln(w, "Registry mirror rewrites:")
+		for _, r := range d.MirrorRewrites {
+			fmt.Fprintf(w, "  %v\n", r)
+		}
+	}
+
+	if d.ServiceAccount != "" {
+		fmt.Fprintln(w, "Function runs as service account:")
+		fmt.Fprintf(w, "  %v\n", d.ServiceAccount)
+	}
+
+	if d.ImagePullPolicy != "" {
+		fmt.Fprintln(w, "Image pull policy:")
+		fmt.Fprintf(w, "  %v\n", d.ImagePullPolicy)
+	}
+
+	if d.Mesh != "" {
+		fmt.Fprintln(w, "Mesh sidecar injected:")
+		fmt.Fprintf(w, "  %v\n", d.Mesh)
+	}
+
+	if d.Port != 0 {
+		fmt.Fprintln(w, "Port:")
+		fmt.Fprintf(w, "  %v\n", d.Port)
+	}
+
+	if d.Metrics != "" {
+		fmt.Fprintln(w, "Metrics endpoint:")
+		fmt.Fprintf(w, "  %v\n", d.Metrics)
+	}
+	if d.Tracing != "" {
+		fmt.Fprintln(w, "Tracing endpoint:")
+		fmt.Fprintf(w, "  %v\n", d.Tracing)
+	}
+
+	if d.IngressClass != "" {
+		fmt.Fprintln(w, "Ingress class:")
+		fmt.Fprintf(w, "  %v\n", d.IngressClass)
+	}
+
+	if d.LivenessPath != "" || d.ReadinessPath != "" {
+		fmt.Fprintln(w, "Health probes:")
+		if d.LivenessPath != "" {
+			fmt.Fprintf(w, "  liveness %v\n", d.LivenessPath)
+		}
+		if d.ReadinessPath != "" {
+			fmt.Fprintf(w, "  readiness %v\n", d.ReadinessPath)
+		}
+	}
+
+	if d.RequestTimeout != 0 {
+		fmt.Fprintln(w, "Request timeout:")
+		fmt.Fprintf(w, "  %vs\n", d.RequestTimeout)
+	}
+
+	fmt.Fprintln(w, "Autoscaling:")
+	for _, setting := range autoscalingSettings(d.Autoscaling) {
+		fmt.Fprintf(w, "  %v %v\n", setting.name, setting.value)
+	}
+
+	if len(d.ExtendedResources) > 0 {
+		fmt.Fprintln(w, "Extended resources:")
+		for _, r := range d.ExtendedResources {
+			fmt.Fprintf(w, "  %v %v\n", r.Name, r.Quantity)
+		}
+	}
+
+	if len(d.Volumes) > 0 {
+		fmt.Fprintln(w, "Volumes:")
+		for _, v := range d.Volumes {
+			fmt.Fprintf(w, "  %v\n", v)
+		}
+	}
+
+	if len(d.InitContainers) > 0 {
+		fmt.Fprintln(w, "Init containers:")
+		for _, c := range d.InitContainers {
+			fmt.Fprintf(w, "  %v %v", c.Name, c.Image)
+			if len(c.Command) > 0 {
+				fmt.Fprintf(w, " (%v)", strings.Join(c.Command, " "))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
 	if len(d.Subscriptions) > 0 {
 		fmt.Fprintln(w, "Subscriptions (Source, Type, Broker):")
 		for _, s := range d.Subscriptions {
 			fmt.Fprintf(w, "  %v %v %v\n", s.Source, s.Type, s.Broker)
 		}
 	}
+
+	if len(d.Sources) > 0 {
+		fmt.Fprintln(w, "Sources (Kind/Name, Ready):")
+		for _, s := range d.Sources {
+			fmt.Fprintf(w, "  %v/%v %v\n", s.Kind, s.Name, s.Ready)
+		}
+	}
+
+	if len(d.Chain) > 0 {
+		fmt.Fprintln(w, "Chain:")
+		fmt.Fprintf(w, "  %v\n", strings.Join(d.Chain, " -> "))
+	}
+
+	// Triggers are only included when requested with --show-triggers, in
+	// which case a Function without any is described as such.
+	if d.Triggers != nil {
+		fmt.Fprintln(w, "Triggers:")
+		if len(d.Triggers) == 0 {
+			fmt.Fprintln(w, "  none")
+			return nil
+		}
+		tw := tabwriter.NewWriter(boldHeader(w), 0, 8, 2, ' ', 0)
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", "NAME", "BROKER", "FILTERS", "READY")
+		for _, t := range d.Triggers {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", t.Name, t.Broker, triggerFilters(t), triggerReady(t))
+		}
+		return tw.Flush()
+	}
 	return nil
 }
 
+// revisionsWithTraffic returns those of the revisions to which traffic is
+// routed, the rest being listed by 'history'.
+func revisionsWithTraffic(revisions []fn.Revision) (traffic []fn.Revision) {
+	for _, r := range revisions {
+		if r.Percent > 0 {
+			traffic = append(traffic, r)
+		}
+	}
+	return
+}
+
+// triggerFilters returns the filters of the Trigger as attribute=value pairs,
+// or "none" if it delivers all events of its broker.
+func triggerFilters(t fn.Trigger) string {
+	if len(t.Filters) == 0 {
+		return "none"
+	}
+	filters := make([]string, len(t.Filters))
+	for i, f := range t.Filters {
+		filters[i] = fmt.Sprintf("%v=%v", f.Attribute, f.Value)
+	}
+	return strings.Join(filters, ",")
+}
+
+// triggerReady returns the readiness of the Trigger, with the reason it is
+// not ready, if any.
+func triggerReady(t fn.Trigger) string {
+	if t.Reason != "" {
+		return fmt.Sprintf("%v (%v)", t.Ready, t.Reason)
+	}
+	return t.Ready
+}
+
 func (d description) Plain(w io.Writer) error {
 	fmt.Fprintf(w, "Name %v\n", d.Name)
 	fmt.Fprintf(w, "Image %v\n", d.Image)
@@ -142,11 +630,93 @@ func (d description) Plain(w io.Writer) error {
 		fmt.Fprintf(w, "Route %v\n", route)
 	}
 
+	if d.Ready != "" {
+		fmt.Fprintf(w, "Ready %v\n", d.Ready)
+	}
+	for _, c := range d.Conditions {
+		fmt.Fprintf(w, "Condition %v\n", c)
+	}
+
+	if d.Revision != "" {
+		fmt.Fprintf(w, "Revision %v\n", d.Revision)
+	}
+
+	for _, r := range revisionsWithTraffic(d.Revisions) {
+		fmt.Fprintf(w, "Traffic %v %v\n", r.Name, r.Percent)
+	}
+
+	if d.ChangeCause != "" {
+		fmt.Fprintf(w, "ChangeCause %v\n", d.ChangeCause)
+	}
+	if d.Locked {
+		fmt.Fprintln(w, "Locked true")
+	}
+	if d.Invocation != "" {
+		fmt.Fprintf(w, "Invocation %v\n", d.Invocation)
+	}
+	for _, r := range d.MirrorRewrites {
+		fmt.Fprintf(w, "MirrorRewrite %v\n", r)
+	}
+
+	if d.ServiceAccount != "" {
+		fmt.Fprintf(w, "ServiceAccount %v\n", d.ServiceAccount)
+	}
+	if d.ImagePullPolicy != "" {
+		fmt.Fprintf(w, "ImagePullPolicy %v\n", d.ImagePullPolicy)
+	}
+	if d.Mesh != "" {
+		fmt.Fprintf(w, "Mesh %v\n", d.Mesh)
+	}
+	if d.Port != 0 {
+		fmt.Fprintf(w, "Port %v\n", d.Port)
+	}
+	if d.Metrics != "" {
+		fmt.Fprintf(w, "Metrics %v\n", d.Metrics)
+	}
+	if d.Tracing != "" {
+		fmt.Fprintf(w, "Tracing %v\n", d.Tracing)
+	}
+	if d.IngressClass != "" {
+		fmt.Fprintf(w, "IngressClass %v\n", d.IngressClass)
+	}
+	if d.LivenessPath != "" {
+		fmt.Fprintf(w, "LivenessPath %v\n", d.LivenessPath)
+	}
+	if d.ReadinessPath != "" {
+		fmt.Fprintf(w, "ReadinessPath %v\n", d.ReadinessPath)
+	}
+	if d.RequestTimeout != 0 {
+		fmt.Fprintf(w, "RequestTimeout %v\n", d.RequestTimeout)
+	}
+	for _, setting := range autoscalingSettings(d.Autoscaling) {
+		fmt.Fprintf(w, "Autoscaling %v %v\n", setting.name, setting.value)
+	}
+	for _, r := range d.ExtendedResources {
+		fmt.Fprintf(w, "ExtendedResource %v %v\n", r.Name, r.Quantity)
+	}
+	for _, v := range d.Volumes {
+		fmt.Fprintf(w, "Volume %v %v\n", volumeSource(v), *v.Path)
+	}
+	for _, c := range d.InitContainers {
+		fmt.Fprintf(w, "InitContainer %v %v\n", c.Name, c.Image)
+	}
+
 	if len(d.Subscriptions) > 0 {
 		for _, s := range d.Subscriptions {
 			fmt.Fprintf(w, "Subscription %v %v %v\n", s.Source, s.Type, s.Broker)
 		}
 	}
+
+	for _, s := range d.Sources {
+		fmt.Fprintf(w, "Source %v/%v %v\n", s.Kind, s.Name, s.Ready)
+	}
+	if len(d.Chain) > 0 {
+		fmt.Fprintf(w, "Chain %v\n", strings.Join(d.Chain, " "))
+	}
+
+	for _, t := range d.Triggers {
+		fmt.Fprintf(w, "Trigger %v %v %v %v\n", t.Name, t.Broker, triggerFilters(t), triggerReady(t))
+	}
 	return nil
 }
 
@@ -168,3 +738,21 @@ func (d description) URL(w io.Writer) error {
 	}
 	return nil
 }
+
+// autoscalingSettings returns the settings of the autoscaling by name, those
+// not set being the default of the cluster.
+func autoscalingSettings(a fn.Autoscaling) (settings []struct{ name, value string }) {
+	for _, s := range []struct{ name, value string }{
+		{"min", a.Min},
+		{"max", a.Max},
+		{"window", a.Window},
+		{"scaleDownDelay", a.ScaleDownDelay},
+		{"retentionPeriod", a.RetentionPeriod},
+	} {
+		if s.value == "" {
+			s.value = "default"
+		}
+		settings = append(settings, s)
+	}
+	return
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/mock"
+)
+
+// TestDescribeTriggers ensures the Triggers, when included with
+// --show-triggers, are printed as a table under the function, and that a
+// function without any is described as such.
+func TestDescribeTriggers(t *testing.T) {
+	d := description{Name: "myfunc", Triggers: []fn.Trigger{
+		{
+			Name:    "myfunc-trigger",
+			Broker:  "default",
+			Filters: []fn.TriggerFilter{{Attribute: "source", Value: "/example"}, {Attribute: "type", Value: "com.example.event"}},
+			Ready:   "True",
+		},
+		{Name: "myfunc-all", Broker: "other", Filters: []fn.TriggerFilter{}, Ready: "False", Reason: "BrokerDoesNotExist"},
+	}}
+
+	var out bytes.Buffer
+	if err := d.Human(&out); err != nil {
+		t.Fatal(err)
+	}
+	expected := `Triggers:
+  NAME            BROKER   FILTERS                                 READY
+  myfunc-trigger  default  source=/example,type=com.example.event  True
+  myfunc-all      other    none                                    False (BrokerDoesNotExist)
+`
+	if !strings.HasSuffix(out.String(), expected) {
+		t.Fatalf("expected the Triggers table:\n%v\ngot:\n%v", expected, out.String())
+	}
+
+	out.Reset()
+	d.Triggers = []fn.Trigger{}
+	if err := d.Human(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out.String(), "Triggers:\n  none\n") {
+		t.Fatalf("expected no Triggers to be described as none, got:\n%v", out.String())
+	}
+
+	out.Reset()
+	d.Triggers = nil
+	if err := d.Human(&out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "Triggers:") {
+		t.Fatalf("expected the Triggers to be omitted when not requested, got:\n%v", out.String())
+	}
+}
+
+// TestDescribeOffline ensures that a function is described from its recorded
+// status, and that a function without any is not.
+func TestDescribeOffline(t *testing.T) {
+	f := fn.Function{Name: "myfunc", Namespace: "test"}
+	if _, err := offlineDescription(f); err == nil {
+		t.Fatal("expected a function without a recorded status not to be described")
+	}
+
+	f.Status = fn.FunctionStatus{Image: "example.com/alice/myfunc@sha256:a278a9", Revision: "myfunc-00001", URL: "http://myfunc.test.example.com"}
+	d, err := offlineDescription(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Image != f.Status.Image || d.Revision != f.Status.Revision || len(d.Routes) != 1 || d.Routes[0] != f.Status.URL || d.Namespace != "test" {
+		t.Fatalf("unexpected description %+v", d)
+	}
+}
+
+// TestDescribeGoTemplate ensures the description is written with the Go
+// template of the output format, given inline or in a file.
+func TestDescribeGoTemplate(t *testing.T) {
+	defer fromTempDir(t)()
+	d := description{Name: "myfunc", Namespace: "test", Routes: []string{"http://myfunc.test.example.com"}}
+
+	var out bytes.Buffer
+	write(&out, d, "go-template={{.Name}} {{index .Routes 0}}")
+	if out.String() != "myfunc http://myfunc.test.example.com" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	if err := ioutil.WriteFile("describe.tmpl", []byte("{{.Namespace}}/{{.Name}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	write(&out, d, "go-template-file=describe.tmpl")
+	if out.String() != "test/myfunc" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	if _, err := outputTemplate("go-template={{.Name"); err == nil {
+		t.Fatal("expected an invalid template to be an error")
+	}
+}
+
+// TestDescribeRevisionDrift ensures that a warning is given only when the
+// deployed revision differs from that recorded.
+func TestDescribeRevisionDrift(t *testing.T) {
+	f := fn.Function{Name: "myfunc", Status: fn.FunctionStatus{Revision: "myfunc-00001"}}
+	if w := revisionDrift(f, fn.Description{Revision: "myfunc-00001"}); w != "" {
+		t.Fatalf("expected no warning, got '%v'", w)
+	}
+	if w := revisionDrift(f, fn.Description{}); w != "" {
+		t.Fatalf("expected no warning for an unknown revision, got '%v'", w)
+	}
+	if w := revisionDrift(f, fn.Description{Revision: "myfunc-00002"}); !strings.Contains(w, "myfunc-00002 differs from the revision myfunc-00001") {
+		t.Fatalf("expected a warning of the drift, got '%v'", w)
+	}
+}
+
+// TestDescribeClient ensures the function is described by the client, with
+// its readiness and the revisions to which its traffic is routed.
+func TestDescribeClient(t *testing.T) {
+	defer fromTempDir(t)()
+	root := pwd(t)
+	if err := fn.New().Create(fn.Function{Name: "myfunc", Root: root, Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	describer := mock.NewDescriber()
+	describer.DescribeFn = func(name string) (fn.Description, error) {
+		return fn.Description{Name: name, Namespace: "test", Routes: []string{"http://myfunc.test.example.com"}, Ready: "True"}, nil
+	}
+	revisions := mock.NewRevisionLister()
+	revisions.RevisionsFn = func(name string) ([]fn.Revision, error) {
+		return []fn.Revision{{Name: "myfunc-00002", Ready: true, Percent: 90}, {Name: "myfunc-00001", Ready: true, Percent: 10}, {Name: "myfunc-00000"}}, nil
+	}
+	cmd := NewDescribeCmd(func(namespace string, config describeConfig) (*fn.Client, error) {
+		return fn.New(fn.WithDescriber(describer), fn.WithRevisionLister(revisions)), nil
+	})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--path", root, "--output", "plain"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !describer.DescribeInvoked || !revisions.RevisionsInvoked {
+		t.Fatal("expected the function to be described by the client")
+	}
+	for _, line := range []string{"Name myfunc\n", "Ready True\n", "Traffic myfunc-00002 90\n", "Traffic myfunc-00001 10\n"} {
+		if !strings.Contains(out.String(), line) {
+			t.Fatalf("expected %q in the description, got:\n%v", line, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "myfunc-00000") {
+		t.Fatalf("expected revisions without traffic to be omitted, got:\n%v", out.String())
+	}
+}
+
+// TestDescribeWatch ensures that with --watch the function is described
+// until it is ready, without a terminal a snapshot each interval, and that
+// it fails if not ready within --timeout.
+func TestDescribeWatch(t *testing.T) {
+	defer fromTempDir(t)()
+	root := pwd(t)
+	if err := fn.New().Create(fn.Function{Name: "myfunc", Root: root, Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	defer func(i time.Duration) { describeWatchInterval = i }(describeWatchInterval)
+	describeWatchInterval = time.Millisecond
+
+	ready := []string{"Unknown", "Unknown", "True"}
+	describer := mock.NewDescriber()
+	describer.DescribeFn = func(name string) (fn.Description, error) {
+		d := fn.Description{Name: name, Ready: ready[0]}
+		if len(ready) > 1 {
+			ready = ready[1:]
+		}
+		return d, nil
+	}
+	cmd := NewDescribeCmd(func(namespace string, config describeConfig) (*fn.Client, error) {
+		return fn.New(fn.WithDescriber(describer)), nil
+	})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--path", root, "--output", "plain", "--watch"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "Ready Unknown\n") != 2 || strings.Count(out.String(), "\n\nName myfunc\n") != 2 || !strings.Contains(out.String(), "Ready True\n") {
+		t.Fatalf("expected three descriptions, until ready, got:\n%v", out.String())
+	}
+
+	ready = []string{"False"}
+	cmd.SetArgs([]string{"--path", root, "--output", "plain", "--watch", "--timeout", "10ms"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "was not ready within 10ms") {
+		t.Fatalf("expected the watch to time out, got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/knative"
+)
+
+// ErrDiffers is returned by the diff command when the function differs from
+// that deployed, such that it exits with 1, as diff does.
+var ErrDiffers = errors.New("the function differs from that deployed")
+
+func init() {
+	root.AddCommand(NewDiffCmd())
+}
+
+// NewDiffCmd creates a diff command, which shows the differences between the
+// function of func.yaml and that deployed.
+func NewDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff [NAME]",
+		Short: "Show the differences between a function and that deployed",
+		Long: `Show the differences between a function and that deployed
+
+Compares the Knative Service which deploying the function would apply with
+that deployed, such as to detect drift before a deploy, or changes made to the
+Service outside of func.  Only the fields func manages are compared: its
+labels, annotations and spec, such as its image, envs, volumes and scale.  The
+time of its build, and the cause of the change, which differ with every deploy,
+are not.
+
+The differences are shown as a unified diff of the Service as YAML, from that
+deployed to that of func.yaml, or with --output json|yaml|xml as the changes
+of each field.  The Service is that of the name of the function, or of NAME,
+in the namespace given with --namespace, or else that of func.yaml, and
+otherwise the active namespace.  A function which is not deployed differs by
+all of its fields.
+
+Exits with 0 if the function does not differ from that deployed, and otherwise
+with 1, as diff does.
+`,
+		Example: `
+# Show the differences between the function in the current directory and that
+# deployed
+kn func diff
+
+# Show the changes of each field as JSON
+kn func diff --output json
+
+# Fail a CI pipeline if the function deployed to prod has drifted
+kn func diff --namespace prod > /dev/null
+`,
+		SuggestFor:        []string{"dif", "drift"},
+		ValidArgsFunction: CompleteFunctionList,
+		Args:              cobra.MaximumNArgs(1),
+		Annotations:       map[string]string{dryRunAnnotation: dryRunReadOnly},
+		PreRunE:           bindEnv("path", "namespace", "output"),
+		RunE:              runDiff,
+	}
+
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace of the function. By default, the namespace in func.yaml is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
+	cmd.Flags().StringP("output", "o", "human", "Output format (human|plain|json|xml|yaml) (Env: $FUNC_OUTPUT)")
+
+	if err := cmd.RegisterFlagCompletionFunc("output", CompleteOutputFormatList); err != nil {
+		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
+	}
+
+	return cmd
+}
+
+func runDiff(cmd *cobra.Command, args []string) (err error) {
+	config := newDiffConfig(args)
+
+	function, err := fn.NewFunctionFromFile(config.Path, configFile())
+	if err != nil {
+		return
+	}
+	if !function.Initialized() {
+		return fmt.Errorf("the given path '%v' does not contain an initialized function. Please create one at this path before comparing it", config.Path)
+	}
+	if config.Name != "" {
+		function.Name = config.Name
+	}
+	if config.Namespace == "" {
+		config.Namespace = function.Namespace
+	}
+
+	if err = configureClusterAccess(); err != nil {
+		return
+	}
+	deployer, err := knative.NewDeployer(config.Namespace)
+	if err != nil {
+		return
+	}
+
+	d, err := deployer.Diff(cmd.Context(), function)
+	if err != nil {
+		return
+	}
+	if !d.Deployed {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Function '%v' is not deployed in namespace '%v'\n", d.Name, d.Namespace)
+	}
+	write(cmd.OutOrStdout(), serviceDiff(d), config.Output)
+	if d.Differs() {
+		return ErrDiffers
+	}
+	return
+}
+
+type diffConfig struct {
+	// Name of the Service, if not that of the function.
+	Name string
+
+	// Path of the function.
+	Path string
+
+	// Namespace of the Service.
+	Namespace string
+
+	// Output format.
+	Output string
+}
+
+func newDiffConfig(args []string) diffConfig {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+	return diffConfig{
+		Name:      name,
+		Path:      viper.GetString("path"),
+		Namespace: viper.GetString("namespace"),
+		Output:    viper.GetString("output"),
+	}
+}
+
+// Output Formatting (serializers)
+// -------------------------------
+
+type serviceDiff knative.ServiceDiff
+
+// Human output is the unified diff, its removed lines red and added lines
+// green if output written to w is colored.
+func (d serviceDiff) Human(w io.Writer) error {
+	if !colorEnabled(w) {
+		return d.Plain(w)
+	}
+	for _, line := range strings.SplitAfter(d.Unified, "\n") {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			line = colored(w, colorBold, strings.TrimSuffix(line, "\n")) + "\n"
+		case strings.HasPrefix(line, "-"):
+			line = colored(w, colorRed, strings.TrimSuffix(line, "\n")) + "\n"
+		case strings.HasPrefix(line, "+"):
+			line = colored(w, colorGreen, strings.TrimSuffix(line, "\n")) + "\n"
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d serviceDiff) Plain(w io.Writer) error {
+	_, err := io.WriteString(w, d.Unified)
+	return err
+}
+
+func (d serviceDiff) JSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(d)
+}
+
+func (d serviceDiff) XML(w io.Writer) error {
+	return xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"diff"`
+		serviceDiff
+	}{serviceDiff: d})
+}
+
+func (d serviceDiff) YAML(w io.Writer) error {
+	return yaml.NewEncoder(w).Encode(d)
+}
+
+func (d serviceDiff) URL(w io.Writer) error {
+	return fmt.Errorf("the url output format is not supported by the diff command")
+}
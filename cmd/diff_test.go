@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/boson-project/func/knative"
+)
+
+// TestDiffOutput ensures the diff is written as is, uncolored when not to a
+// terminal, and its changes as a structured changeset with --output json.
+func TestDiffOutput(t *testing.T) {
+	d := serviceDiff(knative.ServiceDiff{
+		Name:      "myfunc",
+		Namespace: "test",
+		Deployed:  true,
+		Changes:   []knative.FieldChange{{Field: "spec.template.spec.containers[0].image", Deployed: "example.com/alice/myfunc@sha256:a278a9", Local: "example.com/alice/myfunc@sha256:b389b0"}},
+		Unified:   "--- deployed/myfunc\n+++ local/myfunc\n@@ -1,1 +1,1 @@\n-image: a\n+image: b\n",
+	})
+
+	var b bytes.Buffer
+	if err := d.Human(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != d.Unified {
+		t.Fatalf("expected the unified diff, got %q", b.String())
+	}
+
+	b.Reset()
+	if err := d.JSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	var changeset struct {
+		Name     string
+		Deployed bool
+		Changes  []map[string]string
+	}
+	if err := json.Unmarshal(b.Bytes(), &changeset); err != nil {
+		t.Fatal(err)
+	}
+	if changeset.Name != "myfunc" || !changeset.Deployed || len(changeset.Changes) != 1 ||
+		changeset.Changes[0]["local"] != "example.com/alice/myfunc@sha256:b389b0" {
+		t.Fatalf("expected the changeset of the image, got %s", b.String())
+	}
+}
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/discovery"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/docker"
+	"github.com/boson-project/func/k8s"
+)
+
+func init() {
+	root.AddCommand(NewDoctorCmd(newDoctorChecks))
+}
+
+// doctorTimeout bounds each check which calls out to a daemon or cluster,
+// such that an unreachable endpoint fails rather than hangs.
+const doctorTimeout = 10 * time.Second
+
+// Statuses of a doctor check.  Only a failed check fails the command.
+const (
+	doctorPass = "PASS"
+	doctorWarn = "WARN"
+	doctorFail = "FAIL"
+)
+
+// doctorCheck of the local environment, such as of the availability of the
+// container runtime.
+type doctorCheck struct {
+	Name string
+	Run  func(ctx context.Context) doctorResult
+}
+
+// doctorResult of a check: its status, a description of what was found and,
+// unless passed, a hint as to how it may be remedied.
+type doctorResult struct {
+	Status  string
+	Message string
+	Hint    string
+}
+
+// newDoctorChecks returns the checks of the local environment: of the
+// container runtime, the cluster and its Knative installation, and the
+// credentials of the function's registry.
+func newDoctorChecks(config doctorConfig) []doctorCheck {
+	checks := []doctorCheck{{Name: "Container runtime", Run: checkContainerRuntime}}
+	checks = append(checks, clusterChecks(newDiscoveryClient)...)
+	return append(checks, doctorCheck{
+		Name: "Registry credentials",
+		Run: func(context.Context) doctorResult {
+			return checkRegistryCredentials(doctorRegistry(config), docker.StoredCredentials)
+		},
+	})
+}
+
+// NewDoctorCmd creates a doctor command which runs the checks obtained from
+// the given constructor.
+func NewDoctorCmd(newChecks func(config doctorConfig) []doctorCheck) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that the local environment is ready for building and deploying functions",
+		Long: `Check that the local environment is ready for building and deploying functions
+
+Checks that a container runtime, such as Docker or Podman, is available for
+building, that the cluster of the current kubeconfig context is reachable and
+has Knative Serving and Eventing installed, and that credentials are stored
+for the registry of the function in the current directory or that given by
+--registry.
+
+Each check is reported as passed, as a warning or as failed, the latter two
+along with a hint as to how they may be remedied.  The command fails if any
+check fails.
+`,
+		Example: `
+# Check the environment
+kn func doctor
+
+# Check the environment, including the credentials of the given registry
+kn func doctor --registry quay.io/myuser
+`,
+		SuggestFor:  []string{"docter", "check"},
+		PreRunE:     bindEnv("path", "registry"),
+		Annotations: map[string]string{dryRunAnnotation: dryRunReadOnly},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(cmd.Context(), cmd.OutOrStdout(), newChecks(newDoctorConfig()))
+		},
+	}
+
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory, the registry of which is checked (Env: $FUNC_PATH)")
+	cmd.Flags().StringP("registry", "r", "", "Registry for which to check credentials, ex 'quay.io/myuser'. Defaults to that of the function (Env: $FUNC_REGISTRY)")
+
+	return cmd
+}
+
+// runDoctor runs each check in turn, printing its result, and errors if any
+// failed.
+func runDoctor(ctx context.Context, out io.Writer, checks []doctorCheck) error {
+	var failed int
+	for _, check := range checks {
+		result := check.Run(ctx)
+		fmt.Fprintf(out, "[%v] %v: %v\n", result.Status, check.Name, result.Message)
+		if result.Hint != "" && result.Status != doctorPass {
+			fmt.Fprintf(out, "       %v\n", result.Hint)
+		}
+		if result.Status == doctorFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%v of %v checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// checkContainerRuntime pings the container runtime at the docker host of
+// the environment.
+func checkContainerRuntime(ctx context.Context) doctorResult {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return doctorResult{doctorFail, fmt.Sprintf("unable to create a container runtime client: %v", err),
+			"Check the value of $DOCKER_HOST"}
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+	defer cancel()
+	ping, err := cli.Ping(ctx)
+	if err != nil {
+		return doctorResult{doctorFail, fmt.Sprintf("not reachable at %v: %v", cli.DaemonHost(), err),
+			"Install and start Docker or Podman, and set $DOCKER_HOST if it listens on a non-default socket"}
+	}
+	return doctorResult{doctorPass, fmt.Sprintf("reachable at %v (API version %v)", cli.DaemonHost(), ping.APIVersion), ""}
+}
+
+// newDiscoveryClient returns a discovery client of the cluster of the
+// current kubeconfig context.
+func newDiscoveryClient() (discovery.DiscoveryInterface, error) {
+	if err := configureClusterAccess(); err != nil {
+		return nil, err
+	}
+	restConfig, err := k8s.GetClientConfig().ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	restConfig.Timeout = doctorTimeout
+	return discovery.NewDiscoveryClientForConfig(restConfig)
+}
+
+// clusterChecks returns the checks of the reachability of the cluster and of
+// the presence of the Knative API groups therein.  Serving is required to
+// deploy, whereas Eventing is only required to subscribe functions to events,
+// so is warned of if missing.  The groups are not checked when the cluster
+// is unreachable.
+func clusterChecks(newClient func() (discovery.DiscoveryInterface, error)) []doctorCheck {
+	var reachable discovery.DiscoveryInterface
+
+	cluster := doctorCheck{Name: "Cluster", Run: func(context.Context) doctorResult {
+		client, err := newClient()
+		if err != nil {
+			return doctorResult{doctorFail, fmt.Sprintf("no usable kubeconfig: %v", err),
+				"Set $KUBECONFIG, or provide --kubeconfig, to a kubeconfig with a current context"}
+		}
+		version, err := client.ServerVersion()
+		if err != nil {
+			return doctorResult{doctorFail, fmt.Sprintf("not reachable: %v", err),
+				"Check that the cluster of the current context is running, for example with 'kubectl cluster-info'"}
+		}
+		reachable = client
+		return doctorResult{doctorPass, fmt.Sprintf("reachable (Kubernetes %v)", version.GitVersion), ""}
+	}}
+
+	group := func(name, groupVersion, missing, hint string) doctorCheck {
+		return doctorCheck{Name: name, Run: func(context.Context) doctorResult {
+			if reachable == nil {
+				return doctorResult{doctorWarn, "not checked as the cluster is not reachable", ""}
+			}
+			groups, err := reachable.ServerGroups()
+			if err != nil {
+				return doctorResult{doctorFail, fmt.Sprintf("unable to list the API groups of the cluster: %v", err), ""}
+			}
+			for _, g := range groups.Groups {
+				for _, v := range g.Versions {
+					if v.GroupVersion == groupVersion {
+						return doctorResult{doctorPass, fmt.Sprintf("installed (%v)", groupVersion), ""}
+					}
+				}
+			}
+			return doctorResult{missing, fmt.Sprintf("not installed: the API %v is not served by the cluster", groupVersion), hint}
+		}}
+	}
+
+	return []doctorCheck{
+		cluster,
+		group("Knative Serving", "serving.knative.dev/v1", doctorFail,
+			"Install Knative Serving, see https://knative.dev/docs/install/"),
+		group("Knative Eventing", "eventing.knative.dev/v1", doctorWarn,
+			"Functions can be deployed but not subscribed to events. Install Knative Eventing, see https://knative.dev/docs/install/"),
+	}
+}
+
+// doctorRegistry returns the registry whose credentials are checked: that
+// given, or that of the function at path, if any.
+func doctorRegistry(config doctorConfig) string {
+	if config.Registry != "" {
+		return config.Registry
+	}
+	f, err := fn.NewFunctionFromFile(config.Path, configFile())
+	if err != nil {
+		return ""
+	}
+	if f.Image != "" {
+		return f.Image
+	}
+	return f.Registry
+}
+
+// checkRegistryCredentials checks that credentials of the registry, given in
+// the form of a registry, such as quay.io/alice, or an image, are stored.
+// Missing credentials are only warned of, as they are prompted for when
+// pushing and are not required by public registries.
+func checkRegistryCredentials(registry string, lookup func(registry string) (docker.Credentials, bool, error)) doctorResult {
+	if registry == "" {
+		return doctorResult{doctorWarn, "not checked as no registry is configured",
+			"Provide --registry, or run in the directory of a function with a registry"}
+	}
+	host := registryHost(registry)
+	_, ok, err := lookup(host)
+	if err != nil {
+		return doctorResult{doctorWarn, fmt.Sprintf("unable to read the credentials of %v: %v", host, err), ""}
+	}
+	if !ok {
+		return doctorResult{doctorWarn, fmt.Sprintf("no credentials stored for %v", host),
+			fmt.Sprintf("Run 'func registry login %v', or provide them when prompted on deploy", host)}
+	}
+	return doctorResult{doctorPass, fmt.Sprintf("stored for %v", host), ""}
+}
+
+// registryHost returns the host of a registry or image, defaulting to
+// fn.DefaultRegistry when it has none, as in "alice/myfunc".
+func registryHost(registry string) string {
+	host := strings.Split(registry, "/")[0]
+	if strings.Contains(host, ".") || strings.HasPrefix(host, "localhost") ||
+		(strings.Contains(registry, "/") && strings.Contains(host, ":")) {
+		return host
+	}
+	return fn.DefaultRegistry
+}
+
+type doctorConfig struct {
+	// Path of the Function, the registry of which is checked.
+	Path string
+
+	// Registry for which credentials are checked, overriding that of the
+	// Function.
+	Registry string
+}
+
+func newDoctorConfig() doctorConfig {
+	return doctorConfig{
+		Path:     viper.GetString("path"),
+		Registry: viper.GetString("registry"),
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/boson-project/func/docker"
+)
+
+// TestRunDoctor ensures each result is printed, with the hints of those not
+// passed, and that only failed checks fail the command.
+func TestRunDoctor(t *testing.T) {
+	check := func(name string, result doctorResult) doctorCheck {
+		return doctorCheck{Name: name, Run: func(context.Context) doctorResult { return result }}
+	}
+
+	var out bytes.Buffer
+	err := runDoctor(context.Background(), &out, []doctorCheck{
+		check("a", doctorResult{doctorPass, "ok", "unused"}),
+		check("b", doctorResult{doctorWarn, "meh", "try b"}),
+	})
+	if err != nil {
+		t.Fatalf("expected warnings not to fail, got %v", err)
+	}
+	if out.String() != "[PASS] a: ok\n[WARN] b: meh\n       try b\n" {
+		t.Fatalf("unexpected output:\n%v", out.String())
+	}
+
+	err = runDoctor(context.Background(), &bytes.Buffer{}, []doctorCheck{
+		check("a", doctorResult{doctorFail, "broken", ""}),
+		check("b", doctorResult{doctorPass, "ok", ""}),
+	})
+	if err == nil || err.Error() != "1 of 2 checks failed" {
+		t.Fatalf("expected the failure to be reported, got %v", err)
+	}
+}
+
+// TestClusterChecks ensures the Knative API groups are checked on a reachable
+// cluster, a missing Serving failing and a missing Eventing warning.
+func TestClusterChecks(t *testing.T) {
+	client := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{
+		Resources: []*metav1.APIResourceList{{GroupVersion: "serving.knative.dev/v1"}},
+	}}
+	checks := clusterChecks(func() (discovery.DiscoveryInterface, error) { return client, nil })
+
+	expected := []string{doctorPass, doctorPass, doctorWarn}
+	for i, check := range checks {
+		if result := check.Run(context.Background()); result.Status != expected[i] {
+			t.Fatalf("expected check '%v' to %v, got %+v", check.Name, expected[i], result)
+		}
+	}
+}
+
+// TestClusterChecksUnreachable ensures an unusable kubeconfig fails, and the
+// API groups are then not checked.
+func TestClusterChecksUnreachable(t *testing.T) {
+	checks := clusterChecks(func() (discovery.DiscoveryInterface, error) {
+		return nil, errors.New("no configuration has been provided")
+	})
+
+	expected := []string{doctorFail, doctorWarn, doctorWarn}
+	for i, check := range checks {
+		if result := check.Run(context.Background()); result.Status != expected[i] {
+			t.Fatalf("expected check '%v' to %v, got %+v", check.Name, expected[i], result)
+		}
+	}
+}
+
+// TestCheckRegistryCredentials ensures the credentials are looked up by the
+// host of the registry, and are only warned of when missing.
+func TestCheckRegistryCredentials(t *testing.T) {
+	var host string
+	lookup := func(found bool) func(string) (docker.Credentials, bool, error) {
+		return func(registry string) (docker.Credentials, bool, error) {
+			host = registry
+			return docker.Credentials{}, found, nil
+		}
+	}
+
+	if result := checkRegistryCredentials("quay.io/alice", lookup(true)); result.Status != doctorPass {
+		t.Fatalf("expected stored credentials to pass, got %+v", result)
+	}
+	if host != "quay.io" {
+		t.Fatalf("expected credentials of 'quay.io', got '%v'", host)
+	}
+
+	result := checkRegistryCredentials("alice/myfunc:latest", lookup(false))
+	if result.Status != doctorWarn || !strings.Contains(result.Hint, "func registry login docker.io") {
+		t.Fatalf("expected missing credentials to warn, got %+v", result)
+	}
+
+	if result = checkRegistryCredentials("", lookup(true)); result.Status != doctorWarn {
+		t.Fatalf("expected no registry to warn, got %+v", result)
+	}
+}
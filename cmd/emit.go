@@ -70,7 +70,7 @@ func runEmit(cmd *cobra.Command, args []string) (err error) {
 		}
 	} else {
 		var f fn.Function
-		f, err = fn.NewFunction(config.Path)
+		f, err = fn.NewFunctionFromFile(config.Path, configFile())
 		if err != nil {
 			return
 		}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/knative"
+)
+
+func init() {
+	root.AddCommand(NewExportCmd())
+}
+
+// NewExportCmd creates an export command, which renders the Kubernetes
+// manifests of the objects deploying the function applies.
+func NewExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the Kubernetes manifests of a function",
+		Long: `Export the Kubernetes manifests of a function
+
+Renders the objects which deploying the function applies as YAML, such as for
+GitOps: its Knative Service, with all of the settings of its func.yaml (such as
+its envs, volumes, annotations and scale), and the DomainMapping of its domain,
+if any.  The cluster is not contacted, and the function is neither built nor
+deployed.
+
+The image is that of func.yaml, as built, unless given with --image.  The
+objects are of the namespace given with --namespace, or else that of func.yaml,
+and otherwise of none, such that they are applied to that of the client.
+
+The manifests are written to stdout, separated by '---', or with --output-dir
+to the file <name>.yaml in that directory, or with --split-files to a file per
+object, named <kind>-<name>.yaml.
+`,
+		Example: `
+# Write the manifests of the function in the current directory to stdout
+kn func export
+
+# Write the manifests of the function's production environment to a file per
+# object in the deploy directory of a GitOps repository
+kn func export --environment prod --output-dir ../gitops/myfunc --split-files
+`,
+		SuggestFor: []string{"exprot", "render", "manifests"},
+		PreRunE:    bindEnv("path", "namespace", "image", "environment", "output-dir", "split-files"),
+		RunE:       runExport,
+	}
+
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace of the objects exported. By default, the namespace in func.yaml is used, or otherwise none (Env: $FUNC_NAMESPACE)")
+	cmd.Flags().StringP("image", "i", "", "Full image name in the form [registry]/[namespace]/[name]:[tag]@[digest] of the function, such as that built by CI. By default, that of func.yaml is used (Env: $FUNC_IMAGE)")
+	cmd.Flags().String("environment", "", "Name of the environment of which the manifests are exported, the overlay of which under environments in func.yaml is merged over its settings (Env: $FUNC_ENVIRONMENT)")
+	cmd.Flags().String("output-dir", "", "Directory to which the manifests are written, rather than stdout (Env: $FUNC_OUTPUT_DIR)")
+	cmd.Flags().Bool("split-files", false, "Write a file per object, named <kind>-<name>.yaml. Requires --output-dir (Env: $FUNC_SPLIT_FILES)")
+
+	if err := cmd.RegisterFlagCompletionFunc("environment", CompleteEnvironmentList); err != nil {
+		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
+	}
+
+	return cmd
+}
+
+func runExport(cmd *cobra.Command, args []string) (err error) {
+	config := newExportConfig()
+	if config.Split && config.OutputDir == "" {
+		return fmt.Errorf("--split-files requires --output-dir")
+	}
+
+	function, err := fn.NewFunctionFromFile(config.Path, configFile())
+	if err != nil {
+		return
+	}
+	if !function.Initialized() {
+		return fmt.Errorf("the given path '%v' does not contain an initialized function. Please create one at this path before exporting", config.Path)
+	}
+	if config.Image != "" {
+		function.Image = config.Image
+		function.ImageDigest = ""
+	}
+	if function.Image == "" {
+		return fmt.Errorf("the function has no image to export. Build it, or provide --image")
+	}
+	if config.Environment != "" {
+		if function, err = function.WithEnvironment(config.Environment); err != nil {
+			return
+		}
+	}
+	if config.Namespace == "" {
+		config.Namespace = function.Namespace
+	}
+
+	deployer := &knative.Deployer{Namespace: config.Namespace}
+	manifests, err := deployer.Export(cmd.Context(), function)
+	if err != nil {
+		return
+	}
+
+	if config.OutputDir == "" {
+		return writeManifests(cmd.OutOrStdout(), manifests)
+	}
+	if err = os.MkdirAll(config.OutputDir, 0755); err != nil {
+		return
+	}
+	if !config.Split {
+		return writeManifestsFile(filepath.Join(config.OutputDir, function.Name+".yaml"), manifests)
+	}
+	for _, m := range manifests {
+		if err = writeManifestsFile(filepath.Join(config.OutputDir, strings.ToLower(m.Kind)+"-"+m.Name+".yaml"), []knative.Manifest{m}); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// writeManifests to w as a stream of YAML documents.
+func writeManifests(w io.Writer, manifests []knative.Manifest) error {
+	for i, m := range manifests {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w, "---"); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(m.YAML); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeManifestsFile at path, replacing any existing.
+func writeManifestsFile(path string, manifests []knative.Manifest) error {
+	var b strings.Builder
+	if err := writeManifests(&b, manifests); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(b.String()), 0644)
+}
+
+type exportConfig struct {
+	// Path of the function.
+	Path string
+
+	// Namespace of the objects, if any.
+	Namespace string
+
+	// Image of the function, in place of that of func.yaml.
+	Image string
+
+	// Environment, the overlay of which is merged over the function.
+	Environment string
+
+	// OutputDir to which the manifests are written, rather than stdout.
+	OutputDir string
+
+	// Split the manifests into a file per object.
+	Split bool
+}
+
+func newExportConfig() exportConfig {
+	return exportConfig{
+		Path:        viper.GetString("path"),
+		Namespace:   viper.GetString("namespace"),
+		Image:       viper.GetString("image"),
+		Environment: viper.GetString("environment"),
+		OutputDir:   viper.GetString("output-dir"),
+		Split:       viper.GetBool("split-files"),
+	}
+}
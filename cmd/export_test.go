@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fn "github.com/boson-project/func"
+)
+
+// TestExport ensures that the manifests of the function, with those of its
+// environment, are written to stdout, to a file, or to a file per object,
+// and that a function without an image is not exported.
+func TestExport(t *testing.T) {
+	defer fromTempDir(t)()
+
+	if err := fn.New().Create(fn.Function{Root: "myfunc", Name: "myfunc", Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	export := func(args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		cmd := NewExportCmd()
+		cmd.SetOut(out)
+		cmd.SetArgs(append(args, "--path", "myfunc"))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if _, err := export(); err == nil || !strings.Contains(err.Error(), "no image") {
+		t.Fatalf("expected an error exporting a function without an image, got %v", err)
+	}
+
+	f, err := fn.NewFunction("myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Image = "quay.io/alice/myfunc:latest"
+	f.Domain = "myfunc.example.com"
+	f.Environments = map[string]fn.Environment{"prod": {Namespace: "prod"}}
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := export("--image", "quay.io/alice/myfunc@sha256:a278a9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs := strings.Split(out, "---\n"); len(docs) != 2 || !strings.Contains(docs[0], "kind: Service") || !strings.Contains(docs[1], "kind: DomainMapping") {
+		t.Fatalf("expected the Service and DomainMapping, got:\n%v", out)
+	}
+	if !strings.Contains(out, "image: quay.io/alice/myfunc@sha256:a278a9") || strings.Contains(out, "namespace:") {
+		t.Fatalf("expected the image given, without a namespace, got:\n%v", out)
+	}
+
+	if _, err = export("--split-files"); err == nil {
+		t.Fatal("expected --split-files to require --output-dir")
+	}
+	if _, err = export("--environment", "prod", "--output-dir", "out"); err != nil {
+		t.Fatal(err)
+	}
+	bb, err := ioutil.ReadFile(filepath.Join("out", "myfunc.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bb), "namespace: prod") || !strings.Contains(string(bb), "image: quay.io/alice/myfunc:latest") {
+		t.Fatalf("expected the manifests of the environment, got:\n%s", bb)
+	}
+
+	if _, err = export("--output-dir", "split", "--split-files"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"service-myfunc.yaml", "domainmapping-myfunc.example.com.yaml"} {
+		if bb, err = ioutil.ReadFile(filepath.Join("split", name)); err != nil || strings.Contains(string(bb), "---") {
+			t.Fatalf("expected the object alone in %v, got %s (%v)", name, bb, err)
+		}
+	}
+}
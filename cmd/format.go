@@ -3,7 +3,10 @@ package cmd
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"strings"
+	"text/template"
 )
 
 type Format string
@@ -15,6 +18,15 @@ const (
 	XML          = "xml"
 	YAML         = "yaml"
 	URL          = "url"
+	Wide         = "wide" // Human, with more columns where supported.
+)
+
+// Go template formats, given as "go-template=[template]" or
+// "go-template-file=[path]", such as -o go-template='{{.Name}}', with which
+// the result of a command is written by executing the template against it.
+const (
+	GoTemplate     = "go-template"
+	GoTemplateFile = "go-template-file"
 )
 
 // formatter is any structure which has methods for serialization.
@@ -27,6 +39,13 @@ type Formatter interface {
 	URL(io.Writer) error
 }
 
+// wideFormatter is a Formatter of a table which has a wide form, of more
+// columns, written with the Wide format.
+type wideFormatter interface {
+	Formatter
+	Wide(io.Writer) error
+}
+
 // write to the output the output of the formatter's appropriate serilization function.
 // the command to exit with value 2.
 func write(out io.Writer, s Formatter, formatName string) {
@@ -44,11 +63,46 @@ func write(out io.Writer, s Formatter, formatName string) {
 		err = s.YAML(out)
 	case URL:
 		err = s.URL(out)
+	case Wide:
+		if w, ok := s.(wideFormatter); ok {
+			err = w.Wide(out)
+		} else {
+			err = fmt.Errorf("format not recognized: %v\n", formatName)
+		}
 	default:
-		err = fmt.Errorf("format not recognized: %v\n", formatName)
+		var t *template.Template
+		if t, err = outputTemplate(formatName); err == nil && t != nil {
+			err = t.Execute(out, s)
+		} else if err == nil {
+			err = fmt.Errorf("format not recognized: %v\n", formatName)
+		}
 	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
 	}
 }
+
+// outputTemplate returns the template of the given format if it is one of the
+// Go template formats, or nil otherwise.  The template of GoTemplateFile is
+// read from its file.  Commands validate their format with it before doing
+// anything, such that an invalid template fails fast.
+func outputTemplate(formatName string) (*template.Template, error) {
+	parts := strings.SplitN(formatName, "=", 2)
+	if len(parts) != 2 || (parts[0] != GoTemplate && parts[0] != GoTemplateFile) {
+		return nil, nil
+	}
+	text := parts[1]
+	if parts[0] == GoTemplateFile {
+		bb, err := ioutil.ReadFile(text)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the template of --output: %w", err)
+		}
+		text = string(bb)
+	}
+	t, err := template.New("output").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template for --output: %v", err)
+	}
+	return t, nil
+}
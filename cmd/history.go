@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/knative"
+)
+
+func init() {
+	root.AddCommand(NewHistoryCmd(newRevisionLister))
+}
+
+// newRevisionLister returns the lister of the revisions of the deployed
+// Function.
+func newRevisionLister(namespace string) (fn.RevisionLister, error) {
+	return knative.NewTrafficSplitter(namespace)
+}
+
+// NewHistoryCmd creates a history command which lists the revisions of the
+// deployed Function using listers obtained from the given constructor.
+func NewHistoryCmd(newLister func(namespace string) (fn.RevisionLister, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history [NAME]",
+		Short: "List the revisions of a deployed function",
+		Long: `List the revisions of a deployed function
+
+Lists the revisions of the deployed function, newest first, each with the
+digest of its image, the time it was created, whether it is ready, the percent
+of the traffic routed to it and the cause of its change given with
+'deploy --message'.  This is the history from which to choose a revision to
+roll back to (see 'rollback').
+
+The function is that of the current directory or that specified with --path,
+unless its name is given.
+`,
+		Example: `
+# List the revisions of the function in the current directory
+kn func history
+
+# List the last 5 revisions of the function "myfunc" as JSON
+kn func history myfunc --limit 5 --output json
+`,
+		SuggestFor:        []string{"hist", "revisions", "histroy"},
+		ValidArgsFunction: CompleteFunctionList,
+		Args:              cobra.MaximumNArgs(1),
+		PreRunE:           bindEnv("path", "namespace", "output", "limit"),
+		Annotations:       map[string]string{dryRunAnnotation: dryRunReadOnly},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHistory(cmd, args, newLister)
+		},
+	}
+
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace of the function. By default, the namespace in func.yaml is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
+	cmd.Flags().StringP("output", "o", "human", "Output format (human|plain|json|xml|yaml), or a Go template of the revisions as go-template=TEMPLATE or go-template-file=PATH (Env: $FUNC_OUTPUT)")
+	cmd.Flags().Int("limit", 0, "Number of the newest revisions listed. By default, all are listed (Env: $FUNC_LIMIT)")
+
+	if err := cmd.RegisterFlagCompletionFunc("output", CompleteOutputFormatList); err != nil {
+		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
+	}
+
+	return cmd
+}
+
+func runHistory(cmd *cobra.Command, args []string, newLister func(namespace string) (fn.RevisionLister, error)) (err error) {
+	config := newHistoryConfig(args)
+	if _, err = outputTemplate(config.Output); err != nil {
+		return
+	}
+	if config.Limit < 0 {
+		return fmt.Errorf("invalid value '%v' for --limit: must not be negative", config.Limit)
+	}
+	if config.Name == "" {
+		return fmt.Errorf("the given path '%v' does not contain an initialized function. Please provide the name of the function", config.Path)
+	}
+
+	namespace := config.Namespace
+	if namespace == "" {
+		if f, err := fn.NewFunctionFromFile(config.Path, configFile()); err == nil && f.Name == config.Name {
+			namespace = f.Namespace
+		}
+	}
+
+	if err = configureClusterAccess(); err != nil {
+		return
+	}
+	lister, err := newLister(namespace)
+	if err != nil {
+		return
+	}
+	revisions, err := lister.Revisions(cmd.Context(), config.Name)
+	if err != nil {
+		return
+	}
+
+	if revisions == nil {
+		revisions = []fn.Revision{}
+	}
+	sort.SliceStable(revisions, func(i, j int) bool { return revisions[i].Created.After(revisions[j].Created) })
+	if config.Limit > 0 && len(revisions) > config.Limit {
+		revisions = revisions[:config.Limit]
+	}
+
+	// Those of other formats are written as is, such as an empty list.
+	if Format(config.Output) == Human {
+		switch {
+		case len(revisions) == 0:
+			fmt.Fprintf(infoOut(cmd.OutOrStdout()), "No revisions of function '%v' found\n", config.Name)
+			return
+		case len(revisions) == 1 && config.Limit != 1:
+			defer fmt.Fprintf(infoOut(cmd.OutOrStdout()), "\nFunction '%v' has a single revision, and so none to roll back to\n", config.Name)
+		}
+	}
+	write(cmd.OutOrStdout(), revisionItems(revisions), config.Output)
+	return
+}
+
+type historyConfig struct {
+	Name      string
+	Path      string
+	Namespace string
+	Output    string
+	Limit     int
+}
+
+func newHistoryConfig(args []string) historyConfig {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+	return historyConfig{
+		Name:      deriveName(name, viper.GetString("path")),
+		Path:      viper.GetString("path"),
+		Namespace: viper.GetString("namespace"),
+		Output:    viper.GetString("output"),
+		Limit:     viper.GetInt("limit"),
+	}
+}
+
+// Output Formatting (serializers)
+// -------------------------------
+
+type revisionItems []fn.Revision
+
+func (items revisionItems) Human(w io.Writer) error {
+	return items.Plain(boldHeader(w))
+}
+
+func (items revisionItems) Plain(w io.Writer) error {
+	tabWriter := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	defer tabWriter.Flush()
+
+	fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\t%s\t%s\n", "REVISION", "TRAFFIC", "READY", "CREATED", "DIGEST", "CHANGE-CAUSE")
+	for _, r := range items {
+		fmt.Fprintf(tabWriter, "%s\t%d%%\t%v\t%s\t%s\t%s\n", r.Name, r.Percent, r.Ready, r.Created.Format(time.RFC3339), r.ImageDigest, r.ChangeCause)
+	}
+	return nil
+}
+
+func (items revisionItems) JSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(items)
+}
+
+func (items revisionItems) XML(w io.Writer) error {
+	return xml.NewEncoder(w).Encode(items)
+}
+
+func (items revisionItems) YAML(w io.Writer) error {
+	return yaml.NewEncoder(w).Encode(items)
+}
+
+func (items revisionItems) URL(w io.Writer) error {
+	return fmt.Errorf("the url output format is not supported by the history command")
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	fn "github.com/boson-project/func"
+)
+
+// TestHistory ensures that the revisions of the function are listed newest
+// first, limited to the newest given, and written in the format given.
+func TestHistory(t *testing.T) {
+	defer fromTempDir(t)()
+
+	if err := fn.New().Create(fn.Function{Root: "myfunc", Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	lister := &testRevisionSplitter{revisions: []fn.Revision{
+		{Name: "myfunc-00001", Created: created.Add(-2 * time.Hour), Ready: true},
+		{Name: "myfunc-00003", Created: created, Ready: true, Percent: 100, ImageDigest: "example.com/alice/myfunc@sha256:a278a9", ChangeCause: "Fix the handling of empty payloads"},
+		{Name: "myfunc-00002", Created: created.Add(-time.Hour), Ready: false},
+	}}
+	history := func(args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		cmd := NewHistoryCmd(func(string) (fn.RevisionLister, error) {
+			return lister, nil
+		})
+		cmd.SetOut(out)
+		cmd.SetArgs(append(args, "--path", "myfunc"))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := history()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "myfunc-00003") || !strings.HasPrefix(lines[2], "myfunc-00002") || !strings.HasPrefix(lines[3], "myfunc-00001") {
+		t.Fatalf("expected the revisions listed newest first, got:\n%v", out)
+	}
+	for _, s := range []string{"100%", "example.com/alice/myfunc@sha256:a278a9", "Fix the handling of empty payloads", "2021-06-01T12:00:00Z"} {
+		if !strings.Contains(lines[1], s) {
+			t.Fatalf("expected the newest revision to be listed with '%v', got:\n%v", s, lines[1])
+		}
+	}
+
+	if out, err = history("--limit", "2", "-o", "json"); err != nil {
+		t.Fatal(err)
+	}
+	var revisions []fn.Revision
+	if err = json.Unmarshal([]byte(out), &revisions); err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Name != "myfunc-00003" || revisions[1].Name != "myfunc-00002" {
+		t.Fatalf("expected the 2 newest revisions, got %+v", revisions)
+	}
+
+	if _, err = history("--limit", "-1"); err == nil || !strings.Contains(err.Error(), "--limit") {
+		t.Fatalf("expected an error for a negative limit, got '%v'", err)
+	}
+
+	// A function with a single revision has none to roll back to.
+	lister.revisions = []fn.Revision{{Name: "myfunc-00001", Created: created, Ready: true, Percent: 100}}
+	if out, err = history(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "myfunc-00001") || !strings.Contains(out, "single revision") {
+		t.Fatalf("expected the single revision to be listed and noted, got:\n%v", out)
+	}
+}
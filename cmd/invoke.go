@@ -0,0 +1,612 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/cloudevents"
+	"github.com/boson-project/func/knative"
+)
+
+func init() {
+	root.AddCommand(NewInvokeCmd(newInvokeDescriber))
+}
+
+// newInvokeDescriber returns the describer used to resolve the URL of the
+// deployed Function when invoking it remotely.
+func newInvokeDescriber(namespace string) (fn.Describer, error) {
+	return knative.NewDescriber(namespace)
+}
+
+// Request formats with which a Function may be invoked.
+const (
+	invokeFormatHTTP       = "http"
+	invokeFormatCloudEvent = "cloudevent"
+)
+
+// NewInvokeCmd creates an invoke command which resolves the URL of remote
+// Functions using describers obtained from the given constructor.
+func NewInvokeCmd(newDescriber func(namespace string) (fn.Describer, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "invoke",
+		Short: "Send a test request to a function",
+		Long: `Send a test request to a function
+
+Invokes the function with a test request, printing the status and body of the
+response.  Functions created from the 'events' template are sent a CloudEvent,
+while all others are sent an HTTP POST request with the given data as its body.
+The format of the request may be chosen explicitly using --format.
+
+By default the deployed function is invoked, its URL being resolved from the
+cluster.  Use --target local to invoke the function running locally via
+'func run', on the port recorded by it, or provide a URL to invoke an arbitrary
+endpoint.
+
+The response may be recorded as a golden response with --save, in
+.func/responses of the project, to which the responses of subsequent
+invocations are compared.  A response which differs is printed as a diff, and
+the command fails, such that invoke may be used as a smoke test in CI.  Headers
+which vary between responses, such as Date, are ignored (see --ignore-header).
+
+For a quick check of its capacity, the function may be sent --count requests,
+--concurrency of them at a time, rather than one.  A summary of their latencies
+and of the responses received is then printed, and the command fails if the
+percentage of requests which failed, or received an error status, exceeds
+--fail-threshold.  This is a lightweight check, not a benchmarking tool.
+
+CloudEvents are sent in binary mode, their attributes as Ce- headers, unless
+--mode structured is given, in which case the event as a whole is sent as the
+JSON body, such that the function receives events in the format of the source
+which sends them in production.  Extension attributes may be added to the
+event with --extension.
+`,
+		Example: `
+# Invoke the deployed function from the current directory's project
+kn func invoke
+
+# Invoke the function running locally, posting the given JSON
+kn func invoke --target local --data '{"name": "Alice"}'
+
+# Invoke the function at the given URL with a CloudEvent of type "my.event"
+kn func invoke --target http://myfunc.example.com --format cloudevent --type my.event
+
+# Invoke the function with a structured CloudEvent with the extension "tenant"
+kn func invoke --format cloudevent --mode structured --extension tenant=acme
+
+# Record the response of the function as the golden response "greeting"
+kn func invoke --data '{"name": "Alice"}' --golden greeting --save
+
+# Invoke the function, failing if its response differs from that recorded
+kn func invoke --data '{"name": "Alice"}' --golden greeting
+
+# Send the function 1000 requests, 50 at a time, failing if more than 1% fail
+kn func invoke --count 1000 --concurrency 50 --timeout 5s --fail-threshold 1
+`,
+		SuggestFor: []string{"invkoe", "call", "test"},
+		PreRunE:    bindEnv("path", "namespace", "target", "format", "data", "content-type", "type", "source", "mode", "save", "golden", "timeout", "count", "concurrency", "fail-threshold"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInvoke(cmd, newDescriber)
+		},
+	}
+
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace of the deployed function. By default, the namespace in func.yaml is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
+	cmd.Flags().StringP("target", "t", "remote", "Function to invoke: 'remote' (the deployed function), 'local' (the function running locally via 'func run') or a URL (Env: $FUNC_TARGET)")
+	cmd.Flags().StringP("format", "f", "", "Format of the request: 'http' or 'cloudevent'. Defaults to 'cloudevent' for functions created from the 'events' template, otherwise 'http' (Env: $FUNC_FORMAT)")
+	cmd.Flags().StringP("data", "d", "", "Data sent as the body of the request (Env: $FUNC_DATA)")
+	cmd.Flags().StringP("content-type", "c", "application/json", "The MIME Content-Type of the data (Env: $FUNC_CONTENT_TYPE)")
+	cmd.Flags().String("type", cloudevents.DefaultType, "CloudEvent type, when sending a CloudEvent (Env: $FUNC_TYPE)")
+	cmd.Flags().StringP("source", "s", cloudevents.DefaultSource, "CloudEvent source, when sending a CloudEvent (Env: $FUNC_SOURCE)")
+	cmd.Flags().String("mode", cloudevents.ModeBinary, fmt.Sprintf("Content mode in which a CloudEvent is sent, one of %v: binary, its attributes as Ce- headers and its data the body, or structured, the event as the JSON body (Env: $FUNC_MODE)", strings.Join(cloudevents.Modes, ", ")))
+	cmd.Flags().StringArray("extension", []string{}, "Extension attribute of the CloudEvent sent, in the form NAME=VALUE, its name of lower-case letters and digits. You may provide this flag multiple times")
+	cmd.Flags().Bool("save", false, "Record the response as the golden response, to which those of subsequent invocations are compared (Env: $FUNC_SAVE)")
+	cmd.Flags().String("golden", defaultGolden, "Name of the golden response recorded with --save, and compared to if recorded, in "+filepath.Join(fn.RunDataDir, responsesDir)+" (Env: $FUNC_GOLDEN)")
+	cmd.Flags().StringArray("ignore-header", defaultIgnoredHeaders, "Header of the response ignored when recording and comparing golden responses, as it varies between responses. You may provide this flag multiple times")
+	cmd.Flags().Duration("timeout", 0, "Time after which each request is abandoned, such as 5s. Zero is no timeout (Env: $FUNC_TIMEOUT)")
+	cmd.Flags().Int("count", 1, "Number of requests sent. More than one prints a summary of their latencies and errors rather than the response (Env: $FUNC_COUNT)")
+	cmd.Flags().Int("concurrency", 1, "Number of requests sent at a time, with --count (Env: $FUNC_CONCURRENCY)")
+	cmd.Flags().Float64("fail-threshold", 0, "Percentage of the requests sent with --count which may fail, or receive an error status, before the command fails (Env: $FUNC_FAIL_THRESHOLD)")
+
+	return cmd
+}
+
+func runInvoke(cmd *cobra.Command, newDescriber func(namespace string) (fn.Describer, error)) (err error) {
+	config, err := newInvokeConfig()
+	if err != nil {
+		return
+	}
+	if config.IgnoreHeaders, err = cmd.Flags().GetStringArray("ignore-header"); err != nil {
+		return
+	}
+	if config.Extensions, err = extensionsFromFlag(cmd); err != nil {
+		return
+	}
+
+	f, err := fn.NewFunctionFromFile(config.Path, configFile())
+	if err != nil {
+		return
+	}
+
+	format := config.Format
+	if format == "" {
+		format = invokeFormatHTTP
+		if f.Template == "events" || strings.HasSuffix(f.Template, "/events") {
+			format = invokeFormatCloudEvent
+		}
+	}
+	if format != invokeFormatHTTP && format != invokeFormatCloudEvent {
+		return fmt.Errorf("invalid format '%v'. Must be one of: %v, %v", format, invokeFormatHTTP, invokeFormatCloudEvent)
+	}
+	if format == invokeFormatCloudEvent {
+		if err = newInvokeEmitter(config).Validate(); err != nil {
+			return fmt.Errorf("invalid CloudEvent: %w", err)
+		}
+	} else if cmd.Flags().Changed("mode") || len(config.Extensions) > 0 {
+		return fmt.Errorf("--mode and --extension apply only to CloudEvents. Send one with --format %v", invokeFormatCloudEvent)
+	}
+
+	var endpoint string
+	switch config.Target {
+	case "local":
+		if endpoint, err = localEndpoint(config.Path); err != nil {
+			return
+		}
+	case "remote":
+		if !f.Initialized() {
+			return fmt.Errorf("the given path '%v' does not contain an initialized function", config.Path)
+		}
+		if err = configureClusterAccess(); err != nil {
+			return
+		}
+		ns := config.Namespace
+		if ns == "" {
+			ns = f.Namespace
+		}
+		var d fn.Describer
+		if d, err = newDescriber(ns); err != nil {
+			return
+		}
+		var desc fn.Description
+		if desc, err = d.Describe(cmd.Context(), f.Name); err != nil {
+			return
+		}
+		if len(desc.Routes) == 0 {
+			return fmt.Errorf("function '%v' has no routes. Has it been deployed?", f.Name)
+		}
+		endpoint = desc.Routes[0]
+	default:
+		endpoint = config.Target
+	}
+
+	invoke := func(ctx context.Context) (invokeResponse, error) {
+		if config.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+			defer cancel()
+		}
+		if format == invokeFormatCloudEvent {
+			return invokeCloudEvent(ctx, endpoint, config)
+		}
+		return invokeHTTP(ctx, endpoint, config)
+	}
+
+	if config.Count > 1 {
+		result := invokeLoad(cmd.Context(), config.Count, config.Concurrency, invoke)
+		if err = result.print(cmd.OutOrStdout()); err != nil {
+			return
+		}
+		if rate := result.errorRate(); rate > config.FailThreshold {
+			return fmt.Errorf("%.2f%% of requests failed, exceeding the threshold of %v%%", rate, config.FailThreshold)
+		}
+		return
+	}
+
+	response, err := invoke(cmd.Context())
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Status: %v %v\n", response.Status, http.StatusText(response.Status))
+	fmt.Fprintln(cmd.OutOrStdout(), response.Body)
+
+	response.ignore(config.IgnoreHeaders)
+	golden := filepath.Join(f.Root, fn.RunDataDir, responsesDir, config.Golden+".yaml")
+	if config.Save {
+		if err = response.save(golden); err == nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "Response recorded in %v\n", golden)
+		}
+		return
+	}
+	return response.compare(golden)
+}
+
+// localEndpoint returns the endpoint of the instance of the Function at root
+// running locally, as recorded by 'func run'.  An error suggesting 'func run'
+// is returned if no instance is recorded, or if the recorded instance is not
+// accepting connections (for example if the run was terminated abruptly).
+func localEndpoint(root string) (endpoint string, err error) {
+	instance, err := fn.ReadInstance(root)
+	if err == fn.ErrNotRunning {
+		return "", fmt.Errorf("function is not running locally. Start it with 'func run'")
+	}
+	if err != nil {
+		return
+	}
+	address := net.JoinHostPort(instance.Host, instance.Port)
+	conn, err := net.DialTimeout("tcp", address, time.Second)
+	if err != nil {
+		return "", fmt.Errorf("function is not running locally on %v. Start it with 'func run'", address)
+	}
+	conn.Close()
+	return "http://" + address, nil
+}
+
+// invokeHTTP POSTs the data to the endpoint, returning the response.
+func invokeHTTP(ctx context.Context, endpoint string, config invokeConfig) (response invokeResponse, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(config.Data))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", config.ContentType)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return
+	}
+	response = invokeResponse{Status: res.StatusCode, Headers: map[string]string{}, Body: string(body)}
+	for name, values := range res.Header {
+		response.Headers[name] = strings.Join(values, ", ")
+	}
+	return
+}
+
+// extensionsFromFlag returns the extension attributes given by the repeatable
+// --extension flag, by name.
+func extensionsFromFlag(cmd *cobra.Command) (map[string]string, error) {
+	extensions, err := cmd.Flags().GetStringArray("extension")
+	if err != nil {
+		return nil, err
+	}
+	if len(extensions) == 0 {
+		return nil, nil
+	}
+	parsed := map[string]string{}
+	for _, e := range extensions {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid --extension '%v'. Must be of the form NAME=VALUE", e)
+		}
+		parsed[parts[0]] = parts[1]
+	}
+	return parsed, nil
+}
+
+// newInvokeEmitter returns the emitter of a CloudEvent bearing the data, of
+// a new ID.
+func newInvokeEmitter(config invokeConfig) *cloudevents.Emitter {
+	emitter := cloudevents.NewEmitter()
+	emitter.Source = config.Source
+	emitter.Type = config.Type
+	emitter.Id = uuid.NewString()
+	emitter.ContentType = config.ContentType
+	emitter.Data = config.Data
+	emitter.Mode = config.Mode
+	emitter.Extensions = config.Extensions
+	return emitter
+}
+
+// invokeCloudEvent sends a CloudEvent bearing the data to the endpoint,
+// returning the response.  The attributes of any event in reply are its
+// headers, as in binary mode, and its data the body.
+func invokeCloudEvent(ctx context.Context, endpoint string, config invokeConfig) (response invokeResponse, err error) {
+	res, err := newInvokeEmitter(config).Request(ctx, endpoint)
+	if err != nil {
+		return
+	}
+	response = invokeResponse{Status: res.StatusCode}
+	if res.Event != nil {
+		response.Headers = map[string]string{
+			"Ce-Id":        res.Event.ID(),
+			"Ce-Source":    res.Event.Source(),
+			"Ce-Type":      res.Event.Type(),
+			"Content-Type": res.Event.DataContentType(),
+		}
+		if !res.Event.Time().IsZero() {
+			response.Headers["Ce-Time"] = res.Event.Time().Format(time.RFC3339Nano)
+		}
+		response.Body = string(res.Event.Data())
+	}
+	return
+}
+
+// invokeLoadResult is the result of sending a Function a number of requests
+// as a load check.
+type invokeLoadResult struct {
+	Concurrency int
+	Duration    time.Duration   // from the first request sent to the last received
+	Latencies   []time.Duration // of the requests which received a response, sorted
+	Statuses    map[int]int     // number of responses of each status
+	Errors      map[string]int  // number of requests failing with each error
+}
+
+// invokeLoad sends count requests with invoke, concurrency of them at a time,
+// returning the latencies and outcomes of each.
+func invokeLoad(ctx context.Context, count, concurrency int, invoke func(context.Context) (invokeResponse, error)) (result invokeLoadResult) {
+	if concurrency > count {
+		concurrency = count
+	}
+	result = invokeLoadResult{
+		Concurrency: concurrency,
+		Statuses:    map[int]int{},
+		Errors:      map[string]int{},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	requests := make(chan struct{})
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range requests {
+				sent := time.Now()
+				response, err := invoke(ctx)
+				latency := time.Since(sent)
+				mu.Lock()
+				if err != nil {
+					result.Errors[err.Error()]++
+				} else {
+					result.Statuses[response.Status]++
+					result.Latencies = append(result.Latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		requests <- struct{}{}
+	}
+	close(requests)
+	wg.Wait()
+	result.Duration = time.Since(start)
+	sort.Slice(result.Latencies, func(i, j int) bool { return result.Latencies[i] < result.Latencies[j] })
+	return
+}
+
+// requests sent.
+func (r invokeLoadResult) requests() (n int) {
+	for _, c := range r.Statuses {
+		n += c
+	}
+	for _, c := range r.Errors {
+		n += c
+	}
+	return
+}
+
+// failed requests: those which received no response or an error status.
+func (r invokeLoadResult) failed() (n int) {
+	for status, c := range r.Statuses {
+		if status >= http.StatusBadRequest {
+			n += c
+		}
+	}
+	for _, c := range r.Errors {
+		n += c
+	}
+	return
+}
+
+// errorRate is the percentage of requests which failed.
+func (r invokeLoadResult) errorRate() float64 {
+	if r.requests() == 0 {
+		return 0
+	}
+	return float64(r.failed()) * 100 / float64(r.requests())
+}
+
+// percentile p of the latencies, by nearest rank.
+func (r invokeLoadResult) percentile(p int) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	i := (p*len(r.Latencies)+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return r.Latencies[i]
+}
+
+// print a summary of the result to w.
+func (r invokeLoadResult) print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Requests:\t%v\n", r.requests())
+	fmt.Fprintf(tw, "Concurrency:\t%v\n", r.Concurrency)
+	fmt.Fprintf(tw, "Duration:\t%v\n", r.Duration.Round(time.Millisecond))
+	if r.Duration > 0 {
+		fmt.Fprintf(tw, "Requests/sec:\t%.2f\n", float64(r.requests())/r.Duration.Seconds())
+	}
+	fmt.Fprintf(tw, "Failed:\t%v (%.2f%%)\n", r.failed(), r.errorRate())
+	if len(r.Latencies) > 0 {
+		fmt.Fprintln(tw, "Latency:")
+		fmt.Fprintf(tw, "  min\t%v\n", r.Latencies[0].Round(time.Microsecond))
+		for _, p := range []int{50, 90, 95, 99} {
+			fmt.Fprintf(tw, "  p%v\t%v\n", p, r.percentile(p).Round(time.Microsecond))
+		}
+		fmt.Fprintf(tw, "  max\t%v\n", r.Latencies[len(r.Latencies)-1].Round(time.Microsecond))
+	}
+	if len(r.Statuses) > 0 {
+		statuses := make([]int, 0, len(r.Statuses))
+		for s := range r.Statuses {
+			statuses = append(statuses, s)
+		}
+		sort.Ints(statuses)
+		fmt.Fprintln(tw, "Status:")
+		for _, s := range statuses {
+			fmt.Fprintf(tw, "  %v %v\t%v\n", s, http.StatusText(s), r.Statuses[s])
+		}
+	}
+	if len(r.Errors) > 0 {
+		errs := make([]string, 0, len(r.Errors))
+		for e := range r.Errors {
+			errs = append(errs, e)
+		}
+		sort.Strings(errs)
+		fmt.Fprintln(tw, "Errors:")
+		for _, e := range errs {
+			fmt.Fprintf(tw, "  %v\t%v\n", e, r.Errors[e])
+		}
+	}
+	return tw.Flush()
+}
+
+// responsesDir within the RunDataDir of a Function in which its golden
+// responses are recorded.
+const responsesDir = "responses"
+
+// defaultGolden is the name of the golden response if not given.
+const defaultGolden = "default"
+
+// defaultIgnoredHeaders vary between responses, and so are ignored when
+// recording and comparing golden responses.
+var defaultIgnoredHeaders = []string{"Date", "X-Request-Id", "Ce-Id", "Ce-Time"}
+
+// invokeResponse is the response of a Function to an invocation, as recorded
+// as a golden response.
+type invokeResponse struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    string            `yaml:"body"`
+}
+
+// ignore the given headers of the response, matched case-insensitively.
+func (r *invokeResponse) ignore(headers []string) {
+	for _, h := range headers {
+		delete(r.Headers, http.CanonicalHeaderKey(h))
+	}
+	if len(r.Headers) == 0 {
+		r.Headers = nil
+	}
+}
+
+// save the response as the golden response at path.
+func (r invokeResponse) save(path string) error {
+	bb, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, bb, 0644)
+}
+
+// compare the response to the golden response at path, if recorded,
+// returning an error with their differences if they differ.
+func (r invokeResponse) compare(path string) error {
+	bb, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var golden invokeResponse
+	if err = yaml.Unmarshal(bb, &golden); err != nil {
+		return fmt.Errorf("invalid golden response '%v': %v", path, err)
+	}
+	if diff := cmp.Diff(golden, r); diff != "" {
+		return fmt.Errorf("response differs from the golden response recorded in %v (-recorded +received):\n%v", path, diff)
+	}
+	return nil
+}
+
+type invokeConfig struct {
+	Path          string
+	Namespace     string
+	Target        string
+	Format        string
+	Data          string
+	ContentType   string
+	Type          string
+	Source        string
+	Mode          string
+	Extensions    map[string]string
+	Save          bool
+	Golden        string
+	IgnoreHeaders []string
+	Verbose       bool
+
+	// Timeout of each request, if positive.
+	Timeout time.Duration
+
+	// Count of requests sent, Concurrency of them at a time, as a load check
+	// which fails if the percentage of them which fail exceeds FailThreshold.
+	Count         int
+	Concurrency   int
+	FailThreshold float64
+}
+
+func newInvokeConfig() (invokeConfig, error) {
+	c := invokeConfig{
+		Path:          viper.GetString("path"),
+		Namespace:     viper.GetString("namespace"),
+		Target:        viper.GetString("target"),
+		Format:        viper.GetString("format"),
+		Data:          viper.GetString("data"),
+		ContentType:   viper.GetString("content-type"),
+		Type:          viper.GetString("type"),
+		Source:        viper.GetString("source"),
+		Mode:          viper.GetString("mode"),
+		Save:          viper.GetBool("save"),
+		Golden:        viper.GetString("golden"),
+		Verbose:       viper.GetBool("verbose"),
+		Timeout:       viper.GetDuration("timeout"),
+		Count:         viper.GetInt("count"),
+		Concurrency:   viper.GetInt("concurrency"),
+		FailThreshold: viper.GetFloat64("fail-threshold"),
+	}
+	if err := cloudevents.ValidateMode(c.Mode); err != nil {
+		return c, fmt.Errorf("invalid value '%v' for --mode: %v", c.Mode, err)
+	}
+	if c.Timeout < 0 {
+		return c, fmt.Errorf("invalid value '%v' for --timeout: must not be negative", c.Timeout)
+	}
+	if c.Count < 1 {
+		return c, fmt.Errorf("invalid value '%v' for --count: must be at least 1", c.Count)
+	}
+	if c.Concurrency < 1 {
+		return c, fmt.Errorf("invalid value '%v' for --concurrency: must be at least 1", c.Concurrency)
+	}
+	if c.FailThreshold < 0 || c.FailThreshold > 100 {
+		return c, fmt.Errorf("invalid value '%v' for --fail-threshold: must be a percentage from 0 to 100", c.FailThreshold)
+	}
+	if c.Count > 1 && c.Save {
+		return c, fmt.Errorf("the response can not be recorded with --save when sending --count requests")
+	}
+	return c, nil
+}
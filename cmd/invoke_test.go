@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+)
+
+type testDescriber struct {
+	routes []string
+}
+
+func (t *testDescriber) Describe(ctx context.Context, name string) (fn.Description, error) {
+	return fn.Description{Name: name, Routes: t.routes}, nil
+}
+
+// TestInvoke ensures that functions are sent an HTTP request or a CloudEvent
+// per their template, at the URL of the deployed function by default, and
+// that the response status and body are printed.
+func TestInvoke(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		args     []string
+		event    bool
+	}{
+		{"http", "http", nil, false},
+		{"events", "events", nil, true},
+		{"explicit format", "http", []string{"--format", "cloudevent"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer fromTempDir(t)()
+
+			f := fn.Function{Name: "myfunc", Root: "myfunc", Runtime: "go", Template: tt.template}
+			if err := fn.New().Create(f); err != nil {
+				t.Fatal(err)
+			}
+
+			var received *http.Request
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = ioutil.ReadAll(r.Body)
+				w.Write([]byte("OK"))
+			}))
+			defer server.Close()
+
+			out := &bytes.Buffer{}
+			cmd := NewInvokeCmd(func(string) (fn.Describer, error) {
+				return &testDescriber{routes: []string{server.URL}}, nil
+			})
+			cmd.SetOut(out)
+			cmd.SetArgs(append([]string{"--path", filepath.Join(pwd(t), "myfunc"), "--data", `{"name":"Alice"}`}, tt.args...))
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			if received == nil {
+				t.Fatal("function was not invoked")
+			}
+			if received.Method != http.MethodPost {
+				t.Fatalf("expected a POST request, got %v", received.Method)
+			}
+			if string(body) != `{"name":"Alice"}` {
+				t.Fatalf("unexpected request body '%s'", body)
+			}
+			if isEvent := received.Header.Get("ce-type") != ""; isEvent != tt.event {
+				t.Fatalf("expected CloudEvent %v, got %v", tt.event, isEvent)
+			}
+			if !strings.Contains(out.String(), "200") {
+				t.Fatalf("expected the response status to be printed, got '%v'", out.String())
+			}
+		})
+	}
+}
+
+// TestInvokeCloudEventMode ensures that CloudEvents are sent in binary mode,
+// with their extensions as headers, or as the body in structured mode, and
+// that events missing their required attributes are not sent.
+func TestInvokeCloudEventMode(t *testing.T) {
+	defer fromTempDir(t)()
+	f := fn.Function{Name: "myfunc", Root: "myfunc", Runtime: "go", Template: "events"}
+	if err := fn.New().Create(f); err != nil {
+		t.Fatal(err)
+	}
+
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	invoke := func(args ...string) error {
+		received = nil
+		cmd := NewInvokeCmd(func(string) (fn.Describer, error) {
+			return &testDescriber{routes: []string{server.URL}}, nil
+		})
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"--path", filepath.Join(pwd(t), "myfunc"), "--data", `{"name":"Alice"}`}, args...))
+		return cmd.Execute()
+	}
+
+	if err := invoke("--extension", "tenant=acme"); err != nil {
+		t.Fatal(err)
+	}
+	if received.Header.Get("Ce-Tenant") != "acme" || string(body) != `{"name":"Alice"}` {
+		t.Fatalf("expected a binary CloudEvent with the extension, got %v %s", received.Header, body)
+	}
+
+	if err := invoke("--mode", "structured", "--extension", "tenant=acme"); err != nil {
+		t.Fatal(err)
+	}
+	if ct := received.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/cloudevents+json") {
+		t.Fatalf("expected a structured CloudEvent, got the Content-Type %v", ct)
+	}
+	if received.Header.Get("Ce-Type") != "" || !strings.Contains(string(body), `"tenant":"acme"`) || !strings.Contains(string(body), `"type":"boson.fn"`) {
+		t.Fatalf("expected the attributes in the body, got %v %s", received.Header, body)
+	}
+
+	for _, args := range [][]string{
+		{"--type", ""},
+		{"--source", ""},
+		{"--mode", "batched"},
+		{"--extension", "tenant"},
+		{"--extension", "Ten-ant=acme"},
+		{"--extension", "type=my.event"},
+		{"--format", "http", "--mode", "structured"},
+	} {
+		if err := invoke(args...); err == nil {
+			t.Fatalf("expected an error for %v", args)
+		}
+		if received != nil {
+			t.Fatalf("expected no request to be sent for %v", args)
+		}
+	}
+}
+
+// TestInvokeLocal ensures that invoking the local target errors suggesting
+// 'func run' when the function is not running locally, and otherwise sends
+// the request to the port recorded by the running instance.
+func TestInvokeLocal(t *testing.T) {
+	defer fromTempDir(t)()
+
+	root := filepath.Join(pwd(t), "myfunc")
+	f := fn.Function{Name: "myfunc", Root: root, Runtime: "go", Template: "http"}
+	if err := fn.New().Create(f); err != nil {
+		t.Fatal(err)
+	}
+
+	invoked := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		invoked = true
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	newCmd := func() *cobra.Command {
+		cmd := NewInvokeCmd(func(string) (fn.Describer, error) {
+			t.Fatal("the deployed function should not be described when invoking locally")
+			return nil, nil
+		})
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetArgs([]string{"--path", root, "--target", "local"})
+		return cmd
+	}
+
+	err := newCmd().Execute()
+	if err == nil || !strings.Contains(err.Error(), "func run") {
+		t.Fatalf("expected an error suggesting 'func run', got '%v'", err)
+	}
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = fn.WriteInstance(root, fn.Instance{Host: u.Hostname(), Port: u.Port()}); err != nil {
+		t.Fatal(err)
+	}
+	if err = newCmd().Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !invoked {
+		t.Fatal("the locally running function was not invoked")
+	}
+}
+
+// TestInvokeGolden ensures that a response recorded with --save is compared
+// to those of subsequent invocations, ignoring volatile headers, and that a
+// differing response is an error bearing their differences.
+func TestInvokeGolden(t *testing.T) {
+	defer fromTempDir(t)()
+
+	root := filepath.Join(pwd(t), "myfunc")
+	f := fn.Function{Name: "myfunc", Root: root, Runtime: "go", Template: "http"}
+	if err := fn.New().Create(f); err != nil {
+		t.Fatal(err)
+	}
+
+	reply := "Hello Alice"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", uuid.NewString())
+		w.Write([]byte(reply))
+	}))
+	defer server.Close()
+
+	invoke := func(args ...string) error {
+		cmd := NewInvokeCmd(func(string) (fn.Describer, error) {
+			return &testDescriber{routes: []string{server.URL}}, nil
+		})
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"--path", root}, args...))
+		return cmd.Execute()
+	}
+
+	if err := invoke("--save"); err != nil {
+		t.Fatal(err)
+	}
+	golden, err := ioutil.ReadFile(filepath.Join(root, fn.RunDataDir, "responses", "default.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(golden), "X-Request-Id") || strings.Contains(string(golden), "Date") {
+		t.Fatalf("expected volatile headers to be ignored, got:\n%s", golden)
+	}
+
+	if err = invoke(); err != nil {
+		t.Fatalf("expected an identical response to match, got '%v'", err)
+	}
+
+	reply = "Hello Bob"
+	if err = invoke(); err == nil || !strings.Contains(err.Error(), "Hello Bob") {
+		t.Fatalf("expected an error with the differences of the response, got '%v'", err)
+	}
+	if err = invoke("--golden", "other"); err != nil {
+		t.Fatalf("expected no comparison without a recorded golden response, got '%v'", err)
+	}
+}
+
+// TestInvokeLoad ensures that --count requests are sent, --concurrency at a
+// time, that a summary of them is printed, and that the command fails when
+// the percentage which fail, including those timed out, exceeds
+// --fail-threshold.
+func TestInvokeLoad(t *testing.T) {
+	defer fromTempDir(t)()
+
+	root := filepath.Join(pwd(t), "myfunc")
+	f := fn.Function{Name: "myfunc", Root: root, Runtime: "go", Template: "http"}
+	if err := fn.New().Create(f); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var received, inflight, maxInflight int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received++
+		n := received
+		inflight++
+		if inflight > maxInflight {
+			maxInflight = inflight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		if r.URL.Query().Get("slow") != "" {
+			time.Sleep(300 * time.Millisecond)
+		}
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+		if n%4 == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	invoke := func(target string, args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		cmd := NewInvokeCmd(func(string) (fn.Describer, error) {
+			return &testDescriber{routes: []string{target}}, nil
+		})
+		cmd.SetOut(out)
+		cmd.SetArgs(append([]string{"--path", root}, args...))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := invoke(server.URL, "--count", "20", "--concurrency", "4", "--fail-threshold", "25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != 20 {
+		t.Fatalf("expected 20 requests, got %v", received)
+	}
+	if maxInflight > 4 {
+		t.Fatalf("expected at most 4 requests at a time, got %v", maxInflight)
+	}
+	for _, s := range []string{"Requests:", "p99", "500 Internal Server Error", "5 (25.00%)"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("expected the summary to contain '%v', got:\n%v", s, out)
+		}
+	}
+
+	received = 0
+	if _, err = invoke(server.URL, "--count", "20", "--concurrency", "4"); err == nil || !strings.Contains(err.Error(), "25.00%") {
+		t.Fatalf("expected an error for the failed requests exceeding the threshold, got %v", err)
+	}
+
+	received = 0
+	out, err = invoke(server.URL+"?slow=1", "--count", "2", "--timeout", "50ms", "--fail-threshold", "100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "2 (100.00%)") || !strings.Contains(out, "deadline exceeded") {
+		t.Fatalf("expected the requests to time out, got:\n%v", out)
+	}
+
+	if _, err = invoke(server.URL, "--count", "2", "--save"); err == nil {
+		t.Fatal("expected an error recording a response of a number of requests")
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/progress"
+)
+
+// jsonLogs of the command executed with --json-logs, nil otherwise.
+var jsonLogs *commandLogs
+
+// commandLogs are the events of a command executed with --json-logs, written
+// as NDJSON to the original stdout.  All else the command writes to stdout
+// and stderr, itself or by the libraries it uses, is redirected through pipes
+// such that no human output is written: its stdout is its result, and each
+// line of its stderr is a warning.
+type commandLogs struct {
+	events         *progress.NDJSON
+	stdout, stderr *os.File // restored once stopped
+	outW, errW     *os.File // in their place
+	result         bytes.Buffer
+	wg             sync.WaitGroup
+}
+
+// startJSONLogs of the command when executed with --json-logs, emitting its
+// start.  The logs of a command are started once, by the first to run.
+func startJSONLogs(cmd *cobra.Command, args []string) (err error) {
+	if !viper.GetBool("json-logs") || jsonLogs != nil {
+		return
+	}
+	if output := cmd.Flags().Lookup("output"); output != nil && output.Changed && output.Value.String() == "human" {
+		return fmt.Errorf("the --json-logs flag conflicts with human output. Provide --output json, or omit --output")
+	}
+
+	l := &commandLogs{events: progress.NewNDJSON(os.Stdout, cmd.CommandPath()), stdout: os.Stdout, stderr: os.Stderr}
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		return
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		outR.Close()
+		outW.Close()
+		return
+	}
+	l.outW, l.errW = outW, errW
+	l.wg.Add(2)
+	go func() {
+		defer l.wg.Done()
+		_, _ = io.Copy(&l.result, outR)
+		outR.Close()
+	}()
+	go func() {
+		defer l.wg.Done()
+		_ = l.events.Lines(errR, progress.LevelWarning, progress.EventWarning)
+		errR.Close()
+	}()
+	os.Stdout, os.Stderr = outW, errW
+	jsonLogs = l
+
+	l.events.Emit(progress.LevelInfo, progress.EventStart, "Running "+cmd.CommandPath(), map[string]interface{}{"args": args})
+	return
+}
+
+// StopJSONLogs of the command executed with --json-logs, if any, restoring
+// stdout and stderr and emitting its result and the error with which it
+// failed, if any, with the exit code of the error.  Returns whether logs were
+// stopped, such that the error need not be otherwise reported.
+func StopJSONLogs(ctx context.Context, err error) bool {
+	l := jsonLogs
+	if l == nil {
+		// A command which failed before running, such as with a flag which
+		// is not valid, is without logs, but its error is yet an event.
+		if err == nil || !viper.GetBool("json-logs") {
+			return false
+		}
+		l = &commandLogs{events: progress.NewNDJSON(os.Stdout, root.Name())}
+		l.emitError(ctx, err)
+		return true
+	}
+	jsonLogs = nil
+	os.Stdout, os.Stderr = l.stdout, l.stderr
+	l.outW.Close()
+	l.errW.Close()
+	l.wg.Wait()
+
+	if result := strings.TrimSpace(l.result.String()); result != "" {
+		var fields map[string]interface{}
+		if json.Valid([]byte(result)) {
+			fields = map[string]interface{}{"output": json.RawMessage(result)}
+		}
+		l.events.Emit(progress.LevelInfo, progress.EventResult, result, fields)
+	}
+	if err != nil {
+		l.emitError(ctx, err)
+	}
+	return true
+}
+
+// emitError of the command, with the exit code of the process and the
+// problems of a Function which is not valid, if any.
+func (l *commandLogs) emitError(ctx context.Context, err error) {
+	fields := map[string]interface{}{"exitCode": exitCode(ctx, err)}
+	var verr fn.ValidationError
+	if errors.As(err, &verr) {
+		fields["validation"] = verr
+	}
+	l.events.Emit(progress.LevelError, progress.EventError, err.Error(), fields)
+}
+
+// jsonEvents returns the events of the command executed with --json-logs,
+// nil otherwise.
+func jsonEvents() *progress.NDJSON {
+	if jsonLogs == nil {
+		return nil
+	}
+	return jsonLogs.events
+}
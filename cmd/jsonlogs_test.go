@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	"github.com/boson-project/func/progress"
+)
+
+// TestJSONLogs ensures that a command run with --json-logs writes only NDJSON
+// events to stdout: its start, progress, the lines of its stderr as warnings,
+// its stdout as its result and its error, and that human output conflicts.
+func TestJSONLogs(t *testing.T) {
+	stdout, err := ioutil.TempFile(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(original *os.File) { os.Stdout = original }(os.Stdout)
+	os.Stdout = stdout
+
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{
+			Use: "deploy",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				listener := newProgressListener(os.Stdout, false)
+				listener.SetTotal(2)
+				listener.Increment("Building function image")
+				fmt.Fprintln(os.Stderr, "Warning: the registry is insecure")
+				fmt.Fprintln(cmd.OutOrStdout(), "Function deployed at URL: http://myfunc.example.com")
+				listener.Complete("Done")
+				return errors.New("the revision is not ready")
+			},
+		}
+		cmd.Flags().StringP("output", "o", "", "")
+		return cmd
+	}
+	parent := withJSONLogs(t, newCmd())
+	parent.SetArgs([]string{"deploy", "--json-logs"})
+	err = parent.Execute()
+	if !StopJSONLogs(context.Background(), err) {
+		t.Fatal("expected the logs of the command to be stopped")
+	}
+	if os.Stdout != stdout {
+		t.Fatal("expected stdout to be restored")
+	}
+
+	if _, err = stdout.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	var events []progress.Event
+	s := bufio.NewScanner(stdout)
+	for s.Scan() {
+		var e progress.Event
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatalf("expected only NDJSON, got %q: %v", s.Text(), err)
+		}
+		if e.Command != "func deploy" || e.TS.IsZero() {
+			t.Fatalf("expected the command and time of each event, got %+v", e)
+		}
+		events = append(events, e)
+	}
+	// Warnings are emitted as they are read from stderr, and so are ordered
+	// only before the result.
+	var got, warnings []string
+	for _, e := range events {
+		if e.Event == progress.EventWarning {
+			warnings = append(warnings, e.Level+": "+e.Message)
+			continue
+		}
+		got = append(got, e.Level+"/"+e.Event+": "+e.Message)
+	}
+	expected := []string{
+		"info/start: Running func deploy",
+		"info/progress: Building function image",
+		"info/progress: Done",
+		"info/result: Function deployed at URL: http://myfunc.example.com",
+		"error/error: the revision is not ready",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected the events:\n%v\ngot:\n%v", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+	if len(warnings) != 1 || warnings[0] != "warning: Warning: the registry is insecure" {
+		t.Fatalf("expected the line of stderr as a warning, got %v", warnings)
+	}
+	if events[1].Fields["step"] != float64(1) || events[len(events)-1].Fields["exitCode"] != float64(1) {
+		t.Fatalf("expected the step of the progress and the exit code of the error, got %v and %v", events[1].Fields, events[len(events)-1].Fields)
+	}
+
+	parent = withJSONLogs(t, newCmd())
+	parent.SetArgs([]string{"deploy", "--json-logs", "--output", "human"})
+	if err = parent.Execute(); err == nil || !strings.Contains(err.Error(), "conflicts") {
+		t.Fatalf("expected --json-logs to conflict with human output, got %v", err)
+	}
+}
+
+// withJSONLogs returns a root of the command with the global --json-logs
+// flag, as when run by func, restoring the binding of the flag to that of
+// func once the test completes.
+func withJSONLogs(t *testing.T, cmd *cobra.Command) *cobra.Command {
+	parent := &cobra.Command{Use: "func", PersistentPreRunE: preRun, SilenceUsage: true, SilenceErrors: true}
+	parent.PersistentFlags().Bool("json-logs", false, "")
+	parent.PersistentFlags().Bool("dry-run", false, "")
+	parent.AddCommand(cmd)
+	if err := viper.BindPFlag("json-logs", parent.PersistentFlags().Lookup("json-logs")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = viper.BindPFlag("json-logs", root.PersistentFlags().Lookup("json-logs"))
+		_ = viper.BindPFlag("dry-run", root.PersistentFlags().Lookup("dry-run"))
+	})
+	return parent
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"text/tabwriter"
+	"time"
 
 	"github.com/ory/viper"
 	"github.com/spf13/cobra"
@@ -18,9 +19,9 @@ import (
 
 func init() {
 	root.AddCommand(listCmd)
-	listCmd.Flags().BoolP("all-namespaces", "A", false, "List functions in all namespaces. If set, the --namespace flag is ignored.")
+	listCmd.Flags().BoolP("all-namespaces", "A", false, "List functions in all namespaces. Conflicts with --namespace.")
 	listCmd.Flags().StringP("namespace", "n", "", "Namespace to search for functions. By default, the functions of the actual active namespace are listed. (Env: $FUNC_NAMESPACE)")
-	listCmd.Flags().StringP("output", "o", "human", "Output format (human|plain|json|xml|yaml) (Env: $FUNC_OUTPUT)")
+	listCmd.Flags().StringP("output", "o", "human", "Output format (human|plain|wide|json|xml|yaml), or a Go template of the list as go-template=TEMPLATE or go-template-file=PATH (Env: $FUNC_OUTPUT)")
 	err := listCmd.RegisterFlagCompletionFunc("output", CompleteOutputFormatList)
 	if err != nil {
 		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
@@ -38,31 +39,48 @@ Lists all deployed functions in a given namespace.
 # List all functions in the current namespace with human readable output
 kn func list
 
+# List all functions in the current namespace with their revision, image
+# digest, deploy time, ready replicas and scale bounds
+kn func list --output wide
+
 # List all functions in the 'test' namespace with yaml output
 kn func list --namespace test --output yaml
 
 # List all functions in all namespaces with JSON output
 kn func list --all-namespaces --output json
+
+# List the URLs of all functions in the current namespace
+kn func list --output go-template='{{range .}}{{.URL}}{{"\n"}}{{end}}'
 `,
-	SuggestFor: []string{"ls", "lsit"},
-	PreRunE:    bindEnv("namespace", "output"),
-	RunE:       runList,
+	SuggestFor:  []string{"ls", "lsit"},
+	PreRunE:     bindEnv("namespace", "output"),
+	Annotations: map[string]string{dryRunAnnotation: dryRunReadOnly},
+	RunE:        runList,
 }
 
 func runList(cmd *cobra.Command, args []string) (err error) {
 	config := newListConfig()
+	if _, err = outputTemplate(config.Output); err != nil {
+		return
+	}
 
-	lister, err := knative.NewLister(config.Namespace)
+	all, err := allNamespaces(cmd)
 	if err != nil {
 		return
 	}
-	lister.Verbose = config.Verbose
 
-	a, err := cmd.Flags().GetBool("all-namespaces")
+	if err = configureClusterAccess(); err != nil {
+		return
+	}
+
+	lister, err := knative.NewLister(config.Namespace)
 	if err != nil {
 		return
 	}
-	if a {
+	lister.Verbose = config.Verbose
+	lister.Wide = config.Output == Wide
+
+	if all {
 		lister.Namespace = ""
 	}
 
@@ -76,7 +94,11 @@ func runList(cmd *cobra.Command, args []string) (err error) {
 	}
 
 	if len(items) < 1 {
-		fmt.Printf("No functions found in %v namespace\n", lister.Namespace)
+		if all {
+			fmt.Fprintln(infoOut(os.Stdout), "No functions found in any namespace")
+		} else {
+			fmt.Fprintf(infoOut(os.Stdout), "No functions found in %v namespace\n", lister.Namespace)
+		}
 		return
 	}
 
@@ -108,7 +130,7 @@ func newListConfig() listConfig {
 type listItems []fn.ListItem
 
 func (items listItems) Human(w io.Writer) error {
-	return items.Plain(w)
+	return items.Plain(boldHeader(w))
 }
 
 func (items listItems) Plain(w io.Writer) error {
@@ -124,6 +146,26 @@ func (items listItems) Plain(w io.Writer) error {
 	return nil
 }
 
+// Wide writes the table of the items with the details of their latest ready
+// revision and their scale bounds, those not set being '-'.
+func (items listItems) Wide(w io.Writer) error {
+	tabWriter := tabwriter.NewWriter(boldHeader(w), 0, 8, 2, ' ', 0)
+	defer tabWriter.Flush()
+
+	fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		"NAME", "NAMESPACE", "RUNTIME", "URL", "READY", "REVISION", "IMAGE DIGEST", "DEPLOYED", "REPLICAS", "MIN", "MAX")
+	for _, item := range items {
+		deployed := ""
+		if item.Deployed != nil {
+			deployed = item.Deployed.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			item.Name, item.Namespace, orDash(item.Runtime), item.URL, item.Ready, orDash(item.Revision), orDash(item.ImageDigest), orDash(deployed),
+			item.ReadyReplicas, orDash(item.MinScale), orDash(item.MaxScale))
+	}
+	return nil
+}
+
 func (items listItems) JSON(w io.Writer) error {
 	return json.NewEncoder(w).Encode(items)
 }
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestListWide ensures the wide table of functions includes the details of
+// their latest ready revision and their scale bounds, aligned and uncolored
+// when not to a terminal, and '-' for those not set.
+func TestListWide(t *testing.T) {
+	deployed := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	items := listItems{
+		{Name: "myfunc", Namespace: "test", Runtime: "go", URL: "http://myfunc.test.example.com", Ready: "True",
+			Revision: "myfunc-00002", ImageDigest: "sha256:b389b0", Deployed: &deployed, ReadyReplicas: 2, MaxScale: "10"},
+		{Name: "other", Namespace: "test", URL: "http://other.test.example.com", Ready: "Unknown"},
+	}
+
+	var b bytes.Buffer
+	if err := items.Wide(&b); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 || strings.Contains(b.String(), "\x1b[") {
+		t.Fatalf("expected an uncolored table of two functions, got:\n%v", b.String())
+	}
+	if !strings.HasPrefix(lines[0], "NAME") || !strings.Contains(lines[0], "IMAGE DIGEST") || !strings.HasSuffix(lines[0], "MAX") {
+		t.Fatalf("expected the wide columns, got:\n%v", lines[0])
+	}
+	if strings.Join(strings.Fields(lines[1]), " ") != "myfunc test go http://myfunc.test.example.com True myfunc-00002 sha256:b389b0 2022-03-01T12:00:00Z 2 - 10" {
+		t.Fatalf("unexpected row:\n%v", lines[1])
+	}
+	if strings.Join(strings.Fields(lines[2]), " ") != "other test - http://other.test.example.com Unknown - - - 0 - -" {
+		t.Fatalf("unexpected row:\n%v", lines[2])
+	}
+	if strings.Index(lines[1], "sha256") != strings.Index(lines[0], "IMAGE DIGEST") {
+		t.Fatalf("expected the columns to be aligned, got:\n%v", b.String())
+	}
+}
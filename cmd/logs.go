@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/knative"
+)
+
+func init() {
+	root.AddCommand(NewLogsCmd(newLogsLogger))
+}
+
+// newLogsLogger returns the logger used to stream the logs of the deployed
+// Function.
+func newLogsLogger(namespace string) (fn.Logger, error) {
+	return knative.NewLogger(namespace)
+}
+
+// NewLogsCmd creates a logs command which streams the logs of the deployed
+// Function using loggers obtained from the given constructor.
+func NewLogsCmd(newLogger func(namespace string) (fn.Logger, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs [NAME]",
+		Short: "Stream the logs of a deployed function",
+		Long: `Stream the logs of a deployed function
+
+Prints the logs of the pods of the deployed function's latest revision.  When
+the function is scaled to more than one pod, each line is prefixed with the
+name of the pod from which it was read.  With --follow, logs are streamed as
+they are written until interrupted.
+
+The function is that of the current directory or that specified with --path,
+unless its name is given.
+`,
+		Example: `
+# Print the logs of the function in the current directory
+kn func logs
+
+# Follow the logs of the function "myfunc" written in the last 10 minutes
+kn func logs myfunc --follow --since 10m
+
+# Print the last 20 lines of the logs of each pod of the function
+kn func logs --tail 20
+`,
+		SuggestFor:        []string{"log", "lgos"},
+		ValidArgsFunction: CompleteFunctionList,
+		Args:              cobra.MaximumNArgs(1),
+		PreRunE:           bindEnv("path", "namespace", "follow", "since", "tail"),
+		Annotations:       map[string]string{dryRunAnnotation: dryRunReadOnly},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLogs(cmd, args, newLogger)
+		},
+	}
+
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace of the function. By default, the namespace in func.yaml is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
+	cmd.Flags().BoolP("follow", "f", false, "Stream logs as they are written until interrupted (Env: $FUNC_FOLLOW)")
+	cmd.Flags().Duration("since", 0, "Only print logs newer than the given duration, such as 10m or 1h. By default all are printed (Env: $FUNC_SINCE)")
+	cmd.Flags().Int64("tail", -1, "Number of most recent lines of each pod's logs to print. By default all are printed (Env: $FUNC_TAIL)")
+
+	return cmd
+}
+
+func runLogs(cmd *cobra.Command, args []string, newLogger func(namespace string) (fn.Logger, error)) (err error) {
+	config := newLogsConfig(args)
+
+	if config.Since < 0 {
+		return fmt.Errorf("invalid value '%v' for --since: must not be negative", config.Since)
+	}
+	if config.Name == "" {
+		return fmt.Errorf("the given path '%v' does not contain an initialized function. Please provide the name of the function", config.Path)
+	}
+
+	namespace := config.Namespace
+	if namespace == "" {
+		if f, err := fn.NewFunctionFromFile(config.Path, configFile()); err == nil && f.Name == config.Name {
+			namespace = f.Namespace
+		}
+	}
+
+	if err = configureClusterAccess(); err != nil {
+		return
+	}
+	logger, err := newLogger(namespace)
+	if err != nil {
+		return
+	}
+
+	return logger.Logs(cmd.Context(), config.Name, cmd.OutOrStdout(), fn.LogsOptions{
+		Follow: config.Follow,
+		Since:  config.Since,
+		Tail:   config.Tail,
+	})
+}
+
+type logsConfig struct {
+	Name      string
+	Path      string
+	Namespace string
+	Follow    bool
+	Since     time.Duration
+	Tail      int64
+}
+
+func newLogsConfig(args []string) logsConfig {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+	return logsConfig{
+		Name:      deriveName(name, viper.GetString("path")),
+		Path:      viper.GetString("path"),
+		Namespace: viper.GetString("namespace"),
+		Follow:    viper.GetBool("follow"),
+		Since:     viper.GetDuration("since"),
+		Tail:      viper.GetInt64("tail"),
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	fn "github.com/boson-project/func"
+)
+
+type testLogger struct {
+	name    string
+	options fn.LogsOptions
+}
+
+func (l *testLogger) Logs(ctx context.Context, name string, out io.Writer, options fn.LogsOptions) error {
+	l.name, l.options = name, options
+	return nil
+}
+
+// TestLogs ensures that the logs of the function of the current directory
+// are requested with the options given.
+func TestLogs(t *testing.T) {
+	defer fromTempDir(t)()
+
+	if err := fn.New().Create(fn.Function{Root: "myfunc", Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := &testLogger{}
+	cmd := NewLogsCmd(func(string) (fn.Logger, error) {
+		return logger, nil
+	})
+	cmd.SetArgs([]string{"--path", "myfunc", "--follow", "--since", "10m", "--tail", "20"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if logger.name != "myfunc" {
+		t.Fatalf("expected the logs of 'myfunc', got '%v'", logger.name)
+	}
+	expected := fn.LogsOptions{Follow: true, Since: 10 * time.Minute, Tail: 20}
+	if logger.options != expected {
+		t.Fatalf("expected options %+v, got %+v", expected, logger.options)
+	}
+}
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	fn "github.com/boson-project/func"
+)
+
+func init() {
+	root.AddCommand(NewProfileCmd())
+}
+
+// profileSettings are the keys of the settings a profile holds, which are
+// also those of the flags of which it sets the defaults.
+var profileSettings = []string{"registry", "namespace", "builder", "kubeconfig", "context", "registry-mirrors"}
+
+// validProfileName is the form of the names of profiles.
+var validProfileName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// NewProfileCmd creates a profile command, and its subcommands, which manage
+// the named profiles of the defaults of the CLI.
+func NewProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage profiles of defaults, such as dev and ci",
+		Long: `Manage profiles of defaults, such as dev and ci
+
+A profile is a named set of defaults of the registry, namespace, builder,
+kubeconfig, context and registry mirrors of commands, stored in profiles.yaml of the config
+directory (by default ~/.config/func).  That of the global --profile flag (or
+$FUNC_PROFILE) is used, or otherwise the current profile, if any, as set with
+'func profile use'.
+
+The flags of a command, and their environment variables, always override the
+values of its profile.
+`,
+		Aliases:    []string{"profiles"},
+		SuggestFor: []string{"porfile", "profle"},
+	}
+	cmd.AddCommand(newProfileSetCmd())
+	cmd.AddCommand(newProfileListCmd())
+	cmd.AddCommand(newProfileUseCmd())
+	return cmd
+}
+
+func newProfileSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <name>",
+		Short: "Create or update a profile",
+		Long: `Create or update a profile
+
+Sets the values of the profile given with its flags, creating it if it does
+not exist.  Those not given are left as they are, and those given as empty are
+unset.
+`,
+		Example: `
+# Create the "dev" profile, deploying to the dev namespace of the kind cluster
+kn func profile set dev --registry localhost:5000 --namespace dev --context kind-kind
+
+# Unset the namespace of the "dev" profile
+kn func profile set dev --namespace ""
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: runProfileSet,
+	}
+	cmd.Flags().String("registry", "", "Registry + namespace part of the images of functions, ex 'quay.io/myuser'")
+	cmd.Flags().String("namespace", "", "Namespace of functions")
+	cmd.Flags().String("builder", "", "Buildpack builder of functions, as an image name or a mapping name")
+	cmd.Flags().String("kubeconfig", "", "Path to the kubeconfig file to use for cluster operations")
+	cmd.Flags().String("context", "", "Name of the kubeconfig context to use for cluster operations")
+	cmd.Flags().String("registry-mirrors", "", "Registry mirrors of the images functions are built with and deployed, as comma separated PREFIX=MIRROR pairs")
+	return cmd
+}
+
+func runProfileSet(cmd *cobra.Command, args []string) (err error) {
+	name := args[0]
+	if !validProfileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name '%v': must consist of letters, digits, '-', '_' and '.', starting with a letter or digit", name)
+	}
+	pp, err := readProfiles()
+	if err != nil {
+		return
+	}
+	p := pp.Profiles[name]
+	if p == nil {
+		p = profile{}
+	}
+	for _, key := range profileSettings {
+		if !cmd.Flags().Changed(key) {
+			continue
+		}
+		value, _ := cmd.Flags().GetString(key)
+		if key == "registry-mirrors" {
+			if _, err = fn.ParseRegistryMirrors(value); err != nil {
+				return fmt.Errorf("invalid value '%v' for --registry-mirrors: %v", value, err)
+			}
+		}
+		if value != "" {
+			p[key] = value
+		} else {
+			delete(p, key)
+		}
+	}
+	if pp.Profiles == nil {
+		pp.Profiles = map[string]profile{}
+	}
+	pp.Profiles[name] = p
+	if err = writeProfiles(pp); err != nil {
+		return
+	}
+	fmt.Fprintf(infoOut(cmd.OutOrStdout()), "Profile '%v' set\n", name)
+	return
+}
+
+func newProfileListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List profiles",
+		Long: `List profiles
+
+Lists the profiles with their values, marking the current profile.
+`,
+		Example: `
+# List the profiles as YAML
+kn func profile list --output yaml
+`,
+		Aliases:     []string{"ls"},
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{dryRunAnnotation: dryRunReadOnly},
+		PreRunE:     bindEnv("output"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pp, err := readProfiles()
+			if err != nil {
+				return err
+			}
+			if len(pp.Profiles) == 0 {
+				fmt.Fprintf(infoOut(cmd.OutOrStdout()), "No profiles in %v\n", profilesPath())
+				return nil
+			}
+			write(cmd.OutOrStdout(), pp.infos(), viper.GetString("output"))
+			return nil
+		},
+	}
+	cmd.Flags().StringP("output", "o", "human", "Output format (human|plain|json|xml|yaml) (Env: $FUNC_OUTPUT)")
+	if err := cmd.RegisterFlagCompletionFunc("output", CompleteOutputFormatList); err != nil {
+		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
+	}
+	return cmd
+}
+
+func newProfileUseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use <name>",
+		Short: "Set the current profile",
+		Long: `Set the current profile
+
+Sets the profile used by commands run without --profile.  With --none, no
+profile is used.
+`,
+		Example: `
+# Use the "ci" profile
+kn func profile use ci
+
+# Use no profile
+kn func profile use --none
+`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: CompleteProfileList,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			none, err := cmd.Flags().GetBool("none")
+			if err != nil {
+				return err
+			}
+			if none == (len(args) == 1) {
+				return fmt.Errorf("provide either the name of a profile or --none")
+			}
+			pp, err := readProfiles()
+			if err != nil {
+				return err
+			}
+			if none {
+				pp.Current = ""
+			} else if _, ok := pp.Profiles[args[0]]; !ok {
+				return fmt.Errorf("profile '%v' not found. Create it with 'func profile set %v'", args[0], args[0])
+			} else {
+				pp.Current = args[0]
+			}
+			if err = writeProfiles(pp); err != nil {
+				return err
+			}
+			if none {
+				fmt.Fprintln(infoOut(cmd.OutOrStdout()), "No profile in use")
+			} else {
+				fmt.Fprintf(infoOut(cmd.OutOrStdout()), "Using profile '%v'\n", pp.Current)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Bool("none", false, "Use no profile")
+	return cmd
+}
+
+// Profiles (persistence)
+// ----------------------
+
+// profile is the values of the settings of a named profile, by key.
+type profile map[string]string
+
+// profiles are those of profiles.yaml, and the name of the current profile.
+type profiles struct {
+	Current  string             `yaml:"current,omitempty"`
+	Profiles map[string]profile `yaml:"profiles,omitempty"`
+}
+
+// profilesPath is the path of the profiles file in the config directory.
+func profilesPath() string {
+	return filepath.Join(configPath(), "profiles.yaml")
+}
+
+// readProfiles of the profiles file, none if it does not exist.
+func readProfiles() (pp profiles, err error) {
+	bb, err := ioutil.ReadFile(profilesPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return pp, nil
+	} else if err != nil {
+		return
+	}
+	if err = yaml.Unmarshal(bb, &pp); err != nil {
+		err = fmt.Errorf("failed to parse %v: %w", profilesPath(), err)
+	}
+	return
+}
+
+// writeProfiles to the profiles file, creating the config directory.
+func writeProfiles(pp profiles) error {
+	bb, err := yaml.Marshal(pp)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(configPath(), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(profilesPath(), bb, 0644)
+}
+
+// applyProfile sets the values of the profile of the global --profile flag,
+// or else of the current profile, if any, as the defaults of its settings,
+// below those of flags and environment variables.  A profile which does not
+// exist is an error.
+func applyProfile() error {
+	pp, err := readProfiles()
+	if err != nil {
+		return err
+	}
+	name := viper.GetString("profile")
+	if name == "" {
+		name = pp.Current
+	}
+	p, ok := pp.Profiles[name]
+	if name != "" && !ok {
+		return fmt.Errorf("profile '%v' not found. Create it with 'func profile set %v'", name, name)
+	}
+	for _, key := range profileSettings {
+		// Unset values are nil, such that those of a profile applied before
+		// (as in tests) are not retained.
+		var value interface{}
+		if p[key] != "" {
+			value = p[key]
+		}
+		viper.SetDefault(key, value)
+	}
+	return nil
+}
+
+// Output Formatting (serializers)
+// -------------------------------
+
+// profileInfo is a profile as listed.
+type profileInfo struct {
+	Name       string `json:"name" yaml:"name" xml:"name,attr"`
+	Current    bool   `json:"current" yaml:"current" xml:"current,attr"`
+	Registry   string `json:"registry,omitempty" yaml:"registry,omitempty" xml:"registry,omitempty"`
+	Namespace  string `json:"namespace,omitempty" yaml:"namespace,omitempty" xml:"namespace,omitempty"`
+	Builder    string `json:"builder,omitempty" yaml:"builder,omitempty" xml:"builder,omitempty"`
+	Kubeconfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty" xml:"kubeconfig,omitempty"`
+	Context    string `json:"context,omitempty" yaml:"context,omitempty" xml:"context,omitempty"`
+
+	RegistryMirrors string `json:"registryMirrors,omitempty" yaml:"registryMirrors,omitempty" xml:"registryMirrors,omitempty"`
+}
+
+type profileInfos []profileInfo
+
+// infos of the profiles, by name.
+func (pp profiles) infos() (ii profileInfos) {
+	for name, p := range pp.Profiles {
+		ii = append(ii, profileInfo{
+			Name:       name,
+			Current:    name == pp.Current,
+			Registry:   p["registry"],
+			Namespace:  p["namespace"],
+			Builder:    p["builder"],
+			Kubeconfig: p["kubeconfig"],
+			Context:    p["context"],
+
+			RegistryMirrors: p["registry-mirrors"],
+		})
+	}
+	sort.Slice(ii, func(i, j int) bool { return ii[i].Name < ii[j].Name })
+	return
+}
+
+func (ii profileInfos) Human(w io.Writer) error {
+	// minwidth, tabwidth, padding, padchar, flags
+	tabWriter := tabwriter.NewWriter(boldHeader(w), 0, 8, 2, ' ', 0)
+	defer tabWriter.Flush()
+
+	fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", "NAME", "REGISTRY", "NAMESPACE", "BUILDER", "KUBECONFIG", "CONTEXT", "REGISTRY MIRRORS")
+	for _, i := range ii {
+		name := i.Name
+		if i.Current {
+			name += "*"
+		}
+		fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", name, orDash(i.Registry), orDash(i.Namespace), orDash(i.Builder), orDash(i.Kubeconfig), orDash(i.Context), orDash(i.RegistryMirrors))
+	}
+	return nil
+}
+
+func (ii profileInfos) Plain(w io.Writer) error {
+	for _, i := range ii {
+		fmt.Fprintf(w, "%s %t %s %s %s %s %s %s\n", i.Name, i.Current, orDash(i.Registry), orDash(i.Namespace), orDash(i.Builder), orDash(i.Kubeconfig), orDash(i.Context), orDash(i.RegistryMirrors))
+	}
+	return nil
+}
+
+func (ii profileInfos) JSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(ii)
+}
+
+func (ii profileInfos) XML(w io.Writer) error {
+	return xml.NewEncoder(w).Encode(struct {
+		XMLName  xml.Name      `xml:"profiles"`
+		Profiles []profileInfo `xml:"profile"`
+	}{Profiles: ii})
+}
+
+func (ii profileInfos) YAML(w io.Writer) error {
+	return yaml.NewEncoder(w).Encode(ii)
+}
+
+func (ii profileInfos) URL(w io.Writer) error {
+	return fmt.Errorf("the url output format is not supported by the profile list command")
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+)
+
+// TestProfile ensures profiles are set, used and listed, marking the current
+// profile, and that only the values given are changed.
+func TestProfile(t *testing.T) {
+	withConfigPath(t)
+
+	run := func(args ...string) string {
+		t.Helper()
+		out := &bytes.Buffer{}
+		cmd := NewProfileCmd()
+		cmd.SetOut(out)
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+	run("set", "dev", "--registry", "localhost:5000", "--namespace", "dev")
+	run("set", "ci", "--registry", "quay.io/alice", "--context", "ci", "--registry-mirrors", "gcr.io=registry.internal/gcr-mirror")
+	run("set", "dev", "--namespace", "", "--builder", "pack")
+	run("use", "dev")
+
+	expected := "ci false quay.io/alice - - - ci gcr.io=registry.internal/gcr-mirror\ndev true localhost:5000 - pack - - -\n"
+	if out := run("list", "--output", "plain"); out != expected {
+		t.Fatalf("expected the profiles:\n%v\ngot:\n%v", expected, out)
+	}
+
+	cmd := NewProfileCmd()
+	cmd.SetArgs([]string{"use", "prod"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected an unknown profile not to be used, got %v", err)
+	}
+
+	cmd = NewProfileCmd()
+	cmd.SetArgs([]string{"set", "ci", "--registry-mirrors", "gcr.io"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--registry-mirrors") {
+		t.Fatalf("expected invalid registry mirrors not to be set, got %v", err)
+	}
+}
+
+// TestProfilePrecedence ensures the values of a profile are the defaults of
+// the flags of a command, overridden by their environment variables and the
+// flags themselves: flag > env > profile > default.
+func TestProfilePrecedence(t *testing.T) {
+	withConfigPath(t)
+	if err := writeProfiles(profiles{
+		Current: "dev",
+		Profiles: map[string]profile{
+			"dev": {"registry": "localhost:5000"},
+			"ci":  {"registry": "quay.io/ci"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("FUNC_REGISTRY", os.Getenv("FUNC_REGISTRY"))
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		expected string
+	}{
+		{"current profile", nil, "", "localhost:5000"},
+		{"profile flag", []string{"--profile", "ci"}, "", "quay.io/ci"},
+		{"env", []string{"--profile", "ci"}, "quay.io/env", "quay.io/env"},
+		{"flag", []string{"--profile", "ci", "--registry", "quay.io/flag"}, "quay.io/env", "quay.io/flag"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			os.Setenv("FUNC_REGISTRY", test.env)
+			if test.env == "" {
+				os.Unsetenv("FUNC_REGISTRY")
+			}
+			var registry string
+			cmd := &cobra.Command{
+				Use:     "build",
+				PreRunE: bindEnv("registry"),
+				RunE: func(cmd *cobra.Command, args []string) error {
+					registry = viper.GetString("registry")
+					return nil
+				},
+			}
+			cmd.Flags().String("registry", "", "")
+			parent := withProfile(t, cmd)
+			parent.SetArgs(append([]string{"build"}, test.args...))
+			if err := parent.Execute(); err != nil {
+				t.Fatal(err)
+			}
+			if registry != test.expected {
+				t.Fatalf("expected registry '%v', got '%v'", test.expected, registry)
+			}
+		})
+	}
+
+	// Without a profile, the default is that of the flag.
+	if err := writeProfiles(profiles{}); err != nil {
+		t.Fatal(err)
+	}
+	os.Unsetenv("FUNC_REGISTRY")
+	var registry string
+	cmd := &cobra.Command{
+		Use:     "build",
+		PreRunE: bindEnv("registry"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			registry = viper.GetString("registry")
+			return nil
+		},
+	}
+	cmd.Flags().String("registry", "quay.io/default", "")
+	parent := withProfile(t, cmd)
+	parent.SetArgs([]string{"build"})
+	if err := parent.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if registry != "quay.io/default" {
+		t.Fatalf("expected the default registry without a profile, got '%v'", registry)
+	}
+
+	parent = withProfile(t, &cobra.Command{Use: "build", RunE: func(*cobra.Command, []string) error { return nil }})
+	parent.SetArgs([]string{"build", "--profile", "prod"})
+	if err := parent.Execute(); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected an unknown profile to be an error, got %v", err)
+	}
+}
+
+// withConfigPath sets the config directory to a temporary directory until
+// the test completes.
+func withConfigPath(t *testing.T) {
+	t.Helper()
+	original, ok := os.LookupEnv("XDG_CONFIG_HOME")
+	os.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv("XDG_CONFIG_HOME", original)
+		} else {
+			os.Unsetenv("XDG_CONFIG_HOME")
+		}
+	})
+}
+
+// withProfile returns a root of the command with the global --profile flag,
+// as when run by func, restoring the binding of the flag to that of func, and
+// unsetting the values of the profile applied, once the test completes.
+func withProfile(t *testing.T, cmd *cobra.Command) *cobra.Command {
+	parent := &cobra.Command{Use: "func", PersistentPreRunE: preRun, SilenceUsage: true, SilenceErrors: true}
+	parent.PersistentFlags().String("profile", "", "")
+	parent.PersistentFlags().Bool("dry-run", false, "")
+	parent.AddCommand(cmd)
+	if err := viper.BindPFlag("profile", parent.PersistentFlags().Lookup("profile")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = viper.BindPFlag("profile", root.PersistentFlags().Lookup("profile"))
+		_ = viper.BindPFlag("dry-run", root.PersistentFlags().Lookup("dry-run"))
+		for _, key := range profileSettings {
+			viper.SetDefault(key, nil)
+		}
+	})
+	return parent
+}
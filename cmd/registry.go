@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/docker"
+)
+
+func init() {
+	root.AddCommand(NewRegistryCmd())
+}
+
+// NewRegistryCmd creates a registry command, and its subcommands, which
+// manage the credentials of image registries.
+func NewRegistryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "registry",
+		Short: "Manage the credentials of image registries",
+		Long: `Manage the credentials of image registries
+
+The credentials of the registries to which the images of functions are pushed
+are stored in the docker config, that of $DOCKER_CONFIG or else
+~/.docker/config.json, as by 'docker login', such that the Docker CLI is not
+required to log in.
+`,
+		SuggestFor: []string{"regsitry", "registy"},
+	}
+	cmd.AddCommand(newRegistryLoginCmd())
+	return cmd
+}
+
+func newRegistryLoginCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "login <registry>",
+		Short: "Log in to an image registry",
+		Long: `Log in to an image registry
+
+Stores the credentials of the registry in the docker config, with which the
+images of functions are pushed to it.  The registry may be given as a host,
+such as quay.io, or as the registry of functions, such as quay.io/alice.
+
+The username is that of --username, and the password is read from stdin with
+--password-stdin, such as in CI.  Otherwise, those not given are prompted for.
+
+With --get, the username of the credentials stored for the registry is printed
+instead, without the password.
+`,
+		Example: `
+# Log in to quay.io, prompting for the username and password
+kn func registry login quay.io
+
+# Log in to quay.io as alice, reading the password from stdin
+echo $PASSWORD | kn func registry login quay.io --username alice --password-stdin
+
+# Print the username of the credentials stored for quay.io
+kn func registry login quay.io --get
+`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: bindEnv("username", "password-stdin", "get"),
+		RunE:    runRegistryLogin,
+	}
+	cmd.Flags().StringP("username", "u", "", "Username of the registry (Env: $FUNC_USERNAME)")
+	cmd.Flags().Bool("password-stdin", false, "Read the password from stdin. Requires --username (Env: $FUNC_PASSWORD_STDIN)")
+	cmd.Flags().Bool("get", false, "Print the username of the credentials stored for the registry, rather than logging in (Env: $FUNC_GET)")
+	return cmd
+}
+
+func runRegistryLogin(cmd *cobra.Command, args []string) (err error) {
+	config := newRegistryLoginConfig(args)
+	if config.Registry == "" {
+		return fmt.Errorf("the registry is required, such as quay.io")
+	}
+
+	if config.Get {
+		c, ok, err := docker.StoredCredentials(config.Registry)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("no credentials are stored for registry '%v'. Log in with 'func registry login %v'", config.Registry, config.Registry)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Logged in to %v as %v\n", config.Registry, c.Username)
+		return nil
+	}
+
+	c, err := loginCredentials(config, cmd.InOrStdin())(cmd.Context(), config.Registry)
+	if err != nil {
+		return
+	}
+	if c.Username == "" || c.Password == "" {
+		return fmt.Errorf("both a username and a password are required")
+	}
+	path, err := docker.SaveCredentials(config.Registry, c)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Logged in to %v as %v. Credentials saved in %v\n", config.Registry, c.Username, path)
+	return
+}
+
+// loginCredentials returns the provider of the credentials with which to log
+// in: those of the flags and stdin with --password-stdin, or else, in an
+// interactive terminal, those prompted for.
+func loginCredentials(config registryLoginConfig, stdin io.Reader) docker.CredentialsProvider {
+	return func(ctx context.Context, registry string) (c fn.Credentials, err error) {
+		if config.PasswordStdin {
+			if config.Username == "" {
+				return c, fmt.Errorf("--password-stdin requires --username")
+			}
+			bb, err := ioutil.ReadAll(stdin)
+			if err != nil {
+				return c, fmt.Errorf("unable to read the password from stdin: %w", err)
+			}
+			return fn.Credentials{Username: config.Username, Password: strings.TrimRight(string(bb), "\r\n")}, nil
+		}
+		if !interactiveTerminal() {
+			return c, fmt.Errorf("credentials can not be prompted for in a non-interactive terminal. Provide --username and --password-stdin")
+		}
+		return askCredentials(registry, config.Username)
+	}
+}
+
+type registryLoginConfig struct {
+	// Registry logged in to, by host.
+	Registry string
+
+	// Username of the credentials, if given.
+	Username string
+
+	// PasswordStdin reads the password from stdin.
+	PasswordStdin bool
+
+	// Get prints the username of the credentials stored instead.
+	Get bool
+}
+
+func newRegistryLoginConfig(args []string) registryLoginConfig {
+	var registry string
+	if len(args) > 0 && args[0] != "" {
+		// Those given as URLs, or as the registry of functions, are of
+		// their host.
+		registry = args[0]
+		if i := strings.Index(registry, "://"); i >= 0 {
+			registry = registry[i+3:]
+		}
+		registry = registryHost(registry)
+	}
+	return registryLoginConfig{
+		Registry:      registry,
+		Username:      viper.GetString("username"),
+		PasswordStdin: viper.GetBool("password-stdin"),
+		Get:           viper.GetBool("get"),
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/boson-project/func/docker"
+)
+
+// TestRegistryLogin ensures that the credentials given with --username and
+// --password-stdin are saved in the docker config for the host of the
+// registry, and that --get prints the username stored without the password.
+func TestRegistryLogin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	os.Setenv("DOCKER_CONFIG", dir)
+
+	login := func(stdin string, args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		cmd := NewRegistryCmd()
+		cmd.SetIn(strings.NewReader(stdin))
+		cmd.SetOut(out)
+		cmd.SetArgs(append([]string{"login"}, args...))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if _, err = login("secret\n", "registry.example.com/alice", "--password-stdin"); err == nil || !strings.Contains(err.Error(), "--username") {
+		t.Fatalf("expected --password-stdin to require --username, got '%v'", err)
+	}
+
+	if _, err = login("secret\n", "registry.example.com/alice", "--username", "alice", "--password-stdin"); err != nil {
+		t.Fatal(err)
+	}
+	c, ok, err := docker.StoredCredentials("registry.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || c.Username != "alice" || c.Password != "secret" {
+		t.Fatalf("expected the credentials of alice to be stored, got %+v", c)
+	}
+
+	out, err := login("", "registry.example.com", "--get")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "alice") || strings.Contains(out, "secret") {
+		t.Fatalf("expected the username without the password, got %q", out)
+	}
+
+	if _, err = login("", "other.example.com", "--get"); err == nil || !strings.Contains(err.Error(), "no credentials") {
+		t.Fatalf("expected an error for a registry without credentials, got '%v'", err)
+	}
+}
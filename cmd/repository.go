@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	fn "github.com/boson-project/func"
+)
+
+func init() {
+	root.AddCommand(NewRepositoryCmd(newRepositoryClient))
+}
+
+// newRepositoryClient returns an instance of fn.Client for the "Repository"
+// commands, managing the repositories of the given directory.
+func newRepositoryClient(repositories string) *fn.Client {
+	return fn.New(fn.WithRepositories(repositories))
+}
+
+// NewRepositoryCmd creates a repository command, and its subcommands, using
+// the given client creator.
+func NewRepositoryCmd(newClient func(repositories string) *fn.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repository",
+		Short: "Manage template repositories",
+		Long: `Manage template repositories
+
+Template repositories provide templates for creating functions in addition to
+those embedded in func.  They are installed in the --repositories directory,
+and their templates are referred to by the short name of the repository, as
+in 'func create --template [repository]/[template]'.
+
+Repositories added from git are cloned, and may be updated to the latest of
+the ref with which they were added, with 'func repository update'.  Given
+--repositories-ttl, 'func create' also updates those not updated for longer.
+`,
+		Aliases:    []string{"repo", "repositories"},
+		SuggestFor: []string{"repostiory", "repositry"},
+	}
+	cmd.PersistentFlags().StringP("repositories", "r", filepath.Join(configPath(), "repositories"),
+		"Path to extended template repositories (Env: $FUNC_REPOSITORIES)")
+
+	cmd.AddCommand(newRepositoryAddCmd(newClient))
+	cmd.AddCommand(newRepositoryListCmd(newClient))
+	cmd.AddCommand(newRepositoryUpdateCmd(newClient))
+	cmd.AddCommand(newRepositoryRemoveCmd(newClient))
+	return cmd
+}
+
+func newRepositoryAddCmd(newClient func(repositories string) *fn.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add <url>",
+		Short: "Add a template repository from git",
+		Long: `Add a template repository from git
+
+Clones the git repository at the URL into the repositories directory.  It is
+named after the repository in the URL unless --name is given, and the branch
+or tag given by --ref is cloned, defaulting to the default branch.  The
+repository must provide templates, laid out as [runtime]/[template] or as
+declared by its manifest.yaml.
+`,
+		Example: `
+# Add the repository, naming it "boson"
+kn func repository add https://github.com/boson-project/templates.git --name boson
+
+# Create a function from its "go/http" template
+kn func create --runtime go --template boson/http myfunc
+
+# Add the repository at the given tag
+kn func repository add https://github.com/alice/templates.git --ref v1.0.0
+`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: bindEnv("repositories", "name", "ref"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := newRepositoryConfig()
+			r, err := newClient(config.Repositories).AddRepository(cmd.Context(), args[0], viper.GetString("name"), viper.GetString("ref"))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(infoOut(cmd.OutOrStdout()), "Repository '%v' added from %v\n", r.Name, r.URL)
+			return nil
+		},
+	}
+	cmd.Flags().String("name", "", "Name of the repository, with which its templates are prefixed. Defaults to that of the repository in the URL (Env: $FUNC_NAME)")
+	cmd.Flags().String("ref", "", "Branch or tag of the repository to clone. Defaults to its default branch (Env: $FUNC_REF)")
+	return cmd
+}
+
+func newRepositoryListCmd(newClient func(repositories string) *fn.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List template repositories",
+		Long: `List template repositories
+
+Lists the repositories of the repositories directory, with the git URL and ref
+of those added from git, and when each was last updated.
+`,
+		Example: `
+# List the repositories as YAML
+kn func repository list --output yaml
+`,
+		Aliases:     []string{"ls"},
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{dryRunAnnotation: dryRunReadOnly},
+		PreRunE:     bindEnv("repositories", "output"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := newRepositoryConfig()
+			rr, err := newClient(config.Repositories).RepositoryInfos(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if len(rr) == 0 {
+				fmt.Fprintf(infoOut(cmd.OutOrStdout()), "No repositories in %v\n", config.Repositories)
+				return nil
+			}
+			write(cmd.OutOrStdout(), repositoryInfos(rr), viper.GetString("output"))
+			return nil
+		},
+	}
+	cmd.Flags().StringP("output", "o", "human", "Output format (human|plain|json|xml|yaml) (Env: $FUNC_OUTPUT)")
+	if err := cmd.RegisterFlagCompletionFunc("output", CompleteOutputFormatList); err != nil {
+		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
+	}
+	return cmd
+}
+
+func newRepositoryUpdateCmd(newClient func(repositories string) *fn.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "update [name...]",
+		Short: "Update template repositories from git",
+		Long: `Update template repositories from git
+
+Re-fetches the ref with which each named repository was added, or that of each
+repository added from git if none are named, discarding any local changes.
+Repositories are fetched concurrently, and one which fails to update does not
+stop the others from being updated; the failures are reported once all are
+done.
+`,
+		Example: `
+# Update all repositories added from git
+kn func repository update
+
+# Update the "boson" repository
+kn func repository update boson
+`,
+		PreRunE: bindEnv("repositories"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := newClient(newRepositoryConfig().Repositories)
+			names := args
+			if len(names) == 0 {
+				rr, err := client.RepositoryInfos(cmd.Context())
+				if err != nil {
+					return err
+				}
+				for _, r := range rr {
+					if r.URL != "" {
+						names = append(names, r.Name)
+					}
+				}
+			}
+			// Repositories are fetched concurrently, and one failing to update
+			// does not stop the others.
+			rr, err := client.UpdateRepositories(cmd.Context(), names)
+			for _, r := range rr {
+				fmt.Fprintf(infoOut(cmd.OutOrStdout()), "Repository '%v' updated\n", r.Name)
+			}
+			return err
+		},
+	}
+}
+
+func newRepositoryRemoveCmd(newClient func(repositories string) *fn.Client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a template repository",
+		Long: `Remove a template repository
+
+Removes the named repository from the repositories directory.  Functions
+already created from its templates are not affected.
+`,
+		Example: `
+# Remove the "boson" repository
+kn func repository remove boson
+`,
+		Aliases: []string{"rm"},
+		Args:    cobra.ExactArgs(1),
+		PreRunE: bindEnv("repositories"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := newClient(newRepositoryConfig().Repositories).RemoveRepository(args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(infoOut(cmd.OutOrStdout()), "Repository '%v' removed\n", args[0])
+			return nil
+		},
+	}
+}
+
+// CLI Configuration (parameters)
+// ------------------------------
+
+type repositoryConfig struct {
+	Repositories string
+}
+
+func newRepositoryConfig() repositoryConfig {
+	return repositoryConfig{
+		Repositories: viper.GetString("repositories"),
+	}
+}
+
+// Output Formatting (serializers)
+// -------------------------------
+
+type repositoryInfos []fn.RepositoryInfo
+
+func (rr repositoryInfos) Human(w io.Writer) error {
+	// minwidth, tabwidth, padding, padchar, flags
+	tabWriter := tabwriter.NewWriter(boldHeader(w), 0, 8, 2, ' ', 0)
+	defer tabWriter.Flush()
+
+	fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\n", "NAME", "URL", "REF", "UPDATED")
+	for _, r := range rr {
+		fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\n", r.Name, orDash(r.URL), orDash(r.Ref), r.Updated.Local().Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (rr repositoryInfos) Plain(w io.Writer) error {
+	for _, r := range rr {
+		fmt.Fprintf(w, "%s %s %s %s\n", r.Name, orDash(r.URL), orDash(r.Ref), r.Updated.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// orDash returns the value, or "-" if empty, such that columns are not
+// omitted.
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func (rr repositoryInfos) JSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(rr)
+}
+
+func (rr repositoryInfos) XML(w io.Writer) error {
+	return xml.NewEncoder(w).Encode(struct {
+		XMLName      xml.Name            `xml:"repositories"`
+		Repositories []fn.RepositoryInfo `xml:"repository"`
+	}{Repositories: rr})
+}
+
+func (rr repositoryInfos) YAML(w io.Writer) error {
+	return yaml.NewEncoder(w).Encode(rr)
+}
+
+func (rr repositoryInfos) URL(w io.Writer) error {
+	return fmt.Errorf("the url output format is not supported by the repository list command")
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fn "github.com/boson-project/func"
+)
+
+// TestRepositoryList ensures the repositories are listed by name, those not
+// added from git without a URL or ref.
+func TestRepositoryList(t *testing.T) {
+	repositories, err := filepath.Abs("../testdata/repositories")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	cmd := NewRepositoryCmd(func(r string) *fn.Client {
+		return fn.New(fn.WithRepositories(r))
+	})
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"list", "--repositories", repositories, "--output", "plain"})
+	if err = cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(out.String(), "customProvider - - ") {
+		t.Fatalf("expected the customProvider repository to be listed, got:\n%v", out.String())
+	}
+}
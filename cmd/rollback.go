@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+)
+
+func init() {
+	root.AddCommand(NewRollbackCmd(newTrafficSplitter))
+}
+
+// NewRollbackCmd creates a rollback command which routes all of the traffic
+// of the deployed Function to a prior revision using splitters obtained from
+// the given constructor, which must also list its revisions.
+func NewRollbackCmd(newSplitter func(namespace string) (fn.TrafficSplitter, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback [NAME]",
+		Short: "Roll a deployed function back to a prior revision",
+		Long: `Roll a deployed function back to a prior revision
+
+Routes all of the traffic of the deployed function to the revision given with
+--to, such as when a deploy goes bad.  By default, the revision is that
+deployed before the last, as recorded in the status of func.yaml; when run
+interactively without --to, the revisions of the function are listed from
+which to choose.  Only a revision which is ready may be rolled back to.
+
+The tags of the function are preserved, such that its latest revision remains
+reachable by its tag.  The function is rolled forward again by routing its
+traffic to its latest revision with 'func traffic --split @latest=100', or by
+deploying it again without --tag, which routes all of the traffic to the
+revision deployed.
+`,
+		Example: `
+# Roll the function in the current directory back to the revision deployed
+# before the last
+kn func rollback
+
+# Roll the function "myfunc" back to the revision "myfunc-00002"
+kn func rollback myfunc --to myfunc-00002
+`,
+		SuggestFor:        []string{"rollbak", "revert", "undo"},
+		ValidArgsFunction: CompleteFunctionList,
+		Args:              cobra.MaximumNArgs(1),
+		PreRunE:           bindEnv("path", "namespace", "to"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRollback(cmd, args, newSplitter)
+		},
+	}
+
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace of the function. By default, the namespace in func.yaml is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
+	cmd.Flags().String("to", "", "Name of the revision to which the function is rolled back. By default, that deployed before the last, as recorded in func.yaml (Env: $FUNC_TO)")
+
+	return cmd
+}
+
+func runRollback(cmd *cobra.Command, args []string, newSplitter func(namespace string) (fn.TrafficSplitter, error)) (err error) {
+	config := newRollbackConfig(args)
+	if config.Name == "" {
+		return fmt.Errorf("the given path '%v' does not contain an initialized function. Please provide the name of the function", config.Path)
+	}
+
+	// The namespace and the revision deployed before the last are those of
+	// the function's config, if it is that of the function rolled back.
+	namespace, previous := config.Namespace, ""
+	if f, err := fn.NewFunctionFromFile(config.Path, configFile()); err == nil && f.Name == config.Name {
+		if namespace == "" {
+			namespace = f.Namespace
+		}
+		previous = f.Status.PreviousRevision
+	}
+
+	if err = configureClusterAccess(); err != nil {
+		return
+	}
+	splitter, err := newSplitter(namespace)
+	if err != nil {
+		return
+	}
+	lister, ok := splitter.(fn.RevisionLister)
+	if !ok {
+		return fmt.Errorf("the revisions of function '%v' can not be listed", config.Name)
+	}
+	revisions, err := lister.Revisions(cmd.Context(), config.Name)
+	if err != nil {
+		return
+	}
+
+	to := config.To
+	if to == "" && interactiveTerminal() {
+		if to, err = promptRevision(cmd.OutOrStdout(), revisions, previous); err != nil {
+			return
+		}
+	}
+	if to == "" {
+		to = previous
+	}
+	if to == "" {
+		return fmt.Errorf("no prior revision of function '%v' is recorded in func.yaml. Please provide the revision to roll back to with --to", config.Name)
+	}
+	if err = rollbackable(config.Name, to, revisions); err != nil {
+		return
+	}
+
+	traffic, err := splitter.Split(cmd.Context(), config.Name, fn.TrafficSplit{to: 100})
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Rolled back function '%v' to revision '%v'\n", config.Name, to)
+	printTraffic(cmd.OutOrStdout(), config.Name, traffic)
+	return
+}
+
+// rollbackable returns an error unless the named revision is among those of
+// the function, and is ready.
+func rollbackable(name, to string, revisions []fn.Revision) error {
+	names := make([]string, 0, len(revisions))
+	for _, r := range revisions {
+		if r.Name != to {
+			names = append(names, r.Name)
+			continue
+		}
+		if !r.Ready {
+			msg := fmt.Sprintf("revision '%v' of function '%v' is not ready, and can not be rolled back to", to, name)
+			if r.Message != "" {
+				msg += ": " + r.Message
+			}
+			return errors.New(msg)
+		}
+		return nil
+	}
+	return fmt.Errorf("function '%v' has no revision '%v'. Revisions: %v", name, to, strings.Join(names, ", "))
+}
+
+// promptRevision lists the revisions to w, and prompts for that to which to
+// roll back among those which are ready, the previous revision by default.
+func promptRevision(w io.Writer, revisions []fn.Revision, previous string) (to string, err error) {
+	tw := tabwriter.NewWriter(boldHeader(w), 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "REVISION\tTRAFFIC\tREADY\tCREATED\tIMAGE")
+	var ready []string
+	for _, r := range revisions {
+		fmt.Fprintf(tw, "%v\t%v%%\t%v\t%v\t%v\n", r.Name, r.Percent, r.Ready, r.Created.Format(time.RFC3339), r.Image)
+		if r.Ready {
+			ready = append(ready, r.Name)
+		}
+	}
+	if err = tw.Flush(); err != nil {
+		return
+	}
+	if len(ready) == 0 {
+		return "", fmt.Errorf("the function has no revision which is ready")
+	}
+	prompt := &survey.Select{
+		Message: "Revision to roll back to:",
+		Options: ready,
+	}
+	for _, r := range ready {
+		if r == previous {
+			prompt.Default = previous
+		}
+	}
+	err = survey.AskOne(prompt, &to)
+	return
+}
+
+type rollbackConfig struct {
+	Name      string
+	Path      string
+	Namespace string
+	To        string
+}
+
+func newRollbackConfig(args []string) rollbackConfig {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+	return rollbackConfig{
+		Name:      deriveName(name, viper.GetString("path")),
+		Path:      viper.GetString("path"),
+		Namespace: viper.GetString("namespace"),
+		To:        viper.GetString("to"),
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	fn "github.com/boson-project/func"
+)
+
+type testRevisionSplitter struct {
+	testSplitter
+	revisions []fn.Revision
+}
+
+func (s *testRevisionSplitter) Revisions(ctx context.Context, name string) ([]fn.Revision, error) {
+	return s.revisions, nil
+}
+
+// TestRollback ensures that all of the traffic of the function is routed to
+// the revision deployed before the last by default, or to that given, and
+// that revisions which are not ready or do not exist are refused.
+func TestRollback(t *testing.T) {
+	defer fromTempDir(t)()
+	nonInteractive(t)
+
+	if err := fn.New().Create(fn.Function{Root: "myfunc", Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	splitter := &testRevisionSplitter{revisions: []fn.Revision{
+		{Name: "myfunc-00003", Ready: false, Message: "Container failed with: panic"},
+		{Name: "myfunc-00002", Ready: true, Percent: 100},
+		{Name: "myfunc-00001", Ready: true},
+	}}
+	rollback := func(args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		cmd := NewRollbackCmd(func(string) (fn.TrafficSplitter, error) {
+			return splitter, nil
+		})
+		cmd.SetOut(out)
+		cmd.SetArgs(append(args, "--path", "myfunc"))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if _, err := rollback(); err == nil || !strings.Contains(err.Error(), "--to") {
+		t.Fatalf("expected an error requiring --to without a prior revision recorded, got '%v'", err)
+	}
+
+	f, err := fn.NewFunction("myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Status = fn.FunctionStatus{Revision: "myfunc-00003", PreviousRevision: "myfunc-00002"}
+	if err = f.WriteConfig(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if splitter.name != "myfunc" || len(splitter.split) != 1 || splitter.split["myfunc-00002"] != 100 {
+		t.Fatalf("expected all traffic of 'myfunc' to be routed to 'myfunc-00002', got '%v' split %v", splitter.name, splitter.split)
+	}
+	if !strings.Contains(out, "Rolled back function 'myfunc' to revision 'myfunc-00002'") {
+		t.Fatalf("expected the rollback to be printed, got:\n%v", out)
+	}
+
+	if _, err = rollback("--to", "myfunc-00001"); err != nil {
+		t.Fatal(err)
+	}
+	if splitter.split["myfunc-00001"] != 100 {
+		t.Fatalf("expected all traffic routed to 'myfunc-00001', got %v", splitter.split)
+	}
+
+	splitter.name = ""
+	if _, err = rollback("--to", "myfunc-00003"); err == nil || !strings.Contains(err.Error(), "not ready") || !strings.Contains(err.Error(), "panic") {
+		t.Fatalf("expected an error rolling back to a revision not ready, got '%v'", err)
+	}
+	if _, err = rollback("--to", "myfunc-00009"); err == nil || !strings.Contains(err.Error(), "no revision 'myfunc-00009'") {
+		t.Fatalf("expected an error rolling back to an unknown revision, got '%v'", err)
+	}
+	if splitter.name != "" {
+		t.Fatal("expected the traffic not to be split when refusing to roll back")
+	}
+}
+
+// nonInteractive replaces the standard input of the test with a pipe, such
+// that commands do not prompt for input, for the duration of the test.
+func nonInteractive(t *testing.T) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		r.Close()
+		w.Close()
+	})
+}
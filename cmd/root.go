@@ -2,18 +2,32 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/core"
+	"github.com/AlecAivazis/survey/v2/terminal"
 	"github.com/mitchellh/go-homedir"
 	"github.com/ory/viper"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/client/pkg/util"
 
 	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/docker"
+	"github.com/boson-project/func/k8s"
+	"github.com/boson-project/func/progress"
 )
 
 // The root of the command tree defines the command name, descriotion, globally
@@ -63,6 +77,83 @@ func init() {
 		panic(err)
 	}
 
+	// Quiet suppresses informational output, such as summaries and progress,
+	// leaving only errors and the output requested (-o).
+	root.PersistentFlags().BoolP("quiet", "q", false, "suppress all output other than errors and that requested with --output (Env: $FUNC_QUIET)")
+	err = viper.BindPFlag("quiet", root.PersistentFlags().Lookup("quiet"))
+	if err != nil {
+		panic(err)
+	}
+
+	// Kubeconfig used for all cluster operations.  When not provided, the
+	// default loading rules apply ($KUBECONFIG, ~/.kube/config).
+	root.PersistentFlags().String("kubeconfig", "", "Path to the kubeconfig file to use for cluster operations. Takes precedence over $KUBECONFIG (Env: $FUNC_KUBECONFIG)")
+	err = viper.BindPFlag("kubeconfig", root.PersistentFlags().Lookup("kubeconfig"))
+	if err != nil {
+		panic(err)
+	}
+
+	// Context of the kubeconfig to use, overriding its current-context.
+	root.PersistentFlags().String("context", "", "Name of the kubeconfig context to use for cluster operations. Overrides the current-context (Env: $FUNC_CONTEXT)")
+	err = viper.BindPFlag("context", root.PersistentFlags().Lookup("context"))
+	if err != nil {
+		panic(err)
+	}
+
+	// Registry mirrors of the images with which functions are built and
+	// deployed, merged over those of their config.
+	root.PersistentFlags().String("registry-mirrors", "", "Registry mirrors of the images functions are built with and deployed, as comma separated PREFIX=MIRROR pairs, such as gcr.io=registry.internal/gcr-mirror. Takes precedence over the registryMirrors of func.yaml (Env: $FUNC_REGISTRY_MIRRORS)")
+	err = viper.BindPFlag("registry-mirrors", root.PersistentFlags().Lookup("registry-mirrors"))
+	if err != nil {
+		panic(err)
+	}
+
+	// Config file of the function, in place of func.yaml, such that one
+	// directory may hold several functions.  It is not named --config, which
+	// is that of kn, of which func is a plugin.
+	root.PersistentFlags().String("config-file", "", "Name of the config file of the function in its directory, in place of func.yaml, such as api.func.yaml (Env: $FUNC_CONFIG_FILE)")
+	err = viper.BindPFlag("config-file", root.PersistentFlags().Lookup("config-file"))
+	if err != nil {
+		panic(err)
+	}
+
+	// Dry run prints the changes a command would make without making them,
+	// for those commands which support it (see dryRunAnnotation).
+	root.PersistentFlags().Bool("dry-run", false, "Print the changes the command would make, such as the files written, images built and objects applied, without making them (Env: $FUNC_DRY_RUN)")
+	err = viper.BindPFlag("dry-run", root.PersistentFlags().Lookup("dry-run"))
+	if err != nil {
+		panic(err)
+	}
+
+	// JSON logs emit the events of a command as NDJSON on stdout, in place of
+	// all human output, such as for log aggregation.
+	root.PersistentFlags().Bool("json-logs", false, "Write all output as NDJSON events, such as for log aggregation: a JSON object per line of the start, progress, warnings, result and error of the command, in place of human output (Env: $FUNC_JSON_LOGS)")
+	err = viper.BindPFlag("json-logs", root.PersistentFlags().Lookup("json-logs"))
+	if err != nil {
+		panic(err)
+	}
+
+	// No color disables the colors of human output, which is otherwise
+	// colored only when written to a terminal.
+	root.PersistentFlags().Bool("no-color", false, "Disable colored output, as does $NO_COLOR. Output which is not written to a terminal is never colored (Env: $FUNC_NO_COLOR)")
+	err = viper.BindPFlag("no-color", root.PersistentFlags().Lookup("no-color"))
+	if err != nil {
+		panic(err)
+	}
+
+	// Profile of the defaults of the registry, namespace, builder, cluster
+	// access and registry mirrors flags, in place of the current profile.
+	root.PersistentFlags().String("profile", "", "Name of the profile of defaults to use, in place of the current profile set with 'func profile use' (Env: $FUNC_PROFILE)")
+	err = viper.BindPFlag("profile", root.PersistentFlags().Lookup("profile"))
+	if err != nil {
+		panic(err)
+	}
+	if err = root.RegisterFlagCompletionFunc("profile", CompleteProfileList); err != nil {
+		panic(err)
+	}
+
+	root.PersistentPreRunE = preRun
+
 	// Override the --version template to match the output format from the
 	// version subcommand: nothing but the version.
 	root.SetVersionTemplate(`{{printf "%s\n" .Version}}`)
@@ -77,25 +168,137 @@ func init() {
 func Execute(ctx context.Context) {
 	// Sets version to a string partially populated by compile-time flags.
 	root.Version = version.String()
-	// Execute the root of the command tree.
-	if err := root.ExecuteContext(ctx); err != nil {
-		if ctx.Err() != nil {
-			os.Exit(130)
+	// Execute the root of the command tree, recording it with the telemetry
+	// opted in to, if any.
+	start := time.Now()
+	err := root.ExecuteContext(ctx)
+	if cmd, _, findErr := root.Find(os.Args[1:]); findErr == nil {
+		recordCommand(newTelemetry(), cmd, start, err)
+	}
+	code := exitCode(ctx, err)
+	if StopJSONLogs(ctx, err) || err == nil {
+		if code != 0 {
+			os.Exit(code)
+		}
+		return
+	}
+	// An interrupted command has cleaned up after itself, so is not
+	// reported as an error, but exits with the conventional code of a
+	// process terminated by SIGINT.
+	if code == ExitInterrupted {
+		os.Exit(code)
+		return
+	}
+	// Errors are printed to STDERR output and the process exits with code of 1,
+	// or that of the failed tests of the test command.
+	fmt.Fprintf(os.Stderr, "%v %v\n", colored(os.Stderr, colorRed, "Error:"), err)
+	if cmd, _, findErr := root.Find(os.Args[1:]); findErr == nil {
+		writeValidationJSON(os.Stdout, cmd, err)
+	}
+	os.Exit(code)
+}
+
+// exitCode of the process for the error of the command, if any: that of a
+// process terminated by SIGINT when interrupted, that of the failed tests of
+// the test command, and otherwise 1.
+func exitCode(ctx context.Context, err error) int {
+	if err == nil {
+		return 0
+	}
+	if Interrupted(ctx, err) {
+		return ExitInterrupted
+	}
+	var failed fn.ErrTestsFailed
+	if errors.As(err, &failed) {
+		return failed.ExitCode()
+	}
+	return 1
+}
+
+// preRun of every command: its JSON logs are started, if requested, such
+// that any error is emitted as an event, its profile applied, the colors of
+// its prompts disabled unless enabled, and its support of --dry-run checked.
+func preRun(cmd *cobra.Command, args []string) error {
+	if err := startJSONLogs(cmd, args); err != nil {
+		return err
+	}
+	if err := applyProfile(); err != nil {
+		return err
+	}
+	// Prompts are written to stdout.
+	core.DisableColor = !colorEnabled(os.Stdout)
+	return checkDryRun(cmd, args)
+}
+
+// writeValidationJSON writes the ValidationError of the error, if any, to w
+// as JSON when the output of the command is JSON (-o json), such that its
+// consumers may read the problems of the Function by field.
+func writeValidationJSON(w io.Writer, cmd *cobra.Command, err error) {
+	var verr fn.ValidationError
+	output := cmd.Flags().Lookup("output")
+	if output == nil || output.Value.String() != "json" || !errors.As(err, &verr) {
+		return
+	}
+	_ = json.NewEncoder(w).Encode(verr)
+}
+
+// ExitInterrupted is the exit code of a command interrupted by the user,
+// either by SIGINT or SIGTERM or at a prompt: that of a process terminated by
+// SIGINT (128 + 2).
+const ExitInterrupted = 130
+
+// ErrInterrupted is the error of a command interrupted by the user, as
+// returned when executed as a kn plugin, which is not in control of its exit
+// code.
+var ErrInterrupted = errors.New("interrupted")
+
+// NewSignalContext returns a context derived from the parent which is
+// cancelled on the first SIGINT or SIGTERM.  Commands are executed with this
+// context such that long-running operations, such as builds, waiting for a
+// deployment, following logs and running a function, are cancelled and clean
+// up after themselves, for example stopping local containers.  A second
+// signal exits immediately, without cleaning up, as if killed.
+func NewSignalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-sigs:
+			cancel()
+		case <-ctx.Done():
 			return
 		}
-		// Errors are printed to STDERR output and the process exits with code of 1.
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		<-sigs
+		os.Exit(137)
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigs)
+		cancel()
 	}
 }
 
+// Interrupted returns whether the error is that of a command interrupted by
+// the user: either by signal, cancelling the command's context, or by Ctrl-C
+// at a prompt, which does not raise SIGINT as the terminal is in raw mode.
+func Interrupted(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || errors.Is(err, terminal.InterruptErr) || errors.Is(err, ErrInterrupted)
+}
+
 // Helper functions used by multiple commands
 // ------------------------------------------
 
 // interactiveTerminal returns whether or not the currently attached process
 // terminal is interactive.  Used for determining whether or not to
-// interactively prompt the user to confirm default choices, etc.
+// interactively prompt the user to confirm default choices, etc.  Never with
+// --json-logs, with which there is no human output.
 func interactiveTerminal() bool {
+	if jsonLogs != nil {
+		return false
+	}
 	fi, err := os.Stdin.Stat()
 	return err == nil && ((fi.Mode() & os.ModeCharDevice) != 0)
 }
@@ -127,6 +330,64 @@ func configPath() (path string) {
 	return
 }
 
+// cachePath is the effective path to the cache directory used for content
+// reused across invocations, such as that of builds.
+func cachePath() (path string) {
+	if path = os.Getenv("XDG_CACHE_HOME"); path != "" {
+		path = filepath.Join(path, "func")
+		return
+	}
+	home, err := homedir.Expand("~")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not derive home directory for use as default cache path: %v", err)
+		path = filepath.Join(".cache", "func")
+	} else {
+		path = filepath.Join(home, ".cache", "func")
+	}
+	return
+}
+
+// functionCachePath is the path to the cache directory of the named Function,
+// within the cache directory, in which content is cached by its builds.
+func functionCachePath(name string) string {
+	return filepath.Join(cachePath(), name)
+}
+
+// configureClusterAccess applies the global cluster access flags (--kubeconfig
+// and --context) to the Kubernetes client configuration used by all
+// subsequent cluster operations.
+func configureClusterAccess() error {
+	return k8s.SetClientConfig(viper.GetString("kubeconfig"), viper.GetString("context"))
+}
+
+// registryMirrors returns the registry mirrors given with the global
+// --registry-mirrors flag, or of the profile, if any.
+func registryMirrors() (map[string]string, error) {
+	mirrors, err := fn.ParseRegistryMirrors(viper.GetString("registry-mirrors"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value '%v' for --registry-mirrors: %v", viper.GetString("registry-mirrors"), err)
+	}
+	return mirrors, nil
+}
+
+// configFile returns the name of the config file of functions given with the
+// global --config-file flag, empty for the default (func.yaml).
+func configFile() string {
+	return viper.GetString("config-file")
+}
+
+// allNamespaces returns whether --all-namespaces is set, which conflicts with
+// an explicit --namespace.
+func allNamespaces(cmd *cobra.Command) (all bool, err error) {
+	if all, err = cmd.Flags().GetBool("all-namespaces"); err != nil || !all {
+		return
+	}
+	if cmd.Flags().Changed("namespace") {
+		return false, fmt.Errorf("the --namespace and --all-namespaces flags conflict. Provide only one")
+	}
+	return
+}
+
 // bindFunc which conforms to the cobra PreRunE method signature
 type bindFunc func(*cobra.Command, []string) error
 
@@ -138,10 +399,88 @@ func bindEnv(flags ...string) bindFunc {
 				return
 			}
 		}
+		if viper.GetBool("quiet") && viper.GetBool("verbose") {
+			return fmt.Errorf("the --quiet and --verbose flags conflict. Provide only one")
+		}
 		return
 	}
 }
 
+// dryRunAnnotation of a command declares its support of --dry-run: either
+// dryRunPlan, for those which print the plan of the changes they would make,
+// or dryRunReadOnly, for those which make none.  Commands without it fail
+// with --dry-run rather than make changes.
+const (
+	dryRunAnnotation = "dry-run"
+	dryRunPlan       = "plan"
+	dryRunReadOnly   = "read-only"
+)
+
+// checkDryRun ensures that a command run with --dry-run supports it.  The
+// flag is bound anew to that of the command, which deploy defines itself as
+// a string of its modes, and typed as it is, the key otherwise remaining of
+// the type of the flag first bound.
+func checkDryRun(cmd *cobra.Command, args []string) (err error) {
+	flag := cmd.Flags().Lookup("dry-run")
+	if flag.Value.Type() == "string" {
+		viper.SetType("dry-run", "")
+	} else {
+		viper.SetType("dry-run", false)
+	}
+	if err = viper.BindPFlag("dry-run", flag); err != nil {
+		return
+	}
+	if _, ok := cmd.Annotations[dryRunAnnotation]; ok || !dryRun() {
+		return
+	}
+	return fmt.Errorf("the --dry-run flag is not supported by '%v'", cmd.CommandPath())
+}
+
+// dryRun returns whether the global --dry-run flag is set.
+func dryRun() bool {
+	return viper.GetBool("dry-run")
+}
+
+// newPlan returns the plan populated in place of making changes when
+// planning, and otherwise nil.
+func newPlan(planning bool) *fn.Plan {
+	if !planning {
+		return nil
+	}
+	return &fn.Plan{}
+}
+
+// infoOut returns the writer of informational output, such as summaries and
+// progress, to w: w itself, or a writer discarding it with --quiet.  Errors,
+// warnings and the output requested with --output are written regardless.
+func infoOut(w io.Writer) io.Writer {
+	if viper.GetBool("quiet") {
+		return ioutil.Discard
+	}
+	return w
+}
+
+// newProgressListener returns the listener of the progress of a command: a
+// progress bar written to w, or its events with --json-logs.
+func newProgressListener(w io.Writer, verbose bool) fn.ProgressListener {
+	if events := jsonEvents(); events != nil {
+		return events
+	}
+	bar := progress.New(progress.WithOutput(w))
+	bar.Verbose = verbose
+	return bar
+}
+
+// progressOut returns the writer of the progress of a command: that of
+// informational output, or a writer discarding it when planning, such that
+// only the plan is printed.
+func progressOut(plan *fn.Plan) io.Writer {
+	if plan != nil {
+		return ioutil.Discard
+	}
+	return infoOut(os.Stdout)
+}
+
 type functionOverrides struct {
 	Image     string
 	Namespace string
@@ -150,10 +489,10 @@ type functionOverrides struct {
 
 // functionWithOverrides sets the namespace and image strings for the
 // Function project at root, if provided, and returns the Function
-// configuration values.
+// configuration values.  The project must be initialized.
 // Please note that When this function is called, the overrides are not persisted.
 func functionWithOverrides(root string, overrides functionOverrides) (f fn.Function, err error) {
-	f, err = fn.NewFunction(root)
+	f, err = fn.Load(root, configFile())
 	if err != nil {
 		return
 	}
@@ -167,6 +506,15 @@ func functionWithOverrides(root string, overrides functionOverrides) (f fn.Funct
 		{overrides.Namespace, &f.Namespace},
 	}
 
+	// The digest of a previous image does not apply to another, nor that of
+	// a previous builder.
+	if overrides.Image != "" && overrides.Image != f.Image {
+		f.ImageDigest = ""
+	}
+	if overrides.Builder != "" && overrides.Builder != f.Builder {
+		f.BuilderDigest = ""
+	}
+
 	for _, m := range overrideMapping {
 		if m.src != "" {
 			*m.dest = m.src
@@ -186,7 +534,7 @@ func deriveName(explicitName string, path string) string {
 	}
 
 	// If the directory at path contains an initialized Function, use the name therein
-	f, err := fn.NewFunction(path)
+	f, err := fn.NewFunctionFromFile(path, configFile())
 	if err == nil && f.Name != "" {
 		return f.Name
 	}
@@ -197,12 +545,20 @@ func deriveName(explicitName string, path string) string {
 // deriveNameAndAbsolutePathFromPath returns resolved Function name and absolute path
 // to the Function project root. The input parameter path could be one of:
 // 'relative/path/to/foo', '/absolute/path/to/foo', 'foo' or ''
-func deriveNameAndAbsolutePathFromPath(path string) (string, string) {
+// A relative path is within the projects root, if given, rather than the
+// current working directory.  An absolute path bypasses the projects root.
+func deriveNameAndAbsolutePathFromPath(path, projectsRoot string) (string, string) {
 	var absPath string
 
 	// If path is not specifed, we would like to use current working dir
 	if path == "" {
 		path = cwd()
+	} else if projectsRoot != "" && !filepath.IsAbs(path) {
+		root, err := homedir.Expand(projectsRoot)
+		if err != nil {
+			return "", ""
+		}
+		path = filepath.Join(root, path)
 	}
 
 	// Expand the passed Function name to its absolute path
@@ -242,28 +598,279 @@ func deriveImage(explicitImage, defaultRegistry, path string) string {
 	if explicitImage != "" {
 		return explicitImage // use the explicit value provided.
 	}
-	f, err := fn.NewFunction(path)
+	f, err := fn.NewFunctionFromFile(path, configFile())
 	if err != nil {
 		return "" // unable to derive due to load error (uninitialized?)
 	}
-	if f.Image != "" {
-		return f.Image // use value previously provided or derived.
+	if defaultRegistry != "" {
+		f.Registry = defaultRegistry
 	}
-	derivedValue, _ := fn.DerivedImage(path, defaultRegistry)
+	derivedValue, _ := f.ImageName()
 	return derivedValue // Use the func system's derivation logic.
 }
 
+// defaultRegistry returns the registry given, if any, or otherwise that
+// inferred from the git remote of the project at path, or else that of the
+// sole registry logged in to, as the default of the prompt for a registry.
+func defaultRegistry(registry, path string) string {
+	if registry != "" {
+		return registry
+	}
+	if registry = fn.GitRemoteRegistry(path); registry != "" {
+		return registry
+	}
+	return docker.LoggedInRegistry()
+}
+
+// promptRegistry returns the registry of Function images to use when none is
+// configured nor inferred from the git remote: that of the sole registry
+// logged in to in the docker config, if any, noting so, or else that prompted
+// for.
+func promptRegistry() (registry string, err error) {
+	if registry = docker.LoggedInRegistry(); registry != "" {
+		fmt.Printf("Using the registry '%v', the only one logged in to in the docker config. Provide --registry to use another.\n", registry)
+		return
+	}
+	fmt.Println("A registry for Function images is required. For example, 'docker.io/tigerteam'.")
+	err = survey.AskOne(
+		&survey.Input{Message: "Registry for Function images:"},
+		&registry, survey.WithValidator(survey.Required))
+	return
+}
+
+// envFromCmd returns the environment variables to be updated and removed
+// given by the --env flag.  Besides NAME=VALUE and NAME-, a bare NAME takes
+// its value from the local environment when deployed: it is set to the
+// reference {{ env:NAME }}, such that the value itself is not persisted in
+// func.yaml.  The local variable must be set.  Note that NAME= instead sets
+// an explicitly empty value.
 func envFromCmd(cmd *cobra.Command) (*util.OrderedMap, []string, error) {
-	if cmd.Flags().Changed("env") {
-		env, err := cmd.Flags().GetStringArray("env")
+	if !cmd.Flags().Changed("env") {
+		return util.NewOrderedMap(), []string{}, nil
+	}
+	env, err := cmd.Flags().GetStringArray("env")
+	if err != nil {
+		return nil, []string{}, fmt.Errorf("Invalid --env: %w", err)
+	}
+	resolved := make([]string, len(env))
+	for i, e := range env {
+		resolved[i] = e
+		if e == "" || strings.Contains(e, "=") || strings.HasSuffix(e, "-") {
+			continue
+		}
+		if _, ok := os.LookupEnv(e); !ok {
+			return nil, []string{}, fmt.Errorf("invalid --env %v: the local environment variable %v is not set. Set it, or provide a value with %v=VALUE", e, e, e)
+		}
+		resolved[i] = fmt.Sprintf("%v={{ env:%v }}", e, e)
+	}
+	return util.OrderedMapAndRemovalListFromArray(resolved, "=")
+}
+
+// envsFromFile returns the environment variables of the env file at path,
+// given by the --env-file flag, validated, without those to be updated or
+// removed per the --env flag, which take precedence.
+func envsFromFile(path string, envToUpdate *util.OrderedMap, envToRemove []string) (fn.Envs, error) {
+	envs, err := fn.ReadEnvFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value '%v' for --env-file: %w", path, err)
+	}
+	removed := sets.NewString(envToRemove...)
+	filtered := fn.Envs{}
+	for _, e := range envs {
+		if _, updated := envToUpdate.Get(*e.Name); updated || removed.Has(*e.Name) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	if errs := fn.ValidateEnvs(filtered); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid value '%v' for --env-file: %v", path, strings.Join(errs, "; "))
+	}
+	return filtered, nil
+}
+
+// envFromFlag returns the environment variables to be updated and removed
+// given by the repeatable flag of the given name, such as "build-env".
+func envFromFlag(cmd *cobra.Command, flag string) (*util.OrderedMap, []string, error) {
+	if cmd.Flags().Changed(flag) {
+		env, err := cmd.Flags().GetStringArray(flag)
 		if err != nil {
-			return nil, []string{}, fmt.Errorf("Invalid --env: %w", err)
+			return nil, []string{}, fmt.Errorf("Invalid --%v: %w", flag, err)
 		}
 		return util.OrderedMapAndRemovalListFromArray(env, "=")
 	}
 	return util.NewOrderedMap(), []string{}, nil
 }
 
+// mergeBuildEnvs returns the Function's build envs updated per the
+// --build-env flag, validated.
+func mergeBuildEnvs(cmd *cobra.Command, envs fn.Envs) (fn.Envs, error) {
+	envToUpdate, envToRemove, err := envFromFlag(cmd, "build-env")
+	if err != nil {
+		return nil, err
+	}
+	if envs, err = mergeEnvs(envs, envToUpdate, envToRemove); err != nil {
+		return nil, err
+	}
+	if errs := fn.ValidateBuildEnvs(envs); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid --build-env: %v", strings.Join(errs, "; "))
+	}
+	return envs, nil
+}
+
+// buildpacksFromFlag returns the buildpacks given by the repeatable
+// --buildpack flag, validated, in place of those of the Function, if given.
+func buildpacksFromFlag(cmd *cobra.Command, buildpacks []string) ([]string, error) {
+	if !cmd.Flags().Changed("buildpack") {
+		return buildpacks, nil
+	}
+	given, err := cmd.Flags().GetStringArray("buildpack")
+	if err != nil {
+		return nil, fmt.Errorf("Invalid --buildpack: %w", err)
+	}
+	for _, b := range given {
+		if err = fn.ValidateBuildpack(b); err != nil {
+			return nil, fmt.Errorf("invalid value '%v' for --buildpack: %v", b, err)
+		}
+	}
+	return given, nil
+}
+
+// mergeVolumes returns the Function's volumes updated per the --volume flag:
+// each volume given is mounted, in place of any at its path, and each path
+// followed by "-" (e.g., /etc/config-) is unmounted.
+func mergeVolumes(cmd *cobra.Command, volumes fn.Volumes) (fn.Volumes, error) {
+	if !cmd.Flags().Changed("volume") {
+		return volumes, nil
+	}
+	specs, err := cmd.Flags().GetStringArray("volume")
+	if err != nil {
+		return nil, fmt.Errorf("Invalid --volume: %w", err)
+	}
+	for _, spec := range specs {
+		if strings.HasPrefix(spec, "/") && strings.HasSuffix(spec, "-") {
+			path := strings.TrimSuffix(spec, "-")
+			for i, v := range volumes {
+				if v.Path != nil && *v.Path == path {
+					volumes = append(volumes[:i], volumes[i+1:]...)
+					break
+				}
+			}
+			continue
+		}
+		volume, err := fn.ParseVolume(spec)
+		if err != nil {
+			return nil, err
+		}
+		replaced := false
+		for i, v := range volumes {
+			if v.Path != nil && *v.Path == *volume.Path {
+				volumes[i], replaced = volume, true
+				break
+			}
+		}
+		if !replaced {
+			volumes = append(volumes, volume)
+		}
+	}
+	return volumes, nil
+}
+
+// mergeResources returns the Function's options with its resource requests
+// and limits updated per the --requests and --limits flags: cpu and memory,
+// and extended resources such as nvidia.com/gpu, each of which is removed
+// when its name is followed by "-" (e.g., nvidia.com/gpu-).
+func mergeResources(cmd *cobra.Command, options fn.Options) (fn.Options, error) {
+	if !cmd.Flags().Changed("requests") && !cmd.Flags().Changed("limits") {
+		return options, nil
+	}
+	resources := fn.ResourcesOptions{}
+	if options.Resources != nil {
+		resources = *options.Resources
+	}
+	requests := fn.ResourcesRequestsOptions{}
+	if resources.Requests != nil {
+		requests = *resources.Requests
+	}
+	limits := fn.ResourcesLimitsOptions{}
+	if resources.Limits != nil {
+		limits = *resources.Limits
+	}
+	if err := mergeResourceFlag(cmd, "requests", &requests.CPU, &requests.Memory, &requests.Extended); err != nil {
+		return options, err
+	}
+	if err := mergeResourceFlag(cmd, "limits", &limits.CPU, &limits.Memory, &limits.Extended); err != nil {
+		return options, err
+	}
+	for name, quantity := range requests.Extended {
+		request, _ := resource.ParseQuantity(quantity)
+		limit, err := resource.ParseQuantity(limits.Extended[name])
+		if err != nil || request.Cmp(limit) != 0 {
+			return options, fmt.Errorf("invalid --requests %v=%v: extended resources are not overcommitted, provide its limit alone with --limits %v=%v", name, quantity, name, quantity)
+		}
+	}
+
+	resources.Requests, resources.Limits = nil, nil
+	if requests.CPU != nil || requests.Memory != nil || len(requests.Extended) > 0 {
+		resources.Requests = &requests
+	}
+	if limits.CPU != nil || limits.Memory != nil || limits.Concurrency != nil || len(limits.Extended) > 0 {
+		resources.Limits = &limits
+	}
+	options.Resources = nil
+	if resources.Requests != nil || resources.Limits != nil {
+		options.Resources = &resources
+	}
+	return options, nil
+}
+
+// mergeResourceFlag updates the cpu, memory and extended resources per the
+// repeatable flag of the given name, validating each quantity.
+func mergeResourceFlag(cmd *cobra.Command, flag string, cpu, memory **string, extended *map[string]string) error {
+	toUpdate, toRemove, err := envFromFlag(cmd, flag)
+	if err != nil {
+		return err
+	}
+	updated := map[string]string{}
+	for name, quantity := range *extended {
+		updated[name] = quantity
+	}
+	for _, name := range toRemove {
+		switch name {
+		case "cpu":
+			*cpu = nil
+		case "memory":
+			*memory = nil
+		default:
+			delete(updated, name)
+		}
+	}
+	it := toUpdate.Iterator()
+	for name, quantity, ok := it.NextString(); ok; name, quantity, ok = it.NextString() {
+		q := quantity
+		switch name {
+		case "cpu", "memory":
+			if _, err := resource.ParseQuantity(q); err != nil {
+				return fmt.Errorf("invalid --%v %v=%v: %v", flag, name, q, err)
+			}
+			if name == "cpu" {
+				*cpu = &q
+			} else {
+				*memory = &q
+			}
+		default:
+			if err := fn.ValidateExtendedResource(name, q); err != nil {
+				return fmt.Errorf("invalid --%v %v=%v: %v", flag, name, q, err)
+			}
+			updated[name] = q
+		}
+	}
+	*extended = nil
+	if len(updated) > 0 {
+		*extended = updated
+	}
+	return nil
+}
+
 func mergeEnvs(envs fn.Envs, envToUpdate *util.OrderedMap, envToRemove []string) (fn.Envs, error) {
 	updated := sets.NewString()
 
@@ -1,10 +1,22 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
 	"knative.dev/client/pkg/util"
 
 	fn "github.com/boson-project/func"
@@ -117,3 +129,180 @@ func Test_mergeEnvMaps(t *testing.T) {
 		})
 	}
 }
+
+// Test_envFromCmd ensures a bare name given to --env references the local
+// environment variable, which must be set, as distinct from an empty value.
+func Test_envFromCmd(t *testing.T) {
+	os.Setenv("FUNC_TEST_TOKEN", "secret")
+	defer os.Unsetenv("FUNC_TEST_TOKEN")
+
+	cmd := &cobra.Command{}
+	cmd.Flags().StringArrayP("env", "e", []string{}, "")
+	if err := cmd.Flags().Parse([]string{"-e", "FUNC_TEST_TOKEN", "-e", "EMPTY=", "-e", "OLD-"}); err != nil {
+		t.Fatal(err)
+	}
+	toUpdate, toRemove, err := envFromCmd(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := toUpdate.GetString("FUNC_TEST_TOKEN"); v != "{{ env:FUNC_TEST_TOKEN }}" {
+		t.Fatalf("expected a reference to the local variable, got %q", v)
+	}
+	if v, ok := toUpdate.GetString("EMPTY"); !ok || v != "" {
+		t.Fatalf("expected an explicitly empty value, got %q (present %v)", v, ok)
+	}
+	if !reflect.DeepEqual(toRemove, []string{"OLD"}) {
+		t.Fatalf("expected OLD to be removed, got %v", toRemove)
+	}
+
+	cmd = &cobra.Command{}
+	cmd.Flags().StringArrayP("env", "e", []string{}, "")
+	if err = cmd.Flags().Parse([]string{"-e", "FUNC_TEST_UNSET"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = envFromCmd(cmd); err == nil {
+		t.Fatal("expected an error for the unset local variable")
+	}
+}
+
+// Test_NewSignalContext ensures the context is cancelled on SIGINT, such
+// that long-running operations are stopped.
+func Test_NewSignalContext(t *testing.T) {
+	ctx, cancel := NewSignalContext(context.Background())
+	defer cancel()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the context to be cancelled on SIGINT")
+	}
+}
+
+// Test_Interrupted ensures commands interrupted by signal or at a prompt are
+// treated alike, and other errors are not.
+func Test_Interrupted(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		err         error
+		interrupted bool
+	}{
+		{"signal", cancelled, context.Canceled, true},
+		{"prompt", context.Background(), terminal.InterruptErr, true},
+		{"wrapped prompt", context.Background(), fmt.Errorf("prompt failed: %w", terminal.InterruptErr), true},
+		{"error", context.Background(), errors.New("failed"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Interrupted(tt.ctx, tt.err); got != tt.interrupted {
+				t.Fatalf("Interrupted() = %v, want %v", got, tt.interrupted)
+			}
+		})
+	}
+}
+
+// Test_quiet ensures informational output is discarded with --quiet, and
+// that --quiet conflicts with --verbose.
+func Test_quiet(t *testing.T) {
+	defer viper.Set("quiet", false)
+	defer viper.Set("verbose", false)
+
+	var out bytes.Buffer
+	fmt.Fprint(infoOut(&out), "info")
+	if out.String() != "info" {
+		t.Fatalf("expected informational output by default, got %q", out.String())
+	}
+
+	viper.Set("quiet", true)
+	out.Reset()
+	fmt.Fprint(infoOut(&out), "info")
+	if out.Len() != 0 {
+		t.Fatalf("expected informational output to be discarded, got %q", out.String())
+	}
+	if err := bindEnv()(&cobra.Command{}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Set("verbose", true)
+	if err := bindEnv()(&cobra.Command{}, nil); err == nil {
+		t.Fatal("expected --quiet and --verbose to conflict")
+	}
+}
+
+// withDryRun returns a root of the command with the global --dry-run flag,
+// as when run by func, restoring the binding of the flag to that of func once
+// the test completes.
+func withDryRun(t *testing.T, cmd *cobra.Command) *cobra.Command {
+	parent := &cobra.Command{Use: "func", PersistentPreRunE: checkDryRun, SilenceUsage: true, SilenceErrors: true}
+	parent.PersistentFlags().Bool("dry-run", false, "")
+	parent.AddCommand(cmd)
+	t.Cleanup(func() {
+		_ = viper.BindPFlag("dry-run", root.PersistentFlags().Lookup("dry-run"))
+	})
+	return parent
+}
+
+// TestDryRun ensures that a command run with --dry-run prints the plan of
+// its changes without making them, and that commands which do not support it
+// fail rather than make changes.
+func TestDryRun(t *testing.T) {
+	defer fromTempDir(t)()
+
+	var out bytes.Buffer
+	cmd := withDryRun(t, NewCreateCmd(newCreateClient))
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"create", "--dry-run", "--runtime", "go", "--repositories", "", "myfunc"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("myfunc"); !os.IsNotExist(err) {
+		t.Fatalf("expected the function not to be created, got %v", err)
+	}
+	if !strings.Contains(out.String(), filepath.Join(pwd(t), "myfunc", "func.yaml")) {
+		t.Fatalf("expected the plan to write func.yaml, got:\n%v", out.String())
+	}
+
+	cmd = withDryRun(t, NewTestCmd())
+	cmd.SetArgs([]string{"test", "--dry-run"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Fatalf("expected --dry-run not to be supported by test, got %v", err)
+	}
+}
+
+// TestWriteValidationJSON ensures that the problems of a Function which is not
+// valid are written as JSON for commands with JSON output, and otherwise not.
+func TestWriteValidationJSON(t *testing.T) {
+	verr := fn.ValidationError{File: "func.yaml", Errors: []fn.FieldError{{Field: "domain", Message: "invalid value"}}}
+	err := fmt.Errorf("failed to load the function: %w", verr)
+
+	cmd := &cobra.Command{Use: "describe"}
+	cmd.Flags().StringP("output", "o", "human", "")
+
+	out := &bytes.Buffer{}
+	writeValidationJSON(out, cmd, err)
+	if out.Len() != 0 {
+		t.Fatalf("expected nothing written for human output, got %v", out)
+	}
+
+	if err := cmd.Flags().Set("output", "json"); err != nil {
+		t.Fatal(err)
+	}
+	writeValidationJSON(out, cmd, errors.New("not a validation error"))
+	if out.Len() != 0 {
+		t.Fatalf("expected nothing written for other errors, got %v", out)
+	}
+	writeValidationJSON(out, cmd, err)
+	var written fn.ValidationError
+	if err := json.Unmarshal(out.Bytes(), &written); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(written, verr) {
+		t.Fatalf("expected %+v written, got %+v", verr, written)
+	}
+}
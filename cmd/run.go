@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/ory/viper"
 	"github.com/spf13/cobra"
 	"knative.dev/client/pkg/util"
@@ -16,7 +14,8 @@ func init() {
 	root.AddCommand(runCmd)
 	runCmd.Flags().StringArrayP("env", "e", []string{}, "Environment variable to set in the form NAME=VALUE. "+
 		"You may provide this flag multiple times for setting multiple environment variables. "+
-		"To unset, specify the environment variable name followed by a \"-\" (e.g., NAME-).")
+		"To unset, specify the environment variable name followed by a \"-\" (e.g., NAME-). "+
+		"To use the value of a local environment variable, without storing it in func.yaml, specify only its name (e.g., NAME).")
 	runCmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
 }
 
@@ -46,7 +45,7 @@ func runRun(cmd *cobra.Command, args []string) (err error) {
 		return
 	}
 
-	function, err := fn.NewFunction(config.Path)
+	function, err := fn.Load(config.Path, configFile())
 	if err != nil {
 		return
 	}
@@ -61,17 +60,13 @@ func runRun(cmd *cobra.Command, args []string) (err error) {
 		return
 	}
 
-	// Check if the Function has been initialized
-	if !function.Initialized() {
-		return fmt.Errorf("the given path '%v' does not contain an initialized function", config.Path)
-	}
-
 	runner := docker.NewRunner()
 	runner.Verbose = config.Verbose
 
 	client := fn.New(
 		fn.WithRunner(runner),
-		fn.WithVerbose(config.Verbose))
+		fn.WithVerbose(config.Verbose),
+		fn.WithConfigFile(configFile()))
 
 	err = client.Run(cmd.Context(), config.Path)
 	return
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/buildpacks"
+)
+
+func init() {
+	root.AddCommand(NewSchemaCmd())
+}
+
+// NewSchemaCmd creates a command which prints the JSON Schema of func.yaml.
+func NewSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of func.yaml",
+		Long: `Print the JSON Schema of func.yaml
+
+Prints the JSON Schema of the function configuration file func.yaml, with
+which editors validate and complete it, such as those using the YAML language
+server.  The schema is that of the configuration read by this version of func,
+including the runtimes it supports.
+`,
+		Example: `
+# Write the schema to a file referenced by editor settings
+kn func schema > func.yaml.schema.json
+`,
+		SuggestFor:  []string{"schmea", "scheme"},
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{dryRunAnnotation: dryRunReadOnly},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bb, err := json.MarshalIndent(fn.Schema(buildpacks.RuntimesList()...), "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), string(bb))
+			return err
+		},
+	}
+}
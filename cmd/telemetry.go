@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+)
+
+// newTelemetry returns the telemetry with which commands are recorded: that
+// of the file of $FUNC_TELEMETRY_FILE, or nil when it is not set, such that
+// telemetry is opt-in.
+func newTelemetry() fn.Telemetry {
+	if path := os.Getenv(fn.TelemetryFileEnv); path != "" {
+		return fileTelemetry{path: path}
+	}
+	return nil
+}
+
+// fileTelemetry appends each event to the file at path as a line of JSON,
+// from which it may be shipped to a sink of the user's choosing.
+type fileTelemetry struct {
+	path string
+}
+
+// Record the event.  Failing to do so is ignored, such that telemetry never
+// affects the outcome of a command.
+func (t fileTelemetry) Record(e fn.TelemetryEvent) {
+	file, err := os.OpenFile(t.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	_ = json.NewEncoder(file).Encode(e)
+}
+
+// recordCommand records the event of the command having completed since
+// start with the given error, if any, with the runtime and counts of the
+// settings of the Function of its --path, if it has one.
+func recordCommand(t fn.Telemetry, cmd *cobra.Command, start time.Time, err error) {
+	if t == nil || cmd == nil {
+		return
+	}
+	var f fn.Function
+	if cmd.Flags().Lookup("path") != nil {
+		// The Function is only read for its runtime and counts, so any
+		// error loading it (such as there being none) is ignored.
+		f, _ = fn.NewFunctionFromFile(viper.GetString("path"), configFile())
+	}
+	t.Record(fn.NewTelemetryEvent(cmd.CommandPath(), f, start, err))
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+)
+
+// TestTelemetryFile ensures that commands are recorded only when
+// $FUNC_TELEMETRY_FILE is set, appended to its file as lines of JSON with the
+// runtime and counts of the settings of the Function of the command's path.
+func TestTelemetryFile(t *testing.T) {
+	defer fromTempDir(t)()
+	root := pwd(t)
+	if err := ioutil.WriteFile("func.yaml", []byte("name: myfunc\nruntime: go\nenvs:\n- name: SECRET\n  value: s3cr3t\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{Use: "deploy"}
+	cmd.Flags().String("path", root, "")
+	if err := viper.BindPFlag("path", cmd.Flags().Lookup("path")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv(fn.TelemetryFileEnv, os.Getenv(fn.TelemetryFileEnv))
+
+	os.Setenv(fn.TelemetryFileEnv, "")
+	if newTelemetry() != nil {
+		t.Fatal("expected telemetry to be disabled by default")
+	}
+
+	file := filepath.Join(root, "telemetry.jsonl")
+	os.Setenv(fn.TelemetryFileEnv, file)
+	recordCommand(newTelemetry(), cmd, time.Now(), nil)
+	recordCommand(newTelemetry(), cmd, time.Now(), errors.New("deploy failed"))
+
+	bb, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(bb), "s3cr3t") || strings.Contains(string(bb), "deploy failed") {
+		t.Fatalf("expected no values of the Function or errors to be recorded, got %s", bb)
+	}
+	lines := strings.Split(strings.TrimSpace(string(bb)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected an event per command, got %s", bb)
+	}
+	var e fn.TelemetryEvent
+	if err = json.Unmarshal([]byte(lines[0]), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Operation != "deploy" || !e.Success || e.Runtime != "go" || e.Counts["envs"] != 1 {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+	if err = json.Unmarshal([]byte(lines[1]), &e); err != nil || e.Success {
+		t.Fatalf("expected the failed command to be recorded as such, got %+v (%v)", e, err)
+	}
+}
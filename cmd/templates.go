@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/buildpacks"
+)
+
+func init() {
+	root.AddCommand(NewTemplatesCmd(newTemplatesClient))
+}
+
+// newTemplatesClient returns an instance of fn.Client for the "Templates"
+// command, listing the templates of the given repositories.
+func newTemplatesClient(repositories string) *fn.Client {
+	return fn.New(fn.WithRepositories(repositories))
+}
+
+// NewTemplatesCmd creates a templates command using the given client creator.
+func NewTemplatesCmd(newClient func(repositories string) *fn.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "templates",
+		Short: "List available function templates",
+		Long: `List available function templates
+
+Lists the templates available for creating functions, grouped by runtime.
+Both the templates embedded in func and those of the template repositories
+in the --repositories directory are listed, along with their source and the
+function signature they implement ('http' or 'events') where declared.
+The versions of each runtime known to be supported, which may be selected
+with 'func create --runtime <runtime>@<version>', are listed where known.
+`,
+		Example: `
+# List all templates
+kn func templates
+
+# List the templates of the Go runtime as JSON
+kn func templates --runtime go --output json
+`,
+		SuggestFor:  []string{"template", "tempaltes"},
+		PreRunE:     bindEnv("repositories", "runtime", "output"),
+		Annotations: map[string]string{dryRunAnnotation: dryRunReadOnly},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTemplates(cmd, newClient)
+		},
+	}
+
+	cmd.Flags().StringP("repositories", "r", filepath.Join(configPath(), "repositories"),
+		"Path to extended template repositories (Env: $FUNC_REPOSITORIES)")
+	cmd.Flags().StringP("runtime", "l", "", "List only the templates of the given runtime (Env: $FUNC_RUNTIME)")
+	cmd.Flags().StringP("output", "o", "human", "Output format (human|plain|json|xml|yaml) (Env: $FUNC_OUTPUT)")
+
+	if err := cmd.RegisterFlagCompletionFunc("runtime", CompleteRuntimeList); err != nil {
+		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
+	}
+	if err := cmd.RegisterFlagCompletionFunc("output", CompleteOutputFormatList); err != nil {
+		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
+	}
+
+	return cmd
+}
+
+func runTemplates(cmd *cobra.Command, newClient func(repositories string) *fn.Client) (err error) {
+	config := newTemplatesConfig()
+
+	var runtimes []string
+	if config.Runtime != "" {
+		runtimes = append(runtimes, config.Runtime)
+	}
+
+	templates, err := newClient(config.Repositories).Templates(runtimes...)
+	if err != nil {
+		return
+	}
+	if len(templates) == 0 {
+		if config.Runtime != "" {
+			return fmt.Errorf("no templates found for runtime '%v'", config.Runtime)
+		}
+		return fmt.Errorf("no templates found")
+	}
+
+	write(cmd.OutOrStdout(), newTemplateGroups(templates), config.Output)
+	return
+}
+
+// CLI Configuration (parameters)
+// ------------------------------
+
+type templatesConfig struct {
+	Repositories string
+	Runtime      string
+	Output       string
+}
+
+func newTemplatesConfig() templatesConfig {
+	return templatesConfig{
+		Repositories: viper.GetString("repositories"),
+		Runtime:      buildpacks.RuntimeAlias(viper.GetString("runtime")),
+		Output:       viper.GetString("output"),
+	}
+}
+
+// Output Formatting (serializers)
+// -------------------------------
+
+// templateSourceEmbedded is the source of templates embedded in func.
+const templateSourceEmbedded = "embedded"
+
+// templateGroup is the templates of a runtime, and the versions of the
+// runtime known to be supported, if any.
+type templateGroup struct {
+	Runtime   string         `json:"runtime" yaml:"runtime" xml:"name,attr"`
+	Versions  []string       `json:"versions,omitempty" yaml:"versions,omitempty" xml:"version,omitempty"`
+	Templates []templateInfo `json:"templates" yaml:"templates" xml:"template"`
+}
+
+// templateInfo is a template and its source: either "embedded" or the name
+// of the repository providing it.
+type templateInfo struct {
+	Name      string `json:"name" yaml:"name" xml:"name"`
+	Source    string `json:"source" yaml:"source" xml:"source"`
+	Signature string `json:"signature,omitempty" yaml:"signature,omitempty" xml:"signature,omitempty"`
+}
+
+type templateGroups struct {
+	XMLName xml.Name        `json:"-" yaml:"-" xml:"templates"`
+	Groups  []templateGroup `xml:"runtime"`
+}
+
+// newTemplateGroups groups the templates, which are sorted by runtime.
+func newTemplateGroups(templates []fn.Template) templateGroups {
+	groups := templateGroups{}
+	for _, t := range templates {
+		if len(groups.Groups) == 0 || groups.Groups[len(groups.Groups)-1].Runtime != t.Runtime {
+			groups.Groups = append(groups.Groups, templateGroup{Runtime: t.Runtime, Versions: buildpacks.RuntimeVersions[t.Runtime]})
+		}
+		source := t.Repository
+		if source == "" {
+			source = templateSourceEmbedded
+		}
+		g := &groups.Groups[len(groups.Groups)-1]
+		g.Templates = append(g.Templates, templateInfo{Name: t.Name, Source: source, Signature: t.Signature})
+	}
+	return groups
+}
+
+func (g templateGroups) Human(w io.Writer) error {
+	// minwidth, tabwidth, padding, padchar, flags
+	tabWriter := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	defer tabWriter.Flush()
+
+	for i, group := range g.Groups {
+		if i > 0 {
+			fmt.Fprintln(tabWriter)
+		}
+		if len(group.Versions) > 0 {
+			fmt.Fprintf(tabWriter, "%s (versions: %s)\n", group.Runtime, strings.Join(group.Versions, ", "))
+		} else {
+			fmt.Fprintf(tabWriter, "%s\n", group.Runtime)
+		}
+		fmt.Fprintf(tabWriter, "  %s\t%s\t%s\n", "NAME", "SOURCE", "SIGNATURE")
+		for _, t := range group.Templates {
+			fmt.Fprintf(tabWriter, "  %s\t%s\t%s\n", t.Name, t.Source, t.Signature)
+		}
+	}
+	return nil
+}
+
+func (g templateGroups) Plain(w io.Writer) error {
+	for _, group := range g.Groups {
+		for _, t := range group.Templates {
+			fmt.Fprintf(w, "%s %s %s %s\n", group.Runtime, t.Name, t.Source, t.Signature)
+		}
+	}
+	return nil
+}
+
+func (g templateGroups) JSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(g.Groups)
+}
+
+func (g templateGroups) XML(w io.Writer) error {
+	return xml.NewEncoder(w).Encode(g)
+}
+
+func (g templateGroups) YAML(w io.Writer) error {
+	return yaml.NewEncoder(w).Encode(g.Groups)
+}
+
+func (g templateGroups) URL(w io.Writer) error {
+	return fmt.Errorf("the url output format is not supported by the templates command")
+}
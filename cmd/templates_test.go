@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	fn "github.com/boson-project/func"
+)
+
+// TestTemplates ensures that templates are listed grouped by runtime, with
+// the source of each, including those of repositories.
+func TestTemplates(t *testing.T) {
+	repositories, err := filepath.Abs("../testdata/repositories")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	cmd := NewTemplatesCmd(func(r string) *fn.Client {
+		return fn.New(fn.WithRepositories(r))
+	})
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"--repositories", repositories, "--runtime", "test", "--output", "json"})
+	if err = cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	groups := []templateGroup{}
+	if err = json.Unmarshal(out.Bytes(), &groups); err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].Runtime != "test" {
+		t.Fatalf("expected only the 'test' runtime, got %+v", groups)
+	}
+	sources := map[string]string{}
+	for _, tpl := range groups[0].Templates {
+		sources[tpl.Name] = tpl.Source
+	}
+	if sources["tpla"] != "embedded" || sources["customProvider/tpla"] != "customProvider" {
+		t.Fatalf("expected embedded and repository templates with their sources, got %+v", groups[0].Templates)
+	}
+}
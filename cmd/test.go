@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+)
+
+func init() {
+	root.AddCommand(NewTestCmd())
+}
+
+// NewTestCmd creates a test command, which runs the tests of a function.
+func NewTestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Run the tests of a function",
+		Long: `Run the tests of a function
+
+Runs the tests of the function in the current directory or in the directory
+specified with --path, with the idiomatic test command of its runtime:
+
+  go                    go test ./...
+  node, typescript      npm test
+  python                python -m unittest
+  quarkus, springboot   mvn test (./mvnw test if the project has the wrapper)
+  rust                  cargo test
+
+Another command may be configured as test.command in func.yaml, which is run
+by the shell in the function's directory.  The output of the tests is streamed
+as they run, and should they fail, func exits with the exit code of the test
+command.
+`,
+		Example: `
+# Run the tests of the function in the current directory
+kn func test
+
+# Run the tests of the function in the myfunc directory
+kn func test --path myfunc
+`,
+		SuggestFor: []string{"tests", "tset"},
+		PreRunE:    bindEnv("path"),
+		RunE:       runTest,
+	}
+
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+
+	return cmd
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	config := newTestConfig()
+
+	client := fn.New(
+		fn.WithVerbose(config.Verbose),
+		fn.WithConfigFile(configFile()))
+
+	return client.Test(cmd.Context(), config.Path)
+}
+
+type testConfig struct {
+	// Path of the Function implementation on local disk. Defaults to current
+	// working directory of the process.
+	Path string
+
+	// Verbose logging.
+	Verbose bool
+}
+
+func newTestConfig() testConfig {
+	return testConfig{
+		Path:    viper.GetString("path"),
+		Verbose: viper.GetBool("verbose"),
+	}
+}
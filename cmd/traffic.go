@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/knative"
+)
+
+func init() {
+	root.AddCommand(NewTrafficCmd(newTrafficSplitter))
+}
+
+// newTrafficSplitter returns the splitter of the traffic of the deployed
+// Function.
+func newTrafficSplitter(namespace string) (fn.TrafficSplitter, error) {
+	return knative.NewTrafficSplitter(namespace)
+}
+
+// NewTrafficCmd creates a traffic command which splits the traffic of the
+// deployed Function between its revisions using splitters obtained from the
+// given constructor.
+func NewTrafficCmd(newSplitter func(namespace string) (fn.TrafficSplitter, error)) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "traffic [NAME]",
+		Short: "Split the traffic of a deployed function between its revisions",
+		Long: `Split the traffic of a deployed function between its revisions
+
+Routes the percent of the traffic of the deployed function given with --split
+to each of its revisions, such as to roll out a new revision gradually.  Each
+revision is referenced by its tag (see 'deploy --tag'), its name (see 'deploy
+--revision-name') or as @latest, for whichever is the latest revision.  The
+percents must total 100.  The tags of the function are preserved, and its
+revisions no longer split any traffic are otherwise no longer routed to.
+
+The function is that of the current directory or that specified with --path,
+unless its name is given.  The resulting split is printed once applied.
+`,
+		Example: `
+# Route 90% of the traffic of the function in the current directory to the
+# revision tagged "v1" and 10% to that tagged "v2"
+kn func traffic --split v1=90,v2=10
+
+# Route all of the traffic of the function "myfunc" to its latest revision
+kn func traffic myfunc --split @latest=100
+`,
+		SuggestFor:        []string{"split", "canary"},
+		ValidArgsFunction: CompleteFunctionList,
+		Args:              cobra.MaximumNArgs(1),
+		PreRunE:           bindEnv("path", "namespace", "split"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTraffic(cmd, args, newSplitter)
+		},
+	}
+
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace of the function. By default, the namespace in func.yaml is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
+	cmd.Flags().StringP("split", "s", "", "Percent of the traffic routed to each revision, by tag, name or @latest, ex 'v1=90,v2=10'. Must total 100 (Env: $FUNC_SPLIT)")
+
+	return cmd
+}
+
+func runTraffic(cmd *cobra.Command, args []string, newSplitter func(namespace string) (fn.TrafficSplitter, error)) (err error) {
+	config := newTrafficConfig(args)
+
+	if config.Split == "" {
+		return fmt.Errorf("the split of the traffic is required, ex: --split v1=90,v2=10")
+	}
+	split, err := fn.ParseTrafficSplit(config.Split)
+	if err != nil {
+		return
+	}
+	if config.Name == "" {
+		return fmt.Errorf("the given path '%v' does not contain an initialized function. Please provide the name of the function", config.Path)
+	}
+
+	namespace := config.Namespace
+	if namespace == "" {
+		if f, err := fn.NewFunctionFromFile(config.Path, configFile()); err == nil && f.Name == config.Name {
+			namespace = f.Namespace
+		}
+	}
+
+	if err = configureClusterAccess(); err != nil {
+		return
+	}
+	splitter, err := newSplitter(namespace)
+	if err != nil {
+		return
+	}
+
+	traffic, err := splitter.Split(cmd.Context(), config.Name, split)
+	if err != nil {
+		return
+	}
+	printTraffic(cmd.OutOrStdout(), config.Name, traffic)
+	return
+}
+
+// printTraffic of the named Function, as split, to w.
+func printTraffic(w io.Writer, name string, traffic []fn.TrafficTarget) {
+	fmt.Fprintf(w, "Traffic of function '%v':\n", name)
+	for _, t := range traffic {
+		revision := t.Revision
+		if t.Latest {
+			revision = fn.LatestRevision
+		}
+		if t.Tag != "" {
+			revision = fmt.Sprintf("%v (tag %v)", revision, t.Tag)
+		}
+		fmt.Fprintf(w, "  %3d%%  %v\n", t.Percent, revision)
+	}
+}
+
+type trafficConfig struct {
+	Name      string
+	Path      string
+	Namespace string
+	Split     string
+}
+
+func newTrafficConfig(args []string) trafficConfig {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	}
+	return trafficConfig{
+		Name:      deriveName(name, viper.GetString("path")),
+		Path:      viper.GetString("path"),
+		Namespace: viper.GetString("namespace"),
+		Split:     viper.GetString("split"),
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	fn "github.com/boson-project/func"
+)
+
+type testSplitter struct {
+	name  string
+	split fn.TrafficSplit
+}
+
+func (s *testSplitter) Split(ctx context.Context, name string, split fn.TrafficSplit) ([]fn.TrafficTarget, error) {
+	s.name, s.split = name, split
+	return []fn.TrafficTarget{
+		{Revision: "myfunc-v1", Tag: "v1", Percent: split["v1"]},
+		{Latest: true, Percent: split[fn.LatestRevision]},
+	}, nil
+}
+
+// TestTraffic ensures that the traffic of the function of the current
+// directory is split as given, the split being validated and the resulting
+// traffic printed.
+func TestTraffic(t *testing.T) {
+	defer fromTempDir(t)()
+
+	if err := fn.New().Create(fn.Function{Root: "myfunc", Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	splitter := &testSplitter{}
+	traffic := func(args ...string) (string, error) {
+		out := &bytes.Buffer{}
+		cmd := NewTrafficCmd(func(string) (fn.TrafficSplitter, error) {
+			return splitter, nil
+		})
+		cmd.SetOut(out)
+		cmd.SetArgs(append(args, "--path", "myfunc"))
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	if _, err := traffic("--split", "v1=90,@latest=20"); err == nil || !strings.Contains(err.Error(), "must total 100") {
+		t.Fatalf("expected a split not totalling 100 to be invalid, got '%v'", err)
+	}
+	if splitter.name != "" {
+		t.Fatal("expected an invalid split not to be applied")
+	}
+
+	out, err := traffic("--split", "v1=90,@latest=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if splitter.name != "myfunc" || splitter.split["v1"] != 90 || splitter.split[fn.LatestRevision] != 10 {
+		t.Fatalf("expected the traffic of 'myfunc' to be split, got '%v' split %v", splitter.name, splitter.split)
+	}
+	if !strings.Contains(out, " 90%  myfunc-v1 (tag v1)") || !strings.Contains(out, " 10%  @latest") {
+		t.Fatalf("expected the resulting split to be printed, got:\n%v", out)
+	}
+}
@@ -1,11 +1,19 @@
 package cmd
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strings"
 
 	"github.com/ory/viper"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/boson-project/func/buildpacks"
 )
 
 // metadata about the build process/binary etc.
@@ -22,25 +30,43 @@ func SetMeta(date, vers, hash string) {
 
 func init() {
 	root.AddCommand(versionCmd)
+	versionCmd.Flags().StringP("output", "o", "human", "Output format (human|plain|json|xml|yaml) (Env: $FUNC_OUTPUT)")
+
+	err := versionCmd.RegisterFlagCompletionFunc("output", CompleteOutputFormatList)
+	if err != nil {
+		fmt.Println("internal: error while calling RegisterFlagCompletionFunc: ", err)
+	}
 }
 
 var versionCmd = &cobra.Command{
-	Use:        "version",
-	Short:      "Show the version",
-	Long:       `Show the version
+	Use:   "version",
+	Short: "Show the version",
+	Long: `Show the version
+
+Prints the version of the func binary and the default builder image of each
+runtime, suitable for inclusion in bug reports.
 
-Use the --verbose option to include the build date stamp and commit hash"
+Use the --verbose option to include the build date stamp and commit hash,
+which are always included in structured output formats such as json.
 `,
-	SuggestFor: []string{"vers", "verison"},
-	Run:        runVersion,
+	Example: `
+# Show the version and default builders
+kn func version
+
+# Show the full version metadata as JSON
+kn func version -o json
+`,
+	SuggestFor:  []string{"vers", "verison"},
+	PreRunE:     bindEnv("output"),
+	Annotations: map[string]string{dryRunAnnotation: dryRunReadOnly},
+	Run:         runVersion,
 }
 
 func runVersion(cmd *cobra.Command, args []string) {
 	// update version with the value of the (global) flag 'verbose'
 	version.Verbose = viper.GetBool("verbose")
 
-	// version is the metadata, serialized.
-	fmt.Println(version)
+	write(os.Stdout, newVersionInfo(version), viper.GetString("output"))
 }
 
 // versionMetadata is set by the main package.
@@ -87,3 +113,75 @@ func (v Version) String() string {
 		}
 	}
 }
+
+// Output Formatting (serializers)
+// -------------------------------
+
+// versionInfo is the serializable version metadata of the binary, including
+// the default builder images with which Functions are built.
+type versionInfo struct {
+	XMLName  xml.Name       `json:"-" yaml:"-" xml:"version"`
+	Version  string         `json:"version" yaml:"version" xml:"version"`
+	Commit   string         `json:"commit" yaml:"commit" xml:"commit"`
+	Date     string         `json:"date" yaml:"date" xml:"date"`
+	Builders []builderImage `json:"builders" yaml:"builders" xml:"builders>builder"`
+
+	v Version // for the human readable representation
+}
+
+// builderImage is the default builder image of a runtime.
+type builderImage struct {
+	Runtime string `json:"runtime" yaml:"runtime" xml:"runtime"`
+	Image   string `json:"image" yaml:"image" xml:"image"`
+}
+
+func newVersionInfo(v Version) versionInfo {
+	builders := []builderImage{}
+	for runtime, image := range buildpacks.RuntimeToBuildpack {
+		builders = append(builders, builderImage{Runtime: runtime, Image: image})
+	}
+	sort.Slice(builders, func(i, j int) bool { return builders[i].Runtime < builders[j].Runtime })
+
+	return versionInfo{
+		Version:  Version{Vers: v.Vers}.String(), // semver, without metadata
+		Commit:   v.Hash,
+		Date:     v.Date,
+		Builders: builders,
+		v:        v,
+	}
+}
+
+func (v versionInfo) Human(w io.Writer) error {
+	fmt.Fprintln(w, v.v)
+	fmt.Fprintln(w, "Default builders:")
+	for _, b := range v.Builders {
+		fmt.Fprintf(w, "  %-12v %v\n", b.Runtime, b.Image)
+	}
+	return nil
+}
+
+func (v versionInfo) Plain(w io.Writer) error {
+	fmt.Fprintf(w, "Version %v\n", v.Version)
+	fmt.Fprintf(w, "Commit %v\n", v.Commit)
+	fmt.Fprintf(w, "Date %v\n", v.Date)
+	for _, b := range v.Builders {
+		fmt.Fprintf(w, "Builder %v %v\n", b.Runtime, b.Image)
+	}
+	return nil
+}
+
+func (v versionInfo) JSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (v versionInfo) XML(w io.Writer) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func (v versionInfo) YAML(w io.Writer) error {
+	return yaml.NewEncoder(w).Encode(v)
+}
+
+func (v versionInfo) URL(w io.Writer) error {
+	return fmt.Errorf("the url output format is not supported by the version command")
+}
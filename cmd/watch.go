@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ory/viper"
+	"github.com/spf13/cobra"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/docker"
+)
+
+// Targets of the watch command: the Function is either run locally or
+// deployed to the cluster as it changes.
+const (
+	watchTargetLocal   = "local"
+	watchTargetCluster = "cluster"
+)
+
+func init() {
+	root.AddCommand(NewWatchCmd(newWatchClient))
+}
+
+// newWatchClient returns an instance of fn.Client for the "Watch" command,
+// which builds with buildpacks and either runs the Function locally with
+// docker or deploys it as does the "Deploy" command.
+func newWatchClient(config watchConfig, listener fn.ProgressListener) (*fn.Client, error) {
+	if config.Target == watchTargetCluster {
+		return newDeployClient(config.deployConfig(), listener)
+	}
+	builder, err := newBuilder(config.buildConfig(), newBuildProgress(config.Verbose, listener), true, false)
+	if err != nil {
+		return nil, err
+	}
+	dockerfileBuilder := docker.NewBuilder()
+	dockerfileBuilder.Verbose = config.Verbose
+	runner := docker.NewRunner()
+	runner.Verbose = config.Verbose
+	mirrors, err := registryMirrors()
+	if err != nil {
+		return nil, err
+	}
+
+	return fn.New(
+		fn.WithVerbose(config.Verbose),
+		fn.WithConfigFile(configFile()),
+		fn.WithRegistry(config.Registry),
+		fn.WithRegistryMirrors(mirrors),
+		fn.WithBuilder(builder),
+		fn.WithDockerfileBuilder(dockerfileBuilder),
+		fn.WithBuildCache(config.buildConfig().buildCache()),
+		fn.WithRunner(runner),
+		fn.WithProgressListener(listener)), nil
+}
+
+// watchClientFn is a factory function which returns a Client which builds
+// the Function and either runs or deploys it, per the target of the config.
+type watchClientFn func(config watchConfig, listener fn.ProgressListener) (*fn.Client, error)
+
+// NewWatchCmd creates a watch command using the given client creator.
+func NewWatchCmd(newClient watchClientFn) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Rebuild and run or redeploy the function as its source changes",
+		Long: `Rebuild and run or redeploy the function as its source changes
+
+Builds the function in the current directory, or in that provided with --path,
+and runs it locally, then watches its source, rebuilding it and restarting it
+each time it changes.  With --target cluster, the function is instead deployed
+to the cluster, and redeployed as it changes.
+
+Changes are rebuilt once the source has been unchanged for --debounce, such
+that the files written together, such as by an editor or a git checkout, are
+rebuilt together.  The files ignored by the .funcignore of the function are
+not watched, nor are its func.yaml and .func directory, which are written as
+it is built and run.  The duration and result of each rebuild are reported;
+a function which fails to build keeps running as it was.
+
+Watching stops on Ctrl-C, stopping the function when running locally.
+`,
+		Example: `
+# Run the function in the current directory locally, restarting it as it
+# changes
+kn func watch
+
+# Redeploy the function to the cluster as it changes
+kn func watch --target cluster --registry quay.io/myuser
+`,
+		SuggestFor: []string{"wacth", "dev"},
+		PreRunE:    bindEnv("path", "target", "registry", "namespace", "debounce"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWatch(cmd, newClient)
+		},
+	}
+
+	cmd.Flags().StringP("path", "p", cwd(), "Path to the project directory (Env: $FUNC_PATH)")
+	cmd.Flags().String("target", watchTargetLocal, "Where the function is run as it changes: local or cluster (Env: $FUNC_TARGET)")
+	cmd.Flags().StringP("registry", "r", "", "Registry + namespace part of the image to build, ex 'quay.io/myuser', if not in the func.yaml (Env: $FUNC_REGISTRY)")
+	cmd.Flags().StringP("namespace", "n", "", "Namespace of the function deployed with --target cluster. By default, the namespace in func.yaml is used or the actual active namespace if not set in the configuration. (Env: $FUNC_NAMESPACE)")
+	cmd.Flags().Duration("debounce", fn.DefaultWatchDebounce, "Period for which the source must be unchanged before it is rebuilt (Env: $FUNC_DEBOUNCE)")
+
+	registerCompletions(cmd, map[string]completionFunc{
+		"target": completeValues(watchTargetLocal, watchTargetCluster),
+	})
+
+	return cmd
+}
+
+func runWatch(cmd *cobra.Command, newClient watchClientFn) (err error) {
+	config := newWatchConfig()
+	if config.Target != watchTargetLocal && config.Target != watchTargetCluster {
+		return fmt.Errorf("invalid value '%v' for --target: must be %v or %v", config.Target, watchTargetLocal, watchTargetCluster)
+	}
+	if config.Debounce <= 0 {
+		return fmt.Errorf("invalid value '%v' for --debounce: must be positive", config.Debounce)
+	}
+
+	f, err := fn.Load(config.Path, configFile())
+	if err != nil {
+		return
+	}
+	if config.Target == watchTargetCluster {
+		if err = configureClusterAccess(); err != nil {
+			return
+		}
+		if config.Namespace == "" {
+			config.Namespace = f.Namespace
+		}
+	}
+
+	out := infoOut(cmd.OutOrStdout())
+	mu := &sync.Mutex{}
+	listener := &prefixedListener{mu: mu, out: out, prefix: f.Name, verbose: config.Verbose}
+	client, err := newClient(config, listener)
+	if err != nil {
+		return
+	}
+
+	w := &watcher{client: client, config: config, name: f.Name, mu: mu, out: out}
+	defer w.stop()
+
+	ctx := cmd.Context()
+	if err = w.rebuild(ctx, nil); err != nil || ctx.Err() != nil {
+		return
+	}
+	w.printf("Watching %v for changes. Press Ctrl-C to stop\n", f.Root)
+	if err = fn.Watch(ctx, f, config.Debounce, func(paths []string) {
+		w.rebuild(ctx, paths)
+	}); err != nil {
+		return
+	}
+	w.printf("Stopped watching function '%v'\n", f.Name)
+	return nil
+}
+
+// watcher rebuilds the Function as it changes, and either restarts it
+// locally or redeploys it.
+type watcher struct {
+	client *fn.Client
+	config watchConfig
+	name   string
+
+	// mu guards out, to which the Function running locally may report
+	// concurrently.
+	mu  *sync.Mutex
+	out io.Writer
+
+	// stopRun stops the Function running locally, if it is, returning once
+	// it has stopped.
+	stopRun func()
+}
+
+// rebuild the Function, reporting the duration and result with the paths
+// changed, if any.  A failure is reported rather than returned, such that
+// watching continues, other than that of the first build.
+func (w *watcher) rebuild(ctx context.Context, paths []string) error {
+	start := time.Now()
+	var done string
+	err := w.client.Build(ctx, w.config.Path)
+	if err == nil && w.config.Target == watchTargetCluster {
+		err = w.client.Deploy(ctx, w.config.Path)
+		done = "Built and deployed"
+	} else if err == nil {
+		w.stop()
+		w.start(ctx)
+		done = "Built and started"
+		if paths != nil {
+			done = "Built and restarted"
+		}
+	}
+	elapsed := roundDuration(time.Since(start))
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	changed := ""
+	if len(paths) > 0 {
+		changed = fmt.Sprintf(" (changed: %v)", strings.Join(paths, ", "))
+	}
+	if err != nil {
+		if paths == nil {
+			return err
+		}
+		w.printf("Failed to rebuild function '%v' after %v%v: %v\n", w.name, elapsed, changed, err)
+		return nil
+	}
+	w.printf("%v function '%v' in %v%v\n", done, w.name, elapsed, changed)
+	return nil
+}
+
+// start running the Function locally until stopped, reporting it if it
+// exits of itself.
+func (w *watcher) start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		if err := w.client.Run(ctx, w.config.Path); err != nil && ctx.Err() == nil {
+			w.printf("Function '%v' exited: %v\n", w.name, err)
+		}
+	}()
+	w.stopRun = func() {
+		cancel()
+		<-stopped
+	}
+}
+
+// printf reports to the output of the watcher.
+func (w *watcher) printf(format string, args ...interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.out, format, args...)
+}
+
+// stop the Function running locally, if it is.
+func (w *watcher) stop() {
+	if w.stopRun != nil {
+		w.stopRun()
+		w.stopRun = nil
+	}
+}
+
+type watchConfig struct {
+	// Path of the Function implementation on local disk. Defaults to current
+	// working directory of the process.
+	Path string
+
+	// Target where the Function is run: locally or on the cluster.
+	Target string
+
+	// Registry from which the image is derived, if the Function has none.
+	Registry string
+
+	// Namespace override of the Function deployed to the cluster.
+	Namespace string
+
+	// Debounce is the period for which the source must be unchanged before
+	// it is rebuilt.
+	Debounce time.Duration
+
+	// Verbose logging.
+	Verbose bool
+}
+
+// newWatchConfig returns a config populated from the current execution
+// context (flags and environment variables).
+func newWatchConfig() watchConfig {
+	return watchConfig{
+		Path:      viper.GetString("path"),
+		Target:    viper.GetString("target"),
+		Registry:  viper.GetString("registry"),
+		Namespace: viper.GetString("namespace"),
+		Debounce:  viper.GetDuration("debounce"),
+		Verbose:   viper.GetBool("verbose"),
+	}
+}
+
+// buildConfig with which the Function is built: with the defaults of the
+// build command.
+func (c watchConfig) buildConfig() buildConfig {
+	return buildConfig{
+		Path:     c.Path,
+		Registry: c.Registry,
+		Verbose:  c.Verbose,
+	}
+}
+
+// deployConfig with which the Function is built, pushed and deployed with
+// --target cluster: with the defaults of the deploy command.
+func (c watchConfig) deployConfig() deployConfig {
+	return deployConfig{
+		buildConfig: c.buildConfig(),
+		Namespace:   c.Namespace,
+		Verbose:     c.Verbose,
+		Build:       true,
+		Push:        true,
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/mock"
+)
+
+// TestWatchCmd ensures the function is built and run, and rebuilt and
+// restarted as its source changes, until interrupted.
+func TestWatchCmd(t *testing.T) {
+	defer fromTempDir(t)()
+	root := pwd(t)
+	funcYaml := "name: myfunc\nruntime: go\nimage: example.com/alice/myfunc:latest\n"
+	if err := ioutil.WriteFile(filepath.Join(root, "func.yaml"), []byte(funcYaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	builds := make(chan struct{}, 2)
+	builder := mock.NewBuilder()
+	builder.BuildFn = func(fn.Function) error {
+		builds <- struct{}{}
+		return nil
+	}
+	runner := mock.NewRunner()
+	cmd := NewWatchCmd(func(config watchConfig, listener fn.ProgressListener) (*fn.Client, error) {
+		if config.Target != watchTargetLocal {
+			t.Fatalf("expected the function to be run locally, got target '%v'", config.Target)
+		}
+		return fn.New(
+			fn.WithBuilder(builder),
+			fn.WithRunner(runner),
+			fn.WithProgressListener(listener)), nil
+	})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"-p", root, "--debounce", "50ms"})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- cmd.ExecuteContext(ctx) }()
+
+	waitBuild := func() {
+		select {
+		case <-builds:
+		case err := <-done:
+			t.Fatalf("expected the function to be built, the command returned '%v'", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the function to be built")
+		}
+	}
+	waitBuild()
+	// Allow the watcher to be started
+	time.Sleep(200 * time.Millisecond)
+	if err := ioutil.WriteFile(filepath.Join(root, "handle.go"), []byte("package function\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitBuild()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if !runner.RunInvoked {
+		t.Fatal("expected the function to be run")
+	}
+	for _, expected := range []string{
+		"Built and started function 'myfunc'",
+		"Built and restarted function 'myfunc'",
+		"(changed: handle.go)",
+		"Stopped watching function 'myfunc'",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Fatalf("expected %q to be reported, got:\n%v", expected, out.String())
+		}
+	}
+}
+
+// TestWatchCmdTarget ensures an unknown target is rejected.
+func TestWatchCmdTarget(t *testing.T) {
+	cmd := NewWatchCmd(func(config watchConfig, listener fn.ProgressListener) (*fn.Client, error) {
+		t.Fatal("expected no client to be created")
+		return nil, nil
+	})
+	cmd.SetArgs([]string{"--target", "remote"})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "invalid value 'remote' for --target") {
+		t.Fatalf("expected the target to be rejected, got '%v'", err)
+	}
+}
@@ -4,19 +4,29 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/boson-project/func/utils"
+	"github.com/google/go-containerregistry/pkg/name"
 	"gopkg.in/yaml.v2"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // ConfigFile is the name of the config's serialized form.
 const ConfigFile = "func.yaml"
 
+// SpecVersion of the config's serialized form written by this version.
+const SpecVersion = "0.1.0"
+
 var (
 	regWholeSecret      = regexp.MustCompile(`^{{\s*secret:((?:\w|['-]\w)+)\s*}}$`)
 	regKeyFromSecret    = regexp.MustCompile(`^{{\s*secret:((?:\w|['-]\w)+):(\w+)\s*}}$`)
@@ -27,9 +37,21 @@ var (
 
 type Volumes []Volume
 type Volume struct {
-	Secret    *string `yaml:"secret,omitempty"`
-	ConfigMap *string `yaml:"configMap,omitempty"`
-	Path      *string `yaml:"path"`
+	Secret    *string   `yaml:"secret,omitempty" json:"secret,omitempty"`
+	ConfigMap *string   `yaml:"configMap,omitempty" json:"configMap,omitempty"`
+	EmptyDir  *EmptyDir `yaml:"emptyDir,omitempty" json:"emptyDir,omitempty"`
+	Path      *string   `yaml:"path" json:"path"`
+}
+
+// EmptyDir is a volume which is empty when the Function starts, such as for
+// files it writes at runtime, and is removed when it stops.
+type EmptyDir struct {
+	// Medium of the volume: empty for the storage of the node, or Memory for
+	// a tmpfs.
+	Medium string `yaml:"medium,omitempty" json:"medium,omitempty"`
+
+	// SizeLimit of the volume, such as 64Mi.
+	SizeLimit *string `yaml:"sizeLimit,omitempty" json:"sizeLimit,omitempty"`
 }
 
 func (v Volume) String() string {
@@ -37,11 +59,48 @@ func (v Volume) String() string {
 		return fmt.Sprintf("ConfigMap \"%s\" mounted at path: \"%s\"", *v.ConfigMap, *v.Path)
 	} else if v.Secret != nil {
 		return fmt.Sprintf("Secret \"%s\" mounted at path: \"%s\"", *v.Secret, *v.Path)
+	} else if v.EmptyDir != nil {
+		return fmt.Sprintf("EmptyDir mounted at path: \"%s\"", *v.Path)
 	}
 
 	return ""
 }
 
+// ParseVolume parses a volume of the form secret:[name]:[path],
+// configMap:[name]:[path] or emptyDir:[path], such as
+// secret:my-secret:/etc/config, mounting the Secret or ConfigMap of the name,
+// or an empty directory, at the path.  The path must be absolute.
+func ParseVolume(spec string) (v Volume, err error) {
+	parts := strings.SplitN(spec, ":", 3)
+	switch strings.ToLower(parts[0]) {
+	case "secret", "configmap":
+		if len(parts) != 3 || parts[1] == "" {
+			return v, fmt.Errorf("invalid volume '%v': expected %v:[name]:[path]", spec, parts[0])
+		}
+		name := parts[1]
+		if strings.ToLower(parts[0]) == "secret" {
+			v.Secret = &name
+		} else {
+			v.ConfigMap = &name
+		}
+		parts = parts[2:]
+	case "emptydir":
+		if len(parts) != 2 {
+			return v, fmt.Errorf("invalid volume '%v': expected emptyDir:[path]", spec)
+		}
+		v.EmptyDir = &EmptyDir{}
+		parts = parts[1:]
+	default:
+		return v, fmt.Errorf("invalid volume '%v': expected secret:[name]:[path], configMap:[name]:[path] or emptyDir:[path]", spec)
+	}
+	path := parts[0]
+	if !strings.HasPrefix(path, "/") {
+		return v, fmt.Errorf("invalid volume '%v': the path '%v' must be absolute", spec, path)
+	}
+	v.Path = &path
+	return
+}
+
 type Envs []Env
 type Env struct {
 	Name  *string `yaml:"name,omitempty"`
@@ -80,6 +139,9 @@ func (e Env) String() string {
 type Options struct {
 	Scale     *ScaleOptions     `yaml:"scale,omitempty"`
 	Resources *ResourcesOptions `yaml:"resources,omitempty"`
+	// RequestTimeout is the maximum number of seconds within which a request
+	// to the Function must be responded to.  Defaults to that of Knative.
+	RequestTimeout *int64 `yaml:"requestTimeout,omitempty"`
 }
 
 type ScaleOptions struct {
@@ -88,6 +150,14 @@ type ScaleOptions struct {
 	Metric      *string  `yaml:"metric,omitempty"`
 	Target      *float64 `yaml:"target,omitempty"`
 	Utilization *float64 `yaml:"utilization,omitempty"`
+	// Window over which the metric is averaged to scale by, such as "60s".
+	Window *string `yaml:"window,omitempty"`
+	// ScaleDownDelay for which the demand must stay lower before the
+	// Function is scaled down, such as "15m".
+	ScaleDownDelay *string `yaml:"scaleDownDelay,omitempty"`
+	// RetentionPeriod for which the last instance is kept once the Function
+	// is to be scaled to zero, such as "5m".
+	RetentionPeriod *string `yaml:"retentionPeriod,omitempty"`
 }
 
 type ResourcesOptions struct {
@@ -99,37 +169,197 @@ type ResourcesLimitsOptions struct {
 	CPU         *string `yaml:"cpu,omitempty"`
 	Memory      *string `yaml:"memory,omitempty"`
 	Concurrency *int64  `yaml:"concurrency,omitempty"`
+	// Extended resources, such as the accelerator nvidia.com/gpu, by their
+	// fully qualified names, alongside cpu and memory.
+	Extended map[string]string `yaml:",inline"`
 }
 
 type ResourcesRequestsOptions struct {
 	CPU    *string `yaml:"cpu,omitempty"`
 	Memory *string `yaml:"memory,omitempty"`
+	// Extended resources, which are not overcommitted: each must equal its
+	// limit.  See ResourcesLimitsOptions.
+	Extended map[string]string `yaml:",inline"`
+}
+
+// ValidateExtendedResource ensures the name is that of an extended resource,
+// such as nvidia.com/gpu: fully qualified, outside of the kubernetes.io domain
+// of those native to Kubernetes, and that its quantity is a positive whole
+// number, as extended resources can not be fractional.
+func ValidateExtendedResource(name, quantity string) error {
+	if !strings.Contains(name, "/") || strings.Contains(name, "kubernetes.io/") || strings.HasPrefix(name, "requests.") {
+		return fmt.Errorf("'%v' is not the fully qualified name of an extended resource, such as nvidia.com/gpu. The resources native to Kubernetes are cpu and memory", name)
+	}
+	if errs := validation.IsQualifiedName("requests." + name); len(errs) > 0 {
+		return fmt.Errorf("'%v' is not a valid resource name: %v", name, strings.Join(errs, "; "))
+	}
+	q, err := resource.ParseQuantity(quantity)
+	if err != nil {
+		return fmt.Errorf("invalid quantity '%v' of '%v': %v", quantity, name, err)
+	}
+	if q.Sign() <= 0 || q.MilliValue()%1000 != 0 {
+		return fmt.Errorf("invalid quantity '%v' of '%v': extended resources must be of a positive whole number", quantity, name)
+	}
+	return nil
+}
+
+// extendedNames returns the names of the extended resources in order.
+func extendedNames(extended map[string]string) []string {
+	names := make([]string, 0, len(extended))
+	for name := range extended {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Health probes of a deployed Function.
+type Health struct {
+	Liveness  *Probe `yaml:"liveness,omitempty"`
+	Readiness *Probe `yaml:"readiness,omitempty"`
+}
+
+// Probe is an HTTP health probe.  Unset fields default to those of the
+// runtime's probe, or of Knative.
+type Probe struct {
+	Path                *string `yaml:"path,omitempty"`
+	InitialDelaySeconds *int32  `yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       *int32  `yaml:"periodSeconds,omitempty"`
+}
+
+// InitContainer run to completion in the pods of a deployed Function before
+// it is started, such as to run migrations or download assets.
+type InitContainer struct {
+	// Name of the container, unique among those of the Function.
+	Name string `yaml:"name" json:"name"`
+	// Image of the container.
+	Image string `yaml:"image" json:"image"`
+	// Command run, with its arguments.  Defaults to the entrypoint of the
+	// image.
+	Command []string `yaml:"command,omitempty" json:"command,omitempty"`
+	// Envs of the container, as are those of the Function.
+	Envs Envs `yaml:"envs,omitempty" json:"envs,omitempty"`
+}
+
+// DefaultInitContainerName is that of the init container set with the
+// deploy command if none is named.
+const DefaultInitContainerName = "init"
+
+// Metrics endpoint of a deployed Function which exposes Prometheus metrics,
+// by which it is scraped.  The Function is scraped only if the port is set.
+type Metrics struct {
+	// Port on which the metrics are served.
+	Port int `yaml:"port,omitempty"`
+	// Path at which the metrics are served.  Defaults to DefaultMetricsPath.
+	Path string `yaml:"path,omitempty"`
+}
+
+// Tracing of a deployed Function by OpenTelemetry, configured by the standard
+// OTEL_* environment variables of its container.  The Function is traced
+// only if the endpoint is set.
+type Tracing struct {
+	// Endpoint of the OTLP collector to which spans are exported, such as
+	// http://otel-collector.observability:4317.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// ServiceName of the spans exported.  Defaults to the Function's name.
+	ServiceName string `yaml:"serviceName,omitempty"`
+}
+
+// Git repository from which the Function's source is fetched when built on
+// the cluster.
+type Git struct {
+	// URL of the repository, for example https://github.com/alice/myfunc.git
+	URL string `yaml:"url,omitempty"`
+	// Revision (branch or tag) built, of which only the last commit is
+	// cloned.  Defaults to the repository's default branch.
+	Revision string `yaml:"revision,omitempty"`
+}
+
+// FunctionStatus of a Function as last deployed, recorded in its config
+// file after each successful deploy (see WithStatus), such that it may be
+// described without access to the cluster, and drift from the cluster
+// detected.  It is written by deploys, and is not to be edited.
+type FunctionStatus struct {
+	// Image deployed, by digest if deployed by digest.
+	Image string `yaml:"image,omitempty"`
+	// Revision created by the deploy, such as "myfunc-00002".
+	Revision string `yaml:"revision,omitempty"`
+	// PreviousRevision is that created by the deploy before, the last known
+	// to be good, to which the Function is rolled back by default.
+	PreviousRevision string `yaml:"previousRevision,omitempty"`
+	// URL at which the Function is reachable.
+	URL string `yaml:"url,omitempty"`
+	// Domain mapped to the Function, if any, of which the DomainMapping is
+	// removed by the next deploy without it.
+	Domain string `yaml:"domain,omitempty"`
+	// Deployed is the time at which the deploy completed.
+	Deployed time.Time `yaml:"deployed,omitempty"`
 }
 
 // Config represents the serialized state of a Function's metadata.
 // See the Function struct for attribute documentation.
 type config struct {
-	Name        string            `yaml:"name"`
-	Namespace   string            `yaml:"namespace"`
-	Runtime     string            `yaml:"runtime"`
-	Image       string            `yaml:"image"`
-	ImageDigest string            `yaml:"imageDigest"`
-	Builder     string            `yaml:"builder"`
-	BuilderMap  map[string]string `yaml:"builderMap"`
-	Volumes     Volumes           `yaml:"volumes"`
-	Envs        Envs              `yaml:"envs"`
-	Annotations map[string]string `yaml:"annotations"`
-	Options     Options           `yaml:"options"`
+	SpecVersion       string                 `yaml:"specVersion,omitempty"`
+	Name              string                 `yaml:"name"`
+	Namespace         string                 `yaml:"namespace"`
+	Runtime           string                 `yaml:"runtime"`
+	RuntimeVersion    string                 `yaml:"runtimeVersion,omitempty"`
+	Template          string                 `yaml:"template,omitempty"`
+	TemplateRef       string                 `yaml:"templateRef,omitempty"`
+	TemplateCommit    string                 `yaml:"templateCommit,omitempty"`
+	Entrypoint        string                 `yaml:"entrypoint,omitempty"`
+	CI                string                 `yaml:"ci,omitempty"`
+	Devcontainer      bool                   `yaml:"devcontainer,omitempty"`
+	License           string                 `yaml:"license,omitempty"`
+	PackageManager    string                 `yaml:"packageManager,omitempty"`
+	Registry          string                 `yaml:"registry,omitempty"`
+	RegistryMirrors   map[string]string      `yaml:"registryMirrors,omitempty"`
+	ImageSuffix       string                 `yaml:"imageSuffix,omitempty"`
+	Image             string                 `yaml:"image"`
+	ImageDigest       string                 `yaml:"imageDigest"`
+	PullSecret        string                 `yaml:"pullSecret,omitempty"`
+	ServiceAccount    string                 `yaml:"serviceAccount,omitempty"`
+	ImagePullPolicy   string                 `yaml:"imagePullPolicy,omitempty"`
+	Mesh              string                 `yaml:"mesh,omitempty"`
+	Port              int                    `yaml:"port,omitempty"`
+	IngressClass      string                 `yaml:"ingressClass,omitempty"`
+	Domain            string                 `yaml:"domain,omitempty"`
+	RevisionName      string                 `yaml:"revisionName,omitempty"`
+	TrafficTag        string                 `yaml:"trafficTag,omitempty"`
+	Locked            bool                   `yaml:"locked,omitempty"`
+	Next              string                 `yaml:"next,omitempty"`
+	DependsOn         []string               `yaml:"dependsOn,omitempty"`
+	Builder           string                 `yaml:"builder"`
+	BuilderMap        map[string]string      `yaml:"builderMap"`
+	BuilderDigest     string                 `yaml:"builderDigest,omitempty"`
+	BuilderPullPolicy string                 `yaml:"builderPullPolicy,omitempty"`
+	LifecycleImage    string                 `yaml:"lifecycleImage,omitempty"`
+	PlatformAPI       string                 `yaml:"platformAPI,omitempty"`
+	Volumes           Volumes                `yaml:"volumes"`
+	Envs              Envs                   `yaml:"envs"`
+	BuildEnvs         Envs                   `yaml:"buildEnvs,omitempty"`
+	Platform          string                 `yaml:"platform,omitempty"`
+	Build             BuildSpec              `yaml:"build,omitempty"`
+	Annotations       map[string]string      `yaml:"annotations"`
+	Options           Options                `yaml:"options"`
+	Health            Health                 `yaml:"health,omitempty"`
+	Metrics           Metrics                `yaml:"metrics,omitempty"`
+	Tracing           Tracing                `yaml:"tracing,omitempty"`
+	InitContainers    []InitContainer        `yaml:"initContainers,omitempty"`
+	Invocation        Invocation             `yaml:"invocation,omitempty"`
+	Git               Git                    `yaml:"git,omitempty"`
+	Test              Test                   `yaml:"test,omitempty"`
+	Environments      map[string]Environment `yaml:"environments,omitempty"`
+	Status            FunctionStatus         `yaml:"status,omitempty"`
 	// Add new values to the toConfig/fromConfig functions.
 }
 
-// newConfig returns a Config populated from data serialized to disk if it is
-// available.  Errors are returned if the path is not valid, if there are
+// newConfig returns a Config populated from data serialized to disk at the
+// path of the config file, if it is available.  Errors are returned if the path is not valid, if there are
 // errors accessing an extant config file, or the contents of the file do not
 // unmarshall.  A missing file at a valid path does not error but returns the
 // empty value of Config.
-func newConfig(root string) (c config, err error) {
-	filename := filepath.Join(root, ConfigFile)
+func newConfig(filename string) (c config, err error) {
 	if _, err = os.Stat(filename); err != nil {
 		// do not consider a missing config file an error.  Just return.
 		if os.IsNotExist(err) {
@@ -141,68 +371,33 @@ func newConfig(root string) (c config, err error) {
 	if err != nil {
 		return
 	}
+	return parseConfig(filename, bb)
+}
 
-	errMsg := ""
-	errMsgHeader := "'func.yaml' config file is not valid:\n"
-	errMsgReg := regexp.MustCompile("not found in type .*")
+// parseConfig returns a Config unmarshalled from the contents of the config
+// file of the given name, validating its entries as does newConfig.  Its
+// problems, if any, are returned together as a ValidationError.
+func parseConfig(filename string, bb []byte) (c config, err error) {
+	var errs []FieldError
 
 	// Let's try to unmarshal the config file, any fields that are found
 	// in the data that do not have corresponding struct members, or mapping
 	// keys that are duplicates, will result in an error.
-	err = yaml.UnmarshalStrict(bb, &c)
-	if err != nil {
-		errMsg = err.Error()
-
-		if strings.HasPrefix(errMsg, "yaml: unmarshal errors:") {
-			errMsg = errMsgReg.ReplaceAllString(errMsg, "is not valid")
-			errMsg = strings.Replace(errMsg, "yaml: unmarshal errors:\n", errMsgHeader, 1)
-		} else if strings.HasPrefix(errMsg, "yaml:") {
-			errMsg = errMsgReg.ReplaceAllString(errMsg, "is not valid")
-			errMsg = strings.Replace(errMsg, "yaml: ", errMsgHeader+"  ", 1)
-		}
-	}
-
-	// Let's check that all entries in `volumes`, `envs` and `options` contain all required fields
-	volumesErrors := validateVolumes(c.Volumes)
-	envsErrors := ValidateEnvs(c.Envs)
-	optionsErrors := validateOptions(c.Options)
-	if len(volumesErrors) > 0 || len(envsErrors) > 0 || len(optionsErrors) > 0 {
-		// if there aren't any previously reported errors, we need to set the error message header first
-		if errMsg == "" {
-			errMsg = errMsgHeader
-		} else {
-			// if there are some previously reporeted errors, we need to indent them
-			errMsg = errMsg + "\n"
-		}
-
-		// lets make the error message a little bit nice -> indent each error message
-		for i := range volumesErrors {
-			volumesErrors[i] = "  " + volumesErrors[i]
-		}
-		for i := range envsErrors {
-			envsErrors[i] = "  " + envsErrors[i]
+	if err := yaml.UnmarshalStrict(bb, &c); err != nil {
+		msg := strings.TrimPrefix(err.Error(), "yaml: unmarshal errors:\n")
+		msg = strings.TrimPrefix(msg, "yaml: ")
+		msg = regexp.MustCompile("not found in type .*").ReplaceAllString(msg, "is not valid")
+		for _, line := range strings.Split(msg, "\n") {
+			errs = append(errs, FieldError{Message: strings.TrimSpace(line)})
 		}
-		for i := range optionsErrors {
-			optionsErrors[i] = "  " + optionsErrors[i]
-		}
-
-		errMsg = errMsg + strings.Join(volumesErrors, "\n")
-		// we have errors from both volumes and envs sections -> let's make sure they are both indented
-		if len(volumesErrors) > 0 && len(envsErrors) > 0 {
-			errMsg = errMsg + "\n"
-		}
-		errMsg = errMsg + strings.Join(envsErrors, "\n")
-		// lets indent options related errors if there are already some set
-		if len(optionsErrors) > 0 && (len(volumesErrors) > 0 || len(envsErrors) > 0) {
-			errMsg = errMsg + "\n"
-		}
-		errMsg = errMsg + strings.Join(optionsErrors, "\n")
 	}
 
-	if errMsg != "" {
-		err = errors.New(errMsg)
+	// Let's check that all entries in `volumes`, `envs` and `options`, and
+	// the other settings, are valid.
+	errs = append(errs, fromConfig(c).validate()...)
+	if len(errs) > 0 {
+		err = ValidationError{File: filepath.Base(filename), Errors: errs}
 	}
-
 	return
 }
 
@@ -210,40 +405,122 @@ func newConfig(root string) (c config, err error) {
 // Note that config does not include ancillary fields not serialized, such as Root.
 func fromConfig(c config) (f Function) {
 	return Function{
-		Name:        c.Name,
-		Namespace:   c.Namespace,
-		Runtime:     c.Runtime,
-		Image:       c.Image,
-		ImageDigest: c.ImageDigest,
-		Builder:     c.Builder,
-		BuilderMap:  c.BuilderMap,
-		Volumes:     c.Volumes,
-		Envs:        c.Envs,
-		Annotations: c.Annotations,
-		Options:     c.Options,
+		SpecVersion:       c.SpecVersion,
+		Name:              c.Name,
+		Namespace:         c.Namespace,
+		Runtime:           c.Runtime,
+		RuntimeVersion:    c.RuntimeVersion,
+		Template:          c.Template,
+		TemplateRef:       c.TemplateRef,
+		TemplateCommit:    c.TemplateCommit,
+		Entrypoint:        c.Entrypoint,
+		CI:                c.CI,
+		Devcontainer:      c.Devcontainer,
+		License:           c.License,
+		PackageManager:    c.PackageManager,
+		Registry:          c.Registry,
+		RegistryMirrors:   c.RegistryMirrors,
+		ImageSuffix:       c.ImageSuffix,
+		Image:             c.Image,
+		ImageDigest:       c.ImageDigest,
+		PullSecret:        c.PullSecret,
+		ServiceAccount:    c.ServiceAccount,
+		ImagePullPolicy:   c.ImagePullPolicy,
+		Mesh:              c.Mesh,
+		Port:              c.Port,
+		IngressClass:      c.IngressClass,
+		Domain:            c.Domain,
+		RevisionName:      c.RevisionName,
+		TrafficTag:        c.TrafficTag,
+		Locked:            c.Locked,
+		Next:              c.Next,
+		DependsOn:         c.DependsOn,
+		Builder:           c.Builder,
+		BuilderMap:        c.BuilderMap,
+		BuilderDigest:     c.BuilderDigest,
+		BuilderPullPolicy: c.BuilderPullPolicy,
+		LifecycleImage:    c.LifecycleImage,
+		PlatformAPI:       c.PlatformAPI,
+		Volumes:           c.Volumes,
+		Envs:              c.Envs,
+		BuildEnvs:         c.BuildEnvs,
+		Platform:          c.Platform,
+		Build:             c.Build,
+		Annotations:       c.Annotations,
+		Options:           c.Options,
+		Health:            c.Health,
+		Metrics:           c.Metrics,
+		Tracing:           c.Tracing,
+		InitContainers:    c.InitContainers,
+		Invocation:        c.Invocation,
+		Git:               c.Git,
+		Test:              c.Test,
+		Environments:      c.Environments,
+		Status:            c.Status,
 	}
 }
 
 // toConfig serializes a Function to a config object.
 func toConfig(f Function) config {
 	return config{
-		Name:        f.Name,
-		Namespace:   f.Namespace,
-		Runtime:     f.Runtime,
-		Image:       f.Image,
-		ImageDigest: f.ImageDigest,
-		Builder:     f.Builder,
-		BuilderMap:  f.BuilderMap,
-		Volumes:     f.Volumes,
-		Envs:        f.Envs,
-		Annotations: f.Annotations,
-		Options:     f.Options,
+		SpecVersion:       f.SpecVersion,
+		Name:              f.Name,
+		Namespace:         f.Namespace,
+		Runtime:           f.Runtime,
+		RuntimeVersion:    f.RuntimeVersion,
+		Template:          f.Template,
+		TemplateRef:       f.TemplateRef,
+		TemplateCommit:    f.TemplateCommit,
+		Entrypoint:        f.Entrypoint,
+		CI:                f.CI,
+		Devcontainer:      f.Devcontainer,
+		License:           f.License,
+		PackageManager:    f.PackageManager,
+		Registry:          f.Registry,
+		RegistryMirrors:   f.RegistryMirrors,
+		ImageSuffix:       f.ImageSuffix,
+		Image:             f.Image,
+		ImageDigest:       f.ImageDigest,
+		PullSecret:        f.PullSecret,
+		ServiceAccount:    f.ServiceAccount,
+		ImagePullPolicy:   f.ImagePullPolicy,
+		Mesh:              f.Mesh,
+		Port:              f.Port,
+		IngressClass:      f.IngressClass,
+		Domain:            f.Domain,
+		RevisionName:      f.RevisionName,
+		TrafficTag:        f.TrafficTag,
+		Locked:            f.Locked,
+		Next:              f.Next,
+		DependsOn:         f.DependsOn,
+		Builder:           f.Builder,
+		BuilderMap:        f.BuilderMap,
+		BuilderDigest:     f.BuilderDigest,
+		BuilderPullPolicy: f.BuilderPullPolicy,
+		LifecycleImage:    f.LifecycleImage,
+		PlatformAPI:       f.PlatformAPI,
+		Volumes:           f.Volumes,
+		Envs:              f.Envs,
+		BuildEnvs:         f.BuildEnvs,
+		Platform:          f.Platform,
+		Build:             f.Build,
+		Annotations:       f.Annotations,
+		Options:           f.Options,
+		Health:            f.Health,
+		Metrics:           f.Metrics,
+		Tracing:           f.Tracing,
+		InitContainers:    f.InitContainers,
+		Invocation:        f.Invocation,
+		Git:               f.Git,
+		Test:              f.Test,
+		Environments:      f.Environments,
+		Status:            f.Status,
 	}
 }
 
-// writeConfig for the given Function out to disk at root.
+// writeConfig for the given Function out to disk at its config path.
 func writeConfig(f Function) (err error) {
-	path := filepath.Join(f.Root, ConfigFile)
+	path := f.ConfigPath()
 	c := toConfig(f)
 	var bb []byte
 	if bb, err = yaml.Marshal(&c); err != nil {
@@ -260,22 +537,45 @@ func writeConfig(f Function) (err error) {
 // 	 path: /etc/secret-volume
 // - configMap: example-configMap              	# mount ConfigMap as Volume
 // 	 path: /etc/configMap-volume
+// - emptyDir: {}                             	# mount an empty directory as Volume
+// 	 path: /tmp/cache
 func validateVolumes(volumes Volumes) (errors []string) {
 
 	for i, vol := range volumes {
+		sources := 0
+		for _, set := range []bool{vol.Secret != nil, vol.ConfigMap != nil, vol.EmptyDir != nil} {
+			if set {
+				sources++
+			}
+		}
 		if vol.Secret != nil && vol.ConfigMap != nil {
 			errors = append(errors, fmt.Sprintf("volume entry #%d is not properly set, both secret '%s' and configMap '%s' can not be set at the same time",
 				i, *vol.Secret, *vol.ConfigMap))
-		} else if vol.Path == nil && vol.Secret == nil && vol.ConfigMap == nil {
+		} else if sources > 1 {
+			errors = append(errors, fmt.Sprintf("volume entry #%d is not properly set, only one of secret, configMap and emptyDir can be set", i))
+		} else if vol.Path == nil && sources == 0 {
 			errors = append(errors, fmt.Sprintf("volume entry #%d is not properly set", i))
 		} else if vol.Path == nil {
 			if vol.Secret != nil {
 				errors = append(errors, fmt.Sprintf("volume entry #%d is missing path field, only secret '%s' is set", i, *vol.Secret))
 			} else if vol.ConfigMap != nil {
 				errors = append(errors, fmt.Sprintf("volume entry #%d is missing path field, only configMap '%s' is set", i, *vol.ConfigMap))
+			} else {
+				errors = append(errors, fmt.Sprintf("volume entry #%d is missing path field, only emptyDir is set", i))
+			}
+		} else if sources == 0 {
+			errors = append(errors, fmt.Sprintf("volume entry #%d is missing secret, configMap or emptyDir field, only path '%s' is set", i, *vol.Path))
+		}
+
+		if vol.EmptyDir != nil {
+			if vol.EmptyDir.Medium != "" && vol.EmptyDir.Medium != "Memory" {
+				errors = append(errors, fmt.Sprintf("volume entry #%d has invalid emptyDir medium '%s', must be empty or Memory", i, vol.EmptyDir.Medium))
+			}
+			if vol.EmptyDir.SizeLimit != nil {
+				if _, err := resource.ParseQuantity(*vol.EmptyDir.SizeLimit); err != nil {
+					errors = append(errors, fmt.Sprintf("volume entry #%d has invalid emptyDir sizeLimit '%s', must be a quantity such as 64Mi", i, *vol.EmptyDir.SizeLimit))
+				}
 			}
-		} else if vol.Path != nil && vol.Secret == nil && vol.ConfigMap == nil {
-			errors = append(errors, fmt.Sprintf("volume entry #%d is missing secret or configMap field, only path '%s' is set", i, *vol.Path))
 		}
 	}
 
@@ -332,6 +632,36 @@ func ValidateEnvs(envs Envs) (errors []string) {
 	return
 }
 
+// ValidateBuildEnvs checks that input BuildEnvs are correct and contain all necessary fields.
+// Returns array of error messages, empty if no errors are found
+//
+// Allowed settings:
+// - name: EXAMPLE1                            # ENV directly from a value
+//   value: value1
+// - name: EXAMPLE2                            # ENV from the local ENV var
+//   value: {{ env:MY_ENV }}
+func ValidateBuildEnvs(envs Envs) (errors []string) {
+
+	for i, env := range envs {
+		if env.Name == nil || env.Value == nil {
+			errors = append(errors, fmt.Sprintf("buildEnv entry #%d is not properly set, both name and value are required", i))
+			continue
+		}
+
+		if err := utils.ValidateEnvVarName(*env.Name); err != nil {
+			errors = append(errors, fmt.Sprintf("buildEnv entry #%d has invalid name set: %q; %s", i, *env.Name, err.Error()))
+		}
+
+		if strings.HasPrefix(*env.Value, "{{") && !regLocalEnv.MatchString(*env.Value) {
+			errors = append(errors,
+				fmt.Sprintf("buildEnv entry #%d with name '%s' has invalid value field set, it has '%s', but allowed is only '{{ env:MY_ENV }}'",
+					i, *env.Name, *env.Value))
+		}
+	}
+
+	return
+}
+
 // validateOptions checks that input Options are correctly set.
 // Returns array of error messages, empty if no errors are found
 func validateOptions(options Options) (errors []string) {
@@ -379,6 +709,25 @@ func validateOptions(options Options) (errors []string) {
 						*options.Scale.Utilization))
 			}
 		}
+
+		durations := []struct {
+			field    string
+			value    *string
+			validate func(string) error
+		}{
+			{"window", options.Scale.Window, ValidateScaleWindow},
+			{"scaleDownDelay", options.Scale.ScaleDownDelay, ValidateScaleDownDelay},
+			{"retentionPeriod", options.Scale.RetentionPeriod, ValidateRetentionPeriod},
+		}
+		for _, d := range durations {
+			if d.value == nil {
+				continue
+			}
+			if err := d.validate(*d.value); err != nil {
+				errors = append(errors, fmt.Sprintf("options field \"scale.%s\" has invalid value set: \"%s\"; %v",
+					d.field, *d.value, err))
+			}
+		}
 	}
 
 	// options.resource
@@ -402,6 +751,22 @@ func validateOptions(options Options) (errors []string) {
 						*options.Resources.Requests.Memory, err.Error()))
 				}
 			}
+
+			for _, name := range extendedNames(options.Resources.Requests.Extended) {
+				quantity := options.Resources.Requests.Extended[name]
+				if err := ValidateExtendedResource(name, quantity); err != nil {
+					errors = append(errors, fmt.Sprintf("options field \"resources.requests\" has an invalid resource: %v", err))
+					continue
+				}
+				var limit string
+				if options.Resources.Limits != nil {
+					limit = options.Resources.Limits.Extended[name]
+				}
+				if !equalQuantities(quantity, limit) {
+					errors = append(errors, fmt.Sprintf("options field \"resources.requests.%s\" has value set to \"%s\", but extended resources are not overcommitted: it must equal its limit",
+						name, quantity))
+				}
+			}
 		}
 
 		// options.resource.limits
@@ -429,8 +794,527 @@ func validateOptions(options Options) (errors []string) {
 						*options.Resources.Limits.Concurrency))
 				}
 			}
+
+			for _, name := range extendedNames(options.Resources.Limits.Extended) {
+				if err := ValidateExtendedResource(name, options.Resources.Limits.Extended[name]); err != nil {
+					errors = append(errors, fmt.Sprintf("options field \"resources.limits\" has an invalid resource: %v", err))
+				}
+			}
+		}
+	}
+
+	// options.requestTimeout
+	if options.RequestTimeout != nil {
+		if err := ValidateRequestTimeout(*options.RequestTimeout); err != nil {
+			errors = append(errors, fmt.Sprintf("options field \"requestTimeout\" has value set to \"%d\", but %v",
+				*options.RequestTimeout, err))
+		}
+	}
+
+	return
+}
+
+// equalQuantities returns whether both are quantities, and equal.
+func equalQuantities(a, b string) bool {
+	qa, err := resource.ParseQuantity(a)
+	if err != nil {
+		return false
+	}
+	qb, err := resource.ParseQuantity(b)
+	if err != nil {
+		return false
+	}
+	return qa.Cmp(qb) == 0
+}
+
+// validateHealth checks that the health probes are correctly set.
+// Returns array of error messages, empty if no errors are found
+func validateHealth(health Health) (errors []string) {
+	probes := []struct {
+		name  string
+		probe *Probe
+	}{
+		{"liveness", health.Liveness},
+		{"readiness", health.Readiness},
+	}
+	for _, p := range probes {
+		if p.probe == nil {
+			continue
+		}
+		if p.probe.Path != nil {
+			if err := ValidateProbePath(*p.probe.Path); err != nil {
+				errors = append(errors, fmt.Sprintf("health field \"%s.path\" has invalid value set: \"%s\"; %v", p.name, *p.probe.Path, err))
+			}
+		}
+		if p.probe.InitialDelaySeconds != nil && *p.probe.InitialDelaySeconds < 0 {
+			errors = append(errors, fmt.Sprintf("health field \"%s.initialDelaySeconds\" has value set to \"%d\", but it must not be less than 0",
+				p.name, *p.probe.InitialDelaySeconds))
+		}
+		if p.probe.PeriodSeconds != nil && *p.probe.PeriodSeconds < 1 {
+			errors = append(errors, fmt.Sprintf("health field \"%s.periodSeconds\" has value set to \"%d\", but it must not be less than 1",
+				p.name, *p.probe.PeriodSeconds))
+		}
+	}
+	return
+}
+
+// Platforms for which Functions may be built, in the form os/architecture.
+var Platforms = []string{"linux/amd64", "linux/arm64"}
+
+// ValidatePlatform ensures the platform, if any, is one of Platforms.
+func ValidatePlatform(platform string) error {
+	if platform == "" {
+		return nil
+	}
+	for _, p := range Platforms {
+		if p == platform {
+			return nil
+		}
+	}
+	return fmt.Errorf("the platform must be one of %v", strings.Join(Platforms, ", "))
+}
+
+// ImagePullPolicies of the container of a deployed Function.
+var ImagePullPolicies = []string{"Always", "IfNotPresent", "Never"}
+
+// ValidateImagePullPolicy ensures the image pull policy, if any, is one of
+// ImagePullPolicies.
+func ValidateImagePullPolicy(policy string) error {
+	if policy == "" {
+		return nil
+	}
+	for _, p := range ImagePullPolicies {
+		if p == policy {
+			return nil
+		}
+	}
+	return fmt.Errorf("the image pull policy must be one of %v", strings.Join(ImagePullPolicies, ", "))
+}
+
+// Meshes of which a deployed Function can be made a part, by the injection of
+// their sidecar into its pods.
+var Meshes = []string{"istio", "linkerd"}
+
+// MeshAnnotations of the pods of a Function of the given mesh, by which the
+// mesh's sidecar is injected.  None for no mesh.
+func MeshAnnotations(mesh string) map[string]string {
+	switch mesh {
+	case "istio":
+		return map[string]string{"sidecar.istio.io/inject": "true"}
+	case "linkerd":
+		return map[string]string{"linkerd.io/inject": "enabled"}
+	}
+	return nil
+}
+
+// ValidateMesh ensures the mesh, if any, is one of Meshes.
+func ValidateMesh(mesh string) error {
+	if mesh == "" {
+		return nil
+	}
+	for _, m := range Meshes {
+		if m == mesh {
+			return nil
+		}
+	}
+	return fmt.Errorf("the mesh must be one of %v", strings.Join(Meshes, ", "))
+}
+
+// knativeReservedPorts are those of the queue-proxy sidecar of Knative in the
+// pods of a Function, which its container can not listen on.
+var knativeReservedPorts = []int{8012, 8013, 8022, 9090, 9091}
+
+// ValidatePort ensures the port on which a Function listens, if any, is
+// between 1 and 65535 and not reserved by Knative.
+func ValidatePort(port int) error {
+	if port == 0 {
+		return nil
+	}
+	if port < 1 || port > 65535 {
+		return errors.New("the port must be between 1 and 65535")
+	}
+	for _, p := range knativeReservedPorts {
+		if p == port {
+			return fmt.Errorf("the port %d is reserved by Knative", port)
+		}
+	}
+	return nil
+}
+
+// DefaultMetricsPath is the path at which the metrics of a Function are
+// scraped if it declares none, that conventional of Prometheus.
+const DefaultMetricsPath = "/metrics"
+
+// MetricsAnnotations of the pods of a Function exposing the given metrics
+// endpoint, by which Prometheus is configured to scrape it.  None if no port
+// is set.
+func MetricsAnnotations(metrics Metrics) map[string]string {
+	if metrics.Port == 0 {
+		return nil
+	}
+	path := metrics.Path
+	if path == "" {
+		path = DefaultMetricsPath
+	}
+	return map[string]string{
+		"prometheus.io/scrape": "true",
+		"prometheus.io/port":   strconv.Itoa(metrics.Port),
+		"prometheus.io/path":   path,
+	}
+}
+
+// ValidateMetrics ensures the metrics endpoint, if any, is of a port as is
+// ValidatePort and an absolute path, and that a path is not set without its
+// port.
+func ValidateMetrics(metrics Metrics) error {
+	if metrics.Port == 0 {
+		if metrics.Path != "" {
+			return errors.New("the metrics port must be set with its path")
+		}
+		return nil
+	}
+	if err := ValidatePort(metrics.Port); err != nil {
+		return err
+	}
+	if metrics.Path != "" {
+		return ValidateProbePath(metrics.Path)
+	}
+	return nil
+}
+
+// Environment variables of the container of a Function configuring its
+// tracing by the OpenTelemetry SDKs.
+const (
+	TracingEndpointEnv    = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	TracingServiceNameEnv = "OTEL_SERVICE_NAME"
+)
+
+// TracingEnvs of the container of the named Function configuring the given
+// tracing, its service name defaulting to the name of the Function.  None if
+// no endpoint is set.
+func TracingEnvs(name string, tracing Tracing) Envs {
+	if tracing.Endpoint == "" {
+		return nil
+	}
+	serviceName := tracing.ServiceName
+	if serviceName == "" {
+		serviceName = name
+	}
+	endpointEnv, serviceNameEnv := TracingEndpointEnv, TracingServiceNameEnv
+	return Envs{
+		{Name: &endpointEnv, Value: &tracing.Endpoint},
+		{Name: &serviceNameEnv, Value: &serviceName},
+	}
+}
+
+// ValidateTracing ensures the tracing endpoint, if any, is an absolute http or
+// https URL, and that a service name is not set without its endpoint.
+func ValidateTracing(tracing Tracing) error {
+	if tracing.Endpoint == "" {
+		if tracing.ServiceName != "" {
+			return errors.New("the tracing endpoint must be set with its service name")
 		}
+		return nil
+	}
+	u, err := url.Parse(tracing.Endpoint)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("the tracing endpoint must be an http or https URL, such as http://otel-collector:4317")
+	}
+	return nil
+}
+
+// imageSuffixPattern of the suffixes of the names of Functions in their image
+// names: a separator followed by lowercase alphanumerics, such as "-fn".
+var imageSuffixPattern = regexp.MustCompile(`^[._-][a-z0-9]+([._-][a-z0-9]+)*$`)
+
+// ValidateImageSuffix ensures the image suffix, if any, is a separator
+// followed by lowercase alphanumerics, such as "-fn", such that the image name
+// derived with it remains valid.
+func ValidateImageSuffix(suffix string) error {
+	if suffix != "" && !imageSuffixPattern.MatchString(suffix) {
+		return errors.New("the image suffix must be a separator ('-', '.' or '_') followed by lowercase letters and digits, such as -fn")
+	}
+	return nil
+}
+
+// ValidateImageReference ensures the image is a valid reference, such as
+// "quay.io/alice/migrate:v1" or one by digest.
+func ValidateImageReference(image string) error {
+	if image == "" {
+		return errors.New("the image must be set")
 	}
+	if _, err := name.ParseReference(image); err != nil {
+		return err
+	}
+	return nil
+}
 
+// ValidateInitContainers checks that each init container has a unique name
+// which is a DNS-1123 label, a valid image reference and valid envs.
+// Returns array of error messages, empty if no errors are found
+func ValidateInitContainers(containers []InitContainer) (errors []string) {
+	names := map[string]bool{}
+	for i, c := range containers {
+		if errs := validation.IsDNS1123Label(c.Name); len(errs) > 0 {
+			errors = append(errors, fmt.Sprintf("init container #%d has invalid name set: %q; %v", i, c.Name, strings.Join(errs, "; ")))
+		} else if names[c.Name] {
+			errors = append(errors, fmt.Sprintf("init container #%d has the name %q of another", i, c.Name))
+		}
+		names[c.Name] = true
+		if err := ValidateImageReference(c.Image); err != nil {
+			errors = append(errors, fmt.Sprintf("init container %q has invalid image set: %q; %v", c.Name, c.Image, err))
+		}
+		for _, e := range ValidateEnvs(c.Envs) {
+			errors = append(errors, fmt.Sprintf("init container %q %v", c.Name, e))
+		}
+	}
 	return
 }
+
+// BuilderPullPolicies with which the builder image is pulled before building:
+// always, re-pulling it such that the latest is used; if-not-present, pulling
+// it only if not already present; or never, such as for air-gapped
+// environments with the builder preloaded.
+var BuilderPullPolicies = []string{"always", "if-not-present", "never"}
+
+// DefaultBuilderPullPolicy is that of Functions which declare none.
+const DefaultBuilderPullPolicy = "if-not-present"
+
+// ValidateBuilderPullPolicy ensures the builder pull policy, if any, is one
+// of BuilderPullPolicies.
+func ValidateBuilderPullPolicy(policy string) error {
+	if policy == "" {
+		return nil
+	}
+	for _, p := range BuilderPullPolicies {
+		if p == policy {
+			return nil
+		}
+	}
+	return fmt.Errorf("the builder pull policy must be one of %v", strings.Join(BuilderPullPolicies, ", "))
+}
+
+// platformAPIRegex matches a version of the buildpacks platform API, such as
+// "0.4" or "0.10".
+var platformAPIRegex = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+
+// ValidatePlatformAPI ensures the platform API, if any, is a version of the
+// buildpacks platform API of the form <major>.<minor>.
+func ValidatePlatformAPI(api string) error {
+	if api != "" && !platformAPIRegex.MatchString(api) {
+		return errors.New("the platform API must be a version of the form <major>.<minor>, such as 0.4")
+	}
+	return nil
+}
+
+// buildpackIDRegex matches the ID of a buildpack of the buildpack registry,
+// optionally of a version, such as "paketo-buildpacks/datadog@1.2.0".
+var buildpackIDRegex = regexp.MustCompile(`^[a-z0-9\-.]+/[a-z0-9\-.]+(@[0-9]+\.[0-9]+\.[0-9]+[0-9A-Za-z.+-]*)?$`)
+
+// ValidateBuildpack ensures the reference of a buildpack added to a build is
+// one of those pack accepts: an image, such as
+// gcr.io/paketo-buildpacks/datadog:1.2.0 or docker://..., an http(s) URI of a
+// buildpack archive, or an ID of the buildpack registry, as urn:cnb:registry:
+// or urn:cnb:builder: followed by <namespace>/<name>[@<version>].
+func ValidateBuildpack(ref string) error {
+	switch {
+	case ref == "":
+		return errors.New("the buildpack must be set")
+	case strings.HasPrefix(ref, "docker://"):
+		if _, err := name.ParseReference(strings.TrimPrefix(ref, "docker://")); err != nil {
+			return fmt.Errorf("the buildpack image is not valid: %v", err)
+		}
+	case strings.HasPrefix(ref, "http://"), strings.HasPrefix(ref, "https://"):
+		if u, err := url.Parse(ref); err != nil || u.Host == "" {
+			return errors.New("the buildpack URI must be an http(s) URL of a buildpack archive")
+		}
+	case strings.HasPrefix(ref, "urn:cnb:registry:"), strings.HasPrefix(ref, "urn:cnb:builder:"):
+		id := strings.TrimPrefix(strings.TrimPrefix(ref, "urn:cnb:registry:"), "urn:cnb:builder:")
+		if !buildpackIDRegex.MatchString(id) {
+			return errors.New("the buildpack ID must be of the form <namespace>/<name>[@<version>]")
+		}
+	default:
+		if _, err := name.ParseReference(ref); err != nil {
+			return fmt.Errorf("the buildpack must be an image, an http(s) URI or a urn:cnb:registry: ID: %v", err)
+		}
+	}
+	return nil
+}
+
+// MaxRequestTimeout is the maximum request timeout of a Function, in seconds:
+// that of Knative by default (its max-revision-timeout-seconds).
+const MaxRequestTimeout = 600
+
+// ValidateRequestTimeout ensures the request timeout, in seconds, is positive
+// and not more than MaxRequestTimeout.
+func ValidateRequestTimeout(seconds int64) error {
+	if seconds < 1 || seconds > MaxRequestTimeout {
+		return fmt.Errorf("it must be between 1 and %d seconds", MaxRequestTimeout)
+	}
+	return nil
+}
+
+// Bounds of the durations of the autoscaling of a Function, as are accepted
+// by Knative: the window is of at least MinScaleWindow, and none is more than
+// MaxScaleWindow.
+const (
+	MinScaleWindow = 6 * time.Second
+	MaxScaleWindow = time.Hour
+)
+
+// ValidateScaleWindow ensures the window over which the metric of autoscaling
+// is averaged is a duration of whole seconds, such as "60s", between
+// MinScaleWindow and MaxScaleWindow.
+func ValidateScaleWindow(window string) error {
+	return validateScaleDuration(window, MinScaleWindow, true)
+}
+
+// ValidateScaleDownDelay ensures the delay before scaling down is a duration
+// of whole seconds, such as "15m", of not more than MaxScaleWindow.
+func ValidateScaleDownDelay(delay string) error {
+	return validateScaleDuration(delay, 0, true)
+}
+
+// ValidateRetentionPeriod ensures the period for which the last instance is
+// retained when scaling to zero is a duration, such as "5m", of not more than
+// MaxScaleWindow.
+func ValidateRetentionPeriod(period string) error {
+	return validateScaleDuration(period, 0, false)
+}
+
+// validateScaleDuration ensures the value is a duration between min and
+// MaxScaleWindow, of whole seconds if seconds.
+func validateScaleDuration(value string, min time.Duration, seconds bool) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("it must be a duration, such as 60s or 5m: %v", err)
+	}
+	if d < min || d > MaxScaleWindow {
+		return fmt.Errorf("it must be between %v and %v", min, MaxScaleWindow)
+	}
+	if seconds && d.Truncate(time.Second) != d {
+		return errors.New("it must be of whole seconds")
+	}
+	return nil
+}
+
+// digestRegex matches the digest of an image, such as "sha256:a278a9...".
+var digestRegex = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// ValidateDigest ensures the digest, if any, is that of an image in the form
+// sha256:[64 hex characters].
+func ValidateDigest(digest string) error {
+	if digest != "" && !digestRegex.MatchString(digest) {
+		return errors.New("the digest must be of the form sha256:<64 hexadecimal characters>")
+	}
+	return nil
+}
+
+// runtimeVersionRegex matches the version of a runtime, such as "18", "1.19"
+// or "3.10.*", as are understood by the buildpacks of runtimes.
+var runtimeVersionRegex = regexp.MustCompile(`^[0-9][0-9A-Za-z.*+-]*$`)
+
+// ValidateRuntimeVersion ensures the runtime version, if any, is a version
+// number such as "18" or "1.19", optionally with wildcards such as "18.*".
+func ValidateRuntimeVersion(version string) error {
+	if version != "" && !runtimeVersionRegex.MatchString(version) {
+		return errors.New("the runtime version must be a version number, such as 18 or 1.19")
+	}
+	return nil
+}
+
+// ValidateDomain ensures the domain, if any, is a valid DNS subdomain, such
+// as may name a Knative DomainMapping.
+func ValidateDomain(domain string) error {
+	if domain == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(domain); len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// IngressClasses of the ingresses commonly installed with Knative, as are
+// suggested to users.  Others may be installed.
+var IngressClasses = []string{
+	"contour.ingress.networking.knative.dev",
+	"istio.ingress.networking.knative.dev",
+	"kourier.ingress.networking.knative.dev",
+}
+
+// ValidateIngressClass ensures the ingress class, if any, is of the form of
+// the classes of Knative ingresses, a DNS subdomain such as
+// "kourier.ingress.networking.knative.dev".  Whether it is installed is not
+// known, Knative not exposing the classes of a cluster.
+func ValidateIngressClass(class string) error {
+	if class == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Subdomain(class); len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// revisionNameContext is that with which templates of revision names are
+// rendered by Knative's client, with a sample Service name and generation.
+type revisionNameContext struct {
+	Service    string
+	Generation int64
+}
+
+// Random returns l characters, as would be chosen at random.
+func (c revisionNameContext) Random(l int) string {
+	return strings.Repeat("x", l)
+}
+
+// ValidateRevisionName ensures the template of revision names, if any, is
+// valid and renders a DNS-compatible name (a DNS-1123 label), such as
+// "{{.Service}}-v{{.Generation}}".  The name of the Service, and "-", is
+// prefixed to names which do not already begin with it.
+func ValidateRevisionName(tmpl string) error {
+	if tmpl == "" {
+		return nil
+	}
+	t, err := template.New("revisionName").Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	ctx := revisionNameContext{Service: "function", Generation: 1}
+	var b strings.Builder
+	if err = t.Execute(&b, ctx); err != nil {
+		return err
+	}
+	name := b.String()
+	if !strings.HasPrefix(name, ctx.Service+"-") {
+		name = ctx.Service + "-" + name
+	}
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fmt.Errorf("renders the invalid revision name %q: %v", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ValidateTrafficTag ensures the traffic tag, if any, is a DNS-1123 label,
+// such as may prefix the host of the Service at which it is reachable.
+func ValidateTrafficTag(tag string) error {
+	if tag == "" {
+		return nil
+	}
+	if errs := validation.IsDNS1123Label(tag); len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ValidateProbePath ensures the path of a health probe is absolute.
+func ValidateProbePath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return errors.New("the path must start with \"/\"")
+	}
+	return nil
+}
@@ -132,6 +132,37 @@ func Test_validateVolumes(t *testing.T) {
 			},
 			2,
 		},
+		{
+			"correct entry - single volume with emptyDir",
+			Volumes{
+				Volume{
+					EmptyDir: &EmptyDir{Medium: "Memory", SizeLimit: ptr.String("64Mi")},
+					Path:     &path,
+				},
+			},
+			0,
+		},
+		{
+			"incorrect entry - single volume with both secret and emptyDir",
+			Volumes{
+				Volume{
+					Secret:   &secret,
+					EmptyDir: &EmptyDir{},
+					Path:     &path,
+				},
+			},
+			1,
+		},
+		{
+			"incorrect entry - emptyDir with invalid medium and size limit",
+			Volumes{
+				Volume{
+					EmptyDir: &EmptyDir{Medium: "Disk", SizeLimit: ptr.String("a lot")},
+					Path:     &path,
+				},
+			},
+			2,
+		},
 	}
 
 	for _, tt := range tests {
@@ -144,6 +175,40 @@ func Test_validateVolumes(t *testing.T) {
 
 }
 
+func TestParseVolume(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string // the volume's String, empty if invalid
+	}{
+		{"secret:my-secret:/etc/config", `Secret "my-secret" mounted at path: "/etc/config"`},
+		{"configMap:my-config:/etc/config", `ConfigMap "my-config" mounted at path: "/etc/config"`},
+		{"configmap:my-config:/etc/config", `ConfigMap "my-config" mounted at path: "/etc/config"`},
+		{"emptyDir:/tmp/cache", `EmptyDir mounted at path: "/tmp/cache"`},
+		{"secret:/etc/config", ""},
+		{"secret::/etc/config", ""},
+		{"secret:my-secret:etc/config", ""},
+		{"emptyDir:cache:/tmp/cache", ""},
+		{"pvc:my-claim:/data", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			v, err := ParseVolume(tt.spec)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.String() != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, v)
+			}
+		})
+	}
+}
+
 func Test_validateEnvs(t *testing.T) {
 
 	name := "name"
@@ -666,6 +731,37 @@ func Test_validateOptions(t *testing.T) {
 			},
 			1,
 		},
+		{
+			"correct 'scale' durations",
+			Options{
+				Scale: &ScaleOptions{
+					Window:          ptr.String("60s"),
+					ScaleDownDelay:  ptr.String("15m"),
+					RetentionPeriod: ptr.String("0s"),
+				},
+			},
+			0,
+		},
+		{
+			"incorrect 'scale.window' - < 6s, and not a duration",
+			Options{
+				Scale: &ScaleOptions{
+					Window:          ptr.String("5s"),
+					ScaleDownDelay:  ptr.String("15"),
+					RetentionPeriod: ptr.String("2h"),
+				},
+			},
+			3,
+		},
+		{
+			"incorrect 'scale.scaleDownDelay' - fractional seconds",
+			Options{
+				Scale: &ScaleOptions{
+					ScaleDownDelay: ptr.String("1.5s"),
+				},
+			},
+			1,
+		},
 		{
 			"correct 'resources.requests.cpu'",
 			Options{
@@ -835,6 +931,74 @@ func Test_validateOptions(t *testing.T) {
 			},
 			10,
 		},
+		{
+			"correct 'requestTimeout'",
+			Options{
+				RequestTimeout: ptr.Int64(600),
+			},
+			0,
+		},
+		{
+			"incorrect 'requestTimeout' - not positive",
+			Options{
+				RequestTimeout: ptr.Int64(0),
+			},
+			1,
+		},
+		{
+			"incorrect 'requestTimeout' - above the maximum",
+			Options{
+				RequestTimeout: ptr.Int64(601),
+			},
+			1,
+		},
+		{
+			"correct extended resource - limit alone",
+			Options{
+				Resources: &ResourcesOptions{
+					Limits: &ResourcesLimitsOptions{
+						Extended: map[string]string{"nvidia.com/gpu": "1"},
+					},
+				},
+			},
+			0,
+		},
+		{
+			"correct extended resource - request equal to limit",
+			Options{
+				Resources: &ResourcesOptions{
+					Requests: &ResourcesRequestsOptions{
+						Extended: map[string]string{"nvidia.com/gpu": "2"},
+					},
+					Limits: &ResourcesLimitsOptions{
+						Extended: map[string]string{"nvidia.com/gpu": "2"},
+					},
+				},
+			},
+			0,
+		},
+		{
+			"incorrect extended resource - request without limit",
+			Options{
+				Resources: &ResourcesOptions{
+					Requests: &ResourcesRequestsOptions{
+						Extended: map[string]string{"nvidia.com/gpu": "1"},
+					},
+				},
+			},
+			1,
+		},
+		{
+			"incorrect extended resources - unqualified and fractional",
+			Options{
+				Resources: &ResourcesOptions{
+					Limits: &ResourcesLimitsOptions{
+						Extended: map[string]string{"gpu": "1", "nvidia.com/gpu": "500m"},
+					},
+				},
+			},
+			2,
+		},
 	}
 
 	for _, tt := range tests {
@@ -846,3 +1010,499 @@ func Test_validateOptions(t *testing.T) {
 	}
 
 }
+
+func Test_validateHealth(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		health Health
+		errs   int
+	}{
+		{
+			"unset",
+			Health{},
+			0,
+		},
+		{
+			"correct probes",
+			Health{
+				Liveness: &Probe{
+					Path:                ptr.String("/healthz"),
+					InitialDelaySeconds: ptr.Int32(0),
+					PeriodSeconds:       ptr.Int32(5),
+				},
+				Readiness: &Probe{
+					Path: ptr.String("/ready"),
+				},
+			},
+			0,
+		},
+		{
+			"incorrect 'liveness.path'",
+			Health{
+				Liveness: &Probe{
+					Path: ptr.String("healthz"),
+				},
+			},
+			1,
+		},
+		{
+			"incorrect probes",
+			Health{
+				Liveness: &Probe{
+					InitialDelaySeconds: ptr.Int32(-1),
+					PeriodSeconds:       ptr.Int32(0),
+				},
+				Readiness: &Probe{
+					Path:          ptr.String("ready"),
+					PeriodSeconds: ptr.Int32(-5),
+				},
+			},
+			4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateHealth(tt.health); len(got) != tt.errs {
+				t.Errorf("validateHealth() = %v\n got %d errors but want %d", got, len(got), tt.errs)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateBuildEnvs(t *testing.T) {
+
+	tests := []struct {
+		name string
+		envs Envs
+		errs int
+	}{
+		{
+			"correct entries - value and local env",
+			Envs{
+				Env{Name: ptr.String("BP_GO_VERSION"), Value: ptr.String("1.16")},
+				Env{Name: ptr.String("GOPROXY"), Value: ptr.String("{{ env:GOPROXY }}")},
+			},
+			0,
+		},
+		{
+			"incorrect entry - missing value",
+			Envs{
+				Env{Name: ptr.String("BP_GO_VERSION")},
+			},
+			1,
+		},
+		{
+			"incorrect entry - invalid name",
+			Envs{
+				Env{Name: ptr.String(",foo"), Value: ptr.String("value")},
+			},
+			1,
+		},
+		{
+			"incorrect entry - secrets are not allowed",
+			Envs{
+				Env{Name: ptr.String("TOKEN"), Value: ptr.String("{{ secret:mysecret:token }}")},
+			},
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateBuildEnvs(tt.envs); len(got) != tt.errs {
+				t.Errorf("ValidateBuildEnvs() = %v\n got %d errors but want %d", got, len(got), tt.errs)
+			}
+		})
+	}
+
+}
+
+func Test_ValidatePlatform(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		platform string
+		wantErr  bool
+	}{
+		{"unset", "", false},
+		{"amd64", "linux/amd64", false},
+		{"arm64", "linux/arm64", false},
+		{"unknown architecture", "linux/s390x", true},
+		{"unknown os", "windows/amd64", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePlatform(tt.platform); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePlatform() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateDomain(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		domain  string
+		wantErr bool
+	}{
+		{"unset", "", false},
+		{"subdomain", "myfunc.example.com", false},
+		{"uppercase", "MyFunc.example.com", true},
+		{"scheme", "https://myfunc.example.com", true},
+		{"path", "example.com/myfunc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateDomain(tt.domain); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDomain() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateRevisionName(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{"unset", "", false},
+		{"generation", "{{.Service}}-v{{.Generation}}", false},
+		{"random", "{{.Random 5}}", false},
+		{"literal", "blue", false},
+		{"underscore", "{{.Service}}_v{{.Generation}}", true},
+		{"uppercase", "Blue", true},
+		{"unknown field", "{{.Revision}}", true},
+		{"unterminated", "{{.Service", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRevisionName(tt.template); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRevisionName() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateTrafficTag(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		tag     string
+		wantErr bool
+	}{
+		{"unset", "", false},
+		{"label", "green", false},
+		{"dotted", "green.v1", true},
+		{"uppercase", "Green", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTrafficTag(tt.tag); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTrafficTag() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateImagePullPolicy(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{
+		{"unset", "", false},
+		{"never", "Never", false},
+		{"if not present", "IfNotPresent", false},
+		{"always", "Always", false},
+		{"lowercase", "never", true},
+		{"unknown", "Sometimes", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateImagePullPolicy(tt.policy); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateImagePullPolicy() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateIngressClass(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		class   string
+		wantErr bool
+	}{
+		{"unset", "", false},
+		{"kourier", "kourier.ingress.networking.knative.dev", false},
+		{"uppercase", "Kourier.ingress.networking.knative.dev", true},
+		{"spaces", "kourier ingress", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateIngressClass(tt.class); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIngressClass() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateMesh(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		mesh    string
+		wantErr bool
+	}{
+		{"unset", "", false},
+		{"istio", "istio", false},
+		{"linkerd", "linkerd", false},
+		{"uppercase", "Istio", true},
+		{"unknown", "consul", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateMesh(tt.mesh); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMesh() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateMetrics(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		metrics Metrics
+		wantErr bool
+	}{
+		{"unset", Metrics{}, false},
+		{"port", Metrics{Port: 9095}, false},
+		{"port and path", Metrics{Port: 9095, Path: "/q/metrics"}, false},
+		{"path without port", Metrics{Path: "/metrics"}, true},
+		{"relative path", Metrics{Port: 9095, Path: "metrics"}, true},
+		{"out of range", Metrics{Port: 70000}, true},
+		{"reserved by Knative", Metrics{Port: 9091}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateMetrics(tt.metrics); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMetrics() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateImageSuffix(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		suffix  string
+		wantErr bool
+	}{
+		{"unset", "", false},
+		{"dash", "-fn", false},
+		{"dot", ".svc", false},
+		{"several", "-fn-v2", false},
+		{"no separator", "fn", true},
+		{"uppercase", "-Fn", true},
+		{"trailing separator", "-fn-", true},
+		{"slash", "/fn", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateImageSuffix(tt.suffix); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateImageSuffix() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateTracing(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		tracing Tracing
+		wantErr bool
+	}{
+		{"unset", Tracing{}, false},
+		{"endpoint", Tracing{Endpoint: "http://otel-collector.observability:4317"}, false},
+		{"endpoint and service name", Tracing{Endpoint: "https://otel.example.com", ServiceName: "orders"}, false},
+		{"service name without endpoint", Tracing{ServiceName: "orders"}, true},
+		{"no scheme", Tracing{Endpoint: "otel-collector:4317"}, true},
+		{"grpc scheme", Tracing{Endpoint: "grpc://otel-collector:4317"}, true},
+		{"no host", Tracing{Endpoint: "http://"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTracing(tt.tracing); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTracing() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateInvocation(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		runtime    string
+		invocation Invocation
+		wantErr    bool
+	}{
+		{"unset", "go", Invocation{}, false},
+		{"http", "go", Invocation{Signature: "http"}, false},
+		{"events", "node", Invocation{Signature: "events"}, false},
+		{"unknown signature", "go", Invocation{Signature: "grpc"}, true},
+		{"not of the runtime", "test", Invocation{Signature: "events"}, true},
+		{"runtime without templates", "custom", Invocation{Signature: "events"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateInvocation(tt.runtime, tt.invocation); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateInvocation() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateInitContainers(t *testing.T) {
+
+	name := "DB_URL"
+	value := "postgres://db"
+	tests := []struct {
+		name       string
+		containers []InitContainer
+		errs       int
+	}{
+		{"none", nil, 0},
+		{"image", []InitContainer{{Name: "migrate", Image: "example.com/alice/migrate:v1"}}, 0},
+		{"command and envs", []InitContainer{{Name: "migrate", Image: "migrate", Command: []string{"migrate", "up"}, Envs: Envs{{Name: &name, Value: &value}}}}, 0},
+		{"invalid name", []InitContainer{{Name: "Migrate", Image: "migrate"}}, 1},
+		{"duplicate name", []InitContainer{{Name: "migrate", Image: "migrate"}, {Name: "migrate", Image: "seed"}}, 1},
+		{"no image", []InitContainer{{Name: "migrate"}}, 1},
+		{"invalid image", []InitContainer{{Name: "migrate", Image: "example.com/alice/Migrate:v1"}}, 1},
+		{"invalid env", []InitContainer{{Name: "migrate", Image: "migrate", Envs: Envs{{Value: &value}}}}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := ValidateInitContainers(tt.containers); len(errs) != tt.errs {
+				t.Errorf("ValidateInitContainers() = %v\n got %d errors but want %d", errs, len(errs), tt.errs)
+			}
+		})
+	}
+
+}
+
+func Test_ValidatePort(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		port    int
+		wantErr bool
+	}{
+		{"unset", 0, false},
+		{"default", 8080, false},
+		{"lowest", 1, false},
+		{"highest", 65535, false},
+		{"negative", -1, true},
+		{"too high", 65536, true},
+		{"queue-proxy", 8012, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePort(tt.port); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePort() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateExtendedResource(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		resource string
+		quantity string
+		wantErr  bool
+	}{
+		{"gpu", "nvidia.com/gpu", "1", false},
+		{"several", "amd.com/gpu", "4", false},
+		{"unqualified", "gpu", "1", true},
+		{"native", "kubernetes.io/gpu", "1", true},
+		{"invalid name", "nvidia.com/g pu", "1", true},
+		{"zero", "nvidia.com/gpu", "0", true},
+		{"fractional", "nvidia.com/gpu", "0.5", true},
+		{"not a quantity", "nvidia.com/gpu", "one", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateExtendedResource(tt.resource, tt.quantity); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateExtendedResource() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
+
+func Test_ValidateBuildpack(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		buildpack string
+		wantErr   bool
+	}{
+		{"image", "gcr.io/paketo-buildpacks/datadog:1.2.0", false},
+		{"docker image", "docker://gcr.io/paketo-buildpacks/datadog", false},
+		{"uri", "https://example.com/buildpacks/datadog.tgz", false},
+		{"registry id", "urn:cnb:registry:paketo-buildpacks/datadog@1.2.0", false},
+		{"builder id", "urn:cnb:builder:paketo-buildpacks/go", false},
+		{"unset", "", true},
+		{"invalid image", "gcr.io/Paketo/Datadog", true},
+		{"invalid docker image", "docker://gcr.io/paketo:buildpacks:datadog", true},
+		{"uri without host", "https://", true},
+		{"invalid registry id", "urn:cnb:registry:datadog", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateBuildpack(tt.buildpack); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateBuildpack() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
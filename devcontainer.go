@@ -0,0 +1,90 @@
+package function
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/markbates/pkger"
+)
+
+// ErrDevcontainerNotFound is returned when no dev container config is found
+// for the runtime of a Function.
+var ErrDevcontainerNotFound = errors.New("dev container config not found")
+
+// devcontainerDir is the hidden directory of the dev container config of
+// each runtime, laid out as [devcontainerDir]/[runtime], both of the embedded
+// templates and of a repository of templates, which provides its own in place
+// of those embedded.  The dev container config of a runtime is written to the
+// root of the Function as a template is, as the .devcontainer directory of
+// its devcontainer.json, such that its files declared by its ManifestFile
+// are rendered with the Function and the version of func as data.  See
+// devcontainerData.
+const devcontainerDir = ".devcontainer"
+
+// DevcontainerFile is the dev container config of a Function, relative to
+// its root, which when it exists is kept.
+const DevcontainerFile = ".devcontainer/devcontainer.json"
+
+// devcontainerData with which the files of a dev container config are
+// rendered: the Function, and the version of func it installs.
+type devcontainerData struct {
+	Function
+	// FuncVersion is the release of func installed, such as "v0.16.0".
+	// Empty for the latest.
+	FuncVersion string
+}
+
+// writeDevcontainer writes the dev container config of the runtime to dest:
+// that of the repository of the template, if it provides one, and otherwise
+// that embedded, rendered with the name the Function is given when loaded,
+// if it has none, and the version of func writing it.  Unless overwriting,
+// it is not written if dest has a DevcontainerFile already, and otherwise
+// its files which exist are overwritten, returning whether it was.
+func (t templateWriter) writeDevcontainer(runtime, template, dest string, overwrite bool) (written bool, err error) {
+	if _, err = os.Stat(filepath.Join(dest, DevcontainerFile)); err == nil && !overwrite {
+		return false, nil
+	} else if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	src, accessor, err := t.resolveDevcontainer(runtime, template)
+	if err != nil {
+		return
+	}
+	f := t.function
+	if f.Name == "" {
+		f.Name = derivedName(f.Root, f.ConfigFile)
+	}
+	data := devcontainerData{Function: f}
+	if t.version != "tip" {
+		data.FuncVersion = t.version
+	}
+	var onConflict ConflictResolver
+	if !overwrite {
+		onConflict = func(string, []byte, []byte) (bool, error) { return false, nil }
+	}
+	skipped, err := copy(src, dest, accessor, onConflict)
+	if err != nil {
+		return
+	}
+	return true, render(src, dest, accessor, data, skipped)
+}
+
+// resolveDevcontainer of the runtime to its path, and the accessor of its
+// files.
+func (t templateWriter) resolveDevcontainer(runtime, template string) (string, fileAccessor, error) {
+	if isCustom(template) && t.templates != "" {
+		repo := strings.Split(template, "/")[0]
+		path := filepath.Join(t.templates, repo, devcontainerDir, runtime)
+		if _, err := os.Stat(path); err == nil {
+			return path, filesystemAccessor{}, nil
+		}
+	}
+	path := filepath.Join("/templates", devcontainerDir, runtime)
+	if _, err := pkger.Stat(path); err != nil {
+		return "", nil, fmt.Errorf("%w for the runtime '%v'", ErrDevcontainerNotFound, runtime)
+	}
+	return path, embeddedAccessor{}, nil
+}
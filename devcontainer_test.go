@@ -0,0 +1,108 @@
+// +build !integration
+
+package function_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fn "github.com/boson-project/func"
+)
+
+// TestCreateDevcontainer ensures the dev container config of the runtime of
+// a Function is written when requested, rendered for it, and recorded in its
+// config, installing the func with which it was created, that one which
+// already exists is kept unless refreshed with the managed files of the
+// template, and that it is not written otherwise.
+func TestCreateDevcontainer(t *testing.T) {
+	root := "testdata/example.com/testCreateDevcontainer"
+	defer using(t, root)()
+
+	client := fn.New(fn.WithRegistry(TestRegistry), fn.WithVersion("v0.16.0"))
+	if err := client.Create(fn.Function{Root: root, Runtime: "go", Devcontainer: true}); err != nil {
+		t.Fatal(err)
+	}
+	config, err := ioutil.ReadFile(filepath.Join(root, fn.DevcontainerFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(config), `"name": "testCreateDevcontainer"`) {
+		t.Fatalf("expected the dev container to be named for the function, got:\n%s", config)
+	}
+	dockerfile, err := ioutil.ReadFile(filepath.Join(root, ".devcontainer/Dockerfile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(dockerfile), "devcontainers/go") || !strings.Contains(string(dockerfile), "/usr/local/bin/func") {
+		t.Fatalf("expected a go dev container with func installed, got:\n%s", dockerfile)
+	}
+	if !strings.Contains(string(dockerfile), "releases/download/v0.16.0/func_linux_${TARGETARCH") {
+		t.Fatalf("expected the func of the version and architecture to be installed, got:\n%s", dockerfile)
+	}
+	for _, file := range []string{".devcontainer/devcontainer.json.tmpl", fn.ManifestFile} {
+		if _, err := os.Stat(filepath.Join(root, file)); !os.IsNotExist(err) {
+			t.Fatalf("expected %v not to be written", file)
+		}
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Devcontainer {
+		t.Fatal("expected the dev container to be recorded")
+	}
+
+	// The dev container is refreshed with the managed files of the template,
+	// installing the func of the version refreshing it.
+	refreshed := "testdata/example.com/testCreateDevcontainerRefreshed"
+	defer using(t, refreshed)()
+	if err := client.Create(fn.Function{Root: refreshed, Name: "refreshed", Runtime: "go", Devcontainer: true}); err != nil {
+		t.Fatal(err)
+	}
+	refresher := fn.New(fn.WithManagedFilesOnly(true), fn.WithVersion("v0.17.0"))
+	if err := refresher.Create(fn.Function{Root: refreshed, Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	if dockerfile, err = ioutil.ReadFile(filepath.Join(refreshed, ".devcontainer/Dockerfile")); err != nil || !strings.Contains(string(dockerfile), "releases/download/v0.17.0/") {
+		t.Fatalf("expected the dev container to be refreshed, got:\n%s (%v)", dockerfile, err)
+	}
+
+	existing := "testdata/example.com/testCreateDevcontainerExisting"
+	defer using(t, existing)()
+	if err := os.MkdirAll(filepath.Join(existing, ".devcontainer"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(existing, fn.DevcontainerFile), []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Create(fn.Function{Root: existing, Runtime: "node", Devcontainer: true}); err != nil {
+		t.Fatal(err)
+	}
+	if config, err = ioutil.ReadFile(filepath.Join(existing, fn.DevcontainerFile)); err != nil || string(config) != "{}\n" {
+		t.Fatalf("expected the existing dev container config to be kept, got %s (%v)", config, err)
+	}
+	if _, err = os.Stat(filepath.Join(existing, ".devcontainer/Dockerfile")); !os.IsNotExist(err) {
+		t.Fatal("expected no Dockerfile to be written beside the existing dev container config")
+	}
+
+	plain := "testdata/example.com/testCreateDevcontainerNone"
+	defer using(t, plain)()
+	if err := client.Create(fn.Function{Root: plain, Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(plain, ".devcontainer")); !os.IsNotExist(err) {
+		t.Fatal("expected no dev container to be written unless requested")
+	}
+
+	latest := "testdata/example.com/testCreateDevcontainerLatest"
+	defer using(t, latest)()
+	if err := fn.New(fn.WithVersion("tip")).Create(fn.Function{Root: latest, Runtime: "go", Devcontainer: true}); err != nil {
+		t.Fatal(err)
+	}
+	if dockerfile, err = ioutil.ReadFile(filepath.Join(latest, ".devcontainer/Dockerfile")); err != nil || !strings.Contains(string(dockerfile), "releases/latest/download/") {
+		t.Fatalf("expected the latest func to be installed by a development build, got:\n%s (%v)", dockerfile, err)
+	}
+}
@@ -0,0 +1,344 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/daemon"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/pkg/errors"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/buildpacks"
+)
+
+// ErrDockerUnavailable is returned when building a Function from its
+// Dockerfile without a reachable docker, or podman, daemon.
+var ErrDockerUnavailable = errors.New("the docker daemon is not available. Functions with the dockerfile builder are built with docker or podman: start either, setting DOCKER_HOST to the socket of podman")
+
+// Builder of Functions from their Dockerfile, using the docker API of the
+// daemon of DOCKER_HOST, as served by both docker and podman.
+type Builder struct {
+	// Verbose logging flag, printing the output of the build.
+	Verbose bool
+}
+
+// NewBuilder creates an instance of a docker-backed Dockerfile builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Build the Function from its Dockerfile using the default build cache.
+func (b *Builder) Build(ctx context.Context, f fn.Function) error {
+	return b.BuildWithCache(ctx, f, fn.BuildCache{})
+}
+
+// BuildWithCache builds the Function from its Dockerfile, tagging the image
+// as that of the Function.  The files matched by its ignore patterns are not
+// sent to the daemon.  A disabled cache builds without cached layers; a
+// cache directory is not supported, and is ignored.
+func (b *Builder) BuildWithCache(ctx context.Context, f fn.Function, cache fn.BuildCache) error {
+	return b.BuildWithLabels(ctx, f, cache, nil)
+}
+
+// BuildWithLabels builds the Function as does BuildWithCache, labeling the
+// image with the given labels.  The image of a build given SourceDateEpochEnv,
+// as is a reproducible build, has the timestamps of its config, history and
+// files normalized to it, as the buildpacks lifecycle does, since the daemon
+// does not.
+func (b *Builder) BuildWithLabels(ctx context.Context, f fn.Function, cache fn.BuildCache, labels map[string]string) (err error) {
+	if f.Image == "" {
+		return errors.New("Function has no associated image")
+	}
+	if _, err = os.Stat(filepath.Join(f.Root, fn.Dockerfile)); err != nil {
+		return errors.Wrapf(err, "the %v builder requires a %v", fn.DockerfileBuilder, fn.Dockerfile)
+	}
+	if len(f.Build.Buildpacks) > 0 {
+		return fmt.Errorf("the buildpacks %v are not supported by the %v builder, which builds the function from its %v", strings.Join(f.Build.Buildpacks, ", "), fn.DockerfileBuilder, fn.Dockerfile)
+	}
+
+	if err = CheckAvailable(ctx); err != nil {
+		return
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return errors.Wrap(err, "failed to create docker api client")
+	}
+
+	patterns, err := f.IgnorePatterns()
+	if err != nil {
+		return
+	}
+	envs, err := buildpacks.BuildEnvs(f.BuildEnvs)
+	if err != nil {
+		return
+	}
+	args := make(map[string]*string, len(envs))
+	for name, value := range envs {
+		value := value
+		args[name] = &value
+	}
+
+	buildContext := archiveContext(f.Root, fn.NewIgnorer(patterns), f.RegistryMirrors)
+	defer buildContext.Close()
+	r, err := cli.ImageBuild(ctx, buildContext, types.ImageBuildOptions{
+		Tags:        []string{f.Image},
+		Dockerfile:  fn.Dockerfile,
+		BuildArgs:   args,
+		Labels:      labels,
+		NoCache:     cache.Disabled,
+		Remove:      true,
+		ForceRemove: true,
+		Platform:    f.Platform,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to build the image")
+	}
+	defer r.Body.Close()
+
+	// Output is kept to be printed on failure when not verbose.
+	var output bytes.Buffer
+	var out io.Writer = &output
+	if b.Verbose {
+		out = os.Stdout
+	}
+	if err = readBuildOutput(r.Body, out); err != nil {
+		if !b.Verbose {
+			return fmt.Errorf("failed to build the function: %v\noutput: %s", err, output.String())
+		}
+		return fmt.Errorf("failed to build the function: %v", err)
+	}
+	if epoch, ok := envs[fn.SourceDateEpochEnv]; ok {
+		return normalizeImage(f.Image, epoch)
+	}
+	return nil
+}
+
+// normalizeImage replaces the image in the daemon with that of its timestamps
+// normalized to the given source date epoch, in seconds since the Unix epoch.
+func normalizeImage(image, epoch string) error {
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %v '%v': must be a number of seconds since the Unix epoch", fn.SourceDateEpochEnv, epoch)
+	}
+	tag, err := name.NewTag(image)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the image name")
+	}
+	img, err := daemon.Image(tag)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the image built")
+	}
+	if img, err = normalizeTimes(img, time.Unix(seconds, 0).UTC()); err != nil {
+		return errors.Wrap(err, "failed to normalize the timestamps of the image")
+	}
+	if _, err = daemon.Write(tag, img); err != nil {
+		return errors.Wrap(err, "failed to write the normalized image")
+	}
+	return nil
+}
+
+// normalizeTimes returns the image with the time it was created, that of each
+// entry of its history and the modification times of the files of its layers
+// all the given time, such that images built of the same source at different
+// times are the same.
+func normalizeTimes(img v1.Image, t time.Time) (v1.Image, error) {
+	img, err := mutate.Time(img, t)
+	if err != nil {
+		return nil, err
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	config = config.DeepCopy()
+	for i := range config.History {
+		config.History[i].Created = v1.Time{Time: t}
+	}
+	return mutate.ConfigFile(img, config)
+}
+
+// CheckAvailable returns ErrDockerUnavailable, wrapped, if the docker daemon
+// of DOCKER_HOST, or the default, does not respond.
+func CheckAvailable(ctx context.Context) error {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDockerUnavailable, err)
+	}
+	defer cli.Close()
+	if _, err = cli.Ping(ctx); err != nil {
+		return fmt.Errorf("%w: %v", ErrDockerUnavailable, err)
+	}
+	return nil
+}
+
+// buildItem is a message of the stream of a build.
+type buildItem struct {
+	Stream      string      `json:"stream"`
+	Error       string      `json:"error"`
+	ErrorDetail errorDetail `json:"errorDetail"`
+}
+
+// readBuildOutput writes the output of the build streamed by the daemon to
+// out, returning the error with which the build failed, if any.
+func readBuildOutput(r io.Reader, out io.Writer) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var item buildItem
+		if err := decoder.Decode(&item); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if item.Error != "" {
+			if item.ErrorDetail.Message != "" {
+				return errors.New(item.ErrorDetail.Message)
+			}
+			return errors.New(item.Error)
+		}
+		fmt.Fprint(out, item.Stream)
+	}
+}
+
+// archiveContext returns a tar of the files of the directory at root, the
+// build context, other than those ignored by the given ignorer.  The
+// Dockerfile is always included, with the images it pulls rewritten to be
+// pulled from their registry mirrors, if any, and the .git directory and the
+// RunDataDir never.  The tar is streamed as it is read, rather than held in
+// memory, such that a failure to archive the context is that of reading it.
+// Closing it before it is read entirely stops its archiving.
+func archiveContext(root string, ignorer fn.Ignorer, mirrors map[string]string) io.ReadCloser {
+	r, w := io.Pipe()
+	go func() {
+		err := writeContext(w, root, ignorer, mirrors)
+		if err != nil {
+			err = errors.Wrap(err, "failed to archive the build context")
+		}
+		w.CloseWithError(err)
+	}()
+	return r
+}
+
+// writeContext writes the tar of the build context at root to w (see
+// archiveContext).
+func writeContext(w io.Writer, root string, ignorer fn.Ignorer, mirrors map[string]string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		name := rel
+		if fi.IsDir() {
+			name += "/"
+		}
+		if rel == ".git" || rel == fn.RunDataDir || (rel != fn.Dockerfile && ignorer.Ignored(name)) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		link := ""
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if rel == fn.Dockerfile && len(mirrors) > 0 && fi.Mode().IsRegular() {
+			dockerfile, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			dockerfile = mirrorDockerfile(dockerfile, mirrors)
+			header.Size = int64(len(dockerfile))
+			if err = tw.WriteHeader(header); err != nil {
+				return err
+			}
+			_, err = tw.Write(dockerfile)
+			return err
+		}
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+var (
+	// fromRegex matches the FROM instruction of a Dockerfile, optionally with
+	// a --platform, of which the image is the second group.
+	fromRegex = regexp.MustCompile(`(?i)^(\s*FROM\s+(?:--platform=\S+\s+)?)(\S+)(.*)$`)
+	// stageRegex matches the name of the stage of a FROM instruction.
+	stageRegex = regexp.MustCompile(`(?i)^\s+AS\s+(\S+)`)
+	// copyFromRegex matches the COPY instruction of a Dockerfile copying from
+	// an image or stage, which is the second group.
+	copyFromRegex = regexp.MustCompile(`(?i)^(\s*COPY\s+(?:--\S+\s+)*--from=)(\S+)(.*)$`)
+)
+
+// mirrorDockerfile returns the Dockerfile with the images it pulls, those of
+// its FROM and COPY --from instructions, rewritten to be pulled from their
+// registry mirrors, if any.  Its stages, images given by build args and
+// scratch are not images pulled, and are kept as they are.
+func mirrorDockerfile(dockerfile []byte, mirrors map[string]string) []byte {
+	stages := map[string]bool{"scratch": true}
+	mirror := func(image string) string {
+		if stages[strings.ToLower(image)] || strings.Contains(image, "$") {
+			return image
+		}
+		mirrored, _ := fn.MirrorImage(image, mirrors)
+		return mirrored
+	}
+	lines := strings.Split(string(dockerfile), "\n")
+	for i, line := range lines {
+		if m := fromRegex.FindStringSubmatch(line); m != nil {
+			lines[i] = m[1] + mirror(m[2]) + m[3]
+			if s := stageRegex.FindStringSubmatch(m[3]); s != nil {
+				stages[strings.ToLower(s[1])] = true
+			}
+		} else if m := copyFromRegex.FindStringSubmatch(line); m != nil {
+			if _, err := strconv.Atoi(m[2]); err != nil {
+				lines[i] = m[1] + mirror(m[2]) + m[3]
+			}
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
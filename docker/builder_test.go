@@ -0,0 +1,208 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_archiveContext ensures the build context includes the files of the
+// Function other than those ignored, the .git directory and the RunDataDir,
+// and always its Dockerfile, of which the images are pulled from their
+// mirrors, and that a failure to archive it is returned as it is read.
+func Test_archiveContext(t *testing.T) {
+	root, err := ioutil.TempDir("", "func-build-context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	for _, file := range []string{"Dockerfile", "handle.go", "node_modules/dep.js", ".git/HEAD", ".func/instance.json", "cmd/function/main.go"} {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(root, "Dockerfile"), []byte("FROM golang:1.16\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := archiveContext(root, fn.NewIgnorer([]string{"node_modules/", "Dockerfile"}), map[string]string{"docker.io": "registry.internal/hub"})
+	defer r.Close()
+	names := []string{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+		if header.Name == "Dockerfile" {
+			if bb, _ := ioutil.ReadAll(tr); string(bb) != "FROM registry.internal/hub/library/golang:1.16\n" {
+				t.Fatalf("expected the image of the Dockerfile to be pulled from its mirror, got %q", bb)
+			}
+		}
+	}
+	sort.Strings(names)
+	expected := []string{"Dockerfile", "cmd/", "cmd/function/", "cmd/function/main.go", "handle.go"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected the build context %v, got %v", expected, names)
+	}
+
+	// A failure to archive the context is that of reading it.
+	r = archiveContext(filepath.Join(root, "missing"), fn.NewIgnorer(nil), nil)
+	defer r.Close()
+	if _, err = ioutil.ReadAll(r); err == nil || !strings.Contains(err.Error(), "failed to archive the build context") {
+		t.Fatalf("expected an error reading the context of a missing root, got %v", err)
+	}
+}
+
+// Test_mirrorDockerfile ensures the images pulled by the FROM and COPY --from
+// instructions of a Dockerfile are pulled from their registry mirrors, and
+// that its stages, build args and scratch are kept.
+func Test_mirrorDockerfile(t *testing.T) {
+	dockerfile := `ARG RUNTIME=gcr.io/distroless/static
+FROM --platform=$BUILDPLATFORM golang:1.16 AS Builder
+COPY --from=gcr.io/tools/protoc:3 /protoc /usr/bin/protoc
+RUN go build -o /function ./cmd/function
+FROM scratch AS empty
+FROM ${RUNTIME}
+COPY --from=builder /function /function
+COPY --from=0 /etc/ssl /etc/ssl
+from gcr.io/distroless/base
+`
+	expected := `ARG RUNTIME=gcr.io/distroless/static
+FROM --platform=$BUILDPLATFORM registry.internal/hub/library/golang:1.16 AS Builder
+COPY --from=registry.internal/gcr/tools/protoc:3 /protoc /usr/bin/protoc
+RUN go build -o /function ./cmd/function
+FROM scratch AS empty
+FROM ${RUNTIME}
+COPY --from=builder /function /function
+COPY --from=0 /etc/ssl /etc/ssl
+from registry.internal/gcr/distroless/base
+`
+	mirrors := map[string]string{"docker.io": "registry.internal/hub", "gcr.io": "registry.internal/gcr"}
+	if mirrored := string(mirrorDockerfile([]byte(dockerfile), mirrors)); mirrored != expected {
+		t.Fatalf("expected the Dockerfile\n%v\ngot\n%v", expected, mirrored)
+	}
+}
+
+// Test_normalizeTimes ensures images of the same files built at different
+// times are the same once their timestamps are normalized, which are those of
+// the time given.
+func Test_normalizeTimes(t *testing.T) {
+	build := func(now time.Time) v1.Image {
+		t.Helper()
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		content := []byte("package function")
+		if err := tw.WriteHeader(&tar.Header{Name: "handle.go", Mode: 0644, Size: int64(len(content)), ModTime: now}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		layer, err := tarball.LayerFromReader(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: layer, History: v1.History{Created: v1.Time{Time: now}, CreatedBy: "COPY . ."}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img, err = mutate.CreatedAt(img, v1.Time{Time: now}); err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+	digest := func(img v1.Image) v1.Hash {
+		t.Helper()
+		h, err := img.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	first, second := build(time.Now()), build(time.Now().Add(time.Hour))
+	if digest(first) == digest(second) {
+		t.Fatal("expected images built at different times to differ")
+	}
+	epoch := time.Unix(315532801, 0).UTC()
+	first, err := normalizeTimes(first, epoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second, err = normalizeTimes(second, epoch); err != nil {
+		t.Fatal(err)
+	}
+	if digest(first) != digest(second) {
+		t.Fatalf("expected the normalized images to be the same, got %v and %v", digest(first), digest(second))
+	}
+	config, err := first.ConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.Created.Time.Equal(epoch) || len(config.History) == 0 || !config.History[0].Created.Time.Equal(epoch) {
+		t.Fatalf("expected the image to be created at %v, got %v with history %+v", epoch, config.Created, config.History)
+	}
+}
+
+// Test_readBuildOutput ensures the output of a build is written, and the
+// error with which it failed returned.
+func Test_readBuildOutput(t *testing.T) {
+	var out bytes.Buffer
+	stream := `{"stream":"Step 1/2 : FROM scratch\n"}{"stream":"Step 2/2 : RUN false\n"}`
+	if err := readBuildOutput(strings.NewReader(stream), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "Step 1/2 : FROM scratch\nStep 2/2 : RUN false\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	stream = `{"stream":"Step 1/1 : RUN false\n"}{"errorDetail":{"message":"returned a non-zero code: 1"},"error":"returned a non-zero code: 1"}`
+	if err := readBuildOutput(strings.NewReader(stream), &out); err == nil || err.Error() != "returned a non-zero code: 1" {
+		t.Fatalf("expected the build error, got %v", err)
+	}
+}
+
+// TestBuilder_Buildpacks ensures the buildpacks of a Function, which are not
+// of its Dockerfile, are refused rather than ignored.
+func TestBuilder_Buildpacks(t *testing.T) {
+	root, err := ioutil.TempDir("", "func-build-buildpacks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err = ioutil.WriteFile(filepath.Join(root, "Dockerfile"), []byte("FROM golang:1.16\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := fn.Function{Root: root, Image: "example.com/alice/myfunc", Build: fn.BuildSpec{Buildpacks: []string{"gcr.io/paketo-buildpacks/datadog"}}}
+	if err = NewBuilder().Build(context.Background(), f); err == nil || !strings.Contains(err.Error(), "gcr.io/paketo-buildpacks/datadog are not supported") {
+		t.Fatalf("expected the buildpacks to be refused, got %v", err)
+	}
+}
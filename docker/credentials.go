@@ -0,0 +1,191 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containers/image/v5/pkg/docker/config"
+	"github.com/containers/image/v5/types"
+
+	fn "github.com/boson-project/func"
+)
+
+// Environment variables of the credentials with which to push to a registry
+// for which none are stored, such as in CI.
+const (
+	RegistryUsernameEnv = "FUNC_REGISTRY_USERNAME"
+	RegistryPasswordEnv = "FUNC_REGISTRY_PASSWORD"
+)
+
+// NewCredentialsProvider returns the default provider of the credentials of
+// registries, which resolves them in order from:
+//
+//  1. The containers auth files, the docker config, or the docker credentials
+//     store, as stored by 'docker login'.
+//  2. The environment variables $FUNC_REGISTRY_USERNAME and
+//     $FUNC_REGISTRY_PASSWORD.
+//  3. The given prompt, if any, such as one which asks the user in an
+//     interactive terminal.
+//
+// Without credentials from any, those returned are empty, and so anonymous.
+func NewCredentialsProvider(prompt CredentialsProvider) CredentialsProvider {
+	return func(ctx context.Context, registry string) (fn.Credentials, error) {
+		if c, ok, err := StoredCredentials(registry); err != nil || ok {
+			return c, err
+		}
+		if c, ok := EnvCredentials(); ok {
+			return c, nil
+		}
+		if prompt != nil {
+			return prompt(ctx, registry)
+		}
+		return fn.Credentials{}, nil
+	}
+}
+
+// StoredCredentials returns the credentials for the registry found in the
+// containers auth files, the docker config or the docker credentials store,
+// and whether any were found.
+func StoredCredentials(registry string) (c fn.Credentials, ok bool, err error) {
+	auth, err := config.GetCredentials(nil, registry)
+	if err != nil {
+		return c, false, fmt.Errorf("failed to get credentials: %w", err)
+	}
+	if auth == (types.DockerAuthConfig{}) {
+		auth, _ = GetCredentialsFromCredsStore(registry)
+	}
+	if auth == (types.DockerAuthConfig{}) {
+		return c, false, nil
+	}
+	return fn.Credentials{Username: auth.Username, Password: auth.Password}, true, nil
+}
+
+// EnvCredentials returns the credentials of $FUNC_REGISTRY_USERNAME and
+// $FUNC_REGISTRY_PASSWORD, and whether both are set.
+func EnvCredentials() (fn.Credentials, bool) {
+	c := fn.Credentials{Username: os.Getenv(RegistryUsernameEnv), Password: os.Getenv(RegistryPasswordEnv)}
+	return c, c.Username != "" && c.Password != ""
+}
+
+// SaveCredentials stores the credentials for the registry in the docker
+// config, that of $DOCKER_CONFIG or else ~/.docker/config.json, as does
+// 'docker login' without a credentials store.  The other settings of the
+// config are preserved.  Returns the path of the config.
+func SaveCredentials(registry string, c fn.Credentials) (path string, err error) {
+	if path, err = configPath(); err != nil {
+		return
+	}
+	dir := filepath.Dir(path)
+
+	// Settings other than the auths are preserved as read.
+	settings := map[string]json.RawMessage{}
+	auths := map[string]json.RawMessage{}
+	bb, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	if len(bb) > 0 {
+		if err = json.Unmarshal(bb, &settings); err != nil {
+			return path, fmt.Errorf("failed to deserialize docker config file: %w", err)
+		}
+		if raw, ok := settings["auths"]; ok {
+			if err = json.Unmarshal(raw, &auths); err != nil {
+				return path, fmt.Errorf("failed to deserialize docker config file: %w", err)
+			}
+		}
+	}
+
+	auth := base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))
+	if auths[registry], err = json.Marshal(map[string]string{"auth": auth}); err != nil {
+		return
+	}
+	if settings["auths"], err = json.Marshal(auths); err != nil {
+		return
+	}
+	if bb, err = json.MarshalIndent(settings, "", "\t"); err != nil {
+		return
+	}
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+	return path, ioutil.WriteFile(path, bb, 0600)
+}
+
+// configPath returns the path of the docker config: that of $DOCKER_CONFIG or
+// else ~/.docker/config.json.
+func configPath() (string, error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// LoggedInRegistry returns the registry of Function images, such as
+// "quay.io/alice", of the sole registry for which the docker config stores
+// credentials, as does 'docker login': its host, followed by the username of
+// the credentials as the namespace.  Docker Hub is "docker.io".  It is empty
+// when the config stores credentials for no registry or for several, or the
+// username can not be determined.  It is best effort, only a default for when
+// no registry is configured.
+func LoggedInRegistry() string {
+	path, err := configPath()
+	if err != nil {
+		return ""
+	}
+	bb, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var conf struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+		} `json:"auths"`
+	}
+	if err = json.Unmarshal(bb, &conf); err != nil || len(conf.Auths) != 1 {
+		return ""
+	}
+	for server, auth := range conf.Auths {
+		host := registryHost(server)
+		username := auth.Username
+		if decoded, err := base64.StdEncoding.DecodeString(auth.Auth); err == nil && username == "" {
+			username = strings.SplitN(string(decoded), ":", 2)[0]
+		}
+		if username == "" {
+			// Those of a credentials store are not in the config itself.
+			stored, _ := GetCredentialsFromCredsStore(host)
+			username = stored.Username
+		}
+		if host == "" || username == "" {
+			return ""
+		}
+		return host + "/" + strings.ToLower(username)
+	}
+	return ""
+}
+
+// registryHost returns the host of the registry of the given server of the
+// docker config, such as "quay.io" for "https://quay.io/v1/".  Those of Docker
+// Hub are "docker.io".
+func registryHost(server string) string {
+	if i := strings.Index(server, "://"); i >= 0 {
+		server = server[i+3:]
+	}
+	host := strings.SplitN(server, "/", 2)[0]
+	switch host {
+	case "index.docker.io", "registry-1.docker.io":
+		return fn.DefaultRegistry
+	}
+	return host
+}
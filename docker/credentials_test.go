@@ -0,0 +1,128 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fn "github.com/boson-project/func"
+)
+
+// TestNewCredentialsProvider ensures credentials are resolved from the docker
+// config, then the environment, and then the prompt.
+func TestNewCredentialsProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, value := range map[string]string{"DOCKER_CONFIG": dir, RegistryUsernameEnv: "", RegistryPasswordEnv: ""} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Setenv(name, value)
+	}
+
+	const registry = "registry.example.com"
+	prompted := false
+	provider := NewCredentialsProvider(func(ctx context.Context, registry string) (fn.Credentials, error) {
+		prompted = true
+		return fn.Credentials{Username: "prompted", Password: "secret"}, nil
+	})
+	assert := func(username string) {
+		t.Helper()
+		c, err := provider(context.Background(), registry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Username != username {
+			t.Fatalf("expected the credentials of '%v', got those of '%v'", username, c.Username)
+		}
+	}
+
+	assert("prompted")
+
+	os.Setenv(RegistryUsernameEnv, "env")
+	os.Setenv(RegistryPasswordEnv, "secret")
+	prompted = false
+	assert("env")
+	if prompted {
+		t.Fatal("expected no prompt with credentials in the environment")
+	}
+
+	if _, err = SaveCredentials(registry, fn.Credentials{Username: "stored", Password: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	assert("stored")
+}
+
+// TestSaveCredentials ensures credentials are saved in the docker config,
+// preserving its other settings.
+func TestSaveCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	os.Setenv("DOCKER_CONFIG", dir)
+
+	existing := `{"auths": {"quay.io": {"auth": "YWxpY2U6c2VjcmV0"}}, "credHelpers": {"gcr.io": "gcloud"}}`
+	if err = ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(existing), 0600); err != nil {
+		t.Fatal(err)
+	}
+	path, err := SaveCredentials("registry.example.com", fn.Credentials{Username: "bob", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bb, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved struct {
+		Auths       map[string]struct{ Auth string }
+		CredHelpers map[string]string
+	}
+	if err = json.Unmarshal(bb, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Auths["registry.example.com"].Auth != "Ym9iOnNlY3JldA==" {
+		t.Fatalf("expected the credentials to be saved, got:\n%s", bb)
+	}
+	if saved.Auths["quay.io"].Auth == "" || saved.CredHelpers["gcr.io"] != "gcloud" {
+		t.Fatalf("expected the existing settings to be preserved, got:\n%s", bb)
+	}
+}
+
+// TestLoggedInRegistry ensures the registry is that of the sole registry for
+// which the docker config stores credentials, namespaced by their username,
+// and that none is inferred from several.
+func TestLoggedInRegistry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	os.Setenv("DOCKER_CONFIG", dir)
+
+	tests := []struct {
+		config   string
+		registry string
+	}{
+		{`{}`, ""},
+		{`{"auths": {"quay.io": {"auth": "QWxpY2U6c2VjcmV0"}}}`, "quay.io/alice"},
+		{`{"auths": {"https://index.docker.io/v1/": {"auth": "Ym9iOnNlY3JldA=="}}}`, "docker.io/bob"},
+		{`{"auths": {"quay.io": {"auth": "YWxpY2U6c2VjcmV0"}, "ghcr.io": {"auth": "Ym9iOnNlY3JldA=="}}}`, ""},
+	}
+	for _, test := range tests {
+		if err = ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(test.config), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if registry := LoggedInRegistry(); registry != test.registry {
+			t.Fatalf("expected the registry '%v' of the config %v, got '%v'", test.registry, test.config, registry)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/daemon"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/pkg/errors"
+
+	fn "github.com/boson-project/func"
+)
+
+// Exporter of images from the local docker daemon to the filesystem.
+type Exporter struct {
+	// Verbose logging.
+	Verbose bool
+}
+
+// NewExporter creates an instance of a docker-based image exporter.
+func NewExporter() *Exporter {
+	return &Exporter{}
+}
+
+// Export the image of the Function within dir, returning the path written:
+// as a docker-archive tarball (as would be produced by `docker save`), or,
+// with the format "oci", as an OCI image layout, replacing any written
+// before.
+func (e *Exporter) Export(ctx context.Context, f fn.Function, format, dir string) (path string, err error) {
+	if f.Image == "" {
+		return "", errors.New("Function has no associated image.  Has it been built?")
+	}
+	if format == "oci" {
+		path = filepath.Join(dir, LayoutName(f.Image))
+		if err = os.RemoveAll(path); err != nil {
+			return "", errors.Wrap(err, "failed to remove the previous image layout")
+		}
+		return path, e.ExportLayout(ctx, f, path)
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create docker api client")
+	}
+
+	r, err := cli.ImageSave(ctx, []string{f.Image})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to save the image")
+	}
+	defer r.Close()
+
+	path = filepath.Join(dir, ArchiveName(f.Image))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create the image archive")
+	}
+	defer file.Close()
+
+	if _, err = io.Copy(file, r); err != nil {
+		return "", errors.Wrap(err, "failed to write the image archive")
+	}
+	return path, nil
+}
+
+// ExportLayout writes the image of the Function, as built in the local docker
+// daemon, as an OCI image layout in dir, annotated with its name such that it
+// may be pushed from the layout, as with `crane push` or `skopeo copy`.
+func (e *Exporter) ExportLayout(ctx context.Context, f fn.Function, dir string) error {
+	if f.Image == "" {
+		return errors.New("Function has no associated image.  Has it been built?")
+	}
+	ref, err := name.ParseReference(f.Image)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the image name")
+	}
+	img, err := daemon.Image(ref)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the image")
+	}
+	return writeLayout(img, f.Image, dir)
+}
+
+// writeLayout writes the image as an OCI image layout in dir, its
+// descriptor annotated with the name of the image.
+func writeLayout(img v1.Image, image, dir string) error {
+	p, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the image layout")
+	}
+	annotations := map[string]string{"org.opencontainers.image.ref.name": image}
+	if err = p.AppendImage(img, layout.WithAnnotations(annotations)); err != nil {
+		return errors.Wrap(err, "failed to write the image layout")
+	}
+	return nil
+}
+
+// ArchiveName returns the file name of the archive of the given image:
+// its final path segment with any tag or digest, suffixed with ".tar".
+// For example "quay.io/alice/myfunc:latest" is archived as "myfunc.tar".
+func ArchiveName(image string) string {
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.IndexAny(name, ":@"); i >= 0 {
+		name = name[:i]
+	}
+	return name + ".tar"
+}
+
+// LayoutName returns the directory name of the OCI image layout of the given
+// image: its final path segment with any tag or digest, suffixed with "-oci".
+// For example "quay.io/alice/myfunc:latest" is written to "myfunc-oci".
+func LayoutName(image string) string {
+	return strings.TrimSuffix(ArchiveName(image), ".tar") + "-oci"
+}
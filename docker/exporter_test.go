@@ -0,0 +1,85 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+)
+
+func TestArchiveName(t *testing.T) {
+	tests := []struct {
+		name  string
+		image string
+		want  string
+	}{
+		{"tagged", "quay.io/alice/myfunc:latest", "myfunc.tar"},
+		{"untagged", "docker.io/alice/myfunc", "myfunc.tar"},
+		{"digest", "quay.io/alice/myfunc@sha256:a278a91112d17f8bde6b5f802a3317c7c752cf88078dae6f4b5a0784deb81782", "myfunc.tar"},
+		{"registry port", "localhost:5000/myfunc:v1", "myfunc.tar"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ArchiveName(tt.image); got != tt.want {
+				t.Errorf("ArchiveName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutName(t *testing.T) {
+	tests := []struct {
+		name  string
+		image string
+		want  string
+	}{
+		{"tagged", "quay.io/alice/myfunc:latest", "myfunc-oci"},
+		{"digest", "quay.io/alice/myfunc@sha256:a278a91112d17f8bde6b5f802a3317c7c752cf88078dae6f4b5a0784deb81782", "myfunc-oci"},
+		{"registry port", "localhost:5000/myfunc:v1", "myfunc-oci"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LayoutName(tt.image); got != tt.want {
+				t.Errorf("LayoutName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Test_writeLayout ensures the image is written as an OCI image layout, its
+// descriptor annotated with the name of the image.
+func Test_writeLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "func-layout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	img, err := random.Image(64, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = writeLayout(img, "quay.io/alice/myfunc:v1", dir); err != nil {
+		t.Fatal(err)
+	}
+	index, err := layout.ImageIndexFromPath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Manifests) != 1 || manifest.Manifests[0].Annotations["org.opencontainers.image.ref.name"] != "quay.io/alice/myfunc:v1" {
+		t.Fatalf("expected the image to be annotated with its name, got %+v", manifest.Manifests)
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Manifests[0].Digest != digest {
+		t.Fatalf("expected the image %v, got %v", digest, manifest.Manifests[0].Digest)
+	}
+}
@@ -13,6 +13,9 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/pkg/errors"
 
 	fn "github.com/boson-project/func"
@@ -20,12 +23,12 @@ import (
 
 type Opt func(*Pusher) error
 
-type Credentials struct {
-	Username string
-	Password string
-}
+// Credentials with which to authenticate to a registry.
+type Credentials = fn.Credentials
 
-type CredentialsProvider func(ctx context.Context, registry string) (Credentials, error)
+// CredentialsProvider returns the credentials for a registry.  It implements
+// fn.CredentialsProvider.
+type CredentialsProvider = fn.CredentialsProviderFunc
 
 // Pusher of images from local to remote registry.
 type Pusher struct {
@@ -60,9 +63,15 @@ func NewPusher(opts ...Opt) (*Pusher, error) {
 	return result, nil
 }
 
-// Push the image of the Function.
+// Push the image of the Function, authenticating with the credentials of the
+// Pusher's provider.
 func (n *Pusher) Push(ctx context.Context, f fn.Function) (digest string, err error) {
+	return n.PushWithCredentials(ctx, f, n.credentialsProvider)
+}
 
+// PushWithCredentials pushes the image of the Function, authenticating with
+// the credentials of the given provider.
+func (n *Pusher) PushWithCredentials(ctx context.Context, f fn.Function, cp fn.CredentialsProvider) (digest string, err error) {
 	if f.Image == "" {
 		return "", errors.New("Function has no associated image.  Has it been built?")
 	}
@@ -83,7 +92,7 @@ func (n *Pusher) Push(ctx context.Context, f fn.Function) (digest string, err er
 		return "", errors.Wrap(err, "failed to create docker api client")
 	}
 
-	credentials, err := n.credentialsProvider(ctx, registry)
+	credentials, err := cp.Credentials(ctx, registry)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to get credentials")
 	}
@@ -137,6 +146,13 @@ func (n *Pusher) Push(ctx context.Context, f fn.Function) (digest string, err er
 	}
 
 	digest = parseDigest(outBuff.String())
+	if digest == "" {
+		// The digest is not reported by all daemons, in which case it is
+		// resolved from the registry to which the image was pushed.
+		if digest, err = resolveDigest(ctx, f.Image, credentials); err != nil {
+			return "", errors.Wrap(err, "failed to resolve the digest of the pushed image")
+		}
+	}
 
 	return
 }
@@ -154,6 +170,24 @@ func parseDigest(output string) string {
 	return ""
 }
 
+// resolveDigest of the image from its registry, that of the manifest to which
+// its tag refers, for example "sha256:a278a9...".
+func resolveDigest(ctx context.Context, image string, credentials Credentials) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	auth := authn.Anonymous
+	if credentials.Username != "" || credentials.Password != "" {
+		auth = &authn.Basic{Username: credentials.Username, Password: credentials.Password}
+	}
+	desc, err := remote.Head(ref, remote.WithContext(ctx), remote.WithAuth(auth))
+	if err != nil {
+		return "", err
+	}
+	return desc.Digest.String(), nil
+}
+
 type errorDetail struct {
 	Message string `json:"message"`
 }
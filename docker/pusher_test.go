@@ -1,6 +1,16 @@
 package docker
 
-import "testing"
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
 
 func Test_parseDigest(t *testing.T) {
 	tests := []struct {
@@ -22,3 +32,35 @@ func Test_parseDigest(t *testing.T) {
 		})
 	}
 }
+
+// Test_resolveDigest ensures the digest of an image is resolved from the
+// registry to which it was pushed.
+func Test_resolveDigest(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+
+	image := strings.TrimPrefix(server.URL, "http://") + "/alice/myfunc:latest"
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := resolveDigest(context.Background(), image, Credentials{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != expected.String() {
+		t.Fatalf("expected digest '%v', got '%v'", expected, digest)
+	}
+}
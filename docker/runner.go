@@ -3,6 +3,7 @@ package docker
 import (
 	"context"
 	"fmt"
+	"net"
 	"os"
 	"regexp"
 	"strings"
@@ -19,6 +20,15 @@ import (
 	fn "github.com/boson-project/func"
 )
 
+const (
+	// DefaultHost is the host interface to which a running Function is bound.
+	DefaultHost = "127.0.0.1"
+
+	// DefaultPort is the host port to which a running Function is bound,
+	// unless already in use.
+	DefaultPort = "8080"
+)
+
 // Runner of functions using the docker command.
 type Runner struct {
 	// Verbose logging flag.
@@ -60,12 +70,17 @@ func (n *Runner) Run(ctx context.Context, f fn.Function) error {
 		envs = append(envs, "VERBOSE=true")
 	}
 
+	hostPort, err := choosePort(DefaultHost, DefaultPort)
+	if err != nil {
+		return errors.Wrap(err, "failed to choose a host port")
+	}
+
 	httpPort := nat.Port("8080/tcp")
 	ports := map[nat.Port][]nat.PortBinding{
 		httpPort: {
 			nat.PortBinding{
-				HostPort: "8080",
-				HostIP:   "127.0.0.1",
+				HostPort: hostPort,
+				HostIP:   DefaultHost,
 			},
 		},
 	}
@@ -128,6 +143,18 @@ func (n *Runner) Run(ctx context.Context, f fn.Function) error {
 		}
 	}()
 
+	// Record the bound port such that the running instance can be found by
+	// other commands, such as invoke, for the duration of the run.
+	if err = fn.WriteInstance(f.Root, fn.Instance{Host: DefaultHost, Port: hostPort}); err != nil {
+		return errors.Wrap(err, "failed to record running instance")
+	}
+	defer func() {
+		if err := fn.RemoveInstance(f.Root); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to remove running instance record: %v", err)
+		}
+	}()
+	fmt.Fprintf(os.Stderr, "Function running on http://%v\n", net.JoinHostPort(DefaultHost, hostPort))
+
 	select {
 	case body := <-waitBodyChan:
 		if body.StatusCode != 0 {
@@ -144,6 +171,20 @@ func (n *Runner) Run(ctx context.Context, f fn.Function) error {
 	return nil
 }
 
+// choosePort returns the preferred port if it is available on host, or
+// otherwise a free port chosen by the system.
+func choosePort(host, preferred string) (string, error) {
+	l, err := net.Listen("tcp", net.JoinHostPort(host, preferred))
+	if err != nil {
+		if l, err = net.Listen("tcp", net.JoinHostPort(host, "0")); err != nil {
+			return "", err
+		}
+	}
+	defer l.Close()
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	return port, err
+}
+
 // run command supports only ENV values in from FOO=bar or FOO={{ env:LOCAL_VALUE }}
 var evRegex = regexp.MustCompile(`^{{\s*(\w+)\s*:(\w+)\s*}}$`)
 
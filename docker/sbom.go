@@ -0,0 +1,150 @@
+package docker
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/daemon"
+	"github.com/pkg/errors"
+
+	fn "github.com/boson-project/func"
+)
+
+// LifecycleMetadataLabel of images built with buildpacks, which records the
+// layer of the SBOM generated by its buildpacks, if any.
+const LifecycleMetadataLabel = "io.buildpacks.lifecycle.metadata"
+
+// sbomRoot is the directory of the SBOM layer in which the lifecycle writes
+// the SBOM documents of the buildpacks, as launch/<buildpack>/[<layer>/]
+// sbom.<extension>.json.
+const sbomRoot = "layers/sbom/"
+
+// sbomExtensions are the extensions of the SBOM documents of each of the
+// fn.SBOMFormats.
+var sbomExtensions = map[string]string{
+	"cyclonedx": "cdx",
+	"spdx":      "spdx",
+	"syft":      "syft",
+}
+
+// ExportSBOM writes the SBOM documents of the image of the Function, as
+// built in the local docker daemon, in the given format within dir.  They are
+// written as within the SBOM layer of the image: a document per buildpack, or
+// per layer of a buildpack, as launch/<buildpack>/[<layer>/]sbom.<ext>.json.
+func (e *Exporter) ExportSBOM(ctx context.Context, f fn.Function, format, dir string) ([]string, error) {
+	if f.Image == "" {
+		return nil, errors.New("Function has no associated image.  Has it been built?")
+	}
+	ref, err := name.ParseReference(f.Image)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the image name")
+	}
+	img, err := daemon.Image(ref)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the image")
+	}
+	return writeSBOM(img, format, dir)
+}
+
+// writeSBOM writes the SBOM documents of the image in the given format within
+// dir, returning their paths.  The image having no SBOM layer, or none in
+// the format, is fn.ErrNoSBOM.
+func writeSBOM(img v1.Image, format, dir string) (paths []string, err error) {
+	ext, ok := sbomExtensions[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown SBOM format '%v', must be one of %v", format, strings.Join(fn.SBOMFormats, ", "))
+	}
+
+	layer, err := sbomLayer(img)
+	if err != nil {
+		return nil, err
+	}
+	r, err := layer.Uncompressed()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the SBOM layer")
+	}
+	defer r.Close()
+
+	suffix := "/sbom." + ext + ".json"
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to read the SBOM layer")
+		}
+		p := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		if hdr.Typeflag != tar.TypeReg || !strings.HasPrefix(p, sbomRoot) || !strings.HasSuffix(p, suffix) {
+			continue
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(p, sbomRoot)))
+		if err = writeSBOMFile(dest, tr); err != nil {
+			return nil, err
+		}
+		paths = append(paths, dest)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w in the %v format", fn.ErrNoSBOM, format)
+	}
+	return
+}
+
+// sbomLayer of the image, as recorded in its lifecycle metadata.  The image
+// not having been built with buildpacks, or by a lifecycle which does not
+// generate SBOMs, is fn.ErrNoSBOM.
+func sbomLayer(img v1.Image) (v1.Layer, error) {
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the image config")
+	}
+	label := cfg.Config.Labels[LifecycleMetadataLabel]
+	if label == "" {
+		return nil, fmt.Errorf("%w: the image has no %v label, as it was not built with buildpacks", fn.ErrNoSBOM, LifecycleMetadataLabel)
+	}
+	var metadata struct {
+		SBOM *struct {
+			SHA string `json:"sha"`
+		} `json:"sbom"`
+	}
+	if err = json.Unmarshal([]byte(label), &metadata); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the %v label", LifecycleMetadataLabel)
+	}
+	if metadata.SBOM == nil || metadata.SBOM.SHA == "" {
+		return nil, fmt.Errorf("%w: the image has no SBOM layer. Its builder may require an update to one generating SBOMs", fn.ErrNoSBOM)
+	}
+	diffID, err := v1.NewHash(metadata.SBOM.SHA)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the digest of the SBOM layer")
+	}
+	layer, err := img.LayerByDiffID(diffID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the SBOM layer")
+	}
+	return layer, nil
+}
+
+// writeSBOMFile at dest, creating its directory.
+func writeSBOMFile(dest string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return errors.Wrap(err, "failed to create the SBOM directory")
+	}
+	file, err := os.Create(dest)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the SBOM")
+	}
+	defer file.Close()
+	if _, err = io.Copy(file, r); err != nil {
+		return errors.Wrap(err, "failed to write the SBOM")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_writeSBOM ensures that the SBOM documents of the format requested are
+// written from the SBOM layer of an image, and that an image without one, or
+// without documents of the format, is ErrNoSBOM.
+func Test_writeSBOM(t *testing.T) {
+	image := func(t *testing.T, files map[string]string) v1.Image {
+		t.Helper()
+		img, err := random.Image(64, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if files == nil {
+			return img
+		}
+		var b bytes.Buffer
+		tw := tar.NewWriter(&b)
+		for name, content := range files {
+			if err = tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = tw.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(b.Bytes())), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img, err = mutate.AppendLayers(img, layer); err != nil {
+			t.Fatal(err)
+		}
+		diffID, err := layer.DiffID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := img.ConfigFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.Config.Labels = map[string]string{LifecycleMetadataLabel: fmt.Sprintf(`{"sbom":{"sha":"%v"}}`, diffID)}
+		if img, err = mutate.Config(img, cfg.Config); err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+
+	img := image(t, map[string]string{
+		"/layers/sbom/launch/paketo-buildpacks_go-build/targets/sbom.cdx.json":  `{"bomFormat":"CycloneDX"}`,
+		"/layers/sbom/launch/paketo-buildpacks_go-build/targets/sbom.spdx.json": `{"spdxVersion":"SPDX-2.2"}`,
+		"/layers/sbom/launch/paketo-buildpacks_ca-certificates/sbom.cdx.json":   `{"bomFormat":"CycloneDX"}`,
+	})
+
+	dir := t.TempDir()
+	paths, err := writeSBOM(img, "cyclonedx", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("expected the 2 CycloneDX documents, got %v", paths)
+	}
+	bb, err := ioutil.ReadFile(filepath.Join(dir, "launch", "paketo-buildpacks_go-build", "targets", "sbom.cdx.json"))
+	if err != nil || string(bb) != `{"bomFormat":"CycloneDX"}` {
+		t.Fatalf("expected the CycloneDX document of the go-build layer, got %s (%v)", bb, err)
+	}
+
+	if _, err = writeSBOM(img, "syft", t.TempDir()); !errors.Is(err, fn.ErrNoSBOM) {
+		t.Fatalf("expected ErrNoSBOM for a format not generated, got %v", err)
+	}
+	if _, err = writeSBOM(image(t, nil), "spdx", t.TempDir()); !errors.Is(err, fn.ErrNoSBOM) {
+		t.Fatalf("expected ErrNoSBOM for an image without an SBOM layer, got %v", err)
+	}
+	if _, err = writeSBOM(img, "csv", t.TempDir()); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
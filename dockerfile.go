@@ -0,0 +1,44 @@
+package function
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/markbates/pkger"
+)
+
+// DockerfileBuilder is the Builder of a Function built from the Dockerfile
+// of its source, with docker or podman, rather than with buildpacks.
+const DockerfileBuilder = "dockerfile"
+
+// Dockerfile is the name of the file, at the root of a Function, from which
+// it is built with the DockerfileBuilder.
+const Dockerfile = "Dockerfile"
+
+// dockerfileDir is the hidden directory of the embedded templates of the
+// Dockerfiles scaffolded into a Function created with the DockerfileBuilder,
+// when its template does not itself provide a Dockerfile, laid out as
+// [dockerfileDir]/[runtime]/[template], with any other files it builds, such
+// as the entrypoint of a Go Function, or as [dockerfileDir]/[runtime] for a
+// Dockerfile of any template of the runtime.
+const dockerfileDir = ".dockerfile"
+
+// writeDockerfile scaffolds the Dockerfile of the Function's runtime and
+// template, and any files it builds, into the root of the Function.  Nothing
+// is written if the template provides its own Dockerfile, and files already
+// present are not overwritten.
+func writeDockerfile(f Function) error {
+	if _, err := os.Stat(filepath.Join(f.Root, Dockerfile)); err == nil {
+		return nil
+	}
+	src := filepath.Join("/templates", dockerfileDir, f.Runtime, f.Template)
+	if _, err := pkger.Stat(filepath.Join(src, Dockerfile)); err != nil {
+		src = filepath.Join("/templates", dockerfileDir, f.Runtime)
+	}
+	if _, err := pkger.Stat(filepath.Join(src, Dockerfile)); err != nil {
+		return fmt.Errorf("no Dockerfile is available for the '%v' template of the '%v' runtime. Use a template which provides one", f.Template, f.Runtime)
+	}
+	keep := func(string, []byte, []byte) (bool, error) { return false, nil }
+	return write(src, f.Root, embeddedAccessor{}, f, keep)
+}
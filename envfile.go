@@ -0,0 +1,113 @@
+package function
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/boson-project/func/utils"
+)
+
+// ReadEnvFile returns the envs of the env file at path (see ParseEnvFile).
+func ReadEnvFile(path string) (Envs, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	envs, err := ParseEnvFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	return envs, nil
+}
+
+// ParseEnvFile returns the envs of an env file, as read by Docker and
+// Compose: a NAME=VALUE per line, optionally preceded by "export", of which
+// blank lines and those beginning with "#" are skipped.  A value may be
+// quoted: in double quotes, within which \n, \t, \", \$ and \\ are escaped,
+// or in single quotes, taken literally.  An unquoted value is trimmed of
+// surrounding whitespace and of any comment following " #".  An env of which
+// the name is given more than once is that last given.
+func ParseEnvFile(r io.Reader) (Envs, error) {
+	var (
+		envs    Envs
+		indexes = map[string]int{}
+		scanner = bufio.NewScanner(r)
+		line    int
+	)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimSpace(strings.TrimPrefix(text, "export "))
+		i := strings.Index(text, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected NAME=VALUE, got '%v'", line, text)
+		}
+		name := strings.TrimSpace(text[:i])
+		if err := utils.ValidateEnvVarName(name); err != nil {
+			return nil, fmt.Errorf("line %d: invalid name %q: %v", line, name, err)
+		}
+		value, err := envFileValue(strings.TrimSpace(text[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid value of %v: %v", line, name, err)
+		}
+		env := Env{Name: &name, Value: &value}
+		if j, ok := indexes[name]; ok {
+			envs[j] = env
+			continue
+		}
+		indexes[name] = len(envs)
+		envs = append(envs, env)
+	}
+	return envs, scanner.Err()
+}
+
+// envFileValue returns the value of an env file line, unquoted.
+func envFileValue(value string) (string, error) {
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+	var (
+		quote    = value[0]
+		unquoted strings.Builder
+		end      = -1
+	)
+	for i := 1; i < len(value) && end < 0; i++ {
+		c := value[i]
+		switch {
+		case c == quote:
+			end = i
+		case c == '\\' && quote == '"' && i+1 < len(value):
+			i++
+			switch value[i] {
+			case 'n':
+				unquoted.WriteByte('\n')
+			case 't':
+				unquoted.WriteByte('\t')
+			case '"', '$', '\\':
+				unquoted.WriteByte(value[i])
+			default:
+				unquoted.WriteByte('\\')
+				unquoted.WriteByte(value[i])
+			}
+		default:
+			unquoted.WriteByte(c)
+		}
+	}
+	if end < 0 {
+		return "", fmt.Errorf("missing closing quote %c", quote)
+	}
+	if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected '%v' after closing quote", rest)
+	}
+	return unquoted.String(), nil
+}
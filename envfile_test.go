@@ -0,0 +1,59 @@
+// +build !integration
+
+package function
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseEnvFile ensures that the envs of an env file are parsed, blank
+// lines and comments skipped, quoted values unquoted and those given more
+// than once the last given.
+func TestParseEnvFile(t *testing.T) {
+	file := `# Settings of the function
+export GREETING=Hello
+NAME = World  # trailing comment
+
+EMPTY=
+URL=https://example.com/#anchor
+DOUBLE="Hello, \"World\"\n\$HOME # not a comment" # comment
+SINGLE='Hello, \n $HOME'
+NAME=Everyone
+`
+	envs, err := ParseEnvFile(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][2]string{
+		{"GREETING", "Hello"},
+		{"NAME", "Everyone"},
+		{"EMPTY", ""},
+		{"URL", "https://example.com/#anchor"},
+		{"DOUBLE", "Hello, \"World\"\n$HOME # not a comment"},
+		{"SINGLE", `Hello, \n $HOME`},
+	}
+	if len(envs) != len(expected) {
+		t.Fatalf("expected %v envs, got %v", len(expected), len(envs))
+	}
+	for i, e := range expected {
+		if *envs[i].Name != e[0] || *envs[i].Value != e[1] {
+			t.Errorf("expected env #%d %v=%q, got %v=%q", i, e[0], e[1], *envs[i].Name, *envs[i].Value)
+		}
+	}
+}
+
+// TestParseEnvFile_Invalid ensures that lines which are not NAME=VALUE,
+// invalid names and unterminated quotes are errors of their line.
+func TestParseEnvFile_Invalid(t *testing.T) {
+	for file, expected := range map[string]string{
+		"A=1\nGREETING":         "line 2: expected NAME=VALUE",
+		"1NAME=value":           "line 1: invalid name",
+		"NAME=\"unterminated":   "line 1: invalid value of NAME: missing closing quote",
+		"NAME='quoted' trailer": "line 1: invalid value of NAME: unexpected 'trailer'",
+	} {
+		if _, err := ParseEnvFile(strings.NewReader(file)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected an error containing %q for %q, got %v", expected, file, err)
+		}
+	}
+}
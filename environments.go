@@ -0,0 +1,227 @@
+package function
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Environment is an overlay of the deployment settings of a Function for an
+// environment to which it is deployed, such as "staging" or "prod", merged
+// over its settings when deployed to that environment (see WithEnvironment).
+// Settings not set are those of the Function.
+type Environment struct {
+	// Namespace into which the Function is deployed.
+	Namespace string `yaml:"namespace,omitempty"`
+	// PullSecret, ServiceAccount, ImagePullPolicy and Domain, as those of the
+	// Function.
+	PullSecret      string `yaml:"pullSecret,omitempty"`
+	ServiceAccount  string `yaml:"serviceAccount,omitempty"`
+	ImagePullPolicy string `yaml:"imagePullPolicy,omitempty"`
+	Domain          string `yaml:"domain,omitempty"`
+	// Envs merged by name over those of the Function.
+	Envs Envs `yaml:"envs,omitempty"`
+	// Annotations merged by key over those of the Function.
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+	// Options merged over those of the Function, such as the scale.
+	Options Options `yaml:"options,omitempty"`
+}
+
+// ErrUnknownEnvironment is returned when deploying a Function to an
+// environment for which it has no overlay.
+type ErrUnknownEnvironment struct {
+	// Name of the environment.
+	Name string
+	// Defined are the names of the environments of the Function.
+	Defined []string
+}
+
+func (e ErrUnknownEnvironment) Error() string {
+	if len(e.Defined) == 0 {
+		return fmt.Sprintf("unknown environment '%v': the function defines no environments", e.Name)
+	}
+	return fmt.Sprintf("unknown environment '%v'. Defined environments: %v", e.Name, strings.Join(e.Defined, ", "))
+}
+
+// WithEnvironment returns the Function with the overlay of the named
+// environment merged over its settings: those of maps, such as its envs and
+// annotations, by name, and those of scalars, such as its namespace, by
+// replacement.  An environment which is not defined is ErrUnknownEnvironment.
+func (f Function) WithEnvironment(name string) (Function, error) {
+	env, ok := f.Environments[name]
+	if !ok {
+		defined := make([]string, 0, len(f.Environments))
+		for n := range f.Environments {
+			defined = append(defined, n)
+		}
+		sort.Strings(defined)
+		return f, ErrUnknownEnvironment{Name: name, Defined: defined}
+	}
+
+	for _, s := range []struct {
+		overlay string
+		base    *string
+	}{
+		{env.Namespace, &f.Namespace},
+		{env.PullSecret, &f.PullSecret},
+		{env.ServiceAccount, &f.ServiceAccount},
+		{env.ImagePullPolicy, &f.ImagePullPolicy},
+		{env.Domain, &f.Domain},
+	} {
+		if s.overlay != "" {
+			*s.base = s.overlay
+		}
+	}
+	f.Envs = mergeEnvs(f.Envs, env.Envs)
+	if len(env.Annotations) > 0 {
+		annotations := make(map[string]string, len(f.Annotations)+len(env.Annotations))
+		for k, v := range f.Annotations {
+			annotations[k] = v
+		}
+		for k, v := range env.Annotations {
+			annotations[k] = v
+		}
+		f.Annotations = annotations
+	}
+	f.Options = mergeOptions(f.Options, env.Options)
+	return f, nil
+}
+
+// WithEnvs returns the Function with the given envs merged over its own: an
+// env of the same name is replaced, and others appended.
+func (f Function) WithEnvs(envs Envs) Function {
+	f.Envs = mergeEnvs(f.Envs, envs)
+	return f
+}
+
+// mergeEnvs returns the envs with those of the overlay merged over them: an
+// env of the same name is replaced in place, and others appended.  Envs
+// without a name, which import all keys of a Secret or ConfigMap, are
+// appended.
+func mergeEnvs(envs, overlay Envs) Envs {
+	merged := append(Envs{}, envs...)
+	for _, o := range overlay {
+		replaced := false
+		for i, e := range merged {
+			if o.Name != nil && e.Name != nil && *o.Name == *e.Name {
+				merged[i] = o
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, o)
+		}
+	}
+	return merged
+}
+
+// mergeOptions returns the options with those set by the overlay replacing
+// them, field by field.
+func mergeOptions(options, overlay Options) Options {
+	if overlay.Scale != nil {
+		scale := ScaleOptions{}
+		if options.Scale != nil {
+			scale = *options.Scale
+		}
+		if overlay.Scale.Min != nil {
+			scale.Min = overlay.Scale.Min
+		}
+		if overlay.Scale.Max != nil {
+			scale.Max = overlay.Scale.Max
+		}
+		if overlay.Scale.Metric != nil {
+			scale.Metric = overlay.Scale.Metric
+		}
+		if overlay.Scale.Target != nil {
+			scale.Target = overlay.Scale.Target
+		}
+		if overlay.Scale.Utilization != nil {
+			scale.Utilization = overlay.Scale.Utilization
+		}
+		options.Scale = &scale
+	}
+	if overlay.Resources != nil {
+		resources := ResourcesOptions{}
+		if options.Resources != nil {
+			resources = *options.Resources
+		}
+		if o := overlay.Resources.Requests; o != nil {
+			requests := ResourcesRequestsOptions{}
+			if resources.Requests != nil {
+				requests = *resources.Requests
+			}
+			if o.CPU != nil {
+				requests.CPU = o.CPU
+			}
+			if o.Memory != nil {
+				requests.Memory = o.Memory
+			}
+			requests.Extended = mergeExtended(requests.Extended, o.Extended)
+			resources.Requests = &requests
+		}
+		if o := overlay.Resources.Limits; o != nil {
+			limits := ResourcesLimitsOptions{}
+			if resources.Limits != nil {
+				limits = *resources.Limits
+			}
+			if o.CPU != nil {
+				limits.CPU = o.CPU
+			}
+			if o.Memory != nil {
+				limits.Memory = o.Memory
+			}
+			if o.Concurrency != nil {
+				limits.Concurrency = o.Concurrency
+			}
+			limits.Extended = mergeExtended(limits.Extended, o.Extended)
+			resources.Limits = &limits
+		}
+		options.Resources = &resources
+	}
+	if overlay.RequestTimeout != nil {
+		options.RequestTimeout = overlay.RequestTimeout
+	}
+	return options
+}
+
+// mergeExtended returns the extended resources with those of the overlay
+// replacing them, resource by resource.
+func mergeExtended(extended, overlay map[string]string) map[string]string {
+	if len(overlay) == 0 {
+		return extended
+	}
+	merged := map[string]string{}
+	for name, quantity := range extended {
+		merged[name] = quantity
+	}
+	for name, quantity := range overlay {
+		merged[name] = quantity
+	}
+	return merged
+}
+
+// validateEnvironments returns the problems of the settings of each of the
+// environments, in order of name, the field of each being within that of its
+// environment, such as "environments.prod.domain".
+func validateEnvironments(environments map[string]Environment) (errs []FieldError) {
+	names := make([]string, 0, len(environments))
+	for name := range environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env := environments[name]
+		overlay := Function{
+			Envs:            env.Envs,
+			Options:         env.Options,
+			Domain:          env.Domain,
+			ImagePullPolicy: env.ImagePullPolicy,
+		}
+		for _, e := range overlay.validate() {
+			e.Field = "environments." + name + "." + e.Field
+			errs = append(errs, e)
+		}
+	}
+	return
+}
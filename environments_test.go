@@ -0,0 +1,85 @@
+// +build !integration
+
+package function
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestFunction_WithEnvironment ensures that the overlay of an environment is
+// merged over the settings of a Function: scalars replaced, envs and
+// annotations merged by name, and options merged field by field.
+func TestFunction_WithEnvironment(t *testing.T) {
+	name := func(s string) *string { return &s }
+	min, max, newMax := int64(1), int64(5), int64(10)
+
+	f := Function{
+		Name:        "myfunc",
+		Namespace:   "dev",
+		Envs:        Envs{{Name: name("A"), Value: name("1")}, {Name: name("B"), Value: name("2")}},
+		Annotations: map[string]string{"team": "a", "tier": "dev"},
+		Options:     Options{Scale: &ScaleOptions{Min: &min, Max: &max}},
+		Environments: map[string]Environment{
+			"prod": {
+				Namespace:   "prod",
+				Envs:        Envs{{Name: name("B"), Value: name("3")}, {Name: name("C"), Value: name("4")}},
+				Annotations: map[string]string{"tier": "prod"},
+				Options:     Options{Scale: &ScaleOptions{Max: &newMax}},
+			},
+		},
+	}
+
+	prod, err := f.WithEnvironment("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prod.Namespace != "prod" {
+		t.Fatalf("expected namespace 'prod', got '%v'", prod.Namespace)
+	}
+	expectedEnvs := Envs{{Name: name("A"), Value: name("1")}, {Name: name("B"), Value: name("3")}, {Name: name("C"), Value: name("4")}}
+	if !reflect.DeepEqual(prod.Envs, expectedEnvs) {
+		t.Fatalf("expected envs %v, got %v", expectedEnvs, prod.Envs)
+	}
+	if !reflect.DeepEqual(prod.Annotations, map[string]string{"team": "a", "tier": "prod"}) {
+		t.Fatalf("unexpected annotations %v", prod.Annotations)
+	}
+	if *prod.Options.Scale.Min != 1 || *prod.Options.Scale.Max != 10 {
+		t.Fatalf("expected scale 1-10, got %v-%v", *prod.Options.Scale.Min, *prod.Options.Scale.Max)
+	}
+
+	// The Function itself is left as is.
+	if f.Namespace != "dev" || len(f.Envs) != 2 || f.Annotations["tier"] != "dev" || *f.Options.Scale.Max != 5 {
+		t.Fatal("expected the Function not to be modified by its environment")
+	}
+}
+
+// TestFunction_WithEnvironmentUnknown ensures that an environment which is not
+// defined is an error listing those which are.
+func TestFunction_WithEnvironmentUnknown(t *testing.T) {
+	f := Function{Environments: map[string]Environment{"staging": {}, "prod": {}}}
+	_, err := f.WithEnvironment("qa")
+	var unknown ErrUnknownEnvironment
+	if !errors.As(err, &unknown) {
+		t.Fatalf("expected ErrUnknownEnvironment, got %v", err)
+	}
+	if err.Error() != "unknown environment 'qa'. Defined environments: prod, staging" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Test_validateEnvironments ensures that the settings of each environment are
+// validated, with errors of the fields within that of the environment.
+func Test_validateEnvironments(t *testing.T) {
+	errs := validateEnvironments(map[string]Environment{
+		"prod":    {ImagePullPolicy: "Sometimes"},
+		"staging": {Domain: "example.com"},
+	})
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	if errs[0].Field != "environments.prod.imagePullPolicy" {
+		t.Fatalf("expected the error of the field of its environment, got '%v'", errs[0])
+	}
+}
@@ -10,9 +10,18 @@ import (
 )
 
 type Function struct {
+	// SpecVersion of the Function's configuration, with which it was created.
+	SpecVersion string
+
 	// Root on disk at which to find/create source and config files.
 	Root string
 
+	// ConfigFile is the name of the file in Root from which the Function's
+	// configuration is loaded, and to which it is written, such that one
+	// directory may hold the configurations of several Functions.  Empty for
+	// the default, func.yaml.  See ConfigPath.
+	ConfigFile string
+
 	// Name of the Function.  If not provided, path derivation is attempted when
 	// requried (such as for initialization).
 	Name string
@@ -23,13 +32,59 @@ type Function struct {
 	// Runtime is the language plus context.  nodejs|go|quarkus|rust etc.
 	Runtime string
 
+	// RuntimeVersion of the runtime with which the Function is built, such as
+	// "18" of node, as given with the runtime as node@18.  Passed to the
+	// buildpack of the runtime, such as with BP_NODE_VERSION.  Optional, the
+	// buildpack's default version being used.
+	RuntimeVersion string
+
 	// Template for the Function.
 	Template string
 
+	// TemplateRef is the ref (branch, tag or commit) of the git repository of
+	// the Template from which the Function was created, if pinned.  Empty
+	// for the ref with which the repository was added.
+	TemplateRef string
+
+	// TemplateCommit is the commit of the git repository from which the
+	// Function was created, when its Template is given as a URL (see
+	// TemplateURL), recording exactly what it was scaffolded from.
+	TemplateCommit string
+
+	// Entrypoint is the handler of the Template selected as that of the
+	// Function when it was created, of templates exposing several example
+	// handlers.  See ManifestFile.  Empty for templates without.
+	Entrypoint string
+
+	// CI system of the Function, the CI of which was written when it was
+	// created, such as "github" or "tekton".  See CISystems.  Empty for none.
+	CI string
+
+	// Devcontainer is whether the dev container config of the runtime of the
+	// Function, its DevcontainerFile, was written when it was created, such
+	// that it is refreshed with the managed files of its template.
+	Devcontainer bool
+
+	// License of the Function, by its SPDX identifier, such as "Apache-2.0",
+	// the text of which was written as its LICENSE when it was created.  See
+	// Licenses.  Empty for none.
+	License string
+
+	// PackageManager of a Function of a Node runtime, such as "yarn", for
+	// which the variant of its template was written when it was created, and
+	// with which its dependencies are installed when built.  See
+	// PackageManagers.  Empty for npm.
+	PackageManager string
+
 	// Registry at which to store interstitial containers, in the form
 	// [registry]/[user]. If omitted, "Image" must be provided.
 	Registry string
 
+	// RegistryMirrors of the images with which the Function is built and
+	// deployed, by the prefix of the images which are pulled instead from its
+	// mirror, such as "gcr.io" of "registry.internal/gcr-mirror".
+	RegistryMirrors map[string]string
+
 	// Optional full OCI image tag in form:
 	//   [registry]/[namespace]/[name]:[tag]
 	// example:
@@ -41,29 +96,163 @@ type Function struct {
 	// "Registry+Name:latest" to derive the Image.
 	Image string
 
+	// ImageSuffix appended to the name of the Function in the image name
+	// derived from its Registry, such as "-fn" of "quay.io/alice/myfunc-fn".
+	ImageSuffix string
+
 	// SHA256 hash of the latest image that has been built
 	ImageDigest string
 
+	// PullSecret is the name of a Secret in the Function's namespace used to
+	// pull its image from a private registry when deployed.
+	PullSecret string
+
+	// ServiceAccount is the name of the Kubernetes ServiceAccount in the
+	// Function's namespace as which it runs when deployed.
+	ServiceAccount string
+
+	// ImagePullPolicy of the container of the deployed Function: one of
+	// ImagePullPolicies, such as "Never" for images built locally into the
+	// cluster's nodes, as with kind or minikube.  Optional, Kubernetes
+	// defaulting it by image.
+	ImagePullPolicy string
+
+	// Mesh of which the deployed Function is made a part by the injection of
+	// its sidecar into the Function's pods: one of Meshes, such as "istio".
+	// Optional, no sidecar being injected.
+	Mesh string
+
+	// Port on which the Function listens, set as the port of its container,
+	// with which Knative sets $PORT and to which it routes requests.
+	// Optional, Knative's 8080 by default.
+	Port int
+
+	// IngressClass of the Knative ingress through which the deployed Function
+	// is reached, such as "kourier.ingress.networking.knative.dev", for
+	// clusters of several.  Optional, that of the cluster being used.
+	IngressClass string
+
+	// Domain at which the deployed Function is reachable in addition to its
+	// default URL, such as "myfunc.example.com".  Optional.
+	Domain string
+
+	// RevisionName is the template of the names of the Revisions of the
+	// deployed Function, such as "{{.Service}}-v{{.Generation}}", as of the
+	// --revision-name of kn.  Optional, Knative generating names by default.
+	RevisionName string
+
+	// TrafficTag with which the Revision of each deploy is tagged in the
+	// traffic of the Function's Service, such that it is reachable at its
+	// own URL, such as for blue/green deployment.  Optional.
+	TrafficTag string
+
+	// Locked Functions are not modified when deployed, unless forced, as a
+	// guardrail against accidental changes, such as in production.  Their
+	// Services are annotated as locked.  Optional.
+	Locked bool
+
+	// Next is the name of the Function to which the replies of this Function,
+	// the events it returns, are sent once deployed, chaining them in a
+	// Knative Sequence.  Optional.
+	Next string
+
+	// DependsOn are the names of the Functions which are deployed, and Ready,
+	// before this Function when deployed together, such as with 'func all
+	// deploy'.  Optional.
+	DependsOn []string
+
 	// Builder represents the CNCF Buildpack builder image for a function,
-	// or it might be reference to `BuilderMap`.
+	// or it might be reference to `BuilderMap`.  The DockerfileBuilder
+	// builds the function from its Dockerfile instead.
 	Builder string
 
 	// Map containing known builders.
 	// e.g. { "jvm": "docker.io/example/quarkus-jvm-builder" }
 	BuilderMap map[string]string
 
+	// BuilderDigest of the builder image, such as "sha256:a278a9...", to which
+	// builds are pinned, such that they are reproducible as the tag of the
+	// builder moves.  Resolved when first built.
+	BuilderDigest string
+
+	// BuilderPullPolicy with which the builder image is pulled before
+	// building: one of BuilderPullPolicies.  Optional, defaulting to
+	// DefaultBuilderPullPolicy.
+	BuilderPullPolicy string
+
+	// LifecycleImage of the buildpacks lifecycle with which the Function is
+	// built, such as buildpacksio/lifecycle:0.11.1, in place of that
+	// compatible with the builder.  Optional.
+	LifecycleImage string
+
+	// PlatformAPI of the buildpacks lifecycle at which the Function is built,
+	// such as 0.4, as required by some third-party builders.  Optional,
+	// defaulting to the latest supported by both the lifecycle and pack.
+	PlatformAPI string
+
 	// List of volumes to be mounted to the function
 	Volumes Volumes
 
 	// Env variables to be set
 	Envs Envs
 
+	// Env variables set when building the Function, such as those configuring
+	// its buildpacks.  These are not set in the deployed Function.
+	BuildEnvs Envs
+
+	// Platform for which the Function is built, in the form os/architecture,
+	// such as "linux/amd64".  One of Platforms.  Defaults to that of the
+	// builder image.
+	Platform string
+
+	// Build hooks: scripts of the project run before and after the Function
+	// is built, and the buildpacks added to its build.
+	Build BuildSpec
+
 	// Map containing user-supplied annotations
 	// Example: { "division": "finance" }
 	Annotations map[string]string
 
 	// Options to be set on deployed function (scaling, etc.)
 	Options Options
+
+	// Health probes of the deployed function.  Probes not configured default
+	// to those of the runtime.
+	Health Health
+
+	// Metrics endpoint of the deployed Function, if it exposes Prometheus
+	// metrics, by which its pods are annotated to be scraped.
+	Metrics Metrics
+
+	// Tracing of the deployed Function by OpenTelemetry, if its endpoint is
+	// set, by which its container is configured with the OTEL_* envs.
+	Tracing Tracing
+
+	// InitContainers run to completion in the pods of the deployed Function,
+	// in order, before it is started.  None by default.
+	InitContainers []InitContainer
+
+	// Invocation of the Function: the signature with which it is invoked, of
+	// which its buildpack is informed by build env.  Inferred from its
+	// template if not set.
+	Invocation Invocation
+
+	// Git repository of the Function's source, from which it is built on the
+	// cluster by a PipelinesProvider.
+	Git Git
+
+	// Test configures how the Function's tests are run, such as with a command
+	// other than that of its runtime.
+	Test Test
+
+	// Environments are overlays of the deployment settings of the Function
+	// for each environment to which it is deployed, by name, such as
+	// "staging" or "prod".  See WithEnvironment.
+	Environments map[string]Environment
+
+	// Status of the Function as last deployed, recorded after each successful
+	// deploy rather than configured.  See FunctionStatus.
+	Status FunctionStatus
 }
 
 // NewFunction loads a Function from a path on disk. use .Initialized() to determine if
@@ -71,6 +260,16 @@ type Function struct {
 // NewFunction creates a Function struct whose attributes are loaded from the
 // configuraiton located at path.
 func NewFunction(root string) (f Function, err error) {
+	return NewFunctionFromFile(root, "")
+}
+
+// NewFunctionFromFile loads a Function as does NewFunction, but from the
+// config file of the given name in root rather than func.yaml.  An empty
+// name is that of the default, func.yaml.
+func NewFunctionFromFile(root, file string) (f Function, err error) {
+	if err = validateConfigFile(file); err != nil {
+		return
+	}
 
 	// Expand the passed root to its absolute path (default current dir)
 	if root, err = filepath.Abs(root); err != nil {
@@ -78,25 +277,60 @@ func NewFunction(root string) (f Function, err error) {
 	}
 
 	// Load a Config from the given absolute path
-	c, err := newConfig(root)
+	c, err := newConfig(filepath.Join(root, configFileName(file)))
 	if err != nil {
 		return
 	}
 
-	// Let's set Function name, if it is not already set
+	// Let's set Function name, if it is not already set.
 	if c.Name == "" {
-		pathParts := strings.Split(strings.TrimRight(root, string(os.PathSeparator)), string(os.PathSeparator))
-		c.Name = pathParts[len(pathParts)-1]
+		c.Name = derivedName(root, file)
 	}
 
 	// set Function to the value of the config loaded from disk.
 	f = fromConfig(c)
 
-	// The only value not included in the config is the effective path on disk
+	// The only values not included in the config are the effective path on
+	// disk, and the name of the config file there.
 	f.Root = root
+	f.ConfigFile = file
 	return
 }
 
+// derivedName of a Function without one: that of a config file named
+// [name].func.yaml, or otherwise of its root directory.
+func derivedName(root, file string) string {
+	if strings.HasSuffix(file, "."+ConfigFile) {
+		return strings.TrimSuffix(file, "."+ConfigFile)
+	}
+	pathParts := strings.Split(strings.TrimRight(root, string(os.PathSeparator)), string(os.PathSeparator))
+	return pathParts[len(pathParts)-1]
+}
+
+// ConfigPath returns the path of the Function's config file: that named by
+// ConfigFile in its root, or func.yaml by default.
+func (f Function) ConfigPath() string {
+	return filepath.Join(f.Root, configFileName(f.ConfigFile))
+}
+
+// validateConfigFile ensures the name of a config file is that of a file
+// in the root of the Function, rather than a path elsewhere.
+func validateConfigFile(file string) error {
+	if file != "" && (filepath.Base(file) != file || file == "." || file == "..") {
+		return fmt.Errorf("invalid config file name '%v': must be the name of a file in the function's directory", file)
+	}
+	return nil
+}
+
+// configFileName returns the name of the config file, defaulting to
+// ConfigFile.
+func configFileName(file string) string {
+	if file == "" {
+		return ConfigFile
+	}
+	return file
+}
+
 // WriteConfig writes this Function's configuration to disk.
 func (f Function) WriteConfig() (err error) {
 	return writeConfig(f)
@@ -106,7 +340,7 @@ func (f Function) WriteConfig() (err error) {
 // Any errors are considered failure (invalid or inaccessible root, config file, etc).
 func (f Function) Initialized() bool {
 	// Load the Function's configuration from disk and check if the (required) value Runtime is populated.
-	c, err := newConfig(f.Root)
+	c, err := newConfig(f.ConfigPath())
 	if err != nil {
 		return false
 	}
@@ -114,6 +348,60 @@ func (f Function) Initialized() bool {
 	return c.Runtime != "" && c.Name != ""
 }
 
+// ErrNotInitialized is returned when loading a Function from a path which
+// does not contain an initialized Function.
+var ErrNotInitialized = errors.New("function is not initialized")
+
+// Load the Function of the project at path from its config file of the given
+// name, func.yaml if empty.  Unlike NewFunction, the path must contain an
+// initialized Function: if the config file is missing, or lacks the name or
+// runtime, an error wrapping ErrNotInitialized is returned.
+func Load(path, file string) (f Function, err error) {
+	if f, err = NewFunctionFromFile(path, file); err != nil {
+		return
+	}
+	file = configFileName(file)
+	if _, err = os.Stat(f.ConfigPath()); os.IsNotExist(err) {
+		return f, fmt.Errorf("%w: no %v found in '%v'", ErrNotInitialized, file, f.Root)
+	} else if err != nil {
+		return
+	}
+	if !f.Initialized() {
+		return f, fmt.Errorf("%w: the %v of '%v' does not declare both a name and runtime", ErrNotInitialized, file, f.Root)
+	}
+	return
+}
+
+// LoadAll the Functions of the projects at and beneath root, such as those of
+// a monorepo, ordered by path.  A directory with a config file of the given
+// name (func.yaml if empty) is loaded as with Load, and is not searched
+// further, its subdirectories being the Function's source.  Hidden
+// directories, such as .git, and node_modules are not searched.
+func LoadAll(root, file string) (ff []Function, err error) {
+	file = configFileName(file)
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		name := info.Name()
+		if path != root && (strings.HasPrefix(name, ".") || name == "node_modules") {
+			return filepath.SkipDir
+		}
+		if _, err = os.Stat(filepath.Join(path, file)); os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		f, err := Load(path, file)
+		if err != nil {
+			return err
+		}
+		ff = append(ff, f)
+		return filepath.SkipDir
+	})
+	return
+}
+
 // Built indicates the Function has been built.  Does not guarantee the
 // image indicated actually exists, just that it _should_ exist based off
 // the current state of the Funciton object, in particular the value of
@@ -142,6 +430,26 @@ func (f Function) ImageWithDigest() string {
 	return part1 + strings.Split(part2, ":")[0] + "@" + f.ImageDigest
 }
 
+// WithStatusDigest returns the Function to be deployed by the image recorded
+// in its status as last deployed, by its digest, such that an image built
+// once is promoted across environments without being rebuilt.  Errors if it
+// was not last deployed by digest.
+func (f Function) WithStatusDigest() (Function, error) {
+	i := strings.LastIndex(f.Status.Image, "@")
+	if i < 0 {
+		return f, fmt.Errorf("function '%v' has no image digest recorded in its status. Deploy it by digest first, such that the digest of the image pushed is recorded", f.Name)
+	}
+	image, digest := f.Status.Image[:i], f.Status.Image[i+1:]
+	if err := ValidateDigest(digest); err != nil {
+		return f, fmt.Errorf("invalid digest '%v' recorded in the status of function '%v': %v", digest, f.Name, err)
+	}
+	f.ImageDigest = digest
+	if f.ImageWithDigest() != f.Status.Image {
+		f.Image = image
+	}
+	return f, nil
+}
+
 // DerivedImage returns the derived image name (OCI container tag) of the
 // Function whose source is at root, with the default registry for when
 // the image has to be calculated (derived).
@@ -152,9 +460,15 @@ func (f Function) ImageWithDigest() string {
 // in form [registry]/[image-name]:[tag]
 // example docker.io/alice/my.example.func:latest
 // Default if not provided is --registry (a required global setting)
-// followed by the provided (or derived) image name.
+// followed by the provided (or derived) image name.  See ImageName.
 func DerivedImage(root, registry string) (image string, err error) {
-	f, err := NewFunction(root)
+	return derivedImage(root, "", registry)
+}
+
+// derivedImage returns the derived image name of the Function whose source
+// is at root and config is the named file.  See DerivedImage.
+func derivedImage(root, file, registry string) (image string, err error) {
+	f, err := NewFunctionFromFile(root, file)
 	if err != nil {
 		// an inability to load the Function means it is not yet initialized
 		// We could try to be smart here and fall through to the Function name
@@ -164,39 +478,223 @@ func DerivedImage(root, registry string) (image string, err error) {
 		return
 	}
 
+	return derivedImageOf(f, registry)
+}
+
+// derivedImageOf the Function, as derivedImage.
+func derivedImageOf(f Function, registry string) (string, error) {
+	// The registry persisted in the Function's configuration is used if one
+	// is not explicitly provided.
+	if registry != "" {
+		f.Registry = registry
+	}
+	return f.ImageName()
+}
+
+// ErrRegistryRequired indicates that the image of a Function can not be
+// derived, being set neither explicitly nor via a registry.
+var ErrRegistryRequired = errors.New("registry or image required. Provide --registry or --image")
+
+// ImageName returns the effective image reference of the Function: Image if
+// set explicitly (or previously derived), otherwise derived from its Registry
+// and Name, followed by its ImageSuffix, if any, with the tag "latest".  A
+// registry of the form 'namespace' is prefixed with DefaultRegistry.  Without
+// a Registry, that inferred from the git remote of the Function's repository
+// is used, if any.  Neither being set nor inferred is ErrRegistryRequired.
+//
+//	form:    [registry]/[namespace]/[function][suffix]:latest
+//	example: quay.io/alice/my.function.name:latest
+//	example: ghcr.io/org/team/my.function.name:latest
+func (f Function) ImageName() (image string, err error) {
 	// If the Function has already had image populated, use this pre-calculated value.
 	if f.Image != "" {
-		image = f.Image
-		return
+		return f.Image, nil
 	}
 
 	// registry is currently required until such time as we support
 	// pushing to an implicitly-available in-cluster registry by default.
+	registry := f.Registry
 	if registry == "" {
-		err = errors.New("Registry name is required.")
-		return
+		if registry = GitRemoteRegistry(f.Root); registry == "" {
+			return "", ErrRegistryRequired
+		}
 	}
 
-	// If the Function loaded, and there is not yet an Image set, then this is
-	// the first build and no explicit image override was specified.  We should
-	// therefore derive the image tag from the defined registry and name.
-	// form:    [registry]/[user]/[function]:latest
-	// example: quay.io/alice/my.function.name:latest
 	registry = strings.Trim(registry, "/") // too defensive?
 	registryTokens := strings.Split(registry, "/")
 	if len(registryTokens) == 1 {
-		image = DefaultRegistry + "/" + registry + "/" + f.Name
-	} else if len(registryTokens) == 2 {
-		image = registry + "/" + f.Name
+		image = DefaultRegistry + "/" + registry + "/" + f.Name + f.ImageSuffix
 	} else {
-		err = fmt.Errorf("registry should be either 'namespace' or 'registry/namespace'")
+		image = registry + "/" + f.Name + f.ImageSuffix
 	}
 
 	// Explicitly append :latest.  We currently expect source control to drive
 	// versioning, rather than rely on Docker Hub tags with explicit version
 	// numbers, as is seen in many serverless solutions.  This will be updated
 	// to branch name when we add source-driven canary/ bluegreen deployments.
-	image = image + ":latest"
+	return image + ":latest", nil
+}
+
+// GitRegistries maps the hosts of git remotes to the container registries in
+// which images of the repositories they host are conventionally pushed, for
+// inferring a Function's registry from its git remote.
+var GitRegistries = map[string]string{
+	"github.com": "ghcr.io",
+}
+
+// GitRemoteRegistry returns the registry inferred from the "origin" remote of
+// the git repository at root, such as "ghcr.io/alice" for the remote
+// "https://github.com/alice/myfunc.git".  It is empty when root is not the
+// root of a git repository, there is no such remote, or its host has no
+// conventional registry.  It is best effort, only a default for when no
+// registry is configured: read from the repository's configuration, without
+// requiring git, and ignoring repositories enclosing root.
+func GitRemoteRegistry(root string) string {
+	if root == "" {
+		return "" // not that of the working directory
+	}
+	bb, err := ioutil.ReadFile(filepath.Join(root, ".git", "config"))
+	if err != nil {
+		return ""
+	}
+	host, owner := gitRemoteOwner(gitOriginURL(string(bb)))
+	if registry, ok := GitRegistries[host]; ok && owner != "" {
+		return registry + "/" + strings.ToLower(owner)
+	}
+	return ""
+}
+
+// gitOriginURL returns the URL of the "origin" remote in the given git
+// config, or the empty string if it has none.
+func gitOriginURL(config string) string {
+	var origin bool
+	for _, line := range strings.Split(config, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			origin = strings.Join(strings.Fields(line), " ") == `[remote "origin"]`
+			continue
+		}
+		if !origin {
+			continue
+		}
+		if kv := strings.SplitN(line, "=", 2); len(kv) == 2 && strings.TrimSpace(kv[0]) == "url" {
+			return strings.TrimSpace(kv[1])
+		}
+	}
+	return ""
+}
+
+// gitRemoteOwner returns the host of the git remote URL and the owner of the
+// repository: for "https://github.com/alice/myfunc.git", or the scp-like
+// "git@github.com:alice/myfunc.git", "github.com" and "alice".
+func gitRemoteOwner(url string) (host, owner string) {
+	if i := strings.Index(url, "://"); i >= 0 {
+		url = url[i+3:]
+	} else if i = strings.Index(url, ":"); i >= 0 {
+		url = url[:i] + "/" + url[i+1:] // scp-like
+	} else {
+		return
+	}
+	if i := strings.LastIndex(strings.SplitN(url, "/", 2)[0], "@"); i >= 0 {
+		url = url[i+1:] // user
+	}
+	parts := strings.Split(url, "/")
+	if len(parts) < 3 {
+		return
+	}
+	host = strings.SplitN(parts[0], ":", 2)[0] // port
+	return strings.ToLower(host), parts[1]
+}
+
+// ScaffoldingFile is the file within RunDataDir marking a Function whose
+// creation is in progress.  It is removed once the Function's configuration
+// has been written, such that the files of a creation which failed part way
+// can be distinguished from files unrelated to a Function.
+const ScaffoldingFile = "scaffolding"
+
+// ErrIncompleteScaffold indicates the directory contains the files of a
+// Function whose creation did not complete.
+var ErrIncompleteScaffold = errors.New("incomplete Function scaffold")
+
+// ErrUnrelatedFiles indicates the directory contains files unrelated to a
+// Function.
+var ErrUnrelatedFiles = errors.New("unrelated files")
+
+// ErrAlreadyCreated indicates the directory contains a Function created with
+// the settings requested, such that creating it again is a no-op.
+var ErrAlreadyCreated = errors.New("function already created")
+
+// ErrConfigMismatch indicates the directory contains a Function created with
+// settings other than those requested.
+var ErrConfigMismatch = errors.New("function already created with a different configuration")
+
+// assertNotCreated ensures the directory does not contain a Function already
+// created from the config file of the given name.  If it does, and its
+// settings are those requested by cfg, an error wrapping ErrAlreadyCreated is
+// returned, and otherwise one wrapping ErrConfigMismatch which lists their
+// differences.  A creation which did not complete is not considered created.
+func assertNotCreated(root, file string, cfg Function) error {
+	if scaffolding(root) {
+		return nil
+	}
+	f, err := NewFunctionFromFile(root, file)
+	if err != nil || f.Runtime == "" {
+		return nil // an invalid or missing config is reported as contentious
+	}
+	if diffs := f.differences(cfg); len(diffs) > 0 {
+		return fmt.Errorf("%w in '%v': %v", ErrConfigMismatch, root, strings.Join(diffs, "; "))
+	}
+	return fmt.Errorf("%w in '%v'", ErrAlreadyCreated, root)
+}
+
+// differences of the settings of the Function from those requested by cfg.
+// The runtime and template default as when creating, and other settings are
+// compared only if requested.
+func (f Function) differences(cfg Function) (diffs []string) {
+	runtime, template := cfg.Runtime, cfg.Template
+	if runtime == "" {
+		runtime = DefaultRuntime
+	}
+	if template == "" {
+		template = DefaultTemplate
+	}
+	compare := func(setting, existing, requested string) {
+		if existing != requested {
+			diffs = append(diffs, fmt.Sprintf("%v is '%v', not '%v'", setting, existing, requested))
+		}
+	}
+	compare("runtime", f.Runtime, runtime)
+	if cfg.RuntimeVersion != "" {
+		compare("runtime version", f.RuntimeVersion, cfg.RuntimeVersion)
+	}
+	compare("template", f.Template, template)
+	if cfg.Name != "" {
+		compare("name", f.Name, cfg.Name)
+	}
+	if cfg.TemplateRef != "" {
+		compare("template ref", f.TemplateRef, cfg.TemplateRef)
+	}
+	if cfg.Entrypoint != "" {
+		compare("entrypoint", f.Entrypoint, cfg.Entrypoint)
+	}
+	if cfg.CI != "" {
+		compare("CI", f.CI, cfg.CI)
+	}
+	if cfg.License != "" {
+		compare("license", f.License, cfg.License)
+	}
+	if cfg.PackageManager != "" {
+		compare("package manager", f.PackageManager, cfg.PackageManager)
+	}
+	if cfg.Builder != "" {
+		compare("builder", f.Builder, cfg.Builder)
+	}
+	if cfg.Registry != "" {
+		compare("registry", f.Registry, cfg.Registry)
+	}
+	if cfg.Image != "" {
+		compare("image", f.Image, cfg.Image)
+	}
 	return
 }
 
@@ -211,17 +709,49 @@ func assertEmptyRoot(path string) (err error) {
 		return fmt.Errorf("The chosen directory '%v' contains contentious files: %v.  Has the Service Function already been created?  Try either using a different directory, deleting the Function if it exists, or manually removing the files.", path, files)
 	}
 
+	// A previous creation which failed part way leaves its marker behind.
+	if scaffolding(path) {
+		return fmt.Errorf("The chosen directory '%v' contains an %w: a previous creation of a Function there did not complete.  It may be completed by creating the Function again, overwriting the existing files.", path, ErrIncompleteScaffold)
+	}
+
 	// Ensure there are no non-hidden files, and again none of the aforementioned contentious files.
 	empty, err := isEffectivelyEmpty(path)
 	if err != nil {
 		return
 	} else if !empty {
-		err = errors.New("The directory must be empty of visible files and recognized config files before it can be initialized.")
+		err = fmt.Errorf("The chosen directory '%v' contains %w.  The directory must be empty of visible files and recognized config files before it can be initialized.", path, ErrUnrelatedFiles)
 		return
 	}
 	return
 }
 
+// scaffolding returns whether or not the creation of a Function at root is
+// in progress, or failed part way.
+func scaffolding(root string) bool {
+	_, err := os.Stat(filepath.Join(root, RunDataDir, ScaffoldingFile))
+	return err == nil
+}
+
+// markScaffolding marks the creation of a Function at root as in progress.
+func markScaffolding(root string) (err error) {
+	dir := filepath.Join(root, RunDataDir)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	return ioutil.WriteFile(filepath.Join(dir, ScaffoldingFile), []byte{}, 0644)
+}
+
+// unmarkScaffolding marks the creation of a Function at root as complete,
+// removing RunDataDir if it is left empty.
+func unmarkScaffolding(root string) error {
+	err := os.Remove(filepath.Join(root, RunDataDir, ScaffoldingFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	_ = os.Remove(filepath.Join(root, RunDataDir)) // fails if not empty
+	return nil
+}
+
 // contentiousFiles are files which, if extant, preclude the creation of a
 // Function rooted in the given directory.
 var contentiousFiles = []string{
@@ -2,7 +2,14 @@
 
 package function
 
-import "testing"
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestFunction_ImageWithDigest(t *testing.T) {
 	type fields struct {
@@ -42,3 +49,146 @@ func TestFunction_ImageWithDigest(t *testing.T) {
 		})
 	}
 }
+
+func TestFunction_ImageName(t *testing.T) {
+	tests := []struct {
+		name     string
+		function Function
+		want     string
+		err      error
+	}{
+		{"explicit image", Function{Name: "myfunc", Image: "example.com/alice/other:v1", Registry: "quay.io/bob"}, "example.com/alice/other:v1", nil},
+		{"registry", Function{Name: "myfunc", Registry: "quay.io/alice"}, "quay.io/alice/myfunc:latest", nil},
+		{"registry nested namespace", Function{Name: "myfunc", Registry: "ghcr.io/org/team"}, "ghcr.io/org/team/myfunc:latest", nil},
+		{"registry namespace only", Function{Name: "myfunc", Registry: "alice"}, DefaultRegistry + "/alice/myfunc:latest", nil},
+		{"image suffix", Function{Name: "myfunc", Registry: "quay.io/alice", ImageSuffix: "-fn"}, "quay.io/alice/myfunc-fn:latest", nil},
+		{"image suffix of explicit image", Function{Name: "myfunc", Image: "example.com/alice/other:v1", ImageSuffix: "-fn"}, "example.com/alice/other:v1", nil},
+		{"neither image nor registry", Function{Name: "myfunc"}, "", ErrRegistryRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.function.ImageName()
+			if err != tt.err {
+				t.Fatalf("ImageName() error = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("ImageName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGitRemoteRegistry ensures the registry is inferred from the origin
+// remote of a repository hosted where images are conventionally pushed, and
+// is used to derive the image only when no registry is configured.
+func TestGitRemoteRegistry(t *testing.T) {
+	root, err := ioutil.TempDir("", "func-git-remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if GitRemoteRegistry(root) != "" {
+		t.Fatal("expected no registry to be inferred outside of a git repository")
+	}
+
+	if err = os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := func(url string) {
+		t.Helper()
+		data := "[core]\n\tbare = false\n[remote \"upstream\"]\n\turl = https://github.com/other/myfunc.git\n[remote \"origin\"]\n\turl = " + url + "\n"
+		if err := ioutil.WriteFile(filepath.Join(root, ".git", "config"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for url, registry := range map[string]string{
+		"https://github.com/Alice/myfunc.git":       "ghcr.io/alice",
+		"git@github.com:alice/myfunc.git":           "ghcr.io/alice",
+		"ssh://git@github.com/alice/myfunc":         "ghcr.io/alice",
+		"https://gitlab.example.com/alice/myfunc":   "",
+		"/home/alice/src/myfunc":                    "",
+		"https://token@github.com:443/alice/myfunc": "ghcr.io/alice",
+	} {
+		config(url)
+		if got := GitRemoteRegistry(root); got != registry {
+			t.Fatalf("GitRemoteRegistry() with remote %v = %q, want %q", url, got, registry)
+		}
+	}
+
+	config("https://github.com/alice/myfunc.git")
+	if image, _ := (Function{Root: root, Name: "myfunc"}).ImageName(); image != "ghcr.io/alice/myfunc:latest" {
+		t.Fatalf("expected the image to be derived from the inferred registry, got %v", image)
+	}
+	if image, _ := (Function{Root: root, Name: "myfunc", Registry: "quay.io/bob"}).ImageName(); image != "quay.io/bob/myfunc:latest" {
+		t.Fatalf("expected the configured registry to take precedence, got %v", image)
+	}
+}
+
+// TestLoad ensures a Function is only loaded from a path containing an
+// initialized Function, the error otherwise naming the path.
+func TestLoad(t *testing.T) {
+	root, err := ioutil.TempDir("", "func-load")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if _, err = Load(root, ""); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("expected ErrNotInitialized without a %v, got %v", ConfigFile, err)
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(root, ConfigFile), []byte("name: myfunc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Load(root, ""); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("expected ErrNotInitialized without a runtime, got %v", err)
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(root, ConfigFile), []byte("name: myfunc\nruntime: go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "myfunc" || f.Root != root {
+		t.Fatalf("unexpected function loaded: %v at '%v'", f.Name, f.Root)
+	}
+}
+
+// TestLoadAll ensures the Functions beneath a root are loaded in order of
+// path, neither the source of a Function nor hidden directories being
+// searched.
+func TestLoadAll(t *testing.T) {
+	root, err := ioutil.TempDir("", "func-load-all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, dir := range []string{"b", "a", "services/c", "a/nested", ".git/d", "docs"} {
+		if err = os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if dir == "docs" {
+			continue
+		}
+		cfg := "name: " + filepath.Base(dir) + "\nruntime: go\n"
+		if err = ioutil.WriteFile(filepath.Join(root, dir, ConfigFile), []byte(cfg), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ff, err := LoadAll(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range ff {
+		names = append(names, f.Name)
+	}
+	if strings.Join(names, ",") != "a,b,c" {
+		t.Fatalf("expected functions a, b and c, got %v", names)
+	}
+}
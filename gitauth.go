@@ -0,0 +1,104 @@
+package function
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrGitNotFound indicates the git repository of a URL does not exist, or
+// is private and not visible to the credentials provided, if any.
+var ErrGitNotFound = errors.New("git repository not found")
+
+// ErrGitUnauthorized indicates access to the git repository of a URL was
+// denied, credentials being either required or rejected.
+var ErrGitUnauthorized = errors.New("unauthorized to access git repository")
+
+// Environment variables with which git repositories of template repositories
+// are authenticated to over HTTP(S): a token, such as a personal access
+// token, and the username with which it is provided.
+const (
+	GitTokenEnv    = "GIT_TOKEN"
+	GitUsernameEnv = "GIT_USERNAME"
+
+	// gitTokenHelper is the credential helper with which the token of
+	// $GIT_TOKEN is provided to git from its environment.
+	gitTokenHelper = `!f() { test "$1" = get && printf 'username=%s\npassword=%s\n' "$GIT_USERNAME" "$GIT_TOKEN"; }; f`
+
+	// defaultGitUsername with which a token is provided if no username is
+	// set, which is accepted by GitHub and, with any token, by GitLab.
+	defaultGitUsername = "x-access-token"
+)
+
+// gitAuth returns the arguments and environment with which git
+// authenticates to the repository at url, non-interactively.  Credentials
+// are resolved in order:
+//
+//  1. For HTTP(S) URLs, the token of $GIT_TOKEN, provided as the password of
+//     $GIT_USERNAME (by default "x-access-token") by a credential helper of
+//     the host of the URL alone.  The helper reads the token from its
+//     environment, such that it is never among the arguments of git, which
+//     are visible to other users of the system.
+//  2. For HTTP(S) URLs, the credentials of ~/.git-credentials, as stored by
+//     git's "store" credential helper.
+//  3. For SSH URLs, the keys of the SSH agent of $SSH_AUTH_SOCK, and then
+//     those configured for ssh.
+//
+// Credential helpers configured for git itself are consulted after these.
+// Neither git nor ssh prompt for credentials, such that failing to
+// authenticate is an error.
+func gitAuth(url string) (args []string, env []string) {
+	env = []string{"GIT_TERMINAL_PROMPT=0"}
+	if os.Getenv("GIT_SSH_COMMAND") == "" && os.Getenv("GIT_SSH") == "" {
+		env = append(env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	}
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return
+	}
+
+	if os.Getenv(GitTokenEnv) != "" {
+		if u, err := neturl.Parse(url); err == nil && u.Host != "" {
+			if os.Getenv(GitUsernameEnv) == "" {
+				env = append(env, GitUsernameEnv+"="+defaultGitUsername)
+			}
+			// The helpers of the host configured otherwise are reset by that
+			// of an empty value, such that the token takes precedence.
+			key := "credential." + u.Scheme + "://" + u.Host + ".helper"
+			return []string{"-c", key + "=", "-c", key + "=" + gitTokenHelper}, env
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if _, err = os.Stat(filepath.Join(home, ".git-credentials")); err == nil {
+			args = []string{"-c", "credential.helper=store"}
+		}
+	}
+	return
+}
+
+// gitRemote runs the git command with the given arguments in dir, as does
+// git, authenticating to the repository at url (see gitAuth).  A failure to
+// find or access the repository is an error wrapping ErrGitNotFound or
+// ErrGitUnauthorized respectively.
+func gitRemote(ctx context.Context, dir, url string, args ...string) (string, error) {
+	auth, env := gitAuth(url)
+	out, err := gitEnv(ctx, dir, env, append(auth, args...)...)
+	if err == nil {
+		return out, nil
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"authentication failed", "could not read username", "could not read password", "permission denied", "invalid username or password", "returned error: 401", "returned error: 403"} {
+		if strings.Contains(msg, s) {
+			return "", fmt.Errorf("%w '%v': %v. Provide credentials with $%v, an SSH agent, or ~/.git-credentials", ErrGitUnauthorized, url, err, GitTokenEnv)
+		}
+	}
+	for _, s := range []string{"not found", "returned error: 404", "does not appear to be a git repository", "does not exist"} {
+		if strings.Contains(msg, s) {
+			return "", fmt.Errorf("%w: '%v': %v", ErrGitNotFound, url, err)
+		}
+	}
+	return "", err
+}
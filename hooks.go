@@ -0,0 +1,142 @@
+package function
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// HooksDir is the directory, within RunDataDir, in which build hook scripts
+// are found by convention when not declared in func.yaml: an executable
+// named after the hook, such as .func/hooks/pre-build.
+const HooksDir = "hooks"
+
+// Build hooks, run before and after the Function is built.
+const (
+	PreBuildHook  = "pre-build"
+	PostBuildHook = "post-build"
+)
+
+// BuildSpec of a Function: the scripts of its project run before and after it
+// is built, for custom build steps such as generating code or bundling assets,
+// and the buildpacks added to its build.  Paths are relative to the Function's
+// root, and must be within it.
+type BuildSpec struct {
+	// PreScript is run before the Function is built.
+	PreScript string `yaml:"preScript,omitempty"`
+	// PostScript is run after the Function is built successfully.
+	PostScript string `yaml:"postScript,omitempty"`
+	// Buildpacks run after the default group of the builder of the Function,
+	// such as an observability buildpack, each as an image, a URI, or an ID
+	// of the buildpack registry (see ValidateBuildpack).
+	Buildpacks []string `yaml:"buildpacks,omitempty"`
+}
+
+// ErrHookFailed is returned when a build hook script fails, aborting the
+// build.
+type ErrHookFailed struct {
+	// Hook which failed, such as "pre-build".
+	Hook string
+	// Script run.
+	Script string
+	// Err with which the script failed, such as its non-zero exit status.
+	Err error
+	// Output of the script when not verbose, which would otherwise be lost.
+	Output string
+}
+
+func (e ErrHookFailed) Error() string {
+	msg := fmt.Sprintf("%v hook '%v' failed: %v", e.Hook, e.Script, e.Err)
+	if e.Output != "" {
+		msg = fmt.Sprintf("%v\noutput: %s\n", msg, e.Output)
+	}
+	return msg
+}
+
+func (e ErrHookFailed) Unwrap() error {
+	return e.Err
+}
+
+// hookScript returns the absolute path of the script of the given build hook,
+// or the empty string if it has none: that declared in func.yaml or,
+// failing that, that found by convention in the hooks directory.  The script
+// must be within the Function's root, including when so by symbolic link,
+// such that building a project can not run scripts outside of it.
+func (f Function) hookScript(hook string) (string, error) {
+	script := f.Build.PreScript
+	if hook == PostBuildHook {
+		script = f.Build.PostScript
+	}
+	if script == "" {
+		script = filepath.Join(RunDataDir, HooksDir, hook)
+		if _, err := os.Stat(filepath.Join(f.Root, script)); os.IsNotExist(err) {
+			return "", nil
+		}
+	}
+	if filepath.IsAbs(script) {
+		return "", fmt.Errorf("%v hook '%v' must be a path relative to the function's root", hook, script)
+	}
+
+	root, err := filepath.EvalSymlinks(f.Root)
+	if err != nil {
+		return "", err
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(root, script))
+	if err != nil {
+		return "", fmt.Errorf("%v hook '%v' not found: %v", hook, script, err)
+	}
+	if rel, err := filepath.Rel(root, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%v hook '%v' must be within the function's root", hook, script)
+	}
+	return path, nil
+}
+
+// runScript runs the script of the given build hook of the Function in its
+// root.  The script is provided the name, runtime and image of the Function
+// in FUNC_NAME, FUNC_RUNTIME and FUNC_IMAGE.  Its output is shown if verbose,
+// and otherwise included in the error should it fail.
+func runScript(ctx context.Context, f Function, hook, script string, verbose bool) (err error) {
+	cmd := exec.CommandContext(ctx, script)
+	cmd.Dir = f.Root
+	cmd.Env = append(os.Environ(),
+		"FUNC_NAME="+f.Name,
+		"FUNC_RUNTIME="+f.Runtime,
+		"FUNC_IMAGE="+f.Image)
+
+	var output bytes.Buffer
+	if verbose {
+		cmd.Stdout = io.MultiWriter(os.Stdout, &output)
+		cmd.Stderr = io.MultiWriter(os.Stderr, &output)
+	} else {
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+	}
+
+	if err = cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		failed := ErrHookFailed{Hook: hook, Script: script, Err: err}
+		if !verbose {
+			failed.Output = output.String()
+		}
+		return failed
+	}
+	return nil
+}
+
+// runHook runs the given build hook of the Function, if any, reporting it to
+// the progress listener.
+func (c *Client) runHook(ctx context.Context, f Function, hook string) error {
+	script, err := f.hookScript(hook)
+	if err != nil || script == "" {
+		return err
+	}
+	c.progressListener.Increment(fmt.Sprintf("Running %v hook", hook))
+	return runScript(ctx, f, hook, script, c.verbose)
+}
@@ -0,0 +1,83 @@
+package function
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// IgnoreFile lists, in gitignore syntax, the files of a Function which are
+// excluded from its source when it is built.
+const IgnoreFile = ".funcignore"
+
+// DefaultIgnores are the patterns of the files excluded from the source of a
+// Function of the given runtime, such as installed dependencies and build
+// output, when it has neither an IgnoreFile nor a .gitignore.
+var DefaultIgnores = map[string][]string{
+	"go":         {},
+	"node":       {"node_modules/"},
+	"python":     {"__pycache__/", "*.pyc", ".venv/"},
+	"quarkus":    {"target/"},
+	"rust":       {"target/"},
+	"springboot": {"target/"},
+	"typescript": {"node_modules/", "build/"},
+}
+
+// IgnorePatterns returns the patterns of the files excluded from the source
+// of the Function.  These are read from its IgnoreFile or, if it has none,
+// its .gitignore.  If neither exists the DefaultIgnores of its runtime are
+// returned.
+func (f Function) IgnorePatterns() ([]string, error) {
+	for _, name := range []string{IgnoreFile, ".gitignore"} {
+		patterns, err := readIgnoreFile(filepath.Join(f.Root, name))
+		if err == nil {
+			return patterns, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return DefaultIgnores[f.Runtime], nil
+}
+
+// Ignorer matches the files of a Function against its ignore patterns,
+// compiled once, such that each file of a build is matched without compiling
+// them again.
+type Ignorer struct {
+	matcher *ignore.GitIgnore
+}
+
+// NewIgnorer returns an Ignorer of the given patterns, such as those of
+// IgnorePatterns.
+func NewIgnorer(patterns []string) Ignorer {
+	return Ignorer{matcher: ignore.CompileIgnoreLines(patterns...)}
+}
+
+// Ignored returns whether the file at path, relative to the Function root and
+// slash separated, is matched by the patterns.  As with gitignore, a later
+// pattern prefixed with "!" includes a file excluded by an earlier one.
+func (i Ignorer) Ignored(path string) bool {
+	return i.matcher.MatchesPath(path)
+}
+
+// readIgnoreFile returns the patterns of the ignore file at path, omitting
+// blank lines and comments.
+func readIgnoreFile(path string) (patterns []string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
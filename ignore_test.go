@@ -0,0 +1,77 @@
+// +build !integration
+
+package function
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestIgnorePatterns ensures patterns are read from the .funcignore, falling
+// back to the .gitignore and then the defaults of the runtime.
+func TestIgnorePatterns(t *testing.T) {
+	root, err := ioutil.TempDir("", "func-ignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	f := Function{Root: root, Runtime: "node"}
+
+	patterns, err := f.IgnorePatterns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(patterns, DefaultIgnores["node"]) {
+		t.Fatalf("expected the runtime defaults, got %v", patterns)
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(root, ".gitignore"), []byte("dist/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if patterns, err = f.IgnorePatterns(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(patterns, []string{"dist/"}) {
+		t.Fatalf("expected the .gitignore patterns, got %v", patterns)
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(root, IgnoreFile), []byte("# dependencies\nnode_modules/\n\n*.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if patterns, err = f.IgnorePatterns(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(patterns, []string{"node_modules/", "*.log"}) {
+		t.Fatalf("expected the %v patterns, got %v", IgnoreFile, patterns)
+	}
+}
+
+// TestIgnored ensures paths are matched as with gitignore, including
+// negation.
+func TestIgnored(t *testing.T) {
+	ignorer := NewIgnorer([]string{"node_modules/", "target/", "*.log", "!keep.log"})
+
+	tests := []struct {
+		path    string
+		ignored bool
+	}{
+		{"node_modules/express/index.js", true},
+		{"lib/node_modules/index.js", true},
+		{"target/classes/App.class", true},
+		{"debug.log", true},
+		{"logs/debug.log", true},
+		{"keep.log", false},
+		{"index.js", false},
+		{"src/target.js", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ignorer.Ignored(tt.path); got != tt.ignored {
+				t.Fatalf("Ignored(%v) = %v, want %v", tt.path, got, tt.ignored)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package function
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// RunDataDir is the directory, relative to a Function's root, in which
+// runtime state of the Function, such as that of a locally running instance,
+// is recorded.
+const RunDataDir = ".func"
+
+// InstanceFile is the file within RunDataDir recording the locally running
+// instance of the Function, if any.
+const InstanceFile = "instance.json"
+
+// ErrNotRunning is returned when reading the local instance of a Function
+// which is not running locally.
+var ErrNotRunning = errors.New("function is not running locally")
+
+// Instance of a Function running locally, as recorded by a Runner for the
+// duration of the run.
+type Instance struct {
+	// Host on which the instance listens.
+	Host string `json:"host"`
+
+	// Port on the host to which the instance is bound.
+	Port string `json:"port"`
+}
+
+// WriteInstance records the locally running instance of the Function at root.
+// The record is ignored by git, by the .gitignore written within RunDataDir
+// if it has none.
+func WriteInstance(root string, i Instance) (err error) {
+	dir := filepath.Join(root, RunDataDir)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	if err = writeRunDataIgnore(dir); err != nil {
+		return
+	}
+	bb, err := json.Marshal(i)
+	if err != nil {
+		return
+	}
+	return ioutil.WriteFile(filepath.Join(dir, InstanceFile), bb, 0644)
+}
+
+// writeRunDataIgnore writes a .gitignore to the RunDataDir dir, unless it has
+// one, ignoring the runtime state recorded within it.  Other files, such as
+// build hooks and golden responses, are committed with the Function.
+func writeRunDataIgnore(dir string) error {
+	path := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(InstanceFile+"\n"), 0644)
+}
+
+// ReadInstance returns the recorded locally running instance of the Function
+// at root.  ErrNotRunning is returned if none is recorded.
+func ReadInstance(root string) (i Instance, err error) {
+	bb, err := ioutil.ReadFile(filepath.Join(root, RunDataDir, InstanceFile))
+	if os.IsNotExist(err) {
+		return i, ErrNotRunning
+	}
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(bb, &i)
+	return
+}
+
+// RemoveInstance removes the record of the locally running instance of the
+// Function at root.  Removing a nonexistent record is not an error.
+func RemoveInstance(root string) error {
+	err := os.Remove(filepath.Join(root, RunDataDir, InstanceFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
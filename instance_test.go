@@ -0,0 +1,50 @@
+// +build !integration
+
+package function
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestInstance ensures that a recorded local instance can be read back, and
+// ignored by git, and that ErrNotRunning is returned before it is recorded and
+// once removed.
+func TestInstance(t *testing.T) {
+	root, err := ioutil.TempDir("", "instance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if _, err = ReadInstance(root); err != ErrNotRunning {
+		t.Fatalf("expected ErrNotRunning, got '%v'", err)
+	}
+
+	expected := Instance{Host: "127.0.0.1", Port: "8081"}
+	if err = WriteInstance(root, expected); err != nil {
+		t.Fatal(err)
+	}
+	i, err := ReadInstance(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != expected {
+		t.Fatalf("expected instance %+v, got %+v", expected, i)
+	}
+	if bb, err := ioutil.ReadFile(filepath.Join(root, RunDataDir, ".gitignore")); err != nil || string(bb) != InstanceFile+"\n" {
+		t.Fatalf("expected the instance to be ignored by git, got %q (%v)", bb, err)
+	}
+
+	if err = RemoveInstance(root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ReadInstance(root); err != ErrNotRunning {
+		t.Fatalf("expected ErrNotRunning after removal, got '%v'", err)
+	}
+	if err = RemoveInstance(root); err != nil {
+		t.Fatalf("expected removing a nonexistent instance to succeed, got '%v'", err)
+	}
+}
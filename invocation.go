@@ -0,0 +1,78 @@
+package function
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SignatureBuildEnv is the build env by which the buildpack of a Function is
+// told the signature with which it is invoked, and thus which function
+// signature framework to wrap it in.  It only takes effect with builders of
+// which the buildpacks honor it: the buildpacks of the default builders infer
+// the signature from the source of the Function, and ignore it.
+const SignatureBuildEnv = "BP_FUNCTION_SIGNATURE"
+
+// Invocation of a Function: the signature with which it is invoked, such as
+// by plain HTTP requests or by CloudEvents.  A Function of which the handler
+// was changed from that of its template declares it rather than have it
+// inferred from its template.
+type Invocation struct {
+	// Signature of the Function: one of Signatures supported by its runtime.
+	Signature string `yaml:"signature,omitempty"`
+}
+
+// Signature of the Function: that of its Invocation, if declared, or else
+// that of its template, if an embedded template named by its signature.
+// Empty if neither.
+func (f Function) Signature() string {
+	if f.Invocation.Signature != "" {
+		return f.Invocation.Signature
+	}
+	for _, s := range Signatures {
+		if f.Template == s {
+			return s
+		}
+	}
+	return ""
+}
+
+// ValidateInvocation ensures the signature of the invocation, if declared, is
+// one supported by the runtime: that of one of its embedded templates, or any
+// of Signatures for a runtime of which no template is embedded.
+func ValidateInvocation(runtime string, invocation Invocation) error {
+	if invocation.Signature == "" {
+		return nil
+	}
+	supported, err := runtimeSignatures(runtime)
+	if err != nil {
+		return err
+	}
+	for _, s := range supported {
+		if s == invocation.Signature {
+			return nil
+		}
+	}
+	return fmt.Errorf("the signature must be one of %v", strings.Join(supported, ", "))
+}
+
+// runtimeSignatures returns the signatures of the embedded templates of the
+// runtime, in the order of Signatures, or Signatures if it has none.
+func runtimeSignatures(runtime string) ([]string, error) {
+	templates, err := listEmbedded()
+	if err != nil {
+		return nil, err
+	}
+	var supported []string
+	for _, s := range Signatures {
+		for _, t := range templates {
+			if t.Runtime == runtime && t.Name == s {
+				supported = append(supported, s)
+				break
+			}
+		}
+	}
+	if len(supported) == 0 {
+		return Signatures, nil
+	}
+	return supported, nil
+}
@@ -2,11 +2,20 @@ package k8s
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strings"
 
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// Explicit client configuration which, when set via SetClientConfig, takes
+// precedence over that resolved from the ambient environment.
+var (
+	kubeconfig  string // path to a kubeconfig file
+	kubecontext string // name of the context to use
+)
 
 func NewKubernetesClientset(namespace string) (*kubernetes.Clientset, error) {
 
@@ -30,8 +39,50 @@ func GetNamespace(defaultNamespace string) (namespace string, err error) {
 	return
 }
 
+// GetClientConfig returns the client configuration resolved from the ambient
+// environment ($KUBECONFIG, ~/.kube/config), with any explicit kubeconfig path
+// or context provided via SetClientConfig taking precedence.
 func GetClientConfig() clientcmd.ClientConfig {
+	return GetClientConfigFor(kubeconfig, kubecontext)
+}
+
+// GetClientConfigFor returns the client configuration loaded from the given
+// kubeconfig path using the given context.  An empty kubeconfig uses the
+// default loading rules ($KUBECONFIG, ~/.kube/config), and an empty context
+// uses the current-context therein.
+func GetClientConfigFor(kubeconfig, context string) clientcmd.ClientConfig {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
 	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		clientcmd.NewDefaultClientConfigLoadingRules(),
-		&clientcmd.ConfigOverrides{})
+		rules,
+		&clientcmd.ConfigOverrides{CurrentContext: context})
+}
+
+// SetClientConfig sets the kubeconfig path and context to be used by all
+// subsequent calls to GetClientConfig.  An explicitly provided kubeconfig
+// path must exist and an explicitly provided context must be defined
+// therein, such that invalid values error before any API call.
+func SetClientConfig(path, context string) error {
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("unable to use kubeconfig '%v': %v", path, err)
+		}
+	}
+	if context != "" {
+		raw, err := GetClientConfigFor(path, "").RawConfig()
+		if err != nil {
+			return fmt.Errorf("unable to load kubeconfig: %v", err)
+		}
+		if _, ok := raw.Contexts[context]; !ok {
+			available := make([]string, 0, len(raw.Contexts))
+			for name := range raw.Contexts {
+				available = append(available, name)
+			}
+			sort.Strings(available)
+			return fmt.Errorf("context '%v' not found. Available contexts: %v", context, strings.Join(available, ", "))
+		}
+	}
+	kubeconfig = path
+	kubecontext = context
+	return nil
 }
@@ -0,0 +1,147 @@
+// +build !integration
+
+package k8s
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testKubeconfig defines two contexts, each with a distinct namespace, such
+// that the effective context can be determined from the resolved namespace.
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://cluster-a.example.com
+  name: cluster-a
+- cluster:
+    server: https://cluster-b.example.com
+  name: cluster-b
+contexts:
+- context:
+    cluster: cluster-a
+    namespace: namespace-a
+  name: context-a
+- context:
+    cluster: cluster-b
+    namespace: namespace-b
+  name: context-b
+current-context: context-a
+`
+
+// TestGetClientConfigFor ensures that an explicit kubeconfig path is used,
+// and that the current-context therein is used unless overridden.
+func TestGetClientConfigFor(t *testing.T) {
+	path := writeKubeconfig(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	tests := []struct {
+		name      string
+		context   string
+		namespace string
+		server    string
+	}{
+		{"current context", "", "namespace-a", "https://cluster-a.example.com"},
+		{"explicit context", "context-b", "namespace-b", "https://cluster-b.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := GetClientConfigFor(path, tt.context)
+
+			namespace, _, err := config.Namespace()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if namespace != tt.namespace {
+				t.Fatalf("expected namespace '%v', got '%v'", tt.namespace, namespace)
+			}
+
+			restConfig, err := config.ClientConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restConfig.Host != tt.server {
+				t.Fatalf("expected server '%v', got '%v'", tt.server, restConfig.Host)
+			}
+		})
+	}
+}
+
+// TestSetClientConfig ensures that the explicit kubeconfig takes precedence
+// over $KUBECONFIG, and that an invalid path errors immediately.
+func TestSetClientConfig(t *testing.T) {
+	path := writeKubeconfig(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	defer SetClientConfig("", "")
+
+	// $KUBECONFIG points to a nonexistent file, which would fail to load if it
+	// were consulted.
+	old, exists := os.LookupEnv("KUBECONFIG")
+	os.Setenv("KUBECONFIG", filepath.Join(filepath.Dir(path), "nonexistent"))
+	defer func() {
+		if exists {
+			os.Setenv("KUBECONFIG", old)
+		} else {
+			os.Unsetenv("KUBECONFIG")
+		}
+	}()
+
+	if err := SetClientConfig(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	namespace, err := GetNamespace("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if namespace != "namespace-a" {
+		t.Fatalf("expected namespace 'namespace-a', got '%v'", namespace)
+	}
+
+	if err := SetClientConfig(filepath.Join(filepath.Dir(path), "invalid"), ""); err == nil {
+		t.Fatal("expected an error setting a nonexistent kubeconfig path")
+	}
+}
+
+// TestSetClientConfigContext ensures that an explicit context overrides the
+// current-context, and that an unknown context errors listing those available.
+func TestSetClientConfigContext(t *testing.T) {
+	path := writeKubeconfig(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	defer SetClientConfig("", "")
+
+	if err := SetClientConfig(path, "context-b"); err != nil {
+		t.Fatal(err)
+	}
+	namespace, err := GetNamespace("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if namespace != "namespace-b" {
+		t.Fatalf("expected namespace 'namespace-b', got '%v'", namespace)
+	}
+
+	err = SetClientConfig(path, "context-c")
+	if err == nil {
+		t.Fatal("expected an error setting an unknown context")
+	}
+	if !strings.Contains(err.Error(), "context-a, context-b") {
+		t.Fatalf("expected error to list available contexts, got '%v'", err)
+	}
+}
+
+func writeKubeconfig(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config")
+	if err = ioutil.WriteFile(path, []byte(testKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
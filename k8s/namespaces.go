@@ -0,0 +1,48 @@
+package k8s
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Label, and its value, identifying resources created by func.
+const (
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedByFunc  = "func"
+)
+
+// NamespaceExists returns whether the namespace of the given name exists.
+func NamespaceExists(ctx context.Context, name string) (bool, error) {
+
+	client, err := NewKubernetesClientset(name)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = client.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CreateNamespace of the given name, labeled as managed by func.
+func CreateNamespace(ctx context.Context, name string) error {
+
+	client, err := NewKubernetesClientset(name)
+	if err != nil {
+		return err
+	}
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{ManagedByLabel: ManagedByFunc},
+		},
+	}
+	_, err = client.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{})
+	return err
+}
@@ -0,0 +1,23 @@
+package k8s
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func GetServiceAccount(ctx context.Context, name, namespaceOverride string) (*corev1.ServiceAccount, error) {
+
+	namespace, err := GetNamespace(namespaceOverride)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := NewKubernetesClientset(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
+}
@@ -0,0 +1,294 @@
+package knative
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	clientdynamic "knative.dev/client/pkg/dynamic"
+	clientservingv1 "knative.dev/client/pkg/serving/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	fn "github.com/boson-project/func"
+)
+
+// NextAnnotation of the Service of a Function which is chained to the next,
+// recording its name, such that its chain is found when described and
+// removed once it is no longer chained.
+const NextAnnotation = "func.boson.dev/next"
+
+// Sequences is the resource of the Knative Eventing Sequences in which
+// Functions are chained.
+var Sequences = schema.GroupVersionResource{Group: "flows.knative.dev", Version: "v1", Resource: "sequences"}
+
+// Triggers is the resource of the Knative Eventing Triggers, which are
+// subscribed to the chain of a chained Function in place of its Service.
+var Triggers = schema.GroupVersionResource{Group: "eventing.knative.dev", Version: "v1beta1", Resource: "triggers"}
+
+// chainName of the Sequence of the chain of the named Function, from it to
+// the end of the chain.
+func chainName(name string) string {
+	return name + "-chain"
+}
+
+// checkNext ensures the next Function of the chain of the Function, if any,
+// is deployed, such that the Function may be chained to it.
+func checkNext(ctx context.Context, client clientservingv1.KnServingClient, namespace string, f fn.Function) error {
+	if f.Next == "" {
+		return nil
+	}
+	_, err := client.GetService(ctx, f.Next)
+	if errors.IsNotFound(err) {
+		return fmt.Errorf("function '%v', the next of the chain of '%v', is not deployed in namespace '%v'. Deploy it first, or deploy both with func all deploy", f.Next, f.Name, namespace)
+	}
+	if err != nil {
+		return fmt.Errorf("knative deployer failed to get the Knative Service of function '%v', the next of the chain of '%v': %v", f.Next, f.Name, err)
+	}
+	return nil
+}
+
+// deployChain chains the deployed Function to the next in a Sequence of its
+// Service, the reply of which is the chain of the next, if it is itself
+// chained, or otherwise the Service of the next.  The next is referenced
+// rather than its steps copied, such that a chain of which the next is later
+// chained, or redeployed, is not stale.  The Sequence is owned by the
+// Service, such that it is deleted with it.
+//
+// Events are routed to the Sequence in place of the Service: the Triggers and
+// sources of which the Service is the subscriber or sink, and the Sequences
+// of which it is the reply, are made those of the Sequence.  A Function which
+// is no longer chained, as was its existing Service, if any, has these routed
+// back to its Service and its Sequence deleted.
+func (d *Deployer) deployChain(ctx context.Context, client clientservingv1.KnServingClient, existing *servingv1.Service, f fn.Function) error {
+	if f.Next == "" && (existing == nil || existing.Annotations[NextAnnotation] == "") {
+		return nil
+	}
+	eventing, err := sourceClient(d.SourceClient, d.Namespace)
+	if err != nil {
+		return err
+	}
+	sequences := eventing.RawClient().Resource(Sequences).Namespace(d.Namespace)
+
+	if f.Next == "" {
+		if err = reroute(ctx, eventing, sequenceStep(chainName(f.Name)), serviceStep(f.Name)); err != nil {
+			return err
+		}
+		err = sequences.Delete(ctx, chainName(f.Name), metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("knative deployer failed to delete the Sequence of the chain of '%v': %v", f.Name, err)
+		}
+		return nil
+	}
+
+	service, err := client.GetService(ctx, f.Name)
+	if err != nil {
+		return fmt.Errorf("knative deployer failed to get the Knative Service: %v", err)
+	}
+	reply, err := chainOf(ctx, sequences, f.Next)
+	if err != nil {
+		return err
+	}
+
+	sequence, err := sequences.Get(ctx, chainName(f.Name), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		sequence = generateSequence(service, reply)
+		if _, err = sequences.Create(ctx, sequence, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("knative deployer failed to create the Sequence of the chain of '%v', which requires Knative Eventing: %v", f.Name, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("knative deployer failed to get the Sequence of the chain of '%v': %v", f.Name, err)
+	} else {
+		if err = unstructured.SetNestedSlice(sequence.Object, []interface{}{serviceStep(f.Name)}, "spec", "steps"); err != nil {
+			return err
+		}
+		if err = unstructured.SetNestedMap(sequence.Object, reply, "spec", "reply"); err != nil {
+			return err
+		}
+		if _, err = sequences.Update(ctx, sequence, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("knative deployer failed to update the Sequence of the chain of '%v': %v", f.Name, err)
+		}
+	}
+	if err = reroute(ctx, eventing, serviceStep(f.Name), sequenceStep(chainName(f.Name))); err != nil {
+		return err
+	}
+	if d.Verbose {
+		if chain, err := describeChain(ctx, eventing, service); err == nil {
+			fmt.Printf("Function is chained: %v\n", strings.Join(chain, " -> "))
+		}
+	}
+	return nil
+}
+
+// chainOf the named Function: the Sequence of its chain, if it is chained,
+// or otherwise its Service.
+func chainOf(ctx context.Context, sequences dynamic.ResourceInterface, name string) (map[string]interface{}, error) {
+	_, err := sequences.Get(ctx, chainName(name), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return serviceStep(name), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("knative deployer failed to get the Sequence of the chain of '%v': %v", name, err)
+	}
+	return sequenceStep(chainName(name)), nil
+}
+
+// reroute the events of the destination from to the destination to: the
+// Triggers of which it is the subscriber, the sources of which it is the
+// sink, and the Sequences of which it is the reply.  Kinds of which the
+// resources may not be listed, as when Knative Eventing has no sources
+// installed, have none.
+func reroute(ctx context.Context, client clientdynamic.KnDynamicClient, from, to map[string]interface{}) error {
+	resources := []schema.GroupVersionResource{Triggers, Sequences}
+	types, err := client.ListSourcesTypes(ctx)
+	if err != nil && !errors.IsNotFound(err) && !errors.IsForbidden(err) {
+		return fmt.Errorf("knative deployer failed to list the kinds of sources: %v", err)
+	}
+	if types != nil {
+		for i := range types.Items {
+			if resource, ok := sourceResource(types.Items[i].UnstructuredContent()); ok {
+				resources = append(resources, resource)
+			}
+		}
+	}
+	for _, resource := range resources {
+		field := "sink"
+		switch resource {
+		case Triggers:
+			field = "subscriber"
+		case Sequences:
+			field = "reply"
+		}
+		items := client.RawClient().Resource(resource).Namespace(client.Namespace())
+		list, err := items.List(ctx, metav1.ListOptions{})
+		if errors.IsNotFound(err) || errors.IsForbidden(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("knative deployer failed to list the %v: %v", resource.Resource, err)
+		}
+		for i := range list.Items {
+			u := &list.Items[i]
+			destination, _, _ := unstructured.NestedMap(u.Object, "spec", field)
+			if !sameDestination(destination, from, u.GetNamespace()) {
+				continue
+			}
+			if err = unstructured.SetNestedMap(u.Object, to, "spec", field); err != nil {
+				return err
+			}
+			if _, err = items.Update(ctx, u, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("knative deployer failed to update the %v of the %v '%v': %v", field, u.GetKind(), u.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// sameDestination returns whether the destination references the resource of
+// the reference ref, a destination in the given namespace.  A reference
+// without an explicit namespace is in the namespace of its referrer.
+func sameDestination(destination, ref map[string]interface{}, namespace string) bool {
+	for _, field := range []string{"apiVersion", "kind", "name"} {
+		a, _, _ := unstructured.NestedString(destination, "ref", field)
+		b, _, _ := unstructured.NestedString(ref, "ref", field)
+		if a != b {
+			return false
+		}
+	}
+	ns, _, _ := unstructured.NestedString(destination, "ref", "namespace")
+	return ns == "" || ns == namespace
+}
+
+// generateSequence returns the Sequence of the chain of the Function of the
+// Service, of which it is the single step, with the given reply, owned by
+// the Service.
+func generateSequence(service *servingv1.Service, reply map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": Sequences.GroupVersion().String(),
+		"kind":       "Sequence",
+		"metadata": map[string]interface{}{
+			"name": chainName(service.Name),
+			"labels": map[string]interface{}{
+				"boson.dev/function": service.Name,
+			},
+			"ownerReferences": []interface{}{
+				map[string]interface{}{
+					"apiVersion": servingv1.SchemeGroupVersion.String(),
+					"kind":       "Service",
+					"name":       service.Name,
+					"uid":        string(service.UID),
+				},
+			},
+		},
+		"spec": map[string]interface{}{
+			"steps": []interface{}{serviceStep(service.Name)},
+			"reply": reply,
+		},
+	}}
+}
+
+// serviceStep of a Sequence, or other destination of events, which is the
+// named Knative Service.
+func serviceStep(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"ref": map[string]interface{}{
+			"apiVersion": servingv1.SchemeGroupVersion.String(),
+			"kind":       "Service",
+			"name":       name,
+		},
+	}
+}
+
+// sequenceStep of a Sequence, or other destination of events, which is the
+// named Sequence.
+func sequenceStep(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"ref": map[string]interface{}{
+			"apiVersion": Sequences.GroupVersion().String(),
+			"kind":       "Sequence",
+			"name":       name,
+		},
+	}
+}
+
+// describeChain returns the names of the Functions of the chain of the
+// Function of the Service, in order from the Function itself, if it is
+// chained, following the reply of the Sequence of each to the next.
+func describeChain(ctx context.Context, client clientdynamic.KnDynamicClient, service *servingv1.Service) ([]string, error) {
+	if service.Annotations[NextAnnotation] == "" {
+		return nil, nil
+	}
+	sequences := client.RawClient().Resource(Sequences).Namespace(client.Namespace())
+	chain := []string{service.Name}
+	seen := map[string]bool{service.Name: true}
+	name := chainName(service.Name)
+	for {
+		sequence, err := sequences.Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			if len(chain) == 1 {
+				return nil, nil
+			}
+			return chain, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("knative describer failed to get the Sequence '%v': %v", name, err)
+		}
+		kind, _, _ := unstructured.NestedString(sequence.Object, "spec", "reply", "ref", "kind")
+		next, _, _ := unstructured.NestedString(sequence.Object, "spec", "reply", "ref", "name")
+		if kind == "Sequence" {
+			next = strings.TrimSuffix(next, "-chain")
+		}
+		if next == "" || seen[next] {
+			return chain, nil
+		}
+		chain = append(chain, next)
+		seen[next] = true
+		if kind != "Sequence" {
+			return chain, nil
+		}
+		name = chainName(next)
+	}
+}
@@ -0,0 +1,89 @@
+// +build !integration
+
+package knative
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_deployChain ensures a chained Function is the step of a Sequence of
+// which the reply is the chain of its next, that the Triggers, sources and
+// upstream Sequences of its Service are routed to the Sequence, that the
+// Sequence is owned by its Service and its chain described in order, and
+// that all are routed back to the Service once the Function is no longer
+// chained, and the Sequence deleted.
+func Test_deployChain(t *testing.T) {
+	next := generateSequence(&servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "b", UID: "b-uid"}}, serviceStep("c"))
+	next.SetNamespace("test")
+	upstream := generateSequence(&servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "z", UID: "z-uid"}}, serviceStep("a"))
+	upstream.SetNamespace("test")
+	trigger := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": Triggers.GroupVersion().String(),
+		"kind":       "Trigger",
+		"metadata":   map[string]interface{}{"name": "orders", "namespace": "test"},
+		"spec":       map[string]interface{}{"broker": "default", "subscriber": serviceStep("a")},
+	}}
+	ping := newSource("test", "PingSource", "heartbeat", serviceStep("a"))
+	client, sources := mockSources(t, "test", next, upstream, trigger, ping, sourceCRD("PingSource"))
+
+	service := &servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "test", UID: "a-uid",
+		Annotations: map[string]string{NextAnnotation: "b"}}}
+	serving, _ := mockServing(t, "test")
+	serving.Recorder().GetService("a", service, nil)
+	d := &Deployer{Namespace: "test", SourceClient: sources}
+	if err := d.deployChain(context.Background(), serving, nil, fn.Function{Name: "a", Next: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	serving.Recorder().Validate()
+
+	sequence, err := client.RawClient().Resource(Sequences).Namespace("test").Get(context.Background(), "a-chain", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owners := sequence.GetOwnerReferences(); len(owners) != 1 || owners[0].Name != "a" || owners[0].UID != "a-uid" {
+		t.Fatalf("expected the Sequence to be owned by the Service, got %+v", owners)
+	}
+	if reply, _, _ := unstructured.NestedMap(sequence.Object, "spec", "reply"); !sameDestination(reply, sequenceStep("b-chain"), "test") {
+		t.Fatalf("expected the reply of the Sequence to be the chain of b, got %v", reply)
+	}
+	chain, err := describeChain(context.Background(), client, service)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(chain, " -> ") != "a -> b -> c" {
+		t.Fatalf("expected the chain a -> b -> c, got %v", chain)
+	}
+	routed := func(resource schema.GroupVersionResource, name, field string, to map[string]interface{}) {
+		t.Helper()
+		u, err := client.RawClient().Resource(resource).Namespace("test").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if destination, _, _ := unstructured.NestedMap(u.Object, "spec", field); !sameDestination(destination, to, "test") {
+			t.Fatalf("expected the %v of %v to be %v, got %v", field, name, to, destination)
+		}
+	}
+	pings := schema.GroupVersionResource{Group: "sources.knative.dev", Version: "v1beta2", Resource: "pingsources"}
+	routed(Triggers, "orders", "subscriber", sequenceStep("a-chain"))
+	routed(pings, "heartbeat", "sink", sequenceStep("a-chain"))
+	routed(Sequences, "z-chain", "reply", sequenceStep("a-chain"))
+
+	if err = d.deployChain(context.Background(), serving, service, fn.Function{Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.RawClient().Resource(Sequences).Namespace("test").Get(context.Background(), "a-chain", metav1.GetOptions{}); err == nil {
+		t.Fatal("expected the Sequence of a function no longer chained to be deleted")
+	}
+	routed(Triggers, "orders", "subscriber", serviceStep("a"))
+	routed(pings, "heartbeat", "sink", serviceStep("a"))
+	routed(Sequences, "z-chain", "reply", serviceStep("a"))
+}
@@ -0,0 +1,62 @@
+package knative
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// changes returns the differences between the existing Service and that to
+// which it would be patched with the desired Service, or none when updating
+// it would change nothing.  Those of every deploy are ignored: the time of
+// the build, of the BUILT env, the cause of the change and the configuration
+// last applied, in which both are recorded.  The Service is compared as
+// patched regardless of whether it would be replaced, such that the fields
+// defaulted by the server do not differ.
+func changes(existing, desired *servingv1.Service) (string, error) {
+	patched, err := patchService(desired)(existing.DeepCopy())
+	if err != nil {
+		return "", err
+	}
+	before, err := comparable(existing)
+	if err != nil {
+		return "", err
+	}
+	after, err := comparable(patched)
+	if err != nil {
+		return "", err
+	}
+	if before == after {
+		return "", nil
+	}
+	return cmp.Diff(strings.Split(before, "\n"), strings.Split(after, "\n")), nil
+}
+
+// comparable renders the labels, annotations and spec of the Service as
+// YAML, without those which differ with every deploy (see changes).
+func comparable(service *servingv1.Service) (string, error) {
+	s := service.DeepCopy()
+	delete(s.Annotations, corev1.LastAppliedConfigAnnotation)
+	delete(s.Spec.Template.Annotations, ChangeCauseAnnotation)
+	s.Spec.Template.Name = ""
+	for i := range s.Spec.Template.Spec.Containers {
+		for j, env := range s.Spec.Template.Spec.Containers[i].Env {
+			if env.Name == "BUILT" {
+				s.Spec.Template.Spec.Containers[i].Env[j].Value = ""
+			}
+		}
+	}
+	out, err := yaml.Marshal(struct {
+		Labels      map[string]string     `json:"labels,omitempty"`
+		Annotations map[string]string     `json:"annotations,omitempty"`
+		Spec        servingv1.ServiceSpec `json:"spec"`
+	}{s.Labels, s.Annotations, s.Spec})
+	if err != nil {
+		return "", fmt.Errorf("knative deployer failed to compare the Knative Service: %v", err)
+	}
+	return string(out), nil
+}
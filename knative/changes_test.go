@@ -0,0 +1,50 @@
+package knative
+
+import (
+	"strings"
+	"testing"
+
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_changes ensures that redeploying an unchanged Function changes nothing,
+// regardless of the time of its build, its change cause and the fields set by
+// the server and others, and that a change of the Function is reported.
+func Test_changes(t *testing.T) {
+	generate := func(image string) *servingv1.Service {
+		t.Helper()
+		service, err := generateNewService(fn.Function{Name: "myfunc", Image: image, Runtime: "go", Annotations: map[string]string{"owner": "alice"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return service
+	}
+
+	existing := generate("example.com/alice/myfunc@sha256:a278a9")
+	setChangeCause(existing, "Fix the handling of empty payloads")
+	if err := withLastApplied(existing); err != nil {
+		t.Fatal(err)
+	}
+	existing.Spec.Template.Spec.Containers[0].Env[0].Value = "20200101T000000"
+	existing.Annotations["example.com/foreign"] = "preserved"
+	timeout := int64(300)
+	existing.Spec.Template.Spec.TimeoutSeconds = &timeout
+	existing.Status.LatestReadyRevisionName = "myfunc-00001"
+
+	diff, err := changes(existing, generate("example.com/alice/myfunc@sha256:a278a9"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != "" {
+		t.Fatalf("expected no changes, got:\n%v", diff)
+	}
+
+	if diff, err = changes(existing, generate("example.com/alice/myfunc@sha256:b389b0")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "sha256:b389b0") {
+		t.Fatalf("expected the change of the image, got:\n%v", diff)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"time"
 
+	"k8s.io/client-go/dynamic"
+	clientdynamic "knative.dev/client/pkg/dynamic"
 	clienteventingv1beta1 "knative.dev/client/pkg/eventing/v1beta1"
 	clientservingv1 "knative.dev/client/pkg/serving/v1"
+	clientservingv1alpha1 "knative.dev/client/pkg/serving/v1alpha1"
 	eventingv1beta1 "knative.dev/eventing/pkg/client/clientset/versioned/typed/eventing/v1beta1"
 	servingv1 "knative.dev/serving/pkg/client/clientset/versioned/typed/serving/v1"
+	servingv1alpha1 "knative.dev/serving/pkg/client/clientset/versioned/typed/serving/v1alpha1"
 
 	"github.com/boson-project/func/k8s"
 )
@@ -16,6 +20,63 @@ const (
 	DefaultWaitingTimeout = 60 * time.Second
 )
 
+// ServingClientFactory returns a Knative Serving client of the given
+// namespace.  Those of the Deployer, Describer, Lister and Remover default to
+// NewServingClient, and may be replaced, such as in tests with the
+// MockKnServingClient of knative.dev/client/pkg/serving/v1 which records the
+// calls expected.
+type ServingClientFactory func(namespace string) (clientservingv1.KnServingClient, error)
+
+// EventingClientFactory returns a Knative Eventing client of the given
+// namespace, defaulting to NewEventingClient.  See ServingClientFactory.
+type EventingClientFactory func(namespace string) (clienteventingv1beta1.KnEventingClient, error)
+
+// DomainMappingClientFactory returns a client of the Knative Serving
+// DomainMappings of the given namespace, defaulting to
+// NewDomainMappingClient.  See ServingClientFactory.
+type DomainMappingClientFactory func(namespace string) (clientservingv1alpha1.KnServingClient, error)
+
+// SourceClientFactory returns a dynamic client of the Knative Eventing
+// sources of the given namespace, such as PingSources, defaulting to
+// NewSourceClient.  See ServingClientFactory.
+type SourceClientFactory func(namespace string) (clientdynamic.KnDynamicClient, error)
+
+// servingClient of the namespace from the given factory, or from
+// NewServingClient if none.
+func servingClient(factory ServingClientFactory, namespace string) (clientservingv1.KnServingClient, error) {
+	if factory == nil {
+		return NewServingClient(namespace)
+	}
+	return factory(namespace)
+}
+
+// eventingClient of the namespace from the given factory, or from
+// NewEventingClient if none.
+func eventingClient(factory EventingClientFactory, namespace string) (clienteventingv1beta1.KnEventingClient, error) {
+	if factory == nil {
+		return NewEventingClient(namespace)
+	}
+	return factory(namespace)
+}
+
+// domainMappingClient of the namespace from the given factory, or from
+// NewDomainMappingClient if none.
+func domainMappingClient(factory DomainMappingClientFactory, namespace string) (clientservingv1alpha1.KnServingClient, error) {
+	if factory == nil {
+		return NewDomainMappingClient(namespace)
+	}
+	return factory(namespace)
+}
+
+// sourceClient of the namespace from the given factory, or from
+// NewSourceClient if none.
+func sourceClient(factory SourceClientFactory, namespace string) (clientdynamic.KnDynamicClient, error) {
+	if factory == nil {
+		return NewSourceClient(namespace)
+	}
+	return factory(namespace)
+}
+
 func NewServingClient(namespace string) (clientservingv1.KnServingClient, error) {
 
 	restConfig, err := k8s.GetClientConfig().ClientConfig()
@@ -33,6 +94,41 @@ func NewServingClient(namespace string) (clientservingv1.KnServingClient, error)
 	return client, nil
 }
 
+// NewServingServices returns the typed Knative Serving client of Services in
+// the given namespace.  Unlike NewServingClient, this exposes the full request
+// options of create and update calls, such as DryRun.
+func NewServingServices(namespace string) (servingv1.ServiceInterface, error) {
+
+	restConfig, err := k8s.GetClientConfig().ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new serving client: %v", err)
+	}
+
+	servingClient, err := servingv1.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new serving client: %v", err)
+	}
+
+	return servingClient.Services(namespace), nil
+}
+
+// NewDomainMappingClient returns a client of the Knative Serving
+// DomainMappings, an alpha API, of the given namespace.
+func NewDomainMappingClient(namespace string) (clientservingv1alpha1.KnServingClient, error) {
+
+	restConfig, err := k8s.GetClientConfig().ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new serving client: %v", err)
+	}
+
+	servingClient, err := servingv1alpha1.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new serving client: %v", err)
+	}
+
+	return clientservingv1alpha1.NewKnServingClient(servingClient, namespace), nil
+}
+
 func NewEventingClient(namespace string) (clienteventingv1beta1.KnEventingClient, error) {
 
 	restConfig, err := k8s.GetClientConfig().ClientConfig()
@@ -49,3 +145,20 @@ func NewEventingClient(namespace string) (clienteventingv1beta1.KnEventingClient
 
 	return client, nil
 }
+
+// NewSourceClient returns a dynamic client of the Knative Eventing sources of
+// the given namespace, of whichever kinds are installed.
+func NewSourceClient(namespace string) (clientdynamic.KnDynamicClient, error) {
+
+	restConfig, err := k8s.GetClientConfig().ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new source client: %v", err)
+	}
+
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new source client: %v", err)
+	}
+
+	return clientdynamic.NewKnDynamicClient(client, namespace), nil
+}
@@ -2,34 +2,125 @@ package knative
 
 import (
 	"context"
+	"encoding/json"
+	goerrors "errors"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
 	"time"
 
+	jsonpatch "github.com/evanphx/json-patch"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/client/pkg/kn/flags"
 	servingclientlib "knative.dev/client/pkg/serving"
-	"knative.dev/client/pkg/wait"
+	clientservingv1 "knative.dev/client/pkg/serving/v1"
+	clientservingv1alpha1 "knative.dev/client/pkg/serving/v1alpha1"
 	"knative.dev/serving/pkg/apis/autoscaling"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 	v1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/yaml"
 
 	fn "github.com/boson-project/func"
 	"github.com/boson-project/func/k8s"
 )
 
+// Dry run modes of the Deployer.
+const (
+	// DryRunNone deploys the function, persisting the Knative Service.
+	DryRunNone = "none"
+	// DryRunClient renders the Knative Service locally without contacting the cluster.
+	DryRunClient = "client"
+	// DryRunServer submits the Knative Service to the cluster without persisting it.
+	DryRunServer = "server"
+)
+
+// DryRunModes lists the valid values of Deployer.DryRun.
+var DryRunModes = []string{DryRunNone, DryRunClient, DryRunServer}
+
 type Deployer struct {
 	// Namespace with which to override that set on the default configuration (such as the ~/.kube/config).
 	// If left blank, deployment will commence to the configured namespace.
 	Namespace string
 	// Verbose logging enablement flag.
 	Verbose bool
+	// DryRun mode.  When "client" or "server", the resultant Knative Service
+	// is written as YAML to Output rather than being persisted.
+	DryRun string
+	// Output to which dry run results are written (defaults to stdout).
+	Output io.Writer
+	// CreateNamespace when deploying to a namespace which does not exist,
+	// rather than failing with ErrNamespaceNotFound.  The namespace is
+	// checked only when it is to be created.
+	CreateNamespace bool
+	// Replace an existing Service with that generated for the Function,
+	// rather than patching only the fields the Function declares, such that
+	// fields set by others, such as their annotations, are reset.
+	Replace bool
+	// WaitCondition of the Service awaited once created or updated, such as
+	// "RoutesReady".  Defaults to DefaultWaitCondition ("Ready").
+	WaitCondition string
+	// WaitForTraffic is the percent of the traffic of the Service awaited to
+	// be routed to the Revision created, once it meets its WaitCondition,
+	// such as 100 for the full cutover of a gradual rollout.  Zero, the
+	// default, does not wait.
+	WaitForTraffic int64
+	// TrafficTimeout within which the Revision created must be routed the
+	// percent of traffic of WaitForTraffic.  Defaults to
+	// DefaultWaitingTimeout.
+	TrafficTimeout time.Duration
+	// IfChanged skips the update of an existing Service when it would change
+	// nothing, such as when redeploying an unchanged Function, such that no
+	// Revision is created.  The changes are written when Verbose.
+	IfChanged bool
+	// ForceLocked updates the Service of a locked Function (see
+	// LockedAnnotation), rather than failing with ErrLocked when the update
+	// would modify it.
+	ForceLocked bool
+	// NoRetryConflict fails the update of an existing Service modified since
+	// it was read, such as by a concurrent deploy, with
+	// ErrModifiedConcurrently, rather than reading it again and reapplying
+	// the update, up to DefaultConflictRetries times.
+	NoRetryConflict bool
+	// ChangeCause of the deploy, such as "Fix the handling of empty
+	// payloads", with which the Revision created is annotated (see
+	// ChangeCauseAnnotation).  Optional.
+	ChangeCause string
+	// Sources of events, such as PingSources, of which the Function is made
+	// the sink once deployed, each referenced as KIND/NAME or by name alone.
+	// Each must exist in the namespace, as is checked before deploying.
+	Sources []string
+	// ServingClient factory, defaulting to NewServingClient.
+	ServingClient ServingClientFactory
+	// DomainMappingClient factory, defaulting to NewDomainMappingClient.
+	DomainMappingClient DomainMappingClientFactory
+	// SourceClient factory, defaulting to NewSourceClient.
+	SourceClient SourceClientFactory
+}
+
+// DefaultConflictRetries is the number of times the update of an existing
+// Service which conflicts with a concurrent modification is retried.
+const DefaultConflictRetries = 3
+
+// ErrModifiedConcurrently is returned when the update of an existing Service
+// conflicts with a modification made since it was read, such as by a
+// concurrent deploy, and is not retried or has exhausted its retries.
+var ErrModifiedConcurrently = goerrors.New("the Knative Service was modified concurrently")
+
+// ErrNamespaceNotFound is returned when deploying to a namespace which does
+// not exist, unless the Deployer is to create it.
+type ErrNamespaceNotFound struct {
+	Namespace string
+}
+
+func (e ErrNamespaceNotFound) Error() string {
+	return fmt.Sprintf("namespace '%v' does not exist", e.Namespace)
 }
 
 func NewDeployer(namespaceOverride string) (deployer *Deployer, err error) {
@@ -44,23 +135,60 @@ func NewDeployer(namespaceOverride string) (deployer *Deployer, err error) {
 
 func (d *Deployer) Deploy(ctx context.Context, f fn.Function) (result fn.DeploymentResult, err error) {
 
-	client, err := NewServingClient(d.Namespace)
+	switch d.DryRun {
+	case "", DryRunNone:
+	case DryRunClient, DryRunServer:
+		return fn.DeploymentResult{}, d.dryRun(ctx, f)
+	default:
+		return fn.DeploymentResult{}, fmt.Errorf("invalid dry run mode '%v'. Must be one of: %v", d.DryRun, strings.Join(DryRunModes, ", "))
+	}
+
+	client, err := servingClient(d.ServingClient, d.Namespace)
 	if err != nil {
 		return fn.DeploymentResult{}, err
 	}
 
-	_, err = client.GetService(ctx, f.Name)
+	domains, err := domainMappingClient(d.DomainMappingClient, d.Namespace)
+	if err != nil {
+		return fn.DeploymentResult{}, err
+	}
+
+	if d.CreateNamespace {
+		if _, err = d.ensureNamespace(ctx); err != nil {
+			return fn.DeploymentResult{}, err
+		}
+	}
+
+	if err = checkDomain(ctx, domains, f); err != nil {
+		return fn.DeploymentResult{}, err
+	}
+
+	sources, err := d.findSources(ctx)
+	if err != nil {
+		return fn.DeploymentResult{}, err
+	}
+
+	if err = checkNext(ctx, client, d.Namespace, f); err != nil {
+		return fn.DeploymentResult{}, err
+	}
+
+	d.checkPullSecret(ctx, f)
+	d.checkServiceAccount(ctx, f)
+	d.checkVolumes(ctx, f)
+
+	existing, err := client.GetService(ctx, f.Name)
 	if err != nil {
 		if errors.IsNotFound(err) {
 
 			referencedSecrets := sets.NewString()
 			referencedConfigMaps := sets.NewString()
 
-			service, err := generateNewService(f.Name, f.ImageWithDigest(), f.Runtime, f.Envs, f.Volumes, f.Annotations, f.Options)
+			service, err := generateNewService(f)
 			if err != nil {
 				err = fmt.Errorf("knative deployer failed to generate the Knative Service: %v", err)
 				return fn.DeploymentResult{}, err
 			}
+			setChangeCause(service, d.ChangeCause)
 
 			err = checkSecretsConfigMapsArePresent(ctx, d.Namespace, &referencedSecrets, &referencedConfigMaps)
 			if err != nil {
@@ -68,18 +196,23 @@ func (d *Deployer) Deploy(ctx context.Context, f fn.Function) (result fn.Deploym
 				return fn.DeploymentResult{}, err
 			}
 
+			if err = withLastApplied(service); err != nil {
+				return fn.DeploymentResult{}, err
+			}
+			if err = setRevision(service, service, f); err != nil {
+				return fn.DeploymentResult{}, fmt.Errorf("knative deployer failed to name the revision: %v", err)
+			}
+
 			err = client.CreateService(ctx, service)
+			if isNamespaceNotFound(err) {
+				return fn.DeploymentResult{}, ErrNamespaceNotFound{Namespace: d.Namespace}
+			}
 			if err != nil {
 				err = fmt.Errorf("knative deployer failed to deploy the Knative Service: %v", err)
 				return fn.DeploymentResult{}, err
 			}
 
-			if d.Verbose {
-				fmt.Println("Waiting for Knative Service to become ready")
-			}
-			err, _ = client.WaitForService(ctx, f.Name, DefaultWaitingTimeout, wait.NoopMessageCallback())
-			if err != nil {
-				err = fmt.Errorf("knative deployer failed to wait for the Knative Service to become ready: %v", err)
+			if err = d.wait(ctx, client, f); err != nil {
 				return fn.DeploymentResult{}, err
 			}
 
@@ -90,9 +223,28 @@ func (d *Deployer) Deploy(ctx context.Context, f fn.Function) (result fn.Deploym
 			}
 
 			fmt.Println("Function deployed at URL: " + route.Status.URL.String())
+
+			if err = d.deployDomain(ctx, client, domains, f); err != nil {
+				return fn.DeploymentResult{}, err
+			}
+			if err = d.deployChain(ctx, client, nil, f); err != nil {
+				return fn.DeploymentResult{}, err
+			}
+			if err = d.sinkSources(ctx, sources, f); err != nil {
+				return fn.DeploymentResult{}, err
+			}
+
+			revision, err := latestRevision(ctx, client, f.Name)
+			if err != nil {
+				return fn.DeploymentResult{}, err
+			}
+			if err = d.waitTraffic(ctx, client, f, revision); err != nil {
+				return fn.DeploymentResult{}, err
+			}
 			return fn.DeploymentResult{
-				Status: fn.Deployed,
-				URL:    route.Status.URL.String(),
+				Status:   fn.Deployed,
+				URL:      route.Status.URL.String(),
+				Revision: revision,
 			}, nil
 
 		} else {
@@ -101,28 +253,50 @@ func (d *Deployer) Deploy(ctx context.Context, f fn.Function) (result fn.Deploym
 		}
 	} else {
 		// Update the existing Service
-		referencedSecrets := sets.NewString()
-		referencedConfigMaps := sets.NewString()
-
-		newEnv, newEnvFrom, err := processEnvs(f.Envs, &referencedSecrets, &referencedConfigMaps)
-		if err != nil {
+		if err = d.checkReferences(ctx, f); err != nil {
 			return fn.DeploymentResult{}, err
 		}
 
-		newVolumes, newVolumeMounts, err := processVolumes(f.Volumes, &referencedSecrets, &referencedConfigMaps)
+		service, err := generateNewService(f)
 		if err != nil {
+			err = fmt.Errorf("knative deployer failed to generate the Knative Service: %v", err)
 			return fn.DeploymentResult{}, err
 		}
+		setChangeCause(service, d.ChangeCause)
 
-		err = checkSecretsConfigMapsArePresent(ctx, d.Namespace, &referencedSecrets, &referencedConfigMaps)
-		if err != nil {
-			err = fmt.Errorf("knative deployer failed to update the Knative Service: %v", err)
+		// The Service of a locked Function is left as is, unless forced, and
+		// updated only when it would change, as with IfChanged.
+		if !d.ForceLocked {
+			if err = checkLocked(existing, service, f); err != nil {
+				return fn.DeploymentResult{}, err
+			}
+		}
+		if d.IfChanged || locked(existing, f) {
+			diff, err := changes(existing, service)
+			if err != nil {
+				return fn.DeploymentResult{}, err
+			}
+			if diff == "" {
+				if d.Verbose {
+					fmt.Println("No changes to the Knative Service, skipping the update")
+				}
+				return d.unchanged(ctx, client, domains, sources, f, existing)
+			}
+			if d.Verbose {
+				fmt.Printf("Changes to the Knative Service (-deployed +function):\n%v", diff)
+			}
+		}
+
+		retries := DefaultConflictRetries
+		if d.NoRetryConflict {
+			retries = 0
+		}
+		if err = updateWithRetry(ctx, client, existing, withRevision(d.updateService(service), f), retries); err != nil {
+			err = fmt.Errorf("knative deployer failed to update the Knative Service: %w", err)
 			return fn.DeploymentResult{}, err
 		}
 
-		_, err = client.UpdateServiceWithRetry(ctx, f.Name, updateService(f.ImageWithDigest(), newEnv, newEnvFrom, newVolumes, newVolumeMounts, f.Annotations, f.Options), 3)
-		if err != nil {
-			err = fmt.Errorf("knative deployer failed to update the Knative Service: %v", err)
+		if err = d.wait(ctx, client, f); err != nil {
 			return fn.DeploymentResult{}, err
 		}
 
@@ -132,13 +306,378 @@ func (d *Deployer) Deploy(ctx context.Context, f fn.Function) (result fn.Deploym
 			return fn.DeploymentResult{}, err
 		}
 
+		if err = d.deployDomain(ctx, client, domains, f); err != nil {
+			return fn.DeploymentResult{}, err
+		}
+		if err = d.deployChain(ctx, client, existing, f); err != nil {
+			return fn.DeploymentResult{}, err
+		}
+		if err = d.sinkSources(ctx, sources, f); err != nil {
+			return fn.DeploymentResult{}, err
+		}
+
+		revision, err := latestRevision(ctx, client, f.Name)
+		if err != nil {
+			return fn.DeploymentResult{}, err
+		}
+		if err = d.waitTraffic(ctx, client, f, revision); err != nil {
+			return fn.DeploymentResult{}, err
+		}
 		return fn.DeploymentResult{
-			Status: fn.Updated,
-			URL:    route.Status.URL.String(),
+			Status:   fn.Updated,
+			URL:      route.Status.URL.String(),
+			Revision: revision,
 		}, nil
 	}
 }
 
+// unchanged returns the result of a deploy which leaves the existing Service
+// as is, ensuring only the mapping of the Function's domain, the sinks of its
+// sources and its chain, which are not of the Service.
+func (d *Deployer) unchanged(ctx context.Context, client clientservingv1.KnServingClient, domains clientservingv1alpha1.KnServingClient, sources []source, f fn.Function, existing *servingv1.Service) (fn.DeploymentResult, error) {
+	if err := d.deployDomain(ctx, client, domains, f); err != nil {
+		return fn.DeploymentResult{}, err
+	}
+	if err := d.deployChain(ctx, client, existing, f); err != nil {
+		return fn.DeploymentResult{}, err
+	}
+	if err := d.sinkSources(ctx, sources, f); err != nil {
+		return fn.DeploymentResult{}, err
+	}
+	result := fn.DeploymentResult{
+		Status:   fn.Unchanged,
+		Revision: existing.Status.LatestReadyRevisionName,
+	}
+	if existing.Status.URL != nil {
+		result.URL = existing.Status.URL.String()
+	}
+	return result, nil
+}
+
+// latestRevision returns the name of the latest Revision of the Function's
+// Service, that created by the deploy.
+func latestRevision(ctx context.Context, client clientservingv1.KnServingClient, name string) (string, error) {
+	service, err := client.GetService(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("knative deployer failed to get the Knative Service: %v", err)
+	}
+	return service.Status.LatestCreatedRevisionName, nil
+}
+
+// wait for the Deployer's WaitCondition of the Function's Service.
+func (d *Deployer) wait(ctx context.Context, client clientservingv1.KnServingClient, f fn.Function) error {
+	condition := d.WaitCondition
+	if condition == "" {
+		condition = DefaultWaitCondition
+	}
+	if d.Verbose {
+		fmt.Printf("Waiting for condition '%v' of the Knative Service\n", condition)
+	}
+	if err := waitForCondition(ctx, client, f.Name, condition, DefaultWaitingTimeout); err != nil {
+		return fmt.Errorf("knative deployer failed to wait for the Knative Service: %v", err)
+	}
+	return nil
+}
+
+// waitTraffic waits for the percent of the traffic of the Function's Service
+// of the Deployer's WaitForTraffic, if any, to be routed to the revision.
+func (d *Deployer) waitTraffic(ctx context.Context, client clientservingv1.KnServingClient, f fn.Function, revision string) error {
+	if d.WaitForTraffic <= 0 {
+		return nil
+	}
+	timeout := d.TrafficTimeout
+	if timeout <= 0 {
+		timeout = DefaultWaitingTimeout
+	}
+	if d.Verbose {
+		fmt.Printf("Waiting for %v%% of the traffic of the Knative Service to be routed to revision '%v'\n", d.WaitForTraffic, revision)
+	}
+	if err := waitForTraffic(ctx, client, f.Name, revision, d.WaitForTraffic, timeout); err != nil {
+		return fmt.Errorf("knative deployer failed to wait for the traffic of the Knative Service: %v", err)
+	}
+	return nil
+}
+
+// deployDomain maps the Function's domain, if any, to its deployed Service,
+// removing the mappings of domains it was previously deployed with.  Those
+// of a Function which neither has a domain nor was last deployed with one,
+// as recorded in its status, are not listed, such that deploying most
+// Functions requests no DomainMappings at all.
+func (d *Deployer) deployDomain(ctx context.Context, client clientservingv1.KnServingClient, domains clientservingv1alpha1.KnServingClient, f fn.Function) error {
+	if f.Domain == "" && f.Status.Domain == "" {
+		return nil
+	}
+	if err := removeStaleDomains(ctx, domains, f); err != nil {
+		return err
+	}
+	if f.Domain == "" {
+		return nil
+	}
+	service, err := client.GetService(ctx, f.Name)
+	if err != nil {
+		return fmt.Errorf("knative deployer failed to get the Knative Service: %v", err)
+	}
+	url, err := mapDomain(ctx, domains, f, service)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Function mapped to domain at URL: " + url)
+	return nil
+}
+
+// dryRun writes the Knative Service which would result from deploying the
+// Function as YAML (see render).
+func (d *Deployer) dryRun(ctx context.Context, f fn.Function) (err error) {
+	out, err := d.render(ctx, f)
+	if err != nil {
+		return
+	}
+	output := d.Output
+	if output == nil {
+		output = os.Stdout
+	}
+	_, err = output.Write(out)
+	return
+}
+
+// PlanDeploy returns the steps of deploying the Function: the applying of its
+// Knative Service, with its manifest as rendered for a dry run, and of the
+// DomainMapping of its domain, if any.
+func (d *Deployer) PlanDeploy(ctx context.Context, f fn.Function) ([]fn.PlanStep, error) {
+	out, err := d.render(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	steps := []fn.PlanStep{{
+		Action: "apply",
+		Target: fmt.Sprintf("Knative Service %v/%v", d.Namespace, f.Name),
+		Detail: string(out),
+	}}
+	if f.Domain != "" {
+		steps = append(steps, fn.PlanStep{
+			Action: "apply",
+			Target: fmt.Sprintf("DomainMapping %v/%v", d.Namespace, f.Domain),
+		})
+	}
+	sink := fmt.Sprintf("Knative Service %v/%v", d.Namespace, f.Name)
+	if f.Next != "" {
+		sink = fmt.Sprintf("Sequence %v/%v", d.Namespace, chainName(f.Name))
+	}
+	for _, ref := range d.Sources {
+		steps = append(steps, fn.PlanStep{
+			Action: "patch",
+			Target: fmt.Sprintf("source %v/%v", d.Namespace, ref),
+			Detail: "sink: " + sink,
+		})
+	}
+	if f.Next != "" {
+		steps = append(steps, fn.PlanStep{
+			Action: "apply",
+			Target: fmt.Sprintf("Sequence %v/%v", d.Namespace, chainName(f.Name)),
+			Detail: fmt.Sprintf("steps: Knative Service %v/%v, reply: the chain of %v", d.Namespace, f.Name, f.Next),
+		})
+	}
+	return steps, nil
+}
+
+// render the Knative Service which would result from deploying the Function
+// as YAML.  In server mode it is submitted to the cluster with all stages of
+// the request dry run, such that the output is that which the server would
+// persist.  Otherwise the Service is generated locally.
+func (d *Deployer) render(ctx context.Context, f fn.Function) ([]byte, error) {
+	service, err := generateNewService(f)
+	if err != nil {
+		return nil, fmt.Errorf("knative deployer failed to generate the Knative Service: %v", err)
+	}
+	setChangeCause(service, d.ChangeCause)
+	service.Namespace = d.Namespace
+
+	if d.DryRun == DryRunServer {
+		if service, err = d.dryRunServer(ctx, f, service); err != nil {
+			return nil, err
+		}
+	} else if err = setRevision(service, service, f); err != nil {
+		return nil, fmt.Errorf("knative deployer failed to name the revision: %v", err)
+	}
+
+	// Objects returned from typed clients lack TypeMeta.
+	service.APIVersion = v1.SchemeGroupVersion.String()
+	service.Kind = "Service"
+
+	out, err := yaml.Marshal(service)
+	if err != nil {
+		return nil, fmt.Errorf("knative deployer failed to render the Knative Service: %v", err)
+	}
+	return out, nil
+}
+
+// dryRunServer submits the given Service as a create or, if it already
+// exists, as an update of the existing Service, requesting that no changes
+// be persisted.  The Service as it would be persisted by the server is returned.
+// For a Deployer which is to create the namespace, which does not exist, the
+// Service can not be submitted, and is returned as generated.
+func (d *Deployer) dryRunServer(ctx context.Context, f fn.Function, service *servingv1.Service) (*servingv1.Service, error) {
+	services, err := NewServingServices(d.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.CreateNamespace {
+		missing, err := d.ensureNamespace(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if missing {
+			if d.Verbose {
+				fmt.Printf("Namespace %v would be created\n", d.Namespace)
+			}
+			if err = setRevision(service, service, f); err != nil {
+				return nil, fmt.Errorf("knative deployer failed to name the revision: %v", err)
+			}
+			return service, nil
+		}
+	}
+
+	dryRun := []string{metav1.DryRunAll}
+
+	d.checkPullSecret(ctx, f)
+	d.checkServiceAccount(ctx, f)
+	d.checkVolumes(ctx, f)
+
+	existing, err := services.Get(ctx, f.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if err = d.checkReferences(ctx, f); err != nil {
+			return nil, err
+		}
+		if err = setRevision(service, service, f); err != nil {
+			return nil, fmt.Errorf("knative deployer failed to name the revision: %v", err)
+		}
+		service, err = services.Create(ctx, service, metav1.CreateOptions{DryRun: dryRun})
+		if isNamespaceNotFound(err) {
+			return nil, ErrNamespaceNotFound{Namespace: d.Namespace}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("knative deployer failed to dry run the creation of the Knative Service: %v", err)
+		}
+		return service, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("knative deployer failed to get the Knative Service: %v", err)
+	}
+
+	if err = d.checkReferences(ctx, f); err != nil {
+		return nil, err
+	}
+	updated, err := withRevision(d.updateService(service), f)(existing)
+	if err != nil {
+		return nil, fmt.Errorf("knative deployer failed to update the Knative Service: %v", err)
+	}
+	service, err = services.Update(ctx, updated, metav1.UpdateOptions{DryRun: dryRun})
+	if err != nil {
+		return nil, fmt.Errorf("knative deployer failed to dry run the update of the Knative Service: %v", err)
+	}
+	return service, nil
+}
+
+// checkReferences ensures the Secrets and ConfigMaps referenced by the
+// Function's envs, and those of its init containers, are present in the cluster.  Those of its volumes are
+// checked by checkVolumes.
+func (d *Deployer) checkReferences(ctx context.Context, f fn.Function) (err error) {
+	referencedSecrets := sets.NewString()
+	referencedConfigMaps := sets.NewString()
+
+	if _, _, err = processEnvs(f.Envs, &referencedSecrets, &referencedConfigMaps); err != nil {
+		return
+	}
+	if _, err = processInitContainers(f.InitContainers, &referencedSecrets, &referencedConfigMaps); err != nil {
+		return
+	}
+	err = checkSecretsConfigMapsArePresent(ctx, d.Namespace, &referencedSecrets, &referencedConfigMaps)
+	if err != nil {
+		err = fmt.Errorf("knative deployer failed to generate the Knative Service: %v", err)
+	}
+	return
+}
+
+// checkPullSecret warns if the Function's pull secret is not present in the
+// namespace.  This is not an error, as the Secret may be created later, until
+// which time the Function's image can not be pulled.
+func (d *Deployer) checkPullSecret(ctx context.Context, f fn.Function) {
+	if f.PullSecret == "" {
+		return
+	}
+	if _, err := k8s.GetSecret(ctx, f.PullSecret, d.Namespace); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: pull secret \"%s\" is not present in namespace \"%s\". The Function's image can not be pulled until it is created.\n", f.PullSecret, d.Namespace)
+	}
+}
+
+// checkVolumes warns of each Secret and ConfigMap mounted as a volume of the
+// Function which is not present in the namespace.  This is not an error, as
+// they may be created later, until which time the Function can not run.
+func (d *Deployer) checkVolumes(ctx context.Context, f fn.Function) {
+	for _, v := range f.Volumes {
+		if v.Secret != nil {
+			if _, err := k8s.GetSecret(ctx, *v.Secret, d.Namespace); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Secret \"%s\" mounted at \"%s\" is not present in namespace \"%s\". The Function can not run until it is created.\n", *v.Secret, *v.Path, d.Namespace)
+			}
+		} else if v.ConfigMap != nil {
+			if _, err := k8s.GetConfigMap(ctx, *v.ConfigMap, d.Namespace); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: ConfigMap \"%s\" mounted at \"%s\" is not present in namespace \"%s\". The Function can not run until it is created.\n", *v.ConfigMap, *v.Path, d.Namespace)
+			}
+		}
+	}
+}
+
+// ensureNamespace ensures the namespace to which the Function is deployed
+// exists, creating it if it does not, for a Deployer which is to create it.
+// A namespace which may not be read is presumed to exist, as is the case for
+// users whose access is limited to the namespace itself.  During a dry run
+// it is not created, returning whether it would be.
+func (d *Deployer) ensureNamespace(ctx context.Context) (missing bool, err error) {
+	exists, err := k8s.NamespaceExists(ctx, d.Namespace)
+	if errors.IsForbidden(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("knative deployer failed to check the namespace: %v", err)
+	}
+	if exists {
+		return false, nil
+	}
+	if d.DryRun == DryRunClient || d.DryRun == DryRunServer {
+		return true, nil
+	}
+	if err = k8s.CreateNamespace(ctx, d.Namespace); err != nil {
+		return false, fmt.Errorf("knative deployer failed to create the namespace: %v", err)
+	}
+	if d.Verbose {
+		fmt.Printf("Created namespace %v\n", d.Namespace)
+	}
+	return false, nil
+}
+
+// isNamespaceNotFound returns whether err is that of creating a resource in a
+// namespace which does not exist.
+func isNamespaceNotFound(err error) bool {
+	var status errors.APIStatus
+	if !errors.IsNotFound(err) || !goerrors.As(err, &status) {
+		return false
+	}
+	details := status.Status().Details
+	return details != nil && details.Kind == "namespaces"
+}
+
+// checkServiceAccount warns if the Function's service account is not present
+// in the namespace.  This is not an error, as the ServiceAccount may be
+// created later, until which time the Function's revision can not be created.
+func (d *Deployer) checkServiceAccount(ctx context.Context, f fn.Function) {
+	if f.ServiceAccount == "" {
+		return
+	}
+	if _, err := k8s.GetServiceAccount(ctx, f.ServiceAccount, d.Namespace); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: service account \"%s\" is not present in namespace \"%s\". The Function can not run until it is created.\n", f.ServiceAccount, d.Namespace)
+	}
+}
+
 func probeFor(url string) *corev1.Probe {
 	return &corev1.Probe{
 		Handler: corev1.Handler{
@@ -149,50 +688,105 @@ func probeFor(url string) *corev1.Probe {
 	}
 }
 
-func generateNewService(name, image, runtime string, envs fn.Envs, volumes fn.Volumes, annotations map[string]string, options fn.Options) (*servingv1.Service, error) {
+// Default paths of the health probes of runtimes other than quarkus, which
+// has none by default.
+const (
+	DefaultLivenessPath  = "/health/liveness"
+	DefaultReadinessPath = "/health/readiness"
+)
+
+// setProbes sets the liveness and readiness probes of the container: those
+// of the runtime, if any, overridden by those configured.
+func setProbes(container *corev1.Container, runtime string, health fn.Health) {
+	container.LivenessProbe, container.ReadinessProbe = nil, nil
+	if runtime != "quarkus" {
+		container.LivenessProbe = probeFor(DefaultLivenessPath)
+		container.ReadinessProbe = probeFor(DefaultReadinessPath)
+	}
+	container.LivenessProbe = configureProbe(container.LivenessProbe, health.Liveness, DefaultLivenessPath)
+	container.ReadinessProbe = configureProbe(container.ReadinessProbe, health.Readiness, DefaultReadinessPath)
+}
+
+// configureProbe returns the probe with the configuration applied, if any.
+// A configured probe without a path, where the runtime has no probe, uses
+// the default path.
+func configureProbe(probe *corev1.Probe, config *fn.Probe, defaultPath string) *corev1.Probe {
+	if config == nil {
+		return probe
+	}
+	if probe == nil {
+		probe = probeFor(defaultPath)
+	}
+	if config.Path != nil {
+		probe.HTTPGet.Path = *config.Path
+	}
+	if config.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = *config.InitialDelaySeconds
+	}
+	if config.PeriodSeconds != nil {
+		probe.PeriodSeconds = *config.PeriodSeconds
+	}
+	return probe
+}
+
+// generateNewService returns the Knative Service of the Function, of its image
+// with its digest, if any, and of the envs and annotations of its Service.
+// See serviceEnvs and serviceAnnotations.
+func generateNewService(f fn.Function) (*servingv1.Service, error) {
 	containers := []corev1.Container{
 		{
-			Image: image,
+			Image:           f.ImageWithDigest(),
+			ImagePullPolicy: corev1.PullPolicy(f.ImagePullPolicy),
 		},
 	}
 
-	if runtime != "quarkus" {
-		containers[0].LivenessProbe = probeFor("/health/liveness")
-		containers[0].ReadinessProbe = probeFor("/health/readiness")
+	if f.Port != 0 {
+		containers[0].Ports = []corev1.ContainerPort{{ContainerPort: int32(f.Port)}}
 	}
 
+	setProbes(&containers[0], f.Runtime, f.Health)
+
 	referencedSecrets := sets.NewString()
 	referencedConfigMaps := sets.NewString()
 
-	newEnv, newEnvFrom, err := processEnvs(envs, &referencedSecrets, &referencedConfigMaps)
+	newEnv, newEnvFrom, err := processEnvs(serviceEnvs(f), &referencedSecrets, &referencedConfigMaps)
 	if err != nil {
 		return nil, err
 	}
 	containers[0].Env = newEnv
 	containers[0].EnvFrom = newEnvFrom
 
-	newVolumes, newVolumeMounts, err := processVolumes(volumes, &referencedSecrets, &referencedConfigMaps)
+	newVolumes, newVolumeMounts, err := processVolumes(f.Volumes, &referencedSecrets, &referencedConfigMaps)
 	if err != nil {
 		return nil, err
 	}
 	containers[0].VolumeMounts = newVolumeMounts
 
+	newInitContainers, err := processInitContainers(f.InitContainers, &referencedSecrets, &referencedConfigMaps)
+	if err != nil {
+		return nil, err
+	}
+
 	service := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
+			Name: f.Name,
 			Labels: map[string]string{
 				"boson.dev/function": "true",
-				"boson.dev/runtime":  runtime,
+				"boson.dev/runtime":  f.Runtime,
 			},
-			Annotations: annotations,
+			Annotations: serviceAnnotations(f),
 		},
 		Spec: v1.ServiceSpec{
 			ConfigurationSpec: v1.ConfigurationSpec{
 				Template: v1.RevisionTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: templateAnnotations(f.Mesh, f.Metrics),
+					},
 					Spec: v1.RevisionSpec{
 						PodSpec: corev1.PodSpec{
-							Containers: containers,
-							Volumes:    newVolumes,
+							InitContainers: newInitContainers,
+							Containers:     containers,
+							Volumes:        newVolumes,
 						},
 					},
 				},
@@ -200,7 +794,10 @@ func generateNewService(name, image, runtime string, envs fn.Envs, volumes fn.Vo
 		},
 	}
 
-	err = setServiceOptions(&service.Spec.Template, options)
+	flags.UpdateImagePullSecrets(&service.Spec.Template.Spec.PodSpec, f.PullSecret)
+	flags.UpdateServiceAccountName(&service.Spec.Template.Spec.PodSpec, f.ServiceAccount)
+
+	err = setServiceOptions(&service.Spec.Template, f.Options)
 	if err != nil {
 		return service, err
 	}
@@ -208,37 +805,224 @@ func generateNewService(name, image, runtime string, envs fn.Envs, volumes fn.Vo
 	return service, nil
 }
 
-func updateService(image string, newEnv []corev1.EnvVar, newEnvFrom []corev1.EnvFromSource, newVolumes []corev1.Volume, newVolumeMounts []corev1.VolumeMount,
-	annotations map[string]string, options fn.Options) func(service *servingv1.Service) (*servingv1.Service, error) {
-	return func(service *servingv1.Service) (*servingv1.Service, error) {
-		// Removing the name so the k8s server can fill it in with generated name,
-		// this prevents conflicts in Revision name when updating the KService from multiple places.
-		service.Spec.Template.Name = ""
+// processInitContainers returns the init containers of the pods of a Function,
+// with their envs as are those of its container.
+func processInitContainers(initContainers []fn.InitContainer, referencedSecrets, referencedConfigMaps *sets.String) (containers []corev1.Container, err error) {
+	for _, c := range initContainers {
+		env, envFrom, err := processEnvs(c.Envs, referencedSecrets, referencedConfigMaps)
+		if err != nil {
+			return nil, err
+		}
+		// The time at which the Function was built is of its container alone.
+		env = env[1:]
+		if len(env) == 0 {
+			env = nil
+		}
+		if len(envFrom) == 0 {
+			envFrom = nil
+		}
+		containers = append(containers, corev1.Container{
+			Name:    c.Name,
+			Image:   c.Image,
+			Command: c.Command,
+			Env:     env,
+			EnvFrom: envFrom,
+		})
+	}
+	return
+}
 
-		// Don't bother being as clever as we are with env variables
-		// Just set the annotations to be whatever we find in func.yaml
-		for k, v := range annotations {
-			service.ObjectMeta.Annotations[k] = v
+// templateAnnotations returns the annotations of the pods of a Function: those
+// injecting the sidecar of its mesh and those by which its metrics are
+// scraped, if any.
+func templateAnnotations(mesh string, metrics fn.Metrics) map[string]string {
+	annotations := fn.MeshAnnotations(mesh)
+	for k, v := range fn.MetricsAnnotations(metrics) {
+		if annotations == nil {
+			annotations = make(map[string]string)
 		}
+		annotations[k] = v
+	}
+	return annotations
+}
+
+// IngressClassAnnotation of a Knative Service selecting the ingress through
+// which it is reached, on clusters of several.
+const IngressClassAnnotation = "networking.knative.dev/ingress.class"
+
+// InvocationAnnotation of the Service of a Function recording the signature
+// with which it is declared to be invoked, such that it is described.  That
+// inferred from its template is not recorded, such that the Services of
+// Functions which declare none are unchanged.
+const InvocationAnnotation = "func.boson.dev/invocation"
+
+// serviceAnnotations returns the annotations of the Function's Service: its
+// own, with that of its ingress class, the next of its chain, its lock and
+// its declared signature, if any.
+func serviceAnnotations(f fn.Function) map[string]string {
+	signature := f.Invocation.Signature
+	if f.IngressClass == "" && f.Next == "" && !f.Locked && signature == "" {
+		return f.Annotations
+	}
+	// Copied, such that those of the Function are not modified.
+	annotations := make(map[string]string, len(f.Annotations)+4)
+	for k, v := range f.Annotations {
+		annotations[k] = v
+	}
+	if f.IngressClass != "" {
+		annotations[IngressClassAnnotation] = f.IngressClass
+	}
+	if f.Next != "" {
+		annotations[NextAnnotation] = f.Next
+	}
+	if f.Locked {
+		annotations[LockedAnnotation] = "true"
+	}
+	if signature != "" {
+		annotations[InvocationAnnotation] = signature
+	}
+	return annotations
+}
+
+// serviceEnvs returns the envs of the container of the Function's Service: its
+// own, with those configuring its tracing, if any, which it does not itself
+// set.
+func serviceEnvs(f fn.Function) fn.Envs {
+	tracing := fn.TracingEnvs(f.Name, f.Tracing)
+	if len(tracing) == 0 {
+		return f.Envs
+	}
+	declared := sets.NewString()
+	for _, e := range f.Envs {
+		if e.Name != nil {
+			declared.Insert(*e.Name)
+		}
+	}
+	// Copied, such that those of the Function are not modified.
+	envs := append(fn.Envs{}, f.Envs...)
+	for _, e := range tracing {
+		if !declared.Has(*e.Name) {
+			envs = append(envs, e)
+		}
+	}
+	return envs
+}
 
-		err := setServiceOptions(&service.Spec.Template, options)
+// updateWithRetry updates the existing Service as read with the update, which
+// is applied against its resourceVersion such that a modification made since
+// it was read conflicts.  On conflict the Service is read again and the
+// update reapplied, up to retries times, after which ErrModifiedConcurrently
+// is returned.
+func updateWithRetry(ctx context.Context, client clientservingv1.KnServingClient, existing *servingv1.Service, update clientservingv1.ServiceUpdateFunc, retries int) error {
+	for attempt := 0; ; attempt++ {
+		if existing.DeletionTimestamp != nil {
+			return fmt.Errorf("the Knative Service '%v' is being deleted", existing.Name)
+		}
+		updated, err := update(existing.DeepCopy())
 		if err != nil {
-			return service, err
+			return err
+		}
+		if _, err = client.UpdateService(ctx, updated); !errors.IsConflict(err) {
+			return err
+		}
+		if attempt == retries {
+			if retries == 0 {
+				return fmt.Errorf("%w: it changed since it was read at resourceVersion %v. Deploy again to apply the function over the changes", ErrModifiedConcurrently, existing.ResourceVersion)
+			}
+			return fmt.Errorf("%w: it changed each time it was read, %v times. Deploy again once other deploys complete", ErrModifiedConcurrently, attempt+1)
+		}
+		if existing, err = client.GetService(ctx, existing.Name); err != nil {
+			return err
 		}
+	}
+}
+
+// updateService returns the update of an existing Service to the desired
+// Service generated for the Function: a patch of the fields it declares, or
+// with Replace the desired Service in its place.
+func (d *Deployer) updateService(desired *servingv1.Service) clientservingv1.ServiceUpdateFunc {
+	if d.Replace {
+		return replaceService(desired)
+	}
+	return patchService(desired)
+}
 
-		err = flags.UpdateImage(&service.Spec.Template.Spec.PodSpec, image)
+// patchService returns an update of an existing Service which changes only
+// the fields declared by the desired Service, leaving those set by others,
+// such as annotations of other controllers, as they are.  It is a three-way
+// JSON merge of the configuration last applied, as recorded on the Service by
+// withLastApplied, the desired Service and the existing, such that fields no
+// longer declared are removed.  Lists, such as the containers of the template,
+// are replaced as a whole.
+func patchService(desired *servingv1.Service) clientservingv1.ServiceUpdateFunc {
+	return func(existing *servingv1.Service) (*servingv1.Service, error) {
+		modified := desired.DeepCopy()
+		if err := withLastApplied(modified); err != nil {
+			return existing, err
+		}
+		modifiedJSON, err := json.Marshal(modified)
+		if err != nil {
+			return existing, err
+		}
+		currentJSON, err := json.Marshal(existing)
 		if err != nil {
-			return service, err
+			return existing, err
 		}
+		original := []byte(existing.Annotations[corev1.LastAppliedConfigAnnotation])
 
-		service.Spec.ConfigurationSpec.Template.Spec.Containers[0].Env = newEnv
-		service.Spec.ConfigurationSpec.Template.Spec.Containers[0].EnvFrom = newEnvFrom
+		patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(original, modifiedJSON, currentJSON)
+		if err != nil {
+			return existing, fmt.Errorf("failed to compute the patch of the Knative Service: %v", err)
+		}
+		patchedJSON, err := jsonpatch.MergePatch(currentJSON, patch)
+		if err != nil {
+			return existing, fmt.Errorf("failed to patch the Knative Service: %v", err)
+		}
+		patched := &servingv1.Service{}
+		if err = json.Unmarshal(patchedJSON, patched); err != nil {
+			return existing, err
+		}
 
-		service.Spec.ConfigurationSpec.Template.Spec.Containers[0].VolumeMounts = newVolumeMounts
-		service.Spec.ConfigurationSpec.Template.Spec.Volumes = newVolumes
+		// Removing the name so the k8s server can fill it in with generated name,
+		// this prevents conflicts in Revision name when updating the KService from multiple places.
+		patched.Spec.Template.Name = ""
+		return patched, nil
+	}
+}
 
-		return service, nil
+// replaceService returns an update of an existing Service which replaces it
+// with the desired Service, such that fields not declared by the Function are
+// reset, including those set by others.
+func replaceService(desired *servingv1.Service) clientservingv1.ServiceUpdateFunc {
+	return func(existing *servingv1.Service) (*servingv1.Service, error) {
+		replaced := desired.DeepCopy()
+		if err := withLastApplied(replaced); err != nil {
+			return existing, err
+		}
+		replaced.Namespace = existing.Namespace
+		replaced.ResourceVersion = existing.ResourceVersion
+		return replaced, nil
+	}
+}
+
+// withLastApplied records the Service, as generated for the Function, in its
+// last-applied-configuration annotation, from which the fields removed from
+// the Function are determined when it is next patched.
+func withLastApplied(service *servingv1.Service) error {
+	applied := service.DeepCopy()
+	delete(applied.Annotations, corev1.LastAppliedConfigAnnotation)
+	bb, err := json.Marshal(applied)
+	if err != nil {
+		return fmt.Errorf("knative deployer failed to record the applied Knative Service: %v", err)
 	}
+	// Copied, such that the Function's own annotations are not modified.
+	annotations := make(map[string]string, len(service.Annotations)+1)
+	for k, v := range service.Annotations {
+		annotations[k] = v
+	}
+	annotations[corev1.LastAppliedConfigAnnotation] = string(bb)
+	service.Annotations = annotations
+	return nil
 }
 
 // processEnvs generates array of EnvVars and EnvFromSources from a function config
@@ -275,10 +1059,10 @@ func processEnvs(envs fn.Envs, referencedSecrets, referencedConfigMaps *sets.Str
 				if len(slices) == 3 {
 					// ENV from a key in secret/configMap, eg. FOO={{ secret:secretName:key }} FOO={{ configMap:configMapName.key }}
 					valueFrom, err := createEnvVarSource(slices, referencedSecrets, referencedConfigMaps)
-					envVars = append(envVars, corev1.EnvVar{Name: *env.Name, ValueFrom: valueFrom})
 					if err != nil {
 						return nil, nil, err
 					}
+					envVars = append(envVars, corev1.EnvVar{Name: *env.Name, ValueFrom: valueFrom})
 					continue
 				} else if len(slices) == 2 {
 					// ENV from the local ENV var, eg. FOO={{ env:LOCAL_ENV }}
@@ -295,12 +1079,25 @@ func processEnvs(envs fn.Envs, referencedSecrets, referencedConfigMaps *sets.Str
 				continue
 			}
 		}
-		return nil, nil, fmt.Errorf("unsupported env source entry \"%v\"", env)
+		return nil, nil, fmt.Errorf("unsupported env source entry %v", describeEnv(env))
 	}
 
 	return envVars, envFrom, nil
 }
 
+// describeEnv returns a description of the env entry suitable for error
+// messages, naming its fields explicitly as they may be unset.
+func describeEnv(env fn.Env) string {
+	name, value := "<unset>", "<unset>"
+	if env.Name != nil {
+		name = fmt.Sprintf("%q", *env.Name)
+	}
+	if env.Value != nil {
+		value = fmt.Sprintf("%q", *env.Value)
+	}
+	return fmt.Sprintf("(name: %v, value: %v)", name, value)
+}
+
 func createEnvFromSource(value string, referencedSecrets, referencedConfigMaps *sets.String) (*corev1.EnvFromSource, error) {
 	slices := strings.Split(strings.Trim(value, "{} "), ":")
 	if len(slices) != 2 {
@@ -426,6 +1223,8 @@ func processLocalEnvValue(val string) (string, error) {
 //   path: /etc/secret-volume
 // - configMap: example-cm                # mount ConfigMap as Volume
 //   path: /etc/cm-volume
+// - emptyDir: {}                         # mount an empty directory as Volume
+//   path: /tmp/cache
 func processVolumes(volumes fn.Volumes, referencedSecrets, referencedConfigMaps *sets.String) ([]corev1.Volume, []corev1.VolumeMount, error) {
 
 	createdVolumes := sets.NewString()
@@ -476,6 +1275,22 @@ func processVolumes(volumes fn.Volumes, referencedSecrets, referencedConfigMaps
 					referencedConfigMaps.Insert(*vol.ConfigMap)
 				}
 			}
+		} else if vol.EmptyDir != nil {
+			volumeName = emptyDirVolumeName(*vol.Path, createdVolumes)
+
+			emptyDir := &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMedium(vol.EmptyDir.Medium)}
+			if vol.EmptyDir.SizeLimit != nil {
+				limit, err := resource.ParseQuantity(*vol.EmptyDir.SizeLimit)
+				if err != nil {
+					return nil, nil, fmt.Errorf("invalid emptyDir sizeLimit %s: %v", *vol.EmptyDir.SizeLimit, err)
+				}
+				emptyDir.SizeLimit = &limit
+			}
+			newVolumes = append(newVolumes, corev1.Volume{
+				Name:         volumeName,
+				VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir},
+			})
+			createdVolumes.Insert(volumeName)
 		}
 
 		if volumeName != "" {
@@ -494,6 +1309,24 @@ func processVolumes(volumes fn.Volumes, referencedSecrets, referencedConfigMaps
 	return newVolumes, newVolumeMounts, nil
 }
 
+// emptyDirVolumeName returns the name of the volume of an emptyDir mounted at
+// the path, derived from the path, such as empty-dir-tmp-cache for /tmp/cache,
+// and suffixed if that of another volume.
+func emptyDirVolumeName(path string, created sets.String) string {
+	name := "empty-dir-" + strings.Trim(invalidVolumeNameChars.ReplaceAllString(strings.ToLower(path), "-"), "-")
+	if len(name) > 58 {
+		name = strings.TrimRight(name[:58], "-")
+	}
+	unique := name
+	for i := 2; created.Has(unique); i++ {
+		unique = fmt.Sprintf("%v-%d", name, i)
+	}
+	return unique
+}
+
+// invalidVolumeNameChars are those which may not be in the name of a volume.
+var invalidVolumeNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
 // checkSecretsConfigMapsArePresent returns error if Secrets or ConfigMaps
 // referenced in input sets are not deployed on the cluster in the specified namespace
 func checkSecretsConfigMapsArePresent(ctx context.Context, namespace string, referencedSecrets, referencedConfigMaps *sets.String) error {
@@ -558,12 +1391,26 @@ func setServiceOptions(template *servingv1.RevisionTemplateSpec, options fn.Opti
 			toRemove = append(toRemove, autoscaling.TargetUtilizationPercentageKey)
 		}
 
+		durations := map[string]*string{
+			autoscaling.WindowAnnotationKey:              options.Scale.Window,
+			autoscaling.ScaleDownDelayAnnotationKey:      options.Scale.ScaleDownDelay,
+			autoscaling.ScaleToZeroPodRetentionPeriodKey: options.Scale.RetentionPeriod,
+		}
+		for key, duration := range durations {
+			if duration != nil {
+				toUpdate[key] = *duration
+			} else {
+				toRemove = append(toRemove, key)
+			}
+		}
+
 	}
 
 	// in the container always set Requests/Limits & Concurrency values based on the contents of config
 	template.Spec.PodSpec.Containers[0].Resources.Requests = nil
 	template.Spec.PodSpec.Containers[0].Resources.Limits = nil
 	template.Spec.ContainerConcurrency = nil
+	template.Spec.TimeoutSeconds = options.RequestTimeout
 
 	if options.Resources != nil {
 		if options.Resources.Requests != nil {
@@ -584,6 +1431,10 @@ func setServiceOptions(template *servingv1.RevisionTemplateSpec, options fn.Opti
 				}
 				template.Spec.PodSpec.Containers[0].Resources.Requests[corev1.ResourceMemory] = value
 			}
+
+			if err := setExtended(template.Spec.PodSpec.Containers[0].Resources.Requests, options.Resources.Requests.Extended); err != nil {
+				return err
+			}
 		}
 
 		if options.Resources.Limits != nil {
@@ -605,6 +1456,10 @@ func setServiceOptions(template *servingv1.RevisionTemplateSpec, options fn.Opti
 				template.Spec.PodSpec.Containers[0].Resources.Limits[corev1.ResourceMemory] = value
 			}
 
+			if err := setExtended(template.Spec.PodSpec.Containers[0].Resources.Limits, options.Resources.Limits.Extended); err != nil {
+				return err
+			}
+
 			if options.Resources.Limits.Concurrency != nil {
 				template.Spec.ContainerConcurrency = options.Resources.Limits.Concurrency
 			}
@@ -613,3 +1468,16 @@ func setServiceOptions(template *servingv1.RevisionTemplateSpec, options fn.Opti
 
 	return servingclientlib.UpdateRevisionTemplateAnnotations(template, toUpdate, toRemove)
 }
+
+// setExtended sets the quantities of the extended resources, such as
+// nvidia.com/gpu, in the list.
+func setExtended(list corev1.ResourceList, extended map[string]string) error {
+	for name, quantity := range extended {
+		value, err := resource.ParseQuantity(quantity)
+		if err != nil {
+			return err
+		}
+		list[corev1.ResourceName(name)] = value
+	}
+	return nil
+}
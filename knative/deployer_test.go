@@ -1,8 +1,27 @@
 package knative
 
 import (
+	"bytes"
+	"context"
+	goerrors "errors"
+	"fmt"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/client/pkg/util/mock"
+	"knative.dev/pkg/ptr"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/yaml"
+
+	fn "github.com/boson-project/func"
 )
 
 func Test_processValue(t *testing.T) {
@@ -48,3 +67,685 @@ func Test_processValue(t *testing.T) {
 		})
 	}
 }
+
+// Test_processEnvs ensures that literal values are set directly, that keys
+// of Secrets and ConfigMaps are referenced via an EnvVarSource, that whole
+// Secrets and ConfigMaps are referenced via an EnvFromSource, and that
+// invalid templated values error.
+func Test_processEnvs(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	envs := fn.Envs{
+		{Name: str("LITERAL"), Value: str("value")},
+		{Name: str("FROM_SECRET"), Value: str("{{ secret:my-secret:key }}")},
+		{Name: str("FROM_CONFIGMAP"), Value: str("{{ configMap:my-configmap:key }}")},
+		{Value: str("{{ secret:whole-secret }}")},
+		{Value: str("{{ configMap:whole-configmap }}")},
+	}
+	secrets, configMaps := sets.NewString(), sets.NewString()
+	envVars, envFrom, err := processEnvs(envs, &secrets, &configMaps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars := map[string]corev1.EnvVar{}
+	for _, v := range envVars {
+		vars[v.Name] = v
+	}
+	if vars["LITERAL"].Value != "value" {
+		t.Fatalf("expected literal value 'value', got '%v'", vars["LITERAL"].Value)
+	}
+	ref := vars["FROM_SECRET"].ValueFrom
+	if ref == nil || ref.SecretKeyRef == nil || ref.SecretKeyRef.Name != "my-secret" || ref.SecretKeyRef.Key != "key" {
+		t.Fatalf("expected a reference to key 'key' of Secret 'my-secret', got %+v", ref)
+	}
+	ref = vars["FROM_CONFIGMAP"].ValueFrom
+	if ref == nil || ref.ConfigMapKeyRef == nil || ref.ConfigMapKeyRef.Name != "my-configmap" || ref.ConfigMapKeyRef.Key != "key" {
+		t.Fatalf("expected a reference to key 'key' of ConfigMap 'my-configmap', got %+v", ref)
+	}
+	if len(envFrom) != 2 || envFrom[0].SecretRef == nil || envFrom[1].ConfigMapRef == nil {
+		t.Fatalf("expected references to a whole Secret and ConfigMap, got %+v", envFrom)
+	}
+	if !secrets.HasAll("my-secret", "whole-secret") || !configMaps.HasAll("my-configmap", "whole-configmap") {
+		t.Fatalf("expected referenced Secrets and ConfigMaps to be recorded, got %v and %v", secrets.List(), configMaps.List())
+	}
+
+	invalid := []fn.Env{
+		{Name: str("BAD_TYPE"), Value: str("{{ vault:my-secret:key }}")},
+		{Name: str("BAD_SYNTAX"), Value: str("{{ secret }}")},
+		{Value: str("{{ vault:my-secret }}")},
+	}
+	for _, env := range invalid {
+		if _, _, err := processEnvs(fn.Envs{env}, &secrets, &configMaps); err == nil {
+			t.Fatalf("expected an error processing %v", describeEnv(env))
+		}
+	}
+}
+
+// Test_DeployDryRunClient ensures that a client dry run renders the Knative
+// Service manifest locally, and that an invalid dry run mode errors.
+func Test_DeployDryRunClient(t *testing.T) {
+	buf := &bytes.Buffer{}
+	d := &Deployer{Namespace: "myns", DryRun: DryRunClient, Output: buf}
+
+	f := fn.Function{Name: "myfunc", Runtime: "go", Image: "quay.io/alice/myfunc"}
+	if _, err := d.Deploy(context.Background(), f); err != nil {
+		t.Fatal(err)
+	}
+
+	var service servingv1.Service
+	if err := yaml.Unmarshal(buf.Bytes(), &service); err != nil {
+		t.Fatal(err)
+	}
+	if service.APIVersion != "serving.knative.dev/v1" || service.Kind != "Service" {
+		t.Fatalf("unexpected type '%v/%v'", service.APIVersion, service.Kind)
+	}
+	if service.Name != "myfunc" || service.Namespace != "myns" {
+		t.Fatalf("unexpected service '%v/%v'", service.Namespace, service.Name)
+	}
+	if image := service.Spec.Template.Spec.Containers[0].Image; image != "quay.io/alice/myfunc" {
+		t.Fatalf("unexpected image '%v'", image)
+	}
+
+	d.DryRun = "invalid"
+	if _, err := d.Deploy(context.Background(), f); err == nil {
+		t.Fatal("expected an error for an invalid dry run mode")
+	}
+}
+
+// Test_isNamespaceNotFound ensures that only the error of creating a resource
+// in a namespace which does not exist is that of the namespace not found.
+func Test_isNamespaceNotFound(t *testing.T) {
+	namespaces := schema.GroupResource{Resource: "namespaces"}
+	services := schema.GroupResource{Group: "serving.knative.dev", Resource: "services"}
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{errors.NewNotFound(namespaces, "myns"), true},
+		{fmt.Errorf("wrapped: %w", errors.NewNotFound(namespaces, "myns")), true},
+		{errors.NewNotFound(services, "myfunc"), false},
+		{errors.NewForbidden(namespaces, "myns", goerrors.New("forbidden")), false},
+		{nil, false},
+	}
+	for _, test := range tests {
+		if actual := isNamespaceNotFound(test.err); actual != test.expected {
+			t.Errorf("expected %v for '%v', got %v", test.expected, test.err, actual)
+		}
+	}
+}
+
+// Test_ChangeCause ensures that the Revision template of the Service is
+// annotated with the cause of the change of the deploy, and that it is not
+// annotated when there is none.
+func Test_ChangeCause(t *testing.T) {
+	f := fn.Function{Name: "myfunc", Runtime: "go", Image: "quay.io/alice/myfunc"}
+	for _, cause := range []string{"Fix the handling of empty payloads", ""} {
+		buf := &bytes.Buffer{}
+		d := &Deployer{Namespace: "myns", DryRun: DryRunClient, Output: buf, ChangeCause: cause}
+		if _, err := d.Deploy(context.Background(), f); err != nil {
+			t.Fatal(err)
+		}
+		var service servingv1.Service
+		if err := yaml.Unmarshal(buf.Bytes(), &service); err != nil {
+			t.Fatal(err)
+		}
+		got, ok := service.Spec.Template.Annotations[ChangeCauseAnnotation]
+		if got != cause || ok != (cause != "") {
+			t.Fatalf("expected the change cause '%v', got '%v' (annotated: %v)", cause, got, ok)
+		}
+	}
+}
+
+// Test_PlanDeploy ensures that the plan of a deploy applies the Knative
+// Service, with its manifest as rendered locally, and the DomainMapping of
+// the Function's domain.
+func Test_PlanDeploy(t *testing.T) {
+	d := &Deployer{Namespace: "myns"}
+	f := fn.Function{Name: "myfunc", Runtime: "go", Image: "quay.io/alice/myfunc", Domain: "myfunc.example.com"}
+	steps, err := d.PlanDeploy(context.Background(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("expected 2 steps, got %v", steps)
+	}
+	if steps[0].Action != "apply" || steps[0].Target != "Knative Service myns/myfunc" {
+		t.Fatalf("unexpected step '%v %v'", steps[0].Action, steps[0].Target)
+	}
+	var service servingv1.Service
+	if err := yaml.Unmarshal([]byte(steps[0].Detail), &service); err != nil {
+		t.Fatal(err)
+	}
+	if image := service.Spec.Template.Spec.Containers[0].Image; image != "quay.io/alice/myfunc" {
+		t.Fatalf("unexpected image '%v'", image)
+	}
+	if steps[1].Action != "apply" || steps[1].Target != "DomainMapping myns/myfunc.example.com" {
+		t.Fatalf("unexpected step '%v %v'", steps[1].Action, steps[1].Target)
+	}
+}
+
+// Test_PullSecret ensures that the Function's pull secret is set as the image
+// pull secret of both new and updated Services, and is removed from updated
+// Services when no longer configured.
+func Test_PullSecret(t *testing.T) {
+	service, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", PullSecret: "regcred", Runtime: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := service.Spec.Template.Spec.ImagePullSecrets
+	if len(secrets) != 1 || secrets[0].Name != "regcred" {
+		t.Fatalf("expected image pull secret 'regcred', got %v", secrets)
+	}
+
+	service, err = updateDeployed(t, service, "example.com/alice/myfunc", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets = service.Spec.Template.Spec.ImagePullSecrets; len(secrets) != 0 {
+		t.Fatalf("expected no image pull secrets, got %v", secrets)
+	}
+}
+
+// Test_ServiceAccount ensures that the Function's service account is set on
+// both new and updated Services, and is reset to the default on updated
+// Services when no longer configured.
+func Test_ServiceAccount(t *testing.T) {
+	service, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", ServiceAccount: "myfunc-sa", Runtime: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sa := service.Spec.Template.Spec.ServiceAccountName; sa != "myfunc-sa" {
+		t.Fatalf("expected service account 'myfunc-sa', got '%v'", sa)
+	}
+
+	service, err = updateDeployed(t, service, "example.com/alice/myfunc", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sa := service.Spec.Template.Spec.ServiceAccountName; sa != "" {
+		t.Fatalf("expected the default service account, got '%v'", sa)
+	}
+}
+
+// Test_ImagePullPolicy ensures that the Function's image pull policy is set
+// on the container of both new and updated Services, and is reset to the
+// default on updated Services when no longer configured.
+func Test_ImagePullPolicy(t *testing.T) {
+	service, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", ImagePullPolicy: "Never", Runtime: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy := service.Spec.Template.Spec.Containers[0].ImagePullPolicy; policy != corev1.PullNever {
+		t.Fatalf("expected image pull policy 'Never', got '%v'", policy)
+	}
+
+	service, err = updateDeployed(t, service, "example.com/alice/myfunc", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy := service.Spec.Template.Spec.Containers[0].ImagePullPolicy; policy != "" {
+		t.Fatalf("expected the default image pull policy, got '%v'", policy)
+	}
+}
+
+// Test_Port ensures that the Function's port is set as that of the container
+// of both new and updated Services, and is removed from updated Services when
+// no longer configured, such that Knative's default applies.
+func Test_Port(t *testing.T) {
+	service, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", Runtime: "go", Port: 3000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ports := service.Spec.Template.Spec.Containers[0].Ports; len(ports) != 1 || ports[0].ContainerPort != 3000 {
+		t.Fatalf("expected the container port 3000, got %v", ports)
+	}
+
+	service, err = updateDeployed(t, service, "example.com/alice/myfunc", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ports := service.Spec.Template.Spec.Containers[0].Ports; len(ports) != 0 {
+		t.Fatalf("expected no container port, got %v", ports)
+	}
+}
+
+// Test_ExtendedResources ensures that extended resources, such as the GPU
+// nvidia.com/gpu, are requested and limited alongside cpu and memory.
+func Test_ExtendedResources(t *testing.T) {
+	options := fn.Options{Resources: &fn.ResourcesOptions{
+		Limits: &fn.ResourcesLimitsOptions{
+			Memory:   ptr.String("512Mi"),
+			Extended: map[string]string{"nvidia.com/gpu": "1"},
+		},
+	}}
+	service, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", Runtime: "go", Options: options})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits := service.Spec.Template.Spec.Containers[0].Resources.Limits
+	if gpu := limits["nvidia.com/gpu"]; gpu.String() != "1" {
+		t.Fatalf("expected the limit of 1 nvidia.com/gpu, got %v", limits)
+	}
+	if memory := limits[corev1.ResourceMemory]; memory.String() != "512Mi" {
+		t.Fatalf("expected the memory limit of 512Mi, got %v", limits)
+	}
+}
+
+// Test_ScaleDurations ensures the durations of the autoscaling of the
+// Function are annotated on the revision template, and removed once unset.
+func Test_ScaleDurations(t *testing.T) {
+	options := fn.Options{Scale: &fn.ScaleOptions{
+		Window:          ptr.String("60s"),
+		ScaleDownDelay:  ptr.String("15m"),
+		RetentionPeriod: ptr.String("5m"),
+	}}
+	service, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", Runtime: "go", Options: options})
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := service.Spec.Template.Annotations
+	if annotations[autoscaling.WindowAnnotationKey] != "60s" || annotations[autoscaling.ScaleDownDelayAnnotationKey] != "15m" ||
+		annotations[autoscaling.ScaleToZeroPodRetentionPeriodKey] != "5m" {
+		t.Fatalf("expected the durations to be annotated, got %v", annotations)
+	}
+
+	options.Scale.ScaleDownDelay = nil
+	if err = setServiceOptions(&service.Spec.Template, options); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := service.Spec.Template.Annotations[autoscaling.ScaleDownDelayAnnotationKey]; ok {
+		t.Fatalf("expected the scale down delay to be removed, got %v", service.Spec.Template.Annotations)
+	}
+	if service.Spec.Template.Annotations[autoscaling.WindowAnnotationKey] != "60s" {
+		t.Fatalf("expected the window to be kept, got %v", service.Spec.Template.Annotations)
+	}
+}
+
+// Test_Mesh ensures that the sidecar injection annotations of the Function's
+// mesh are set on the template of both new and updated Services, and are
+// removed from updated Services when the mesh is no longer configured.
+func Test_Mesh(t *testing.T) {
+	service, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", Mesh: "istio", Runtime: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inject := service.Spec.Template.Annotations["sidecar.istio.io/inject"]; inject != "true" {
+		t.Fatalf("expected the istio sidecar to be injected, got %v", service.Spec.Template.Annotations)
+	}
+
+	service, err = updateDeployed(t, service, "example.com/alice/myfunc", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := service.Spec.Template.Annotations["sidecar.istio.io/inject"]; ok {
+		t.Fatalf("expected the injection annotation to be removed, got %v", service.Spec.Template.Annotations)
+	}
+}
+
+// Test_Metrics ensures that the Function's metrics endpoint annotates the
+// template of both new and updated Services to be scraped, with the default
+// path if none is set, alongside those of its mesh, and that the annotations
+// are removed from updated Services when no longer configured.
+func Test_Metrics(t *testing.T) {
+	service, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", Mesh: "istio", Runtime: "go", Metrics: fn.Metrics{Port: 9095}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := service.Spec.Template.Annotations
+	if annotations["prometheus.io/scrape"] != "true" || annotations["prometheus.io/port"] != "9095" || annotations["prometheus.io/path"] != "/metrics" {
+		t.Fatalf("expected the function to be scraped at :9095/metrics, got %v", annotations)
+	}
+	if annotations["sidecar.istio.io/inject"] != "true" {
+		t.Fatalf("expected the istio sidecar to be injected, got %v", annotations)
+	}
+
+	service, err = updateDeployed(t, service, "example.com/alice/myfunc", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"prometheus.io/scrape", "prometheus.io/port", "prometheus.io/path"} {
+		if _, ok := service.Spec.Template.Annotations[k]; ok {
+			t.Fatalf("expected the metrics annotations to be removed, got %v", service.Spec.Template.Annotations)
+		}
+	}
+}
+
+// Test_Tracing ensures that the tracing of the Function configures the OTEL
+// envs of its container, its service name defaulting to the name of the
+// Function, without overriding those it sets itself.
+func Test_Tracing(t *testing.T) {
+	f := fn.Function{Name: "myfunc", Tracing: fn.Tracing{Endpoint: "http://otel-collector:4317"}}
+	f.Image, f.Runtime = "example.com/alice/myfunc", "go"
+	service, err := generateNewService(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envs := map[string]string{}
+	for _, e := range service.Spec.Template.Spec.Containers[0].Env {
+		envs[e.Name] = e.Value
+	}
+	if envs["OTEL_EXPORTER_OTLP_ENDPOINT"] != "http://otel-collector:4317" || envs["OTEL_SERVICE_NAME"] != "myfunc" {
+		t.Fatalf("expected the OTEL envs of the tracing, got %v", envs)
+	}
+
+	name := "OTEL_SERVICE_NAME"
+	value := "orders"
+	f.Envs = fn.Envs{{Name: &name, Value: &value}}
+	merged := serviceEnvs(f)
+	if len(merged) != 2 || *merged[0].Value != "orders" || *merged[1].Name != "OTEL_EXPORTER_OTLP_ENDPOINT" {
+		t.Fatalf("expected the service name of the function to be kept, got %+v", merged)
+	}
+	if len(f.Envs) != 1 {
+		t.Fatal("expected the envs of the function to be unmodified")
+	}
+}
+
+// Test_InitContainers ensures that the init containers of the Function are
+// those of its Revisions, without the BUILT env of the Function, and that
+// they are removed from updated Services when no longer configured.
+func Test_InitContainers(t *testing.T) {
+	name := "DB_URL"
+	value := "postgres://db"
+	initContainers := []fn.InitContainer{{Name: "migrate", Image: "example.com/alice/migrate:v1", Command: []string{"migrate", "up"}, Envs: fn.Envs{{Name: &name, Value: &value}}}}
+	service, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", Runtime: "go", InitContainers: initContainers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	containers := service.Spec.Template.Spec.InitContainers
+	if len(containers) != 1 {
+		t.Fatalf("expected one init container, got %+v", containers)
+	}
+	c := containers[0]
+	if c.Name != "migrate" || c.Image != "example.com/alice/migrate:v1" || !reflect.DeepEqual(c.Command, []string{"migrate", "up"}) {
+		t.Fatalf("unexpected init container %+v", c)
+	}
+	if len(c.Env) != 1 || c.Env[0].Name != "DB_URL" || c.Env[0].Value != "postgres://db" {
+		t.Fatalf("expected the envs of the init container alone, got %+v", c.Env)
+	}
+
+	service, err = updateDeployed(t, service, "example.com/alice/myfunc", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(service.Spec.Template.Spec.InitContainers) != 0 {
+		t.Fatalf("expected the init containers to be removed, got %+v", service.Spec.Template.Spec.InitContainers)
+	}
+}
+
+// Test_IngressClass ensures that the ingress class of the Function annotates
+// its Service, without modifying the Function's annotations, and that the
+// annotation is removed from updated Services when no longer configured.
+func Test_IngressClass(t *testing.T) {
+	f := fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", Runtime: "go", Annotations: map[string]string{"team": "payments"}, IngressClass: "kourier.ingress.networking.knative.dev"}
+	service, err := generateNewService(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if class := service.Annotations[IngressClassAnnotation]; class != "kourier.ingress.networking.knative.dev" || service.Annotations["team"] != "payments" {
+		t.Fatalf("expected the ingress class and annotations of the function, got %v", service.Annotations)
+	}
+	if _, ok := f.Annotations[IngressClassAnnotation]; ok {
+		t.Fatal("expected the annotations of the function to be unmodified")
+	}
+
+	service, err = updateDeployed(t, service, "example.com/alice/myfunc", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := service.Annotations[IngressClassAnnotation]; ok {
+		t.Fatalf("expected the ingress class to be removed, got %v", service.Annotations)
+	}
+}
+
+// Test_Invocation ensures that only a declared signature annotates the
+// Service of the Function, such that the Services of Functions inferring it
+// from their template are unchanged.
+func Test_Invocation(t *testing.T) {
+	f := fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", Runtime: "go", Template: "events"}
+	service, err := generateNewService(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := service.Annotations[InvocationAnnotation]; ok {
+		t.Fatalf("expected the signature of the template not to be recorded, got %v", service.Annotations)
+	}
+
+	f.Invocation.Signature = "http"
+	if service, err = generateNewService(f); err != nil {
+		t.Fatal(err)
+	}
+	if signature := service.Annotations[InvocationAnnotation]; signature != "http" {
+		t.Fatalf("expected the declared signature to be recorded, got %v", service.Annotations)
+	}
+}
+
+// Test_RequestTimeout ensures that the request timeout of the Function is
+// that of its Revisions, and that it is reset to Knative's default when no
+// longer set.
+func Test_RequestTimeout(t *testing.T) {
+	timeout := int64(450)
+	service, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", Runtime: "go", Options: fn.Options{RequestTimeout: &timeout}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seconds := service.Spec.Template.Spec.TimeoutSeconds; seconds == nil || *seconds != 450 {
+		t.Fatalf("expected a request timeout of 450 seconds, got %v", seconds)
+	}
+
+	service, err = updateDeployed(t, service, "example.com/alice/myfunc", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seconds := service.Spec.Template.Spec.TimeoutSeconds; seconds != nil {
+		t.Fatalf("expected the default request timeout, got %v", *seconds)
+	}
+}
+
+// updateDeployed patches the given Service, as deployed for a Function, with
+// that generated for the Function of the given image, pull secret and
+// service account.
+func updateDeployed(t *testing.T, service *servingv1.Service, image, pullSecret, serviceAccount string) (*servingv1.Service, error) {
+	t.Helper()
+	if err := withLastApplied(service); err != nil {
+		t.Fatal(err)
+	}
+	desired, err := generateNewService(fn.Function{Name: service.Name, Image: image, PullSecret: pullSecret, ServiceAccount: serviceAccount, Runtime: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return patchService(desired)(service)
+}
+
+// Test_patchService ensures that updating a Service changes only the fields
+// the Function declares, removing those it no longer declares, and preserves
+// those set by others, such as their annotations.
+func Test_patchService(t *testing.T) {
+	deployed, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc:v1", Runtime: "go", Annotations: map[string]string{"owner": "alice", "team": "a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = withLastApplied(deployed); err != nil {
+		t.Fatal(err)
+	}
+
+	// As changed by others once deployed.
+	existing := deployed.DeepCopy()
+	existing.ResourceVersion = "42"
+	existing.Annotations["example.com/foreign"] = "kept"
+	existing.Labels["example.com/foreign"] = "kept"
+	existing.Spec.Template.Name = "myfunc-v1"
+
+	desired, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc:v2", Runtime: "go", Annotations: map[string]string{"owner": "bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := patchService(desired)(existing.DeepCopy())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if service.Annotations["example.com/foreign"] != "kept" || service.Labels["example.com/foreign"] != "kept" {
+		t.Fatalf("expected the foreign annotation and label to survive, got %v and %v", service.Annotations, service.Labels)
+	}
+	if service.Annotations["owner"] != "bob" {
+		t.Fatalf("expected annotation owner=bob, got %v", service.Annotations)
+	}
+	if _, ok := service.Annotations["team"]; ok {
+		t.Fatalf("expected the annotation removed from the function to be removed, got %v", service.Annotations)
+	}
+	if image := service.Spec.Template.Spec.Containers[0].Image; image != "example.com/alice/myfunc:v2" {
+		t.Fatalf("expected the image to be updated, got '%v'", image)
+	}
+	if service.Spec.Template.Name != "" {
+		t.Fatalf("expected the revision name to be cleared, got '%v'", service.Spec.Template.Name)
+	}
+	if service.ResourceVersion != "42" {
+		t.Fatalf("expected the resource version of the existing Service, got '%v'", service.ResourceVersion)
+	}
+	if desired.Annotations[corev1.LastAppliedConfigAnnotation] != "" {
+		t.Fatal("expected the desired Service not to be modified")
+	}
+
+	// A Service deployed without the last applied configuration is patched
+	// without removing anything.
+	delete(existing.Annotations, corev1.LastAppliedConfigAnnotation)
+	if service, err = patchService(desired)(existing); err != nil {
+		t.Fatal(err)
+	}
+	if service.Annotations["example.com/foreign"] != "kept" || service.Annotations["team"] != "a" {
+		t.Fatalf("expected the existing annotations to survive, got %v", service.Annotations)
+	}
+	if service.Annotations[corev1.LastAppliedConfigAnnotation] == "" {
+		t.Fatal("expected the last applied configuration to be recorded")
+	}
+}
+
+// Test_replaceService ensures that replacing a Service resets the fields set
+// by others, retaining only its resource version.
+func Test_replaceService(t *testing.T) {
+	existing, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc:v1", Runtime: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing.ResourceVersion = "42"
+	existing.Annotations = map[string]string{"example.com/foreign": "dropped"}
+
+	desired, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc:v2", Runtime: "go", Annotations: map[string]string{"owner": "bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := (&Deployer{Replace: true}).updateService(desired)(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := service.Annotations["example.com/foreign"]; ok {
+		t.Fatalf("expected the foreign annotation to be dropped, got %v", service.Annotations)
+	}
+	if service.Annotations["owner"] != "bob" || service.ResourceVersion != "42" {
+		t.Fatalf("unexpected Service %+v", service.ObjectMeta)
+	}
+}
+
+// Test_Probes ensures that the health probes default to those of the runtime,
+// and that those configured override them.
+func Test_Probes(t *testing.T) {
+	path, delay, period := "/ready", int32(10), int32(5)
+	tests := []struct {
+		name      string
+		runtime   string
+		health    fn.Health
+		liveness  string
+		readiness string
+	}{
+		{"default", "go", fn.Health{}, DefaultLivenessPath, DefaultReadinessPath},
+		{"quarkus default", "quarkus", fn.Health{}, "", ""},
+		{"configured", "go", fn.Health{Readiness: &fn.Probe{Path: &path, InitialDelaySeconds: &delay, PeriodSeconds: &period}}, DefaultLivenessPath, path},
+		{"quarkus configured", "quarkus", fn.Health{Liveness: &fn.Probe{PeriodSeconds: &period}}, DefaultLivenessPath, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", Runtime: tt.runtime, Health: tt.health})
+			if err != nil {
+				t.Fatal(err)
+			}
+			container := service.Spec.Template.Spec.Containers[0]
+			if got := probePath(container.LivenessProbe); got != tt.liveness {
+				t.Fatalf("expected liveness path '%v', got '%v'", tt.liveness, got)
+			}
+			if got := probePath(container.ReadinessProbe); got != tt.readiness {
+				t.Fatalf("expected readiness path '%v', got '%v'", tt.readiness, got)
+			}
+			if tt.health.Readiness != nil && (container.ReadinessProbe.InitialDelaySeconds != delay || container.ReadinessProbe.PeriodSeconds != period) {
+				t.Fatalf("expected the configured timings, got %+v", container.ReadinessProbe)
+			}
+		})
+	}
+}
+
+// Test_Volumes ensures the Secrets, ConfigMaps and emptyDirs of the Function
+// are mounted by its container, and are described as declared.
+func Test_Volumes(t *testing.T) {
+	secret, configMap, limit := "my-secret", "my-config", "64Mi"
+	config, cache, tmp := "/etc/config", "/etc/settings", "/tmp/cache"
+	volumes := fn.Volumes{
+		{Secret: &secret, Path: &config},
+		{ConfigMap: &configMap, Path: &cache},
+		{EmptyDir: &fn.EmptyDir{Medium: "Memory", SizeLimit: &limit}, Path: &tmp},
+	}
+	service, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", Runtime: "go", Volumes: volumes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := service.Spec.Template.Spec
+	if len(spec.Volumes) != 3 || spec.Volumes[2].Name != "empty-dir-tmp-cache" || spec.Volumes[2].EmptyDir == nil ||
+		spec.Volumes[2].EmptyDir.Medium != corev1.StorageMediumMemory || spec.Volumes[2].EmptyDir.SizeLimit.String() != "64Mi" {
+		t.Fatalf("expected the volumes of the secret, config map and memory emptyDir, got %+v", spec.Volumes)
+	}
+	if mounts := spec.Containers[0].VolumeMounts; len(mounts) != 3 || mounts[2].Name != "empty-dir-tmp-cache" || mounts[2].MountPath != tmp {
+		t.Fatalf("expected the emptyDir to be mounted at %v, got %+v", tmp, mounts)
+	}
+
+	described := describeVolumes(spec.Volumes, spec.Containers[0].VolumeMounts)
+	if !reflect.DeepEqual(fn.Volumes(described), volumes) {
+		t.Fatalf("expected the volumes to be described as declared, got %+v", described)
+	}
+
+	if name := emptyDirVolumeName("/tmp/cache", sets.NewString("empty-dir-tmp-cache")); name != "empty-dir-tmp-cache-2" {
+		t.Fatalf("expected a unique name of the volume, got %v", name)
+	}
+}
+
+// Test_updateWithRetry ensures the update of a Service which conflicts with a
+// concurrent modification is reapplied to the Service read again, and that
+// without retries the conflict fails with ErrModifiedConcurrently.
+func Test_updateWithRetry(t *testing.T) {
+	existing := &servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "myfunc", ResourceVersion: "1"}}
+	modified := &servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "myfunc", ResourceVersion: "2"}}
+	conflict := errors.NewConflict(servingv1.Resource("services"), "myfunc", goerrors.New("the object has been modified"))
+
+	var versions []string
+	update := func(s *servingv1.Service) (*servingv1.Service, error) {
+		versions = append(versions, s.ResourceVersion)
+		return s, nil
+	}
+
+	serving, _ := mockServing(t, "test")
+	serving.Recorder().UpdateService(mock.Any(), false, conflict)
+	serving.Recorder().GetService("myfunc", modified, nil)
+	serving.Recorder().UpdateService(mock.Any(), true, nil)
+	if err := updateWithRetry(context.Background(), serving, existing, update, DefaultConflictRetries); err != nil {
+		t.Fatal(err)
+	}
+	serving.Recorder().Validate()
+	if strings.Join(versions, ",") != "1,2" {
+		t.Fatalf("expected the update to be applied to resourceVersion 1 and then 2, got %v", versions)
+	}
+
+	serving.Recorder().UpdateService(mock.Any(), false, conflict)
+	err := updateWithRetry(context.Background(), serving, existing, update, 0)
+	if !goerrors.Is(err, ErrModifiedConcurrently) || !strings.Contains(err.Error(), "resourceVersion 1") {
+		t.Fatalf("expected the service to be modified concurrently, got '%v'", err)
+	}
+	serving.Recorder().Validate()
+}
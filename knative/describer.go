@@ -2,10 +2,16 @@ package knative
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "knative.dev/client/pkg/serving/v1"
 	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/pkg/apis"
+	"knative.dev/serving/pkg/apis/autoscaling"
 
 	fn "github.com/boson-project/func"
 	"github.com/boson-project/func/k8s"
@@ -14,6 +20,14 @@ import (
 type Describer struct {
 	Verbose   bool
 	namespace string
+	// ServingClient, EventingClient and DomainMappingClient factories,
+	// defaulting to NewServingClient, NewEventingClient and
+	// NewDomainMappingClient.
+	ServingClient       ServingClientFactory
+	EventingClient      EventingClientFactory
+	DomainMappingClient DomainMappingClientFactory
+	// SourceClient factory, defaulting to NewSourceClient.
+	SourceClient SourceClientFactory
 }
 
 func NewDescriber(namespaceOverride string) (describer *Describer, err error) {
@@ -33,12 +47,12 @@ func NewDescriber(namespaceOverride string) (describer *Describer, err error) {
 // www.example-site.com -> www-example--site-com
 func (d *Describer) Describe(ctx context.Context, name string) (description fn.Description, err error) {
 
-	servingClient, err := NewServingClient(d.namespace)
+	servingClient, err := servingClient(d.ServingClient, d.namespace)
 	if err != nil {
 		return
 	}
 
-	eventingClient, err := NewEventingClient(d.namespace)
+	eventingClient, err := eventingClient(d.EventingClient, d.namespace)
 	if err != nil {
 		return
 	}
@@ -58,36 +72,258 @@ func (d *Describer) Describe(ctx context.Context, name string) (description fn.D
 		routeURLs = append(routeURLs, route.Status.URL.String())
 	}
 
+	// The URLs of custom domains mapped to the Function are routes too.
+	domainClient, err := domainMappingClient(d.DomainMappingClient, d.namespace)
+	if err != nil {
+		return
+	}
+	domains, err := domainURLs(ctx, domainClient, name)
+	if err != nil {
+		return
+	}
+	routeURLs = append(routeURLs, domains...)
+
 	triggers, err := eventingClient.ListTriggers(ctx)
 	// IsNotFound -- Eventing is probably not installed on the cluster
 	if err != nil && !errors.IsNotFound(err) {
 		return
 	}
 
+	// The Triggers of a chained Function are subscribed to its chain.
 	triggerMatches := func(t *v1beta1.Trigger) bool {
 		return (t.Spec.Subscriber.Ref != nil && t.Spec.Subscriber.Ref.Name == service.Name) ||
+			(t.Spec.Subscriber.Ref != nil && t.Spec.Subscriber.Ref.Kind == "Sequence" && t.Spec.Subscriber.Ref.Name == chainName(service.Name)) ||
 			(t.Spec.Subscriber.URI != nil && service.Status.Address != nil && service.Status.Address.URL != nil &&
 				t.Spec.Subscriber.URI.Path == service.Status.Address.URL.Path)
 
 	}
 
 	subscriptions := make([]fn.Subscription, 0, len(triggers.Items))
+	functionTriggers := make([]fn.Trigger, 0, len(triggers.Items))
 	for _, trigger := range triggers.Items {
 		if triggerMatches(&trigger) {
-			filterAttrs := trigger.Spec.Filter.Attributes
+			var filterAttrs v1beta1.TriggerFilterAttributes
+			if trigger.Spec.Filter != nil {
+				filterAttrs = trigger.Spec.Filter.Attributes
+			}
 			subscription := fn.Subscription{
 				Source: filterAttrs["source"],
 				Type:   filterAttrs["type"],
 				Broker: trigger.Spec.Broker,
 			}
 			subscriptions = append(subscriptions, subscription)
+			functionTriggers = append(functionTriggers, describeTrigger(trigger, filterAttrs))
 		}
 	}
 
 	description.Name = name
 	description.Namespace = d.namespace
 	description.Routes = routeURLs
+	description.Revision = service.Status.LatestReadyRevisionName
+	description.Ready = string(corev1.ConditionUnknown)
+	if ready := service.Status.GetCondition(apis.ConditionReady); ready != nil {
+		description.Ready = string(ready.Status)
+	}
+	description.Conditions = conditions(service)
+	description.ServiceAccount = service.Spec.Template.Spec.ServiceAccountName
+	description.ChangeCause = service.Spec.Template.Annotations[ChangeCauseAnnotation]
+	description.Mesh = mesh(service.Spec.Template.Annotations)
+	description.Metrics = metricsEndpoint(service.Spec.Template.Annotations)
+	for _, c := range service.Spec.Template.Spec.InitContainers {
+		description.InitContainers = append(description.InitContainers, fn.InitContainer{Name: c.Name, Image: c.Image, Command: c.Command})
+	}
+	description.Autoscaling = describeAutoscaling(service.Spec.Template.Annotations)
+	description.IngressClass = service.Annotations[IngressClassAnnotation]
+	description.Locked = service.Annotations[LockedAnnotation] == "true"
+	description.Invocation = service.Annotations[InvocationAnnotation]
+	if containers := service.Spec.Template.Spec.Containers; len(containers) > 0 {
+		description.LivenessPath = probePath(containers[0].LivenessProbe)
+		description.ReadinessPath = probePath(containers[0].ReadinessProbe)
+		description.ImagePullPolicy = imagePullPolicy(containers[0])
+		description.Tracing = tracingEndpoint(containers[0])
+		if ports := containers[0].Ports; len(ports) > 0 {
+			description.Port = int(ports[0].ContainerPort)
+		}
+		description.Volumes = describeVolumes(service.Spec.Template.Spec.Volumes, containers[0].VolumeMounts)
+		description.ExtendedResources = describeExtended(containers[0].Resources)
+	}
+	if timeout := service.Spec.Template.Spec.TimeoutSeconds; timeout != nil {
+		description.RequestTimeout = *timeout
+	}
 	description.Subscriptions = subscriptions
+	description.Triggers = functionTriggers
+
+	// The sources of which the Function is the sink, by reference to its
+	// Service or by its URL.
+	sources, err := sourceClient(d.SourceClient, d.namespace)
+	if err != nil {
+		return
+	}
+	urls := append([]string{}, routeURLs...)
+	if service.Status.Address != nil && service.Status.Address.URL != nil {
+		urls = append(urls, service.Status.Address.URL.String())
+	}
+	if description.Sources, err = describeSources(ctx, sources, service.Name, urls...); err != nil {
+		return
+	}
+	if description.Chain, err = describeChain(ctx, sources, service); err != nil {
+		return
+	}
+
+	return
+}
+
+// describeTrigger returns the detailed description of the Trigger, with its
+// filters sorted by attribute and its readiness.
+func describeTrigger(trigger v1beta1.Trigger, filterAttrs v1beta1.TriggerFilterAttributes) fn.Trigger {
+	t := fn.Trigger{
+		Name:    trigger.Name,
+		Broker:  trigger.Spec.Broker,
+		Filters: make([]fn.TriggerFilter, 0, len(filterAttrs)),
+		Ready:   string(corev1.ConditionUnknown),
+	}
+	for attribute, value := range filterAttrs {
+		t.Filters = append(t.Filters, fn.TriggerFilter{Attribute: attribute, Value: value})
+	}
+	sort.Slice(t.Filters, func(i, j int) bool { return t.Filters[i].Attribute < t.Filters[j].Attribute })
+	if ready := trigger.Status.GetCondition(apis.ConditionReady); ready != nil {
+		t.Ready = string(ready.Status)
+		if !ready.IsTrue() {
+			t.Reason = ready.Reason
+			if ready.Message != "" {
+				t.Reason = fmt.Sprintf("%v: %v", ready.Reason, ready.Message)
+			}
+		}
+	}
+	return t
+}
+
+// describeVolumes returns the Secrets, ConfigMaps and emptyDirs mounted by
+// the container, as they are declared in func.yaml.
+func describeVolumes(volumes []corev1.Volume, mounts []corev1.VolumeMount) (described []fn.Volume) {
+	for _, m := range mounts {
+		path := m.MountPath
+		for _, v := range volumes {
+			if v.Name != m.Name {
+				continue
+			}
+			switch {
+			case v.Secret != nil:
+				name := v.Secret.SecretName
+				described = append(described, fn.Volume{Secret: &name, Path: &path})
+			case v.ConfigMap != nil:
+				name := v.ConfigMap.Name
+				described = append(described, fn.Volume{ConfigMap: &name, Path: &path})
+			case v.EmptyDir != nil:
+				emptyDir := &fn.EmptyDir{Medium: string(v.EmptyDir.Medium)}
+				if v.EmptyDir.SizeLimit != nil {
+					limit := v.EmptyDir.SizeLimit.String()
+					emptyDir.SizeLimit = &limit
+				}
+				described = append(described, fn.Volume{EmptyDir: emptyDir, Path: &path})
+			}
+		}
+	}
+	return
+}
 
+// describeExtended returns the extended resources of the container, such as
+// nvidia.com/gpu, in order: their limits, or requests if not limited, which
+// are equal.  Resources native to Kubernetes, such as cpu, are not extended.
+func describeExtended(resources corev1.ResourceRequirements) (extended []fn.ExtendedResource) {
+	quantities := map[corev1.ResourceName]string{}
+	for name, q := range resources.Requests {
+		quantities[name] = q.String()
+	}
+	for name, q := range resources.Limits {
+		quantities[name] = q.String()
+	}
+	for name, quantity := range quantities {
+		if !strings.Contains(string(name), "/") || strings.Contains(string(name), "kubernetes.io/") {
+			continue
+		}
+		extended = append(extended, fn.ExtendedResource{Name: string(name), Quantity: quantity})
+	}
+	sort.Slice(extended, func(i, j int) bool { return extended[i].Name < extended[j].Name })
 	return
 }
+
+// describeAutoscaling returns the autoscaling set by the annotations of the
+// revision template, those not set being empty.
+func describeAutoscaling(annotations map[string]string) fn.Autoscaling {
+	return fn.Autoscaling{
+		Min:             annotations[autoscaling.MinScaleAnnotationKey],
+		Max:             annotations[autoscaling.MaxScaleAnnotationKey],
+		Window:          annotations[autoscaling.WindowAnnotationKey],
+		ScaleDownDelay:  annotations[autoscaling.ScaleDownDelayAnnotationKey],
+		RetentionPeriod: annotations[autoscaling.ScaleToZeroPodRetentionPeriodKey],
+	}
+}
+
+// imagePullPolicy returns the effective image pull policy of the container:
+// that set or, as defaulted by Kubernetes, Always for images of the latest
+// tag or without a tag, and IfNotPresent otherwise.
+func imagePullPolicy(container corev1.Container) string {
+	if container.ImagePullPolicy != "" {
+		return string(container.ImagePullPolicy)
+	}
+	if strings.Contains(container.Image, "@") {
+		return string(corev1.PullIfNotPresent)
+	}
+	name := container.Image[strings.LastIndex(container.Image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i < 0 || name[i+1:] == "latest" {
+		return string(corev1.PullAlways)
+	}
+	return string(corev1.PullIfNotPresent)
+}
+
+// mesh returns the mesh of which the sidecar is injected into the pods of
+// the template of the given annotations, if any.
+func mesh(annotations map[string]string) string {
+	for _, m := range fn.Meshes {
+		injected := true
+		for k, v := range fn.MeshAnnotations(m) {
+			if annotations[k] != v {
+				injected = false
+			}
+		}
+		if injected {
+			return m
+		}
+	}
+	return ""
+}
+
+// metricsEndpoint returns the port and path, such as ":9095/metrics", at
+// which the pods of the template of the given annotations are scraped for
+// metrics, if they are.
+func metricsEndpoint(annotations map[string]string) string {
+	port := annotations["prometheus.io/port"]
+	if annotations["prometheus.io/scrape"] != "true" || port == "" {
+		return ""
+	}
+	path := annotations["prometheus.io/path"]
+	if path == "" {
+		path = fn.DefaultMetricsPath
+	}
+	return ":" + port + path
+}
+
+// tracingEndpoint returns the OTLP endpoint to which the container exports
+// spans, if it is traced.
+func tracingEndpoint(container corev1.Container) string {
+	for _, e := range container.Env {
+		if e.Name == fn.TracingEndpointEnv {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+// probePath returns the path of the HTTP probe, if any.
+func probePath(probe *corev1.Probe) string {
+	if probe == nil || probe.HTTPGet == nil {
+		return ""
+	}
+	return probe.HTTPGet.Path
+}
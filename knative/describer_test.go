@@ -0,0 +1,163 @@
+// +build !integration
+
+package knative
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/client/pkg/util/mock"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/serving/pkg/apis/autoscaling"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	servingv1alpha1 "knative.dev/serving/pkg/apis/serving/v1alpha1"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_Describe ensures the deployed Function is described from its Knative
+// Service, Routes, the domains mapped to it and the Triggers which subscribe
+// it to events, and the sources of which it is the sink.
+func Test_Describe(t *testing.T) {
+	serving, servingFactory := mockServing(t, "test")
+	eventing, eventingFactory := mockEventing(t, "test")
+	domains, domainsFactory := mockDomainMappings(t, "test")
+	_, sourcesFactory := mockSources(t, "test", sourceCRD("PingSource"),
+		newSource("test", "PingSource", "heartbeat", map[string]interface{}{"ref": map[string]interface{}{"apiVersion": "serving.knative.dev/v1", "kind": "Service", "name": "myfunc"}}),
+		newSource("test", "PingSource", "other", map[string]interface{}{"ref": map[string]interface{}{"apiVersion": "serving.knative.dev/v1", "kind": "Service", "name": "other"}}))
+
+	service := &servingv1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfunc", Namespace: "test", Annotations: map[string]string{IngressClassAnnotation: "kourier.ingress.networking.knative.dev", LockedAnnotation: "true", InvocationAnnotation: "events"}},
+		Spec: servingv1.ServiceSpec{ConfigurationSpec: servingv1.ConfigurationSpec{Template: servingv1.RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{ChangeCauseAnnotation: "Fix the handling of empty payloads", "linkerd.io/inject": "enabled", "prometheus.io/scrape": "true", "prometheus.io/port": "9095"}},
+			Spec: servingv1.RevisionSpec{PodSpec: corev1.PodSpec{
+				ServiceAccountName: "myfunc-sa",
+				InitContainers:     []corev1.Container{{Name: "migrate", Image: "example.com/alice/migrate:v1", Command: []string{"migrate", "up"}}},
+				Containers: []corev1.Container{{
+					ImagePullPolicy: corev1.PullNever,
+					Env:             []corev1.EnvVar{{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: "http://otel-collector:4317"}},
+					ReadinessProbe:  &corev1.Probe{Handler: corev1.Handler{HTTPGet: &corev1.HTTPGetAction{Path: "/ready"}}},
+				}},
+			}},
+		}}},
+	}
+	service.Status.SetConditions(apis.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionTrue}})
+	url, _ := apis.ParseURL("http://myfunc.test.example.com")
+	route := servingv1.Route{Status: servingv1.RouteStatus{RouteStatusFields: servingv1.RouteStatusFields{URL: url}}}
+	trigger := v1beta1.Trigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfunc-trigger"},
+		Spec: v1beta1.TriggerSpec{
+			Broker:     "default",
+			Filter:     &v1beta1.TriggerFilter{Attributes: v1beta1.TriggerFilterAttributes{"type": "com.example.event", "source": "/example"}},
+			Subscriber: duckv1.Destination{Ref: &duckv1.KReference{Kind: "Service", Name: "myfunc"}},
+		},
+	}
+	trigger.Status.SetConditions(apis.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionFalse, Reason: "BrokerDoesNotExist"}})
+
+	serving.Recorder().GetService("myfunc", service, nil)
+	serving.Recorder().ListRoutes(mock.Any(), &servingv1.RouteList{Items: []servingv1.Route{route}}, nil)
+	eventing.Recorder().ListTriggers(&v1beta1.TriggerList{Items: []v1beta1.Trigger{trigger}}, nil)
+	domains.Recorder().ListDomainMappings(&servingv1alpha1.DomainMappingList{Items: []servingv1alpha1.DomainMapping{
+		{ObjectMeta: metav1.ObjectMeta{Name: "myfunc.example.com"}, Spec: servingv1alpha1.DomainMappingSpec{Ref: duckv1.KReference{Kind: "Service", Name: "myfunc"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other.example.com"}, Spec: servingv1alpha1.DomainMappingSpec{Ref: duckv1.KReference{Kind: "Service", Name: "other"}}},
+	}}, nil)
+
+	describer := &Describer{namespace: "test", ServingClient: servingFactory, EventingClient: eventingFactory, DomainMappingClient: domainsFactory, SourceClient: sourcesFactory}
+	description, err := describer.Describe(context.Background(), "myfunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fn.Description{
+		Name:            "myfunc",
+		Namespace:       "test",
+		Routes:          []string{"http://myfunc.test.example.com", "http://myfunc.example.com"},
+		Ready:           "True",
+		Conditions:      []string{"Ready=True"},
+		ServiceAccount:  "myfunc-sa",
+		ImagePullPolicy: "Never",
+		Mesh:            "linkerd",
+		Metrics:         ":9095/metrics",
+		Tracing:         "http://otel-collector:4317",
+		InitContainers:  []fn.InitContainer{{Name: "migrate", Image: "example.com/alice/migrate:v1", Command: []string{"migrate", "up"}}},
+		IngressClass:    "kourier.ingress.networking.knative.dev",
+		Locked:          true,
+		Invocation:      "events",
+		ReadinessPath:   "/ready",
+		ChangeCause:     "Fix the handling of empty payloads",
+		Subscriptions:   []fn.Subscription{{Source: "/example", Type: "com.example.event", Broker: "default"}},
+		Triggers: []fn.Trigger{{
+			Name:    "myfunc-trigger",
+			Broker:  "default",
+			Filters: []fn.TriggerFilter{{Attribute: "source", Value: "/example"}, {Attribute: "type", Value: "com.example.event"}},
+			Ready:   "False",
+			Reason:  "BrokerDoesNotExist",
+		}},
+		Sources: []fn.Source{{Kind: "PingSource", Name: "heartbeat", Ready: "Unknown"}},
+	}
+	if !reflect.DeepEqual(description, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, description)
+	}
+	serving.Recorder().Validate()
+	eventing.Recorder().Validate()
+	domains.Recorder().Validate()
+}
+
+// Test_imagePullPolicy ensures that the effective image pull policy is that
+// set, or that defaulted by Kubernetes for the image.
+func Test_imagePullPolicy(t *testing.T) {
+	tests := []struct {
+		image  string
+		policy corev1.PullPolicy
+		want   string
+	}{
+		{"example.com/alice/myfunc:v1", corev1.PullNever, "Never"},
+		{"example.com/alice/myfunc:v1", "", "IfNotPresent"},
+		{"example.com/alice/myfunc@sha256:a278a9", "", "IfNotPresent"},
+		{"example.com/alice/myfunc:latest", "", "Always"},
+		{"localhost:5000/myfunc", "", "Always"},
+	}
+	for _, tt := range tests {
+		if got := imagePullPolicy(corev1.Container{Image: tt.image, ImagePullPolicy: tt.policy}); got != tt.want {
+			t.Errorf("imagePullPolicy(%v, %v) = %v, want %v", tt.image, tt.policy, got, tt.want)
+		}
+	}
+}
+
+// Test_describeExtended ensures that the extended resources of the container
+// are described in order, and not those native to Kubernetes.
+func Test_describeExtended(t *testing.T) {
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:   resource.MustParse("500m"),
+			"nvidia.com/gpu":     resource.MustParse("1"),
+			"example.com/dongle": resource.MustParse("2"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+			"nvidia.com/gpu":      resource.MustParse("1"),
+		},
+	}
+	want := []fn.ExtendedResource{{Name: "example.com/dongle", Quantity: "2"}, {Name: "nvidia.com/gpu", Quantity: "1"}}
+	if got := describeExtended(resources); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected the extended resources %v, got %v", want, got)
+	}
+}
+
+// Test_describeAutoscaling ensures the autoscaling is that annotated, those
+// not annotated being empty such that the defaults of the cluster apply.
+func Test_describeAutoscaling(t *testing.T) {
+	annotations := map[string]string{
+		autoscaling.MinScaleAnnotationKey:       "1",
+		autoscaling.ScaleDownDelayAnnotationKey: "15m",
+	}
+	want := fn.Autoscaling{Min: "1", ScaleDownDelay: "15m"}
+	if got := describeAutoscaling(annotations); got != want {
+		t.Fatalf("expected the autoscaling %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,283 @@
+package knative
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/yaml"
+
+	fn "github.com/boson-project/func"
+)
+
+// ServiceDiff is the difference between the Knative Service of a Function as
+// deployed and as deploying it would patch it, such as to detect drift.  Only
+// the fields the Function manages are compared (its labels, annotations and
+// spec, such as its image, envs and scale), without those which differ with
+// every deploy (see changes).
+type ServiceDiff struct {
+	// Name of the Function.
+	Name string `json:"name" yaml:"name" xml:"name,attr"`
+	// Namespace of its Service.
+	Namespace string `json:"namespace" yaml:"namespace" xml:"namespace,attr"`
+	// Deployed is whether its Service exists.  If not, every field is added.
+	Deployed bool `json:"deployed" yaml:"deployed" xml:"deployed,attr"`
+	// Changes of the fields of the Service, by field.
+	Changes []FieldChange `json:"changes" yaml:"changes" xml:"change"`
+	// Unified diff of the Service as YAML, from that deployed to that of the
+	// Function, empty if they do not differ.
+	Unified string `json:"-" yaml:"-" xml:"-"`
+}
+
+// FieldChange is a field of a Service which differs: added, removed or
+// changed.  Lists of named items, such as envs, are keyed by name.
+type FieldChange struct {
+	// Field path, such as spec.template.spec.containers[0].env[MODE].value.
+	Field string `json:"field" yaml:"field" xml:"field,attr"`
+	// Deployed value of the field, none if it is added.
+	Deployed interface{} `json:"deployed,omitempty" yaml:"deployed,omitempty" xml:"deployed,omitempty"`
+	// Local value of the field, as of the Function, none if it is removed.
+	Local interface{} `json:"local,omitempty" yaml:"local,omitempty" xml:"local,omitempty"`
+}
+
+// Differs returns whether the Function differs from that deployed.
+func (d ServiceDiff) Differs() bool {
+	return len(d.Changes) > 0
+}
+
+// Diff the Knative Service of the Function, as deploying it would patch that
+// of the Deployer's Namespace, with that deployed.  A Function which is not
+// deployed differs by all of its fields.
+func (d *Deployer) Diff(ctx context.Context, f fn.Function) (diff ServiceDiff, err error) {
+	diff = ServiceDiff{Name: f.Name, Namespace: d.Namespace}
+	desired, err := generateNewService(f)
+	if err != nil {
+		return diff, fmt.Errorf("knative deployer failed to generate the Knative Service: %v", err)
+	}
+
+	client, err := servingClient(d.ServingClient, d.Namespace)
+	if err != nil {
+		return
+	}
+	before, after := "", ""
+	existing, err := client.GetService(ctx, f.Name)
+	if errors.IsNotFound(err) {
+		if after, err = comparable(desired); err != nil {
+			return
+		}
+	} else if err != nil {
+		return diff, fmt.Errorf("knative deployer failed to get the Knative Service: %v", err)
+	} else {
+		diff.Deployed = true
+		patched, err := patchService(desired)(existing.DeepCopy())
+		if err != nil {
+			return diff, err
+		}
+		if before, err = comparable(existing); err != nil {
+			return diff, err
+		}
+		if after, err = comparable(patched); err != nil {
+			return diff, err
+		}
+	}
+
+	if diff.Changes, err = fieldChanges(before, after); err != nil {
+		return
+	}
+	diff.Unified = unifiedDiff(lines(before), lines(after), "deployed/"+f.Name, "local/"+f.Name)
+	return
+}
+
+// fieldChanges between the Services rendered as YAML by comparable, sorted
+// by field.
+func fieldChanges(before, after string) ([]FieldChange, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := []FieldChange{}
+	for field, value := range b {
+		if local, ok := a[field]; !ok || !reflect.DeepEqual(value, local) {
+			changes = append(changes, FieldChange{Field: field, Deployed: value, Local: local})
+		}
+	}
+	for field, value := range a {
+		if _, ok := b[field]; !ok {
+			changes = append(changes, FieldChange{Field: field, Local: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// fields of the YAML, by path, of each of its values.
+func fields(y string) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	if y == "" {
+		return out, nil
+	}
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(y), &v); err != nil {
+		return nil, fmt.Errorf("knative deployer failed to compare the Knative Service: %v", err)
+	}
+	flatten("", v, out)
+	return out, nil
+}
+
+// plainKey is a key of a map which may be appended to a path with a dot.
+var plainKey = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// flatten the value into the values of its fields, by path.  The items of
+// lists are keyed by their name, if they have one, and otherwise by index,
+// and the keys of maps which are not plain, such as annotations, in brackets.
+func flatten(path string, v interface{}, out map[string]interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if !plainKey.MatchString(k) {
+				flatten(path+"["+k+"]", e, out)
+			} else if path == "" {
+				flatten(k, e, out)
+			} else {
+				flatten(path+"."+k, e, out)
+			}
+		}
+	case []interface{}:
+		for i, e := range v {
+			key := fmt.Sprint(i)
+			if m, ok := e.(map[string]interface{}); ok {
+				if name, ok := m["name"].(string); ok && name != "" {
+					key = name
+				}
+			}
+			flatten(path+"["+key+"]", e, out)
+		}
+	default:
+		out[path] = v
+	}
+}
+
+// lines of the text, none if empty.
+func lines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffContext is the number of lines of context of each hunk of a diff.
+const diffContext = 3
+
+// unifiedDiff of the lines a and b, labelled from and to, in the unified
+// format of diff -u, empty if they are equal.
+func unifiedDiff(a, b []string, from, to string) string {
+	// The longest common subsequence of the lines of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// Each line, kept, removed or added, with its line in a and b.
+	type op struct {
+		kind byte
+		text string
+		i, j int
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{' ', a[i], i, j})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, op{'+', b[j], i, j})
+			j++
+		default:
+			ops = append(ops, op{'-', a[i], i, j})
+			i++
+		}
+	}
+
+	var sb strings.Builder
+	for start := 0; start < len(ops); {
+		// The next hunk: the next change, with its context, extended while
+		// the context of the changes which follow overlaps.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for k := first; k < len(ops) && k <= last+2*diffContext; k++ {
+			if ops[k].kind != ' ' {
+				last = k
+			}
+		}
+		begin, end := max(first-diffContext, start), min(last+diffContext+1, len(ops))
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %v\n+++ %v\n", from, to)
+		}
+		var aLen, bLen int
+		for _, o := range ops[begin:end] {
+			if o.kind != '+' {
+				aLen++
+			}
+			if o.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%v +%v @@\n", hunkRange(ops[begin].i, aLen), hunkRange(ops[begin].j, bLen))
+		for _, o := range ops[begin:end] {
+			fmt.Fprintf(&sb, "%c%v\n", o.kind, o.text)
+		}
+		start = end
+	}
+	return sb.String()
+}
+
+// hunkRange of a hunk of the lines of a file from the index of its first,
+// as 1-based start,length, where an empty range starts at the line before.
+func hunkRange(index, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%v,0", index)
+	}
+	return fmt.Sprintf("%v,%v", index+1, length)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,96 @@
+package knative
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_Diff ensures the fields of a deployed Service which differ from those
+// of the Function are reported, keyed by name, with a unified diff, and that
+// a Function which is not deployed differs by all of its fields.
+func Test_Diff(t *testing.T) {
+	name, mode, debug := "MODE", "production", "debug"
+	f := fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc", ImageDigest: "sha256:b389b0", Runtime: "go",
+		Envs: fn.Envs{{Name: &name, Value: &mode}}}
+
+	existing, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc@sha256:a278a9", Runtime: "go", Envs: fn.Envs{{Name: &name, Value: &debug}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serving, factory := mockServing(t, "test")
+	serving.Recorder().GetService("myfunc", existing, nil)
+	deployer := &Deployer{Namespace: "test", ServingClient: factory}
+	diff, err := deployer.Diff(context.Background(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serving.Recorder().Validate()
+
+	expected := []FieldChange{
+		{"spec.template.spec.containers[0].env[MODE].value", "debug", "production"},
+		{"spec.template.spec.containers[0].image", "example.com/alice/myfunc@sha256:a278a9", "example.com/alice/myfunc@sha256:b389b0"},
+	}
+	if !diff.Deployed || len(diff.Changes) != len(expected) {
+		t.Fatalf("expected the changes %+v, got %+v", expected, diff.Changes)
+	}
+	for i, c := range diff.Changes {
+		if c != expected[i] {
+			t.Fatalf("expected the change %+v, got %+v", expected[i], c)
+		}
+	}
+	if !strings.HasPrefix(diff.Unified, "--- deployed/myfunc\n+++ local/myfunc\n@@ ") ||
+		!strings.Contains(diff.Unified, "\n-          value: debug\n") || !strings.Contains(diff.Unified, "\n+          value: production\n") {
+		t.Fatalf("expected a unified diff of the env, got:\n%v", diff.Unified)
+	}
+
+	serving.Recorder().GetService("myfunc", nil, apierrors.NewNotFound(servingv1.Resource("services"), "myfunc"))
+	if diff, err = deployer.Diff(context.Background(), f); err != nil {
+		t.Fatal(err)
+	}
+	if diff.Deployed || !diff.Differs() || !strings.Contains(diff.Unified, "\n+          value: production\n") {
+		t.Fatalf("expected all of the fields of a function not deployed to be added, got %+v", diff)
+	}
+	for _, c := range diff.Changes {
+		if c.Deployed != nil {
+			t.Fatalf("expected no deployed value of %v", c.Field)
+		}
+	}
+}
+
+// Test_unifiedDiff ensures changes are shown in hunks with their context,
+// which are merged where their context overlaps.
+func Test_unifiedDiff(t *testing.T) {
+	a := strings.Split("a b c d e f g h i j k l m n o p", " ")
+	b := strings.Split("a b c D e f g h i j k l m n o P q", " ")
+	expected := `--- a
++++ b
+@@ -1,7 +1,7 @@
+ a
+ b
+ c
+-d
++D
+ e
+ f
+ g
+@@ -13,4 +13,5 @@
+ m
+ n
+ o
+-p
++P
++q
+`
+	if diff := unifiedDiff(a, b, "a", "b"); diff != expected {
+		t.Fatalf("expected:\n%v\ngot:\n%v", expected, diff)
+	}
+	if diff := unifiedDiff(a, a, "a", "b"); diff != "" {
+		t.Fatalf("expected no diff of equal lines, got:\n%v", diff)
+	}
+}
@@ -0,0 +1,158 @@
+package knative
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	knerrors "knative.dev/client/pkg/errors"
+	clientservingv1alpha1 "knative.dev/client/pkg/serving/v1alpha1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	servingv1alpha1 "knative.dev/serving/pkg/apis/serving/v1alpha1"
+
+	fn "github.com/boson-project/func"
+)
+
+// domainLabel is set on the DomainMappings created for a Function, its value
+// being the name of the Function, such that those of domains it is no longer
+// configured with are removed on deploy.
+const domainLabel = "boson.dev/domain-of"
+
+// ErrDomainMappingUnsupported is returned when deploying a Function with a
+// domain to a cluster which does not serve the Knative DomainMapping API.
+var ErrDomainMappingUnsupported = goerrors.New("the cluster does not support custom domains: the Knative Serving DomainMapping API (serving.knative.dev/v1alpha1) is not installed. Install it, or remove the domain of the function")
+
+// domainMappingUnsupported returns whether the error is that of a request
+// for a resource of an API which is not served.
+func domainMappingUnsupported(err error) bool {
+	var knerr *knerrors.KNError
+	if goerrors.As(err, &knerr) && knerr.Status != nil {
+		return true
+	}
+	var status errors.APIStatus
+	return errors.IsNotFound(err) && goerrors.As(err, &status) &&
+		(status.Status().Details == nil || status.Status().Details.Name == "")
+}
+
+// checkDomain ensures the Function's domain, if any, may be mapped to it:
+// that DomainMappings are supported by the cluster, and that the domain is
+// not mapped to another Service.  This is checked before the Service is
+// deployed, such that it is not deployed if the domain can not be mapped.
+func checkDomain(ctx context.Context, client clientservingv1alpha1.KnServingClient, f fn.Function) error {
+	if f.Domain == "" {
+		return nil
+	}
+	mapping, err := client.GetDomainMapping(ctx, f.Domain)
+	if err != nil {
+		if domainMappingUnsupported(err) {
+			return ErrDomainMappingUnsupported
+		}
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("knative deployer failed to get the DomainMapping: %v", err)
+	}
+	if ref := mapping.Spec.Ref; ref.Kind != "Service" || ref.Name != f.Name {
+		return fmt.Errorf("domain '%v' is already mapped to %v '%v'", f.Domain, ref.Kind, ref.Name)
+	}
+	return nil
+}
+
+// mapDomain maps the Function's domain to its deployed Service, returning the
+// URL at which it is then reachable.  The DomainMapping is owned by the
+// Service, such that it is removed along with it.
+func mapDomain(ctx context.Context, client clientservingv1alpha1.KnServingClient, f fn.Function, service *servingv1.Service) (url string, err error) {
+	url = apis.HTTP(f.Domain).String()
+
+	if _, err = client.GetDomainMapping(ctx, f.Domain); err == nil {
+		return // mapped by a previous deploy, as ensured by checkDomain
+	} else if !errors.IsNotFound(err) {
+		return "", fmt.Errorf("knative deployer failed to get the DomainMapping: %v", err)
+	}
+
+	mapping := newDomainMapping(f, client.Namespace())
+	mapping.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: servingv1.SchemeGroupVersion.String(),
+		Kind:       "Service",
+		Name:       service.Name,
+		UID:        service.UID,
+	}}
+	if err = client.CreateDomainMapping(ctx, mapping); err != nil {
+		return "", fmt.Errorf("knative deployer failed to map the domain '%v': %v", f.Domain, err)
+	}
+	return
+}
+
+// newDomainMapping returns the DomainMapping of the Function's domain to its
+// Service in the given namespace.
+func newDomainMapping(f fn.Function, namespace string) *servingv1alpha1.DomainMapping {
+	mapping := clientservingv1alpha1.NewDomainMappingBuilder(f.Domain).
+		Namespace(namespace).
+		Reference(duckv1.KReference{
+			APIVersion: servingv1.SchemeGroupVersion.String(),
+			Kind:       "Service",
+			Name:       f.Name,
+			Namespace:  namespace,
+		}).
+		Build()
+	mapping.Labels = map[string]string{domainLabel: f.Name}
+	return mapping
+}
+
+// removeStaleDomains removes the DomainMappings created for the Function
+// other than that of its current domain.  When the Function has no domain
+// failing to list them is not an error, as is the case on a cluster which
+// does not support DomainMappings.
+func removeStaleDomains(ctx context.Context, client clientservingv1alpha1.KnServingClient, f fn.Function) error {
+	mappings, err := client.ListDomainMappings(ctx)
+	if err != nil {
+		if f.Domain == "" {
+			return nil
+		}
+		return fmt.Errorf("knative deployer failed to list the DomainMappings: %v", err)
+	}
+	for _, mapping := range mappings.Items {
+		if mapping.Labels[domainLabel] != f.Name || mapping.Name == f.Domain {
+			continue
+		}
+		if err = client.DeleteDomainMapping(ctx, mapping.Name); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("knative deployer failed to remove the DomainMapping of the domain '%v': %v", mapping.Name, err)
+		}
+	}
+	return nil
+}
+
+// domainURLs returns the URLs of the domains mapped to the Service of the
+// given name.  None are returned if the cluster does not support
+// DomainMappings, or if they may not be listed, as is the case for users
+// whose access is limited to the Services of the namespace.
+func domainURLs(ctx context.Context, client clientservingv1alpha1.KnServingClient, name string) ([]string, error) {
+	mappings, err := client.ListDomainMappings(ctx)
+	if err != nil {
+		if domainMappingUnsupported(err) || errors.IsForbidden(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	urls := []string{}
+	for _, mapping := range mappings.Items {
+		if ref := mapping.Spec.Ref; ref.Kind != "Service" || ref.Name != name {
+			continue
+		}
+		urls = append(urls, domainMappingURL(mapping))
+	}
+	return urls, nil
+}
+
+// domainMappingURL returns the URL of the DomainMapping, as reported in its
+// status once ready, or otherwise derived from its name.
+func domainMappingURL(mapping servingv1alpha1.DomainMapping) string {
+	if mapping.Status.URL != nil {
+		return mapping.Status.URL.String()
+	}
+	return apis.HTTP(mapping.Name).String()
+}
@@ -0,0 +1,179 @@
+// +build !integration
+
+package knative
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	knerrors "knative.dev/client/pkg/errors"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	servingv1alpha1 "knative.dev/serving/pkg/apis/serving/v1alpha1"
+
+	fn "github.com/boson-project/func"
+)
+
+// unsupportedErr is the error of a request for a resource of an API which the
+// cluster does not serve, as translated by the Knative client.
+func unsupportedErr() error {
+	return knerrors.GetError(&apierrors.StatusError{ErrStatus: metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   404,
+		Reason: metav1.StatusReasonNotFound,
+		Details: &metav1.StatusDetails{
+			Group:  "serving.knative.dev",
+			Causes: []metav1.StatusCause{{Type: metav1.CauseTypeUnexpectedServerResponse, Message: "404 page not found"}},
+		},
+	}})
+}
+
+func notFoundErr(name string) error {
+	return apierrors.NewNotFound(servingv1alpha1.Resource("domainmappings"), name)
+}
+
+// Test_checkDomain ensures a domain may only be mapped when DomainMappings
+// are supported and the domain is not mapped to another Service.
+func Test_checkDomain(t *testing.T) {
+	f := fn.Function{Name: "myfunc", Domain: "myfunc.example.com"}
+	mapped := func(name string) *servingv1alpha1.DomainMapping {
+		return &servingv1alpha1.DomainMapping{Spec: servingv1alpha1.DomainMappingSpec{Ref: duckv1.KReference{Kind: "Service", Name: name}}}
+	}
+
+	tests := []struct {
+		name    string
+		mapping *servingv1alpha1.DomainMapping
+		err     error
+		wantErr bool
+	}{
+		{"unmapped", nil, notFoundErr(f.Domain), false},
+		{"mapped to the function", mapped("myfunc"), nil, false},
+		{"mapped to another service", mapped("other"), nil, true},
+		{"unsupported", nil, unsupportedErr(), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains, _ := mockDomainMappings(t, "test")
+			domains.Recorder().GetDomainMapping(f.Domain, tt.mapping, tt.err)
+			if err := checkDomain(context.Background(), domains, f); (err != nil) != tt.wantErr {
+				t.Fatalf("checkDomain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			domains.Recorder().Validate()
+		})
+	}
+
+	domains, _ := mockDomainMappings(t, "test")
+	domains.Recorder().GetDomainMapping(f.Domain, nil, unsupportedErr())
+	if err := checkDomain(context.Background(), domains, f); !errors.Is(err, ErrDomainMappingUnsupported) {
+		t.Fatalf("expected ErrDomainMappingUnsupported, got %v", err)
+	}
+}
+
+// Test_mapDomain ensures the DomainMapping created references, and is owned
+// by, the Service of the Function.
+func Test_mapDomain(t *testing.T) {
+	domains, _ := mockDomainMappings(t, "test")
+	f := fn.Function{Name: "myfunc", Domain: "myfunc.example.com"}
+	service := &servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "myfunc", UID: "1234"}}
+
+	domains.Recorder().GetDomainMapping(f.Domain, nil, notFoundErr(f.Domain))
+	domains.Recorder().CreateDomainMapping(func(t *testing.T, a interface{}) {
+		mapping := a.(*servingv1alpha1.DomainMapping)
+		if mapping.Name != f.Domain || mapping.Namespace != "test" {
+			t.Fatalf("unexpected DomainMapping %v/%v", mapping.Namespace, mapping.Name)
+		}
+		if mapping.Spec.Ref.Kind != "Service" || mapping.Spec.Ref.Name != "myfunc" {
+			t.Fatalf("unexpected reference %+v", mapping.Spec.Ref)
+		}
+		if len(mapping.OwnerReferences) != 1 || mapping.OwnerReferences[0].UID != "1234" {
+			t.Fatalf("expected the DomainMapping to be owned by the Service, got %+v", mapping.OwnerReferences)
+		}
+		if mapping.Labels[domainLabel] != "myfunc" {
+			t.Fatalf("expected label %v=myfunc, got %v", domainLabel, mapping.Labels)
+		}
+	}, nil)
+
+	url, err := mapDomain(context.Background(), domains, f, service)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "http://myfunc.example.com" {
+		t.Fatalf("unexpected URL '%v'", url)
+	}
+	domains.Recorder().Validate()
+}
+
+// Test_removeStaleDomains ensures only those DomainMappings created for the
+// Function, of domains other than its current, are removed.
+func Test_removeStaleDomains(t *testing.T) {
+	domains, _ := mockDomainMappings(t, "test")
+	f := fn.Function{Name: "myfunc", Domain: "new.example.com"}
+	mapping := func(name, function string) servingv1alpha1.DomainMapping {
+		return servingv1alpha1.DomainMapping{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{domainLabel: function}}}
+	}
+
+	domains.Recorder().ListDomainMappings(&servingv1alpha1.DomainMappingList{Items: []servingv1alpha1.DomainMapping{
+		mapping("old.example.com", "myfunc"),
+		mapping("new.example.com", "myfunc"),
+		mapping("other.example.com", "other"),
+		{ObjectMeta: metav1.ObjectMeta{Name: "manual.example.com"}},
+	}}, nil)
+	domains.Recorder().DeleteDomainMapping("old.example.com", nil)
+
+	if err := removeStaleDomains(context.Background(), domains, f); err != nil {
+		t.Fatal(err)
+	}
+	domains.Recorder().Validate()
+}
+
+// Test_removeStaleDomainsUnsupported ensures a Function without a domain may
+// be deployed to a cluster which does not support DomainMappings.
+func Test_removeStaleDomainsUnsupported(t *testing.T) {
+	domains, _ := mockDomainMappings(t, "test")
+	domains.Recorder().ListDomainMappings(nil, unsupportedErr())
+
+	if err := removeStaleDomains(context.Background(), domains, fn.Function{Name: "myfunc"}); err != nil {
+		t.Fatal(err)
+	}
+	domains.Recorder().Validate()
+}
+
+// Test_deployDomain ensures the DomainMappings of a Function are listed only
+// if it has a domain, or was last deployed with one, of which the mapping is
+// then removed.
+func Test_deployDomain(t *testing.T) {
+	d := &Deployer{Namespace: "test"}
+
+	domains, _ := mockDomainMappings(t, "test")
+	if err := d.deployDomain(context.Background(), nil, domains, fn.Function{Name: "myfunc"}); err != nil {
+		t.Fatal(err)
+	}
+	domains.Recorder().Validate()
+
+	domains, _ = mockDomainMappings(t, "test")
+	domains.Recorder().ListDomainMappings(&servingv1alpha1.DomainMappingList{Items: []servingv1alpha1.DomainMapping{
+		{ObjectMeta: metav1.ObjectMeta{Name: "old.example.com", Labels: map[string]string{domainLabel: "myfunc"}}},
+	}}, nil)
+	domains.Recorder().DeleteDomainMapping("old.example.com", nil)
+	f := fn.Function{Name: "myfunc", Status: fn.FunctionStatus{Domain: "old.example.com"}}
+	if err := d.deployDomain(context.Background(), nil, domains, f); err != nil {
+		t.Fatal(err)
+	}
+	domains.Recorder().Validate()
+}
+
+// Test_domainURLsForbidden ensures a Function is described without the URLs
+// of its domains by users who may not list DomainMappings.
+func Test_domainURLsForbidden(t *testing.T) {
+	domains, _ := mockDomainMappings(t, "test")
+	domains.Recorder().ListDomainMappings(nil, apierrors.NewForbidden(servingv1alpha1.Resource("domainmappings"), "", errors.New("denied")))
+
+	urls, err := domainURLs(context.Background(), domains, "myfunc")
+	if err != nil || len(urls) != 0 {
+		t.Fatalf("expected no domains where they may not be listed, got %v (%v)", urls, err)
+	}
+	domains.Recorder().Validate()
+}
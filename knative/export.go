@@ -0,0 +1,52 @@
+package knative
+
+import (
+	"context"
+	"fmt"
+
+	servingv1alpha1 "knative.dev/serving/pkg/apis/serving/v1alpha1"
+	"sigs.k8s.io/yaml"
+
+	fn "github.com/boson-project/func"
+)
+
+// Manifest of an object which deploying a Function applies, rendered as YAML.
+type Manifest struct {
+	// Kind of the object, such as Service.
+	Kind string
+	// Name of the object.
+	Name string
+	// YAML of the object.
+	YAML []byte
+}
+
+// Export renders the objects which deploying the Function applies, without
+// contacting the cluster: its Knative Service, generated as for a client dry
+// run with all of the Function's settings, and the DomainMapping of its
+// domain, if any.  The objects are of the Deployer's Namespace, or of none
+// when it is empty, such that they are applied to that of the client.
+//
+// The objects are those of the Function as declared: the DomainMapping is not
+// owned by the Service, as it is when deployed, and the sources of which the
+// Function is made the sink, which exist independently, are not included.
+func (d *Deployer) Export(ctx context.Context, f fn.Function) ([]Manifest, error) {
+	local := *d
+	local.DryRun = DryRunClient
+	service, err := local.render(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	manifests := []Manifest{{Kind: "Service", Name: f.Name, YAML: service}}
+
+	if f.Domain != "" {
+		mapping := newDomainMapping(f, d.Namespace)
+		mapping.APIVersion = servingv1alpha1.SchemeGroupVersion.String()
+		mapping.Kind = "DomainMapping"
+		out, err := yaml.Marshal(mapping)
+		if err != nil {
+			return nil, fmt.Errorf("knative deployer failed to render the DomainMapping: %v", err)
+		}
+		manifests = append(manifests, Manifest{Kind: "DomainMapping", Name: f.Domain, YAML: out})
+	}
+	return manifests, nil
+}
@@ -0,0 +1,66 @@
+package knative
+
+import (
+	"context"
+	"testing"
+
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	servingv1alpha1 "knative.dev/serving/pkg/apis/serving/v1alpha1"
+	"sigs.k8s.io/yaml"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_Export ensures that the Knative Service is rendered locally with the
+// Function's settings, even for a Deployer of server dry runs, along with the
+// DomainMapping of its domain.
+func Test_Export(t *testing.T) {
+	name, value := "GREETING", "hello"
+	min := int64(1)
+	f := fn.Function{
+		Name:        "myfunc",
+		Runtime:     "go",
+		Image:       "quay.io/alice/myfunc",
+		Domain:      "myfunc.example.com",
+		Envs:        fn.Envs{{Name: &name, Value: &value}},
+		Annotations: map[string]string{"team": "payments"},
+		Options:     fn.Options{Scale: &fn.ScaleOptions{Min: &min}},
+	}
+	d := &Deployer{DryRun: DryRunServer}
+	manifests, err := d.Export(context.Background(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 2 || manifests[0].Kind != "Service" || manifests[1].Kind != "DomainMapping" {
+		t.Fatalf("expected the Service and DomainMapping, got %v", manifests)
+	}
+
+	var service servingv1.Service
+	if err = yaml.Unmarshal(manifests[0].YAML, &service); err != nil {
+		t.Fatal(err)
+	}
+	if service.Kind != "Service" || service.Name != "myfunc" || service.Namespace != "" {
+		t.Fatalf("unexpected service '%v %v/%v'", service.Kind, service.Namespace, service.Name)
+	}
+	container := service.Spec.Template.Spec.Containers[0]
+	var greeting string
+	for _, env := range container.Env {
+		if env.Name == name {
+			greeting = env.Value
+		}
+	}
+	if container.Image != "quay.io/alice/myfunc" || greeting != "hello" {
+		t.Fatalf("expected the image and envs of the function, got %v %v", container.Image, container.Env)
+	}
+	if service.Annotations["team"] != "payments" || service.Spec.Template.Annotations["autoscaling.knative.dev/minScale"] != "1" {
+		t.Fatalf("expected the annotations and scale of the function, got %v %v", service.Annotations, service.Spec.Template.Annotations)
+	}
+
+	var mapping servingv1alpha1.DomainMapping
+	if err = yaml.Unmarshal(manifests[1].YAML, &mapping); err != nil {
+		t.Fatal(err)
+	}
+	if mapping.Kind != "DomainMapping" || mapping.Name != "myfunc.example.com" || mapping.Spec.Ref.Name != "myfunc" {
+		t.Fatalf("unexpected mapping '%v %v' of '%v'", mapping.Kind, mapping.Name, mapping.Spec.Ref.Name)
+	}
+}
@@ -2,9 +2,14 @@ package knative
 
 import (
 	"context"
+	"fmt"
+	"sort"
+
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	clientservingv1 "knative.dev/client/pkg/serving/v1"
 	"knative.dev/pkg/apis"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 
 	fn "github.com/boson-project/func"
 	"github.com/boson-project/func/k8s"
@@ -16,8 +21,17 @@ const (
 )
 
 type Lister struct {
-	Verbose   bool
+	Verbose bool
+	// Namespace of the Functions listed, or all namespaces if empty.
 	Namespace string
+	// ServingClient factory, defaulting to NewServingClient.
+	ServingClient ServingClientFactory
+	// Wide lists the details of the latest ready Revision of each Function
+	// and its scale bounds, which requires a request per Function.
+	Wide bool
+	// Selector of the labels of the Functions listed, all being listed if
+	// nil.
+	Selector labels.Selector
 }
 
 func NewLister(namespaceOverride string) (l *Lister, err error) {
@@ -32,9 +46,10 @@ func NewLister(namespaceOverride string) (l *Lister, err error) {
 	return
 }
 
+// List the Functions, sorted by namespace and then name.
 func (l *Lister) List(ctx context.Context) (items []fn.ListItem, err error) {
 
-	client, err := NewServingClient(l.Namespace)
+	client, err := servingClient(l.ServingClient, l.Namespace)
 	if err != nil {
 		return
 	}
@@ -45,6 +60,9 @@ func (l *Lister) List(ctx context.Context) (items []fn.ListItem, err error) {
 	}
 
 	for _, service := range lst.Items {
+		if l.Selector != nil && !l.Selector.Matches(labels.Set(service.Labels)) {
+			continue
+		}
 
 		// get status
 		ready := corev1.ConditionUnknown
@@ -62,8 +80,48 @@ func (l *Lister) List(ctx context.Context) (items []fn.ListItem, err error) {
 			URL:       service.Status.URL.String(),
 			Ready:     string(ready),
 		}
+		if l.Wide {
+			if err = l.widen(ctx, client, &listItem, &service); err != nil {
+				return
+			}
+		}
 
 		items = append(items, listItem)
 	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Namespace != items[j].Namespace {
+			return items[i].Namespace < items[j].Namespace
+		}
+		return items[i].Name < items[j].Name
+	})
+	return
+}
+
+// widen the item of the Service with its scale bounds and the details of its
+// latest ready Revision, if any, got with a client of the namespace of the
+// Service when listing those of all namespaces.
+func (l *Lister) widen(ctx context.Context, client clientservingv1.KnServingClient, item *fn.ListItem, service *servingv1.Service) (err error) {
+	scale := describeAutoscaling(service.Spec.Template.Annotations)
+	item.MinScale, item.MaxScale = scale.Min, scale.Max
+
+	name := service.Status.LatestReadyRevisionName
+	if name == "" {
+		return
+	}
+	if l.Namespace == "" {
+		if client, err = servingClient(l.ServingClient, service.Namespace); err != nil {
+			return
+		}
+	}
+	revision, err := client.GetRevision(ctx, name)
+	if err != nil {
+		return fmt.Errorf("knative lister failed to get the revision '%v' of function '%v': %v", name, service.Name, err)
+	}
+	item.Revision = revision.Name
+	item.Deployed = &revision.CreationTimestamp.Time
+	item.ReadyReplicas = revision.Status.ActualReplicas
+	if len(revision.Status.ContainerStatuses) > 0 {
+		item.ImageDigest = revision.Status.ContainerStatuses[0].ImageDigest
+	}
 	return
 }
@@ -0,0 +1,155 @@
+// +build !integration
+
+package knative
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"knative.dev/client/pkg/util/mock"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_List ensures the Functions deployed are listed from their Knative
+// Services.
+func Test_List(t *testing.T) {
+	serving, factory := mockServing(t, "test")
+	url, _ := apis.ParseURL("http://myfunc.test.example.com")
+	service := servingv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myfunc",
+			Namespace: "test",
+			Labels:    map[string]string{labelKey: labelValue, "boson.dev/runtime": "go"},
+		},
+		Status: servingv1.ServiceStatus{
+			Status:            duckv1.Status{Conditions: duckv1.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionTrue}}},
+			RouteStatusFields: servingv1.RouteStatusFields{URL: url},
+		},
+	}
+	serving.Recorder().ListServices(mock.Any(), &servingv1.ServiceList{Items: []servingv1.Service{service}}, nil)
+
+	lister := &Lister{Namespace: "test", ServingClient: factory}
+	items, err := lister.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []fn.ListItem{{
+		Name:      "myfunc",
+		Namespace: "test",
+		Runtime:   "go",
+		URL:       "http://myfunc.test.example.com",
+		Ready:     "True",
+	}}
+	if !reflect.DeepEqual(items, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, items)
+	}
+	serving.Recorder().Validate()
+}
+
+// Test_ListAllNamespaces ensures the Functions of all namespaces are listed
+// when no namespace is given, sorted by namespace and then name.
+func Test_ListAllNamespaces(t *testing.T) {
+	serving, factory := mockServing(t, "")
+	service := func(namespace, name string) servingv1.Service {
+		return servingv1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{labelKey: labelValue},
+		}}
+	}
+	serving.Recorder().ListServices(mock.Any(), &servingv1.ServiceList{Items: []servingv1.Service{
+		service("test", "b"),
+		service("prod", "b"),
+		service("test", "a"),
+	}}, nil)
+
+	lister := &Lister{ServingClient: factory}
+	items, err := lister.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Namespace+"/"+item.Name)
+	}
+	expected := []string{"prod/b", "test/a", "test/b"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	serving.Recorder().Validate()
+}
+
+// Test_ListWide ensures the Functions are listed with their scale bounds and
+// the details of their latest ready Revision when wide.
+func Test_ListWide(t *testing.T) {
+	serving, factory := mockServing(t, "test")
+	service := servingv1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfunc", Namespace: "test", Labels: map[string]string{labelKey: labelValue}},
+		Spec: servingv1.ServiceSpec{ConfigurationSpec: servingv1.ConfigurationSpec{Template: servingv1.RevisionTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"autoscaling.knative.dev/maxScale": "10"}},
+		}}},
+		Status: servingv1.ServiceStatus{ConfigurationStatusFields: servingv1.ConfigurationStatusFields{LatestReadyRevisionName: "myfunc-00002"}},
+	}
+	created := metav1.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	revision := &servingv1.Revision{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfunc-00002", Namespace: "test", CreationTimestamp: created},
+		Status: servingv1.RevisionStatus{
+			ActualReplicas:    2,
+			ContainerStatuses: []servingv1.ContainerStatus{{Name: "user-container", ImageDigest: "example.com/alice/myfunc@sha256:b389b0"}},
+		},
+	}
+	serving.Recorder().ListServices(mock.Any(), &servingv1.ServiceList{Items: []servingv1.Service{service}}, nil)
+	serving.Recorder().GetRevision("myfunc-00002", revision, nil)
+
+	lister := &Lister{Namespace: "test", ServingClient: factory, Wide: true}
+	items, err := lister.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := items[0]
+	if item.Revision != "myfunc-00002" || item.ImageDigest != "example.com/alice/myfunc@sha256:b389b0" || item.ReadyReplicas != 2 ||
+		item.Deployed == nil || !item.Deployed.Equal(created.Time) || item.MinScale != "" || item.MaxScale != "10" {
+		t.Fatalf("expected the details of the revision myfunc-00002, got %+v", item)
+	}
+	serving.Recorder().Validate()
+}
+
+// Test_ListSelector ensures only the Functions of which the labels match the
+// selector are listed.
+func Test_ListSelector(t *testing.T) {
+	serving, factory := mockServing(t, "test")
+	service := func(name, team string) servingv1.Service {
+		return servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test",
+			Labels: map[string]string{labelKey: labelValue, "team": team}}}
+	}
+	serving.Recorder().ListServices(mock.Any(), &servingv1.ServiceList{Items: []servingv1.Service{
+		service("a", "payments"), service("b", "search"), service("c", "payments"),
+	}}, nil)
+
+	selector, err := labels.Parse("team=payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lister := &Lister{Namespace: "test", ServingClient: factory, Selector: selector}
+	items, err := lister.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Name)
+	}
+	if expected := []string{"a", "c"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	serving.Recorder().Validate()
+}
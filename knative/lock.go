@@ -0,0 +1,57 @@
+package knative
+
+import (
+	"fmt"
+
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	fn "github.com/boson-project/func"
+)
+
+// LockedAnnotation of the Service of a locked Function, which deploys refuse
+// to modify unless forced.  It is set on the Services of Functions which are
+// locked in their config, and may be set on a Service directly, such as to
+// lock all Functions of a production namespace.
+const LockedAnnotation = "func.boson.dev/locked"
+
+// ErrLocked is returned when deploying a locked Function would modify its
+// Service, unless the Deployer is to ForceLocked.
+type ErrLocked struct {
+	Name string
+	// Changes to the Service which were refused, as a diff of its fields
+	// (-deployed +function).
+	Changes string
+}
+
+func (e ErrLocked) Error() string {
+	return fmt.Sprintf("function '%v' is locked: deploying it would modify its Knative Service. Deploy with --force-locked to apply the changes", e.Name)
+}
+
+// locked returns whether the Function, or its existing Service, is locked.
+func locked(existing *servingv1.Service, f fn.Function) bool {
+	return f.Locked || existing.Annotations[LockedAnnotation] == "true"
+}
+
+// checkLocked returns ErrLocked if the Function is locked and updating its
+// existing Service with the desired would modify it.  Locking or unlocking
+// it alone is not refused, such that a Function is locked, or unlocked, by
+// deploying it once its config is.
+func checkLocked(existing, desired *servingv1.Service, f fn.Function) error {
+	if !locked(existing, f) {
+		return nil
+	}
+	unlocked := desired.DeepCopy()
+	if lock, ok := existing.Annotations[LockedAnnotation]; ok {
+		if unlocked.Annotations == nil {
+			unlocked.Annotations = map[string]string{}
+		}
+		unlocked.Annotations[LockedAnnotation] = lock
+	} else {
+		delete(unlocked.Annotations, LockedAnnotation)
+	}
+	diff, err := changes(existing, unlocked)
+	if err != nil || diff == "" {
+		return err
+	}
+	return ErrLocked{Name: f.Name, Changes: diff}
+}
@@ -0,0 +1,111 @@
+package knative
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/client/pkg/util/mock"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_checkLocked ensures that the update of the Service of a locked
+// Function, locked in its config or by the annotation of its Service, is
+// refused with the changes it would make, and that redeploying it unchanged,
+// locking it and unlocking it are not.
+func Test_checkLocked(t *testing.T) {
+	generate := func(f fn.Function, image string) *servingv1.Service {
+		t.Helper()
+		f.Image, f.Runtime = image, "go"
+		service, err := generateNewService(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = withLastApplied(service); err != nil {
+			t.Fatal(err)
+		}
+		return service
+	}
+	unlocked := fn.Function{Name: "myfunc"}
+	locked := fn.Function{Name: "myfunc", Locked: true}
+
+	existing := generate(locked, "example.com/alice/myfunc@sha256:a278a9")
+	err := checkLocked(existing, generate(locked, "example.com/alice/myfunc@sha256:b389b0"), locked)
+	var lockedErr ErrLocked
+	if !errors.As(err, &lockedErr) {
+		t.Fatalf("expected the deploy of the locked function to be refused, got %v", err)
+	}
+	if lockedErr.Name != "myfunc" || !strings.Contains(lockedErr.Changes, "sha256:b389b0") {
+		t.Fatalf("expected the changes refused to be reported, got %+v", lockedErr)
+	}
+	if err = checkLocked(existing, generate(locked, "example.com/alice/myfunc@sha256:a278a9"), locked); err != nil {
+		t.Fatalf("expected the unchanged function to be deployed, got %v", err)
+	}
+	if err = checkLocked(existing, generate(unlocked, "example.com/alice/myfunc@sha256:a278a9"), unlocked); err != nil {
+		t.Fatalf("expected the function to be unlocked, got %v", err)
+	}
+
+	existing = generate(unlocked, "example.com/alice/myfunc@sha256:a278a9")
+	if err = checkLocked(existing, generate(locked, "example.com/alice/myfunc@sha256:a278a9"), locked); err != nil {
+		t.Fatalf("expected the function to be locked, got %v", err)
+	}
+	if err = checkLocked(existing, generate(unlocked, "example.com/alice/myfunc@sha256:b389b0"), unlocked); err != nil {
+		t.Fatalf("expected the function which is not locked to be deployed, got %v", err)
+	}
+
+	existing.Annotations[LockedAnnotation] = "true"
+	if err = checkLocked(existing, generate(unlocked, "example.com/alice/myfunc@sha256:b389b0"), unlocked); !errors.As(err, &lockedErr) {
+		t.Fatalf("expected the deploy to the locked service to be refused, got %v", err)
+	}
+}
+
+// Test_DeployLocked ensures that the deploy of a locked Function which would
+// change its Service is refused without updating it, and that it is updated
+// when forced with ForceLocked.
+func Test_DeployLocked(t *testing.T) {
+	f := fn.Function{Name: "myfunc", Runtime: "go", Image: "example.com/alice/myfunc@sha256:a278a9", Locked: true}
+	existing, err := generateNewService(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = withLastApplied(existing); err != nil {
+		t.Fatal(err)
+	}
+	f.Image = "example.com/alice/myfunc@sha256:b389b0"
+
+	serving, factory := mockServing(t, "test")
+	_, domains := mockDomainMappings(t, "test")
+	serving.Recorder().GetService("myfunc", existing, nil)
+	d := &Deployer{Namespace: "test", ServingClient: factory, DomainMappingClient: domains}
+	var lockedErr ErrLocked
+	if _, err = d.Deploy(context.Background(), f); !errors.As(err, &lockedErr) {
+		t.Fatalf("expected the deploy of the locked function to be refused, got %v", err)
+	}
+	serving.Recorder().Validate()
+
+	ready := existing.DeepCopy()
+	ready.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionReady, Status: corev1.ConditionTrue}}
+	ready.Status.LatestCreatedRevisionName = "myfunc-00002"
+	route := &servingv1.Route{}
+	route.Status.URL = apis.HTTP("myfunc.test.example.com")
+	serving.Recorder().GetService("myfunc", existing, nil)
+	serving.Recorder().UpdateService(mock.Any(), true, nil)
+	serving.Recorder().GetService("myfunc", ready, nil)
+	serving.Recorder().GetRoute("myfunc", route, nil)
+	serving.Recorder().GetService("myfunc", ready, nil)
+	d.ForceLocked = true
+	result, err := d.Deploy(context.Background(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != fn.Updated || result.Revision != "myfunc-00002" {
+		t.Fatalf("expected the forced deploy to update the function, got %+v", result)
+	}
+	serving.Recorder().Validate()
+}
@@ -0,0 +1,143 @@
+package knative
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/k8s"
+)
+
+// Labels set by Knative on the pods of a revision, and the name of the
+// container in which the Function runs.
+const (
+	revisionLabel = "serving.knative.dev/revision"
+	userContainer = "user-container"
+)
+
+type Logger struct {
+	Verbose   bool
+	namespace string
+}
+
+func NewLogger(namespaceOverride string) (logger *Logger, err error) {
+	logger = &Logger{}
+	namespace, err := k8s.GetNamespace(namespaceOverride)
+	if err != nil {
+		return
+	}
+
+	logger.namespace = namespace
+	return
+}
+
+// Logs of the pods of the latest ready revision of the Function's Service.
+// When there is more than one pod, each line is prefixed with the name of the
+// pod from which it was read.
+func (l *Logger) Logs(ctx context.Context, name string, out io.Writer, options fn.LogsOptions) (err error) {
+	servingClient, err := NewServingClient(l.namespace)
+	if err != nil {
+		return
+	}
+	service, err := servingClient.GetService(ctx, name)
+	if err != nil {
+		return
+	}
+	revision := service.Status.LatestReadyRevisionName
+	if revision == "" {
+		return fmt.Errorf("function '%v' has no ready revision", name)
+	}
+
+	clientset, err := k8s.NewKubernetesClientset(l.namespace)
+	if err != nil {
+		return
+	}
+	pods, err := clientset.CoreV1().Pods(l.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: revisionLabel + "=" + revision,
+	})
+	if err != nil {
+		return
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("function '%v' has no running pods. It may have been scaled to zero; invoke it to start one", name)
+	}
+	if l.Verbose {
+		fmt.Fprintf(out, "Streaming logs of %v pod(s) of revision %v\n", len(pods.Items), revision)
+	}
+
+	podOptions := corev1.PodLogOptions{Container: userContainer, Follow: options.Follow}
+	if options.Since > 0 {
+		seconds := int64(options.Since.Seconds())
+		podOptions.SinceSeconds = &seconds
+	}
+	if options.Tail >= 0 {
+		podOptions.TailLines = &options.Tail
+	}
+
+	streams := make(map[string]io.ReadCloser, len(pods.Items))
+	defer func() {
+		for _, s := range streams {
+			s.Close()
+		}
+	}()
+	for _, pod := range pods.Items {
+		var stream io.ReadCloser
+		if stream, err = clientset.CoreV1().Pods(l.namespace).GetLogs(pod.Name, &podOptions).Stream(ctx); err != nil {
+			return fmt.Errorf("failed to stream the logs of pod '%v': %v", pod.Name, err)
+		}
+		streams[pod.Name] = stream
+	}
+
+	err = copyLogs(out, streams)
+	if ctx.Err() != nil {
+		return nil // canceled, such as by SIGINT, while following
+	}
+	return
+}
+
+// copyLogs copies the lines of each stream to out, keyed by the name of the
+// pod from which it is read, until all are exhausted.  When there is more
+// than one stream, lines are prefixed with the pod name.  Lines of different
+// streams are not interleaved.
+func copyLogs(out io.Writer, streams map[string]io.ReadCloser) error {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs = make(chan error, len(streams))
+	)
+	for pod, stream := range streams {
+		prefix := ""
+		if len(streams) > 1 {
+			prefix = "[" + pod + "] "
+		}
+		wg.Add(1)
+		go func(prefix string, stream io.Reader) {
+			defer wg.Done()
+			scanner := bufio.NewScanner(stream)
+			for scanner.Scan() {
+				mu.Lock()
+				_, err := fmt.Fprintf(out, "%v%v\n", prefix, scanner.Text())
+				mu.Unlock()
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- scanner.Err()
+		}(prefix, stream)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
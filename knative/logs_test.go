@@ -0,0 +1,39 @@
+package knative
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Test_copyLogs ensures that the lines of a single pod are printed as-is, and
+// that those of multiple pods are each prefixed with the name of their pod.
+func Test_copyLogs(t *testing.T) {
+	stream := func(s string) io.ReadCloser { return ioutil.NopCloser(strings.NewReader(s)) }
+
+	out := &bytes.Buffer{}
+	if err := copyLogs(out, map[string]io.ReadCloser{"pod-a": stream("one\ntwo\n")}); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "one\ntwo\n" {
+		t.Fatalf("expected the lines of the pod unprefixed, got %q", out.String())
+	}
+
+	out.Reset()
+	err := copyLogs(out, map[string]io.ReadCloser{
+		"pod-a": stream("one\ntwo\n"),
+		"pod-b": stream("three"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	sort.Strings(lines)
+	expected := []string{"[pod-a] one", "[pod-a] two", "[pod-b] three"}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected lines %q, got %q", expected, lines)
+	}
+}
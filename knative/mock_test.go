@@ -0,0 +1,117 @@
+// +build !integration
+
+package knative
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clientdynamic "knative.dev/client/pkg/dynamic"
+	clienteventingv1beta1 "knative.dev/client/pkg/eventing/v1beta1"
+	clientservingv1 "knative.dev/client/pkg/serving/v1"
+	clientservingv1alpha1 "knative.dev/client/pkg/serving/v1alpha1"
+)
+
+// Test doubles of the Knative clients, which record the calls expected of
+// them and the results to return, for example:
+//
+//	serving, factory := mockServing(t, "test")
+//	serving.Recorder().DeleteService("myfunc", mock.Any(), nil)
+//	remover := &Remover{Namespace: "test", ServingClient: factory}
+//	...
+//	serving.Recorder().Validate()
+//
+// Validate fails the test unless each call recorded has been made.
+
+// mockServing returns a mock Knative Serving client of the namespace, and
+// a factory which returns it, failing the test if the factory is invoked
+// for any other namespace.
+func mockServing(t *testing.T, namespace string) (*clientservingv1.MockKnServingClient, ServingClientFactory) {
+	t.Helper()
+	client := clientservingv1.NewMockKnServiceClient(t, namespace)
+	return client, func(ns string) (clientservingv1.KnServingClient, error) {
+		if ns != namespace {
+			t.Fatalf("expected a serving client of namespace '%v', got '%v'", namespace, ns)
+		}
+		return client, nil
+	}
+}
+
+// mockEventing returns a mock Knative Eventing client of the namespace, and
+// a factory which returns it.  See mockServing.
+func mockEventing(t *testing.T, namespace string) (*clienteventingv1beta1.MockKnEventingClient, EventingClientFactory) {
+	t.Helper()
+	client := clienteventingv1beta1.NewMockKnEventingClient(t, namespace)
+	return client, func(ns string) (clienteventingv1beta1.KnEventingClient, error) {
+		if ns != namespace {
+			t.Fatalf("expected an eventing client of namespace '%v', got '%v'", namespace, ns)
+		}
+		return client, nil
+	}
+}
+
+// mockDomainMappings returns a mock Knative Serving client of the
+// DomainMappings of the namespace, and a factory which returns it.  See
+// mockServing.
+func mockDomainMappings(t *testing.T, namespace string) (*clientservingv1alpha1.MockKnServingClient, DomainMappingClientFactory) {
+	t.Helper()
+	client := clientservingv1alpha1.NewMockKnServiceClient(t, namespace)
+	return client, func(ns string) (clientservingv1alpha1.KnServingClient, error) {
+		if ns != namespace {
+			t.Fatalf("expected a domain mapping client of namespace '%v', got '%v'", namespace, ns)
+		}
+		return client, nil
+	}
+}
+
+// mockSources returns a fake dynamic client of the Knative Eventing sources
+// of the namespace, holding the given objects, and a factory which returns
+// it.  Unlike the mocks of the other clients, calls are not recorded; the
+// objects are instead inspected once updated.  See sourceCRD and newSource.
+func mockSources(t *testing.T, namespace string, objects ...runtime.Object) (clientdynamic.KnDynamicClient, SourceClientFactory) {
+	t.Helper()
+	client := clientdynamic.NewKnDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...), namespace)
+	return client, func(ns string) (clientdynamic.KnDynamicClient, error) {
+		if ns != namespace {
+			t.Fatalf("expected a source client of namespace '%v', got '%v'", namespace, ns)
+		}
+		return client, nil
+	}
+}
+
+// sourceCRD returns the CRD of the sources of the given kind, such as
+// PingSource, of sources.knative.dev/v1beta2, labeled as a source.
+func sourceCRD(kind string) *unstructured.Unstructured {
+	plural := strings.ToLower(kind) + "s"
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata": map[string]interface{}{
+			"name":   plural + ".sources.knative.dev",
+			"labels": map[string]interface{}{"duck.knative.dev/source": "true"},
+		},
+		"spec": map[string]interface{}{
+			"group":    "sources.knative.dev",
+			"names":    map[string]interface{}{"kind": kind, "plural": plural},
+			"versions": []interface{}{map[string]interface{}{"name": "v1beta2", "served": true}},
+		},
+	}}
+}
+
+// newSource returns the source of the kind and name in the namespace, with
+// the given sink, if any.
+func newSource(namespace, kind, name string, sink map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "sources.knative.dev/v1beta2",
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		"spec":       map[string]interface{}{},
+	}}
+	if sink != nil {
+		_ = unstructured.SetNestedMap(u.Object, sink, "spec", "sink")
+	}
+	return u
+}
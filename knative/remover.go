@@ -5,6 +5,11 @@ import (
 	"fmt"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	clientservingv1 "knative.dev/client/pkg/serving/v1"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+
+	fn "github.com/boson-project/func"
 	"github.com/boson-project/func/k8s"
 )
 
@@ -24,21 +29,169 @@ func NewRemover(namespaceOverride string) (remover *Remover, err error) {
 type Remover struct {
 	Namespace string
 	Verbose   bool
+	// KeepTriggers disables the removal of Triggers whose subscriber is the
+	// Knative Service being removed.
+	KeepTriggers bool
+	// Wait until the Knative Service and its Revisions no longer exist, up to
+	// WaitTimeout, rather than only until the Service is deleted.
+	Wait        bool
+	WaitTimeout time.Duration
+	// ServingClient and EventingClient factories, defaulting to
+	// NewServingClient and NewEventingClient.
+	ServingClient  ServingClientFactory
+	EventingClient EventingClientFactory
+	// Listener to which the progress of removals is reported, if any.
+	Listener fn.ProgressListener
 }
 
-func (remover *Remover) Remove(ctx context.Context, name string) (err error) {
+// Remove the Knative Service of the named Function from the namespace, or
+// from that of the remover if empty, along with the Triggers which target it
+// unless kept.  Removing a Service which does not exist is not an error.
+func (remover *Remover) Remove(ctx context.Context, name, namespace string) (err error) {
+	remover = remover.in(namespace)
+
+	client, err := servingClient(remover.ServingClient, remover.Namespace)
+	if err != nil {
+		return
+	}
+
+	remover.report(fmt.Sprintf("Removing Knative Service: %v", name))
 
-	client, err := NewServingClient(remover.Namespace)
+	// When waiting, the delete is only issued, and its completion polled.
+	timeout := RemoveTimeout
+	if remover.Wait {
+		timeout = 0
+	}
+	removed, err := removeService(ctx, client, name, timeout)
 	if err != nil {
 		return
 	}
+	if !removed {
+		if remover.Verbose {
+			fmt.Printf("Knative Service %v not found, nothing to remove\n", name)
+		}
+	} else if remover.Wait {
+		if remover.Verbose {
+			fmt.Printf("Waiting for Knative Service %v to be removed\n", name)
+		}
+		if err = waitForDeletion(ctx, client, name, remover.WaitTimeout); err != nil {
+			return
+		}
+	}
+
+	if remover.KeepTriggers {
+		return
+	}
+
+	return remover.removeTriggers(ctx, name)
+}
+
+// PlanRemove returns the steps of removing the named Function from the
+// namespace, as with Remove: the deleting of its Knative Service and, unless
+// kept, of the Triggers which target it.
+func (remover *Remover) PlanRemove(ctx context.Context, name, namespace string) (steps []fn.PlanStep, err error) {
+	remover = remover.in(namespace)
+	steps = []fn.PlanStep{{Action: "delete", Target: fmt.Sprintf("Knative Service %v/%v", remover.Namespace, name)}}
+	if remover.KeepTriggers {
+		return
+	}
+
+	client, err := eventingClient(remover.EventingClient, remover.Namespace)
+	if err != nil {
+		return
+	}
+	triggers, err := client.ListTriggers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("knative remover failed to list triggers: %v", err)
+	}
+	for _, trigger := range triggersForService(triggers.Items, name, remover.Namespace) {
+		steps = append(steps, fn.PlanStep{Action: "delete", Target: fmt.Sprintf("Trigger %v/%v", remover.Namespace, trigger)})
+	}
+	return
+}
+
+// in returns the remover of the namespace, or this remover if empty.
+func (remover *Remover) in(namespace string) *Remover {
+	if namespace == "" || namespace == remover.Namespace {
+		return remover
+	}
+	r := *remover
+	r.Namespace = namespace
+	return &r
+}
 
-	fmt.Printf("Removing Knative Service: %v\n", name)
+// removeService deletes the named Knative Service, returning whether it
+// existed.  Removing a Service which does not exist is not an error, such
+// that removal is idempotent.
+func removeService(ctx context.Context, client clientservingv1.KnServingClient, name string, timeout time.Duration) (removed bool, err error) {
+	err = client.DeleteService(ctx, name, timeout)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("knative remover failed to delete the service: %v", err)
+	}
+	return true, nil
+}
 
-	err = client.DeleteService(ctx, name, RemoveTimeout)
+// removeTriggers deletes all Triggers which target the named Knative Service.
+func (remover *Remover) removeTriggers(ctx context.Context, name string) (err error) {
+	client, err := eventingClient(remover.EventingClient, remover.Namespace)
 	if err != nil {
-		err = fmt.Errorf("knative remover failed to delete the service: %v", err)
+		return
 	}
 
+	triggers, err := client.ListTriggers(ctx)
+	if err != nil {
+		return fmt.Errorf("knative remover failed to list triggers: %v", err)
+	}
+
+	names := triggersForService(triggers.Items, name, remover.Namespace)
+	for _, trigger := range names {
+		if remover.Verbose {
+			fmt.Printf("Removing Trigger: %v\n", trigger)
+		}
+		if err = client.DeleteTrigger(ctx, trigger); err != nil {
+			return fmt.Errorf("knative remover failed to delete the trigger '%v': %v", trigger, err)
+		}
+	}
+	if len(names) > 0 {
+		remover.report(fmt.Sprintf("Removed %v Trigger(s)", len(names)))
+	}
+
+	return
+}
+
+// report the progress of a removal to the Listener, if any.
+func (remover *Remover) report(message string) {
+	if remover.Listener != nil {
+		remover.Listener.Increment(message)
+	}
+}
+
+// triggersForService returns the names of the Triggers whose subscriber
+// references the Knative Service of the given name, or the Sequence of its
+// chain.  A subscriber reference without an explicit namespace is in the
+// namespace of its Trigger.
+func triggersForService(triggers []v1beta1.Trigger, name, namespace string) (names []string) {
+	for _, trigger := range triggers {
+		ref := trigger.Spec.Subscriber.Ref
+		if ref == nil {
+			continue
+		}
+		service := ref.Kind == "Service" && ref.Name == name && (ref.APIVersion == "" || ref.APIVersion == "serving.knative.dev/v1")
+		chain := ref.Kind == "Sequence" && ref.Name == chainName(name) && (ref.APIVersion == "" || ref.APIVersion == Sequences.GroupVersion().String())
+		if !service && !chain {
+			continue
+		}
+		refNamespace := ref.Namespace
+		if refNamespace == "" {
+			refNamespace = trigger.Namespace
+		}
+		if refNamespace != "" && namespace != "" && refNamespace != namespace {
+			continue
+		}
+		names = append(names, trigger.Name)
+	}
 	return
 }
@@ -0,0 +1,215 @@
+// +build !integration
+
+package knative
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientservingv1 "knative.dev/client/pkg/serving/v1"
+	"knative.dev/client/pkg/util/mock"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	servingfake "knative.dev/serving/pkg/client/clientset/versioned/fake"
+)
+
+// Test_triggersForService ensures that only Triggers whose subscriber
+// references the given Knative Service, or the Sequence of its chain, are
+// selected for removal.
+func Test_triggersForService(t *testing.T) {
+	trigger := func(name, namespace string, ref *duckv1.KReference) v1beta1.Trigger {
+		return v1beta1.Trigger{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       v1beta1.TriggerSpec{Subscriber: duckv1.Destination{Ref: ref}},
+		}
+	}
+	triggers := []v1beta1.Trigger{
+		trigger("match", "ns", &duckv1.KReference{Kind: "Service", APIVersion: "serving.knative.dev/v1", Name: "myfunc"}),
+		trigger("match-explicit-ns", "ns", &duckv1.KReference{Kind: "Service", APIVersion: "serving.knative.dev/v1", Name: "myfunc", Namespace: "ns"}),
+		trigger("other-service", "ns", &duckv1.KReference{Kind: "Service", APIVersion: "serving.knative.dev/v1", Name: "other"}),
+		trigger("other-namespace", "ns", &duckv1.KReference{Kind: "Service", APIVersion: "serving.knative.dev/v1", Name: "myfunc", Namespace: "other"}),
+		trigger("core-service", "ns", &duckv1.KReference{Kind: "Service", APIVersion: "v1", Name: "myfunc"}),
+		trigger("uri", "ns", nil),
+		trigger("chain", "ns", &duckv1.KReference{Kind: "Sequence", APIVersion: "flows.knative.dev/v1", Name: "myfunc-chain"}),
+		trigger("other-chain", "ns", &duckv1.KReference{Kind: "Sequence", APIVersion: "flows.knative.dev/v1", Name: "other-chain"}),
+	}
+
+	names := triggersForService(triggers, "myfunc", "ns")
+	expected := []string{"match", "match-explicit-ns", "chain"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected triggers %v, got %v", expected, names)
+	}
+}
+
+// Test_removeService ensures that removing a Knative Service which does not
+// exist is not an error, such that removal is idempotent.
+func Test_removeService(t *testing.T) {
+	existing := &servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "myfunc", Namespace: "test"}}
+	client := clientservingv1.NewKnServingClient(servingfake.NewSimpleClientset(existing).ServingV1(), "test")
+
+	removed, err := removeService(context.Background(), client, "myfunc", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !removed {
+		t.Fatal("expected the existing service to be removed")
+	}
+
+	removed, err = removeService(context.Background(), client, "myfunc", 0)
+	if err != nil {
+		t.Fatalf("expected removing a missing service not to be an error, got %v", err)
+	}
+	if removed {
+		t.Fatal("expected the missing service not to be reported as removed")
+	}
+}
+
+// Test_RemoveWait ensures that when waiting the removal completes once the
+// Knative Service and its Revisions no longer exist, and otherwise times out
+// with those still present.
+func Test_RemoveWait(t *testing.T) {
+	defer func(interval time.Duration) { waitInterval = interval }(waitInterval)
+	waitInterval = time.Millisecond
+
+	service := &servingv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "myfunc", Namespace: "test"}}
+	revision := &servingv1.Revision{ObjectMeta: metav1.ObjectMeta{
+		Name:       "myfunc-00001",
+		Namespace:  "test",
+		Labels:     map[string]string{"serving.knative.dev/service": "myfunc"},
+		Finalizers: []string{"example.com/cleanup"},
+	}}
+
+	remover := &Remover{Namespace: "test", KeepTriggers: true, Wait: true, WaitTimeout: 50 * time.Millisecond}
+	remover.ServingClient = func(namespace string) (clientservingv1.KnServingClient, error) {
+		return clientservingv1.NewKnServingClient(servingfake.NewSimpleClientset(service.DeepCopy()).ServingV1(), namespace), nil
+	}
+	if err := remover.Remove(context.Background(), "myfunc", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Revisions are not removed along with the Service by the fake client.
+	remover.ServingClient = func(namespace string) (clientservingv1.KnServingClient, error) {
+		return clientservingv1.NewKnServingClient(servingfake.NewSimpleClientset(service.DeepCopy(), revision).ServingV1(), namespace), nil
+	}
+	err := remover.Remove(context.Background(), "myfunc", "")
+	if err == nil || !strings.Contains(err.Error(), "Still present: Revision myfunc-00001 (finalizers: example.com/cleanup)") {
+		t.Fatalf("expected the removal to time out with the revision still present, got %v", err)
+	}
+}
+
+type recordingListener struct {
+	messages []string
+}
+
+func (l *recordingListener) SetTotal(int)             {}
+func (l *recordingListener) Increment(message string) { l.messages = append(l.messages, message) }
+func (l *recordingListener) Complete(string)          {}
+func (l *recordingListener) Done()                    {}
+
+// Test_Remove ensures the Knative Service is removed along with the Triggers
+// which target it, unless they are to be kept, the Triggers removed being
+// reported only if any were.
+func Test_Remove(t *testing.T) {
+	ref := &duckv1.KReference{Kind: "Service", Name: "myfunc"}
+	triggers := &v1beta1.TriggerList{Items: []v1beta1.Trigger{
+		{ObjectMeta: metav1.ObjectMeta{Name: "myfunc-trigger"}, Spec: v1beta1.TriggerSpec{Subscriber: duckv1.Destination{Ref: ref}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other-trigger"}, Spec: v1beta1.TriggerSpec{Subscriber: duckv1.Destination{Ref: &duckv1.KReference{Kind: "Service", Name: "other"}}}},
+	}}
+
+	for _, keep := range []bool{false, true} {
+		serving, servingFactory := mockServing(t, "test")
+		eventing, eventingFactory := mockEventing(t, "test")
+		serving.Recorder().DeleteService("myfunc", mock.Any(), nil)
+		if !keep {
+			eventing.Recorder().ListTriggers(triggers, nil)
+			eventing.Recorder().DeleteTrigger("myfunc-trigger", nil)
+		}
+
+		listener := &recordingListener{}
+		remover := &Remover{Namespace: "test", KeepTriggers: keep, ServingClient: servingFactory, EventingClient: eventingFactory, Listener: listener}
+		if err := remover.Remove(context.Background(), "myfunc", ""); err != nil {
+			t.Fatal(err)
+		}
+		serving.Recorder().Validate()
+		eventing.Recorder().Validate()
+
+		expected := []string{"Removing Knative Service: myfunc"}
+		if !keep {
+			expected = append(expected, "Removed 1 Trigger(s)")
+		}
+		if !reflect.DeepEqual(listener.messages, expected) {
+			t.Fatalf("expected the removal to be reported as %v, got %v", expected, listener.messages)
+		}
+	}
+
+	serving, servingFactory := mockServing(t, "test")
+	eventing, eventingFactory := mockEventing(t, "test")
+	serving.Recorder().DeleteService("myfunc", mock.Any(), nil)
+	eventing.Recorder().ListTriggers(&v1beta1.TriggerList{Items: triggers.Items[1:]}, nil)
+	listener := &recordingListener{}
+	remover := &Remover{Namespace: "test", ServingClient: servingFactory, EventingClient: eventingFactory, Listener: listener}
+	if err := remover.Remove(context.Background(), "myfunc", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(listener.messages) != 1 {
+		t.Fatalf("expected no Triggers to be reported removed, got %v", listener.messages)
+	}
+}
+
+// Test_RemoveNamespace ensures the Knative Service is removed from the
+// namespace requested, rather than that of the remover.
+func Test_RemoveNamespace(t *testing.T) {
+	serving, servingFactory := mockServing(t, "test")
+	serving.Recorder().DeleteService("myfunc", mock.Any(), nil)
+
+	remover := &Remover{Namespace: "default", KeepTriggers: true, ServingClient: servingFactory}
+	if err := remover.Remove(context.Background(), "myfunc", "test"); err != nil {
+		t.Fatal(err)
+	}
+	serving.Recorder().Validate()
+	if remover.Namespace != "default" {
+		t.Fatalf("expected the namespace of the remover to be kept, got '%v'", remover.Namespace)
+	}
+}
+
+// Test_PlanRemove ensures the plan of a removal deletes the Knative Service
+// and the Triggers which target it, unless they are to be kept, without
+// deleting anything.
+func Test_PlanRemove(t *testing.T) {
+	ref := &duckv1.KReference{Kind: "Service", Name: "myfunc"}
+	triggers := &v1beta1.TriggerList{Items: []v1beta1.Trigger{
+		{ObjectMeta: metav1.ObjectMeta{Name: "myfunc-trigger"}, Spec: v1beta1.TriggerSpec{Subscriber: duckv1.Destination{Ref: ref}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other-trigger"}, Spec: v1beta1.TriggerSpec{Subscriber: duckv1.Destination{Ref: &duckv1.KReference{Kind: "Service", Name: "other"}}}},
+	}}
+
+	for _, keep := range []bool{false, true} {
+		eventing, eventingFactory := mockEventing(t, "test")
+		expected := []string{"Knative Service test/myfunc"}
+		if !keep {
+			eventing.Recorder().ListTriggers(triggers, nil)
+			expected = append(expected, "Trigger test/myfunc-trigger")
+		}
+
+		remover := &Remover{Namespace: "test", KeepTriggers: keep, EventingClient: eventingFactory}
+		steps, err := remover.PlanRemove(context.Background(), "myfunc", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var targets []string
+		for _, s := range steps {
+			if s.Action != "delete" {
+				t.Fatalf("unexpected action '%v'", s.Action)
+			}
+			targets = append(targets, s.Target)
+		}
+		if !reflect.DeepEqual(targets, expected) {
+			t.Fatalf("expected %v, got %v", expected, targets)
+		}
+		eventing.Recorder().Validate()
+	}
+}
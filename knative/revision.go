@@ -0,0 +1,123 @@
+package knative
+
+import (
+	servingclientlib "knative.dev/client/pkg/serving"
+	clientservingv1 "knative.dev/client/pkg/serving/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	fn "github.com/boson-project/func"
+)
+
+// ChangeCauseAnnotation of the Revision created by a deploy, recording the
+// cause of the change given with the deploy, if any, such as for an audit of
+// the changes made across rollouts.
+const ChangeCauseAnnotation = "func.boson.dev/change-cause"
+
+// setChangeCause annotates the Revision template of the Service with the
+// cause of the change, unless empty.  Being of the template, it is that of
+// the Revision created, and is not carried over to those created by later
+// deploys.
+func setChangeCause(service *servingv1.Service, cause string) {
+	if cause == "" {
+		return
+	}
+	// Copied, such that those of the options are not modified.
+	annotations := make(map[string]string, len(service.Spec.Template.Annotations)+1)
+	for k, v := range service.Spec.Template.Annotations {
+		annotations[k] = v
+	}
+	annotations[ChangeCauseAnnotation] = cause
+	service.Spec.Template.Annotations = annotations
+}
+
+// withRevision returns the update of an existing Service which, following
+// the given update, names and tags the Revision it creates (see setRevision).
+func withRevision(update clientservingv1.ServiceUpdateFunc, f fn.Function) clientservingv1.ServiceUpdateFunc {
+	return func(existing *servingv1.Service) (*servingv1.Service, error) {
+		updated, err := update(existing.DeepCopy())
+		if err != nil {
+			return updated, err
+		}
+		return updated, setRevision(updated, existing, f)
+	}
+}
+
+// setRevision names the Revision of the Service, as created or updated from
+// the existing Service, with the Function's revision name template, and tags
+// it in the traffic of the Service with the Function's traffic tag.  Neither
+// is recorded in the configuration last applied.  Untagged, the Revision is
+// routed all of the traffic again if it is pinned to a single Revision, as by
+// a rollback; the traffic of the Service is otherwise left as is, for example
+// as split by kn.
+func setRevision(service, existing *servingv1.Service, f fn.Function) (err error) {
+	var revision string
+	if f.RevisionName != "" {
+		if revision, err = servingclientlib.GenerateRevisionName(f.RevisionName, existing); err != nil {
+			return
+		}
+		service.Spec.Template.Name = revision
+	}
+	if f.TrafficTag != "" {
+		service.Spec.Traffic = tagTraffic(existing.Spec.Traffic, f.TrafficTag, revision)
+	} else if pinned(service.Spec.Traffic) {
+		service.Spec.Traffic = unpinTraffic(service.Spec.Traffic)
+	}
+	return
+}
+
+// pinned returns whether all of the traffic is routed to a single Revision by
+// name, rather than to the latest Revision, as it is once rolled back.
+func pinned(traffic []servingv1.TrafficTarget) bool {
+	for _, t := range traffic {
+		latest := t.LatestRevision != nil && *t.LatestRevision
+		if !latest && t.RevisionName != "" && t.Percent != nil && *t.Percent == 100 {
+			return true
+		}
+	}
+	return false
+}
+
+// unpinTraffic returns the traffic with all of it routed to the latest
+// Revision again, the targets of tags being preserved without traffic.
+func unpinTraffic(traffic []servingv1.TrafficTarget) []servingv1.TrafficTarget {
+	latest, all, none := true, int64(100), int64(0)
+	unpinned := []servingv1.TrafficTarget{{LatestRevision: &latest, Percent: &all}}
+	for _, t := range traffic {
+		if t.Tag == "" {
+			continue
+		}
+		t.Percent = &none
+		unpinned = append(unpinned, t)
+	}
+	return unpinned
+}
+
+// tagTraffic returns the traffic with the tag moved to the named Revision, or
+// to the latest Revision if no name is given, without routing traffic to it,
+// such that it is reachable at the URL of the tag.  The targets of other tags
+// and the split of traffic are preserved, all traffic being routed to the
+// latest Revision by default.
+func tagTraffic(traffic []servingv1.TrafficTarget, tag, revision string) []servingv1.TrafficTarget {
+	latest, all, none := true, int64(100), int64(0)
+	if len(traffic) == 0 {
+		traffic = []servingv1.TrafficTarget{{LatestRevision: &latest, Percent: &all}}
+	}
+	tagged := make([]servingv1.TrafficTarget, 0, len(traffic)+1)
+	for _, t := range traffic {
+		if t.Tag == tag {
+			// Targets only of the tag are removed, and the tag otherwise.
+			if t.Percent == nil || *t.Percent == 0 {
+				continue
+			}
+			t.Tag = ""
+		}
+		tagged = append(tagged, t)
+	}
+	target := servingv1.TrafficTarget{Tag: tag, Percent: &none}
+	if revision != "" {
+		target.RevisionName = revision
+	} else {
+		target.LatestRevision = &latest
+	}
+	return append(tagged, target)
+}
@@ -0,0 +1,113 @@
+package knative
+
+import (
+	"testing"
+
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_withRevision ensures that the Revision created by an update is named
+// with the template of the Function and tagged with its traffic tag, the
+// traffic of other tags being preserved and a tag of the same name moved.
+func Test_withRevision(t *testing.T) {
+	latest, all, none := true, int64(100), int64(0)
+	existing, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc:v1", Runtime: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing.Generation = 1
+	existing.Spec.Traffic = []servingv1.TrafficTarget{
+		{LatestRevision: &latest, Percent: &all},
+		{Tag: "blue", RevisionName: "myfunc-v1", Percent: &none},
+	}
+
+	f := fn.Function{Name: "myfunc", RevisionName: "{{.Service}}-v{{.Generation}}", TrafficTag: "green"}
+	desired, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc:v2", Runtime: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := withRevision(patchService(desired), f)(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service.Spec.Template.Name != "myfunc-v2" {
+		t.Fatalf("expected the revision 'myfunc-v2', got '%v'", service.Spec.Template.Name)
+	}
+	tags := map[string]string{}
+	for _, target := range service.Spec.Traffic {
+		if target.Tag != "" {
+			tags[target.Tag] = target.RevisionName
+		}
+	}
+	if len(service.Spec.Traffic) != 3 || tags["blue"] != "myfunc-v1" || tags["green"] != "myfunc-v2" {
+		t.Fatalf("expected blue and green to tag myfunc-v1 and myfunc-v2, got %+v", service.Spec.Traffic)
+	}
+
+	// The tag of an existing target is moved to the new revision.
+	f.TrafficTag = "blue"
+	if service, err = withRevision(patchService(desired), f)(existing); err != nil {
+		t.Fatal(err)
+	}
+	if len(service.Spec.Traffic) != 2 || service.Spec.Traffic[1].Tag != "blue" || service.Spec.Traffic[1].RevisionName != "myfunc-v2" {
+		t.Fatalf("expected blue to be moved to myfunc-v2, got %+v", service.Spec.Traffic)
+	}
+}
+
+// Test_tagTraffic ensures that without a revision name the latest Revision
+// is tagged, all traffic being routed to it by default.
+func Test_tagTraffic(t *testing.T) {
+	traffic := tagTraffic(nil, "canary", "")
+	if len(traffic) != 2 || *traffic[0].Percent != 100 || traffic[1].Tag != "canary" || !*traffic[1].LatestRevision {
+		t.Fatalf("expected all traffic to the latest revision, tagged canary, got %+v", traffic)
+	}
+}
+
+// Test_withRevisionPinned ensures that the traffic of a Service pinned to a
+// single Revision, as by a rollback, is routed to the Revision created again,
+// the targets of tags being preserved, unless it is tagged, and that a split
+// between Revisions is left as is.
+func Test_withRevisionPinned(t *testing.T) {
+	all, half, none := int64(100), int64(50), int64(0)
+	existing, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc:v1", Runtime: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing.Spec.Traffic = []servingv1.TrafficTarget{
+		{RevisionName: "myfunc-00001", Percent: &all},
+		{Tag: "blue", RevisionName: "myfunc-00002", Percent: &none},
+	}
+	desired, err := generateNewService(fn.Function{Name: "myfunc", Image: "example.com/alice/myfunc:v2", Runtime: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, err := withRevision(patchService(desired), fn.Function{Name: "myfunc"})(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	traffic := service.Spec.Traffic
+	if len(traffic) != 2 || !*traffic[0].LatestRevision || *traffic[0].Percent != 100 || traffic[1].Tag != "blue" || *traffic[1].Percent != 0 {
+		t.Fatalf("expected all of the traffic to be routed to the latest revision, blue preserved, got %+v", traffic)
+	}
+
+	// Tagged, the revision created is reachable by its tag alone.
+	if service, err = withRevision(patchService(desired), fn.Function{Name: "myfunc", TrafficTag: "green"})(existing); err != nil {
+		t.Fatal(err)
+	}
+	if traffic = service.Spec.Traffic; len(traffic) != 3 || traffic[0].RevisionName != "myfunc-00001" || *traffic[0].Percent != 100 {
+		t.Fatalf("expected the traffic to remain pinned to myfunc-00001, got %+v", traffic)
+	}
+
+	existing.Spec.Traffic = []servingv1.TrafficTarget{
+		{RevisionName: "myfunc-00001", Percent: &half},
+		{RevisionName: "myfunc-00002", Percent: &half},
+	}
+	if service, err = withRevision(patchService(desired), fn.Function{Name: "myfunc"})(existing); err != nil {
+		t.Fatal(err)
+	}
+	if traffic = service.Spec.Traffic; len(traffic) != 2 || traffic[0].RevisionName != "myfunc-00001" || traffic[1].RevisionName != "myfunc-00002" {
+		t.Fatalf("expected the split to be left as is, got %+v", traffic)
+	}
+}
@@ -0,0 +1,208 @@
+package knative
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientdynamic "knative.dev/client/pkg/dynamic"
+	"knative.dev/pkg/apis"
+
+	fn "github.com/boson-project/func"
+)
+
+// source of events, such as a PingSource, found by reference, with the
+// resource of its kind.
+type source struct {
+	*unstructured.Unstructured
+	resource schema.GroupVersionResource
+}
+
+// findSource returns the source of events referenced as KIND/NAME, such as
+// PingSource/heartbeat, or by name alone if unique among the sources of the
+// namespace.  Kinds are matched case-insensitively.  The sources of the
+// cluster are those of the CRDs labeled as such by Knative Eventing.
+func findSource(ctx context.Context, client clientdynamic.KnDynamicClient, ref string) (found source, err error) {
+	kind, name := "", ref
+	if i := strings.Index(ref, "/"); i >= 0 {
+		kind, name = ref[:i], ref[i+1:]
+	}
+	if name == "" {
+		return found, fmt.Errorf("invalid source '%v': expected KIND/NAME or NAME, such as PingSource/heartbeat", ref)
+	}
+
+	types, err := client.ListSourcesTypes(ctx)
+	if err != nil && !errors.IsNotFound(err) {
+		return found, fmt.Errorf("knative deployer failed to list the kinds of sources: %v", err)
+	}
+	if types == nil || len(types.Items) == 0 {
+		return found, fmt.Errorf("source '%v' not found: the cluster has no Knative Eventing sources installed", ref)
+	}
+
+	var matches []source
+	for i := range types.Items {
+		crd := types.Items[i].UnstructuredContent()
+		crdKind, _, _ := unstructured.NestedString(crd, "spec", "names", "kind")
+		if kind != "" && !strings.EqualFold(kind, crdKind) {
+			continue
+		}
+		resource, ok := sourceResource(crd)
+		if !ok {
+			continue
+		}
+		u, err := client.RawClient().Resource(resource).Namespace(client.Namespace()).Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return found, fmt.Errorf("knative deployer failed to get the %v '%v': %v", crdKind, name, err)
+		}
+		matches = append(matches, source{Unstructured: u, resource: resource})
+	}
+
+	switch len(matches) {
+	case 0:
+		return found, fmt.Errorf("source '%v' not found in namespace '%v'", ref, client.Namespace())
+	case 1:
+		return matches[0], nil
+	default:
+		kinds := make([]string, len(matches))
+		for i, m := range matches {
+			kinds[i] = m.GetKind() + "/" + name
+		}
+		return found, fmt.Errorf("source '%v' is ambiguous: please qualify it by kind, as one of %v", ref, strings.Join(kinds, ", "))
+	}
+}
+
+// sourceResource returns the resource of the sources of the CRD, at the first
+// version served.
+func sourceResource(crd map[string]interface{}) (resource schema.GroupVersionResource, ok bool) {
+	resource.Group, _, _ = unstructured.NestedString(crd, "spec", "group")
+	resource.Resource, _, _ = unstructured.NestedString(crd, "spec", "names", "plural")
+	// CRDs of apiextensions.k8s.io/v1beta1 may have only a single version.
+	resource.Version, _, _ = unstructured.NestedString(crd, "spec", "version")
+	versions, _, _ := unstructured.NestedSlice(crd, "spec", "versions")
+	for _, v := range versions {
+		if v, isMap := v.(map[string]interface{}); isMap && v["served"] == true {
+			resource.Version, _ = v["name"].(string)
+			break
+		}
+	}
+	return resource, resource.Group != "" && resource.Resource != "" && resource.Version != ""
+}
+
+// setSink of the source to the Function's Knative Service, by reference, or
+// to the Sequence of its chain if it is chained, unless it is already.
+func setSink(ctx context.Context, client clientdynamic.KnDynamicClient, s source, f fn.Function) (updated bool, err error) {
+	sink := serviceStep(f.Name)
+	if f.Next != "" {
+		sink = sequenceStep(chainName(f.Name))
+	}
+	current, _, _ := unstructured.NestedMap(s.Object, "spec", "sink")
+	if sameDestination(current, sink, s.GetNamespace()) {
+		return false, nil
+	}
+	if err = unstructured.SetNestedMap(s.Object, sink, "spec", "sink"); err != nil {
+		return
+	}
+	_, err = client.RawClient().Resource(s.resource).Namespace(s.GetNamespace()).Update(ctx, s.Unstructured, metav1.UpdateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("knative deployer failed to set the sink of the %v '%v': %v", s.GetKind(), s.GetName(), err)
+	}
+	return true, nil
+}
+
+// sinksTo returns whether the sink of the source is the named Knative
+// Service, or the Sequence of its chain, by reference or, if its URL is
+// given, by URI.
+func sinksTo(u *unstructured.Unstructured, name, url string) bool {
+	content := u.UnstructuredContent()
+	sink, _, _ := unstructured.NestedMap(content, "spec", "sink")
+	if sameDestination(sink, serviceStep(name), u.GetNamespace()) || sameDestination(sink, sequenceStep(chainName(name)), u.GetNamespace()) {
+		return true
+	}
+	uri, _, _ := unstructured.NestedString(content, "spec", "sink", "uri")
+	return url != "" && uri != "" && strings.TrimSuffix(uri, "/") == strings.TrimSuffix(url, "/")
+}
+
+// describeSources returns the sources of the namespace of which the named
+// Knative Service, reachable at the given URLs, is the sink.  A cluster
+// without sources, or of which the kinds of sources may not be listed, has
+// none.
+func describeSources(ctx context.Context, client clientdynamic.KnDynamicClient, name string, urls ...string) ([]fn.Source, error) {
+	types, err := client.ListSourcesTypes(ctx)
+	if errors.IsNotFound(err) || errors.IsForbidden(err) || (err == nil && (types == nil || len(types.Items) == 0)) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	list, err := client.ListSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sources := []fn.Source{}
+	for i := range list.Items {
+		u := &list.Items[i]
+		matches := sinksTo(u, name, "")
+		for _, url := range urls {
+			matches = matches || sinksTo(u, name, url)
+		}
+		if !matches {
+			continue
+		}
+		s := fn.Source{Kind: u.GetKind(), Name: u.GetName(), Ready: "Unknown"}
+		conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+		for _, c := range conditions {
+			if c, ok := c.(map[string]interface{}); ok && c["type"] == string(apis.ConditionReady) {
+				s.Ready, _ = c["status"].(string)
+			}
+		}
+		sources = append(sources, s)
+	}
+	return sources, nil
+}
+
+// findSources of which the Function is to be made the sink, failing if any
+// does not exist.
+func (d *Deployer) findSources(ctx context.Context) (sources []source, err error) {
+	if len(d.Sources) == 0 {
+		return
+	}
+	client, err := sourceClient(d.SourceClient, d.Namespace)
+	if err != nil {
+		return
+	}
+	for _, ref := range d.Sources {
+		s, err := findSource(ctx, client, ref)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, s)
+	}
+	return
+}
+
+// sinkSources sets the sink of each of the sources to the Function's deployed
+// Knative Service, or the Sequence of its chain (see setSink).
+func (d *Deployer) sinkSources(ctx context.Context, sources []source, f fn.Function) error {
+	if len(sources) == 0 {
+		return nil
+	}
+	client, err := sourceClient(d.SourceClient, d.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, s := range sources {
+		updated, err := setSink(ctx, client, s, f)
+		if err != nil {
+			return err
+		}
+		if updated {
+			fmt.Printf("Function is the sink of %v '%v'\n", s.GetKind(), s.GetName())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// +build !integration
+
+package knative
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_findSource ensures that sources are found by kind and name, or by name
+// alone if unique, and that those which do not exist, or are ambiguous, are
+// errors.
+func Test_findSource(t *testing.T) {
+	client, _ := mockSources(t, "test",
+		sourceCRD("PingSource"), sourceCRD("ApiServerSource"),
+		newSource("test", "PingSource", "heartbeat", nil),
+		newSource("test", "PingSource", "events", nil),
+		newSource("test", "ApiServerSource", "events", nil))
+
+	for _, ref := range []string{"PingSource/heartbeat", "pingsource/heartbeat", "heartbeat"} {
+		s, err := findSource(context.Background(), client, ref)
+		if err != nil {
+			t.Fatalf("%v: %v", ref, err)
+		}
+		if s.GetKind() != "PingSource" || s.GetName() != "heartbeat" || s.resource.Resource != "pingsources" {
+			t.Fatalf("%v: expected the PingSource 'heartbeat', got %v '%v'", ref, s.GetKind(), s.GetName())
+		}
+	}
+
+	if _, err := findSource(context.Background(), client, "missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected a source which does not exist to be an error, got %v", err)
+	}
+	if _, err := findSource(context.Background(), client, "ApiServerSource/heartbeat"); err == nil {
+		t.Fatal("expected a source of another kind not to be found")
+	}
+	if _, err := findSource(context.Background(), client, "events"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("expected a name of sources of several kinds to be ambiguous, got %v", err)
+	}
+
+	empty, _ := mockSources(t, "test")
+	if _, err := findSource(context.Background(), empty, "heartbeat"); err == nil || !strings.Contains(err.Error(), "no Knative Eventing sources") {
+		t.Fatalf("expected an error for a cluster without sources, got %v", err)
+	}
+}
+
+// Test_sinkSources ensures that the sinks of the sources of a deploy are set
+// to the Function's Knative Service, and that the sources of which it is the
+// sink are described, by reference or by URL.
+func Test_sinkSources(t *testing.T) {
+	client, factory := mockSources(t, "test",
+		sourceCRD("PingSource"),
+		newSource("test", "PingSource", "heartbeat", map[string]interface{}{"uri": "http://other.test.svc"}),
+		newSource("test", "PingSource", "by-url", map[string]interface{}{"uri": "http://myfunc.test.svc.cluster.local"}),
+		newSource("test", "PingSource", "other", nil))
+	d := &Deployer{Namespace: "test", Sources: []string{"PingSource/heartbeat"}, SourceClient: factory}
+
+	sources, err := d.findSources(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = d.sinkSources(context.Background(), sources, fn.Function{Name: "myfunc"}); err != nil {
+		t.Fatal(err)
+	}
+	resource := schema.GroupVersionResource{Group: "sources.knative.dev", Version: "v1beta2", Resource: "pingsources"}
+	u, err := client.RawClient().Resource(resource).Namespace("test").Get(context.Background(), "heartbeat", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, _, _ := unstructured.NestedStringMap(u.Object, "spec", "sink", "ref")
+	if ref["apiVersion"] != "serving.knative.dev/v1" || ref["kind"] != "Service" || ref["name"] != "myfunc" {
+		t.Fatalf("expected the sink to be the Knative Service 'myfunc', got %v", ref)
+	}
+
+	described, err := describeSources(context.Background(), client, "myfunc", "http://myfunc.test.svc.cluster.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(described) != 2 || described[0].Name != "by-url" || described[1].Name != "heartbeat" || described[1].Ready != "Unknown" {
+		t.Fatalf("expected the sources 'by-url' and 'heartbeat', got %+v", described)
+	}
+}
@@ -0,0 +1,169 @@
+package knative
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	clientservingv1 "knative.dev/client/pkg/serving/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/k8s"
+)
+
+type TrafficSplitter struct {
+	namespace string
+}
+
+func NewTrafficSplitter(namespaceOverride string) (splitter *TrafficSplitter, err error) {
+	splitter = &TrafficSplitter{}
+	namespace, err := k8s.GetNamespace(namespaceOverride)
+	if err != nil {
+		return
+	}
+
+	splitter.namespace = namespace
+	return
+}
+
+// Split the traffic of the Function's Service between its Revisions, as does
+// 'kn service update --traffic'.  The tags of the Service are preserved.
+func (s *TrafficSplitter) Split(ctx context.Context, name string, split fn.TrafficSplit) (traffic []fn.TrafficTarget, err error) {
+	if err = fn.ValidateTrafficSplit(split); err != nil {
+		return
+	}
+	servingClient, err := NewServingClient(s.namespace)
+	if err != nil {
+		return
+	}
+	var targets []servingv1.TrafficTarget
+	_, err = servingClient.UpdateServiceWithRetry(ctx, name, func(service *servingv1.Service) (*servingv1.Service, error) {
+		var err error
+		targets, err = splitTraffic(service.Spec.Traffic, split)
+		service.Spec.Traffic = targets
+		return service, err
+	}, 3)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split the traffic of the Knative Service: %v", err)
+	}
+	for _, t := range targets {
+		target := fn.TrafficTarget{Revision: t.RevisionName, Tag: t.Tag}
+		if t.LatestRevision != nil {
+			target.Latest = *t.LatestRevision
+		}
+		if t.Percent != nil {
+			target.Percent = *t.Percent
+		}
+		traffic = append(traffic, target)
+	}
+	return
+}
+
+// Revisions of the Function's Service, newest first, with the percent of the
+// traffic routed to each.
+func (s *TrafficSplitter) Revisions(ctx context.Context, name string) (revisions []fn.Revision, err error) {
+	servingClient, err := NewServingClient(s.namespace)
+	if err != nil {
+		return
+	}
+	service, err := servingClient.GetService(ctx, name)
+	if err != nil {
+		return
+	}
+	list, err := servingClient.ListRevisions(ctx, clientservingv1.WithService(name))
+	if err != nil {
+		return
+	}
+	return revisionsOf(list.Items, service.Status.Traffic), nil
+}
+
+// revisionsOf returns the Revisions, newest first, with the percent of the
+// traffic routed to each and, if not ready, the reason.
+func revisionsOf(items []servingv1.Revision, traffic []servingv1.TrafficTarget) []fn.Revision {
+	revisions := make([]fn.Revision, 0, len(items))
+	for i := range items {
+		r := &items[i]
+		revision := fn.Revision{
+			Name:        r.Name,
+			Created:     r.CreationTimestamp.Time,
+			Ready:       r.IsReady(),
+			ChangeCause: r.Annotations[ChangeCauseAnnotation],
+		}
+		if len(r.Spec.Containers) > 0 {
+			revision.Image = r.Spec.Containers[0].Image
+		}
+		if len(r.Status.ContainerStatuses) > 0 {
+			revision.ImageDigest = r.Status.ContainerStatuses[0].ImageDigest
+		}
+		if c := r.Status.GetCondition(servingv1.RevisionConditionReady); c != nil && !revision.Ready {
+			revision.Message = c.Message
+		}
+		for _, t := range traffic {
+			if t.RevisionName == r.Name && t.Percent != nil {
+				revision.Percent += *t.Percent
+			}
+		}
+		revisions = append(revisions, revision)
+	}
+	sort.SliceStable(revisions, func(i, j int) bool { return revisions[i].Created.After(revisions[j].Created) })
+	return revisions
+}
+
+// splitTraffic returns the traffic with the percent of each target reset to
+// that of the split, each Revision of which is referenced by the tag of a
+// target, by the name of a Revision, or as fn.LatestRevision.  Revisions not
+// yet targeted are added, and targets without a tag which are routed none of
+// the traffic are removed.
+func splitTraffic(traffic []servingv1.TrafficTarget, split fn.TrafficSplit) ([]servingv1.TrafficTarget, error) {
+	latest := true
+	if len(traffic) == 0 {
+		traffic = []servingv1.TrafficTarget{{LatestRevision: &latest}}
+	}
+	percents := make([]int64, len(traffic)+len(split))
+	for _, ref := range split.Revisions() {
+		i := targetOf(traffic, ref)
+		if i < 0 {
+			target := servingv1.TrafficTarget{RevisionName: ref}
+			if ref == fn.LatestRevision {
+				target = servingv1.TrafficTarget{LatestRevision: &latest}
+			}
+			traffic = append(traffic, target)
+			i = len(traffic) - 1
+		}
+		percents[i] = split[ref]
+	}
+
+	var total int64
+	targets := make([]servingv1.TrafficTarget, 0, len(traffic))
+	for i, t := range traffic {
+		if t.Tag == "" && percents[i] == 0 {
+			continue
+		}
+		t.Percent = &percents[i]
+		total += percents[i]
+		targets = append(targets, t)
+	}
+	if total != 100 {
+		return nil, fmt.Errorf("the traffic split totals %v percent rather than 100", total)
+	}
+	return targets, nil
+}
+
+// targetOf returns the index of the target of the traffic referenced, firstly
+// by tag, then by the name of its Revision or, as fn.LatestRevision, as the
+// untagged target of the latest Revision.  Returns -1 if none is found.
+func targetOf(traffic []servingv1.TrafficTarget, ref string) int {
+	for i, t := range traffic {
+		if t.Tag == ref {
+			return i
+		}
+	}
+	for i, t := range traffic {
+		latest := t.LatestRevision != nil && *t.LatestRevision
+		if (ref == fn.LatestRevision && latest && t.Tag == "") || (!latest && t.RevisionName == ref) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,107 @@
+package knative
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_splitTraffic ensures that the Revisions of a split are resolved by
+// tag, by name and as the latest, that those not yet targeted are added, and
+// that untagged targets routed no traffic are removed while tags are kept.
+func Test_splitTraffic(t *testing.T) {
+	latest, all, none := true, int64(100), int64(0)
+	traffic := []servingv1.TrafficTarget{
+		{LatestRevision: &latest, Percent: &all},
+		{Tag: "blue", RevisionName: "myfunc-v1", Percent: &none},
+		{Tag: "green", LatestRevision: &latest, Percent: &none},
+	}
+
+	split, err := splitTraffic(traffic, fn.TrafficSplit{"blue": 80, "myfunc-v0": 10, fn.LatestRevision: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := describeTraffic(split); s != "@latest=10,blue:myfunc-v1=80,green:@latest=0,myfunc-v0=10" {
+		t.Fatalf("unexpected split %v", s)
+	}
+
+	split, err = splitTraffic(split, fn.TrafficSplit{"green": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := describeTraffic(split); s != "blue:myfunc-v1=0,green:@latest=100" {
+		t.Fatalf("unexpected split %v", s)
+	}
+
+	if _, err = splitTraffic(split, fn.TrafficSplit{"blue": 50, "myfunc-v1": 50}); err == nil {
+		t.Fatal("expected a split of one revision by both tag and name to be invalid")
+	}
+}
+
+// Test_revisionsOf ensures that Revisions are listed newest first, with their
+// readiness, the reason they are not ready, the cause of their change and the
+// traffic routed to each.
+func Test_revisionsOf(t *testing.T) {
+	created := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	revision := func(name string, age time.Duration, ready corev1.ConditionStatus, message string) servingv1.Revision {
+		r := servingv1.Revision{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created.Add(-age))}}
+		r.Spec.Containers = []corev1.Container{{Image: "example.com/alice/myfunc:" + name}}
+		r.Status.ContainerStatuses = []servingv1.ContainerStatus{{Name: "user-container", ImageDigest: "example.com/alice/myfunc@sha256:" + name}}
+		r.Status.Conditions = duckv1.Conditions{{Type: apis.ConditionReady, Status: ready, Message: message}}
+		return r
+	}
+	withChangeCause := func(r servingv1.Revision, cause string) servingv1.Revision {
+		r.Annotations = map[string]string{ChangeCauseAnnotation: cause}
+		return r
+	}
+	percent := int64(100)
+	revisions := revisionsOf([]servingv1.Revision{
+		revision("myfunc-00001", 2*time.Hour, corev1.ConditionTrue, ""),
+		revision("myfunc-00003", 0, corev1.ConditionFalse, "Container failed with: panic"),
+		withChangeCause(revision("myfunc-00002", time.Hour, corev1.ConditionTrue, ""), "Fix the handling of empty payloads"),
+	}, []servingv1.TrafficTarget{{RevisionName: "myfunc-00002", Percent: &percent}})
+
+	var names []string
+	for _, r := range revisions {
+		names = append(names, r.Name)
+	}
+	if strings.Join(names, ",") != "myfunc-00003,myfunc-00002,myfunc-00001" {
+		t.Fatalf("expected the revisions newest first, got %v", names)
+	}
+	if revisions[0].Ready || revisions[0].Message != "Container failed with: panic" {
+		t.Fatalf("expected the newest revision not to be ready, with its reason, got %+v", revisions[0])
+	}
+	if !revisions[1].Ready || revisions[1].Percent != 100 || revisions[1].Image != "example.com/alice/myfunc:myfunc-00002" || revisions[1].ImageDigest != "example.com/alice/myfunc@sha256:myfunc-00002" {
+		t.Fatalf("expected the ready revision routed all traffic, got %+v", revisions[1])
+	}
+	if revisions[1].ChangeCause != "Fix the handling of empty payloads" {
+		t.Fatalf("expected the change cause of the revision, got %+v", revisions[1])
+	}
+	if revisions[2].Percent != 0 {
+		t.Fatalf("expected the oldest revision to be routed no traffic, got %+v", revisions[2])
+	}
+}
+
+func describeTraffic(traffic []servingv1.TrafficTarget) string {
+	targets := make([]string, len(traffic))
+	for i, t := range traffic {
+		revision := t.RevisionName
+		if t.LatestRevision != nil && *t.LatestRevision {
+			revision = fn.LatestRevision
+		}
+		if t.Tag != "" {
+			revision = t.Tag + ":" + revision
+		}
+		targets[i] = fmt.Sprintf("%v=%v", revision, *t.Percent)
+	}
+	return strings.Join(targets, ",")
+}
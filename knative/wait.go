@@ -0,0 +1,213 @@
+package knative
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientservingv1 "knative.dev/client/pkg/serving/v1"
+	"knative.dev/pkg/apis"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+// DefaultWaitCondition is the condition of the Knative Service awaited on
+// deploy unless another is given.
+const DefaultWaitCondition = string(apis.ConditionReady)
+
+// waitInterval between polls of the Service for its conditions.
+var waitInterval = time.Second
+
+// waitForCondition polls the Service of the given name until its condition
+// of the given type is True, failing if it becomes False, or if the timeout
+// elapses first, with the conditions last observed.  Conditions are only
+// considered once the Service's status reflects its latest generation, such
+// that those of the revision replaced by an update are not mistaken for
+// those of the new revision.
+func waitForCondition(ctx context.Context, client clientservingv1.KnServingClient, name, condition string, timeout time.Duration) error {
+	if condition == "" {
+		condition = DefaultWaitCondition
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var service *servingv1.Service
+	for {
+		var err error
+		if service, err = client.GetService(ctx, name); err != nil && ctx.Err() == nil {
+			return err
+		}
+		if err == nil && service.Status.ObservedGeneration == service.Generation {
+			c := service.Status.GetCondition(apis.ConditionType(condition))
+			if c.IsTrue() {
+				return nil
+			}
+			if c.IsFalse() {
+				return fmt.Errorf("condition '%v' of Knative Service '%v' is False: %v", condition, name, describeCondition(c))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("timed out after %v waiting for condition '%v' of Knative Service '%v'. Observed conditions: %v", timeout, condition, name, describeConditions(service))
+			}
+			return ctx.Err()
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+// waitForTraffic polls the Service of the given name until at least the
+// given percent of its traffic is routed to the revision, failing if the
+// timeout elapses first, with the split of its traffic last observed.  The
+// traffic of the Service is only considered once its status reflects its
+// latest generation.
+func waitForTraffic(ctx context.Context, client clientservingv1.KnServingClient, name, revision string, percent int64, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var service *servingv1.Service
+	for {
+		var err error
+		if service, err = client.GetService(ctx, name); err != nil && ctx.Err() == nil {
+			return err
+		}
+		if err == nil && service.Status.ObservedGeneration == service.Generation && routed(service, revision) >= percent {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("timed out after %v waiting for %v%% of the traffic of Knative Service '%v' to be routed to revision '%v'. Observed traffic: %v", timeout, percent, name, revision, describeSplit(service))
+			}
+			return ctx.Err()
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+// routed returns the percent of the traffic of the Service routed to the
+// revision, by all of its targets.
+func routed(service *servingv1.Service, revision string) (percent int64) {
+	for _, t := range service.Status.Traffic {
+		if t.RevisionName == revision && t.Percent != nil {
+			percent += *t.Percent
+		}
+	}
+	return
+}
+
+// describeSplit of the traffic of the Service, each target as
+// "[revision] [percent]%", joined, or "none" if there are none.
+func describeSplit(service *servingv1.Service) string {
+	if service == nil || len(service.Status.Traffic) == 0 {
+		return "none"
+	}
+	var targets []string
+	for _, t := range service.Status.Traffic {
+		var percent int64
+		if t.Percent != nil {
+			percent = *t.Percent
+		}
+		target := fmt.Sprintf("%v %v%%", t.RevisionName, percent)
+		if t.Tag != "" {
+			target += fmt.Sprintf(" (tag %v)", t.Tag)
+		}
+		targets = append(targets, target)
+	}
+	return strings.Join(targets, ", ")
+}
+
+// waitForDeletion polls until neither the Service of the given name nor its
+// Revisions exist, failing if the timeout elapses first, with those still
+// present.
+func waitForDeletion(ctx context.Context, client clientservingv1.KnServingClient, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var present []string
+	for {
+		var err error
+		if present, err = remaining(ctx, client, name); err != nil && ctx.Err() == nil {
+			return err
+		}
+		if err == nil && len(present) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("timed out after %v waiting for Knative Service '%v' to be removed. Still present: %v", timeout, name, strings.Join(present, ", "))
+			}
+			return ctx.Err()
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+// remaining returns the Service of the given name and its Revisions which
+// still exist, each as its kind and name, followed by the finalizers which
+// delay its removal, if any.
+func remaining(ctx context.Context, client clientservingv1.KnServingClient, name string) (present []string, err error) {
+	service, err := client.GetService(ctx, name)
+	if err == nil {
+		present = append(present, describeRemaining("Knative Service", service.ObjectMeta))
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+	revisions, err := client.ListRevisions(ctx, clientservingv1.WithService(name))
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range revisions.Items {
+		present = append(present, describeRemaining("Revision", r.ObjectMeta))
+	}
+	return present, nil
+}
+
+// describeRemaining as its kind and name, followed by its finalizers, if any.
+func describeRemaining(kind string, meta metav1.ObjectMeta) string {
+	if len(meta.Finalizers) == 0 {
+		return fmt.Sprintf("%v %v", kind, meta.Name)
+	}
+	return fmt.Sprintf("%v %v (finalizers: %v)", kind, meta.Name, strings.Join(meta.Finalizers, ", "))
+}
+
+// describeConditions of the Service, joined, or "none" if there are none.
+// See conditions.
+func describeConditions(service *servingv1.Service) string {
+	if service == nil || len(service.Status.Conditions) == 0 {
+		return "none"
+	}
+	return strings.Join(conditions(service), ", ")
+}
+
+// conditions of the Service, each as "[type]=[status] ([reason]: [message])".
+func conditions(service *servingv1.Service) (cc []string) {
+	for i := range service.Status.Conditions {
+		c := service.Status.Conditions[i]
+		cc = append(cc, fmt.Sprintf("%v=%v", c.Type, describeCondition(&c)))
+	}
+	return
+}
+
+// describeCondition as its status, followed by its reason and message, if
+// any.
+func describeCondition(c *apis.Condition) string {
+	if c == nil {
+		return "Unknown"
+	}
+	s := string(c.Status)
+	switch {
+	case c.Reason != "" && c.Message != "":
+		s += fmt.Sprintf(" (%v: %v)", c.Reason, c.Message)
+	case c.Reason != "" || c.Message != "":
+		s += fmt.Sprintf(" (%v%v)", c.Reason, c.Message)
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+// +build !integration
+
+package knative
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+)
+
+// serviceWith returns a Service of the given generation, whose status was
+// observed at the given generation, with the given conditions.
+func serviceWith(generation, observed int64, conditions ...apis.Condition) *servingv1.Service {
+	service := &servingv1.Service{}
+	service.Name = "myfunc"
+	service.Generation = generation
+	service.Status.Status = duckv1.Status{ObservedGeneration: observed, Conditions: conditions}
+	return service
+}
+
+// Test_waitForCondition ensures the named condition is awaited, conditions
+// of a stale generation being ignored, and that a False condition fails.
+func Test_waitForCondition(t *testing.T) {
+	defer func(i time.Duration) { waitInterval = i }(waitInterval)
+	waitInterval = time.Millisecond
+
+	serving, factory := mockServing(t, "test")
+	client, _ := factory("test")
+	routes := apis.Condition{Type: servingv1.ServiceConditionRoutesReady, Status: corev1.ConditionTrue}
+	serving.Recorder().GetService("myfunc", serviceWith(2, 1, routes), nil)
+	serving.Recorder().GetService("myfunc", serviceWith(2, 2, apis.Condition{Type: servingv1.ServiceConditionRoutesReady, Status: corev1.ConditionUnknown}), nil)
+	serving.Recorder().GetService("myfunc", serviceWith(2, 2, routes), nil)
+	if err := waitForCondition(context.Background(), client, "myfunc", "RoutesReady", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	serving.Recorder().Validate()
+
+	failed := apis.Condition{Type: apis.ConditionReady, Status: corev1.ConditionFalse, Reason: "RevisionFailed", Message: "image not found"}
+	serving.Recorder().GetService("myfunc", serviceWith(1, 1, failed), nil)
+	err := waitForCondition(context.Background(), client, "myfunc", "", time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "RevisionFailed: image not found") {
+		t.Fatalf("expected the failed condition, got %v", err)
+	}
+	serving.Recorder().Validate()
+}
+
+// Test_waitForConditionTimeout ensures the conditions last observed are
+// reported on timeout.
+func Test_waitForConditionTimeout(t *testing.T) {
+	defer func(i time.Duration) { waitInterval = i }(waitInterval)
+	waitInterval = time.Hour
+
+	serving, factory := mockServing(t, "test")
+	client, _ := factory("test")
+	serving.Recorder().GetService("myfunc", serviceWith(1, 1,
+		apis.Condition{Type: apis.ConditionReady, Status: corev1.ConditionUnknown, Reason: "RevisionMissing"},
+		apis.Condition{Type: servingv1.ServiceConditionRoutesReady, Status: corev1.ConditionTrue},
+	), nil)
+	err := waitForCondition(context.Background(), client, "myfunc", "Ready", 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), "Ready=Unknown (RevisionMissing), RoutesReady=True") {
+		t.Fatalf("expected a timeout with the observed conditions, got %v", err)
+	}
+	serving.Recorder().Validate()
+}
+
+// serviceRouting returns a Service of which the traffic is split between
+// the given revisions by the given percents.
+func serviceRouting(split map[string]int64) *servingv1.Service {
+	service := serviceWith(2, 2)
+	for _, revision := range []string{"myfunc-00002", "myfunc-00001"} {
+		if percent, ok := split[revision]; ok {
+			service.Status.Traffic = append(service.Status.Traffic, servingv1.TrafficTarget{RevisionName: revision, Percent: &percent})
+		}
+	}
+	return service
+}
+
+// Test_waitForTraffic ensures the percent of the traffic routed to the
+// revision is awaited, and that the split last observed is reported on
+// timeout.
+func Test_waitForTraffic(t *testing.T) {
+	defer func(i time.Duration) { waitInterval = i }(waitInterval)
+	waitInterval = time.Millisecond
+
+	serving, factory := mockServing(t, "test")
+	client, _ := factory("test")
+	serving.Recorder().GetService("myfunc", serviceRouting(map[string]int64{"myfunc-00002": 20, "myfunc-00001": 80}), nil)
+	serving.Recorder().GetService("myfunc", serviceRouting(map[string]int64{"myfunc-00002": 60, "myfunc-00001": 40}), nil)
+	serving.Recorder().GetService("myfunc", serviceRouting(map[string]int64{"myfunc-00002": 100}), nil)
+	if err := waitForTraffic(context.Background(), client, "myfunc", "myfunc-00002", 100, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	serving.Recorder().Validate()
+
+	waitInterval = time.Hour
+	serving.Recorder().GetService("myfunc", serviceRouting(map[string]int64{"myfunc-00002": 60, "myfunc-00001": 40}), nil)
+	err := waitForTraffic(context.Background(), client, "myfunc", "myfunc-00002", 100, 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), "myfunc-00002 60%, myfunc-00001 40%") {
+		t.Fatalf("expected a timeout with the observed traffic, got %v", err)
+	}
+	serving.Recorder().Validate()
+}
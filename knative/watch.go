@@ -0,0 +1,87 @@
+package knative
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/watch"
+	clientservingv1 "knative.dev/client/pkg/serving/v1"
+
+	fn "github.com/boson-project/func"
+)
+
+// serviceWatcher is a Knative Serving client which watches Services, as does
+// that of NewServingClient, though its KnServingClient interface does not.
+type serviceWatcher interface {
+	WatchService(ctx context.Context, name string, timeout time.Duration) (watch.Interface, error)
+}
+
+// watchTimeout of a watch of a Service when not limited by the deadline of
+// its context, after which it is renewed.
+var watchTimeout = 10 * time.Minute
+
+// Watch the deployed Function, describing it (see Describe) when watched and
+// each time its Service changes thereafter, until changed returns true or the
+// context is done.  The Service is watched if the serving client supports
+// it, and otherwise polled each waitInterval.
+func (d *Describer) Watch(ctx context.Context, name string, changed func(fn.Description) bool) error {
+	client, err := servingClient(d.ServingClient, d.namespace)
+	if err != nil {
+		return err
+	}
+	events, stop, err := watchService(ctx, client, name)
+	if err != nil {
+		return err
+	}
+	defer func() { stop() }()
+
+	for {
+		description, err := d.Describe(ctx, name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if changed(description) {
+			return nil
+		}
+
+		// Without a watch, the Service is polled.
+		var poll <-chan time.Time
+		if events == nil {
+			poll = time.After(waitInterval)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case _, ok := <-events:
+			if !ok { // expired: renewed
+				stop()
+				if events, stop, err = watchService(ctx, client, name); err != nil {
+					return err
+				}
+			}
+		case <-poll:
+		}
+	}
+}
+
+// watchService returns the events of a watch of the named Service, until the
+// deadline of the context or watchTimeout, and the function by which it is
+// stopped.  The events are nil if the client does not watch Services.
+func watchService(ctx context.Context, client clientservingv1.KnServingClient, name string) (<-chan watch.Event, func(), error) {
+	w, ok := client.(serviceWatcher)
+	if !ok {
+		return nil, func() {}, nil
+	}
+	timeout := watchTimeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+	watcher, err := w.WatchService(ctx, name, timeout)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	return watcher.ResultChan(), watcher.Stop, nil
+}
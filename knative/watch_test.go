@@ -0,0 +1,105 @@
+// +build !integration
+
+package knative
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	clientservingv1 "knative.dev/client/pkg/serving/v1"
+	"knative.dev/client/pkg/util/mock"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/pkg/apis"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	servingv1alpha1 "knative.dev/serving/pkg/apis/serving/v1alpha1"
+
+	fn "github.com/boson-project/func"
+)
+
+// watchingServing is a mock Knative Serving client which watches Services,
+// of which the events are those of its fake watch.
+type watchingServing struct {
+	*clientservingv1.MockKnServingClient
+	watch *watch.FakeWatcher
+}
+
+func (c watchingServing) WatchService(ctx context.Context, name string, timeout time.Duration) (watch.Interface, error) {
+	return c.watch, nil
+}
+
+// Test_DescriberWatch ensures the Function is described when watched and
+// each time its Service changes, until described as is awaited, and that the
+// Service is polled by a client which does not watch.
+func Test_DescriberWatch(t *testing.T) {
+	for _, watching := range []bool{true, false} {
+		serving, servingFactory := mockServing(t, "test")
+		eventing, eventingFactory := mockEventing(t, "test")
+		domains, domainsFactory := mockDomainMappings(t, "test")
+		_, sourcesFactory := mockSources(t, "test")
+
+		fake := watch.NewFake()
+		if watching {
+			servingFactory = func(string) (clientservingv1.KnServingClient, error) {
+				return watchingServing{serving, fake}, nil
+			}
+		} else {
+			defer func(i time.Duration) { waitInterval = i }(waitInterval)
+			waitInterval = time.Millisecond
+		}
+		for _, status := range []corev1.ConditionStatus{corev1.ConditionUnknown, corev1.ConditionTrue} {
+			service := serviceWith(1, 1, apis.Condition{Type: apis.ConditionReady, Status: status})
+			serving.Recorder().GetService("myfunc", service, nil)
+			serving.Recorder().ListRoutes(mock.Any(), &servingv1.RouteList{}, nil)
+			eventing.Recorder().ListTriggers(&v1beta1.TriggerList{}, nil)
+			domains.Recorder().ListDomainMappings(&servingv1alpha1.DomainMappingList{}, nil)
+		}
+
+		describer := &Describer{namespace: "test", ServingClient: servingFactory, EventingClient: eventingFactory, DomainMappingClient: domainsFactory, SourceClient: sourcesFactory}
+		var described []string
+		err := describer.Watch(context.Background(), "myfunc", func(d fn.Description) bool {
+			described = append(described, d.Ready)
+			if watching && d.Ready != "True" {
+				go fake.Modify(&servingv1.Service{})
+			}
+			return d.Ready == "True"
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(described) != 2 || described[0] != "Unknown" || described[1] != "True" {
+			t.Fatalf("expected the function to be described until ready, got %v", described)
+		}
+		serving.Recorder().Validate()
+		eventing.Recorder().Validate()
+		domains.Recorder().Validate()
+	}
+}
+
+// Test_DescriberWatchCancelled ensures the watch ends with its context.
+func Test_DescriberWatchCancelled(t *testing.T) {
+	serving, _ := mockServing(t, "test")
+	eventing, eventingFactory := mockEventing(t, "test")
+	domains, domainsFactory := mockDomainMappings(t, "test")
+	_, sourcesFactory := mockSources(t, "test")
+	serving.Recorder().GetService("myfunc", serviceWith(1, 1), nil)
+	serving.Recorder().ListRoutes(mock.Any(), &servingv1.RouteList{}, nil)
+	eventing.Recorder().ListTriggers(&v1beta1.TriggerList{}, nil)
+	domains.Recorder().ListDomainMappings(&servingv1alpha1.DomainMappingList{}, nil)
+
+	fake := watch.NewFake()
+	factory := func(string) (clientservingv1.KnServingClient, error) {
+		return watchingServing{serving, fake}, nil
+	}
+	describer := &Describer{namespace: "test", ServingClient: factory, EventingClient: eventingFactory, DomainMappingClient: domainsFactory, SourceClient: sourcesFactory}
+	ctx, cancel := context.WithCancel(context.Background())
+	err := describer.Watch(ctx, "myfunc", func(fn.Description) bool {
+		cancel()
+		return false
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected the watch to be cancelled, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package function
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// OCI annotations of the source of an image, with which the images of
+// Functions are labeled when built (see WithSourceLabels).
+const (
+	SourceLabel   = "org.opencontainers.image.source"
+	RevisionLabel = "org.opencontainers.image.revision"
+	CreatedLabel  = "org.opencontainers.image.created"
+)
+
+// LabelingBuilder is a Builder which supports labeling the images it builds.
+type LabelingBuilder interface {
+	Builder
+	// BuildWithLabels builds a Function using the given cache configuration,
+	// labeling its image with the given labels.
+	BuildWithLabels(context.Context, Function, BuildCache, map[string]string) error
+}
+
+// sourceLabels returns the OCI labels of the source of the Function's image,
+// as built at the given time: the URL of the "origin" remote of the git
+// repository of its source (or else that of its git configuration), and the
+// commit of its HEAD.  Labels of which the value is not available, such as
+// when the source is not in a git repository, are omitted.
+func sourceLabels(ctx context.Context, f Function, created time.Time) map[string]string {
+	labels := map[string]string{CreatedLabel: created.UTC().Format(time.RFC3339)}
+	source, err := git(ctx, f.Root, "config", "--get", "remote.origin.url")
+	if err != nil || source == "" {
+		source = f.Git.URL
+	}
+	if source != "" {
+		labels[SourceLabel] = redactURL(source)
+	}
+	if revision, err := git(ctx, f.Root, "rev-parse", "--verify", "-q", "HEAD"); err == nil && revision != "" {
+		labels[RevisionLabel] = revision
+	}
+	return labels
+}
+
+// redactURL returns the URL without any user information, such as the token
+// of a remote cloned with credentials, which is not to be published in the
+// labels of an image.  Those which are not URLs, such as the scp-like
+// git@github.com:alice/myfunc.git, are returned as is.
+func redactURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.User == nil || parsed.Host == "" {
+		return u
+	}
+	parsed.User = nil
+	return parsed.String()
+}
@@ -0,0 +1,99 @@
+package function
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/markbates/pkger"
+)
+
+// Licenses of which the text is bundled, by SPDX identifier, any of which may
+// be written as the LICENSE of a Function when it is created.
+var Licenses = []string{"Apache-2.0", "BSD-2-Clause", "BSD-3-Clause", "ISC", "MIT"}
+
+// ErrLicenseAuthorRequired is returned when creating a Function with a
+// license without an author, none being configured for git either.
+var ErrLicenseAuthorRequired = errors.New("the author of the license is required")
+
+// licenseDir is the hidden directory of the embedded templates in which the
+// text of each license is bundled, as [licenseDir]/[SPDX identifier].  The
+// text is rendered as a Go text template with the Year and Author of the
+// copyright.
+const licenseDir = ".license"
+
+// licenseFiles of a Function, any of which already existing is kept in place
+// of that of its license.
+var licenseFiles = []string{"LICENSE", "LICENSE.md", "LICENSE.txt"}
+
+// ValidateLicense ensures the license, if any, is the SPDX identifier of one
+// of Licenses.
+func ValidateLicense(license string) error {
+	if license == "" {
+		return nil
+	}
+	for _, l := range Licenses {
+		if l == license {
+			return nil
+		}
+	}
+	for _, l := range Licenses {
+		if strings.EqualFold(l, license) {
+			return fmt.Errorf("the license must be given by its SPDX identifier: did you mean %v?", l)
+		}
+	}
+	return fmt.Errorf("the license must be one of %v", strings.Join(Licenses, ", "))
+}
+
+// licenseAuthor returns the author given or, if none, the user.name of the
+// git config of root, such as that of the user's global config.
+func licenseAuthor(root, author string) (string, error) {
+	if author != "" {
+		return author, nil
+	}
+	// An unset user.name is not an error, git exiting non-zero for it.
+	if name, _ := git(context.Background(), root, "config", "--get", "user.name"); name != "" {
+		return name, nil
+	}
+	return "", fmt.Errorf("%w: provide it, or set the user.name of git", ErrLicenseAuthorRequired)
+}
+
+// writeLicense writes the text of the license as the LICENSE file of root,
+// of the copyright of the author in the current year, returning whether it
+// was written: it is not when a LICENSE file already exists.
+func writeLicense(root, license, author string) (written bool, err error) {
+	for _, name := range licenseFiles {
+		if _, err = os.Stat(filepath.Join(root, name)); err == nil {
+			return false, nil
+		}
+	}
+	file, err := pkger.Open(filepath.Join("/templates", licenseDir, license))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	text, err := ioutil.ReadAll(file)
+	if err != nil {
+		return
+	}
+	tpl, err := template.New(license).Parse(string(text))
+	if err != nil {
+		return
+	}
+	out, err := os.Create(filepath.Join(root, "LICENSE"))
+	if err != nil {
+		return
+	}
+	defer out.Close()
+	data := struct {
+		Year   int
+		Author string
+	}{time.Now().Year(), author}
+	return true, tpl.Execute(out, data)
+}
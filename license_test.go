@@ -0,0 +1,78 @@
+// +build !integration
+
+package function_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	fn "github.com/boson-project/func"
+)
+
+// TestCreateLicense ensures the LICENSE of a Function created with a license
+// is written of the copyright of its author in the current year, that the
+// license is persisted, and that an existing LICENSE is kept.
+func TestCreateLicense(t *testing.T) {
+	root := "testdata/example.com/testCreateLicense"
+	defer using(t, root)()
+
+	client := fn.New(fn.WithRegistry(TestRegistry), fn.WithLicenseAuthor("Alice"))
+	if err := client.Create(fn.Function{Root: root, Runtime: "go", License: "MIT"}); err != nil {
+		t.Fatal(err)
+	}
+	license, err := ioutil.ReadFile(filepath.Join(root, "LICENSE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	copyright := fmt.Sprintf("Copyright (c) %v Alice", time.Now().Year())
+	if !strings.HasPrefix(string(license), "MIT License") || !strings.Contains(string(license), copyright) {
+		t.Fatalf("expected the MIT license of '%v', got:\n%s", copyright, license)
+	}
+	f, err := fn.NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.License != "MIT" {
+		t.Fatalf("expected the license 'MIT' to be persisted, got '%v'", f.License)
+	}
+
+	existing := "testdata/example.com/testCreateLicenseExisting"
+	defer using(t, existing)()
+	if err = os.MkdirAll(existing, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(existing, "LICENSE.md"), []byte("All rights reserved\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client = fn.New(fn.WithRegistry(TestRegistry), fn.WithForce(true), fn.WithLicenseAuthor("Alice"))
+	if err = client.Create(fn.Function{Root: existing, Runtime: "go", License: "Apache-2.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(existing, "LICENSE")); !os.IsNotExist(err) {
+		t.Fatal("expected no LICENSE to be written where a LICENSE.md exists")
+	}
+}
+
+// TestCreateLicenseInvalid ensures a Function is not created with a license
+// which is not bundled, and that one given in the wrong case is suggested.
+func TestCreateLicenseInvalid(t *testing.T) {
+	root := "testdata/example.com/testCreateLicenseInvalid"
+	defer using(t, root)()
+
+	client := fn.New(fn.WithRegistry(TestRegistry), fn.WithLicenseAuthor("Alice"))
+	err := client.Create(fn.Function{Root: root, Runtime: "go", License: "mit"})
+	if err == nil || !strings.Contains(err.Error(), "did you mean MIT?") {
+		t.Fatalf("expected the license to be suggested, got %v", err)
+	}
+	if err = client.Create(fn.Function{Root: root, Runtime: "go", License: "GPL-3.0"}); err == nil {
+		t.Fatal("expected an error for a license which is not bundled")
+	}
+	if _, err = os.Stat(filepath.Join(root, "func.yaml")); !os.IsNotExist(err) {
+		t.Fatal("expected nothing to be written for an invalid license")
+	}
+}
@@ -0,0 +1,168 @@
+package function
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// registryPrefixRegex matches the prefix of the images of a registry mirror,
+// and its mirror: a registry domain, optionally with a port, followed by any
+// path of the repositories beneath it, without a tag or digest.
+var registryPrefixRegex = regexp.MustCompile(`^[a-z0-9]+([.-][a-z0-9]+)*(:[0-9]+)?(/[a-z0-9]+([._-]+[a-z0-9]+)*)*$`)
+
+// ParseRegistryMirrors returns the registry mirrors of the comma separated
+// PREFIX=MIRROR pairs of s, such as
+// "gcr.io=registry.internal/gcr-mirror,docker.io=registry.internal/hub".
+func ParseRegistryMirrors(s string) (map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	mirrors := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		i := strings.Index(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("expected PREFIX=MIRROR, got '%v'", pair)
+		}
+		prefix, mirror := strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+		if err := ValidateRegistryMirror(prefix, mirror); err != nil {
+			return nil, err
+		}
+		mirrors[prefix] = mirror
+	}
+	return mirrors, nil
+}
+
+// ValidateRegistryMirror ensures the prefix of the images mirrored, and its
+// mirror, are each a registry, optionally followed by a repository path,
+// without a tag or digest.
+func ValidateRegistryMirror(prefix, mirror string) error {
+	if !registryPrefixRegex.MatchString(prefix) {
+		return fmt.Errorf("the prefix '%v' must be a registry, optionally followed by a repository path, such as gcr.io or docker.io/library", prefix)
+	}
+	if mirror == "" {
+		return errors.New("the mirror must be set")
+	}
+	if !registryPrefixRegex.MatchString(mirror) {
+		return fmt.Errorf("the mirror '%v' must be a registry, optionally followed by a repository path, such as registry.internal/gcr-mirror", mirror)
+	}
+	return nil
+}
+
+// MirrorImage returns the image rewritten to be pulled from its mirror of
+// those given, and whether it was.  The image is matched, by the registry
+// and repository path of its canonical name, against the prefixes of the
+// mirrors on a path boundary, the longest matching prefix taking precedence,
+// such that "nginx:1.25" is of "docker.io/library" and of "docker.io".  Its
+// tag and digest, if any, are retained.  Images which are not valid
+// references are returned as is.
+func MirrorImage(image string, mirrors map[string]string) (string, bool) {
+	if image == "" || len(mirrors) == 0 {
+		return image, false
+	}
+	repository, suffix := splitImage(image)
+	repo, err := name.NewRepository(repository, name.WeakValidation)
+	if err != nil {
+		return image, false
+	}
+	canonical := canonicalRegistry(repo.RegistryStr()) + "/" + repo.RepositoryStr()
+
+	var matched string
+	for prefix := range mirrors {
+		p := canonicalRegistryPrefix(prefix)
+		if (canonical == p || strings.HasPrefix(canonical, p+"/")) && len(p) > len(canonicalRegistryPrefix(matched)) {
+			matched = prefix
+		}
+	}
+	if matched == "" {
+		return image, false
+	}
+	rest := strings.TrimPrefix(canonical, canonicalRegistryPrefix(matched))
+	return strings.TrimSuffix(mirrors[matched], "/") + rest + suffix, true
+}
+
+// splitImage returns the repository of the image and the remainder: its
+// ":tag", "@digest" or both, if any.
+func splitImage(image string) (repository, suffix string) {
+	repository = image
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository = repository[:i]
+	}
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+	return repository, image[len(repository):]
+}
+
+// canonicalRegistry returns the registry of the given domain, of which the
+// aliases of Docker Hub are "docker.io".
+func canonicalRegistry(registry string) string {
+	switch registry {
+	case name.DefaultRegistry, "registry-1.docker.io":
+		return "docker.io"
+	}
+	return registry
+}
+
+// canonicalRegistryPrefix returns the prefix of a registry mirror with its
+// registry canonical.
+func canonicalRegistryPrefix(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	parts := strings.SplitN(prefix, "/", 2)
+	parts[0] = canonicalRegistry(parts[0])
+	return strings.Join(parts, "/")
+}
+
+// WithRegistryMirrors returns the Function with the given registry mirrors
+// merged over those of its config, those given taking precedence.  The
+// Function's own mirrors are not modified.
+func (f Function) WithRegistryMirrors(mirrors map[string]string) Function {
+	if len(mirrors) == 0 {
+		return f
+	}
+	merged := make(map[string]string, len(f.RegistryMirrors)+len(mirrors))
+	for prefix, mirror := range f.RegistryMirrors {
+		merged[prefix] = mirror
+	}
+	for prefix, mirror := range mirrors {
+		merged[prefix] = mirror
+	}
+	f.RegistryMirrors = merged
+	return f
+}
+
+// Mirrored returns the Function with its images, and those of its init
+// containers, rewritten to be pulled from their registry mirrors, if any,
+// and the rewrites applied, each as "image -> mirrored".
+func (f Function) Mirrored() (Function, []string) {
+	if len(f.RegistryMirrors) == 0 {
+		return f, nil
+	}
+	var rewrites []string
+	mirror := func(image string) string {
+		if mirrored, ok := MirrorImage(image, f.RegistryMirrors); ok {
+			rewrites = append(rewrites, image+" -> "+mirrored)
+			return mirrored
+		}
+		return image
+	}
+	f.Image = mirror(f.Image)
+	if len(f.InitContainers) > 0 {
+		containers := make([]InitContainer, len(f.InitContainers))
+		for i, c := range f.InitContainers {
+			c.Image = mirror(c.Image)
+			containers[i] = c
+		}
+		f.InitContainers = containers
+	}
+	return f, rewrites
+}
@@ -0,0 +1,96 @@
+// +build !integration
+
+package function
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMirrorImage ensures images are rewritten to be pulled from the mirror
+// of the longest prefix of their canonical name, retaining their tag and
+// digest, and that those of no mirror are not.
+func TestMirrorImage(t *testing.T) {
+	digest := "sha256:a278a91112d17f8bde6b5f802a3317c7c752cf88078dae6f4b5a0784deb81782"
+	mirrors := map[string]string{
+		"gcr.io":              "registry.internal/gcr-mirror",
+		"gcr.io/distroless":   "registry.internal/distroless",
+		"docker.io":           "registry.internal/hub",
+		"localhost:5000/team": "registry.internal/team",
+	}
+	tests := []struct {
+		image    string
+		expected string
+		mirrored bool
+	}{
+		{"gcr.io/alice/myfunc", "registry.internal/gcr-mirror/alice/myfunc", true},
+		{"gcr.io/alice/myfunc:v1", "registry.internal/gcr-mirror/alice/myfunc:v1", true},
+		{"gcr.io/alice/myfunc@" + digest, "registry.internal/gcr-mirror/alice/myfunc@" + digest, true},
+		{"gcr.io/alice/myfunc:v1@" + digest, "registry.internal/gcr-mirror/alice/myfunc:v1@" + digest, true},
+		{"gcr.io/distroless/static:nonroot", "registry.internal/distroless/static:nonroot", true},
+		{"nginx:1.25", "registry.internal/hub/library/nginx:1.25", true},
+		{"alice/myfunc", "registry.internal/hub/alice/myfunc", true},
+		{"index.docker.io/alice/myfunc:v1", "registry.internal/hub/alice/myfunc:v1", true},
+		{"localhost:5000/team/myfunc:latest", "registry.internal/team/myfunc:latest", true},
+		{"localhost:5000/other/myfunc:latest", "localhost:5000/other/myfunc:latest", false},
+		{"gcr.iox/alice/myfunc", "gcr.iox/alice/myfunc", false},
+		{"quay.io/alice/myfunc:v1", "quay.io/alice/myfunc:v1", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		if image, mirrored := MirrorImage(test.image, mirrors); image != test.expected || mirrored != test.mirrored {
+			t.Errorf("expected '%v' to be mirrored as '%v' (%v), got '%v' (%v)", test.image, test.expected, test.mirrored, image, mirrored)
+		}
+	}
+}
+
+// TestParseRegistryMirrors ensures the PREFIX=MIRROR pairs of registry
+// mirrors are parsed, and that invalid prefixes and mirrors are errors.
+func TestParseRegistryMirrors(t *testing.T) {
+	mirrors, err := ParseRegistryMirrors("gcr.io=registry.internal/gcr-mirror, docker.io=registry.internal/hub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"gcr.io": "registry.internal/gcr-mirror", "docker.io": "registry.internal/hub"}
+	if !reflect.DeepEqual(mirrors, expected) {
+		t.Fatalf("expected the mirrors %v, got %v", expected, mirrors)
+	}
+	if mirrors, err = ParseRegistryMirrors(""); err != nil || mirrors != nil {
+		t.Fatalf("expected no mirrors, got %v (%v)", mirrors, err)
+	}
+	for _, invalid := range []string{"gcr.io", "gcr.io=", "gcr.io/alice:v1=registry.internal", "gcr.io=registry.internal/mirror@sha256:a278a9", "=registry.internal"} {
+		if _, err = ParseRegistryMirrors(invalid); err == nil {
+			t.Errorf("expected '%v' to be invalid", invalid)
+		}
+	}
+}
+
+// TestFunctionMirrored ensures the images of a Function and of its init
+// containers are rewritten by its registry mirrors, those given taking
+// precedence over its own, without modifying the Function.
+func TestFunctionMirrored(t *testing.T) {
+	f := Function{
+		Image:           "gcr.io/alice/myfunc:v1",
+		RegistryMirrors: map[string]string{"gcr.io": "registry.internal/gcr-mirror"},
+		InitContainers:  []InitContainer{{Name: "migrate", Image: "alice/migrate:v1"}},
+	}
+	mirrored, rewrites := f.WithRegistryMirrors(map[string]string{"docker.io": "registry.internal/hub"}).Mirrored()
+	if mirrored.Image != "registry.internal/gcr-mirror/alice/myfunc:v1" || mirrored.InitContainers[0].Image != "registry.internal/hub/alice/migrate:v1" {
+		t.Fatalf("expected the images to be mirrored, got %v and %v", mirrored.Image, mirrored.InitContainers[0].Image)
+	}
+	expected := []string{
+		"gcr.io/alice/myfunc:v1 -> registry.internal/gcr-mirror/alice/myfunc:v1",
+		"alice/migrate:v1 -> registry.internal/hub/alice/migrate:v1",
+	}
+	if !reflect.DeepEqual(rewrites, expected) {
+		t.Fatalf("expected the rewrites %v, got %v", expected, rewrites)
+	}
+	if f.InitContainers[0].Image != "alice/migrate:v1" || len(f.RegistryMirrors) != 1 {
+		t.Fatalf("expected the function not to be modified, got %+v", f)
+	}
+
+	mirrored, _ = f.WithRegistryMirrors(map[string]string{"gcr.io": "registry.internal/override"}).Mirrored()
+	if mirrored.Image != "registry.internal/override/alice/myfunc:v1" {
+		t.Fatalf("expected the mirror given to take precedence, got %v", mirrored.Image)
+	}
+}
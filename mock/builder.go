@@ -8,11 +8,18 @@ import (
 type Builder struct {
 	BuildInvoked bool
 	BuildFn      func(fn.Function) error
+	// BuildCache with which the build was most recently invoked.
+	BuildCache fn.BuildCache
+	// Labels with which the build was most recently invoked.
+	Labels map[string]string
+	// ResolveBuilderFn returns the digest of the builder image.
+	ResolveBuilderFn func(fn.Function) (string, error)
 }
 
 func NewBuilder() *Builder {
 	return &Builder{
-		BuildFn: func(fn.Function) error { return nil },
+		BuildFn:          func(fn.Function) error { return nil },
+		ResolveBuilderFn: func(fn.Function) (string, error) { return "", nil },
 	}
 }
 
@@ -20,3 +27,17 @@ func (i *Builder) Build(ctx context.Context, f fn.Function) error {
 	i.BuildInvoked = true
 	return i.BuildFn(f)
 }
+
+func (i *Builder) BuildWithCache(ctx context.Context, f fn.Function, cache fn.BuildCache) error {
+	i.BuildCache = cache
+	return i.Build(ctx, f)
+}
+
+func (i *Builder) BuildWithLabels(ctx context.Context, f fn.Function, cache fn.BuildCache, labels map[string]string) error {
+	i.Labels = labels
+	return i.BuildWithCache(ctx, f, cache)
+}
+
+func (i *Builder) ResolveBuilder(ctx context.Context, f fn.Function) (string, error) {
+	return i.ResolveBuilderFn(f)
+}
@@ -19,5 +19,8 @@ func NewDeployer() *Deployer {
 
 func (i *Deployer) Deploy(ctx context.Context, f fn.Function) (fn.DeploymentResult, error) {
 	i.DeployInvoked = true
-	return fn.DeploymentResult{}, i.DeployFn(f)
+	if err := i.DeployFn(f); err != nil {
+		return fn.DeploymentResult{}, err
+	}
+	return fn.DeploymentResult{Status: fn.Deployed}, nil
 }
@@ -0,0 +1,25 @@
+package mock
+
+import (
+	"context"
+
+	fn "github.com/boson-project/func"
+)
+
+type Describer struct {
+	DescribeInvoked bool
+	DescribeFn      func(name string) (fn.Description, error)
+}
+
+func NewDescriber() *Describer {
+	return &Describer{
+		DescribeFn: func(name string) (fn.Description, error) {
+			return fn.Description{Name: name, Routes: []string{}, Subscriptions: []fn.Subscription{}}, nil
+		},
+	}
+}
+
+func (d *Describer) Describe(_ context.Context, name string) (fn.Description, error) {
+	d.DescribeInvoked = true
+	return d.DescribeFn(name)
+}
@@ -0,0 +1,39 @@
+package mock
+
+import (
+	"context"
+
+	fn "github.com/boson-project/func"
+)
+
+type Exporter struct {
+	ExportInvoked       bool
+	ExportFn            func(fn.Function, string, string) (string, error)
+	ExportSBOMInvoked   bool
+	ExportSBOMFn        func(fn.Function, string, string) ([]string, error)
+	ExportLayoutInvoked bool
+	ExportLayoutFn      func(fn.Function, string) error
+}
+
+func NewExporter() *Exporter {
+	return &Exporter{
+		ExportFn:       func(fn.Function, string, string) (string, error) { return "", nil },
+		ExportSBOMFn:   func(fn.Function, string, string) ([]string, error) { return nil, nil },
+		ExportLayoutFn: func(fn.Function, string) error { return nil },
+	}
+}
+
+func (i *Exporter) Export(ctx context.Context, f fn.Function, format, dir string) (string, error) {
+	i.ExportInvoked = true
+	return i.ExportFn(f, format, dir)
+}
+
+func (i *Exporter) ExportSBOM(ctx context.Context, f fn.Function, format, dir string) ([]string, error) {
+	i.ExportSBOMInvoked = true
+	return i.ExportSBOMFn(f, format, dir)
+}
+
+func (i *Exporter) ExportLayout(ctx context.Context, f fn.Function, dir string) error {
+	i.ExportLayoutInvoked = true
+	return i.ExportLayoutFn(f, dir)
+}
@@ -0,0 +1,31 @@
+package mock
+
+import (
+	"context"
+
+	fn "github.com/boson-project/func"
+)
+
+type PipelinesProvider struct {
+	RunInvoked        bool
+	RunFn             func(fn.Function) error
+	RunArchiveInvoked bool
+	RunArchiveFn      func(fn.Function, string) error
+}
+
+func NewPipelinesProvider() *PipelinesProvider {
+	return &PipelinesProvider{
+		RunFn:        func(fn.Function) error { return nil },
+		RunArchiveFn: func(fn.Function, string) error { return nil },
+	}
+}
+
+func (p *PipelinesProvider) Run(ctx context.Context, f fn.Function) error {
+	p.RunInvoked = true
+	return p.RunFn(f)
+}
+
+func (p *PipelinesProvider) RunArchive(ctx context.Context, f fn.Function, archive string) error {
+	p.RunArchiveInvoked = true
+	return p.RunArchiveFn(f, archive)
+}
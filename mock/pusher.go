@@ -9,6 +9,8 @@ import (
 type Pusher struct {
 	PushInvoked bool
 	PushFn      func(fn.Function) (string, error)
+	// Credentials with which the push was most recently invoked, if any.
+	Credentials fn.CredentialsProvider
 }
 
 func NewPusher() *Pusher {
@@ -21,3 +23,8 @@ func (i *Pusher) Push(ctx context.Context, f fn.Function) (string, error) {
 	i.PushInvoked = true
 	return i.PushFn(f)
 }
+
+func (i *Pusher) PushWithCredentials(ctx context.Context, f fn.Function, cp fn.CredentialsProvider) (string, error) {
+	i.Credentials = cp
+	return i.Push(ctx, f)
+}
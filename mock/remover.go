@@ -1,17 +1,26 @@
 package mock
 
-import "context"
+import (
+	"context"
+	"sync"
+)
 
 type Remover struct {
 	RemoveInvoked bool
 	RemoveFn      func(string) error
+	// Namespace requested of the last removal, if any.
+	Namespace string
+	mu        sync.Mutex
 }
 
 func NewRemover() *Remover {
 	return &Remover{}
 }
 
-func (r *Remover) Remove(ctx context.Context, name string) error {
+func (r *Remover) Remove(ctx context.Context, name, namespace string) error {
+	r.mu.Lock()
 	r.RemoveInvoked = true
+	r.Namespace = namespace
+	r.mu.Unlock()
 	return r.RemoveFn(name)
 }
@@ -0,0 +1,23 @@
+package mock
+
+import (
+	"context"
+
+	fn "github.com/boson-project/func"
+)
+
+type RevisionLister struct {
+	RevisionsInvoked bool
+	RevisionsFn      func(name string) ([]fn.Revision, error)
+}
+
+func NewRevisionLister() *RevisionLister {
+	return &RevisionLister{
+		RevisionsFn: func(string) ([]fn.Revision, error) { return []fn.Revision{}, nil },
+	}
+}
+
+func (l *RevisionLister) Revisions(_ context.Context, name string) ([]fn.Revision, error) {
+	l.RevisionsInvoked = true
+	return l.RevisionsFn(name)
+}
@@ -0,0 +1,116 @@
+package function
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/markbates/pkger"
+)
+
+// PackageManagers of the Node runtimes, one of which may be chosen for a
+// Function when it is created.  npm is the default.
+var PackageManagers = []string{"npm", "yarn", "pnpm"}
+
+// DefaultPackageManager of the Node runtimes, of which the templates are
+// written as they are.
+const DefaultPackageManager = "npm"
+
+// ErrPackageManagerNotFound is returned when the template of a Function
+// provides no variant of its files for the package manager chosen.
+var ErrPackageManagerNotFound = errors.New("package manager variant not found")
+
+// packageManagerDir is the hidden directory of the variants of the templates
+// for each package manager other than npm, laid out as
+// [packageManagerDir]/[manager]/[runtime]/[template], both of the embedded
+// templates and of a repository of templates.  The files of a variant, such
+// as a package.json of which the scripts run the package manager, and its
+// lockfile, are written over those of the template.  Buildpacks detect the
+// package manager by its lockfile.
+const packageManagerDir = ".package-manager"
+
+// lockfiles of the package managers, of which those of the other package
+// managers are not written with the template.
+var lockfiles = map[string]string{
+	"npm":  "package-lock.json",
+	"yarn": "yarn.lock",
+	"pnpm": "pnpm-lock.yaml",
+}
+
+// ValidatePackageManager ensures the package manager, if any, is one of
+// PackageManagers, and is chosen only for a Node runtime.
+func ValidatePackageManager(runtime, manager string) error {
+	if manager == "" {
+		return nil
+	}
+	valid := false
+	for _, m := range PackageManagers {
+		valid = valid || m == manager
+	}
+	if !valid {
+		return fmt.Errorf("the package manager must be one of %v", strings.Join(PackageManagers, ", "))
+	}
+	if runtime != "node" && runtime != "typescript" {
+		return fmt.Errorf("a package manager may only be chosen for the node and typescript runtimes, not '%v'", runtime)
+	}
+	return nil
+}
+
+// writePackageManager writes the variant of the template of the runtime for
+// the package manager, with its lockfile, over the template written to dest,
+// and removes the lockfiles of the other package managers which the template
+// wrote.  The
+// variant is that of the repository of the template, or that embedded for an
+// embedded template.  npm, of which the templates are written, has none.
+func (t templateWriter) writePackageManager(manager, runtime, template, dest string) (err error) {
+	if manager == "" || manager == DefaultPackageManager {
+		return
+	}
+	variant, accessor, err := t.resolvePackageManager(manager, runtime, template)
+	if err != nil {
+		return
+	}
+	if err = write(variant, dest, accessor, t.function, nil); err != nil {
+		return
+	}
+	src, templateAccessor, err := t.resolve(runtime, template)
+	if err != nil {
+		return
+	}
+	for m, lockfile := range lockfiles {
+		if m == manager {
+			continue
+		}
+		if _, e := templateAccessor.Stat(filepath.Join(src, lockfile)); e != nil {
+			continue // not of the template
+		}
+		if err = os.Remove(filepath.Join(dest, lockfile)); err != nil && !os.IsNotExist(err) {
+			return
+		}
+	}
+	return nil
+}
+
+// resolvePackageManager variant of the template of the runtime to its path,
+// and the accessor of its files.
+func (t templateWriter) resolvePackageManager(manager, runtime, template string) (string, fileAccessor, error) {
+	notFound := fmt.Errorf("%w: the template '%v' of the runtime '%v' provides none for '%v'", ErrPackageManagerNotFound, template, runtime, manager)
+	if IsTemplateURL(template) {
+		return "", nil, notFound
+	}
+	if isCustom(template) {
+		parts := strings.SplitN(template, "/", 2)
+		path := filepath.Join(t.templates, parts[0], packageManagerDir, manager, runtime, parts[1])
+		if _, err := os.Stat(path); err != nil {
+			return "", nil, notFound
+		}
+		return path, filesystemAccessor{}, nil
+	}
+	path := filepath.Join("/templates", packageManagerDir, manager, runtime, template)
+	if _, err := pkger.Stat(path); err != nil {
+		return "", nil, notFound
+	}
+	return path, embeddedAccessor{}, nil
+}
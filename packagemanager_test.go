@@ -0,0 +1,87 @@
+// +build !integration
+
+package function_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fn "github.com/boson-project/func"
+)
+
+// TestCreatePackageManager ensures the variant of the template for the
+// package manager is written with its lockfile, without that of npm, that the package
+// manager is persisted, and that npm writes the template as it is.
+func TestCreatePackageManager(t *testing.T) {
+	for _, runtime := range []string{"node", "typescript"} {
+		for _, manager := range []string{"yarn", "pnpm"} {
+			t.Run(runtime+"/"+manager, func(t *testing.T) {
+				root := "testdata/example.com/testCreatePackageManager-" + runtime + "-" + manager
+				defer using(t, root)()
+
+				client := fn.New(fn.WithRegistry(TestRegistry))
+				if err := client.Create(fn.Function{Root: root, Runtime: runtime, PackageManager: manager}); err != nil {
+					t.Fatal(err)
+				}
+				pkg, err := ioutil.ReadFile(filepath.Join(root, "package.json"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(pkg), `"local": "`+manager+` `) || strings.Contains(string(pkg), "npx ") {
+					t.Fatalf("expected the scripts of the package.json to run %v, got:\n%s", manager, pkg)
+				}
+				if _, err = os.Stat(filepath.Join(root, "package-lock.json")); !os.IsNotExist(err) {
+					t.Fatal("expected no package-lock.json to be written")
+				}
+				lockfile := map[string]string{"yarn": "yarn.lock", "pnpm": "pnpm-lock.yaml"}[manager]
+				if _, err = os.Stat(filepath.Join(root, lockfile)); err != nil {
+					t.Fatalf("expected the %v of %v to be written: %v", lockfile, manager, err)
+				}
+				f, err := fn.NewFunction(root)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if f.PackageManager != manager {
+					t.Fatalf("expected the package manager '%v' to be persisted, got '%v'", manager, f.PackageManager)
+				}
+			})
+		}
+	}
+
+	root := "testdata/example.com/testCreatePackageManagerNpm"
+	defer using(t, root)()
+	if err := fn.New(fn.WithRegistry(TestRegistry)).Create(fn.Function{Root: root, Runtime: "node", PackageManager: "npm"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "package-lock.json")); err != nil {
+		t.Fatalf("expected the package-lock.json of npm to be written: %v", err)
+	}
+}
+
+// TestCreatePackageManagerInvalid ensures a package manager may only be chosen
+// for a Node runtime, of those known, and that a template without a variant
+// for it is an error.
+func TestCreatePackageManagerInvalid(t *testing.T) {
+	root := "testdata/example.com/testCreatePackageManagerInvalid"
+	defer using(t, root)()
+
+	client := fn.New(fn.WithRegistry(TestRegistry))
+	if err := client.Create(fn.Function{Root: root, Runtime: "go", PackageManager: "yarn"}); err == nil {
+		t.Fatal("expected an error for a package manager of the go runtime")
+	}
+	if err := client.Create(fn.Function{Root: root, Runtime: "node", PackageManager: "bower"}); err == nil {
+		t.Fatal("expected an error for an unknown package manager")
+	}
+
+	custom := "testdata/example.com/testCreatePackageManagerCustom"
+	defer using(t, custom)()
+	client = fn.New(fn.WithRegistry(TestRegistry), fn.WithRepositories("testdata/repositories"))
+	err := client.Create(fn.Function{Root: custom, Runtime: "node", Template: "customProvider/json", PackageManager: "pnpm"})
+	if !errors.Is(err, fn.ErrPackageManagerNotFound) {
+		t.Fatalf("expected the variant of the template not to be found, got '%v'", err)
+	}
+}
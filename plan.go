@@ -0,0 +1,282 @@
+package function
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Plan of the changes the methods of a Client would make, populated in place
+// of making them when the Client is planning (see WithPlan), such as for a
+// dry run.  Create, Build, Deploy, RunPipeline and Remove are planned; the
+// other methods which make changes fail with ErrNotPlanned.  The zero value
+// is an empty Plan.
+type Plan struct {
+	mu     sync.Mutex
+	steps  []PlanStep
+	staged map[string]Function // configs planned to be written, by path
+}
+
+// PlanStep is a change planned, such as the writing of a file or the
+// applying of an object to the cluster.
+type PlanStep struct {
+	// Action of the step, such as "write", "build", "push", "apply",
+	// "delete" or "run".
+	Action string
+	// Target of the action, such as the path of a file, the name of an image
+	// or the kind and name of an object.
+	Target string
+	// Detail of the action, if any, such as the manifest of an object.
+	Detail string
+}
+
+// ErrNotPlanned is returned by the methods of a planning Client which make
+// changes that are not planned.
+var ErrNotPlanned = errors.New("the changes of this operation can not be planned")
+
+// Add steps to the plan.
+func (p *Plan) Add(steps ...PlanStep) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.steps = append(p.steps, steps...)
+}
+
+// Steps planned, in the order they would be taken.
+func (p *Plan) Steps() []PlanStep {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]PlanStep(nil), p.steps...)
+}
+
+// WriteConfig plans the writing of the config file of the Function, as would
+// Function.WriteConfig.  The Function is that loaded from its root by the
+// steps which follow, such that they are planned as though it were written.
+// A config planned to be written more than once is planned as a single step.
+func (p *Plan) WriteConfig(f Function) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	path := f.ConfigPath()
+	if _, ok := p.staged[path]; !ok {
+		p.steps = append(p.steps, PlanStep{Action: "write", Target: path})
+	}
+	if p.staged == nil {
+		p.staged = make(map[string]Function)
+	}
+	p.staged[path] = f
+}
+
+// config planned to be written for the Function at root, if any.
+func (p *Plan) config(root, file string) (f Function, ok bool) {
+	if root, err := filepath.Abs(root); err == nil {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		f, ok = p.staged[filepath.Join(root, configFileName(file))]
+	}
+	return
+}
+
+// Print the plan to w: a line per step of its action and target, followed by
+// its detail, if any, indented.
+func (p *Plan) Print(w io.Writer) (err error) {
+	steps := p.Steps()
+	if len(steps) == 0 {
+		_, err = fmt.Fprintln(w, "Dry run: no changes would be made")
+		return
+	}
+	if _, err = fmt.Fprintln(w, "Dry run: the following changes would be made"); err != nil {
+		return
+	}
+	for _, s := range steps {
+		if _, err = fmt.Fprintf(w, "  %-7v %v\n", s.Action, s.Target); err != nil {
+			return
+		}
+		if s.Detail == "" {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimRight(s.Detail, "\n"), "\n") {
+			if _, err = fmt.Fprintf(w, "      %v\n", line); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// PlanningDeployer is a Deployer which supports planning a deploy without
+// making it.
+type PlanningDeployer interface {
+	Deployer
+	// PlanDeploy returns the steps of deploying the Function, such as the
+	// applying of its objects, each with its manifest.
+	PlanDeploy(context.Context, Function) ([]PlanStep, error)
+}
+
+// PlanningRemover is a Remover which supports planning a removal without
+// making it.
+type PlanningRemover interface {
+	Remover
+	// PlanRemove returns the steps of removing the named Function from the
+	// namespace, as with Remove, such as the deleting of its objects.
+	PlanRemove(ctx context.Context, name, namespace string) ([]PlanStep, error)
+}
+
+// load the Function at root, or that planned to be written there when
+// planning.
+func (c *Client) load(root string) (Function, error) {
+	if c.plan != nil {
+		if f, ok := c.plan.config(root, c.configFile); ok {
+			return f, nil
+		}
+	}
+	return NewFunctionFromFile(root, c.configFile)
+}
+
+// planCreate plans the creation of the Function described by cfg by creating
+// it in a temporary directory, the files of which are those which would be
+// written to its root.  Its root, if it exists, is checked as by Create.
+func (c *Client) planCreate(cfg Function) (err error) {
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return
+	}
+	if cfg, _, err = templateURLOf(cfg); err != nil {
+		return
+	}
+	file := c.configFileOf(cfg)
+	if err = validateConfigFile(file); err != nil {
+		return
+	}
+	if _, statErr := os.Stat(root); statErr == nil {
+		if err = c.assertCreatable(root, file, cfg); err != nil {
+			return
+		}
+	}
+
+	tmp, err := ioutil.TempDir("", "func-plan")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	creator := *c
+	creator.plan = nil
+	creator.verbose = false
+	cfg.Root = tmp
+	cfg.ConfigFile = file
+	if err = creator.Create(cfg); err != nil {
+		return
+	}
+	f, err := NewFunctionFromFile(tmp, file)
+	if err != nil {
+		return
+	}
+
+	var steps []PlanStep
+	err = filepath.Walk(tmp, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || path == f.ConfigPath() {
+			return err
+		}
+		rel, err := filepath.Rel(tmp, path)
+		if err != nil {
+			return err
+		}
+		steps = append(steps, PlanStep{Action: "write", Target: filepath.Join(root, rel)})
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	c.plan.Add(steps...)
+	f.Root = root
+	c.plan.WriteConfig(f)
+	return
+}
+
+// planBuild plans the build of the Function: the running of its build hooks,
+// the building of its image, the writing of its config and the saving of its
+// image, as would Build.
+func (c *Client) planBuild(f Function) (err error) {
+	if err = c.planHook(f, PreBuildHook); err != nil {
+		return
+	}
+	step := PlanStep{Action: "build", Target: f.Image}
+	if f.Builder != "" {
+		step.Detail = "builder: " + f.Builder
+	}
+	c.plan.Add(step)
+	if err = c.planHook(f, PostBuildHook); err != nil {
+		return
+	}
+	f.ImageDigest = ""
+	c.plan.WriteConfig(f)
+	if c.outputDir != "" {
+		target := filepath.Join(c.outputDir, f.Name+".tar")
+		if c.outputFormat == "oci" {
+			target = filepath.Join(c.outputDir, f.Name+"-oci")
+		}
+		c.plan.Add(PlanStep{Action: "save", Target: target, Detail: c.outputFormat})
+	}
+	if c.sbomDir != "" {
+		c.plan.Add(PlanStep{Action: "save", Target: c.sbomDir, Detail: c.sbomFormat + " SBOM"})
+	}
+	return
+}
+
+// planHook plans the running of the given build hook of the Function, if any.
+func (c *Client) planHook(f Function, hook string) error {
+	script, err := f.hookScript(hook)
+	if err != nil || script == "" {
+		return err
+	}
+	c.plan.Add(PlanStep{Action: "run", Target: script, Detail: hook + " hook"})
+	return nil
+}
+
+// planDeploy plans the deploy of the Function: the pushing of its image, if
+// pushed, the steps of its deployer and the writing of its config, as would
+// Deploy.  Deployers which do not plan their deploys are planned as a single
+// step.
+func (c *Client) planDeploy(ctx context.Context, f Function, push bool) (err error) {
+	if push {
+		c.plan.Add(PlanStep{Action: "push", Target: f.Image})
+		// The digest of the image is not known until it is pushed.
+		f.ImageDigest = ""
+	}
+	deployed, _, err := c.deployed(f)
+	if err != nil {
+		return
+	}
+	steps := []PlanStep{{Action: "deploy", Target: f.Name}}
+	if pd, ok := c.deployer.(PlanningDeployer); ok {
+		if steps, err = pd.PlanDeploy(ctx, deployed); err != nil {
+			return
+		}
+	}
+	c.plan.Add(steps...)
+	if push || c.status {
+		c.plan.WriteConfig(f)
+	}
+	return
+}
+
+// remove the named Function from the namespace, or plan to when planning.
+// Removers which do not plan their removals are planned as a single step.
+func (c *Client) remove(ctx context.Context, name, namespace string) (err error) {
+	if c.plan == nil {
+		return c.remover.Remove(ctx, name, namespace)
+	}
+	steps := []PlanStep{{Action: "delete", Target: name}}
+	if pr, ok := c.remover.(PlanningRemover); ok {
+		if steps, err = pr.PlanRemove(ctx, name, namespace); err != nil {
+			return
+		}
+	}
+	c.plan.Add(steps...)
+	return
+}
@@ -3,10 +3,8 @@ package plugin
 import (
 	"context"
 	"os"
-	"os/signal"
 	"runtime/debug"
 	"strings"
-	"syscall"
 
 	"knative.dev/client/pkg/kn/plugin"
 
@@ -24,17 +22,9 @@ func (f *funcPlugin) Name() string {
 }
 
 func (f *funcPlugin) Execute(args []string) error {
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := cmd.NewSignalContext(context.Background())
 	defer cancel()
 
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-
-	go func() {
-		<-sigs
-		cancel()
-	}()
-
 	rootCmd := cmd.NewRootCmd()
 	info, _ := debug.ReadBuildInfo()
 	for _, dep := range info.Deps {
@@ -47,7 +37,15 @@ func (f *funcPlugin) Execute(args []string) error {
 		os.Args = oldArgs
 	})()
 	os.Args = append([]string{"kn-func"}, args...)
-	return rootCmd.ExecuteContext(ctx)
+	err := rootCmd.ExecuteContext(ctx)
+	cmd.StopJSONLogs(ctx, err)
+	if err != nil {
+		if cmd.Interrupted(ctx, err) {
+			return cmd.ErrInterrupted
+		}
+		return err
+	}
+	return nil
 }
 
 // Description for function subcommand visible in 'kn --help'
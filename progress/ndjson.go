@@ -0,0 +1,122 @@
+package progress
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Levels of the events of a command.
+const (
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// Kinds of the events of a command: its start, the progress of its steps,
+// warnings, its result (its output) and the error with which it failed.
+const (
+	EventStart    = "start"
+	EventProgress = "progress"
+	EventWarning  = "warning"
+	EventResult   = "result"
+	EventError    = "error"
+)
+
+// Event of a command, written as a line of NDJSON.
+type Event struct {
+	TS      time.Time              `json:"ts"`
+	Level   string                 `json:"level"`
+	Command string                 `json:"command"`
+	Event   string                 `json:"event"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// NDJSON is a progress listener writing the steps of a command, and any other
+// of its events, as newline delimited JSON: an Event per line, such as for
+// log aggregation.  It is safe for concurrent use.
+type NDJSON struct {
+	mu      sync.Mutex
+	out     io.Writer
+	command string
+	index   int
+	total   int
+}
+
+// NewNDJSON creates a writer of the events of the given command to w.
+func NewNDJSON(w io.Writer, command string) *NDJSON {
+	return &NDJSON{out: w, command: command}
+}
+
+// Emit an event of the given level and kind.
+func (j *NDJSON) Emit(level, event, message string, fields map[string]interface{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_ = json.NewEncoder(j.out).Encode(Event{
+		TS:      time.Now().UTC(),
+		Level:   level,
+		Command: j.command,
+		Event:   event,
+		Message: message,
+		Fields:  fields,
+	})
+}
+
+// SetTotal number of steps.
+func (j *NDJSON) SetTotal(n int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.total = n
+}
+
+// Increment to the next step, emitting its progress.
+func (j *NDJSON) Increment(text string) {
+	j.mu.Lock()
+	if j.index < j.total {
+		j.index++
+	}
+	fields := j.steps()
+	j.mu.Unlock()
+	j.Emit(LevelInfo, EventProgress, text, fields)
+}
+
+// Complete the steps, emitting the final progress.
+func (j *NDJSON) Complete(text string) {
+	j.mu.Lock()
+	j.index = j.total
+	fields := j.steps()
+	j.mu.Unlock()
+	j.Emit(LevelInfo, EventProgress, text, fields)
+}
+
+// Done is a noop, as events are written as they occur.
+func (j *NDJSON) Done() {}
+
+// steps returns the fields of the current step, none if no total is set.
+func (j *NDJSON) steps() map[string]interface{} {
+	if j.total == 0 {
+		return nil
+	}
+	return map[string]interface{}{"step": j.index, "total": j.total}
+}
+
+// Lines emits each line read from r as an event of the given level and kind,
+// until r is exhausted.
+func (j *NDJSON) Lines(r io.Reader, level, event string) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			j.Emit(level, event, line, nil)
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,424 @@
+package function
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRepositoryExists is returned when adding a repository of the same name
+// as one already in the repositories directory.
+var ErrRepositoryExists = errors.New("repository already exists")
+
+// ErrRepositoryNotGit is returned when updating a repository which was not
+// added from a git URL, such as one copied into the repositories directory.
+var ErrRepositoryNotGit = errors.New("repository was not added from git")
+
+// Keys of the git config of a repository's clone in which the ref added and
+// the time it was last fetched are recorded.
+const (
+	repositoryRefKey     = "func.ref"
+	repositoryUpdatedKey = "func.updated"
+)
+
+// RepositoryInfo describes a template repository of the repositories
+// directory: where it was cloned from, if from git, and when last updated.
+type RepositoryInfo struct {
+	// Name of the repository, with which its templates are prefixed.
+	Name string `json:"name" yaml:"name" xml:"name,attr"`
+
+	// URL of the git repository from which it was cloned, if any.
+	URL string `json:"url,omitempty" yaml:"url,omitempty" xml:"url,omitempty"`
+
+	// Ref (branch or tag) cloned.  Empty for the default branch.
+	Ref string `json:"ref,omitempty" yaml:"ref,omitempty" xml:"ref,omitempty"`
+
+	// Updated is when the repository was last fetched or, if not from git,
+	// last modified.
+	Updated time.Time `json:"updated" yaml:"updated" xml:"updated"`
+}
+
+// Stale returns whether the repository is from git and was last updated
+// longer ago than the given time to live.
+func (r RepositoryInfo) Stale(ttl time.Duration) bool {
+	return r.URL != "" && time.Since(r.Updated) > ttl
+}
+
+// RepositoryInfos describes each repository of the client's repositories
+// directory, in order of name.
+func (c *Client) RepositoryInfos(ctx context.Context) (rr []RepositoryInfo, err error) {
+	if c.repositories == "" {
+		return nil, nil
+	}
+	dirs, err := readDir(c.repositories, filesystemAccessor{})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() || hidden(dir.Name()) {
+			continue
+		}
+		var r RepositoryInfo
+		if r, err = repositoryInfo(ctx, c.repositories, dir.Name()); err != nil {
+			return
+		}
+		rr = append(rr, r)
+	}
+	sort.Slice(rr, func(i, j int) bool { return rr[i].Name < rr[j].Name })
+	return
+}
+
+// AddRepository clones the git repository at url into the client's
+// repositories directory, such that its templates are available to create
+// Functions as [name]/[template].  The name defaults to that of the
+// repository in the URL.  The ref, a branch or tag, defaults to the
+// repository's default branch.  The repository must be a valid template
+// repository; it is cloned aside and only added once validated.
+func (c *Client) AddRepository(ctx context.Context, url, name, ref string) (r RepositoryInfo, err error) {
+	if c.plan != nil {
+		return r, ErrNotPlanned
+	}
+	if !c.persist {
+		return r, ErrPersistenceDisabled
+	}
+	if c.repositories == "" {
+		return r, errors.New("no repositories directory configured")
+	}
+	if name == "" {
+		name = repositoryName(url)
+	}
+	if name == "" || hidden(name) || strings.ContainsAny(name, `/\`) {
+		return r, fmt.Errorf("invalid repository name '%v'", name)
+	}
+	dest := filepath.Join(c.repositories, name)
+	if _, err = os.Stat(dest); err == nil {
+		return r, fmt.Errorf("%w: '%v'", ErrRepositoryExists, name)
+	}
+	if err = os.MkdirAll(c.repositories, 0755); err != nil {
+		return
+	}
+
+	// Clone into a hidden directory, which is not read as a repository, such
+	// that a failed clone does not leave a partial repository.
+	tmp, err := ioutil.TempDir(c.repositories, "."+name+"-")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	args := []string{"clone", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	if _, err = gitRemote(ctx, "", url, append(args, "--", url, tmp)...); err != nil {
+		return
+	}
+	if ref != "" {
+		if _, err = git(ctx, tmp, "config", repositoryRefKey, ref); err != nil {
+			return
+		}
+	}
+	if err = touchRepository(ctx, tmp); err != nil {
+		return
+	}
+	if _, err = readRepository(tmp, name); err != nil {
+		return
+	}
+	if err = os.Rename(tmp, dest); err != nil {
+		return
+	}
+	return repositoryInfo(ctx, c.repositories, name)
+}
+
+// UpdateRepository re-fetches the ref of the named repository from the URL
+// from which it was cloned, discarding any local changes.
+func (c *Client) UpdateRepository(ctx context.Context, name string) (r RepositoryInfo, err error) {
+	if c.plan != nil {
+		return r, ErrNotPlanned
+	}
+	if !c.persist {
+		return r, ErrPersistenceDisabled
+	}
+	if r, err = repositoryInfo(ctx, c.repositories, name); err != nil {
+		return
+	}
+	if r.URL == "" {
+		return r, fmt.Errorf("%w: '%v' can not be updated", ErrRepositoryNotGit, name)
+	}
+	dir := filepath.Join(c.repositories, name)
+	ref := r.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err = gitRemote(ctx, dir, r.URL, "fetch", "--depth", "1", "origin", ref); err != nil {
+		return
+	}
+	if _, err = git(ctx, dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+		return
+	}
+	if err = touchRepository(ctx, dir); err != nil {
+		return
+	}
+	return repositoryInfo(ctx, c.repositories, name)
+}
+
+// repositoryWorkers is the maximum number of repositories fetched
+// concurrently when updating several.
+var repositoryWorkers = 4
+
+// RepositoryErrors are the errors of the repositories which failed to update,
+// by name.
+type RepositoryErrors map[string]error
+
+func (e RepositoryErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = fmt.Sprintf("repository '%v': %v", name, e[name])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// UpdateRepositories updates each of the named repositories as does
+// UpdateRepository, fetching at most repositoryWorkers of them concurrently,
+// such that several repositories are updated in about the time of the
+// slowest rather than of them all.  A repository which fails to update does
+// not stop the others: those updated are returned in the order named, along
+// with the RepositoryErrors of those which failed.
+func (c *Client) UpdateRepositories(ctx context.Context, names []string) (rr []RepositoryInfo, err error) {
+	if c.plan != nil {
+		return nil, ErrNotPlanned
+	}
+	if !c.persist {
+		return nil, ErrPersistenceDisabled
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		updated = make([]*RepositoryInfo, len(names))
+		errs    = RepositoryErrors{}
+		queue   = make(chan int)
+	)
+	workers := repositoryWorkers
+	if workers > len(names) {
+		workers = len(names)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				r, err := c.UpdateRepository(ctx, names[i])
+				mu.Lock()
+				if err != nil {
+					errs[names[i]] = err
+				} else {
+					updated[i] = &r
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range names {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	for _, r := range updated {
+		if r != nil {
+			rr = append(rr, *r)
+		}
+	}
+	if len(errs) > 0 {
+		err = errs
+	}
+	return
+}
+
+// UpdateStaleRepositories updates, concurrently, those of the repositories
+// added from git which were last updated longer ago than the ttl, such that
+// the templates subsequently resolved are those of warm clones.  Those
+// updated are returned, along with the RepositoryErrors of any which failed,
+// the others being updated regardless.
+func (c *Client) UpdateStaleRepositories(ctx context.Context, ttl time.Duration) ([]RepositoryInfo, error) {
+	rr, err := c.RepositoryInfos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var stale []string
+	for _, r := range rr {
+		if r.Stale(ttl) {
+			stale = append(stale, r.Name)
+		}
+	}
+	if len(stale) == 0 {
+		return nil, nil
+	}
+	return c.UpdateRepositories(ctx, stale)
+}
+
+// RemoveRepository of the given name from the client's repositories
+// directory.
+func (c *Client) RemoveRepository(name string) error {
+	if c.plan != nil {
+		return ErrNotPlanned
+	}
+	if !c.persist {
+		return ErrPersistenceDisabled
+	}
+	if c.repositories == "" || name == "" || hidden(name) || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("%w: '%v'", ErrRepositoryNotFound, name)
+	}
+	dir := filepath.Join(c.repositories, name)
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: '%v'", ErrRepositoryNotFound, name)
+		}
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// checkoutRepository fetches the given ref, a branch, tag or commit, of the
+// named repository added from git, returning the path of a repositories
+// directory containing only that repository at the ref.  It is a hidden
+// directory of the client's repositories, or a temporary directory without
+// persistence, to be removed once used.  The repository as added is left as
+// is.
+func (c *Client) checkoutRepository(ctx context.Context, name, ref string) (dir string, err error) {
+	r, err := repositoryInfo(ctx, c.repositories, name)
+	if err != nil {
+		return
+	}
+	if r.URL == "" {
+		return "", fmt.Errorf("%w: '%v' can not be checked out at ref '%v'", ErrRepositoryNotGit, name, ref)
+	}
+	parent := c.repositories
+	if !c.persist {
+		parent = ""
+	}
+	if dir, err = ioutil.TempDir(parent, "."+name+"@"); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dir)
+			dir = ""
+		}
+	}()
+
+	// Fetching rather than cloning the ref permits it to be a commit.
+	clone := filepath.Join(dir, name)
+	if _, err = git(ctx, "", "init", "--quiet", clone); err != nil {
+		return
+	}
+	if _, err = git(ctx, clone, "remote", "add", "origin", r.URL); err != nil {
+		return
+	}
+	if _, err = gitRemote(ctx, clone, r.URL, "fetch", "--depth", "1", "origin", ref); err != nil {
+		return dir, fmt.Errorf("failed to fetch ref '%v' of repository '%v': %w", ref, name, err)
+	}
+	_, err = git(ctx, clone, "checkout", "--quiet", "FETCH_HEAD")
+	return
+}
+
+// repositoryInfo describes the repository of the given name in the
+// repositories directory at path.
+func repositoryInfo(ctx context.Context, path, name string) (r RepositoryInfo, err error) {
+	dir := filepath.Join(path, name)
+	fi, err := os.Stat(dir)
+	if os.IsNotExist(err) || (err == nil && !fi.IsDir()) || path == "" {
+		return r, fmt.Errorf("%w: '%v'", ErrRepositoryNotFound, name)
+	}
+	if err != nil {
+		return
+	}
+	r = RepositoryInfo{Name: name, Updated: fi.ModTime()}
+
+	if _, err = os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		return r, nil // not from git
+	}
+	// Unset config values are not errors, git exiting non-zero for them.
+	r.URL, _ = git(ctx, dir, "config", "--get", "remote.origin.url")
+	r.Ref, _ = git(ctx, dir, "config", "--get", repositoryRefKey)
+	if updated, _ := git(ctx, dir, "config", "--get", repositoryUpdatedKey); updated != "" {
+		if t, err := time.Parse(time.RFC3339, updated); err == nil {
+			r.Updated = t
+		}
+	}
+	return r, nil
+}
+
+// touchRepository records the current time as that at which the repository
+// cloned at dir was last updated.
+func touchRepository(ctx context.Context, dir string) error {
+	_, err := git(ctx, dir, "config", repositoryUpdatedKey, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// repositoryName derives the name of a repository from its git URL, as git
+// does for the directory of a clone: "https://github.com/alice/templates.git"
+// is "templates".
+func repositoryName(url string) string {
+	url = strings.TrimRight(url, "/")
+	if i := strings.LastIndex(url, ":"); i > strings.LastIndex(url, "/") {
+		url = url[i+1:] // scp-like, such as git@github.com:templates.git
+	}
+	return strings.TrimSuffix(path.Base(url), ".git")
+}
+
+// git runs the git command with the given arguments in dir, returning its
+// output.  The error on failure includes what git printed to stderr.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	return gitEnv(ctx, dir, nil, args...)
+}
+
+// gitEnv runs the git command as does git, with the given variables added to
+// its environment.
+func gitEnv(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// The command is named by its first argument which is not a -c option.
+		name := args[0]
+		for i := 0; i+2 < len(args) && args[i] == "-c"; i += 2 {
+			name = args[i+2]
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %v failed: %v", name, msg)
+		}
+		return "", fmt.Errorf("git %v failed: %v", name, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// hidden returns whether the file of the given name is hidden, such as the
+// .git directory of a repository, or a repository being cloned.
+func hidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
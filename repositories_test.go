@@ -0,0 +1,327 @@
+// +build !integration
+
+package function
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRepositoryLifecycle ensures a repository added from git provides its
+// templates, is updated to the latest of its ref, and may be removed.
+func TestRepositoryLifecycle(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	tmp, err := ioutil.TempDir("", "func-repositories")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	origin := filepath.Join(tmp, "templates")
+	commitTemplate(t, origin, "go", "http")
+
+	client := New(WithRepositories(filepath.Join(tmp, "repositories")))
+	r, err := client.AddRepository(ctx, origin, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Name != "templates" || r.URL != origin || time.Since(r.Updated) > time.Minute {
+		t.Fatalf("unexpected repository %+v", r)
+	}
+	if !hasTemplate(t, client, "templates/http") {
+		t.Fatal("expected the template of the repository added")
+	}
+	if _, err = client.AddRepository(ctx, origin, "", ""); !errors.Is(err, ErrRepositoryExists) {
+		t.Fatalf("expected ErrRepositoryExists, got %v", err)
+	}
+
+	commitTemplate(t, origin, "go", "events")
+	if _, err = client.UpdateRepository(ctx, "templates"); err != nil {
+		t.Fatal(err)
+	}
+	if !hasTemplate(t, client, "templates/events") {
+		t.Fatal("expected the template added to the repository since to be updated")
+	}
+
+	rr, err := client.RepositoryInfos(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rr) != 1 || rr[0].Stale(time.Hour) || !rr[0].Stale(0) {
+		t.Fatalf("expected only the repository added, updated just now, got %+v", rr)
+	}
+
+	if err = client.RemoveRepository("templates"); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.RemoveRepository("templates"); !errors.Is(err, ErrRepositoryNotFound) {
+		t.Fatalf("expected ErrRepositoryNotFound, got %v", err)
+	}
+}
+
+// TestConfigDir ensures the template repositories of a client are those of
+// its config directory unless given explicitly, and that a client without
+// persistence reads them but does not write to the config directory.
+func TestConfigDir(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	tmp, err := ioutil.TempDir("", "func-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	origin := filepath.Join(tmp, "templates")
+	commitTemplate(t, origin, "go", "http")
+	config := filepath.Join(tmp, "config")
+
+	if _, err = New(WithConfigDir(config)).AddRepository(ctx, origin, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(config, RepositoriesDir, "templates")); err != nil {
+		t.Fatalf("expected the repository to be added to the config directory: %v", err)
+	}
+	if hasTemplate(t, New(WithConfigDir(config), WithRepositories("")), "templates/http") {
+		t.Fatal("expected the repositories given explicitly to take precedence over the config directory")
+	}
+
+	client := New(WithConfigDir(config), WithoutPersistence())
+	if !hasTemplate(t, client, "templates/http") {
+		t.Fatal("expected the repositories of the config directory to be read without persistence")
+	}
+	if _, err = client.AddRepository(ctx, origin, "other", ""); !errors.Is(err, ErrPersistenceDisabled) {
+		t.Fatalf("expected ErrPersistenceDisabled adding a repository, got %v", err)
+	}
+	if _, err = client.UpdateRepository(ctx, "templates"); !errors.Is(err, ErrPersistenceDisabled) {
+		t.Fatalf("expected ErrPersistenceDisabled updating a repository, got %v", err)
+	}
+	if err = client.RemoveRepository("templates"); !errors.Is(err, ErrPersistenceDisabled) {
+		t.Fatalf("expected ErrPersistenceDisabled removing a repository, got %v", err)
+	}
+
+	root := filepath.Join(tmp, "myfunc")
+	if err = client.Create(Function{Root: root, Runtime: "go", Template: "templates/http", TemplateRef: "HEAD"}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ioutil.ReadDir(filepath.Join(config, RepositoriesDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected the ref to be checked out outside of the config directory, got %v entries", len(entries))
+	}
+}
+
+// TestCreateTemplateRef ensures a Function is created from the template of a
+// repository at the ref given, which is recorded in its configuration, and
+// that a ref which can not be fetched is named in the error.
+func TestCreateTemplateRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	tmp, err := ioutil.TempDir("", "func-template-ref")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	origin := filepath.Join(tmp, "templates")
+	commitTemplate(t, origin, "go", "http")
+	if _, err = git(ctx, origin, "tag", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	commitTemplate(t, origin, "go", "events")
+
+	repositories := filepath.Join(tmp, "repositories")
+	client := New(WithRepositories(repositories))
+	if _, err = client.AddRepository(ctx, origin, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Create(Function{Root: filepath.Join(tmp, "events"), Runtime: "go", Template: "templates/events", TemplateRef: "v1"})
+	if !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("expected the template added since v1 not to be found, got %v", err)
+	}
+	root := filepath.Join(tmp, "myfunc")
+	if err = client.Create(Function{Root: root, Runtime: "go", Template: "templates/http", TemplateRef: "v1"}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.TemplateRef != "v1" {
+		t.Fatalf("expected the template ref to be recorded, got '%v'", f.TemplateRef)
+	}
+
+	err = client.Create(Function{Root: filepath.Join(tmp, "other"), Runtime: "go", Template: "templates/http", TemplateRef: "nope"})
+	if err == nil || !strings.Contains(err.Error(), "'nope'") {
+		t.Fatalf("expected an error naming the ref, got %v", err)
+	}
+	if dirs, _ := ioutil.ReadDir(repositories); len(dirs) != 1 {
+		t.Fatalf("expected the checkouts to be removed, got %v entries", len(dirs))
+	}
+}
+
+// TestUpdateRepositoryNotGit ensures a repository not added from git is not
+// updated.
+func TestUpdateRepositoryNotGit(t *testing.T) {
+	client := New(WithRepositories("testdata/repositories"))
+	if _, err := client.UpdateRepository(context.Background(), "customProvider"); !errors.Is(err, ErrRepositoryNotGit) {
+		t.Fatalf("expected ErrRepositoryNotGit, got %v", err)
+	}
+}
+
+// TestUpdateRepositories ensures repositories are updated concurrently, and
+// that one failing to update is reported without stopping the others.
+func TestUpdateRepositories(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	tmp, err := ioutil.TempDir("", "func-repositories")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	client := New(WithRepositories(filepath.Join(tmp, "repositories")))
+	names := []string{"alpha", "beta", "gamma"}
+	for _, name := range names {
+		origin := filepath.Join(tmp, name)
+		commitTemplate(t, origin, "go", "http")
+		if _, err = client.AddRepository(ctx, origin, "", ""); err != nil {
+			t.Fatal(err)
+		}
+		commitTemplate(t, origin, "go", "events")
+	}
+	// The origin of beta is gone, such that it fails to update.
+	if err = os.RemoveAll(filepath.Join(tmp, "beta")); err != nil {
+		t.Fatal(err)
+	}
+
+	rr, err := client.UpdateStaleRepositories(ctx, 0)
+	var errs RepositoryErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs["beta"] == nil {
+		t.Fatalf("expected the error of beta alone, got %v", err)
+	}
+	if len(rr) != 2 || rr[0].Name != "alpha" || rr[1].Name != "gamma" {
+		t.Fatalf("expected alpha and gamma to be updated, got %+v", rr)
+	}
+	if !hasTemplate(t, client, "alpha/events") || !hasTemplate(t, client, "gamma/events") || hasTemplate(t, client, "beta/events") {
+		t.Fatal("expected the templates of the repositories updated")
+	}
+
+	if rr, err = client.UpdateStaleRepositories(ctx, time.Hour); err != nil || len(rr) != 0 {
+		t.Fatalf("expected no repositories updated within the ttl, got %+v, %v", rr, err)
+	}
+}
+
+// TestRepositoryName ensures the name of a repository is derived from its
+// URL as git does.
+func TestRepositoryName(t *testing.T) {
+	for url, name := range map[string]string{
+		"https://github.com/alice/templates.git":  "templates",
+		"https://github.com/alice/templates/":     "templates",
+		"git@github.com:alice/templates.git":      "templates",
+		"git@github.com:templates.git":            "templates",
+		"/home/alice/src/templates":               "templates",
+		"file:///home/alice/src/my-templates.git": "my-templates",
+	} {
+		if got := repositoryName(url); got != name {
+			t.Fatalf("repositoryName(%v) = %v, want %v", url, got, name)
+		}
+	}
+}
+
+// commitTemplate to the git repository at dir, initializing it if need be.
+func commitTemplate(t testing.TB, dir, runtime, template string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, runtime, template), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, runtime, template, "handle.go"), []byte("package function\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", template},
+	} {
+		if _, err := git(context.Background(), dir, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// hasTemplate returns whether the client provides the named template.
+func hasTemplate(t *testing.T, client *Client, name string) bool {
+	t.Helper()
+	tt, err := client.Templates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tpl := range tt {
+		if tpl.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// BenchmarkUpdateRepositories compares updating several repositories
+// serially with updating them concurrently.  The origins being local, the
+// speedup is bounded by the CPUs available, whereas fetches from remote
+// origins mostly wait on the network, and so overlap regardless.
+func BenchmarkUpdateRepositories(b *testing.B) {
+	if _, err := exec.LookPath("git"); err != nil {
+		b.Skip("git is not installed")
+	}
+	tmp, err := ioutil.TempDir("", "func-bench-repositories")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	client := New(WithRepositories(filepath.Join(tmp, "repositories")))
+	var names []string
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("templates%v", i)
+		origin := filepath.Join(tmp, name)
+		commitTemplate(b, origin, "go", "http")
+		if _, err = client.AddRepository(context.Background(), origin, "", ""); err != nil {
+			b.Fatal(err)
+		}
+		names = append(names, name)
+	}
+
+	defer func(workers int) { repositoryWorkers = workers }(repositoryWorkers)
+	for _, workers := range []int{1, repositoryWorkers} {
+		name := "serial"
+		if workers > 1 {
+			name = "concurrent"
+		}
+		b.Run(name, func(b *testing.B) {
+			repositoryWorkers = workers
+			for i := 0; i < b.N; i++ {
+				if _, err := client.UpdateRepositories(context.Background(), names); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package function
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RepositoryManifestFile is the optional manifest at the root of a template
+// repository, declaring the runtimes and templates it provides, and the
+// builder images with which Functions of each runtime are built.  For
+// example:
+//
+//	runtimes:
+//	- name: go
+//	  builders:
+//	    default: quay.io/alice/go-builder
+//	  templates:
+//	  - http
+//	  - events
+//
+// A repository without a manifest provides each [runtime]/[template]
+// directory it contains, without builders.
+const RepositoryManifestFile = "manifest.yaml"
+
+// ErrNotDeclared is the cause of a TemplateError for a runtime or template
+// which exists in a repository but is not declared by its manifest.
+var ErrNotDeclared = errors.New("not declared by the repository manifest")
+
+// Repository of templates, as declared by its manifest or inferred from its
+// directory structure.
+type Repository struct {
+	// Name of the repository, with which its templates are prefixed.
+	Name string `yaml:"-"`
+
+	// Runtimes provided by the repository.
+	Runtimes []RepositoryRuntime `yaml:"runtimes"`
+}
+
+// RepositoryRuntime is a runtime provided by a repository.
+type RepositoryRuntime struct {
+	// Name of the runtime, for example "go".
+	Name string `yaml:"name"`
+
+	// Builders with which Functions created from the runtime's templates are
+	// built, keyed by name.  The builder named "default" is used unless
+	// otherwise configured.  Optional.
+	Builders map[string]string `yaml:"builders,omitempty"`
+
+	// Templates provided for the runtime, by name, excluding the repository
+	// prefix.
+	Templates []string `yaml:"templates"`
+}
+
+// runtime of the given name, and whether it is provided by the repository.
+func (r Repository) runtime(name string) (RepositoryRuntime, bool) {
+	for _, rt := range r.Runtimes {
+		if rt.Name == name {
+			return rt, true
+		}
+	}
+	return RepositoryRuntime{}, false
+}
+
+// hasTemplate returns whether the runtime provides the named template.
+func (rt RepositoryRuntime) hasTemplate(name string) bool {
+	for _, t := range rt.Templates {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// readRepositories reads each repository within the directory at path, in
+// order of name.  A nonexistent directory has no repositories.  Hidden
+// directories, such as a repository being cloned, are not repositories.
+func readRepositories(path string) (rr []Repository, err error) {
+	dirs, err := readDir(path, filesystemAccessor{})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() || hidden(dir.Name()) {
+			continue
+		}
+		var r Repository
+		if r, err = readRepository(filepath.Join(path, dir.Name()), dir.Name()); err != nil {
+			return
+		}
+		rr = append(rr, r)
+	}
+	return
+}
+
+// readRepository of the given name at path, from its manifest if any,
+// ensuring the runtimes and templates declared exist, or otherwise inferred
+// from its directory structure.
+func readRepository(path, name string) (r Repository, err error) {
+	bb, err := ioutil.ReadFile(filepath.Join(path, RepositoryManifestFile))
+	if os.IsNotExist(err) {
+		return inferRepository(path, name)
+	}
+	if err != nil {
+		return
+	}
+
+	if err = yaml.UnmarshalStrict(bb, &r); err != nil {
+		return r, fmt.Errorf("repository '%v' manifest '%v' is not valid: %v", name, RepositoryManifestFile, err)
+	}
+	r.Name = name
+	for _, rt := range r.Runtimes {
+		if rt.Name == "" {
+			return r, fmt.Errorf("repository '%v' manifest '%v' declares a runtime without a name", name, RepositoryManifestFile)
+		}
+		for _, t := range rt.Templates {
+			if _, err = os.Stat(filepath.Join(path, rt.Name, t)); err != nil {
+				return r, fmt.Errorf("repository '%v' manifest '%v' declares template '%v' of runtime '%v', which does not exist: %w", name, RepositoryManifestFile, t, rt.Name, err)
+			}
+		}
+	}
+	return
+}
+
+// inferRepository of the given name at path from its directory structure,
+// each [runtime]/[template] directory being provided.  Hidden directories,
+// such as .git, are neither runtimes nor templates.
+func inferRepository(path, name string) (r Repository, err error) {
+	r.Name = name
+	runtimes, err := readDir(path, filesystemAccessor{})
+	if err != nil {
+		return
+	}
+	for _, runtime := range runtimes {
+		if !runtime.IsDir() || hidden(runtime.Name()) {
+			continue
+		}
+		var children []os.FileInfo
+		if children, err = readDir(filepath.Join(path, runtime.Name()), filesystemAccessor{}); err != nil {
+			return
+		}
+		rt := RepositoryRuntime{Name: runtime.Name()}
+		for _, child := range children {
+			if child.IsDir() && !hidden(child.Name()) {
+				rt.Templates = append(rt.Templates, child.Name())
+			}
+		}
+		r.Runtimes = append(r.Runtimes, rt)
+	}
+	return
+}
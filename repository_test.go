@@ -0,0 +1,51 @@
+// +build !integration
+
+package function
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRepositoryInferred ensures that a repository without a manifest
+// provides each of its [runtime]/[template] directories.
+func TestRepositoryInferred(t *testing.T) {
+	r, err := readRepository("testdata/repositories/customProvider", "customProvider")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt, ok := r.runtime("test")
+	if !ok {
+		t.Fatalf("expected the 'test' runtime to be inferred, got %+v", r)
+	}
+	for _, name := range []string{"tpla", "tplb", "tplc", "tpld"} {
+		if !rt.hasTemplate(name) {
+			t.Fatalf("expected template '%v' to be inferred, got %+v", name, rt.Templates)
+		}
+	}
+	if len(rt.Builders) != 0 {
+		t.Fatalf("expected no builders to be inferred, got %v", rt.Builders)
+	}
+}
+
+// TestRepositoryManifestInvalid ensures that a manifest declaring a template
+// which does not exist is an error.
+func TestRepositoryManifestInvalid(t *testing.T) {
+	root, err := ioutil.TempDir("", "repository")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	manifest := "runtimes:\n- name: go\n  templates:\n  - missing\n"
+	if err = ioutil.WriteFile(filepath.Join(root, RepositoryManifestFile), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = readRepository(root, "invalid")
+	if err == nil || !strings.Contains(err.Error(), "declares template 'missing'") {
+		t.Fatalf("expected an error for the missing template, got %v", err)
+	}
+}
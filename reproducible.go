@@ -0,0 +1,57 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// SourceDateEpochEnv is the environment variable, of the convention of
+// reproducible-builds.org, of the time in seconds since the Unix epoch to
+// which the timestamps of a reproducible build are normalized.
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// DefaultSourceDateEpoch is the time of the reproducible builds of Functions
+// of which the source is not in a git repository: that to which the
+// buildpacks lifecycle normalizes the timestamps of the images it exports.
+var DefaultSourceDateEpoch = time.Date(1980, time.January, 1, 0, 0, 1, 0, time.UTC)
+
+// sourceDateEpoch returns the time to which a reproducible build of the
+// Function is normalized: that of SourceDateEpochEnv, if set, or else the
+// commit time of the HEAD of the git repository of its source, and otherwise
+// DefaultSourceDateEpoch.
+func sourceDateEpoch(ctx context.Context, f Function) (time.Time, error) {
+	if epoch := os.Getenv(SourceDateEpochEnv); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil || seconds < 0 {
+			return time.Time{}, fmt.Errorf("invalid %v '%v': must be a number of seconds since the Unix epoch", SourceDateEpochEnv, epoch)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	if commit, err := git(ctx, f.Root, "log", "-1", "--format=%ct"); err == nil && commit != "" {
+		if seconds, err := strconv.ParseInt(commit, 10, 64); err == nil {
+			return time.Unix(seconds, 0).UTC(), nil
+		}
+	}
+	return DefaultSourceDateEpoch, nil
+}
+
+// withSourceDateEpoch returns the build envs with SourceDateEpochEnv of the
+// given time, unless they set it themselves, such that the buildpacks and
+// Dockerfiles which honor it normalize their timestamps to it.
+func withSourceDateEpoch(envs Envs, epoch time.Time) Envs {
+	return withBuildEnv(envs, SourceDateEpochEnv, strconv.FormatInt(epoch.Unix(), 10))
+}
+
+// withBuildEnv returns the build envs with that of the given name and value,
+// unless they set it themselves.  The envs given are not modified.
+func withBuildEnv(envs Envs, name, value string) Envs {
+	for _, e := range envs {
+		if e.Name != nil && *e.Name == name {
+			return envs
+		}
+	}
+	return append(append(Envs{}, envs...), Env{Name: &name, Value: &value})
+}
@@ -0,0 +1,93 @@
+package function
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaID is the identifier of the JSON Schema of the config file.
+const SchemaID = "https://boson-project.github.io/func/schema/func.yaml.json"
+
+// Schema returns the JSON Schema of the config file (func.yaml), derived by
+// reflection from its serialized form, such that it remains in sync.  Each
+// object's properties are those of its fields' yaml tags, additional
+// properties being invalid as when the file is loaded, other than those of
+// inline maps, such as extended resources.  The runtime is restricted to
+// those given, if any, the platform to Platforms, the image pull policy to
+// ImagePullPolicies and the builder pull policy to BuilderPullPolicies.
+func Schema(runtimes ...string) map[string]interface{} {
+	s := schemaOf(reflect.TypeOf(config{}))
+	s["$schema"] = "http://json-schema.org/draft-07/schema#"
+	s["$id"] = SchemaID
+	s["title"] = "Function configuration (" + ConfigFile + ")"
+	s["required"] = []string{"name"}
+
+	properties := s["properties"].(map[string]interface{})
+	properties["specVersion"].(map[string]interface{})["default"] = SpecVersion
+	properties["platform"].(map[string]interface{})["enum"] = Platforms
+	properties["imagePullPolicy"].(map[string]interface{})["enum"] = ImagePullPolicies
+	properties["mesh"].(map[string]interface{})["enum"] = Meshes
+	properties["builderPullPolicy"].(map[string]interface{})["enum"] = BuilderPullPolicies
+	if len(runtimes) > 0 {
+		properties["runtime"].(map[string]interface{})["enum"] = runtimes
+	}
+	return s
+}
+
+// schemaOf the given type.
+func schemaOf(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		// Times are serialized as RFC 3339 timestamps.
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		// Pointers may be serialized as null.
+		s := schemaOf(t.Elem())
+		if typ, ok := s["type"].(string); ok {
+			s["type"] = []string{typ, "null"}
+		}
+		return s
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		var additional interface{} = false
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("yaml")
+			name := strings.Split(tag, ",")[0]
+			if name == "-" || field.PkgPath != "" {
+				continue // not serialized
+			}
+			if tag == ",inline" && field.Type.Kind() == reflect.Map {
+				// The keys of an inline map are those of the object not
+				// otherwise its properties.
+				additional = schemaOf(field.Type.Elem())
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			properties[name] = schemaOf(field.Type)
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": additional,
+		}
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,50 @@
+// +build !integration
+
+package function
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+// TestSchema ensures the schema declares each field of the serialized
+// config, and restricts the runtime to those given.
+func TestSchema(t *testing.T) {
+	s := Schema("go", "node")
+	properties := s["properties"].(map[string]interface{})
+
+	bb, err := yaml.Marshal(toConfig(Function{Name: "myfunc", Health: Health{Liveness: &Probe{}}, Git: Git{URL: "https://example.com/myfunc.git"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var serialized map[string]interface{}
+	if err = yaml.Unmarshal(bb, &serialized); err != nil {
+		t.Fatal(err)
+	}
+	for key := range serialized {
+		if _, ok := properties[key]; !ok {
+			t.Errorf("expected the schema to declare '%v'", key)
+		}
+	}
+	for _, key := range []string{"specVersion", "options", "health", "envs", "volumes"} {
+		if _, ok := properties[key]; !ok {
+			t.Errorf("expected the schema to declare '%v'", key)
+		}
+	}
+
+	runtime := properties["runtime"].(map[string]interface{})
+	if !reflect.DeepEqual(runtime["enum"], []string{"go", "node"}) {
+		t.Errorf("expected the runtimes to be enumerated, got %v", runtime["enum"])
+	}
+
+	scale := properties["options"].(map[string]interface{})["properties"].(map[string]interface{})["scale"].(map[string]interface{})
+	min := scale["properties"].(map[string]interface{})["min"].(map[string]interface{})
+	if !reflect.DeepEqual(min["type"], []string{"integer", "null"}) {
+		t.Errorf("expected options.scale.min to be a nullable integer, got %v", min["type"])
+	}
+	if s["additionalProperties"] != false {
+		t.Error("expected additional properties to be invalid")
+	}
+}
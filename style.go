@@ -0,0 +1,50 @@
+package function
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/markbates/pkger"
+)
+
+// ErrStyleNotFound is returned when no style config is found for the runtime
+// of a Function.
+var ErrStyleNotFound = errors.New("style config not found")
+
+// styleDir is the hidden directory of the style config of each runtime, laid
+// out as [styleDir]/[runtime], both of the embedded templates and of a
+// repository of templates, which provides its own in place of those embedded.
+// The style config of a runtime is an .editorconfig and the config of the
+// formatter of the runtime, if any, such as a .prettierrc for Node.
+const styleDir = ".style"
+
+// writeStyle writes the style config of the runtime to dest: that of the
+// repository of the template, if it provides one, and otherwise that embedded.
+// Files which already exist, such as those of the template, are kept.
+func (t templateWriter) writeStyle(runtime, template, dest string) (err error) {
+	src, accessor, err := t.resolveStyle(runtime, template)
+	if err != nil {
+		return
+	}
+	keep := func(string, []byte, []byte) (bool, error) { return false, nil }
+	return write(src, dest, accessor, t.function, keep)
+}
+
+// resolveStyle of the runtime to its path, and the accessor of its files.
+func (t templateWriter) resolveStyle(runtime, template string) (string, fileAccessor, error) {
+	if isCustom(template) && t.templates != "" {
+		repo := strings.Split(template, "/")[0]
+		path := filepath.Join(t.templates, repo, styleDir, runtime)
+		if _, err := os.Stat(path); err == nil {
+			return path, filesystemAccessor{}, nil
+		}
+	}
+	path := filepath.Join("/templates", styleDir, runtime)
+	if _, err := pkger.Stat(path); err != nil {
+		return "", nil, fmt.Errorf("%w for the runtime '%v'", ErrStyleNotFound, runtime)
+	}
+	return path, embeddedAccessor{}, nil
+}
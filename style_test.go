@@ -0,0 +1,50 @@
+// +build !integration
+
+package function_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fn "github.com/boson-project/func"
+)
+
+// TestCreateStyle ensures the style config of the runtime of a Function is
+// written when requested, keeping files which already exist, and that it is
+// not written otherwise.
+func TestCreateStyle(t *testing.T) {
+	root := "testdata/example.com/testCreateStyle"
+	defer using(t, root)()
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, ".editorconfig"), []byte("root = true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client := fn.New(fn.WithRegistry(TestRegistry), fn.WithForce(true), fn.WithStyle(true))
+	if err := client.Create(fn.Function{Root: root, Runtime: "node"}); err != nil {
+		t.Fatal(err)
+	}
+	editorconfig, err := ioutil.ReadFile(filepath.Join(root, ".editorconfig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(editorconfig) != "root = true\n" {
+		t.Fatalf("expected the existing .editorconfig to be kept, got:\n%s", editorconfig)
+	}
+	if _, err = os.Stat(filepath.Join(root, ".prettierrc")); err != nil {
+		t.Fatalf("expected the .prettierrc to be written: %v", err)
+	}
+
+	plain := "testdata/example.com/testCreateStyleNone"
+	defer using(t, plain)()
+	if err := fn.New(fn.WithRegistry(TestRegistry)).Create(fn.Function{Root: plain, Runtime: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(plain, ".editorconfig")); !os.IsNotExist(err) {
+		t.Fatal("expected no .editorconfig to be written without the style")
+	}
+}
@@ -0,0 +1,497 @@
+package tekton
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	fn "github.com/boson-project/func"
+	"github.com/boson-project/func/buildpacks"
+	"github.com/boson-project/func/k8s"
+)
+
+const (
+	// DefaultTimeout of a build run on the cluster.
+	DefaultTimeout = 10 * time.Minute
+
+	// DefaultGitImage with which the Function's source is cloned.
+	DefaultGitImage = "docker.io/alpine/git:v2.30.2"
+
+	// DefaultArchiveImage with which a source archive is extracted.
+	DefaultArchiveImage = "docker.io/library/alpine:3.13"
+
+	// MaxArchiveSize of a source archive, which is uploaded to the cluster in
+	// parts of archivePartSize, each in a ConfigMap.
+	MaxArchiveSize = 16 * 1024 * 1024
+
+	// DefaultUploadAttempts of each part of a source archive, after which its
+	// upload is failed.
+	DefaultUploadAttempts = 5
+
+	// archivePartSize of the parts of a source archive, well within the size
+	// of a ConfigMap.
+	archivePartSize = 512 * 1024
+
+	// archiveKey of the part of the source archive in its ConfigMap.
+	archiveKey = "source.tar.gz"
+
+	// pollInterval between checks of the status of a PipelineRun.
+	pollInterval = 2 * time.Second
+)
+
+// uploadBackoff before the first retry of the upload of a part of a source
+// archive, doubled before each retry thereafter.
+var uploadBackoff = time.Second
+
+// ErrUploadFailed is returned when a part of a source archive fails to be
+// uploaded in any of its attempts.
+var ErrUploadFailed = errors.New("failed to upload the source archive")
+
+// PipelineRuns is the resource of Tekton PipelineRuns.
+var PipelineRuns = schema.GroupVersionResource{Group: "tekton.dev", Version: "v1beta1", Resource: "pipelineruns"}
+
+// ConfigMaps is the resource of ConfigMaps, in which source archives are
+// uploaded.
+var ConfigMaps = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// Secrets is the resource of Secrets, in which the build envs of a run are
+// provided to its build.
+var Secrets = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+// PipelinesProvider builds Functions on the cluster using Tekton: a
+// PipelineRun clones the Function's git repository and builds it with the
+// Function's buildpacks builder, pushing the image to the registry.  The
+// image is pushed with the credentials of the ServiceAccount of the
+// PipelineRun, which is that of the Function if set.
+type PipelinesProvider struct {
+	Namespace string
+	Verbose   bool
+	// Timeout of the PipelineRun, after which it is failed.
+	Timeout time.Duration
+	// Output to which the progress of verbose runs is written (defaults to
+	// stdout).
+	Output io.Writer
+	// DynamicClient factory, defaulting to NewDynamicClient.
+	DynamicClient func(namespace string) (dynamic.Interface, error)
+	// UploadAttempts of each part of a source archive, defaulting to
+	// DefaultUploadAttempts.
+	UploadAttempts int
+	// Progress, if set, is called with the number of parts of a source
+	// archive uploaded, of the total, as each is uploaded.
+	Progress func(uploaded, total int)
+}
+
+func NewPipelinesProvider(namespaceOverride string) (provider *PipelinesProvider, err error) {
+	provider = &PipelinesProvider{Timeout: DefaultTimeout}
+	namespace, err := k8s.GetNamespace(namespaceOverride)
+	if err != nil {
+		return
+	}
+
+	provider.Namespace = namespace
+	return
+}
+
+// NewDynamicClient returns a dynamic client of the cluster, with which
+// Tekton resources are managed without depending on the Tekton clients.
+func NewDynamicClient(namespace string) (dynamic.Interface, error) {
+	restConfig, err := k8s.GetClientConfig().ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new dynamic client: %v", err)
+	}
+	return dynamic.NewForConfig(restConfig)
+}
+
+// Run the build of the Function on the cluster, creating a PipelineRun and
+// waiting until it completes, fails or times out.
+func (p *PipelinesProvider) Run(ctx context.Context, f fn.Function) (err error) {
+	newClient := p.DynamicClient
+	if newClient == nil {
+		newClient = NewDynamicClient
+	}
+	client, err := newClient(p.Namespace)
+	if err != nil {
+		return
+	}
+
+	envs, err := p.createBuildEnvs(ctx, client, f)
+	if err != nil {
+		return
+	}
+	defer p.deleteBuildEnvs(client, envs)
+
+	run, err := generatePipelineRun(f, p.Timeout, envs)
+	if err != nil {
+		return
+	}
+	return p.run(ctx, client, run)
+}
+
+// RunArchive builds the Function on the cluster from the gzipped tarball of
+// its source at archive.  The archive is uploaded in parts, each in a
+// ConfigMap, from which the PipelineRun extracts it, and which are deleted
+// once the run is done.  The upload of a part which fails is retried, and
+// the parts of an upload which fails are kept, such that uploading the same
+// archive again resumes it.
+func (p *PipelinesProvider) RunArchive(ctx context.Context, f fn.Function, archive string) (err error) {
+	bb, err := ioutil.ReadFile(archive)
+	if err != nil {
+		return
+	}
+	if len(bb) > MaxArchiveSize {
+		return fmt.Errorf("source archive '%v' is %v bytes, exceeding the maximum of %v which can be uploaded to the cluster", archive, len(bb), MaxArchiveSize)
+	}
+
+	newClient := p.DynamicClient
+	if newClient == nil {
+		newClient = NewDynamicClient
+	}
+	client, err := newClient(p.Namespace)
+	if err != nil {
+		return
+	}
+
+	configMaps := client.Resource(ConfigMaps).Namespace(p.Namespace)
+	parts, err := p.upload(ctx, configMaps, f, bb)
+	if err != nil {
+		return
+	}
+	defer func() {
+		for _, part := range parts {
+			_ = configMaps.Delete(context.Background(), part, metav1.DeleteOptions{})
+		}
+	}()
+	if p.Verbose {
+		fmt.Fprintf(p.output(), "Uploaded source archive: %v\n", strings.Join(parts, ", "))
+	}
+
+	envs, err := p.createBuildEnvs(ctx, client, f)
+	if err != nil {
+		return
+	}
+	defer p.deleteBuildEnvs(client, envs)
+
+	run, err := generateArchivePipelineRun(f, p.Timeout, parts, envs)
+	if err != nil {
+		return
+	}
+	return p.run(ctx, client, run)
+}
+
+// upload the archive in parts, each in a ConfigMap, returning their names.
+// The parts are named by the digest of the archive, such that those already
+// uploaded, such as by an upload which failed part way, are not uploaded
+// again.  The upload of each part is retried, with backoff, until it has
+// failed in each of its attempts or the context is done.  Errors which
+// retrying would not resolve, such as of permissions, are not retried.
+func (p *PipelinesProvider) upload(ctx context.Context, configMaps dynamic.ResourceInterface, f fn.Function, archive []byte) (parts []string, err error) {
+	attempts := p.UploadAttempts
+	if attempts <= 0 {
+		attempts = DefaultUploadAttempts
+	}
+	digest := fmt.Sprintf("%x", sha256.Sum256(archive))[:12]
+	total := (len(archive) + archivePartSize - 1) / archivePartSize
+	for i := 0; i < total; i++ {
+		end := (i + 1) * archivePartSize
+		if end > len(archive) {
+			end = len(archive)
+		}
+		part := generateArchiveConfigMap(f, fmt.Sprintf("%v-source-%v-%v", f.Name, digest, i), archive[i*archivePartSize:end])
+
+		backoff := uploadBackoff
+		for attempt := 1; ; attempt++ {
+			_, err = configMaps.Create(ctx, part, metav1.CreateOptions{})
+			if err == nil || apierrors.IsAlreadyExists(err) {
+				break
+			}
+			if apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err) || apierrors.IsInvalid(err) {
+				return parts, fmt.Errorf("%w: %v", ErrUploadFailed, err)
+			}
+			if attempt == attempts {
+				return parts, fmt.Errorf("%w: part %v of %v failed in %v attempts, the last with: %v. Deploy again to resume the upload", ErrUploadFailed, i+1, total, attempts, err)
+			}
+			select {
+			case <-ctx.Done():
+				return parts, fmt.Errorf("%w: part %v of %v did not complete in %v attempts: %v", ErrUploadFailed, i+1, total, attempt, ctx.Err())
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		parts = append(parts, part.GetName())
+		if p.Progress != nil {
+			p.Progress(i+1, total)
+		}
+	}
+	return parts, nil
+}
+
+// createBuildEnvs creates the Secret of the build envs of the Function, as
+// resolved locally, returning its name, or none if it has no build envs.
+// The values of the envs, which may be secrets themselves, are thus not
+// written into the PipelineRun.
+func (p *PipelinesProvider) createBuildEnvs(ctx context.Context, client dynamic.Interface, f fn.Function) (string, error) {
+	envs, err := buildpacks.BuildEnvs(f.BuildEnvs)
+	if err != nil || len(envs) == 0 {
+		return "", err
+	}
+	created, err := client.Resource(Secrets).Namespace(p.Namespace).Create(ctx, generateBuildEnvsSecret(f, envs), metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("tekton pipelines provider failed to create the Secret of the build envs: %v", err)
+	}
+	return created.GetName(), nil
+}
+
+// deleteBuildEnvs deletes the named Secret of build envs, if any, once the
+// run is done.
+func (p *PipelinesProvider) deleteBuildEnvs(client dynamic.Interface, name string) {
+	if name == "" {
+		return
+	}
+	_ = client.Resource(Secrets).Namespace(p.Namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+}
+
+// run the PipelineRun, waiting until it completes, fails or times out.
+func (p *PipelinesProvider) run(ctx context.Context, client dynamic.Interface, run *unstructured.Unstructured) error {
+	runs := client.Resource(PipelineRuns).Namespace(p.Namespace)
+	created, err := runs.Create(ctx, run, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("tekton pipelines provider failed to create the PipelineRun: %v", err)
+	}
+	if p.Verbose {
+		fmt.Fprintf(p.output(), "Created PipelineRun: %v\n", created.GetName())
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return waitForPipelineRun(ctx, runs, created.GetName(), pollInterval)
+}
+
+func (p *PipelinesProvider) output() io.Writer {
+	if p.Output == nil {
+		return os.Stdout
+	}
+	return p.Output
+}
+
+// generatePipelineRun returns the PipelineRun which builds the Function: a
+// single Task whose steps clone its repository into the shared workspace
+// and build it with the buildpacks lifecycle of its builder, with the build
+// envs of the named Secret, if any.
+func generatePipelineRun(f fn.Function, timeout time.Duration, envs string) (*unstructured.Unstructured, error) {
+	if f.Git.URL == "" {
+		return nil, fn.ErrGitRequired
+	}
+	clone := []interface{}{"clone", "--depth", "1"}
+	if f.Git.Revision != "" {
+		clone = append(clone, "--branch", f.Git.Revision)
+	}
+	clone = append(clone, f.Git.URL, "/workspace/source")
+
+	return newPipelineRun(f, timeout, map[string]interface{}{
+		"name":  "clone",
+		"image": DefaultGitImage,
+		"args":  clone,
+	}, nil, envs)
+}
+
+// generateArchivePipelineRun returns the PipelineRun which builds the
+// Function as does generatePipelineRun, but from the source archive in the
+// parts of the named ConfigMaps, in order, which are mounted together and
+// extracted into the shared workspace.
+func generateArchivePipelineRun(f fn.Function, timeout time.Duration, configMaps []string, envs string) (*unstructured.Unstructured, error) {
+	sources := make([]interface{}, len(configMaps))
+	for i, name := range configMaps {
+		sources[i] = map[string]interface{}{"configMap": map[string]interface{}{
+			"name":  name,
+			"items": []interface{}{map[string]interface{}{"key": archiveKey, "path": fmt.Sprintf("part-%04d", i)}},
+		}}
+	}
+	return newPipelineRun(f, timeout, map[string]interface{}{
+		"name":    "extract",
+		"image":   DefaultArchiveImage,
+		"command": []interface{}{"sh", "-c"},
+		"args":    []interface{}{"cat /archive/part-* | tar -xzf - -C /workspace/source"},
+		"volumeMounts": []interface{}{
+			map[string]interface{}{"name": "archive", "mountPath": "/archive", "readOnly": true},
+		},
+	}, []interface{}{
+		map[string]interface{}{"name": "archive", "projected": map[string]interface{}{"sources": sources}},
+	}, envs)
+}
+
+// generateArchiveConfigMap returns the named ConfigMap in which a part of the
+// source archive of the Function is uploaded.
+func generateArchiveConfigMap(f fn.Function, name string, archive []byte) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name": name,
+			"labels": map[string]interface{}{
+				"boson.dev/function": f.Name,
+			},
+		},
+		"binaryData": map[string]interface{}{
+			archiveKey: base64.StdEncoding.EncodeToString(archive),
+		},
+	}}
+}
+
+// generateBuildEnvsSecret returns the Secret of the given build envs of the
+// Function, named after it, with which its build is run.
+func generateBuildEnvsSecret(f fn.Function, envs map[string]string) *unstructured.Unstructured {
+	data := make(map[string]interface{}, len(envs))
+	for name, value := range envs {
+		data[name] = value
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"generateName": f.Name + "-build-envs-",
+			"labels": map[string]interface{}{
+				"boson.dev/function": f.Name,
+			},
+		},
+		"type":       "Opaque",
+		"stringData": data,
+	}}
+}
+
+// newPipelineRun returns the PipelineRun whose single Task fetches the
+// source of the Function into /workspace/source with the given step, and
+// builds it with the buildpacks lifecycle of its builder, with the build
+// envs of the named Secret, if any.  Volumes, if any, are those of the Task,
+// such as mounted by the fetch step.
+func newPipelineRun(f fn.Function, timeout time.Duration, fetch map[string]interface{}, volumes []interface{}, envs string) (*unstructured.Unstructured, error) {
+	image, err := f.ImageName()
+	if err != nil {
+		return nil, err
+	}
+	if f.Builder == fn.DockerfileBuilder {
+		return nil, fmt.Errorf("function '%v' is built from its Dockerfile, which is not supported by remote builds. Build it locally", f.Name)
+	}
+	if len(f.Build.Buildpacks) > 0 {
+		return nil, fmt.Errorf("the buildpacks %v of function '%v' are not supported by remote builds, which run those of its builder. Build it locally", strings.Join(f.Build.Buildpacks, ", "), f.Name)
+	}
+	builder := f.Builder
+	if b, ok := f.BuilderMap[builder]; ok {
+		builder = b
+	}
+	if builder == "" {
+		return nil, fmt.Errorf("function '%v' has no builder with which to build it", f.Name)
+	}
+	if builder, err = buildpacks.Pinned(builder, f.BuilderDigest); err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	build := map[string]interface{}{
+		"name":    "build",
+		"image":   builder,
+		"command": []interface{}{"/cnb/lifecycle/creator"},
+		"args":    []interface{}{"-app=/workspace/source", image},
+	}
+	if envs != "" {
+		build["envFrom"] = []interface{}{
+			map[string]interface{}{"secretRef": map[string]interface{}{"name": envs}},
+		}
+	}
+
+	task := map[string]interface{}{
+		"steps": []interface{}{fetch, build},
+	}
+	if len(volumes) > 0 {
+		task["volumes"] = volumes
+	}
+
+	spec := map[string]interface{}{
+		"timeout": timeout.String(),
+		"pipelineSpec": map[string]interface{}{
+			"tasks": []interface{}{
+				map[string]interface{}{
+					"name":     "build",
+					"taskSpec": task,
+				},
+			},
+		},
+	}
+	if f.ServiceAccount != "" {
+		spec["serviceAccountName"] = f.ServiceAccount
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": PipelineRuns.GroupVersion().String(),
+		"kind":       "PipelineRun",
+		"metadata": map[string]interface{}{
+			"generateName": f.Name + "-build-",
+			"labels": map[string]interface{}{
+				"boson.dev/function": f.Name,
+			},
+		},
+		"spec": spec,
+	}}, nil
+}
+
+// waitForPipelineRun polls the status of the named PipelineRun until it
+// succeeds, fails, or the context is done.
+func waitForPipelineRun(ctx context.Context, runs dynamic.ResourceInterface, name string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		run, err := runs.Get(ctx, name, metav1.GetOptions{})
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("tekton pipelines provider failed to get the PipelineRun '%v': %v", name, err)
+		}
+		if err == nil {
+			status, reason, message := succeeded(run)
+			switch status {
+			case "True":
+				return nil
+			case "False":
+				return fmt.Errorf("PipelineRun '%v' failed: %v: %v", name, reason, message)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("PipelineRun '%v' did not complete: %v", name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// succeeded returns the status, reason and message of the Succeeded
+// condition of the PipelineRun, the status being empty if it has none.
+func succeeded(run *unstructured.Unstructured) (status, reason, message string) {
+	conditions, _, _ := unstructured.NestedSlice(run.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Succeeded" {
+			continue
+		}
+		status, _ = condition["status"].(string)
+		reason, _ = condition["reason"].(string)
+		message, _ = condition["message"].(string)
+		return
+	}
+	return
+}
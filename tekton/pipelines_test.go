@@ -0,0 +1,267 @@
+// +build !integration
+
+package tekton
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	fn "github.com/boson-project/func"
+)
+
+// Test_generatePipelineRun ensures the PipelineRun clones the Function's
+// repository and builds its image with its builder, and with its build envs
+// by reference to their Secret.
+func Test_generatePipelineRun(t *testing.T) {
+	name, value := "BP_GO_VERSION", "1.16"
+	f := fn.Function{
+		Name:           "myfunc",
+		Registry:       "quay.io/alice",
+		Builder:        "default",
+		BuilderMap:     map[string]string{"default": "quay.io/boson/faas-go-builder"},
+		BuildEnvs:      fn.Envs{{Name: &name, Value: &value}},
+		ServiceAccount: "builder",
+		Git:            fn.Git{URL: "https://github.com/alice/myfunc.git", Revision: "main"},
+	}
+	run, err := generatePipelineRun(f, time.Minute, "myfunc-build-envs-abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sa, _, _ := unstructured.NestedString(run.Object, "spec", "serviceAccountName"); sa != "builder" {
+		t.Fatalf("expected the ServiceAccount 'builder', got '%v'", sa)
+	}
+	if timeout, _, _ := unstructured.NestedString(run.Object, "spec", "timeout"); timeout != "1m0s" {
+		t.Fatalf("expected the timeout '1m0s', got '%v'", timeout)
+	}
+	tasks, _, _ := unstructured.NestedSlice(run.Object, "spec", "pipelineSpec", "tasks")
+	if len(tasks) != 1 {
+		t.Fatalf("expected a single task, got %v", tasks)
+	}
+	steps, _, _ := unstructured.NestedSlice(tasks[0].(map[string]interface{}), "taskSpec", "steps")
+	if len(steps) != 2 {
+		t.Fatalf("expected clone and build steps, got %v", steps)
+	}
+	clone, build := steps[0].(map[string]interface{}), steps[1].(map[string]interface{})
+	cloneArgs, _, _ := unstructured.NestedStringSlice(clone, "args")
+	if got := strings.Join(cloneArgs, " "); got != "clone --depth 1 --branch main https://github.com/alice/myfunc.git /workspace/source" {
+		t.Fatalf("unexpected clone args: %v", got)
+	}
+	if build["image"] != "quay.io/boson/faas-go-builder" {
+		t.Fatalf("expected the builder image, got %v", build["image"])
+	}
+	buildArgs, _, _ := unstructured.NestedStringSlice(build, "args")
+	if got := strings.Join(buildArgs, " "); got != "-app=/workspace/source quay.io/alice/myfunc:latest" {
+		t.Fatalf("unexpected build args: %v", got)
+	}
+	if _, ok := build["env"]; ok {
+		t.Fatalf("expected no build env to be written into the PipelineRun, got %v", build["env"])
+	}
+	envFrom, _, _ := unstructured.NestedSlice(build, "envFrom")
+	if len(envFrom) != 1 {
+		t.Fatalf("expected the build envs of their Secret, got %v", envFrom)
+	}
+	if secret, _, _ := unstructured.NestedString(envFrom[0].(map[string]interface{}), "secretRef", "name"); secret != "myfunc-build-envs-abc" {
+		t.Fatalf("expected the Secret 'myfunc-build-envs-abc', got '%v'", secret)
+	}
+
+	if _, err = generatePipelineRun(fn.Function{Name: "myfunc", Registry: "quay.io/alice"}, 0, ""); err != fn.ErrGitRequired {
+		t.Fatalf("expected ErrGitRequired without a git repository, got %v", err)
+	}
+
+	f.Build.Buildpacks = []string{"gcr.io/paketo-buildpacks/datadog"}
+	if _, err = generatePipelineRun(f, 0, ""); err == nil || !strings.Contains(err.Error(), "not supported by remote builds") {
+		t.Fatalf("expected the buildpacks to be refused, got %v", err)
+	}
+}
+
+// Test_waitForPipelineRun ensures the status of the PipelineRun is reported
+// once it succeeds or fails.
+func Test_waitForPipelineRun(t *testing.T) {
+	run := func(name, status string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "tekton.dev/v1beta1",
+			"kind":       "PipelineRun",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "test"},
+			"status": map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Succeeded", "status": status, "reason": "Failed", "message": "build step failed"},
+			}},
+		}}
+	}
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), run("succeeded", "True"), run("failed", "False"), run("running", "Unknown"))
+	runs := client.Resource(PipelineRuns).Namespace("test")
+
+	if err := waitForPipelineRun(context.Background(), runs, "succeeded", time.Millisecond); err != nil {
+		t.Fatalf("expected the succeeded run not to be an error, got %v", err)
+	}
+	if err := waitForPipelineRun(context.Background(), runs, "failed", time.Millisecond); err == nil || !strings.Contains(err.Error(), "build step failed") {
+		t.Fatalf("expected the failure to be reported, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := waitForPipelineRun(ctx, runs, "running", time.Millisecond); err == nil {
+		t.Fatal("expected a run which does not complete to time out")
+	}
+}
+
+// Test_generateArchivePipelineRun ensures the PipelineRun of a source archive
+// extracts it from the parts of its ConfigMaps, in order, rather than cloning
+// a repository.
+func Test_generateArchivePipelineRun(t *testing.T) {
+	f := fn.Function{
+		Name:       "myfunc",
+		Registry:   "quay.io/alice",
+		Builder:    "default",
+		BuilderMap: map[string]string{"default": "quay.io/boson/faas-go-builder"},
+	}
+	run, err := generateArchivePipelineRun(f, time.Minute, []string{"myfunc-source-abc-0", "myfunc-source-abc-1"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, _, _ := unstructured.NestedSlice(run.Object, "spec", "pipelineSpec", "tasks")
+	task := tasks[0].(map[string]interface{})
+	volumes, _, _ := unstructured.NestedSlice(task, "taskSpec", "volumes")
+	if len(volumes) != 1 {
+		t.Fatalf("expected the archive volume, got %v", volumes)
+	}
+	sources, _, _ := unstructured.NestedSlice(volumes[0].(map[string]interface{}), "projected", "sources")
+	if len(sources) != 2 {
+		t.Fatalf("expected the ConfigMap of each part to be mounted, got %v", sources)
+	}
+	if name, _, _ := unstructured.NestedString(sources[1].(map[string]interface{}), "configMap", "name"); name != "myfunc-source-abc-1" {
+		t.Fatalf("expected the ConfigMap of the second part to be mounted second, got '%v'", name)
+	}
+	steps, _, _ := unstructured.NestedSlice(task, "taskSpec", "steps")
+	extract := steps[0].(map[string]interface{})
+	args, _, _ := unstructured.NestedStringSlice(extract, "args")
+	if got := strings.Join(args, " "); got != "cat /archive/part-* | tar -xzf - -C /workspace/source" {
+		t.Fatalf("unexpected extract args: %v", got)
+	}
+}
+
+// Test_createBuildEnvs ensures the build envs of the Function, as resolved
+// locally, are created in a Secret, and that none is created without any.
+func Test_createBuildEnvs(t *testing.T) {
+	defer os.Setenv("FUNC_TEST_TOKEN", os.Getenv("FUNC_TEST_TOKEN"))
+	os.Setenv("FUNC_TEST_TOKEN", "hunter2")
+	name, value := "TOKEN", "{{ env:FUNC_TEST_TOKEN }}"
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	p := &PipelinesProvider{Namespace: "test"}
+	if _, err := p.createBuildEnvs(context.Background(), client, fn.Function{Name: "myfunc", BuildEnvs: fn.Envs{{Name: &name, Value: &value}}}); err != nil {
+		t.Fatal(err)
+	}
+	actions := client.Actions()
+	if len(actions) != 1 || actions[0].GetResource() != Secrets {
+		t.Fatalf("expected the Secret of the build envs to be created, got %v", actions)
+	}
+	secret := actions[0].(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+	if token, _, _ := unstructured.NestedString(secret.Object, "stringData", "TOKEN"); token != "hunter2" {
+		t.Fatalf("expected the build env resolved locally, got '%v'", token)
+	}
+
+	client = fake.NewSimpleDynamicClient(runtime.NewScheme())
+	if secret, err := p.createBuildEnvs(context.Background(), client, fn.Function{Name: "myfunc"}); err != nil || secret != "" || len(client.Actions()) != 0 {
+		t.Fatalf("expected no Secret without build envs, got '%v' (%v)", secret, err)
+	}
+}
+
+// TestRunArchive ensures an archive exceeding the size of a ConfigMap is
+// rejected before anything is created on the cluster.
+func TestRunArchive(t *testing.T) {
+	file, err := ioutil.TempFile("", "func-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	if _, err = file.Write(make([]byte, MaxArchiveSize+1)); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	p := &PipelinesProvider{
+		Namespace:     "test",
+		DynamicClient: func(string) (dynamic.Interface, error) { return client, nil },
+	}
+	if err = p.RunArchive(context.Background(), fn.Function{Name: "myfunc"}, file.Name()); err == nil || !strings.Contains(err.Error(), "exceeding") {
+		t.Fatalf("expected an oversized archive to be rejected, got %v", err)
+	}
+	if len(client.Actions()) != 0 {
+		t.Fatalf("expected nothing to be created on the cluster, got %v", client.Actions())
+	}
+}
+
+// Test_upload ensures an archive is uploaded in parts, that a part which
+// fails is retried until uploaded, that parts already uploaded are not
+// uploaded again, and that a part failing in each attempt fails the upload
+// with the number of attempts.
+func Test_upload(t *testing.T) {
+	defer func(backoff time.Duration) { uploadBackoff = backoff }(uploadBackoff)
+	uploadBackoff = 0
+
+	archive := make([]byte, archivePartSize*2+1)
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	configMaps := client.Resource(ConfigMaps).Namespace("test")
+
+	failures := 2
+	client.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failures > 0 {
+			failures--
+			return true, nil, errors.New("connection reset by peer")
+		}
+		return false, nil, nil
+	})
+	var progress []int
+	p := &PipelinesProvider{Namespace: "test", Progress: func(uploaded, total int) {
+		if total != 3 {
+			t.Fatalf("expected 3 parts, got %v", total)
+		}
+		progress = append(progress, uploaded)
+	}}
+	parts, err := p.upload(context.Background(), configMaps, fn.Function{Name: "myfunc"}, archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 3 || len(progress) != 3 || progress[2] != 3 {
+		t.Fatalf("expected 3 parts to be uploaded and reported, got %v (%v)", parts, progress)
+	}
+
+	// Uploaded again, as on resuming, the parts uploaded are kept.
+	resumed, err := p.upload(context.Background(), configMaps, fn.Function{Name: "myfunc"}, archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(resumed, ",") != strings.Join(parts, ",") {
+		t.Fatalf("expected the parts %v to be resumed, got %v", parts, resumed)
+	}
+
+	failures = 10
+	p.UploadAttempts = 3
+	if _, err = p.upload(context.Background(), configMaps, fn.Function{Name: "other"}, archive); !errors.Is(err, ErrUploadFailed) ||
+		!strings.Contains(err.Error(), "part 1 of 3 failed in 3 attempts") {
+		t.Fatalf("expected the upload to fail after 3 attempts, got %v", err)
+	}
+	if failures != 7 {
+		t.Fatalf("expected 3 attempts, got %v", 10-failures)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	uploadBackoff = time.Hour
+	if _, err = p.upload(ctx, configMaps, fn.Function{Name: "other"}, archive); err == nil || !strings.Contains(err.Error(), "did not complete") {
+		t.Fatalf("expected the upload to end with the context, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package function
+
+import (
+	"time"
+)
+
+// TelemetryFileEnv is the environment variable naming the file to which the
+// func CLI appends a TelemetryEvent for each command, as a line of JSON.
+// Telemetry is disabled unless it is set.
+const TelemetryFileEnv = "FUNC_TELEMETRY_FILE"
+
+// Telemetry records the timing and outcome of operations, such as for
+// dashboards of their use.  It is opt-in: a client records no events unless
+// provided one with WithTelemetry.  Embedders provide their own sink, such as
+// OpenTelemetry or statsd.
+//
+// Events never include the source, config values, names, images, URLs,
+// paths, error messages or secrets of Functions: only the operation, its
+// duration and success, the runtime, and counts of the Function's settings.
+type Telemetry interface {
+	// Record the event of an operation having completed.  Called
+	// synchronously, so should not block.
+	Record(TelemetryEvent)
+}
+
+// TelemetryFunc is a function which implements Telemetry.
+type TelemetryFunc func(TelemetryEvent)
+
+// Record the event by calling the function.
+func (f TelemetryFunc) Record(e TelemetryEvent) {
+	f(e)
+}
+
+// TelemetryEvent describes an operation having completed, anonymously.
+type TelemetryEvent struct {
+	// Operation completed, such as "build" or "deploy" for those of the
+	// client, or the command, such as "func deploy", for the CLI.
+	Operation string `json:"operation"`
+	// Duration of the operation.
+	Duration time.Duration `json:"duration"`
+	// Success of the operation.
+	Success bool `json:"success"`
+	// Runtime of the Function, if known.
+	Runtime string `json:"runtime,omitempty"`
+	// Counts of the settings of the Function, by setting, such as "envs",
+	// without their values.
+	Counts map[string]int `json:"counts,omitempty"`
+}
+
+// NewTelemetryEvent returns the event of the operation on the Function having
+// completed since start, with the given error, if any.  Only the runtime and
+// the counts of the settings of the Function are recorded.
+func NewTelemetryEvent(operation string, f Function, start time.Time, err error) TelemetryEvent {
+	e := TelemetryEvent{
+		Operation: operation,
+		Duration:  time.Since(start),
+		Success:   err == nil,
+		Runtime:   f.Runtime,
+	}
+	counts := map[string]int{
+		"envs":         len(f.Envs),
+		"buildEnvs":    len(f.BuildEnvs),
+		"volumes":      len(f.Volumes),
+		"annotations":  len(f.Annotations),
+		"environments": len(f.Environments),
+	}
+	for setting, n := range counts {
+		if n > 0 {
+			if e.Counts == nil {
+				e.Counts = map[string]int{}
+			}
+			e.Counts[setting] = n
+		}
+	}
+	return e
+}
+
+// record the event of the operation on the Function with the client's
+// telemetry, if any.  Operations planned are not recorded.
+func (c *Client) record(operation string, f Function, start time.Time, err error) {
+	if c.telemetry == nil || c.plan != nil {
+		return
+	}
+	c.telemetry.Record(NewTelemetryEvent(operation, f, start, err))
+}
@@ -6,14 +6,21 @@ package function
 //go:generate pkger
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"text/template"
 
 	"github.com/markbates/pkger"
+	"gopkg.in/yaml.v2"
 )
 
 // fileAccessor encapsulates methods for accessing template files.
@@ -52,7 +59,22 @@ type templateWriter struct {
 	// Ie. "Using the custom templates in the func configuration directory,
 	//    write the Boson HTTP template for the Go runtime."
 	templates string
-	verbose   bool
+	// fetched is the directory of the template fetched from its URL, if the
+	// template is given as one.  See TemplateURL.
+	fetched string
+	verbose bool
+	// function with which files declared by the template's manifest are
+	// rendered.  See ManifestFile.
+	function Function
+	// onConflict resolves the files of the template which already exist at
+	// the destination.  If nil, they are overwritten.
+	onConflict ConflictResolver
+	// managed writes only the files declared as managed by the template's
+	// manifest, overwriting them, and no others.  See writeManaged.
+	managed bool
+	// version of func writing the template, to which the dev container of
+	// the Function pins the func it installs.  See WithVersion.
+	version string
 }
 
 var (
@@ -61,45 +83,417 @@ var (
 	ErrRuntimeNotFound           = errors.New("runtime not found")
 	ErrTemplateNotFound          = errors.New("template not found")
 	ErrTemplateMissingRepository = errors.New("template name missing repository prefix")
+	ErrTemplateNotManaged        = errors.New("template declares no managed files")
+	ErrTemplateIncludeCycle      = errors.New("template includes itself")
+	ErrEntrypointNotFound        = errors.New("entrypoint not found")
 )
 
-func (t templateWriter) Write(runtime, template, dest string) error {
+// TemplateError is a failure to resolve the template of a Function.  It
+// matches the sentinel error describing the failure, such as
+// ErrTemplateNotFound, using errors.Is, and wraps the underlying cause, if
+// any, for debugging.
+type TemplateError struct {
+	// Err is the sentinel error describing the failure.
+	Err error
+
+	// Runtime, Template and Repository being resolved.  Repository is empty
+	// for embedded templates.
+	Runtime    string
+	Template   string
+	Repository string
+
+	// Cause of the failure, if any.
+	Cause error
+}
+
+func (e *TemplateError) Error() string {
+	switch e.Err {
+	case ErrRuntimeNotFound:
+		if e.Repository != "" {
+			return fmt.Sprintf("%v: '%v' in repository '%v'", e.Err, e.Runtime, e.Repository)
+		}
+		return fmt.Sprintf("%v: '%v'", e.Err, e.Runtime)
+	case ErrTemplateNotFound:
+		return fmt.Sprintf("%v: '%v' for runtime '%v'", e.Err, e.Template, e.Runtime)
+	case ErrRepositoryNotFound:
+		return fmt.Sprintf("%v: '%v'", e.Err, e.Repository)
+	default:
+		return fmt.Sprintf("%v: '%v'", e.Err, e.Template)
+	}
+}
+
+// Is the target the sentinel error describing the failure.
+func (e *TemplateError) Is(target error) bool {
+	return target == e.Err
+}
+
+// Unwrap returns the underlying cause of the failure.
+func (e *TemplateError) Unwrap() error {
+	return e.Cause
+}
+
+func (t templateWriter) Write(runtime, template, dest string) (err error) {
 	if template == "" {
 		template = DefaultTemplate
 	}
 
+	src, accessor, err := t.resolve(runtime, template)
+	if err != nil {
+		return
+	}
+
+	// A template which includes others is first composed with them in a
+	// working directory, from which it is then written.
+	m, err := manifestOf(src, accessor)
+	if err != nil {
+		return
+	}
+	if len(m.Includes) > 0 {
+		work, err := ioutil.TempDir("", "func-template")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(work)
+		if _, err = t.compose(runtime, template, work, nil); err != nil {
+			return err
+		}
+		src, accessor = work, filesystemAccessor{}
+	}
+
+	if t.managed {
+		return writeManaged(src, dest, accessor, t.function)
+	}
+	if err = write(src, dest, accessor, t.function, t.onConflict); err != nil {
+		return
+	}
+	return writeEntrypoint(m, dest, t.function.Entrypoint)
+}
+
+// entrypoint returns the handler of the template of the runtime selected as
+// the entrypoint of the Function: that of the given name, or by default the
+// first the template declares.  None for templates which declare no
+// handlers.  A name the template does not declare is ErrEntrypointNotFound.
+func (t templateWriter) entrypoint(runtime, template, name string) (string, error) {
+	if template == "" {
+		template = DefaultTemplate
+	}
+	src, accessor, err := t.resolve(runtime, template)
+	if err != nil {
+		return "", err
+	}
+	m, err := manifestOf(src, accessor)
+	if err != nil {
+		return "", err
+	}
+	if name == "" && len(m.Handlers) > 0 {
+		return m.Handlers[0].Name, nil
+	}
+	if name == "" {
+		return "", nil
+	}
+	names := make([]string, len(m.Handlers))
+	for i, h := range m.Handlers {
+		if h.Name == name {
+			return name, nil
+		}
+		names[i] = h.Name
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("%w: '%v' of template '%v', which declares no handlers", ErrEntrypointNotFound, name, template)
+	}
+	return "", fmt.Errorf("%w: '%v' of template '%v', which declares %v", ErrEntrypointNotFound, name, template, strings.Join(names, ", "))
+}
+
+// writeEntrypoint moves the handler of the template of the given name, as
+// written to dest, to the entrypoint of the template's manifest, if it
+// declares one, and removes the others, with the directories they leave
+// empty.  Handlers not written, such as those skipped on conflict, are
+// ignored.
+func writeEntrypoint(m manifest, dest, name string) error {
+	for _, h := range m.Handlers {
+		handler := filepath.Join(dest, filepath.FromSlash(strings.TrimSuffix(h.File, TemplateSuffix)))
+		if _, err := os.Stat(handler); os.IsNotExist(err) {
+			continue
+		}
+		if h.Name != name {
+			if err := os.Remove(handler); err != nil {
+				return err
+			}
+			removeEmptyDirs(filepath.Dir(handler), dest)
+			continue
+		}
+		if m.Entrypoint == "" || path.Clean(m.Entrypoint) == path.Clean(h.File) {
+			continue
+		}
+		entrypoint := filepath.Join(dest, filepath.FromSlash(m.Entrypoint))
+		if err := os.MkdirAll(filepath.Dir(entrypoint), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(handler, entrypoint); err != nil {
+			return err
+		}
+		removeEmptyDirs(filepath.Dir(handler), dest)
+	}
+	return nil
+}
+
+// removeEmptyDirs removes dir, and those containing it up to but not
+// including root, while they are empty.
+func removeEmptyDirs(dir, root string) {
+	for dir != root && strings.HasPrefix(dir, root) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// resolve the template of the runtime to its path, and the accessor of its
+// files: those fetched for a template URL, those of a repository for a
+// template of the form [repo]/[template], and otherwise those embedded.
+func (t templateWriter) resolve(runtime, template string) (string, fileAccessor, error) {
+	if IsTemplateURL(template) {
+		return t.fetched, filesystemAccessor{}, nil
+	}
 	if isCustom(template) {
-		return writeCustom(t.templates, runtime, template, dest)
+		path, err := resolveCustom(t.templates, runtime, template)
+		return path, filesystemAccessor{}, err
+	}
+	path, err := resolveEmbedded(runtime, template)
+	return path, embeddedAccessor{}, err
+}
+
+// compose the template of the runtime with the templates it includes, if
+// any, in the directory work: each of those it includes first, in order and
+// themselves composed, and then its own files, such that later files
+// override earlier ones.  The manifest written, and returned, is that of the
+// template with the render and managed globs of those it includes.  The
+// chain is of the templates including it, a template including one of which
+// is ErrTemplateIncludeCycle.
+func (t templateWriter) compose(runtime, template, work string, chain []string) (m manifest, err error) {
+	for _, c := range chain {
+		if c == template {
+			return m, fmt.Errorf("%w: %v", ErrTemplateIncludeCycle, strings.Join(append(chain, template), " -> "))
+		}
+	}
+	chain = append(chain, template)
+
+	src, accessor, err := t.resolve(runtime, template)
+	if err != nil {
+		return
+	}
+	if m, err = manifestOf(src, accessor); err != nil {
+		return
+	}
+	var render, managed []string
+	for _, include := range m.Includes {
+		included, err := t.compose(runtime, include, work, chain)
+		if err != nil {
+			return m, err
+		}
+		render = append(render, included.Render...)
+		managed = append(managed, included.Managed...)
+	}
+	if _, err = copy(src, work, accessor, nil); err != nil {
+		return
+	}
+
+	m.Render = append(render, m.Render...)
+	m.Managed = append(managed, m.Managed...)
+	m.Includes = nil
+	bb, err := yaml.Marshal(m)
+	if err != nil {
+		return
 	}
+	err = ioutil.WriteFile(filepath.Join(work, ManifestFile), bb, 0644)
+	return
+}
+
+// Template available for the creation of Functions.
+type Template struct {
+	// Name of the template, as given when creating a Function.  Templates of
+	// repositories are prefixed with the repository name, for example
+	// "boson/json".
+	Name string `json:"name" yaml:"name"`
+
+	// Runtime of the template.
+	Runtime string `json:"runtime" yaml:"runtime"`
+
+	// Repository providing the template.  Empty for embedded templates.
+	Repository string `json:"repository,omitempty" yaml:"repository,omitempty"`
 
-	return writeEmbedded(runtime, template, dest)
+	// Signature of the Function implemented by the template, "http" or
+	// "events", if declared.  Embedded templates are named by their signature.
+	Signature string `json:"signature,omitempty" yaml:"signature,omitempty"`
+
+	// Description of the template in a line, as declared by its manifest.
+	// Embedded templates are described by their signature.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// Preferences of the template for the Functions created from it, if
+	// declared by its manifest.
+	Preferences *TemplatePreferences `json:"preferences,omitempty" yaml:"preferences,omitempty"`
+
+	// Handlers of the template, if it declares several of which one is
+	// selected as the entrypoint of the Function.  The first is the default.
+	Handlers []string `json:"handlers,omitempty" yaml:"handlers,omitempty"`
 }
 
-func isCustom(template string) bool {
-	return len(strings.Split(template, "/")) > 1
+// TemplatePreferences are the settings a template suggests for the Functions
+// created from it, offered as the defaults when creating one interactively.
+type TemplatePreferences struct {
+	// Name suggested for the Function, such as "myfunc".
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Registry suggested for the Function's image, such as "quay.io/alice".
+	Registry string `json:"registry,omitempty" yaml:"registry,omitempty"`
+	// ImageSuffix suggested for the Function's image name, such as "-fn".
+	ImageSuffix string `json:"imageSuffix,omitempty" yaml:"imageSuffix,omitempty"`
 }
 
-func writeCustom(templatesPath, runtime, templateFullName, dest string) error {
-	if templatesPath == "" {
-		return ErrRepositoriesNotDefined
+// Signatures of the Functions which templates may implement.
+var Signatures = []string{"http", "events"}
+
+// signatureDescriptions describe the embedded templates, which declare no
+// description, by their signature.
+var signatureDescriptions = map[string]string{
+	"http":   "Function invoked by HTTP requests",
+	"events": "Function invoked by CloudEvents",
+}
+
+// templates lists the embedded templates and those of the repositories at
+// the given path, if any, of the given runtimes, or of all runtimes if none
+// are given.  Templates are sorted by runtime, then name.
+func templates(repositories string, runtimes ...string) (tt []Template, err error) {
+	if tt, err = listEmbedded(); err != nil {
+		return
+	}
+	for i := range tt {
+		if tt[i].Signature == "" && (tt[i].Name == "http" || tt[i].Name == "events") {
+			tt[i].Signature = tt[i].Name
+		}
+		if tt[i].Description == "" {
+			tt[i].Description = signatureDescriptions[tt[i].Signature]
+		}
+	}
+
+	if repositories != "" {
+		var repos []Repository
+		if repos, err = readRepositories(repositories); err != nil {
+			return
+		}
+		for _, r := range repos {
+			for _, rt := range r.Runtimes {
+				for _, name := range rt.Templates {
+					t := Template{Name: r.Name + "/" + name, Runtime: rt.Name, Repository: r.Name}
+					if err = describe(&t, filepath.Join(repositories, r.Name, rt.Name, name), filesystemAccessor{}); err != nil {
+						return
+					}
+					tt = append(tt, t)
+				}
+			}
+		}
+	}
+
+	if len(runtimes) > 0 {
+		filtered := []Template{}
+		for _, t := range tt {
+			for _, r := range runtimes {
+				if t.Runtime == r {
+					filtered = append(filtered, t)
+				}
+			}
+		}
+		tt = filtered
+	}
+
+	sort.SliceStable(tt, func(i, j int) bool {
+		if tt[i].Runtime != tt[j].Runtime {
+			return tt[i].Runtime < tt[j].Runtime
+		}
+		return tt[i].Name < tt[j].Name
+	})
+	return
+}
+
+// listEmbedded lists the embedded templates, laid out as [runtime]/[template].
+func listEmbedded() (tt []Template, err error) {
+	path, accessor := "/templates", embeddedAccessor{}
+	runtimes, err := readDir(path, accessor)
+	if err != nil {
+		return
+	}
+	for _, runtime := range runtimes {
+		if !runtime.IsDir() || hidden(runtime.Name()) {
+			continue
+		}
+		var children []os.FileInfo
+		if children, err = readDir(filepath.Join(path, runtime.Name()), accessor); err != nil {
+			return
+		}
+		for _, child := range children {
+			if !child.IsDir() {
+				continue
+			}
+			t := Template{Name: child.Name(), Runtime: runtime.Name()}
+			if err = describe(&t, filepath.Join(path, runtime.Name(), child.Name()), accessor); err != nil {
+				return
+			}
+			tt = append(tt, t)
+		}
 	}
+	return
+}
 
-	if !repositoryExists(templatesPath, templateFullName) {
-		return ErrRepositoryNotFound
+// describe the template at path with the signature, description and
+// preferences declared by its manifest, if any.
+func describe(t *Template, path string, accessor fileAccessor) error {
+	manifestPath := filepath.Join(path, ManifestFile)
+	if _, err := accessor.Stat(manifestPath); err != nil {
+		return nil // no manifest
+	}
+	m, err := readManifest(manifestPath, accessor)
+	if err != nil {
+		return err
+	}
+	t.Signature = m.Signature
+	t.Description = m.Description
+	if m.Preferences != (TemplatePreferences{}) {
+		preferences := m.Preferences
+		t.Preferences = &preferences
+	}
+	for _, h := range m.Handlers {
+		t.Handlers = append(t.Handlers, h.Name)
+	}
+	return nil
+}
+
+func isCustom(template string) bool {
+	return !IsTemplateURL(template) && len(strings.Split(template, "/")) > 1
+}
+
+// resolveCustom returns the path of the template of the runtime of the form
+// [repo]/[template] within the repositories at templatesPath.
+func resolveCustom(templatesPath, runtime, templateFullName string) (string, error) {
+	if templatesPath == "" {
+		return "", &TemplateError{Err: ErrRepositoriesNotDefined, Runtime: runtime, Template: templateFullName}
 	}
 
 	// ensure that the templateFullName is of the format "repoName/templateName"
 	cc := strings.Split(templateFullName, "/")
 	if len(cc) != 2 {
-		return ErrTemplateMissingRepository
+		return "", &TemplateError{Err: ErrTemplateMissingRepository, Runtime: runtime, Template: templateFullName}
 	}
 	repo := cc[0]
 	template := cc[1]
 
+	if _, err := os.Stat(filepath.Join(templatesPath, repo)); err != nil {
+		return "", &TemplateError{Err: ErrRepositoryNotFound, Runtime: runtime, Template: templateFullName, Repository: repo, Cause: err}
+	}
+
 	runtimePath := filepath.Join(templatesPath, repo, runtime)
 	_, err := os.Stat(runtimePath)
 	if err != nil {
-		return ErrRuntimeNotFound
+		return "", &TemplateError{Err: ErrRuntimeNotFound, Runtime: runtime, Template: templateFullName, Repository: repo, Cause: err}
 	}
 
 	// Example FileSystem path:
@@ -107,28 +501,43 @@ func writeCustom(templatesPath, runtime, templateFullName, dest string) error {
 	templatePath := filepath.Join(templatesPath, repo, runtime, template)
 	_, err = os.Stat(templatePath)
 	if err != nil {
-		return ErrTemplateNotFound
+		return "", &TemplateError{Err: ErrTemplateNotFound, Runtime: runtime, Template: templateFullName, Repository: repo, Cause: err}
+	}
+
+	// The runtime and template must also be declared by the repository's
+	// manifest, if it has one.
+	r, err := readRepository(filepath.Join(templatesPath, repo), repo)
+	if err != nil {
+		return "", err
 	}
-	return copy(templatePath, dest, filesystemAccessor{})
+	rt, ok := r.runtime(runtime)
+	if !ok {
+		return "", &TemplateError{Err: ErrRuntimeNotFound, Runtime: runtime, Template: templateFullName, Repository: repo, Cause: ErrNotDeclared}
+	}
+	if !rt.hasTemplate(template) {
+		return "", &TemplateError{Err: ErrTemplateNotFound, Runtime: runtime, Template: templateFullName, Repository: repo, Cause: ErrNotDeclared}
+	}
+	return templatePath, nil
 }
 
-func writeEmbedded(runtime, template, dest string) (err error) {
-	// Copy files to the destination
+// resolveEmbedded returns the path of the embedded template of the runtime.
+func resolveEmbedded(runtime, template string) (string, error) {
 	// Example embedded path:
 	//   /templates/go/http
+	// Hidden directories, such as the CI of each system, are not runtimes.
 	runtimePath := filepath.Join("/templates", runtime)
-	_, err = pkger.Stat(runtimePath)
-	if err != nil {
-		return ErrRuntimeNotFound
+	_, err := pkger.Stat(runtimePath)
+	if err != nil || hidden(runtime) {
+		return "", &TemplateError{Err: ErrRuntimeNotFound, Runtime: runtime, Template: template, Cause: err}
 	}
 
 	templatePath := filepath.Join("/templates", runtime, template)
 	_, err = pkger.Stat(templatePath)
 	if err != nil {
-		return ErrTemplateNotFound
+		return "", &TemplateError{Err: ErrTemplateNotFound, Runtime: runtime, Template: template, Cause: err}
 	}
 
-	return copy(templatePath, dest, embeddedAccessor{})
+	return templatePath, nil
 }
 
 type embeddedAccessor struct{}
@@ -157,7 +566,137 @@ func repositoryExists(repositories, template string) bool {
 	return err == nil
 }
 
-func copy(src, dest string, accessor fileAccessor) (err error) {
+// write the template at src to dest, rendering those of its files declared
+// by its manifest with the Function as data.  Files which already exist at
+// dest are resolved by onConflict, if provided, those skipped being neither
+// copied nor rendered.
+func write(src, dest string, accessor fileAccessor, f Function, onConflict ConflictResolver) (err error) {
+	skipped, err := copy(src, dest, accessor, onConflict)
+	if err != nil {
+		return
+	}
+	return render(src, dest, accessor, f, skipped)
+}
+
+// writeManaged writes only the files of the template at src declared as
+// managed by its manifest to dest, overwriting those which exist, and
+// rendering them as does write.  Other files, such as the implementation of
+// the Function and its tests, are never touched.  A template which declares
+// no managed files is ErrTemplateNotManaged.
+func writeManaged(src, dest string, accessor fileAccessor, f Function) (err error) {
+	m, err := manifestOf(src, accessor)
+	if err != nil {
+		return
+	}
+	if len(m.Managed) == 0 {
+		return ErrTemplateNotManaged
+	}
+
+	var files, managed []string
+	if err = templateFiles(src, "", accessor, &files); err != nil {
+		return
+	}
+	var jobs []copyJob
+	for _, rel := range files {
+		if !matchesAny(m.Managed, rel) && !matchesAny(m.Managed, strings.TrimSuffix(rel, TemplateSuffix)) {
+			continue
+		}
+		target := filepath.Join(dest, filepath.FromSlash(rel))
+		if err = os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return
+		}
+		jobs = append(jobs, copyJob{filepath.Join(src, filepath.FromSlash(rel)), target})
+		managed = append(managed, rel)
+	}
+	if err = copyJobs(jobs, accessor, copyWorkers); err != nil {
+		return
+	}
+	for _, rel := range managed {
+		if matchesAny(m.Render, rel) {
+			if err = renderFile(filepath.Join(dest, filepath.FromSlash(rel)), rel, f); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// copyWorkers is the maximum number of files copied concurrently when
+// writing a template.
+var copyWorkers = 8
+
+// copy the file or directory tree at src to dest, copying files concurrently
+// using at most copyWorkers workers.  Files which already exist at dest are
+// resolved by onConflict, if provided, returning the slash-separated paths
+// relative to dest of those skipped.
+func copy(src, dest string, accessor fileAccessor, onConflict ConflictResolver) (skipped map[string]bool, err error) {
+	var jobs []copyJob
+	if err = copyTree(src, dest, accessor, &jobs); err != nil {
+		return
+	}
+	if jobs, skipped, err = resolveConflicts(dest, jobs, accessor, onConflict); err != nil {
+		return
+	}
+	return skipped, copyJobs(jobs, accessor, copyWorkers)
+}
+
+// resolveConflicts of the jobs whose destination file already exists with
+// differing contents, one at a time and in order, returning the jobs to be
+// copied and the slash-separated paths relative to dest of those skipped.
+// Without a resolver all jobs are copied.
+func resolveConflicts(dest string, jobs []copyJob, accessor fileAccessor, onConflict ConflictResolver) (remaining []copyJob, skipped map[string]bool, err error) {
+	skipped = map[string]bool{}
+	if onConflict == nil {
+		return jobs, skipped, nil
+	}
+	for _, job := range jobs {
+		existing, e := ioutil.ReadFile(job.dest)
+		if os.IsNotExist(e) {
+			remaining = append(remaining, job)
+			continue
+		}
+		if e != nil {
+			return nil, nil, e
+		}
+		var template []byte
+		if template, err = readFile(job.src, accessor); err != nil {
+			return
+		}
+		if bytes.Equal(existing, template) {
+			remaining = append(remaining, job)
+			continue
+		}
+		var rel string
+		if rel, err = filepath.Rel(dest, job.dest); err != nil {
+			return
+		}
+		rel = filepath.ToSlash(rel)
+		var overwrite bool
+		if overwrite, err = onConflict(rel, existing, template); err != nil {
+			return
+		}
+		if overwrite {
+			remaining = append(remaining, job)
+		} else {
+			skipped[rel] = true
+		}
+	}
+	return
+}
+
+// readFile at path using the accessor.
+func readFile(path string, accessor fileAccessor) ([]byte, error) {
+	f, err := accessor.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// copySerial copies the file or directory tree at src to dest, one file at
+// a time.
+func copySerial(src, dest string, accessor fileAccessor) (err error) {
 	node, err := accessor.Stat(src)
 	if err != nil {
 		return
@@ -169,6 +708,94 @@ func copy(src, dest string, accessor fileAccessor) (err error) {
 	}
 }
 
+// copyJob is a single file to be copied.
+type copyJob struct{ src, dest string }
+
+// copyConcurrent copies the file or directory tree at src to dest.  The
+// directory structure is created first, after which its files are copied by
+// a pool of the given number of workers.  See copyJobs.
+func copyConcurrent(src, dest string, accessor fileAccessor, workers int) (err error) {
+	var jobs []copyJob
+	if err = copyTree(src, dest, accessor, &jobs); err != nil {
+		return
+	}
+	return copyJobs(jobs, accessor, workers)
+}
+
+// copyJobs copies the files of the jobs using a pool of the given number of
+// workers.  The first error encountered stops the remaining copies and is
+// returned.
+func copyJobs(jobs []copyJob, accessor fileAccessor, workers int) (err error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		queue = make(chan copyJob)
+		done  = make(chan struct{})
+	)
+	fail := func(e error) {
+		once.Do(func() {
+			err = e
+			close(done)
+		})
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				if e := copyLeaf(job.src, job.dest, accessor); e != nil {
+					fail(e)
+				}
+			}
+		}()
+	}
+
+enqueue:
+	for _, job := range jobs {
+		select {
+		case queue <- job:
+		case <-done:
+			break enqueue
+		}
+	}
+	close(queue)
+	wg.Wait()
+	return
+}
+
+// copyTree creates the directories of the tree at src within dest,
+// preserving their modes, and appends the files to be copied to jobs.
+func copyTree(src, dest string, accessor fileAccessor, jobs *[]copyJob) (err error) {
+	node, err := accessor.Stat(src)
+	if err != nil {
+		return
+	}
+	if !node.IsDir() {
+		*jobs = append(*jobs, copyJob{src, dest})
+		return
+	}
+
+	if err = os.MkdirAll(dest, node.Mode()); err != nil {
+		return
+	}
+
+	children, err := readDir(src, accessor)
+	if err != nil {
+		return
+	}
+	for _, child := range children {
+		if err = copyTree(filepath.Join(src, child.Name()), filepath.Join(dest, child.Name()), accessor, jobs); err != nil {
+			return
+		}
+	}
+	return
+}
+
 func copyNode(src, dest string, accessor fileAccessor) (err error) {
 	node, err := accessor.Stat(src)
 	if err != nil {
@@ -185,7 +812,7 @@ func copyNode(src, dest string, accessor fileAccessor) (err error) {
 		return
 	}
 	for _, child := range children {
-		if err = copy(filepath.Join(src, child.Name()), filepath.Join(dest, child.Name()), accessor); err != nil {
+		if err = copySerial(filepath.Join(src, child.Name()), filepath.Join(dest, child.Name()), accessor); err != nil {
 			return
 		}
 	}
@@ -227,3 +854,239 @@ func copyLeaf(src, dest string, accessor fileAccessor) (err error) {
 	_, err = io.Copy(destFile, srcFile)
 	return
 }
+
+// ManifestFile is the optional file at the root of a template declaring how
+// it is written.  It is not retained in the written Function.
+//
+// Files of the template matching any of the globs listed under "render" are
+// rendered as Go text templates with the Function as data, such that for
+// example {{.Name}} is replaced with the Function's name.  Globs without a
+// path separator match file names, and otherwise paths relative to the
+// template root.  Rendered files with the suffix TemplateSuffix are written
+// without it.  All other files are copied unchanged.  The signature of the
+// Function implemented by the template, a description of it in a line, and
+// the name and registry it suggests for the Functions created from it may
+// also be declared, as may the files it manages, such as its build config,
+// which alone are refreshed with WithManagedFilesOnly.  A template exposing
+// several example handlers declares each by name and file, one of which is
+// selected as the Function's Entrypoint, the first by default: it is moved to
+// the entrypoint path declared, if any, and the others are removed.  A
+// template may be composed of others of its runtime, such as a base and
+// overlays, which it includes: each is written first, in order, and then the
+// template's own files, later files overriding earlier ones.  For example:
+//
+//	signature: http
+//	description: Function responding to HTTP requests with JSON
+//	preferences:
+//	  name: myjsonfunc
+//	includes:
+//	- http
+//	- mytemplates/auth
+//	render:
+//	- "*.tmpl"
+//	managed:
+//	- go.mod
+//	- .builders.yaml
+//	entrypoint: handle.go
+//	handlers:
+//	- name: echo
+//	  file: handlers/echo.go
+//	- name: json
+//	  file: handlers/json.go
+const ManifestFile = ".manifest.yaml"
+
+// TemplateSuffix is removed from the names of rendered files.
+const TemplateSuffix = ".tmpl"
+
+// manifest of a template.  See ManifestFile.
+type manifest struct {
+	// Signature of the Function implemented by the template: "http" or
+	// "events".  Optional.
+	Signature string `yaml:"signature"`
+	// Description of the template in a line.  Optional.
+	Description string `yaml:"description"`
+	// Preferences of the template for the Functions created from it.
+	// Optional.
+	Preferences TemplatePreferences `yaml:"preferences"`
+	Render      []string            `yaml:"render"`
+	// Managed files of the template, by glob as those of Render, which are
+	// the only files written when refreshing the template of a Function with
+	// WithManagedFilesOnly.  Optional.
+	Managed []string `yaml:"managed"`
+	// Includes are the templates of the runtime, such as http or
+	// [repo]/[template], with which the template is composed: their files
+	// are written before its own, in order, such that later files override
+	// earlier ones, and their render and managed globs apply as well.
+	// Optional.
+	Includes []string `yaml:"includes,omitempty"`
+	// Handlers of a template exposing several, of which that selected as the
+	// Function's Entrypoint is kept and the others removed.  The first is
+	// selected by default.  Handlers are not those of included templates.
+	// Optional.
+	Handlers []manifestHandler `yaml:"handlers,omitempty"`
+	// Entrypoint is the path, relative to the template root, to which the
+	// handler selected is moved, such as "handle.go".  Optional: without,
+	// it is kept at its own path.
+	Entrypoint string `yaml:"entrypoint,omitempty"`
+}
+
+// manifestHandler is one of the handlers of a template.  See ManifestFile.
+type manifestHandler struct {
+	// Name of the handler, by which it is selected.
+	Name string `yaml:"name"`
+	// File of the handler, relative to the template root.  A rendered file
+	// is that written without TemplateSuffix.
+	File string `yaml:"file"`
+}
+
+// render the files of the template at src which are declared by its
+// manifest, if any, as written to dest, with the given data, such as the
+// Function.  Those skipped, by slash-separated path relative to dest, were
+// not written and so are not rendered.
+func render(src, dest string, accessor fileAccessor, data interface{}, skipped map[string]bool) (err error) {
+	if _, err = accessor.Stat(filepath.Join(src, ManifestFile)); err != nil {
+		return nil // no manifest
+	}
+	m, err := readManifest(filepath.Join(src, ManifestFile), accessor)
+	if err != nil {
+		return
+	}
+	if !skipped[ManifestFile] {
+		if err = os.Remove(filepath.Join(dest, ManifestFile)); err != nil {
+			return
+		}
+	}
+
+	var files []string
+	if err = templateFiles(src, "", accessor, &files); err != nil {
+		return
+	}
+	for _, rel := range files {
+		if matchesAny(m.Render, rel) && !skipped[rel] {
+			if err = renderFile(filepath.Join(dest, filepath.FromSlash(rel)), rel, data); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// manifestOf the template at src, empty if it has none.
+func manifestOf(src string, accessor fileAccessor) (m manifest, err error) {
+	if _, err = accessor.Stat(filepath.Join(src, ManifestFile)); err != nil {
+		return m, nil // no manifest
+	}
+	return readManifest(filepath.Join(src, ManifestFile), accessor)
+}
+
+// readManifest at path, validating its globs.
+func readManifest(path string, accessor fileAccessor) (m manifest, err error) {
+	r, err := accessor.Open(path)
+	if err != nil {
+		return
+	}
+	defer r.Close()
+	bb, err := ioutil.ReadAll(r)
+	if err != nil {
+		return
+	}
+	if err = yaml.Unmarshal(bb, &m); err != nil {
+		return m, fmt.Errorf("template manifest '%v' is not valid: %v", ManifestFile, err)
+	}
+	for _, glob := range m.Render {
+		if _, err = filepath.Match(glob, ""); err != nil {
+			return m, fmt.Errorf("template manifest '%v' has invalid render glob '%v': %v", ManifestFile, glob, err)
+		}
+	}
+	for _, glob := range m.Managed {
+		if _, err = filepath.Match(glob, ""); err != nil {
+			return m, fmt.Errorf("template manifest '%v' has invalid managed glob '%v': %v", ManifestFile, glob, err)
+		}
+	}
+	for _, include := range m.Includes {
+		if include == "" {
+			return m, fmt.Errorf("template manifest '%v' includes a template without a name", ManifestFile)
+		}
+	}
+	handlers := map[string]bool{}
+	for _, h := range m.Handlers {
+		if h.Name == "" || h.File == "" {
+			return m, fmt.Errorf("template manifest '%v' declares a handler without a name or file", ManifestFile)
+		}
+		if handlers[h.Name] {
+			return m, fmt.Errorf("template manifest '%v' declares handler '%v' more than once", ManifestFile, h.Name)
+		}
+		handlers[h.Name] = true
+	}
+	if err = ValidateImageSuffix(m.Preferences.ImageSuffix); err != nil {
+		return m, fmt.Errorf("template manifest '%v' has invalid image suffix '%v': %v", ManifestFile, m.Preferences.ImageSuffix, err)
+	}
+	return
+}
+
+// templateFiles appends the slash-separated paths, relative to the template
+// root, of the files within the directory src at the relative path rel.
+func templateFiles(src, rel string, accessor fileAccessor, files *[]string) (err error) {
+	children, err := readDir(src, accessor)
+	if err != nil {
+		return
+	}
+	for _, child := range children {
+		childRel := path.Join(rel, child.Name())
+		if child.IsDir() {
+			err = templateFiles(filepath.Join(src, child.Name()), childRel, accessor, files)
+		} else if childRel != ManifestFile {
+			*files = append(*files, childRel)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// matchesAny returns whether the slash-separated relative path matches any
+// of the globs.  Globs without a separator are matched against its name.
+func matchesAny(globs []string, rel string) bool {
+	for _, glob := range globs {
+		name := rel
+		if !strings.Contains(glob, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// renderFile at path as a template with the given data, such as the Function,
+// writing the result without TemplateSuffix.  Errors, such as those
+// referencing unknown fields, name the file by its path relative to the
+// template root.
+func renderFile(filePath, rel string, data interface{}) (err error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return
+	}
+	bb, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return
+	}
+	t, err := template.New(rel).Option("missingkey=error").Parse(string(bb))
+	if err != nil {
+		return fmt.Errorf("template file '%v' is not valid: %v", rel, err)
+	}
+	var out bytes.Buffer
+	if err = t.Execute(&out, data); err != nil {
+		return fmt.Errorf("template file '%v' could not be rendered: %v", rel, err)
+	}
+	dest := strings.TrimSuffix(filePath, TemplateSuffix)
+	if err = ioutil.WriteFile(dest, out.Bytes(), info.Mode()); err != nil {
+		return
+	}
+	if dest != filePath {
+		err = os.Remove(filePath)
+	}
+	return
+}
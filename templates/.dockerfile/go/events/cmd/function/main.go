@@ -0,0 +1,35 @@
+// Entrypoint of the function when built from its Dockerfile.
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	function "function"
+)
+
+func main() {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	ctx := context.Background()
+	protocol, err := cloudevents.NewHTTP()
+	if err != nil {
+		log.Fatal(err)
+	}
+	receiver, err := cloudevents.NewHTTPReceiveHandler(ctx, protocol, function.Handle)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.Handle("/", receiver)
+	log.Fatal(http.ListenAndServe(":"+port, mux))
+}
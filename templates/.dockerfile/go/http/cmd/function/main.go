@@ -0,0 +1,25 @@
+// Entrypoint of the function when built from its Dockerfile.
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	function "function"
+)
+
+func main() {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		function.Handle(r.Context(), w, r)
+	})
+	log.Fatal(http.ListenAndServe(":"+port, mux))
+}
@@ -0,0 +1,21 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// Handle an HTTP Request, echoing its body.
+func Handle(ctx context.Context, res http.ResponseWriter, req *http.Request) {
+
+	res.Header().Add("Content-Type", "text/plain")
+	res.WriteHeader(200)
+
+	_, err := io.Copy(res, req.Body)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error or response write: %v", err)
+	}
+}
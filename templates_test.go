@@ -1,12 +1,17 @@
+//go:build !integration
 // +build !integration
 
 package function
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -101,6 +106,368 @@ func TestWriteInvalid(t *testing.T) {
 	}
 }
 
+// TestWriteInvalidCustom ensures that failures to resolve a custom template
+// are TemplateErrors which identify what was requested, match the sentinel
+// error and retain the underlying cause.
+func TestWriteInvalidCustom(t *testing.T) {
+	root := "testdata/testWriteInvalidCustom"
+	defer using(t, root)()
+
+	w := templateWriter{templates: "testdata/repositories"}
+
+	tests := []struct {
+		name     string
+		runtime  string
+		template string
+		expected error
+	}{
+		{"repository", "go", "invalid/tpla", ErrRepositoryNotFound},
+		{"runtime", "invalid", "customProvider/tpla", ErrRuntimeNotFound},
+		{"template", "test", "customProvider/invalid", ErrTemplateNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := w.Write(tt.runtime, tt.template, root)
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, err)
+			}
+			var terr *TemplateError
+			if !errors.As(err, &terr) {
+				t.Fatalf("expected a TemplateError, got %T", err)
+			}
+			if terr.Runtime != tt.runtime || terr.Template != tt.template {
+				t.Fatalf("expected error for %v %v, got %v %v", tt.runtime, tt.template, terr.Runtime, terr.Template)
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("expected the underlying cause to be retained, got %v", errors.Unwrap(err))
+			}
+		})
+	}
+}
+
+// TestWriteRendered ensures that the files of a template declared by its
+// manifest are rendered with the Function as data, that the template suffix
+// is removed from their names, and that all other files, including the
+// manifest itself, are not.
+func TestWriteRendered(t *testing.T) {
+	root := "testdata/testWriteRendered"
+	defer using(t, root)()
+
+	w := templateWriter{templates: "testdata/repositories", function: Function{Name: "myfunc", Runtime: "test"}}
+	if err := w.Write(TestRuntime, "customProvider/tpld", root); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"README.md":      "# myfunc\n\nA test Function.\n",
+		"docs/deploy.md": "Deploy myfunc with func deploy.\n",
+		"plain.txt":      "{{.Name}} is not rendered.\n",
+	}
+	for name, content := range expected {
+		bb, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bb) != content {
+			t.Fatalf("expected '%v' to contain %q, got %q", name, content, bb)
+		}
+	}
+	for _, name := range []string{"README.md.tmpl", ManifestFile} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected '%v' not to be written", name)
+		}
+	}
+}
+
+// TestRenderUnknownField ensures that a template file referencing an unknown
+// field errors, naming the file.
+func TestRenderUnknownField(t *testing.T) {
+	src, err := ioutil.TempDir("", "render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	if err = ioutil.WriteFile(filepath.Join(src, ManifestFile), []byte("render: [\"*.tmpl\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(src, "README.md.tmpl"), []byte("{{.Unknown}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := "testdata/testRenderUnknownField"
+	defer using(t, dest)()
+
+	err = write(src, dest, filesystemAccessor{}, Function{Name: "myfunc"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "README.md.tmpl") {
+		t.Fatalf("expected an error naming 'README.md.tmpl', got '%v'", err)
+	}
+}
+
+// TestWriteManaged ensures that only the files of a template declared as
+// managed are written, overwriting those which exist and being rendered, and
+// that a template which declares none is ErrTemplateNotManaged.
+func TestWriteManaged(t *testing.T) {
+	src, err := ioutil.TempDir("", "managed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	files := map[string]string{
+		ManifestFile:       "render: [\"*.tmpl\"]\nmanaged: [go.mod, build/*]\n",
+		"go.mod.tmpl":      "module {{.Name}}",
+		"build/config.txt": "config",
+		"handle.go":        "package function",
+		"test/handle.go":   "package function",
+	}
+	for name, content := range files {
+		if err = os.MkdirAll(filepath.Dir(filepath.Join(src, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dest := "testdata/testWriteManaged"
+	defer using(t, dest)()
+	for name, content := range map[string]string{"go.mod": "module old", "handle.go": "package mine"} {
+		if err = ioutil.WriteFile(filepath.Join(dest, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = writeManaged(src, dest, filesystemAccessor{}, Function{Name: "myfunc"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"go.mod": "module myfunc", "build/config.txt": "config", "handle.go": "package mine"}
+	for name, content := range expected {
+		bb, err := ioutil.ReadFile(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bb) != content {
+			t.Fatalf("expected '%v' to contain %q, got %q", name, content, bb)
+		}
+	}
+	for _, name := range []string{"test", "go.mod.tmpl", ManifestFile} {
+		if _, err = os.Stat(filepath.Join(dest, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected '%v' not to be written", name)
+		}
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(src, ManifestFile), []byte("render: [\"*.tmpl\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = writeManaged(src, dest, filesystemAccessor{}, Function{Name: "myfunc"}); !errors.Is(err, ErrTemplateNotManaged) {
+		t.Fatalf("expected ErrTemplateNotManaged, got %v", err)
+	}
+}
+
+// TestWriteIncludes ensures that a template is composed with those it
+// includes, embedded or of repositories, their files written before its own
+// such that later files override earlier ones, their render globs applying
+// as well, and that templates which include themselves are an error.
+func TestWriteIncludes(t *testing.T) {
+	repositories, err := ioutil.TempDir("", "includes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repositories)
+	files := map[string]string{
+		"base/" + ManifestFile:  "render: [\"*.tmpl\"]\n",
+		"base/name.txt.tmpl":    "{{.Name}}",
+		"base/handle.go":        "package base",
+		"auth/" + ManifestFile:  "includes: [mine/base]\n",
+		"auth/auth.go":          "package auth",
+		"auth/handle.go":        "package auth",
+		"app/" + ManifestFile:   "includes: [http, mine/auth]\ndescription: App\n",
+		"app/README.md":         "app",
+		"cycle/" + ManifestFile: "includes: [mine/loop]\n",
+		"loop/" + ManifestFile:  "includes: [mine/cycle]\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(repositories, "mine", "go", filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root := "testdata/testWriteIncludes"
+	defer using(t, root)()
+	w := templateWriter{templates: repositories, function: Function{Name: "myfunc"}}
+	if err = w.Write("go", "mine/app", root); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"name.txt": "myfunc", "handle.go": "package auth", "auth.go": "package auth", "README.md": "app"}
+	for name, content := range expected {
+		bb, err := ioutil.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bb) != content {
+			t.Fatalf("expected '%v' to contain %q, got %q", name, content, bb)
+		}
+	}
+	for _, name := range []string{"go.mod", ".builders.yaml"} {
+		if _, err = os.Stat(filepath.Join(root, name)); err != nil {
+			t.Fatalf("expected '%v' of the embedded template included: %v", name, err)
+		}
+	}
+	for _, name := range []string{ManifestFile, "name.txt.tmpl"} {
+		if _, err = os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected '%v' not to be written", name)
+		}
+	}
+
+	err = w.Write("go", "mine/cycle", "testdata/testWriteIncludes/cycle")
+	if !errors.Is(err, ErrTemplateIncludeCycle) || !strings.Contains(err.Error(), "mine/cycle -> mine/loop -> mine/cycle") {
+		t.Fatalf("expected ErrTemplateIncludeCycle, got %v", err)
+	}
+}
+
+// TestWriteEntrypoint ensures that the handler of a template exposing several
+// which is selected, the first by default, is moved to its entrypoint and the
+// others removed, and that a handler the template does not declare is
+// ErrEntrypointNotFound.
+func TestWriteEntrypoint(t *testing.T) {
+	repositories, err := ioutil.TempDir("", "entrypoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repositories)
+	files := map[string]string{
+		ManifestFile:            "render: [\"*.tmpl\"]\nentrypoint: handle.go\nhandlers:\n- name: echo\n  file: handlers/echo.go\n- name: json\n  file: handlers/json.go.tmpl\n",
+		"handlers/echo.go":      "package echo",
+		"handlers/json.go.tmpl": "package {{.Name}}",
+		"handlers/README.md":    "handlers",
+		"handle_test.go":        "package function",
+		"other/" + ManifestFile: "description: Other\n",
+		"other/handle.go":       "package other",
+	}
+	for name, content := range files {
+		dir := "multi"
+		if strings.HasPrefix(name, "other/") {
+			dir = ""
+		}
+		path := filepath.Join(repositories, "mine", "go", dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := templateWriter{templates: repositories}
+
+	tests := []struct {
+		name, entrypoint, selected, handle string
+	}{
+		{"default", "", "echo", "package echo"},
+		{"selected", "json", "json", "package myfunc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := "testdata/testWriteEntrypoint"
+			defer using(t, root)()
+			selected, err := w.entrypoint("go", "mine/multi", tt.entrypoint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if selected != tt.selected {
+				t.Fatalf("expected the handler '%v' to be selected, got '%v'", tt.selected, selected)
+			}
+			w.function = Function{Name: "myfunc", Entrypoint: selected}
+			if err = w.Write("go", "mine/multi", root); err != nil {
+				t.Fatal(err)
+			}
+			bb, err := ioutil.ReadFile(filepath.Join(root, "handle.go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(bb) != tt.handle {
+				t.Fatalf("expected the entrypoint to contain %q, got %q", tt.handle, bb)
+			}
+			for _, name := range []string{"handlers/echo.go", "handlers/json.go", "handlers/json.go.tmpl"} {
+				if _, err = os.Stat(filepath.Join(root, filepath.FromSlash(name))); !os.IsNotExist(err) {
+					t.Fatalf("expected '%v' to be removed", name)
+				}
+			}
+			if _, err = os.Stat(filepath.Join(root, "handlers", "README.md")); err != nil {
+				t.Fatalf("expected the other files of the template to be kept: %v", err)
+			}
+		})
+	}
+
+	if _, err = w.entrypoint("go", "mine/multi", "xml"); !errors.Is(err, ErrEntrypointNotFound) || !strings.Contains(err.Error(), "echo, json") {
+		t.Fatalf("expected ErrEntrypointNotFound listing the handlers, got %v", err)
+	}
+	if _, err = w.entrypoint("go", "mine/other", "echo"); !errors.Is(err, ErrEntrypointNotFound) {
+		t.Fatalf("expected ErrEntrypointNotFound for a template without handlers, got %v", err)
+	}
+	if selected, err := w.entrypoint("go", "mine/other", ""); err != nil || selected != "" {
+		t.Fatalf("expected no handler of a template without, got '%v' (%v)", selected, err)
+	}
+}
+
+// TestWriteResolvesConflicts ensures that the files of a template which
+// already exist with differing contents are resolved, those skipped being
+// neither copied nor rendered.
+func TestWriteResolvesConflicts(t *testing.T) {
+	src, err := ioutil.TempDir("", "conflicts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	files := map[string]string{
+		ManifestFile: "render: [\"*.txt\"]\n",
+		"a.txt":      "{{.Name}}",
+		"b.txt":      "{{.Name}}",
+		"c.txt":      "{{.Name}}",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dest := "testdata/testWriteResolvesConflicts"
+	defer using(t, dest)()
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err = ioutil.WriteFile(filepath.Join(dest, name), []byte("{{.Existing}}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var resolved []string
+	onConflict := func(path string, existing, template []byte) (bool, error) {
+		resolved = append(resolved, path)
+		if string(existing) != "{{.Existing}}" || string(template) != "{{.Name}}" {
+			t.Fatalf("unexpected contents of '%v': %q and %q", path, existing, template)
+		}
+		return path == "a.txt", nil
+	}
+	if err = write(src, dest, filesystemAccessor{}, Function{Name: "myfunc"}, onConflict); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(resolved, []string{"a.txt", "b.txt"}) {
+		t.Fatalf("expected a.txt and b.txt to be resolved, got %v", resolved)
+	}
+	expected := map[string]string{"a.txt": "myfunc", "b.txt": "{{.Existing}}", "c.txt": "myfunc"}
+	for name, content := range expected {
+		bb, err := ioutil.ReadFile(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bb) != content {
+			t.Fatalf("expected '%v' to contain %q, got %q", name, content, bb)
+		}
+	}
+}
+
 // TestWriteModeEmbedded ensures that templates written from the embedded
 // templates retain their mode.
 func TestWriteModeEmbedded(t *testing.T) {
@@ -185,3 +552,165 @@ func rm(t *testing.T, dir string) {
 		t.Fatal(err)
 	}
 }
+
+// TestCopyConcurrent ensures that copying a template concurrently produces
+// output identical to that of copying it serially, including file modes and
+// directory structure.
+func TestCopyConcurrent(t *testing.T) {
+	src := "testdata/testCopyConcurrentSrc"
+	defer using(t, src)()
+	writeTree(t, src, 5, 20)
+
+	serial := "testdata/testCopyConcurrentSerial"
+	defer using(t, serial)()
+	if err := copySerial(src, serial, filesystemAccessor{}); err != nil {
+		t.Fatal(err)
+	}
+
+	concurrent := "testdata/testCopyConcurrent"
+	defer using(t, concurrent)()
+	if err := copyConcurrent(src, concurrent, filesystemAccessor{}, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, actual := readTree(t, serial), readTree(t, concurrent)
+	if len(expected) != len(actual) {
+		t.Fatalf("expected %v entries, got %v", len(expected), len(actual))
+	}
+	for path, e := range expected {
+		a, ok := actual[path]
+		if !ok {
+			t.Fatalf("missing %v", path)
+		}
+		if e != a {
+			t.Fatalf("%v differs. Expected %+v, got %+v", path, e, a)
+		}
+	}
+}
+
+// TestCopyConcurrentError ensures that an error copying any file is reported.
+func TestCopyConcurrentError(t *testing.T) {
+	src := "testdata/testCopyConcurrentErrorSrc"
+	defer using(t, src)()
+	writeTree(t, src, 2, 10)
+
+	dest := "testdata/testCopyConcurrentError"
+	defer using(t, dest)()
+
+	accessor := failingAccessor{fail: filepath.Join(src, "dir1", "file5")}
+	err := copyConcurrent(src, dest, accessor, 4)
+	if !errors.Is(err, errCopyFailed) {
+		t.Fatalf("expected errCopyFailed, got %v", err)
+	}
+}
+
+// BenchmarkCopy compares copying a large template serially with copying it
+// concurrently.
+func BenchmarkCopy(b *testing.B) {
+	src, err := ioutil.TempDir("", "func-bench-copy")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	writeTree(b, src, 10, 50)
+
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchmarkCopy(b, src, copySerial)
+		}
+	})
+	b.Run("concurrent", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			benchmarkCopy(b, src, func(src, dest string, accessor fileAccessor) error {
+				return copyConcurrent(src, dest, accessor, copyWorkers)
+			})
+		}
+	})
+}
+
+func benchmarkCopy(b *testing.B, src string, copyFn func(string, string, fileAccessor) error) {
+	b.StopTimer()
+	dest, err := ioutil.TempDir("", "func-bench-copy-dest")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+	b.StartTimer()
+	if err = copyFn(src, filepath.Join(dest, "out"), filesystemAccessor{}); err != nil {
+		b.Fatal(err)
+	}
+	b.StopTimer()
+}
+
+var errCopyFailed = errors.New("copy failed")
+
+// failingAccessor is a filesystem accessor which fails to open a given file.
+type failingAccessor struct {
+	filesystemAccessor
+	fail string
+}
+
+func (a failingAccessor) Open(path string) (file, error) {
+	if path == a.fail {
+		return nil, errCopyFailed
+	}
+	return a.filesystemAccessor.Open(path)
+}
+
+// writeTree writes a tree of the given number of directories, each containing
+// the given number of files, alternating between regular and executable modes.
+func writeTree(t testing.TB, root string, dirs, files int) {
+	t.Helper()
+	for d := 0; d < dirs; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("dir%v", d))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for f := 0; f < files; f++ {
+			mode := os.FileMode(0644)
+			if f%2 == 1 {
+				mode = 0755
+			}
+			path := filepath.Join(dir, fmt.Sprintf("file%v", f))
+			content := strings.Repeat(path, 100)
+			if err := ioutil.WriteFile(path, []byte(content), mode); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+// treeEntry is the mode and content of a file or directory.
+type treeEntry struct {
+	mode    os.FileMode
+	content string
+}
+
+// readTree returns the entries of the tree at root, keyed by relative path.
+func readTree(t *testing.T, root string) map[string]treeEntry {
+	t.Helper()
+	entries := map[string]treeEntry{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		entry := treeEntry{mode: info.Mode()}
+		if !info.IsDir() {
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			entry.content = string(content)
+		}
+		entries[rel] = entry
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
@@ -0,0 +1,174 @@
+package function
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidTemplateURL indicates a template given as a URL is not of the
+// form [url]//[path]@[ref], or its path is outside of its repository.
+var ErrInvalidTemplateURL = errors.New("invalid template URL")
+
+// TemplateURL is a template given as the URL of a git repository and the
+// path of the template's directory within it, such as
+// git://github.com/alice/templates//go/json@v1, from which a Function is
+// created directly rather than from a repository added beforehand.
+type TemplateURL struct {
+	// Repository is the URL of the git repository, such as
+	// git://github.com/alice/templates.
+	Repository string
+
+	// Path of the template's directory within the repository, such as
+	// "go/json".  Empty for the root of the repository.
+	Path string
+
+	// Ref (branch, tag or commit) of the repository.  Empty for the default
+	// branch.
+	Ref string
+}
+
+// IsTemplateURL returns whether the template is given as the URL of a git
+// repository, as distinct from the name of one embedded or of a repository.
+func IsTemplateURL(template string) bool {
+	return strings.Contains(template, "://")
+}
+
+// ParseTemplateURL parses a template of the form [url]//[path]@[ref], where
+// the path and ref are optional, such as
+// git://github.com/alice/templates//go/json@v1.  The path must be within the
+// repository.
+func ParseTemplateURL(template string) (u TemplateURL, err error) {
+	i := strings.Index(template, "://")
+	if i <= 0 {
+		return u, fmt.Errorf("%w '%v': expected a URL such as git://github.com/alice/templates//go/json@v1", ErrInvalidTemplateURL, template)
+	}
+	scheme, rest := template[:i+3], template[i+3:]
+
+	// The path and ref follow the host and path of the repository, separated
+	// by "//".  Without a path, a ref follows its last path segment.
+	if j := strings.Index(rest, "//"); j >= 0 {
+		u.Repository, u.Path = scheme+rest[:j], rest[j+2:]
+		if k := strings.LastIndex(u.Path, "@"); k >= 0 {
+			u.Path, u.Ref = u.Path[:k], u.Path[k+1:]
+		}
+	} else {
+		u.Repository = scheme + rest
+		if k := strings.LastIndex(rest, "@"); k > strings.LastIndex(rest, "/") {
+			u.Repository, u.Ref = scheme+rest[:k], rest[k+1:]
+		}
+	}
+	if u.Repository == scheme || strings.HasSuffix(u.Repository, "/") {
+		return u, fmt.Errorf("%w '%v': no repository", ErrInvalidTemplateURL, template)
+	}
+
+	u.Path = strings.Trim(u.Path, "/")
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment == ".." {
+			return u, fmt.Errorf("%w '%v': the path '%v' is outside of the repository", ErrInvalidTemplateURL, template, u.Path)
+		}
+	}
+	if u.Path != "" {
+		u.Path = path.Clean(u.Path)
+	}
+	return
+}
+
+// String returns the template URL without its ref, as recorded as the
+// template of the Functions created from it.
+func (u TemplateURL) String() string {
+	if u.Path == "" {
+		return u.Repository
+	}
+	return u.Repository + "//" + u.Path
+}
+
+// templateURLOf the Function requested, if its template is given as a URL, at
+// its ref or otherwise the TemplateRef requested, which may not differ.  The
+// Function is returned with the template as recorded, without its ref, and
+// the ref as its TemplateRef.  Nil for a template not given as a URL.
+func templateURLOf(cfg Function) (Function, *TemplateURL, error) {
+	if !IsTemplateURL(cfg.Template) {
+		return cfg, nil, nil
+	}
+	u, err := ParseTemplateURL(cfg.Template)
+	if err != nil {
+		return cfg, nil, err
+	}
+	if u.Ref == "" {
+		u.Ref = cfg.TemplateRef
+	} else if cfg.TemplateRef != "" && cfg.TemplateRef != u.Ref {
+		return cfg, nil, fmt.Errorf("%w '%v': its ref differs from the template ref '%v'", ErrInvalidTemplateURL, cfg.Template, cfg.TemplateRef)
+	}
+	cfg.Template, cfg.TemplateRef = u.String(), u.Ref
+	return cfg, &u, nil
+}
+
+// fetchTemplate fetches the ref of the repository of the template URL into a
+// temporary directory, returning that of the template within it and the
+// commit fetched.  The temporary directory is removed with cleanup.
+func fetchTemplate(ctx context.Context, u TemplateURL) (dir, commit string, cleanup func(), err error) {
+	tmp, err := ioutil.TempDir("", "func-template")
+	if err != nil {
+		return
+	}
+	cleanup = func() { os.RemoveAll(tmp) }
+	defer func() {
+		if err != nil {
+			cleanup()
+		}
+	}()
+
+	// Fetching rather than cloning the ref permits it to be a commit.
+	ref := u.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	clone := filepath.Join(tmp, "repository")
+	if _, err = git(ctx, "", "init", "--quiet", clone); err != nil {
+		return
+	}
+	if _, err = git(ctx, clone, "remote", "add", "origin", u.Repository); err != nil {
+		return
+	}
+	if _, err = gitRemote(ctx, clone, u.Repository, "fetch", "--depth", "1", "origin", ref); err != nil {
+		err = fmt.Errorf("failed to fetch ref '%v' of template repository '%v': %w", ref, u.Repository, err)
+		return
+	}
+	if _, err = git(ctx, clone, "checkout", "--quiet", "FETCH_HEAD"); err != nil {
+		return
+	}
+	if commit, err = git(ctx, clone, "rev-parse", "HEAD"); err != nil {
+		return
+	}
+	if err = os.RemoveAll(filepath.Join(clone, ".git")); err != nil {
+		return
+	}
+
+	// The template must be a directory of the repository, which symbolic
+	// links may not escape.
+	dir = filepath.Join(clone, filepath.FromSlash(u.Path))
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		err = fmt.Errorf("%w: '%v' is not a directory of repository '%v'", ErrTemplateNotFound, u.Path, u.Repository)
+		return
+	}
+	root, err := filepath.EvalSymlinks(clone)
+	if err != nil {
+		return
+	}
+	if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+		err = fmt.Errorf("%w '%v': the path '%v' is outside of the repository", ErrInvalidTemplateURL, u, u.Path)
+		return
+	}
+	if fi, statErr := os.Stat(resolved); statErr != nil || !fi.IsDir() {
+		err = fmt.Errorf("%w: '%v' is not a directory of repository '%v'", ErrTemplateNotFound, u.Path, u.Repository)
+		return
+	}
+	return resolved, commit, cleanup, nil
+}
@@ -0,0 +1,108 @@
+// +build !integration
+
+package function
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestParseTemplateURL ensures a template URL is parsed into the URL of its
+// repository, the path of the template within it and its ref, and that a path
+// outside of the repository is rejected.
+func TestParseTemplateURL(t *testing.T) {
+	tests := []struct {
+		template string
+		want     TemplateURL
+		err      bool
+	}{
+		{"git://github.com/alice/templates//go/json@v1", TemplateURL{"git://github.com/alice/templates", "go/json", "v1"}, false},
+		{"https://github.com/alice/templates.git//go/json", TemplateURL{"https://github.com/alice/templates.git", "go/json", ""}, false},
+		{"https://github.com/alice/templates@main", TemplateURL{"https://github.com/alice/templates", "", "main"}, false},
+		{"ssh://git@github.com/alice/templates//json/", TemplateURL{"ssh://git@github.com/alice/templates", "json", ""}, false},
+		{"file:///home/alice/templates//go/./json@a278a91", TemplateURL{"file:///home/alice/templates", "go/json", "a278a91"}, false},
+		{"git://github.com/alice/templates//../../etc", TemplateURL{}, true},
+		{"git://github.com/alice/templates//go/../../etc@v1", TemplateURL{}, true},
+		{"git:////go/json", TemplateURL{}, true},
+		{"://github.com/alice/templates", TemplateURL{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			got, err := ParseTemplateURL(tt.template)
+			if tt.err {
+				if !errors.Is(err, ErrInvalidTemplateURL) {
+					t.Fatalf("expected ErrInvalidTemplateURL, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestCreateTemplateURL ensures a Function is created from the directory of
+// a git repository given as a template URL, at its ref, recording the
+// template, ref and commit, and that a directory not of the repository is
+// not found.
+func TestCreateTemplateURL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	ctx := context.Background()
+	tmp, err := ioutil.TempDir("", "func-template-url")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	origin := filepath.Join(tmp, "templates")
+	commitTemplate(t, origin, "go", "json")
+	if _, err = git(ctx, origin, "tag", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	commit, err := git(ctx, origin, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitTemplate(t, origin, "go", "events")
+
+	client := New()
+	url := "file://" + filepath.ToSlash(origin)
+	err = client.Create(Function{Root: filepath.Join(tmp, "events"), Runtime: "go", Template: url + "//go/events@v1"})
+	if !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("expected the template added since v1 not to be found, got %v", err)
+	}
+
+	root := filepath.Join(tmp, "myfunc")
+	if err = client.Create(Function{Root: root, Runtime: "go", Template: url + "//go/json@v1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(root, "handle.go")); err != nil {
+		t.Fatalf("expected the files of the template: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(root, ".git")); !os.IsNotExist(err) {
+		t.Fatalf("expected the git repository of the template not to be copied, got %v", err)
+	}
+	f, err := NewFunction(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Template != url+"//go/json" || f.TemplateRef != "v1" || f.TemplateCommit != commit {
+		t.Fatalf("expected the template, ref and commit to be recorded, got '%v', '%v', '%v'", f.Template, f.TemplateRef, f.TemplateCommit)
+	}
+
+	err = client.Create(Function{Root: filepath.Join(tmp, "other"), Runtime: "go", Template: url + "//go/json@v1", TemplateRef: "main"})
+	if !errors.Is(err, ErrInvalidTemplateURL) {
+		t.Fatalf("expected a ref differing from that of the URL to be rejected, got %v", err)
+	}
+}
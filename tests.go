@@ -0,0 +1,123 @@
+package function
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// Test configures how the tests of a Function are run.
+type Test struct {
+	// Command with which the tests are run in the Function's root, such as
+	// "go test -race ./...", in place of that of its runtime.  Run by the
+	// shell, such that it may be a pipeline or a compound command.
+	Command string `yaml:"command,omitempty"`
+}
+
+// TestCommands are the idiomatic commands with which the tests of Functions
+// of each runtime are run, unless a Function configures its own.
+var TestCommands = map[string]string{
+	"go":         "go test ./...",
+	"node":       "npm test",
+	"typescript": "npm test",
+	"python":     "python -m unittest",
+	"quarkus":    "mvn test",
+	"springboot": "mvn test",
+	"rust":       "cargo test",
+}
+
+// ErrTestsFailed is returned when the tests of a Function fail, or can not be
+// run, with the error with which its test command exited.
+type ErrTestsFailed struct {
+	// Command run.
+	Command string
+	// Err with which the command failed, such as its non-zero exit status.
+	Err error
+}
+
+func (e ErrTestsFailed) Error() string {
+	return fmt.Sprintf("tests failed: '%v': %v", e.Command, e.Err)
+}
+
+func (e ErrTestsFailed) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode of the test command, or 1 if it did not exit of its own accord,
+// such that it may be propagated as the exit code of the process.
+func (e ErrTestsFailed) ExitCode() int {
+	var exit *exec.ExitError
+	if errors.As(e.Err, &exit) && exit.ExitCode() > 0 {
+		return exit.ExitCode()
+	}
+	return 1
+}
+
+// TestCommand returns the command with which the tests of the Function are
+// run: that of its configuration or, failing that, that of its runtime.  The
+// maven wrapper of the project is preferred to mvn, where present.
+func (f Function) TestCommand() (string, error) {
+	if f.Test.Command != "" {
+		return f.Test.Command, nil
+	}
+	command, ok := TestCommands[f.Runtime]
+	if !ok {
+		return "", fmt.Errorf("no test command is known for the runtime '%v'. Please provide one as test.command in %v", f.Runtime, filepath.Base(f.ConfigPath()))
+	}
+	if command == "mvn test" {
+		if _, err := os.Stat(filepath.Join(f.Root, "mvnw")); err == nil && runtime.GOOS != "windows" {
+			command = "./mvnw test"
+		}
+	}
+	return command, nil
+}
+
+// Test runs the tests of the Function at root with its test command (see
+// TestCommand), streaming their output.  The command is provided the name
+// and runtime of the Function in FUNC_NAME and FUNC_RUNTIME.  Should the
+// tests fail, an ErrTestsFailed is returned.
+func (c *Client) Test(ctx context.Context, root string) (err error) {
+	if c.plan != nil {
+		return ErrNotPlanned
+	}
+	f, err := NewFunctionFromFile(root, c.configFile)
+	if err != nil {
+		return
+	}
+	if !f.Initialized() {
+		return fmt.Errorf("the given path '%v' does not contain an initialized Function", root)
+	}
+	command, err := f.TestCommand()
+	if err != nil {
+		return
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Dir = f.Root
+	cmd.Env = append(os.Environ(),
+		"FUNC_NAME="+f.Name,
+		"FUNC_RUNTIME="+f.Runtime)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if c.verbose {
+		fmt.Printf("Running tests: %v\n", command)
+	}
+	if err = cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return ErrTestsFailed{Command: command, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package function
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LatestRevision refers, in a TrafficSplit, to whichever Revision of the
+// Function is the latest.
+const LatestRevision = "@latest"
+
+// TrafficSplit of the traffic of a deployed Function: the percent of it
+// routed to each Revision, referenced by tag, by name or as LatestRevision.
+type TrafficSplit map[string]int64
+
+// TrafficTarget of the traffic of a deployed Function.
+type TrafficTarget struct {
+	// Revision to which the traffic is routed, by name.  Empty when routed to
+	// the latest Revision.
+	Revision string `json:"revision,omitempty" yaml:"revision,omitempty"`
+
+	// Latest is whether the traffic is routed to the latest Revision.
+	Latest bool `json:"latest,omitempty" yaml:"latest,omitempty"`
+
+	// Tag of the target, by which it is reachable at its own URL.
+	Tag string `json:"tag,omitempty" yaml:"tag,omitempty"`
+
+	// Percent of the traffic routed to the target.
+	Percent int64 `json:"percent" yaml:"percent"`
+}
+
+// Revision of a deployed Function.
+type Revision struct {
+	// Name of the Revision, such as "myfunc-00002".
+	Name string `json:"name" yaml:"name"`
+
+	// Image of the Revision.
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+
+	// ImageDigest is the reference by digest of the image of the Revision, as
+	// resolved when it was created, such as
+	// "example.com/alice/myfunc@sha256:a278a9...".
+	ImageDigest string `json:"imageDigest,omitempty" yaml:"imageDigest,omitempty"`
+
+	// Created is the time at which the Revision was created.
+	Created time.Time `json:"created" yaml:"created"`
+
+	// Ready is whether the Revision is ready to serve traffic.  Message is
+	// the reason it is not, if known.
+	Ready   bool   `json:"ready" yaml:"ready"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+
+	// ChangeCause given with the deploy which created the Revision, if any.
+	ChangeCause string `json:"changeCause,omitempty" yaml:"changeCause,omitempty"`
+
+	// Percent of the traffic of the Function routed to the Revision.
+	Percent int64 `json:"percent" yaml:"percent"`
+}
+
+// ParseTrafficSplit parses a split of the form "v1=90,v2=10", where each
+// Revision is referenced by tag, by name or as "@latest", and validates it
+// (see ValidateTrafficSplit).
+func ParseTrafficSplit(s string) (TrafficSplit, error) {
+	split := TrafficSplit{}
+	for _, target := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(target), "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid target '%v' of the traffic split: expected REVISION=PERCENT", target)
+		}
+		ref := strings.TrimSpace(parts[0])
+		percent, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(parts[1]), "%"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid percent of the traffic split for '%v': %w", ref, err)
+		}
+		if _, ok := split[ref]; ok {
+			return nil, fmt.Errorf("revision '%v' is repeated in the traffic split", ref)
+		}
+		split[ref] = percent
+	}
+	return split, ValidateTrafficSplit(split)
+}
+
+// ValidateTrafficSplit ensures each Revision of the split is referenced and
+// routed between 0 and 100 percent of the traffic, totalling 100.
+func ValidateTrafficSplit(split TrafficSplit) error {
+	var total int64
+	for _, ref := range split.Revisions() {
+		percent := split[ref]
+		if ref == "" {
+			return fmt.Errorf("a revision of the traffic split is empty: expected a tag, a name or '%v'", LatestRevision)
+		}
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("the percent of the traffic split for '%v' must be between 0 and 100, got %v", ref, percent)
+		}
+		total += percent
+	}
+	if total != 100 {
+		return fmt.Errorf("the percents of the traffic split must total 100, got %v", total)
+	}
+	return nil
+}
+
+// Revisions referenced by the split, in order.
+func (s TrafficSplit) Revisions() []string {
+	refs := make([]string, 0, len(s))
+	for ref := range s {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
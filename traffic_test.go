@@ -0,0 +1,41 @@
+// +build !integration
+
+package function
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ParseTrafficSplit(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		split   string
+		want    TrafficSplit
+		wantErr bool
+	}{
+		{"split", "v1=90,v2=10", TrafficSplit{"v1": 90, "v2": 10}, false},
+		{"latest", "v1=50%, @latest=50%", TrafficSplit{"v1": 50, LatestRevision: 50}, false},
+		{"all", "@latest=100", TrafficSplit{LatestRevision: 100}, false},
+		{"under 100", "v1=90,v2=5", nil, true},
+		{"over 100", "v1=110,v2=-10", nil, true},
+		{"repeated", "v1=50,v1=50", nil, true},
+		{"no percent", "v1", nil, true},
+		{"no revision", "=100", nil, true},
+		{"not a number", "v1=all", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTrafficSplit(tt.split)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTrafficSplit() = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTrafficSplit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+}
@@ -2,6 +2,9 @@ package utils
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -30,6 +33,23 @@ func ValidateFunctionName(name string) error {
 	return nil
 }
 
+// invalidNameChars matches runs of the characters which may not be of a
+// function name.
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// SanitizeFunctionName returns a valid function name derived from the given
+// name, such as that of a directory: lowercased, with each run of characters
+// which are not alphanumeric replaced by '-', and trimmed of '-' and to at most
+// 63 characters.  A name of no alphanumeric characters is sanitized to "".
+func SanitizeFunctionName(name string) string {
+	name = invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name, "-")
+	if len(name) > validation.DNS1123LabelMaxLength {
+		name = strings.TrimRight(name[:validation.DNS1123LabelMaxLength], "-")
+	}
+	return name
+}
+
 // ValidateEnvVarName validatest that the input name is a valid Kubernetes Environmet Variable name.
 // It must  must consist of alphabetic characters, digits, '_', '-', or '.', and must not start with a digit
 // (e.g. 'my.env-name',  or 'MY_ENV.NAME',  or 'MyEnvName1', regex used for validation is '[-._a-zA-Z][-._a-zA-Z0-9]*'))
@@ -40,3 +60,43 @@ func ValidateEnvVarName(name string) error {
 
 	return nil
 }
+
+// ErrInvalidRegistry indicates the registry did not pass registry validation.
+type ErrInvalidRegistry error
+
+// registryNamespace is the form of a registry namespace (path component),
+// per the docker reference grammar.
+var registryNamespace = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*$`)
+
+// ValidateRegistry validates that the input is a valid registry prefix for
+// Function images, of the form 'namespace' or 'registry/namespace', where
+// namespace may have any number of path components (e.g. 'alice',
+// 'ghcr.io/alice' or 'ghcr.io/org/team'), and registry is a host name with an
+// optional port (e.g. 'localhost:5000').
+func ValidateRegistry(registry string) error {
+	tokens := strings.Split(strings.Trim(registry, "/"), "/")
+	namespaces := tokens
+	if len(tokens) > 1 {
+		namespaces = tokens[1:]
+	}
+	for _, namespace := range namespaces {
+		if !registryNamespace.MatchString(namespace) {
+			return ErrInvalidRegistry(fmt.Errorf("Registry namespace '%v' must consist of lower case alphanumeric characters, optionally separated by '.', '_' or '-'", namespace))
+		}
+	}
+
+	if len(tokens) > 1 {
+		host := tokens[0]
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			if port, err := strconv.Atoi(host[i+1:]); err != nil || port < 1 || port > 65535 {
+				return ErrInvalidRegistry(fmt.Errorf("Registry host '%v' has an invalid port", host))
+			}
+			host = host[:i]
+		}
+		if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+			return ErrInvalidRegistry(fmt.Errorf("Registry host '%v' is not a valid host name", tokens[0]))
+		}
+	}
+
+	return nil
+}
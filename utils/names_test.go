@@ -2,7 +2,10 @@
 
 package utils
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 // TestValidateFunctionName tests that only correct function names are accepted
 func TestValidateFunctionName(t *testing.T) {
@@ -62,3 +65,65 @@ func TestValidateEnvVarName(t *testing.T) {
 		}
 	}
 }
+
+// TestValidateRegistry tests that only correct registry prefixes are accepted
+func TestValidateRegistry(t *testing.T) {
+	cases := []struct {
+		In    string
+		Valid bool
+	}{
+		{"", false},
+		{"alice", true},
+		{"ghcr.io/alice", true},
+		{"quay.io/alice/", true},
+		{"localhost:5000/alice", true},
+		{"my-registry.example.com/team.a", true},
+		{"Alice", false},
+		{"ghcr.io/org/team", true},
+		{"registry.example.com/org/team/project", true},
+		{"ghcr.io/org//team", false},
+		{"ghcr.io/org/Team", false},
+		{"quay.io/-alice", false},
+		{"quay..io/alice", false},
+		{"localhost:port/alice", false},
+	}
+
+	for _, c := range cases {
+		err := ValidateRegistry(c.In)
+		if err != nil && c.Valid {
+			t.Fatalf("Unexpected error: %v, for '%v'", err, c.In)
+		}
+		if err == nil && !c.Valid {
+			t.Fatalf("Expected error for invalid entry: %v", c.In)
+		}
+	}
+}
+
+func TestSanitizeFunctionName(t *testing.T) {
+	cases := []struct {
+		In  string
+		Out string
+	}{
+		{"example", "example"},
+		{"MyFunc", "myfunc"},
+		{"my_func", "my-func"},
+		{"My Func (copy)", "my-func-copy"},
+		{"__init__", "init"},
+		{"example.com", "example-com"},
+		{"café-2", "caf-2"},
+		{"---", ""},
+		{strings.Repeat("a", 62) + "_b", strings.Repeat("a", 62)},
+	}
+
+	for _, c := range cases {
+		out := SanitizeFunctionName(c.In)
+		if out != c.Out {
+			t.Fatalf("expected '%v' to be sanitized to '%v', got '%v'", c.In, c.Out, out)
+		}
+		if out != "" {
+			if err := ValidateFunctionName(out); err != nil {
+				t.Fatalf("expected the sanitized name '%v' to be valid: %v", out, err)
+			}
+		}
+	}
+}
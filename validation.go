@@ -0,0 +1,123 @@
+package function
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/boson-project/func/utils"
+)
+
+// FieldError is a problem with the value of a field of a Function.
+type FieldError struct {
+	// Field of the Function, by its path in its config file, such as "envs"
+	// or "environments.prod.domain".  Empty for problems of the config file
+	// as a whole, such as its syntax.
+	Field string `json:"field,omitempty" yaml:"field,omitempty"`
+
+	// Message describing the problem.
+	Message string `json:"message" yaml:"message"`
+}
+
+func (e FieldError) String() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned when a Function, or its config file, is not
+// valid, with each of its problems, such that all may be reported at once.
+type ValidationError struct {
+	// File of the config which is not valid, if loaded from one.
+	File string `json:"file,omitempty" yaml:"file,omitempty"`
+
+	// Errors of the Function, in the order of its fields.
+	Errors []FieldError `json:"errors" yaml:"errors"`
+}
+
+// Error lists the problems as bullets.
+func (e ValidationError) Error() string {
+	lines := []string{"function is not valid:"}
+	if e.File != "" {
+		lines[0] = fmt.Sprintf("'%v' config file is not valid:", e.File)
+	}
+	for _, fe := range e.Errors {
+		lines = append(lines, "  - "+fe.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate the Function, returning a ValidationError with all of its
+// problems, if any: those of its name, and those of the fields validated when
+// its config file is loaded.
+func (f Function) Validate() error {
+	var errs []FieldError
+	if err := utils.ValidateFunctionName(f.Name); err != nil {
+		errs = append(errs, FieldError{Field: "name", Message: err.Error()})
+	}
+	errs = append(errs, f.validate()...)
+	if len(errs) > 0 {
+		return ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// validate the fields of the Function which are validated when its config
+// file is loaded, returning the problems of each.
+func (f Function) validate() (errs []FieldError) {
+	add := func(field string, messages ...string) {
+		for _, m := range messages {
+			errs = append(errs, FieldError{Field: field, Message: m})
+		}
+	}
+	invalid := func(field, value string, err error) {
+		if err != nil {
+			add(field, fmt.Sprintf("invalid value %q; %v", value, err))
+		}
+	}
+
+	add("volumes", validateVolumes(f.Volumes)...)
+	add("envs", ValidateEnvs(f.Envs)...)
+	add("buildEnvs", ValidateBuildEnvs(f.BuildEnvs)...)
+	invalid("platform", f.Platform, ValidatePlatform(f.Platform))
+	invalid("builderDigest", f.BuilderDigest, ValidateDigest(f.BuilderDigest))
+	invalid("builderPullPolicy", f.BuilderPullPolicy, ValidateBuilderPullPolicy(f.BuilderPullPolicy))
+	invalid("platformAPI", f.PlatformAPI, ValidatePlatformAPI(f.PlatformAPI))
+	for _, b := range f.Build.Buildpacks {
+		invalid("build.buildpacks", b, ValidateBuildpack(b))
+	}
+	add("options", validateOptions(f.Options)...)
+	add("health", validateHealth(f.Health)...)
+	invalid("domain", f.Domain, ValidateDomain(f.Domain))
+	invalid("revisionName", f.RevisionName, ValidateRevisionName(f.RevisionName))
+	invalid("trafficTag", f.TrafficTag, ValidateTrafficTag(f.TrafficTag))
+	invalid("next", f.Next, ValidateNext(f.Name, f.Next))
+	for _, d := range f.DependsOn {
+		invalid("dependsOn", d, ValidateDependency(f.Name, d))
+	}
+	invalid("imageSuffix", f.ImageSuffix, ValidateImageSuffix(f.ImageSuffix))
+	invalid("imagePullPolicy", f.ImagePullPolicy, ValidateImagePullPolicy(f.ImagePullPolicy))
+	invalid("mesh", f.Mesh, ValidateMesh(f.Mesh))
+	invalid("port", strconv.Itoa(f.Port), ValidatePort(f.Port))
+	add("initContainers", ValidateInitContainers(f.InitContainers)...)
+	invalid("metrics", fmt.Sprintf(":%d%v", f.Metrics.Port, f.Metrics.Path), ValidateMetrics(f.Metrics))
+	invalid("tracing", f.Tracing.Endpoint, ValidateTracing(f.Tracing))
+	invalid("invocation.signature", f.Invocation.Signature, ValidateInvocation(f.Runtime, f.Invocation))
+	prefixes := make([]string, 0, len(f.RegistryMirrors))
+	for prefix := range f.RegistryMirrors {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		invalid("registryMirrors", prefix+"="+f.RegistryMirrors[prefix], ValidateRegistryMirror(prefix, f.RegistryMirrors[prefix]))
+	}
+	invalid("ingressClass", f.IngressClass, ValidateIngressClass(f.IngressClass))
+	invalid("runtimeVersion", f.RuntimeVersion, ValidateRuntimeVersion(f.RuntimeVersion))
+	invalid("ci", f.CI, ValidateCI(f.CI))
+	invalid("license", f.License, ValidateLicense(f.License))
+	invalid("packageManager", f.PackageManager, ValidatePackageManager(f.Runtime, f.PackageManager))
+	errs = append(errs, validateEnvironments(f.Environments)...)
+	return
+}
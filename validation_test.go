@@ -0,0 +1,82 @@
+// +build !integration
+
+package function
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestFunction_Validate ensures that all of the problems of a Function are
+// returned together, each with the path of its field.
+func TestFunction_Validate(t *testing.T) {
+	if err := (Function{Name: "myfunc"}).Validate(); err != nil {
+		t.Fatalf("expected a valid Function, got %v", err)
+	}
+
+	value := "{{ secret: }}"
+	f := Function{
+		Name:            "My_Func",
+		Envs:            Envs{{Value: &value}},
+		Domain:          "not a domain",
+		ImagePullPolicy: "Sometimes",
+		Environments:    map[string]Environment{"prod": {ImagePullPolicy: "Sometimes"}},
+	}
+	var verr ValidationError
+	if err := f.Validate(); !errors.As(err, &verr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	var fields []string
+	for _, e := range verr.Errors {
+		fields = append(fields, e.Field)
+	}
+	expected := []string{"name", "envs", "domain", "imagePullPolicy", "environments.prod.imagePullPolicy"}
+	if len(fields) != len(expected) {
+		t.Fatalf("expected errors of the fields %v, got %v", expected, fields)
+	}
+	for i := range expected {
+		if fields[i] != expected[i] {
+			t.Fatalf("expected errors of the fields %v, got %v", expected, fields)
+		}
+	}
+}
+
+// Test_parseConfig ensures that the problems of a config file, both of its
+// syntax and of its fields, are returned together as a ValidationError, which
+// is listed as bullets and represented as JSON by field.
+func Test_parseConfig(t *testing.T) {
+	_, err := parseConfig("/path/to/func.yaml", []byte("name: myfunc\nunknown: true\ndomain: not a domain\n"))
+	var verr ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	if verr.File != "func.yaml" || len(verr.Errors) != 2 {
+		t.Fatalf("expected 2 errors of func.yaml, got %+v", verr)
+	}
+	expected := "'func.yaml' config file is not valid:\n" +
+		"  - line 2: field unknown is not valid\n" +
+		"  - domain: invalid value \"not a domain\"; "
+	if !strings.HasPrefix(err.Error(), expected) {
+		t.Fatalf("expected the error to begin:\n%v\ngot:\n%v", expected, err)
+	}
+
+	bb, err := json.Marshal(verr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		File   string `json:"file"`
+		Errors []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err = json.Unmarshal(bb, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.File != "func.yaml" || decoded.Errors[0].Field != "" || decoded.Errors[1].Field != "domain" {
+		t.Fatalf("unexpected JSON representation: %s", bb)
+	}
+}
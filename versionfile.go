@@ -0,0 +1,56 @@
+package function
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// versionFile of a version manager, by which the version of a runtime used in
+// local development is selected: its name, the format of its content of a
+// version, and the versions it accepts.
+type versionFile struct {
+	name     string
+	format   string
+	versions *regexp.Regexp
+}
+
+// exactVersion is a version of all of its major, minor and patch numbers, as
+// asdf requires.
+var exactVersion = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+
+// runtimeVersionFiles are the files of the version managers of the runtimes
+// of which a version may be selected for the build: nvm for Node, and asdf
+// for Go and Python.  Their content is of the same version as is passed to
+// the buildpack of the runtime, such as with BP_NODE_VERSION, where the
+// version manager accepts it: nvm any version, and asdf only exact versions.
+// The JVM runtimes have none, as the versions of SDKMAN! are identifiers of
+// a distribution, such as 17.0.2-tem, rather than the major version of the
+// buildpack.
+var runtimeVersionFiles = map[string]versionFile{
+	"go":         {".tool-versions", "golang %v\n", exactVersion},
+	"node":       {".nvmrc", "%v\n", nil},
+	"python":     {".tool-versions", "python %v\n", exactVersion},
+	"typescript": {".nvmrc", "%v\n", nil},
+}
+
+// writeRuntimeVersionFile writes the version manager file of the runtime, of
+// the version, to root, returning whether it was written: it is not when the
+// runtime has none, when its version manager does not accept the version, or
+// when the file already exists.
+func writeRuntimeVersionFile(root, runtime, version string) (written bool, err error) {
+	file, ok := runtimeVersionFiles[runtime]
+	if !ok {
+		return false, nil
+	}
+	if file.versions != nil && !file.versions.MatchString(version) {
+		return false, nil
+	}
+	path := filepath.Join(root, file.name)
+	if _, err = os.Stat(path); err == nil {
+		return false, nil
+	}
+	return true, ioutil.WriteFile(path, []byte(fmt.Sprintf(file.format, version)), 0644)
+}
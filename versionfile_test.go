@@ -0,0 +1,98 @@
+// +build !integration
+
+package function_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fn "github.com/boson-project/func"
+)
+
+// TestCreateRuntimeVersionFile ensures the version manager file of each
+// runtime is written of the version of the runtime when requested, and not
+// when its version manager does not accept the version or the runtime has
+// none.
+func TestCreateRuntimeVersionFile(t *testing.T) {
+	tests := []struct {
+		runtime string
+		version string
+		file    string
+		content string
+	}{
+		{"go", "1.19.5", ".tool-versions", "golang 1.19.5\n"},
+		{"go", "1.19", ".tool-versions", ""},
+		{"node", "18", ".nvmrc", "18\n"},
+		{"python", "3.10.9", ".tool-versions", "python 3.10.9\n"},
+		{"python", "3.10", ".tool-versions", ""},
+		{"quarkus", "17", ".sdkmanrc", ""},
+		{"springboot", "11", ".sdkmanrc", ""},
+		{"typescript", "16", ".nvmrc", "16\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.runtime+"@"+tt.version, func(t *testing.T) {
+			root := "testdata/example.com/testCreateRuntimeVersionFile-" + tt.runtime
+			defer using(t, root)()
+
+			client := fn.New(fn.WithRegistry(TestRegistry), fn.WithRuntimeVersionFile(true))
+			if err := client.Create(fn.Function{Root: root, Runtime: tt.runtime, RuntimeVersion: tt.version}); err != nil {
+				t.Fatal(err)
+			}
+			content, err := ioutil.ReadFile(filepath.Join(root, tt.file))
+			if tt.content == "" {
+				if !os.IsNotExist(err) {
+					t.Fatalf("expected no %v to be written, got %q (%v)", tt.file, content, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.content {
+				t.Fatalf("expected the %v %q, got %q", tt.file, tt.content, content)
+			}
+		})
+	}
+}
+
+// TestCreateRuntimeVersionFileExisting ensures an existing version manager
+// file is kept, and that none is written unless requested or without a
+// runtime version.
+func TestCreateRuntimeVersionFileExisting(t *testing.T) {
+	root := "testdata/example.com/testCreateRuntimeVersionFileExisting"
+	defer using(t, root)()
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, ".nvmrc"), []byte("lts/*\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client := fn.New(fn.WithRegistry(TestRegistry), fn.WithForce(true), fn.WithRuntimeVersionFile(true))
+	if err := client.Create(fn.Function{Root: root, Runtime: "node", RuntimeVersion: "18"}); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := ioutil.ReadFile(filepath.Join(root, ".nvmrc")); err != nil || string(content) != "lts/*\n" {
+		t.Fatalf("expected the existing .nvmrc to be kept, got %q (%v)", content, err)
+	}
+
+	for name, client := range map[string]*fn.Client{
+		"NotRequested": fn.New(fn.WithRegistry(TestRegistry)),
+		"NoVersion":    fn.New(fn.WithRegistry(TestRegistry), fn.WithRuntimeVersionFile(true)),
+	} {
+		other := "testdata/example.com/testCreateRuntimeVersionFile" + name
+		defer using(t, other)()
+		f := fn.Function{Root: other, Runtime: "node"}
+		if name == "NotRequested" {
+			f.RuntimeVersion = "18"
+		}
+		if err := client.Create(f); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(other, ".nvmrc")); !os.IsNotExist(err) {
+			t.Fatalf("expected no .nvmrc to be written when %v", name)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package function
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is the period for which the source of a Function must
+// be unchanged before its changes are reported when watched, such that the
+// files written together, such as by an editor or a git checkout, are
+// reported together.
+const DefaultWatchDebounce = 500 * time.Millisecond
+
+// Watch the source of the Function for changes until the context is done,
+// calling changed with the paths changed, relative to its root and slash
+// separated, once it has been unchanged for the debounce period.  The files
+// excluded by its IgnorePatterns are not watched, nor are .git, RunDataDir
+// and config files, which are written when the Function is built and run.
+// Directories created are watched as they are.  Changes made while changed
+// is called are reported once it returns.
+func Watch(ctx context.Context, f Function, debounce time.Duration, changed func(paths []string)) error {
+	patterns, err := f.IgnorePatterns()
+	if err != nil {
+		return err
+	}
+	ignorer := NewIgnorer(patterns)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err = watchDirs(watcher, f.Root, f.Root, ignorer); err != nil {
+		return err
+	}
+
+	pending := make(map[string]bool)
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-watcher.Errors:
+			return err
+		case event := <-watcher.Events:
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			rel, err := filepath.Rel(f.Root, event.Name)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if watchIgnored(ignorer, rel) {
+				continue
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+					if watchIgnored(ignorer, rel+"/") {
+						continue
+					}
+					if err = watchDirs(watcher, f.Root, event.Name, ignorer); err != nil {
+						return err
+					}
+				}
+			}
+			pending[rel] = true
+			settled = time.After(debounce)
+		case <-settled:
+			paths := make([]string, 0, len(pending))
+			for p := range pending {
+				paths = append(paths, p)
+			}
+			sort.Strings(paths)
+			pending, settled = make(map[string]bool), nil
+			changed(paths)
+		}
+	}
+}
+
+// watchDirs adds dir, and the directories beneath it which are not ignored,
+// to the watcher of the Function at root.
+func watchDirs(watcher *fsnotify.Watcher, root, dir string, ignorer Ignorer) error {
+	return filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			// A directory removed since being listed is no longer of interest.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !fi.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); rel != "." && watchIgnored(ignorer, rel+"/") {
+			return filepath.SkipDir
+		}
+		return watcher.Add(p)
+	})
+}
+
+// watchIgnored returns whether changes to the path, relative to the root of
+// a Function and slash separated, are ignored when watching it.
+func watchIgnored(ignorer Ignorer, rel string) bool {
+	top := strings.SplitN(rel, "/", 2)[0]
+	if top == ".git" || top == RunDataDir {
+		return true
+	}
+	base := path.Base(strings.TrimSuffix(rel, "/"))
+	if base == ConfigFile || strings.HasSuffix(base, "."+ConfigFile) {
+		return true
+	}
+	return ignorer.Ignored(rel)
+}
@@ -0,0 +1,93 @@
+// +build !integration
+
+package function
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestWatch ensures the changes to the source of a Function are reported
+// together once settled, other than those of the files ignored, its config
+// file and its run data, including those of directories created while
+// watching.
+func TestWatch(t *testing.T) {
+	root, err := ioutil.TempDir("", "func-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	for name, content := range map[string]string{
+		IgnoreFile:          "build/\n",
+		ConfigFile:          "name: myfunc\nruntime: node\n",
+		"index.js":          "",
+		"build/index.js":    "",
+		"lib/handle.js":     "",
+		".func/placeholder": "",
+	} {
+		if err = os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan []string, 2)
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, Function{Root: root, Runtime: "node"}, 100*time.Millisecond, func(paths []string) {
+			changes <- paths
+		})
+	}()
+	// Allow the watcher to be started
+	time.Sleep(200 * time.Millisecond)
+
+	for _, name := range []string{ConfigFile, "index.js", "build/index.js", "lib/handle.js", ".func/instance.json"} {
+		if err = ioutil.WriteFile(filepath.Join(root, name), []byte("changed"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case paths := <-changes:
+		if expected := []string{"index.js", "lib/handle.js"}; !reflect.DeepEqual(paths, expected) {
+			t.Fatalf("expected the changes %v, got %v", expected, paths)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the changes to be reported")
+	}
+
+	if err = os.Mkdir(filepath.Join(root, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case paths := <-changes:
+		if expected := []string{"src"}; !reflect.DeepEqual(paths, expected) {
+			t.Fatalf("expected the directory created to be reported, got %v", paths)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the directory created to be reported")
+	}
+	if err = ioutil.WriteFile(filepath.Join(root, "src", "util.js"), []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case paths := <-changes:
+		if expected := []string{"src/util.js"}; !reflect.DeepEqual(paths, expected) {
+			t.Fatalf("expected the file of the directory created to be reported, got %v", paths)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the file of the directory created to be reported")
+	}
+
+	cancel()
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+}